@@ -6,9 +6,13 @@ package analytics
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
 )
 
 const AnalyticsDefaultPort = 8081
@@ -40,21 +44,27 @@ func NewAnalyticsClient(server string, port int) *AnalyticsClient {
 	return client
 }
 
+// baseURL returns the URL of the analytics API; the server may be an IPv6
+// literal, bracketed or not.
+func (client *AnalyticsClient) baseURL() string {
+	host := strings.TrimSuffix(strings.TrimPrefix(client.server, "["), "]")
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(client.port))
+}
+
 func (client *AnalyticsClient) VirtualRouterList() ([]string, error) {
 	type Reference struct {
 		Href string
 		Name string
 	}
-	url := fmt.Sprintf("http://%s:%d/%ss",
-		client.server, client.port, AnalyticsVRouter)
+	url := fmt.Sprintf("%s/%ss", client.baseURL(), AnalyticsVRouter)
 	resp, err := client.httpClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(resp.Status)
+		return nil, errors.New(resp.Status)
 	}
-	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -77,16 +87,16 @@ func (client *AnalyticsClient) VirtualRouterList() ([]string, error) {
 func (client *AnalyticsClient) VirtualRouterStatus(name string) (
 	string, error) {
 
-	url := fmt.Sprintf("http://%s:%d/%s/%s?cfilt=NodeStatus",
-		client.server, client.port, AnalyticsVRouter, name)
+	url := fmt.Sprintf("%s/%s/%s?cfilt=NodeStatus",
+		client.baseURL(), AnalyticsVRouter, name)
 	resp, err := client.httpClient.Get(url)
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf(resp.Status)
+		return "", errors.New(resp.Status)
 	}
-	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
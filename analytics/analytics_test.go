@@ -0,0 +1,128 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) (
+	*httptest.Server, *AnalyticsClient) {
+	server := httptest.NewServer(handler)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	return server, NewAnalyticsClient(host, portNum)
+}
+
+func TestVirtualNetworkUVE(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analytics/uves/virtual-network/default-domain:p1:n1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"UveVirtualNetworkAgent": {"in_tpkts": 10, "virtualmachine_list": ["vm1"]}}`)
+	})
+	defer server.Close()
+
+	vn, err := client.VirtualNetworkUVE("default-domain:p1:n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vn.Agent == nil || vn.Agent.InTpkts != 10 {
+		t.Errorf("unexpected agent data: %+v", vn.Agent)
+	}
+	if vn.Config != nil {
+		t.Errorf("unexpected config data: %+v", vn.Config)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var request Query
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"value": [{"sourcevn": "a", "sum(bytes)": 12345678901234}]}`)
+	})
+	defer server.Close()
+
+	query := NewFlowSeriesQuery(true).
+		Select("sourcevn", "sum(bytes)").
+		Match(Equal("sourcevn", "a"))
+	result, err := client.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Table != FlowSeriesTable || request.Direction == nil || *request.Direction != 1 {
+		t.Errorf("unexpected request: %+v", request)
+	}
+	if len(request.Where) != 1 || request.Where[0][0].Op != OpEqual {
+		t.Errorf("unexpected where clause: %+v", request.Where)
+	}
+	if len(result.Rows) != 1 || result.Rows[0]["sum(bytes)"].(json.Number).String() != "12345678901234" {
+		t.Errorf("unexpected result: %+v", result.Rows)
+	}
+}
+
+func TestUVEGetEscape(t *testing.T) {
+	var path string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		fmt.Fprint(w, `{"UveVirtualNetworkConfig": {"total_acl_rules": 2}}`)
+	})
+	defer server.Close()
+
+	if _, err := client.UVEGet(UVETypeVirtualNetwork, "default-domain:p1:a/b c#1"); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/analytics/uves/virtual-network/default-domain:p1:a%2Fb%20c%231" {
+		t.Errorf("unexpected path %s", path)
+	}
+}
+
+func TestStatsQuery(t *testing.T) {
+	var request Query
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"value": [{"T=60": 1500000000000000, "SUM(if_stats.in_bytes)": 9007199254740993}]}`)
+	})
+	defer server.Close()
+
+	query := NewStatsQuery("VirtualMachineStats", "if_stats").
+		Granularity(time.Minute).
+		Sum("in_bytes").
+		MatchAttr("vm_name", "vm1")
+	query.Since(time.Hour)
+	result, err := client.Query(query.Query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Table != "StatTable.VirtualMachineStats.if_stats" || request.StartTime != "now-3600s" {
+		t.Errorf("unexpected request: %+v", request)
+	}
+	if fmt.Sprint(request.SelectFields) != "[T=60 SUM(if_stats.in_bytes)]" {
+		t.Errorf("unexpected select fields: %v", request.SelectFields)
+	}
+	if len(request.Where) != 1 || request.Where[0][0].Name != "if_stats.vm_name" {
+		t.Errorf("unexpected where clause: %+v", request.Where)
+	}
+	if len(result.Rows) != 1 || result.Rows[0]["SUM(if_stats.in_bytes)"].(json.Number).String() != "9007199254740993" {
+		t.Errorf("unexpected result: %+v", result.Rows)
+	}
+}
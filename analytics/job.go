@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ObjectJobExecutionTable holds the log messages of fabric jobs.
+const ObjectJobExecutionTable = "ObjectJobExecutionTable"
+
+// Job status values reported by the job manager.
+const (
+	JobStarting   = "STARTING"
+	JobInProgress = "IN_PROGRESS"
+	JobSuccess    = "SUCCESS"
+	JobFailure    = "FAILURE"
+	JobWarning    = "WARNING"
+)
+
+// JobLogEntry is a job progress message.
+// defined in src/config/fabric-ansible/job_manager/sandesh/job.sandesh
+type JobLogEntry struct {
+	Name        string `xml:"name"`
+	ExecutionID string `xml:"execution_id"`
+	Timestamp   int64  `xml:"timestamp"`
+	Message     string `xml:"message"`
+	Status      string `xml:"status"`
+	Result      string `xml:"result"`
+}
+
+// Done returns true if the entry reports the completion of the job.
+func (e *JobLogEntry) Done() bool {
+	return e.Status == JobSuccess || e.Status == JobFailure ||
+		e.Status == JobWarning
+}
+
+// DecodeResult unmarshals the JSON encoded job output.
+func (e *JobLogEntry) DecodeResult(v interface{}) error {
+	if e.Result == "" {
+		return fmt.Errorf("job %s: no result", e.ExecutionID)
+	}
+	return json.Unmarshal([]byte(e.Result), v)
+}
+
+// parseJobLog extracts the entry of a JobLog object log message.
+func parseJobLog(objectLog string) (*JobLogEntry, error) {
+	var msg struct {
+		Entry JobLogEntry `xml:"log_entry>JobLogEntry"`
+	}
+	if err := xml.Unmarshal([]byte(objectLog), &msg); err != nil {
+		return nil, err
+	}
+	return &msg.Entry, nil
+}
+
+// JobLog retrieves the log entries of a job, oldest first. templateFQName
+// is the fq_name of the job template used to start it.
+func (client *AnalyticsClient) JobLog(templateFQName []string,
+	executionID string, since time.Duration) ([]JobLogEntry, error) {
+	objectID := strings.Join(templateFQName, ":") + ":" + executionID
+	query := NewQuery(ObjectJobExecutionTable).
+		Select("MessageTS", "Messagetype", "ObjectLog").
+		Since(since).
+		Match(Equal("ObjectId", objectID))
+	result, err := client.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JobLogEntry
+	for _, row := range result.Rows {
+		if row["Messagetype"] != "JobLog" {
+			continue
+		}
+		objectLog, ok := row["ObjectLog"].(string)
+		if !ok {
+			continue
+		}
+		entry, err := parseJobLog(objectLog)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp < entries[j].Timestamp
+	})
+	return entries, nil
+}
+
+// WaitForJob polls the job log until the job completes or ctx is done.
+// progress, when not nil, is invoked once for each new log entry. The
+// final entry is returned; a job that completes with a FAILURE status is
+// reported as an error.
+func (client *AnalyticsClient) WaitForJob(ctx context.Context,
+	templateFQName []string, executionID string, interval time.Duration,
+	progress func(*JobLogEntry)) (*JobLogEntry, error) {
+	start := time.Now()
+	seen := 0
+	for {
+		// Query a window that covers the whole job execution.
+		since := time.Since(start) + 10*time.Minute
+		entries, err := client.JobLog(templateFQName, executionID, since)
+		if err != nil {
+			return nil, err
+		}
+		for ; seen < len(entries); seen++ {
+			entry := &entries[seen]
+			if progress != nil {
+				progress(entry)
+			}
+			if entry.Done() {
+				if entry.Status == JobFailure {
+					return entry, fmt.Errorf("job %s failed: %s",
+						executionID, entry.Message)
+				}
+				return entry, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
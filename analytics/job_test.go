@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const jobLogFormat = `<JobLog type="sandesh"><log_entry type="struct"><JobLogEntry><name type="string" identifier="1">image_upgrade_template</name><execution_id type="string" identifier="2">1545234</execution_id><timestamp type="u64" identifier="3">%d</timestamp><message type="string" identifier="4">%s</message><status type="string" identifier="5">%s</status><result type="string" identifier="6">%s</result></JobLogEntry></log_entry></JobLog>`
+
+func TestWaitForJob(t *testing.T) {
+	statuses := []string{JobStarting, JobInProgress, JobSuccess}
+	polls := 0
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var query Query
+		json.NewDecoder(r.Body).Decode(&query)
+		if query.Table != ObjectJobExecutionTable ||
+			query.Where[0][0].Value != "default-global-system-config:image_upgrade_template:1545234" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		polls++
+		var rows []map[string]interface{}
+		for i := 0; i < polls && i < len(statuses); i++ {
+			result := ""
+			if statuses[i] == JobSuccess {
+				result = `{"upgraded": 2}`
+			}
+			rows = append(rows, map[string]interface{}{
+				"MessageTS":   i,
+				"Messagetype": "JobLog",
+				"ObjectLog":   fmt.Sprintf(jobLogFormat, i, "step", statuses[i], result),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"value": rows})
+	})
+	defer server.Close()
+
+	var seen []string
+	entry, err := client.WaitForJob(context.Background(),
+		[]string{"default-global-system-config", "image_upgrade_template"}, "1545234",
+		time.Millisecond, func(entry *JobLogEntry) {
+			seen = append(seen, entry.Status)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 || seen[2] != JobSuccess {
+		t.Errorf("unexpected progress %v", seen)
+	}
+	var output struct {
+		Upgraded int `json:"upgraded"`
+	}
+	if err := entry.DecodeResult(&output); err != nil || output.Upgraded != 2 {
+		t.Errorf("unexpected result %q: %v", entry.Result, err)
+	}
+}
@@ -0,0 +1,273 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Analytics tables commonly used with the query API.
+const (
+	FlowSeriesTable = "FlowSeriesTable"
+	FlowRecordTable = "FlowRecordTable"
+	MessageTable    = "MessageTable"
+	ObjectVNTable   = "ObjectVNTable"
+)
+
+// StatTable returns the name of the statistics table for a given sandesh
+// structure and attribute (e.g. StatTable("VirtualMachineStats", "if_stats")).
+func StatTable(name, attribute string) string {
+	return fmt.Sprintf("StatTable.%s.%s", name, attribute)
+}
+
+// MatchOp is the comparison operator of a where clause term.
+// defined in src/query_engine/qe.sandesh
+type MatchOp int
+
+const (
+	OpEqual      MatchOp = 1
+	OpNotEqual   MatchOp = 2
+	OpInRange    MatchOp = 3
+	OpNotInRange MatchOp = 4
+	OpLEQ        MatchOp = 5
+	OpGEQ        MatchOp = 6
+	OpPrefix     MatchOp = 7
+	OpRegexMatch MatchOp = 8
+	OpContains   MatchOp = 9
+)
+
+// MatchTerm is an element of a where clause.
+type MatchTerm struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Value2 interface{} `json:"value2,omitempty"`
+	Op     MatchOp     `json:"op"`
+}
+
+// Query is a request to the analytics query engine. Use NewQuery to build it.
+type Query struct {
+	Table        string        `json:"table"`
+	StartTime    interface{}   `json:"start_time"`
+	EndTime      interface{}   `json:"end_time"`
+	SelectFields []string      `json:"select_fields"`
+	Where        [][]MatchTerm `json:"where,omitempty"`
+	Filter       [][]MatchTerm `json:"filter,omitempty"`
+	SortFields   []string      `json:"sort_fields,omitempty"`
+	Sort         int           `json:"sort,omitempty"`
+	Limit        int           `json:"limit,omitempty"`
+	Direction    *int          `json:"dir,omitempty"`
+}
+
+// NewQuery starts building a query on the specified table. The default
+// time range is the last 10 minutes.
+func NewQuery(table string) *Query {
+	return &Query{
+		Table:     table,
+		StartTime: "now-10m",
+		EndTime:   "now",
+	}
+}
+
+// NewFlowSeriesQuery builds a query on the FlowSeriesTable. Flow queries
+// require a traffic direction: ingress (1) or egress (0).
+func NewFlowSeriesQuery(ingress bool) *Query {
+	dir := 0
+	if ingress {
+		dir = 1
+	}
+	query := NewQuery(FlowSeriesTable)
+	query.Direction = &dir
+	return query
+}
+
+// Select adds fields to the list of returned columns.
+func (q *Query) Select(fields ...string) *Query {
+	q.SelectFields = append(q.SelectFields, fields...)
+	return q
+}
+
+// Between sets the query time range.
+func (q *Query) Between(start, end time.Time) *Query {
+	q.StartTime = start.UnixNano() / int64(time.Microsecond)
+	q.EndTime = end.UnixNano() / int64(time.Microsecond)
+	return q
+}
+
+// Since sets the query time range to the last interval.
+func (q *Query) Since(interval time.Duration) *Query {
+	q.StartTime = fmt.Sprintf("now-%ds", int64(interval/time.Second))
+	q.EndTime = "now"
+	return q
+}
+
+// Match adds a conjunction of terms to the where clause. Successive calls
+// are OR'ed.
+func (q *Query) Match(terms ...MatchTerm) *Query {
+	q.Where = append(q.Where, terms)
+	return q
+}
+
+// FilterBy adds a conjunction of terms to the filter clause.
+func (q *Query) FilterBy(terms ...MatchTerm) *Query {
+	q.Filter = append(q.Filter, terms)
+	return q
+}
+
+// SortBy sets the sort fields. Sort order is ascending unless desc is set.
+func (q *Query) SortBy(desc bool, fields ...string) *Query {
+	q.SortFields = fields
+	q.Sort = 1
+	if desc {
+		q.Sort = 2
+	}
+	return q
+}
+
+// WithLimit sets the maximum number of rows returned.
+func (q *Query) WithLimit(limit int) *Query {
+	q.Limit = limit
+	return q
+}
+
+// StatsQuery is a query on a statistics table. The attribute fields of
+// the table are qualified with the attribute name, e.g. if_stats.in_bytes;
+// Field returns the qualified name.
+type StatsQuery struct {
+	*Query
+	attribute string
+}
+
+// NewStatsQuery builds a query on the statistics table of a sandesh
+// structure attribute (e.g. NewStatsQuery("VirtualMachineStats", "if_stats")).
+func NewStatsQuery(name, attribute string) *StatsQuery {
+	return &StatsQuery{
+		Query:     NewQuery(StatTable(name, attribute)),
+		attribute: attribute,
+	}
+}
+
+// Field returns the qualified name of an attribute field.
+func (q *StatsQuery) Field(name string) string {
+	return q.attribute + "." + name
+}
+
+// SelectAttr adds attribute fields to the list of returned columns.
+func (q *StatsQuery) SelectAttr(fields ...string) *StatsQuery {
+	for _, field := range fields {
+		q.SelectFields = append(q.SelectFields, q.Field(field))
+	}
+	return q
+}
+
+func (q *StatsQuery) aggregate(function, field string) *StatsQuery {
+	q.SelectFields = append(q.SelectFields,
+		fmt.Sprintf("%s(%s)", function, q.Field(field)))
+	return q
+}
+
+// Sum adds the sum of an attribute field to the returned columns.
+func (q *StatsQuery) Sum(field string) *StatsQuery {
+	return q.aggregate("SUM", field)
+}
+
+// Avg adds the average of an attribute field to the returned columns.
+func (q *StatsQuery) Avg(field string) *StatsQuery {
+	return q.aggregate("AVG", field)
+}
+
+// Min adds the minimum of an attribute field to the returned columns.
+func (q *StatsQuery) Min(field string) *StatsQuery {
+	return q.aggregate("MIN", field)
+}
+
+// Max adds the maximum of an attribute field to the returned columns.
+func (q *StatsQuery) Max(field string) *StatsQuery {
+	return q.aggregate("MAX", field)
+}
+
+// Count adds the number of samples to the returned columns.
+func (q *StatsQuery) Count() *StatsQuery {
+	q.SelectFields = append(q.SelectFields,
+		fmt.Sprintf("COUNT(%s)", q.attribute))
+	return q
+}
+
+// Granularity groups the samples in time buckets of the given duration
+// (the T=<seconds> column).
+func (q *StatsQuery) Granularity(interval time.Duration) *StatsQuery {
+	q.SelectFields = append(q.SelectFields,
+		fmt.Sprintf("T=%d", int64(interval/time.Second)))
+	return q
+}
+
+// MatchAttr adds an equality match on an attribute field to the where
+// clause.
+func (q *StatsQuery) MatchAttr(field string, value interface{}) *StatsQuery {
+	q.Match(Equal(q.Field(field), value))
+	return q
+}
+
+// Equal builds an equality match term.
+func Equal(name string, value interface{}) MatchTerm {
+	return MatchTerm{Name: name, Value: value, Op: OpEqual}
+}
+
+// InRange builds a range match term.
+func InRange(name string, low, high interface{}) MatchTerm {
+	return MatchTerm{Name: name, Value: low, Value2: high, Op: OpInRange}
+}
+
+// Prefix builds a prefix match term.
+func Prefix(name string, value string) MatchTerm {
+	return MatchTerm{Name: name, Value: value, Op: OpPrefix}
+}
+
+// QueryResult holds the rows returned by the query engine.
+type QueryResult struct {
+	Rows []map[string]interface{} `json:"value"`
+}
+
+// Query executes a synchronous query against the analytics API.
+func (client *AnalyticsClient) Query(query *Query) (*QueryResult, error) {
+	if query.Table == "" {
+		return nil, fmt.Errorf("query: table not specified")
+	}
+	if len(query.SelectFields) == 0 {
+		return nil, fmt.Errorf("query: no select fields")
+	}
+	data, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	url := client.baseURL() + "/analytics/query"
+	resp, err := client.httpClient.Post(url, "application/json",
+		bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, body)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	result := new(QueryResult)
+	err = decoder.Decode(result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,199 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// UVE types as exposed by the analytics API under /analytics/uves.
+const (
+	UVETypeVirtualNetwork          = "virtual-network"
+	UVETypeVirtualMachine          = "virtual-machine"
+	UVETypeVirtualMachineInterface = "virtual-machine-interface"
+	UVETypeVirtualRouter           = "vrouter"
+	UVETypeControlNode             = "control-node"
+	UVETypeConfigNode              = "config-node"
+	UVETypeAnalyticsNode           = "analytics-node"
+)
+
+// UVEReference is an element of the list returned by a UVE type listing.
+type UVEReference struct {
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+// UVE is a User Visible Entity. Its content is keyed by the name of the
+// sandesh structure that contributes the data (e.g. UveVirtualNetworkAgent).
+type UVE struct {
+	Name  string                     `json:"name"`
+	Value map[string]json.RawMessage `json:"value"`
+}
+
+// Decode unmarshals the data contributed by a given sandesh structure.
+// It returns false when the UVE doesn't contain that structure.
+func (uve *UVE) Decode(structName string, v interface{}) (bool, error) {
+	data, ok := uve.Value[structName]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// defined in src/vnsw/agent/uve/virtual_network.sandesh
+type UveVirtualNetworkAgent struct {
+	InTpkts            uint64   `json:"in_tpkts"`
+	OutTpkts           uint64   `json:"out_tpkts"`
+	InBytes            uint64   `json:"in_bytes"`
+	OutBytes           uint64   `json:"out_bytes"`
+	AclRuleCount       int      `json:"total_acl_rules"`
+	VirtualMachineList []string `json:"virtualmachine_list"`
+	InterfaceList      []string `json:"interface_list"`
+}
+
+// defined in src/config/uve/virtual_network.sandesh
+type UveVirtualNetworkConfig struct {
+	ConnectedNetworks   []string `json:"connected_networks"`
+	RoutingInstanceList []string `json:"routing_instance_list"`
+	TotalAclRules       int      `json:"total_acl_rules"`
+}
+
+// VirtualNetworkUVE is the typed representation of a virtual-network UVE.
+type VirtualNetworkUVE struct {
+	Name   string
+	Agent  *UveVirtualNetworkAgent
+	Config *UveVirtualNetworkConfig
+}
+
+func (client *AnalyticsClient) httpGet(path string, values url.Values) (
+	[]byte, error) {
+	url := client.baseURL() + "/" + path
+	if len(values) > 0 {
+		url += "?" + values.Encode()
+	}
+	resp, err := client.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return body, nil
+}
+
+// UVEList retrieves the names of the UVEs of a given type.
+func (client *AnalyticsClient) UVEList(uveType string) ([]UVEReference, error) {
+	body, err := client.httpGet(
+		fmt.Sprintf("analytics/uves/%ss", url.PathEscape(uveType)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var refs []UVEReference
+	err = json.Unmarshal(body, &refs)
+	return refs, err
+}
+
+// UVEGet retrieves a single UVE. When cfilt is not empty, only the
+// listed sandesh structures are returned by the server.
+func (client *AnalyticsClient) UVEGet(uveType, name string, cfilt ...string) (
+	*UVE, error) {
+	values := make(url.Values, 0)
+	values.Add("flat", "")
+	if len(cfilt) > 0 {
+		values.Add("cfilt", strings.Join(cfilt, ","))
+	}
+	body, err := client.httpGet(fmt.Sprintf("analytics/uves/%s/%s",
+		url.PathEscape(uveType), url.PathEscape(name)), values)
+	if err != nil {
+		return nil, err
+	}
+	uve := &UVE{Name: name}
+	err = json.Unmarshal(body, &uve.Value)
+	if err != nil {
+		return nil, err
+	}
+	if len(uve.Value) == 0 {
+		return nil, errors.New("404 Not Found: " + uveType + " " + name)
+	}
+	return uve, nil
+}
+
+// UVEQuery retrieves all the UVEs of a given type that match pattern
+// (e.g. "*" or "default-domain:admin:*").
+func (client *AnalyticsClient) UVEQuery(uveType, pattern string, cfilt ...string) (
+	[]UVE, error) {
+	values := make(url.Values, 0)
+	values.Add("flat", "")
+	if len(cfilt) > 0 {
+		values.Add("cfilt", strings.Join(cfilt, ","))
+	}
+	body, err := client.httpGet(fmt.Sprintf("analytics/uves/%s/%s",
+		url.PathEscape(uveType), url.PathEscape(pattern)), values)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Value []UVE `json:"value"`
+	}
+	err = json.Unmarshal(body, &response)
+	return response.Value, err
+}
+
+func buildVirtualNetworkUVE(uve *UVE) (*VirtualNetworkUVE, error) {
+	result := &VirtualNetworkUVE{Name: uve.Name}
+	agent := new(UveVirtualNetworkAgent)
+	if ok, err := uve.Decode("UveVirtualNetworkAgent", agent); err != nil {
+		return nil, err
+	} else if ok {
+		result.Agent = agent
+	}
+	config := new(UveVirtualNetworkConfig)
+	if ok, err := uve.Decode("UveVirtualNetworkConfig", config); err != nil {
+		return nil, err
+	} else if ok {
+		result.Config = config
+	}
+	return result, nil
+}
+
+// VirtualNetworkUVE retrieves the UVE of the virtual-network identified by
+// its colon separated fully qualified name.
+func (client *AnalyticsClient) VirtualNetworkUVE(fqn string) (
+	*VirtualNetworkUVE, error) {
+	uve, err := client.UVEGet(UVETypeVirtualNetwork, fqn)
+	if err != nil {
+		return nil, err
+	}
+	return buildVirtualNetworkUVE(uve)
+}
+
+// VirtualNetworkUVEs retrieves the UVEs of all virtual-networks.
+func (client *AnalyticsClient) VirtualNetworkUVEs() ([]*VirtualNetworkUVE, error) {
+	uves, err := client.UVEQuery(UVETypeVirtualNetwork, "*")
+	if err != nil {
+		return nil, err
+	}
+	var result []*VirtualNetworkUVE
+	for i := range uves {
+		vn, err := buildVirtualNetworkUVE(&uves[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, vn)
+	}
+	return result, nil
+}
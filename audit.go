@@ -0,0 +1,143 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Audit operations.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	// AuditRequest is any other request that may modify the
+	// configuration: reference and property collection updates,
+	// actions such as set-tag and execute-job, DoJSON requests.
+	AuditRequest = "request"
+)
+
+// AuditRecord describes a Create, Update or Delete issued by the client,
+// or another request that may modify the configuration.
+type AuditRecord struct {
+	Time time.Time
+	// User is the identity reported by the Authenticator, when it
+	// implements Identity (e.g. the keystone user name).
+	User      string
+	Operation string
+	Type      string
+	Uuid      string
+	FQName    []string
+	// Method and Path identify the HTTP request of AuditRequest records.
+	// Type and Uuid are set when the request body holds them (e.g.
+	// ref-update).
+	Method string
+	Path   string
+	// Diff is the object sent to the API server: the complete object on
+	// create, the modified properties on update, nil on delete. It is the
+	// request body of AuditRequest records.
+	Diff json.RawMessage
+	// Err is the result of the operation. It is only set when the
+	// record is passed to AuditSink.Complete.
+	Err error
+}
+
+// AuditSink receives a record of each mutation. Begin is called before the
+// request is sent (write-ahead): if it returns an error, the operation is
+// not performed and the error is returned to the caller. Complete is
+// called with the result once the API server has responded.
+type AuditSink interface {
+	Begin(record *AuditRecord) error
+	Complete(record *AuditRecord)
+}
+
+// Identity is implemented by the authenticators that can report the user
+// on whose behalf requests are made.
+type Identity interface {
+	Identity() string
+}
+
+// SetAuditSink enables audit records for Create, Update, Delete and
+// DeleteByUuid, and for all the other requests that may modify the
+// configuration (see AuditRequest). A nil sink disables them.
+func (c *Client) SetAuditSink(sink AuditSink) {
+	c.audit = sink
+}
+
+func (c *Client) newAuditRecord(operation, typename string, obj IObject,
+	diff []byte) *AuditRecord {
+	record := &AuditRecord{
+		Time:      time.Now(),
+		Operation: operation,
+		Type:      typename,
+		Diff:      diff,
+	}
+	if identity, ok := c.auth.(Identity); ok {
+		record.User = identity.Identity()
+	}
+	if obj != nil {
+		record.Uuid = obj.GetUuid()
+		record.FQName = obj.GetFQName()
+	}
+	return record
+}
+
+// audited runs fn between the Begin and Complete calls of the sink. The
+// uuid of obj is recorded on completion, since Create assigns it.
+func (c *Client) audited(record *AuditRecord, obj IObject, fn func() error) error {
+	if err := c.audit.Begin(record); err != nil {
+		return err
+	}
+	err := fn()
+	completed := *record
+	completed.Err = err
+	if obj != nil && completed.Uuid == "" {
+		completed.Uuid = obj.GetUuid()
+	}
+	c.audit.Complete(&completed)
+	return err
+}
+
+// auditedKey marks the context of the requests sent by an operation that
+// is already audited.
+type auditedKey struct{}
+
+var auditedContext = context.WithValue(context.Background(), auditedKey{}, true)
+
+// auditRequest sends req between the Begin and Complete calls of the sink.
+// data is the request body. The request fails with an HTTPError, without
+// body, if the response status is not 2xx.
+func (c *Client) auditRequest(req *http.Request, data []byte) (*http.Response, error) {
+	record := c.newAuditRecord(AuditRequest, "", nil, data)
+	record.Method = req.Method
+	record.Path = req.URL.Path
+	var ids struct {
+		Type    string `json:"type"`
+		Uuid    string `json:"uuid"`
+		ObjType string `json:"obj_type"`
+		ObjUuid string `json:"obj_uuid"`
+	}
+	if json.Unmarshal(data, &ids) == nil {
+		record.Type, record.Uuid = ids.Type, ids.Uuid
+		if ids.ObjUuid != "" {
+			record.Type, record.Uuid = ids.ObjType, ids.ObjUuid
+		}
+	}
+	if err := c.audit.Begin(record); err != nil {
+		return nil, err
+	}
+	resp, err := c.track(req)
+	completed := *record
+	completed.Err = err
+	if err == nil && (resp.StatusCode < http.StatusOK || resp.StatusCode >= 300) {
+		completed.Err = &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status,
+			RequestID: RequestID(resp)}
+	}
+	c.audit.Complete(&completed)
+	return resp, err
+}
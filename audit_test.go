@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type testAuditSink struct {
+	begun     []AuditRecord
+	completed []AuditRecord
+	err       error
+}
+
+func (s *testAuditSink) Begin(record *AuditRecord) error {
+	s.begun = append(s.begun, *record)
+	return s.err
+}
+
+func (s *testAuditSink) Complete(record *AuditRecord) {
+	s.completed = append(s.completed, *record)
+}
+
+type testIdentity struct {
+	NopAuthenticator
+}
+
+func (*testIdentity) Identity() string {
+	return "admin"
+}
+
+func TestAudit(t *testing.T) {
+	var requests []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case "POST":
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "href": "http://localhost/test-network/net-uuid"}}`)
+		case "DELETE":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+	sink := new(testAuditSink)
+	client.SetAuditSink(sink)
+	client.SetAuthenticator(new(testIdentity))
+
+	network := new(TestNetwork)
+	network.SetName("net")
+	network.display_name = "net"
+	if err := client.Create(network); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteByUuid("test-network", "missing"); err == nil {
+		t.Error("expected delete error")
+	}
+	if len(sink.begun) != 2 || len(sink.completed) != 2 {
+		t.Fatalf("unexpected records %d, %d", len(sink.begun), len(sink.completed))
+	}
+	create := sink.completed[0]
+	if create.Operation != AuditCreate || create.Type != "test-network" ||
+		create.Uuid != "net-uuid" || create.User != "admin" || create.Err != nil {
+		t.Errorf("unexpected record %+v", create)
+	}
+	var diff map[string]interface{}
+	if err := json.Unmarshal(create.Diff, &diff); err != nil || diff["display_name"] != "net" {
+		t.Errorf("unexpected diff %s", create.Diff)
+	}
+	if sink.begun[0].Uuid != "" {
+		t.Errorf("uuid set before create: %+v", sink.begun[0])
+	}
+	del := sink.completed[1]
+	if del.Operation != AuditDelete || del.Uuid != "missing" || del.Err == nil {
+		t.Errorf("unexpected record %+v", del)
+	}
+
+	// A sink that fails to record prevents the mutation.
+	sink.err = errors.New("audit log unavailable")
+	if err := client.Delete(network); err != sink.err {
+		t.Errorf("expected audit error, got %v", err)
+	}
+	if len(requests) != 2 || len(sink.completed) != 2 {
+		t.Errorf("mutation performed without audit record: %v", requests)
+	}
+}
+
+func TestAuditRequests(t *testing.T) {
+	var requests []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/id-to-fqname":
+			fmt.Fprint(w, `{"type": "test-network", "fq_name": ["default-project", "net"]}`)
+		case "/test-networks":
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net"}}`)
+		case "/execute-job":
+			http.Error(w, "no such job", http.StatusBadRequest)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	defer server.Close()
+	sink := new(testAuditSink)
+	client.SetAuditSink(sink)
+
+	if _, err := client.FQNameByUuid("net-uuid"); err != nil {
+		t.Fatal(err)
+	}
+	network := new(TestNetwork)
+	network.SetName("net")
+	if err := client.Create(network); err != nil {
+		t.Fatal(err)
+	}
+	steps := []func() error{
+		func() error {
+			return client.DoJSON(context.Background(), "PUT", "test-network/net-uuid",
+				map[string]interface{}{"test-network": map[string]string{"display_name": "x"}}, nil)
+		},
+		func() error {
+			return client.SetTags("test-network", "net-uuid",
+				TagUpdate{Type: TagTypeTier, Value: "web"})
+		},
+		func() error {
+			return client.UpdateReference(&ReferenceUpdateMsg{
+				Type: "test-network", Uuid: "net-uuid", RefType: "test-project",
+				RefUuid: "p1", Operation: "ADD"})
+		},
+		func() error {
+			return client.UpdatePropCollection("net-uuid", []PropCollectionUpdate{
+				{Field: "annotations", Operation: "delete", Position: "key"}})
+		},
+		func() error {
+			return client.DoJSON(context.Background(), "POST", "execute-job",
+				map[string]string{"job_template_id": "missing"}, nil)
+		},
+	}
+	for i, step := range steps {
+		err := step()
+		if (err != nil) != (i == len(steps)-1) {
+			t.Errorf("step %d: unexpected result %v", i, err)
+		}
+	}
+
+	// The read-only POST is not audited, the create is audited once.
+	expected := []struct {
+		operation, method, path, uuid string
+		failed                        bool
+	}{
+		{AuditCreate, "", "", "net-uuid", false},
+		{AuditRequest, "PUT", "/test-network/net-uuid", "", false},
+		{AuditRequest, "POST", "/set-tag", "net-uuid", false},
+		{AuditRequest, "POST", "/ref-update", "net-uuid", false},
+		{AuditRequest, "POST", "/prop-collection-update", "net-uuid", false},
+		{AuditRequest, "POST", "/execute-job", "", true},
+	}
+	if len(sink.begun) != len(expected) || len(sink.completed) != len(expected) {
+		t.Fatalf("expected %d records, got %d, %d", len(expected), len(sink.begun),
+			len(sink.completed))
+	}
+	for i, e := range expected {
+		record := sink.completed[i]
+		if record.Operation != e.operation || record.Method != e.method ||
+			record.Path != e.path || record.Uuid != e.uuid ||
+			(record.Err != nil) != e.failed {
+			t.Errorf("record %d: unexpected %+v", i, record)
+		}
+	}
+	if len(sink.completed[3].Diff) == 0 {
+		t.Error("request body not recorded")
+	}
+
+	// A sink that fails to record prevents the request.
+	sink.err = errors.New("audit log unavailable")
+	sent := len(requests)
+	if err := steps[2](); err != sink.err {
+		t.Errorf("expected audit error, got %v", err)
+	}
+	if len(requests) != sent {
+		t.Errorf("request sent without audit record: %v", requests[sent:])
+	}
+}
@@ -0,0 +1,166 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/fakeserver"
+)
+
+const idPerms = `"id_perms": {"enable": true, "created": "2014-01-01T00:00:00.000000", "last_modified": "2014-01-01T00:00:00.000000", "uuid": {"uuid_mslong": 1, "uuid_lslong": 2}}`
+
+// objects are representative API server responses, one per type.
+var objects = []struct {
+	typename string
+	data     string
+}{
+	{"project", `{"project": {"fq_name": ["default-domain", "bench"], "uuid": "c3b1c1a6-0000-4000-8000-000000000001", "name": "bench", "parent_type": "domain", "display_name": "bench", ` + idPerms + `,
+		"quota": {"virtual_network": 100, "virtual_machine_interface": 1000, "floating_ip": 50},
+		"virtual_networks": [{"to": ["default-domain", "bench", "net-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000010"}, {"to": ["default-domain", "bench", "net-1"], "uuid": "c3b1c1a6-0000-4000-8000-000000000011"}]}}`},
+	{"virtual-network", `{"virtual-network": {"fq_name": ["default-domain", "bench", "net-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000010", "name": "net-0", "parent_type": "project", "display_name": "net-0", ` + idPerms + `,
+		"virtual_network_properties": {"forwarding_mode": "l2_l3", "allow_transit": false, "rpf": "enable"},
+		"route_target_list": {"route_target": ["target:64512:8000001"]},
+		"network_ipam_refs": [{"to": ["default-domain", "default-project", "default-network-ipam"], "uuid": "c3b1c1a6-0000-4000-8000-000000000020",
+			"attr": {"ipam_subnets": [{"subnet": {"ip_prefix": "10.0.0.0", "ip_prefix_len": 24}, "default_gateway": "10.0.0.1", "enable_dhcp": true, "subnet_uuid": "c3b1c1a6-0000-4000-8000-000000000021"}]}}],
+		"network_policy_refs": [{"to": ["default-domain", "bench", "allow-all"], "uuid": "c3b1c1a6-0000-4000-8000-000000000030", "attr": {"sequence": {"major": 0, "minor": 0}, "timer": null}}]}}`},
+	{"virtual-machine-interface", `{"virtual-machine-interface": {"fq_name": ["default-domain", "bench", "port-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000040", "name": "port-0", "parent_type": "project", "display_name": "port-0", ` + idPerms + `,
+		"virtual_machine_interface_mac_addresses": {"mac_address": ["02:c3:b1:c1:a6:40"]},
+		"virtual_machine_interface_bindings": {"key_value_pair": [{"key": "vnic_type", "value": "normal"}, {"key": "host_id", "value": "compute-1"}]},
+		"virtual_network_refs": [{"to": ["default-domain", "bench", "net-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000010"}],
+		"security_group_refs": [{"to": ["default-domain", "bench", "default"], "uuid": "c3b1c1a6-0000-4000-8000-000000000050"}],
+		"instance_ip_back_refs": [{"to": ["c3b1c1a6-0000-4000-8000-000000000060"], "uuid": "c3b1c1a6-0000-4000-8000-000000000060"}]}}`},
+	{"instance-ip", `{"instance-ip": {"fq_name": ["c3b1c1a6-0000-4000-8000-000000000060"], "uuid": "c3b1c1a6-0000-4000-8000-000000000060", "name": "c3b1c1a6-0000-4000-8000-000000000060", ` + idPerms + `,
+		"instance_ip_address": "10.0.0.3", "instance_ip_family": "v4", "subnet_uuid": "c3b1c1a6-0000-4000-8000-000000000021",
+		"virtual_network_refs": [{"to": ["default-domain", "bench", "net-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000010"}],
+		"virtual_machine_interface_refs": [{"to": ["default-domain", "bench", "port-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000040"}]}}`},
+	{"security-group", `{"security-group": {"fq_name": ["default-domain", "bench", "default"], "uuid": "c3b1c1a6-0000-4000-8000-000000000050", "name": "default", "parent_type": "project", ` + idPerms + `,
+		"security_group_entries": {"policy_rule": [
+			{"rule_uuid": "r1", "direction": ">", "protocol": "any", "ethertype": "IPv4", "src_addresses": [{"security_group": "default-domain:bench:default"}], "src_ports": [{"start_port": 0, "end_port": 65535}], "dst_addresses": [{"security_group": "local"}], "dst_ports": [{"start_port": 0, "end_port": 65535}]},
+			{"rule_uuid": "r2", "direction": ">", "protocol": "any", "ethertype": "IPv4", "src_addresses": [{"security_group": "local"}], "src_ports": [{"start_port": 0, "end_port": 65535}], "dst_addresses": [{"subnet": {"ip_prefix": "0.0.0.0", "ip_prefix_len": 0}}], "dst_ports": [{"start_port": 0, "end_port": 65535}]}]},
+		"virtual_machine_interface_back_refs": [{"to": ["default-domain", "bench", "port-0"], "uuid": "c3b1c1a6-0000-4000-8000-000000000040"}]}}`},
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, object := range objects {
+		typename, data := object.typename, []byte(object.data)
+		b.Run(typename, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := contrail.DecodeObject(typename, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, object := range objects {
+		obj, err := contrail.DecodeObject(object.typename, []byte(object.data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(object.typename, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := json.Marshal(obj); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// listResponse is a detailed list response of count virtual-network
+// objects.
+func listResponse(count int) []byte {
+	var content map[string]json.RawMessage
+	json.Unmarshal([]byte(objects[1].data), &content)
+	network := string(content["virtual-network"])
+	elements := make([]string, count)
+	for i := range elements {
+		element := strings.Replace(network, "net-0", fmt.Sprintf("net-%d", i), -1)
+		element = strings.Replace(element, "000000000010", fmt.Sprintf("%012d", 100000+i), 1)
+		elements[i] = `{"virtual-network": ` + element + `}`
+	}
+	return []byte(`{"virtual-networks": [` + strings.Join(elements, ", ") + `]}`)
+}
+
+func newClient(b *testing.B, handler http.Handler) (*httptest.Server, *contrail.Client) {
+	server := httptest.NewServer(handler)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	return server, contrail.NewClient(host, portNum)
+}
+
+func BenchmarkListDetail(b *testing.B) {
+	for _, count := range []int{10, 1000} {
+		data := listResponse(count)
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			server, client := newClient(b, http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.Write(data)
+				}))
+			defer server.Close()
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				objs, err := client.ListDetail("virtual-network", nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(objs) != count {
+					b.Fatalf("%d objects, expected %d", len(objs), count)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReferenceUpdate(b *testing.B) {
+	server, client := newClient(b, fakeserver.New(nil))
+	defer server.Close()
+	network := contrail.NewGenericObject("virtual-network")
+	network.SetFQName("project", []string{"default-domain", "default-project", "bench"})
+	if err := client.Create(network); err != nil {
+		b.Fatal(err)
+	}
+	ipam, err := client.FindByName("network-ipam",
+		"default-domain:default-project:default-network-ipam")
+	if err != nil {
+		b.Fatal(err)
+	}
+	msg := &contrail.ReferenceUpdateMsg{
+		Type:      "virtual-network",
+		Uuid:      network.GetUuid(),
+		RefType:   "network-ipam",
+		RefUuid:   ipam.GetUuid(),
+		RefFQName: ipam.GetFQName(),
+	}
+	operations := []string{"ADD", "DELETE"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg.Operation = operations[i%2]
+		if err := client.UpdateReference(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package bench contains the benchmarks of the contrail package: object
+// encoding and decoding per type, list decoding throughput and the
+// latency of reference updates against the fake API server. They don't
+// require an API server and their inputs are fixed, so that the results
+// of two trees can be compared.
+//
+// To evaluate a change, run the benchmarks on both trees and compare the
+// results with benchstat (golang.org/x/perf/cmd/benchstat):
+//
+//	git stash
+//	go test -run '^$' -bench . -benchmem -count 10 ./bench > old.txt
+//	git stash pop
+//	go test -run '^$' -bench . -benchmem -count 10 ./bench > new.txt
+//	benchstat old.txt new.txt
+//
+// The generated types are not linked: objects are decoded as
+// GenericObjects.
+package bench
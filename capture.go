@@ -0,0 +1,250 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exchange is a request and its response, as captured by CaptureBodies.
+// Credentials are redacted.
+type Exchange struct {
+	Time           time.Time
+	Duration       time.Duration
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	// Err is the transport error, if any.
+	Err error
+}
+
+// ExchangeRecorder receives captured exchanges. Implementations must be
+// safe for concurrent use.
+type ExchangeRecorder interface {
+	Record(exchange *Exchange)
+}
+
+// CaptureBuffer is an ExchangeRecorder that keeps the last exchanges.
+type CaptureBuffer struct {
+	mutex     sync.Mutex
+	exchanges []*Exchange
+	next      int
+	full      bool
+}
+
+// NewCaptureBuffer allocates a buffer that holds up to size exchanges.
+func NewCaptureBuffer(size int) *CaptureBuffer {
+	return &CaptureBuffer{exchanges: make([]*Exchange, size)}
+}
+
+// Record implements ExchangeRecorder.
+func (b *CaptureBuffer) Record(exchange *Exchange) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.exchanges) == 0 {
+		return
+	}
+	b.exchanges[b.next] = exchange
+	b.next = (b.next + 1) % len(b.exchanges)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Exchanges returns the buffered exchanges, oldest first.
+func (b *CaptureBuffer) Exchanges() []*Exchange {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.full {
+		return append([]*Exchange(nil), b.exchanges[:b.next]...)
+	}
+	return append(append([]*Exchange(nil), b.exchanges[b.next:]...),
+		b.exchanges[:b.next]...)
+}
+
+// captureWriter formats exchanges as text.
+type captureWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewCaptureWriter returns an ExchangeRecorder that writes the exchanges
+// to w in a human readable format.
+func NewCaptureWriter(w io.Writer) ExchangeRecorder {
+	return &captureWriter{writer: w}
+}
+
+func (c *captureWriter) Record(e *Exchange) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s\n", e.Time.Format(time.RFC3339Nano), e.Method, e.URL)
+	e.RequestHeader.Write(&buf)
+	if len(e.RequestBody) > 0 {
+		fmt.Fprintf(&buf, "\n%s\n", e.RequestBody)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&buf, "--- error after %v: %v\n\n", e.Duration, e.Err)
+	} else {
+		fmt.Fprintf(&buf, "--- %d after %v\n", e.Status, e.Duration)
+		e.ResponseHeader.Write(&buf)
+		fmt.Fprintf(&buf, "\n%s\n\n", e.ResponseBody)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.writer.Write(buf.Bytes())
+}
+
+// Headers that carry credentials.
+var sensitiveHeaders = []string{
+	"Authorization", "Cookie", "Set-Cookie", "X-Auth-Token",
+	"X-Subject-Token", "Proxy-Authorization",
+}
+
+const redacted = "<redacted>"
+
+// sensitiveKey returns true for JSON keys whose values are credentials.
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "passwd", "secret", "token",
+		"credential", "private_key", "api_key", "apikey", "auth_key"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, element := range v {
+			if sensitiveKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(element)
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactValue(element)
+		}
+	}
+	return value
+}
+
+var sensitiveParameter = regexp.MustCompile(
+	`(?i)((?:password|passwd|secret|token|api_key)=)[^&\s]*`)
+
+// RedactBody replaces the credentials (passwords, tokens, keys) contained
+// in a JSON or form encoded body.
+func RedactBody(data []byte) []byte {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err == nil {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(redactValue(value)); err == nil {
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		}
+	}
+	return sensitiveParameter.ReplaceAll(data, []byte("${1}"+redacted))
+}
+
+// RedactHeader returns a copy of header with credentials replaced.
+func RedactHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if result.Get(name) != "" {
+			result.Set(name, redacted)
+		}
+	}
+	return result
+}
+
+// readRequestBody returns the body of a request and restores it so that
+// it can be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return data, nil
+		}
+		if plain, err := ioutil.ReadAll(reader); err == nil {
+			return plain, nil
+		}
+	}
+	return data, nil
+}
+
+// CaptureBodies returns a middleware that records each request and
+// response, including their bodies, with credentials redacted:
+//
+//	capture := contrail.NewCaptureBuffer(100)
+//	client.Use(contrail.CaptureBodies(capture))
+//
+// Response bodies are read in full before being returned to the caller.
+func CaptureBodies(recorder ExchangeRecorder) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			exchange := &Exchange{
+				Time:          time.Now(),
+				Method:        req.Method,
+				URL:           req.URL.String(),
+				RequestHeader: RedactHeader(req.Header),
+			}
+			body, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+			if len(body) > 0 {
+				exchange.RequestBody = RedactBody(body)
+			}
+			resp, err := next(req)
+			exchange.Duration = time.Since(exchange.Time)
+			if err != nil {
+				exchange.Err = err
+				recorder.Record(exchange)
+				return nil, err
+			}
+			exchange.Status = resp.StatusCode
+			exchange.ResponseHeader = RedactHeader(resp.Header)
+			data, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				exchange.Err = err
+				recorder.Record(exchange)
+				return nil, err
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+			exchange.ResponseBody = RedactBody(data)
+			recorder.Record(exchange)
+			return resp, nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type tokenAuthenticator string
+
+func (a tokenAuthenticator) AddAuthentication(req *http.Request) error {
+	req.Header.Set("X-Auth-Token", string(a))
+	return nil
+}
+
+func TestCaptureBodies(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"uuid": "vn-uuid", "keystone": {"admin_password": "s3cret"}}`))
+	})
+	defer server.Close()
+	client.SetAuthenticator(tokenAuthenticator("tok-123"))
+	client.SetRequestCompressionThreshold(1)
+
+	capture := NewCaptureBuffer(2)
+	var log bytes.Buffer
+	client.Use(CaptureBodies(capture), CaptureBodies(NewCaptureWriter(&log)))
+
+	uuid, err := client.UuidByName("virtual-network", "default-domain:p:vn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uuid != "vn-uuid" {
+		t.Errorf("response body not restored: %q", uuid)
+	}
+	client.UuidByName("virtual-network", "default-domain:p:vn2")
+	client.UuidByName("virtual-network", "default-domain:p:vn3")
+
+	exchanges := capture.Exchanges()
+	if len(exchanges) != 2 {
+		t.Fatalf("expected 2 exchanges, got %d", len(exchanges))
+	}
+	e := exchanges[1]
+	if !strings.Contains(string(e.RequestBody), "vn3") {
+		t.Errorf("unexpected request body %s", e.RequestBody)
+	}
+	if e.Status != 200 || e.RequestHeader.Get("X-Auth-Token") != "<redacted>" {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+	if strings.Contains(string(e.ResponseBody), "s3cret") {
+		t.Errorf("password not redacted: %s", e.ResponseBody)
+	}
+	if strings.Contains(log.String(), "s3cret") || strings.Contains(log.String(), "tok-123") ||
+		!strings.Contains(log.String(), "POST") {
+		t.Errorf("unexpected log:\n%s", log.String())
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	cases := map[string]string{
+		`{"auth": {"passwordCredentials": {"username": "admin", "password": "x"}}}`: `{"auth":{"passwordCredentials":"<redacted>"}}`,
+		`{"annotations": {"key_value_pair": [{"key": "k", "value": "v"}]}}`:         `{"annotations":{"key_value_pair":[{"key":"k","value":"v"}]}}`,
+		`grant_type=password&password=abc&client_id=x`:                              `grant_type=password&password=<redacted>&client_id=x`,
+	}
+	for input, expected := range cases {
+		if actual := string(RedactBody([]byte(input))); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
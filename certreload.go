@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader provides the client certificate of a TLS connection and
+// reloads it from disk when the certificate or key files are modified
+// (e.g. rotated by cert-manager or a vault agent). The files are checked
+// at each TLS handshake; established connections are not affected.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mutex   sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := reloader.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.load(modTime); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// lastModified returns the most recent modification time of the files.
+func (r *certReloader) lastModified() (time.Time, error) {
+	var modTime time.Time
+	for _, filename := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return modTime, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// GetClientCertificate is the tls.Config callback. When the files cannot
+// be read (e.g. while being rewritten) the previous certificate is used.
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (
+	*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if modTime, err := r.lastModified(); err == nil && !modTime.Equal(r.modTime) {
+		r.load(modTime)
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,171 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key.
+func writeTestCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "contrail-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serialNumber(t *testing.T, reloader *certReloader) int64 {
+	cert, err := reloader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.SerialNumber.Int64()
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	writeTestCertificate(t, certFile, keyFile, 1)
+
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial := serialNumber(t, reloader); serial != 1 {
+		t.Errorf("unexpected serial %d", serial)
+	}
+
+	writeTestCertificate(t, certFile, keyFile, 2)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	if serial := serialNumber(t, reloader); serial != 2 {
+		t.Errorf("certificate not reloaded: serial %d", serial)
+	}
+
+	// A partially written key pair keeps the previous certificate.
+	ioutil.WriteFile(keyFile, []byte("garbage"), 0600)
+	future = future.Add(time.Minute)
+	os.Chtimes(keyFile, future, future)
+	if serial := serialNumber(t, reloader); serial != 2 {
+		t.Errorf("unexpected serial %d", serial)
+	}
+
+	if _, err := newCertReloader(filepath.Join(dir, "missing.crt"), keyFile); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// newClientCertServer starts a TLS server that requires a client
+// certificate. It emulates the keystone v3 token API and records the
+// serial number of the client certificate of each connection.
+func newClientCertServer(t *testing.T, mutex *sync.Mutex, serials *[]int64) (
+	*httptest.Server, string) {
+	server := httptest.NewUnstartedServer(new(keystoneV3Handler))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state != http.StateActive {
+			return
+		}
+		tlsConn := conn.(*tls.Conn)
+		certs := tlsConn.ConnectionState().PeerCertificates
+		if len(certs) > 0 {
+			mutex.Lock()
+			*serials = append(*serials, certs[0].SerialNumber.Int64())
+			mutex.Unlock()
+		}
+	}
+	server.StartTLS()
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, caFile
+}
+
+func TestKeystoneV3CertReload(t *testing.T) {
+	var mutex sync.Mutex
+	var serials []int64
+	server, caFile := newClientCertServer(t, &mutex, &serials)
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	writeTestCertificate(t, certFile, keyFile, 1)
+
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+		WithCertificates(caFile, keyFile, certFile, false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keystone.AuthenticateV3(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestCertificate(t, certFile, keyFile, 2)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	keystone.httpClient.CloseIdleConnections()
+	if err := keystone.AuthenticateV3(); err != nil {
+		t.Fatal(err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(serials) != 2 || serials[0] != 1 || serials[1] != 2 {
+		t.Errorf("unexpected client certificates %v", serials)
+	}
+}
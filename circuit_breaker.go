@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, without contacting the API server, while
+// the circuit breaker is open.
+var ErrCircuitOpen = errors.New("Circuit breaker open: API server unavailable")
+
+// Circuit breaker states.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// circuitBreaker fails requests locally once the API server has failed
+// threshold consecutive requests. After cooldown, a single request is let
+// through to probe the server: the circuit closes if it succeeds, and
+// opens for another cooldown if it fails.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// failed reports whether the outcome of a request counts as a failure of
+// the API server: a transport error (other than the cancellation of the
+// request) or a response from a proxy or load balancer without a healthy
+// server behind it.
+func failed(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (b *circuitBreaker) state() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch {
+	case b.failures < b.threshold:
+		return CircuitClosed
+	case b.probing || !b.now().Before(b.openUntil):
+		return CircuitHalfOpen
+	}
+	return CircuitOpen
+}
+
+// allow reports whether a request may be sent, and whether it is the
+// probe of a half-open circuit.
+func (b *circuitBreaker) allow() (bool, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+func (b *circuitBreaker) record(probe, failure bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if !failure {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+func (b *circuitBreaker) wrap(next Handler) Handler {
+	return func(req *http.Request) (resp *http.Response, err error) {
+		ok, probe := b.allow()
+		if !ok {
+			return nil, ErrCircuitOpen
+		}
+		// A panic counts as a failure, and must not leave the circuit
+		// probing.
+		failure := true
+		defer func() { b.record(probe, failure) }()
+		resp, err = next(req)
+		failure = failed(req, resp, err)
+		return resp, err
+	}
+}
+
+// SetCircuitBreaker enables a circuit breaker: once threshold consecutive
+// requests have failed (connection errors, timeouts, or 502, 503 and 504
+// responses), requests fail immediately with ErrCircuitOpen rather than
+// waiting for the API server. After cooldown, one request is sent to
+// probe the server; the others keep failing until it succeeds. A
+// threshold of 0 disables the circuit breaker, which is the default.
+//
+// The circuit breaker is shared with the copies of the client made by
+// WithTimeout.
+func (c *Client) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	if threshold <= 0 {
+		c.breaker = nil
+		return
+	}
+	c.breaker = newCircuitBreaker(threshold, cooldown)
+}
+
+// CircuitState returns the state of the circuit breaker: CircuitClosed
+// (also when it is disabled), CircuitOpen or CircuitHalfOpen.
+func (c *Client) CircuitState() string {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.state()
+}
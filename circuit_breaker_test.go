@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	requests := 0
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", status)
+	})
+	defer server.Close()
+
+	client.SetCircuitBreaker(3, time.Minute)
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.FindByUuid("test-network", "x"); err == nil || err == ErrCircuitOpen {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+	}
+	if _, err := client.FindByUuid("test-network", "x"); err != ErrCircuitOpen {
+		t.Errorf("expected open circuit, got %v", err)
+	}
+	if requests != 3 || client.CircuitState() != CircuitOpen {
+		t.Errorf("%d requests, state %s", requests, client.CircuitState())
+	}
+
+	// The probe fails: the circuit opens again.
+	now = now.Add(time.Minute)
+	if client.CircuitState() != CircuitHalfOpen {
+		t.Errorf("state %s, expected half-open", client.CircuitState())
+	}
+	client.FindByUuid("test-network", "x")
+	if _, err := client.FindByUuid("test-network", "x"); err != ErrCircuitOpen || requests != 4 {
+		t.Errorf("%d requests, error %v", requests, err)
+	}
+
+	// Client errors are not failures of the server.
+	now = now.Add(time.Minute)
+	status = http.StatusNotFound
+	client.FindByUuid("test-network", "x")
+	client.FindByUuid("test-network", "x")
+	if requests != 6 || client.CircuitState() != CircuitClosed {
+		t.Errorf("%d requests, state %s", requests, client.CircuitState())
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	breaker := newCircuitBreaker(1, 0)
+	breaker.record(false, true)
+	if ok, probe := breaker.allow(); !ok || !probe {
+		t.Fatal("expected probe")
+	}
+	if ok, _ := breaker.allow(); ok {
+		t.Error("request allowed while probing")
+	}
+	breaker.record(true, false)
+	if ok, probe := breaker.allow(); !ok || probe {
+		t.Error("expected closed circuit")
+	}
+}
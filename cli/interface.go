@@ -5,17 +5,15 @@
 package main
 
 import (
-	"encoding/xml"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"os"
 	"strings"
 	"text/tabwriter"
 	"text/template"
 
 	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/introspect"
 	"github.com/pborman/uuid"
 )
 
@@ -33,39 +31,9 @@ func interfaceStatus(client *contrail.Client, flagSet *flag.FlagSet) {
 		fmt.Fprintln(os.Stderr, "virtual-router must be specified.")
 		os.Exit(1)
 	}
-	url := fmt.Sprintf("http://%s:8085/Snh_ItfReq",
-		interfaceStatusOpts.vrouter)
-	resp, err := http.Get(url)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-	// see: controller/src/vnsw/agent/oper/agent.sandesh
-	// struct ItfSandeshData
-	type InterfaceData struct {
-		Name             string `xml:"name"`
-		Uuid             string `xml:"uuid"`
-		VrfName          string `xml:"vrf_name"`
-		Status           string `xml:"active"`
-		NetworkName      string `xml:"vn_name"`
-		InstanceName     string `xml:"vm_name"`
-		IpAddress        string `xml:"ip_addr"`
-		LinkLocalAddress string `xml:"mdata_ip_addr"`
-	}
-
-	type Envelope struct {
-		XMLName xml.Name        `xml:"ItfResp"`
-		Data    []InterfaceData `xml:"itf_list>list>ItfSandeshData"`
-	}
-
-	var m Envelope
-	err = xml.Unmarshal(body, &m)
+	api := introspect.NewClient(interfaceStatusOpts.vrouter,
+		introspect.AgentIntrospectPort)
+	interfaces, err := api.AgentInterfaceList("")
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -89,7 +57,7 @@ func interfaceStatus(client *contrail.Client, flagSet *flag.FlagSet) {
 		writer.Init(os.Stdout, 0, 0, 1, ' ', 0)
 		fmt.Fprintf(writer, "Instance\tNetwork\tIpAddress\n")
 	}
-	for _, ifdata := range m.Data {
+	for _, ifdata := range interfaces {
 		id := uuid.Parse(ifdata.Uuid)
 		if uuid.Equal(id, uuid.NIL) {
 			continue
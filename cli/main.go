@@ -85,17 +85,22 @@ func InitFlags() {
 }
 
 func setupAuthKeystone(client *contrail.Client) {
-	keystone := contrail.NewKeystoneClient(
-		os_auth_url,
-		os_tenant_name,
-		os_username,
-		os_password,
-		os_token,
-	)
+	opts := []contrail.KeystoneOption{
+		contrail.WithAuthURL(os_auth_url),
+		contrail.WithTenant(os_tenant_name),
+		contrail.WithCredentials(os_username, os_password),
+		contrail.WithAdminToken(os_token),
+	}
 	if !os_insecure {
-		keystone.AddEncryption(os_ca_file, os_key_file, os_cert_file, os_skip_verify)
+		opts = append(opts, contrail.WithCertificates(
+			os_ca_file, os_key_file, os_cert_file, os_skip_verify))
+	}
+	keystone, err := contrail.NewKeystoneClientWithOptions(opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	err := keystone.Authenticate()
+	err = keystone.Authenticate()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -147,7 +147,7 @@ func networkNameOrIdUsage(argument *flag.FlagSet) func() {
 }
 
 func getNetworkUuidByName(
-	client *contrail.Client, project_name, project_id, name string) (
+	client contrail.ApiClient, project_name, project_id, name string) (
 		string, error) {
 	var fqn []string
 	if len(project_id) > 0 {
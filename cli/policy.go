@@ -131,7 +131,7 @@ func getRulesNetworks(policy *types.NetworkPolicy) (string, string) {
 }
 
 // Summary information of the network policies configured (per project)
-func policyListTerse(client *contrail.Client, projectId string) {
+func policyListTerse(client contrail.ApiClient, projectId string) {
 	poList, err := client.ListDetailByParent(
 		"network-policy", projectId, nil)
 	if err != nil {
@@ -162,7 +162,7 @@ func makeShowTemplate() *template.Template {
 }
 
 // Display the rules associated with a policy as well as the attached networks.
-func policyListDetail(client *contrail.Client, projectId string) {
+func policyListDetail(client contrail.ApiClient, projectId string) {
 	poList, err := client.ListDetailByParent(
 		"network-policy", projectId,
 		[]string{"virtual_network_back_refs"})
@@ -264,7 +264,7 @@ func policyDelete(client *contrail.Client, flagSet *flag.FlagSet) {
 
 // Locate the policy given project name/id and policy name/id.
 func getPolicyObject(
-	client *contrail.Client,
+	client contrail.ApiClient,
 	projectName, projectId, policyNameOrId string) (
 	*types.NetworkPolicy, error) {
 
@@ -292,7 +292,7 @@ func getPolicyObject(
 	return obj.(*types.NetworkPolicy), nil
 }
 
-func getPolicyId(client *contrail.Client,
+func getPolicyId(client contrail.ApiClient,
 	projectName, projectId, policyNameOrId string) (
 	string, error) {
 
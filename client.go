@@ -6,6 +6,7 @@ package contrail
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -13,9 +14,9 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"reflect"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -66,6 +67,9 @@ func (*NopEncryptor) AddEncryption(caFile string, keyFile string, certFile strin
 }
 
 // AddEncryption implements the Encryptor interface for Client.
+// The modification time of certFile and keyFile is checked at each TLS
+// handshake (the files are not watched): a rotated client certificate is
+// used by the connections established after the change.
 func (c *Client) AddEncryption(caFile string, keyFile string, certFile string, insecure bool) error {
 	c.scheme = "https"
 	tlsConfig := &tls.Config{}
@@ -80,24 +84,24 @@ func (c *Client) AddEncryption(caFile string, keyFile string, certFile string, i
 		caCertPool.AppendCertsFromPEM(caCert)
 		tlsConfig.RootCAs = caCertPool
 		if certFile != "" && keyFile != "" {
-			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			reloader, err := newCertReloader(certFile, keyFile)
 			if err != nil {
 				return nil
 			}
-			tlsConfig.Certificates = []tls.Certificate{cert}
+			tlsConfig.GetClientCertificate = reloader.GetClientCertificate
 		} else {
 			tlsConfig.InsecureSkipVerify = true
 		}
 	}
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}
-	c.httpClient.Transport = transport
+	c.tlsConfig = tlsConfig
+	c.updateTransport()
 
 	return nil
 }
 
-// ApiClient interface
+// ApiClient is the interface implemented by Client. Helper functions
+// (e.g. the config package) accept it so that the client can be wrapped,
+// decorated or replaced by a mock (see the mocks package).
 type ApiClient interface {
 	Create(ptr IObject) error
 	Update(ptr IObject) error
@@ -109,10 +113,18 @@ type ApiClient interface {
 	FindByName(typename string, fqn string) (IObject, error)
 	List(typename string) ([]ListResult, error)
 	ListByParent(typename string, parentID string) ([]ListResult, error)
+	Count(typename string) (int, error)
+	CountByParent(typename string, parentID string) (int, error)
 	ListDetail(typename string, fields []string) ([]IObject, error)
 	ListDetailByParent(typename string, parentID string, fields []string) ([]IObject, error)
+	ReadListResult(typename string, result *ListResult) (IObject, error)
+	ReadReference(typename string, ref *Reference) (IObject, error)
+	GetField(obj IObject, field string) error
+	UpdateReference(msg *ReferenceUpdateMsg) error
 }
 
+var _ ApiClient = (*Client)(nil)
+
 // A Client of the OpenContrail API server.
 type Client struct {
 	server     string
@@ -121,6 +133,54 @@ type Client struct {
 	httpClient *http.Client
 	auth       Authenticator
 	encrypt    Encryptor
+
+	// transport is shared with the copies made by WithTimeout.
+	transport      *sharedTransport
+	tlsConfig      *tls.Config
+	connectTimeout time.Duration
+	readTimeout    time.Duration
+	dial           dialOptions
+
+	disableCompression          bool
+	disableHTTP2                bool
+	requestCompressionThreshold int
+
+	listShared       bool
+	listExcludeHrefs bool
+
+	readOnly  bool
+	useNumber bool
+
+	idempotentCreate bool
+
+	// component is sent in the User-Agent header.
+	component string
+
+	// pathPrefix of the API on the server (e.g. a Contrail Command
+	// proxy).
+	pathPrefix     string
+	commandCluster string
+
+	middleware []Middleware
+
+	// serverInfo caches the root document for Supports. It is shared
+	// with the copies made by WithTimeout.
+	serverInfo *serverInfoCache
+
+	// reads collapses concurrent identical reads, when enabled.
+	reads *readGroup
+
+	// responseCache revalidates GET responses, when enabled.
+	responseCache *responseCache
+
+	// breaker fails requests locally while the server is down, when
+	// enabled.
+	breaker *circuitBreaker
+
+	audit AuditSink
+
+	lifetime           *lifetime
+	revokeTokenOnClose bool
 }
 
 type TlsConfig struct {
@@ -136,10 +196,6 @@ type ListResult struct {
 	Uuid    string
 }
 
-var (
-	typeMap TypeMap
-)
-
 // NewClient allocates and initializes a Contrail API client.
 //
 func NewClient(server string, port int) *Client {
@@ -147,12 +203,22 @@ func NewClient(server string, port int) *Client {
 	client.server = server
 	client.port = port
 	client.scheme = "http"
-	client.httpClient = &http.Client{}
+	client.connectTimeout = DefaultConnectTimeout
+	client.httpClient = &http.Client{Timeout: DefaultTimeout}
+	client.updateTransport()
 	client.auth = new(NopAuthenticator)
 	client.encrypt = new(NopEncryptor)
+	client.serverInfo = new(serverInfoCache)
+	client.lifetime = newLifetime()
 	return client
 }
 
+// baseURL returns the URL of the API server root document.
+func (c *Client) baseURL() string {
+	return fmt.Sprintf("%s://%s%s", c.scheme, hostPort(c.server, c.port),
+		c.pathPrefix)
+}
+
 // GetServer retrieves the name or address of the Contrail API server.
 func (c *Client) GetServer() string {
 	return c.server
@@ -169,7 +235,24 @@ func (c *Client) SetEncryptor(encrypt Encryptor) {
 	c.encrypt = encrypt
 }
 
+// SetCompression controls whether responses are requested gzip encoded.
+// Compression is enabled by default.
+func (c *Client) SetCompression(enabled bool) {
+	c.disableCompression = !enabled
+}
+
+// SetRequestCompressionThreshold enables gzip encoding of request bodies
+// of at least size bytes. The API server (or a proxy in front of it) must
+// accept compressed requests. A size of 0 disables request compression,
+// which is the default.
+func (c *Client) SetRequestCompressionThreshold(size int) {
+	c.requestCompressionThreshold = size
+}
+
 func typename(ptr IObject) string {
+	if generic, ok := ptr.(*GenericObject); ok {
+		return generic.typename
+	}
 	name := reflect.TypeOf(ptr).Elem().Name()
 	var buf []rune
 	for i, c := range name {
@@ -185,66 +268,90 @@ func typename(ptr IObject) string {
 	return string(buf)
 }
 
-func (c *Client) httpPost(url string, bodyType string, body io.Reader) (
-	*http.Response, error) {
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		return nil, err
+// httpRequest issues a request to the API server. All requests made by the
+// client go through this method.
+func (c *Client) httpRequest(ctx context.Context, method, url, bodyType string,
+	data []byte) (*http.Response, error) {
+	var body io.Reader
+	compressed := false
+	request := data
+	if data != nil {
+		if c.requestCompressionThreshold > 0 &&
+			len(data) >= c.requestCompressionThreshold {
+			zdata, err := gzipEncode(data)
+			if err != nil {
+				return nil, err
+			}
+			data = zdata
+			compressed = true
+		}
+		body = bytes.NewReader(data)
 	}
-	req.Header.Set("Content-Type", bodyType)
-	err = c.auth.AddAuthentication(req)
+	req, err := http.NewRequestWithContext(ctx, method, c.proxyURL(url), body)
 	if err != nil {
 		return nil, err
 	}
-	return c.httpClient.Do(req)
-}
-
-func (c *Client) httpPut(url string, bodyType string, body io.Reader) (
-	*http.Response, error) {
-	req, err := http.NewRequest("PUT", url, body)
-	if err != nil {
+	c.addCommandHeaders(req)
+	if err := c.addRequestHeaders(req); err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", bodyType)
-	err = c.auth.AddAuthentication(req)
-	if err != nil {
+	if err := c.checkReadOnly(method, req.URL.Path, request); err != nil {
 		return nil, err
 	}
-	return c.httpClient.Do(req)
-}
-
-func (c *Client) httpGet(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	if len(bodyType) > 0 {
+		req.Header.Set("Content-Type", bodyType)
 	}
-	err = c.auth.AddAuthentication(req)
-	if err != nil {
-		return nil, err
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
 	}
-	return c.httpClient.Do(req)
-}
-
-func (c *Client) httpDelete(url string) (*http.Response, error) {
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return nil, err
+	if !c.disableCompression {
+		req.Header.Set("Accept-Encoding", "gzip")
 	}
 	err = c.auth.AddAuthentication(req)
 	if err != nil {
 		return nil, err
 	}
-	return c.httpClient.Do(req)
+	if c.audit != nil && ctx.Value(auditedKey{}) == nil &&
+		mayModify(method, req.URL.Path, request) {
+		return c.auditRequest(req, request)
+	}
+	return c.track(req)
+}
+
+func (c *Client) httpPost(url string, bodyType string, data []byte) (
+	*http.Response, error) {
+	return c.httpRequest(context.Background(), "POST", url, bodyType, data)
+}
+
+func (c *Client) httpGet(url string) (*http.Response, error) {
+	return c.httpRequest(context.Background(), "GET", url, "", nil)
 }
 
 // Create an object in the OpenContrail API server.
 //
 // The object must have been initialized with a name.
 func (c *Client) Create(ptr IObject) error {
+	if err := c.assignCreateUuid(ptr); err != nil {
+		return err
+	}
+	if c.audit == nil {
+		return c.create(ptr)
+	}
+	data, err := marshalObject(ptr)
+	if err != nil {
+		return err
+	}
+	record := c.newAuditRecord(AuditCreate, typename(ptr), ptr, data)
+	return c.audited(record, ptr, func() error {
+		return c.create(ptr)
+	})
+}
+
+func (c *Client) create(ptr IObject) error {
 	xtype := typename(ptr)
-	url := fmt.Sprintf("%s://%s:%d/%ss", c.scheme, c.server, c.port, xtype)
+	url := fmt.Sprintf("%s/%ss", c.baseURL(), xtype)
 
-	objJson, err := json.Marshal(ptr)
+	objJson, err := marshalObject(ptr)
 	if err != nil {
 		return err
 	}
@@ -255,7 +362,7 @@ func (c *Client) Create(ptr IObject) error {
 	}
 	data, err := json.Marshal(msg)
 
-	resp, err := c.httpPost(url, "application/json", bytes.NewReader(data))
+	resp, err := c.httpRequest(auditedContext, "POST", url, "application/json", data)
 	if err != nil {
 		return err
 	}
@@ -265,8 +372,12 @@ func (c *Client) Create(ptr IObject) error {
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode == http.StatusConflict &&
+		c.createdByPreviousAttempt(xtype, ptr) {
+		return nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s: %s", resp.Status, body)
+		return responseError(resp, body)
 	}
 
 	ptr.SetClient(c)
@@ -277,7 +388,10 @@ func (c *Client) Create(ptr IObject) error {
 		return err
 	}
 
-	return json.Unmarshal(m[xtype], ptr)
+	if err := c.unmarshal(m[xtype], ptr); err != nil {
+		return err
+	}
+	return recordSent(ptr, objJson)
 }
 
 // Read an object from the API server.
@@ -285,48 +399,87 @@ func (c *Client) Create(ptr IObject) error {
 // This method retrieves the object properties but not its references to
 // other objects.
 func (c *Client) readObject(typename string, href string) (IObject, error) {
-	url := fmt.Sprintf("%s?exclude_back_refs=true&exclude_children=true",
-		href)
-	resp, err := c.httpGet(url)
+	url := href + "?exclude_back_refs=true&exclude_children=true"
+	if c.reads != nil {
+		body, err := c.readDeduplicated(url, func() ([]byte, error) {
+			return c.readObjectData(url)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return c.decodeObjectResponse(typename, body)
+	}
+	// The response is decoded from a pooled buffer: decoded objects
+	// retain a copy of their input, not the buffer.
+	buf, err := c.readObjectBuffer(url)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer releaseBuffer(buf)
+	return c.decodeObjectResponse(typename, buf.Bytes())
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// decodeObjectResponse decodes the {"<typename>": {...}} response to a
+// read request.
+func (c *Client) decodeObjectResponse(typename string, body []byte) (
+	IObject, error) {
+	content := objectContent(typename, body)
+	if content == nil {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(body, &m); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if content, ok = m[typename]; !ok {
+			return nil, fmt.Errorf("No %s in Response", typename)
+		}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: %s", resp.Status, body)
+	obj := newObjectOfType(typename)
+	if err := c.unmarshal(content, obj); err != nil {
+		return nil, err
 	}
+	obj.SetClient(c)
+	return obj, nil
+}
 
-	var m map[string]*json.RawMessage
-	err = json.Unmarshal(body, &m)
+// readObjectBuffer reads the response to a GET request into a pooled
+// buffer, which the caller releases.
+func (c *Client) readObjectBuffer(url string) (*bytes.Buffer, error) {
+	resp, err := c.httpGet(url)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	content, ok := m[typename]
-	if !ok {
-		return nil, fmt.Errorf("No %s in Response", typename)
+	buf, err := readBuffer(resp)
+	if err != nil {
+		return nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		defer releaseBuffer(buf)
+		return nil, responseError(resp, buf.Bytes())
+	}
+	return buf, nil
+}
 
-	var xtype reflect.Type = typeMap[typename]
-	valueT := reflect.New(xtype)
-	obj := valueT.Interface().(IObject)
-	err = json.Unmarshal(*content, obj)
+func (c *Client) readObjectData(url string) ([]byte, error) {
+	buf, err := c.readObjectBuffer(url)
 	if err != nil {
 		return nil, err
 	}
-	obj.SetClient(c)
-	return obj, err
+	defer releaseBuffer(buf)
+	return append([]byte(nil), buf.Bytes()...), nil
 }
 
 // Given a ListResult, retrieve an object from the API server.
 func (c *Client) ReadListResult(
 	typename string, result *ListResult) (IObject, error) {
-	return c.readObject(typename, result.Href)
+	href := result.Href
+	if href == "" {
+		// Lists requested with exclude_hrefs.
+		href = fmt.Sprintf("%s/%s/%s", c.baseURL(), typename, result.Uuid)
+	}
+	return c.readObject(typename, href)
 }
 
 // Given a link reference, retrieve an object from the API server.
@@ -341,12 +494,29 @@ func (c *Client) ReadReference(
 // There is currently no mechanism to guarantee that the object as not
 // been concurrently modified in the API server.
 // Updates modify properties that have been marked as modified in the local
-// representation.
+// representation. Of those, the properties and references that hold the
+// value last read from (or written to) the API server are not sent.
 func (c *Client) Update(ptr IObject) error {
 	objJson, err := ptr.UpdateObject()
 	if err != nil {
 		return err
 	}
+	if objJson, err = addUnknown(ptr, objJson); err != nil {
+		return err
+	}
+	if objJson, err = minimalUpdate(ptr, objJson); err != nil {
+		return err
+	}
+	if c.audit == nil {
+		return c.update(ptr, objJson)
+	}
+	record := c.newAuditRecord(AuditUpdate, ptr.GetType(), ptr, objJson)
+	return c.audited(record, ptr, func() error {
+		return c.update(ptr, objJson)
+	})
+}
+
+func (c *Client) update(ptr IObject, objJson []byte) error {
 	var rawJson json.RawMessage = objJson
 	msg := map[string]*json.RawMessage{
 		ptr.GetType(): &rawJson,
@@ -356,8 +526,8 @@ func (c *Client) Update(ptr IObject) error {
 		return err
 	}
 
-	resp, err := c.httpPut(ptr.GetHref(), "application/json",
-		bytes.NewReader(data))
+	resp, err := c.httpRequest(auditedContext, "PUT", ptr.GetHref(),
+		"application/json", data)
 	if err != nil {
 		return err
 	}
@@ -368,7 +538,10 @@ func (c *Client) Update(ptr IObject) error {
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("%s: %s", resp.Status, body)
+		return responseError(resp, body)
+	}
+	if err := recordSent(ptr, objJson); err != nil {
+		return err
 	}
 
 	err = ptr.UpdateReferences()
@@ -382,8 +555,19 @@ func (c *Client) Update(ptr IObject) error {
 
 // DeleteByUuid deletes the specified object.
 func (c *Client) DeleteByUuid(typename, uuid string) error {
-	url := fmt.Sprintf("%s://%s:%d/%s/%s", c.scheme, c.server, c.port, typename, uuid)
-	resp, err := c.httpDelete(url)
+	if c.audit == nil {
+		return c.deleteByUuid(typename, uuid)
+	}
+	record := c.newAuditRecord(AuditDelete, typename, nil, nil)
+	record.Uuid = uuid
+	return c.audited(record, nil, func() error {
+		return c.deleteByUuid(typename, uuid)
+	})
+}
+
+func (c *Client) deleteByUuid(typename, uuid string) error {
+	url := fmt.Sprintf("%s/%s/%s", c.baseURL(), typename, uuid)
+	resp, err := c.httpRequest(auditedContext, "DELETE", url, "", nil)
 	if err != nil {
 		return err
 	}
@@ -394,7 +578,7 @@ func (c *Client) DeleteByUuid(typename, uuid string) error {
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("%s: %s", resp.Status, body)
+		return responseError(resp, body)
 	}
 
 	return nil
@@ -402,7 +586,17 @@ func (c *Client) DeleteByUuid(typename, uuid string) error {
 
 // Delete an object from the API server.
 func (c *Client) Delete(ptr IObject) error {
-	resp, err := c.httpDelete(ptr.GetHref())
+	if c.audit == nil {
+		return c.delete(ptr)
+	}
+	record := c.newAuditRecord(AuditDelete, ptr.GetType(), ptr, nil)
+	return c.audited(record, nil, func() error {
+		return c.delete(ptr)
+	})
+}
+
+func (c *Client) delete(ptr IObject) error {
+	resp, err := c.httpRequest(auditedContext, "DELETE", ptr.GetHref(), "", nil)
 	if err != nil {
 		return err
 	}
@@ -413,7 +607,7 @@ func (c *Client) Delete(ptr IObject) error {
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("%s: %s", resp.Status, body)
+		return responseError(resp, body)
 	}
 
 	return nil
@@ -421,14 +615,13 @@ func (c *Client) Delete(ptr IObject) error {
 
 // FindByUuid reads an object identified by UUID.
 func (c *Client) FindByUuid(typename string, uuid string) (IObject, error) {
-	url := fmt.Sprintf("%s://%s:%d/%s/%s", c.scheme, c.server, c.port,
-		typename, uuid)
+	url := fmt.Sprintf("%s/%s/%s", c.baseURL(), typename, uuid)
 	return c.readObject(typename, url)
 }
 
 // UuidByName returns the UUID of an object as identified by its fully qualified name.
 func (c *Client) UuidByName(typename string, fqn string) (string, error) {
-	url := fmt.Sprintf("%s://%s:%d/fqname-to-id", c.scheme, c.server, c.port)
+	url := c.baseURL() + "/fqname-to-id"
 	request := struct {
 		Typename string   `json:"type"`
 		Fq_name  []string `json:"fq_name"`
@@ -440,19 +633,25 @@ func (c *Client) UuidByName(typename string, fqn string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	resp, err := c.httpPost(url, "application/json", bytes.NewReader(data))
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	body, err := c.readDeduplicated(url+" "+string(data), func() ([]byte, error) {
+		resp, err := c.httpPost(url, "application/json", data)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, responseError(resp, body)
+		}
+		return body, nil
+	})
 	if err != nil {
 		return "", err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%s: %s", resp.Status, body)
-	}
 
 	m := struct {
 		Uuid string
@@ -477,8 +676,8 @@ func (c *Client) FQNameByUuid(uuid string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	url := fmt.Sprintf("%s://%s:%d/id-to-fqname", c.scheme, c.server, c.port)
-	resp, err := c.httpPost(url, "application/json", bytes.NewReader(data))
+	url := c.baseURL() + "/id-to-fqname"
+	resp, err := c.httpPost(url, "application/json", data)
 	if err != nil {
 		return nil, err
 	}
@@ -489,7 +688,7 @@ func (c *Client) FQNameByUuid(uuid string) ([]string, error) {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: %s", resp.Status, body)
+		return nil, responseError(resp, body)
 	}
 
 	var response struct {
@@ -507,53 +706,28 @@ func (c *Client) FindByName(typename string, fqn string) (IObject, error) {
 	if err != nil {
 		return nil, err
 	}
-	href := fmt.Sprintf(
-		"%s://%s:%d/%s/%s", c.scheme, c.server, c.port, typename, uuid)
+	href := fmt.Sprintf("%s/%s/%s", c.baseURL(), typename, uuid)
 	return c.readObject(typename, href)
 }
 
+// SetListShared controls whether list requests include the objects that
+// other tenants share with the caller (RBAC shared objects).
+func (c *Client) SetListShared(enabled bool) {
+	c.listShared = enabled
+}
+
+// SetListExcludeHrefs controls whether list responses omit the href of each
+// object, which reduces their size. The client computes the hrefs it needs
+// from the object uuids.
+func (c *Client) SetListExcludeHrefs(enabled bool) {
+	c.listExcludeHrefs = enabled
+}
+
 // ListByParent retrieves the identifiers of the objects of a specific type that are
 // descendents of a specific object.
 func (c *Client) ListByParent(
 	typename string, parentID string) ([]ListResult, error) {
-	var values url.Values
-	values = make(url.Values, 0)
-	if len(parentID) > 0 {
-		values.Add("parent_id", parentID)
-	}
-
-	url := fmt.Sprintf("%s://%s:%d/%ss", c.scheme, c.server, c.port, typename)
-	if len(values) > 0 {
-		url += fmt.Sprintf("?%s", values.Encode())
-	}
-	resp, err := c.httpGet(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: %s", resp.Status, body)
-	}
-
-	var m map[string]*json.RawMessage
-	err = json.Unmarshal(body, &m)
-	if err != nil {
-		return nil, err
-	}
-
-	content, ok := m[typename+"s"]
-	if !ok {
-		return nil, fmt.Errorf("No %ss in Response", typename)
-	}
-	var rlist []ListResult
-	err = json.Unmarshal(*content, &rlist)
-	return rlist, err
+	return c.ListWithOptions(typename, parentOptions(parentID, nil)...)
 }
 
 // List retrieves the identifiers of all objects of a given type.
@@ -561,80 +735,23 @@ func (c *Client) List(typename string) ([]ListResult, error) {
 	return c.ListByParent(typename, "")
 }
 
+// CountByParent returns the number of objects of a specific type that are
+// descendents of a specific object, without retrieving the objects.
+func (c *Client) CountByParent(typename string, parentID string) (int, error) {
+	return c.CountWithOptions(typename, parentOptions(parentID, nil)...)
+}
+
+// Count returns the number of objects of a given type.
+func (c *Client) Count(typename string) (int, error) {
+	return c.CountByParent(typename, "")
+}
+
 // ListDetailByParent reads all the objects of a given type that are descendents of the
 // specified parent object.
 func (c *Client) ListDetailByParent(
 	typename string, parentID string, fields []string) (
 	[]IObject, error) {
-	var values url.Values
-	values = make(url.Values, 0)
-	if len(parentID) > 0 {
-		values.Add("parent_id", parentID)
-	}
-	for _, field := range fields {
-		values.Add("fields", field)
-	}
-	values.Add("detail", "true")
-
-	url := fmt.Sprintf("%s://%s:%d/%ss?%s", c.scheme, c.server, c.port, typename, values.Encode())
-	resp, err := c.httpGet(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: %s", resp.Status, body)
-	}
-
-	var m map[string]*json.RawMessage
-	err = json.Unmarshal(body, &m)
-	if err != nil {
-		return nil, err
-	}
-
-	content, ok := m[typename+"s"]
-	if !ok {
-		return nil, fmt.Errorf("No %ss in Response", typename)
-	}
-
-	var elements []*json.RawMessage
-	err = json.Unmarshal(*content, &elements)
-	if err != nil {
-		return nil, err
-	}
-
-	var result []IObject
-	var xtype reflect.Type = typeMap[typename]
-
-	for _, element := range elements {
-		var item map[string]*json.RawMessage
-		err = json.Unmarshal(*element, &item)
-		if err != nil {
-			return nil, err
-		}
-
-		content, ok := item[typename]
-		if !ok {
-			return nil, fmt.Errorf("No %s in element", typename)
-		}
-
-		valueT := reflect.New(xtype)
-		obj := valueT.Interface().(IObject)
-		err = json.Unmarshal(*content, obj)
-		if err != nil {
-			return nil, err
-		}
-		obj.SetClient(c)
-		result = append(result, obj)
-	}
-
-	return result, nil
+	return c.ListDetailWithOptions(typename, parentOptions(parentID, fields)...)
 }
 
 // ListDetail reads all the objects of a specific type.
@@ -659,7 +776,7 @@ func (c *Client) GetField(obj IObject, field string) error {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s: %s", resp.Status, body)
+		return responseError(resp, body)
 	}
 
 	var m map[string]json.RawMessage
@@ -669,7 +786,20 @@ func (c *Client) GetField(obj IObject, field string) error {
 		return err
 	}
 
-	return json.Unmarshal(m[obj.GetType()], obj)
+	// The response only holds the requested field; keep the unknown
+	// and received fields recorded when the object was read.
+	base, ok := obj.(baseObject)
+	if !ok {
+		return c.unmarshal(m[obj.GetType()], obj)
+	}
+	b := base.objectBase()
+	unknown, received := b.UnknownFields(), b.received
+	if err := c.unmarshal(m[obj.GetType()], obj); err != nil {
+		return err
+	}
+	b.mergeUnknownFields(unknown)
+	b.received = mergeFields(b.received, received)
+	return nil
 }
 
 // UpdateReference sends a reference update message to the API server.
@@ -679,8 +809,8 @@ func (c *Client) UpdateReference(msg *ReferenceUpdateMsg) error {
 	if err != nil {
 		return err
 	}
-	url := fmt.Sprintf("%s://%s:%d/ref-update", c.scheme, c.server, c.port)
-	resp, err := c.httpPost(url, "application/json", bytes.NewReader(data))
+	url := c.baseURL() + "/ref-update"
+	resp, err := c.httpPost(url, "application/json", data)
 	if err != nil {
 		return err
 	}
@@ -691,14 +821,17 @@ func (c *Client) UpdateReference(msg *ReferenceUpdateMsg) error {
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("%s: %s", resp.Status, body)
+		return responseError(resp, body)
 	}
 
 	return nil
 }
 
-// RegisterTypeMap is used by the generated types library to register the list of known
-// object types.
-func RegisterTypeMap(m TypeMap) {
-	typeMap = m
+// NewObject allocates a transient object of the specified registered type.
+func NewObject(typename string) (IObject, error) {
+	xtype, ok := registeredTypes()[typename]
+	if !ok {
+		return nil, fmt.Errorf("Unknown type %s", typename)
+	}
+	return reflect.New(xtype).Interface().(IObject), nil
 }
@@ -0,0 +1,309 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestNetwork is a minimal implementation of a generated type.
+type TestNetwork struct {
+	ObjectBase
+	display_name        string
+	test_project_refs   ReferenceList
+	test_port_back_refs ReferenceList
+}
+
+func (*TestNetwork) GetType() string {
+	return "test-network"
+}
+func (*TestNetwork) GetDefaultParent() []string {
+	return []string{"default-project"}
+}
+func (*TestNetwork) GetDefaultParentType() string {
+	return "test-project"
+}
+func (obj *TestNetwork) SetName(name string) {
+	obj.VSetName(obj, name)
+}
+func (obj *TestNetwork) UpdateObject() ([]byte, error) {
+	return obj.MarshalJSON()
+}
+func (*TestNetwork) UpdateReferences() error {
+	return nil
+}
+func (*TestNetwork) UpdateDone() {
+}
+
+func (obj *TestNetwork) MarshalJSON() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalCommon(m); err != nil {
+		return nil, err
+	}
+	if obj.display_name != "" {
+		value, _ := json.Marshal(obj.display_name)
+		raw := json.RawMessage(value)
+		m["display_name"] = &raw
+	}
+	if len(obj.test_project_refs) > 0 {
+		value, _ := json.Marshal(obj.test_project_refs)
+		raw := json.RawMessage(value)
+		m["test_project_refs"] = &raw
+	}
+	obj.MarshalUnknown(m)
+	return json.Marshal(m)
+}
+
+func (obj *TestNetwork) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	obj.UnmarshalUnknown(obj, m)
+	if value, ok := m["display_name"]; ok {
+		if err := json.Unmarshal(value, &obj.display_name); err != nil {
+			return err
+		}
+	}
+	if value, ok := m["test_project_refs"]; ok {
+		if err := json.Unmarshal(value, &obj.test_project_refs); err != nil {
+			return err
+		}
+	}
+	if value, ok := m["test_port_back_refs"]; ok {
+		if err := json.Unmarshal(value, &obj.test_port_back_refs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (obj *TestNetwork) UnmarshalField(name string, value []byte) (bool, error) {
+	var err error
+	switch name {
+	case "display_name":
+		obj.display_name, err = DecodeString(value)
+	case "test_project_refs":
+		obj.test_project_refs, err = DecodeReferenceList(value)
+	case "test_port_back_refs":
+		obj.test_port_back_refs, err = DecodeReferenceList(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func registerTestTypes() {
+	RegisterTypeMap(TypeMap{
+		"test-network": reflect.TypeOf(TestNetwork{}),
+	})
+}
+
+func newTestServer(t testing.TB, handler http.HandlerFunc) (*httptest.Server, *Client) {
+	registerTestTypes()
+	server := httptest.NewServer(handler)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	return server, NewClient(host, portNum)
+}
+
+func listDetailResponse(count int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"test-networks": [`)
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"test-network": {"fq_name": ["default-project", "net%d"], "uuid": "uuid-%d", "name": "net%d", "href": "http://localhost:8082/test-network/uuid-%d", "display_name": "Network %d", "test_project_refs": [{"to": ["default-project"], "uuid": "p1"}]}}`, i, i, i, i, i)
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes()
+}
+
+func TestListDetail(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/test-networks" || r.URL.Query().Get("detail") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(listDetailResponse(3))
+	})
+	defer server.Close()
+
+	objList, err := client.ListDetail("test-network", []string{"test_project_refs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objList) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objList))
+	}
+	net := objList[2].(*TestNetwork)
+	if net.GetName() != "net2" || net.display_name != "Network 2" || net.IsTransient() {
+		t.Errorf("unexpected object %+v", net)
+	}
+	if len(net.test_project_refs) != 1 || net.test_project_refs[0].Uuid != "p1" {
+		t.Errorf("unexpected refs %+v", net.test_project_refs)
+	}
+}
+
+func TestCount(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/test-networks" || query.Get("count") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		if query.Get("parent_id") == "p1" {
+			fmt.Fprint(w, `{"test-networks": {"count": 2}}`)
+			return
+		}
+		fmt.Fprint(w, `{"test-networks": {"count": 7}}`)
+	})
+	defer server.Close()
+
+	count, err := client.Count("test-network")
+	if err != nil || count != 7 {
+		t.Errorf("expected 7, got %d (%v)", count, err)
+	}
+	count, err = client.CountByParent("test-network", "p1")
+	if err != nil || count != 2 {
+		t.Errorf("expected 2, got %d (%v)", count, err)
+	}
+	if _, err := client.Count("test-port"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestListFlags(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path == "/test-network/uuid-1":
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net1"], "uuid": "uuid-1", "name": "net1"}}`)
+		case r.URL.Path != "/test-networks" || query.Get("shared") != "true" ||
+			query.Get("exclude_hrefs") != "true":
+			http.NotFound(w, r)
+		case query.Get("detail") == "true":
+			fmt.Fprint(w, `{"test-networks": [{"test-network": {"fq_name": ["default-project", "net1"], "uuid": "uuid-1", "name": "net1"}}]}`)
+		default:
+			fmt.Fprint(w, `{"test-networks": [{"fq_name": ["default-project", "net1"], "uuid": "uuid-1"}]}`)
+		}
+	})
+	defer server.Close()
+	client.SetListShared(true)
+	client.SetListExcludeHrefs(true)
+
+	results, err := client.List("test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Href != "" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	obj, err := client.ReadListResult("test-network", &results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.GetName() != "net1" {
+		t.Errorf("unexpected object %+v", obj)
+	}
+
+	objList, err := client.ListDetail("test-network", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objList) != 1 || !strings.HasSuffix(objList[0].GetHref(), "/test-network/uuid-1") {
+		t.Errorf("unexpected objects %+v", objList)
+	}
+}
+
+func TestDecodeListDetailErrors(t *testing.T) {
+	registerTestTypes()
+	inputs := []string{
+		`{}`,
+		`{"test-networks": [{"foo": {}}]}`,
+		`{"test-networks": [{"test-network": {"uuid": "x"`,
+		`[]`,
+	}
+	for _, input := range inputs {
+		_, err := decodeListDetail("test-network", json.NewDecoder(strings.NewReader(input)))
+		if err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func BenchmarkDecodeListDetail(b *testing.B) {
+	registerTestTypes()
+	data := listDetailResponse(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeListDetail("test-network", json.NewDecoder(bytes.NewReader(data))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	var requestEncoding string
+	var requestBody []byte
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestEncoding = r.Header.Get("Content-Encoding")
+		if requestEncoding == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			requestBody, _ = ioutil.ReadAll(reader)
+		}
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.Write(listDetailResponse(2))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		writer.Write(listDetailResponse(2))
+		writer.Close()
+	})
+	defer server.Close()
+
+	objList, err := client.ListDetail("test-network", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objList) != 2 {
+		t.Errorf("expected 2 objects, got %d", len(objList))
+	}
+
+	client.SetCompression(false)
+	if _, err := client.ListDetail("test-network", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetRequestCompressionThreshold(1)
+	client.FQNameByUuid("uuid-0")
+	if requestEncoding != "gzip" || string(requestBody) != `{"uuid":"uuid-0"}` {
+		t.Errorf("unexpected request %s: %s", requestEncoding, requestBody)
+	}
+}
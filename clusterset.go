@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ClusterErrors reports the failures of a broadcast operation, by cluster
+// name.
+type ClusterErrors map[string]error
+
+func (e ClusterErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := make([]string, len(names))
+	for i, name := range names {
+		messages[i] = name + ": " + e[name].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ClusterSet manages the clients of multiple API servers (e.g. one per
+// region). Each client is configured independently, with its own
+// authentication and TLS settings. Writes are routed to a cluster by name;
+// reads can be broadcast to all clusters.
+type ClusterSet struct {
+	mutex   sync.RWMutex
+	clients map[string]ApiClient
+}
+
+// NewClusterSet allocates an empty ClusterSet.
+func NewClusterSet() *ClusterSet {
+	return &ClusterSet{clients: make(map[string]ApiClient)}
+}
+
+// Add registers the client of a cluster.
+func (s *ClusterSet) Add(name string, client ApiClient) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.clients[name]; exists {
+		return fmt.Errorf("Cluster %s already registered", name)
+	}
+	s.clients[name] = client
+	return nil
+}
+
+// Remove unregisters a cluster.
+func (s *ClusterSet) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.clients, name)
+}
+
+// Names returns the sorted names of the registered clusters.
+func (s *ClusterSet) Names() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	names := make([]string, 0, len(s.clients))
+	for name := range s.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Client returns the client of a cluster.
+func (s *ClusterSet) Client(name string) (ApiClient, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	client, ok := s.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown cluster %s", name)
+	}
+	return client, nil
+}
+
+// Create creates an object in the named cluster.
+func (s *ClusterSet) Create(cluster string, obj IObject) error {
+	client, err := s.Client(cluster)
+	if err != nil {
+		return err
+	}
+	return client.Create(obj)
+}
+
+// Update updates an object in the named cluster.
+func (s *ClusterSet) Update(cluster string, obj IObject) error {
+	client, err := s.Client(cluster)
+	if err != nil {
+		return err
+	}
+	return client.Update(obj)
+}
+
+// Delete deletes an object from the named cluster.
+func (s *ClusterSet) Delete(cluster string, obj IObject) error {
+	client, err := s.Client(cluster)
+	if err != nil {
+		return err
+	}
+	return client.Delete(obj)
+}
+
+// ForEach invokes fn concurrently for each cluster. The failures are
+// returned as ClusterErrors.
+func (s *ClusterSet) ForEach(fn func(cluster string, client ApiClient) error) error {
+	s.mutex.RLock()
+	clients := make(map[string]ApiClient, len(s.clients))
+	for name, client := range s.clients {
+		clients[name] = client
+	}
+	s.mutex.RUnlock()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(ClusterErrors)
+	for name, client := range clients {
+		wg.Add(1)
+		go func(name string, client ApiClient) {
+			defer wg.Done()
+			if err := fn(name, client); err != nil {
+				mutex.Lock()
+				errs[name] = err
+				mutex.Unlock()
+			}
+		}(name, client)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// FindByName looks up an object in all clusters. The result contains the
+// clusters where the object exists. Lookup failures other than "not found"
+// are returned as ClusterErrors, along with the partial result.
+func (s *ClusterSet) FindByName(typename, fqn string) (map[string]IObject, error) {
+	var mutex sync.Mutex
+	result := make(map[string]IObject)
+	err := s.ForEach(func(cluster string, client ApiClient) error {
+		obj, err := client.FindByName(typename, fqn)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "404") {
+				return nil
+			}
+			return err
+		}
+		mutex.Lock()
+		result[cluster] = obj
+		mutex.Unlock()
+		return nil
+	})
+	return result, err
+}
+
+// ListDetail lists the objects of a type in all clusters. Failures are
+// returned as ClusterErrors, along with the partial result.
+func (s *ClusterSet) ListDetail(typename string, fields []string) (
+	map[string][]IObject, error) {
+	var mutex sync.Mutex
+	result := make(map[string][]IObject)
+	err := s.ForEach(func(cluster string, client ApiClient) error {
+		objects, err := client.ListDetail(typename, fields)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		result[cluster] = objects
+		mutex.Unlock()
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// clusterClient is a single object store.
+type clusterClient struct {
+	ApiClient
+	objects map[string]IObject
+	err     error
+}
+
+func (c *clusterClient) Create(obj IObject) error {
+	c.objects[obj.GetName()] = obj
+	return nil
+}
+
+func (c *clusterClient) FindByName(typename, fqn string) (IObject, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	obj, ok := c.objects[fqn]
+	if !ok {
+		return nil, fmt.Errorf("404 Not Found: %s", fqn)
+	}
+	return obj, nil
+}
+
+func (c *clusterClient) ListDetail(typename string, fields []string) ([]IObject, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var objects []IObject
+	for _, obj := range c.objects {
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func TestClusterSet(t *testing.T) {
+	set := NewClusterSet()
+	east := &clusterClient{objects: make(map[string]IObject)}
+	west := &clusterClient{objects: make(map[string]IObject)}
+	if err := set.Add("east", east); err != nil {
+		t.Fatal(err)
+	}
+	set.Add("west", west)
+	if err := set.Add("east", west); err == nil {
+		t.Error("expected error on duplicate cluster")
+	}
+	if names := set.Names(); len(names) != 2 || names[0] != "east" {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	network := new(TestNetwork)
+	network.SetName("net")
+	if err := set.Create("west", network); err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Create("north", network); err == nil {
+		t.Error("expected error for unknown cluster")
+	}
+
+	found, err := set.FindByName("test-network", "net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found["west"] != network {
+		t.Errorf("unexpected result %v", found)
+	}
+
+	east.err = errors.New("connection refused")
+	lists, err := set.ListDetail("test-network", nil)
+	errs, ok := err.(ClusterErrors)
+	if !ok || len(errs) != 1 || errs["east"] == nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(lists) != 1 || len(lists["west"]) != 1 {
+		t.Errorf("unexpected result %v", lists)
+	}
+	if err.Error() != "east: connection refused" {
+		t.Errorf("unexpected message %q", err)
+	}
+
+	set.Remove("east")
+	if _, err := set.ListDetail("test-network", nil); err != nil {
+		t.Error(err)
+	}
+}
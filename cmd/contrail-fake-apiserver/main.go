@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// contrail-fake-apiserver serves the REST interface of the API server
+// from memory, validated against the schema of the generated types, for
+// CI pipelines that can't run a Contrail cluster:
+//
+//	services:
+//	  contrail-api:
+//	    image: contrail-fake-apiserver
+//	    command: ["-listen", ":8082"]
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/fakeserver"
+	_ "github.com/Juniper/contrail-go-api/types"
+)
+
+func main() {
+	listen := flag.String("listen", ":8082",
+		"Address to listen on, or unix:<path> for a unix socket")
+	verbose := flag.Bool("v", false, "Log each request")
+	flag.Parse()
+
+	var handler http.Handler = fakeserver.New(contrail.Schema())
+	if *verbose {
+		next := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("%s %s", r.Method, r.URL)
+			next.ServeHTTP(w, r)
+		})
+	}
+	network, address := "tcp", *listen
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+		os.Remove(address)
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Serving the Contrail API on %s", *listen)
+	log.Fatal(http.Serve(listener, handler))
+}
@@ -0,0 +1,50 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// contrail-types-subset writes a copy of the generated types package that
+// contains only the listed object types and the declarations they depend
+// on, for programs that use a handful of types and shouldn't compile and
+// link the complete schema:
+//
+//	contrail-types-subset -src $GOPATH/src/github.com/Juniper/contrail-go-api/types \
+//	    -dst ./internal/types -types virtual-network,network-ipam,project
+//
+// The methods and functions that refer to the object types that are
+// left out (e.g. VirtualNetwork.AddNetworkPolicy without network-policy)
+// are removed; the reference lists remain available through the generic
+// accessors of the client.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	src := flag.String("src", "", "Directory of the generated types package")
+	dst := flag.String("dst", "", "Directory to write the subset to")
+	types := flag.String("types", "",
+		"Comma-separated object types to keep (e.g. virtual-network or VirtualNetwork)")
+	flag.Parse()
+	if *src == "" || *dst == "" || *types == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pkg, err := parsePackage(*src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := pkg.subset(strings.Split(*types, ",")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := pkg.write(*dst); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,457 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// decl is a top-level declaration of the types package, other than the
+// imports.
+type decl struct {
+	node ast.Decl
+	// The names declared; methods are named Type.Method.
+	names []string
+	// object is set for the types that embed contrail.ObjectBase.
+	object bool
+	// The top-level names used by the declaration.
+	refs map[string]bool
+	keep bool
+}
+
+type typesPackage struct {
+	fset   *token.FileSet
+	files  map[string]*ast.File
+	decls  map[ast.Decl]*decl
+	byName map[string]*decl
+}
+
+func parsePackage(dir string) (*typesPackage, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	p := &typesPackage{
+		fset:   token.NewFileSet(),
+		files:  make(map[string]*ast.File),
+		decls:  make(map[ast.Decl]*decl),
+		byName: make(map[string]*decl),
+	}
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(p.fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		p.files[filepath.Base(filename)] = file
+		for _, node := range file.Decls {
+			if gen, ok := node.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+			d := &decl{
+				node:   node,
+				names:  declaredNames(node),
+				object: isObjectType(node),
+			}
+			p.decls[node] = d
+			for _, name := range d.names {
+				p.byName[name] = d
+			}
+		}
+	}
+	if len(p.files) == 0 {
+		return nil, fmt.Errorf("No Go files in %s", dir)
+	}
+	return p, nil
+}
+
+func declaredNames(node ast.Decl) []string {
+	var names []string
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		if node.Recv != nil {
+			return []string{receiverType(node) + "." + node.Name.Name}
+		}
+		if node.Name.Name != "init" {
+			names = append(names, node.Name.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range node.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					if name.Name != "_" {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func receiverType(fn *ast.FuncDecl) string {
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isObjectType reports whether node declares a struct that embeds
+// contrail.ObjectBase.
+func isObjectType(node ast.Decl) bool {
+	gen, ok := node.(*ast.GenDecl)
+	if !ok || gen.Tok != token.TYPE || len(gen.Specs) != 1 {
+		return false
+	}
+	st, ok := gen.Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	if !ok {
+		return false
+	}
+	for _, field := range st.Fields.List {
+		if len(field.Names) != 0 {
+			continue
+		}
+		switch t := field.Type.(type) {
+		case *ast.SelectorExpr:
+			if t.Sel.Name == "ObjectBase" {
+				return true
+			}
+		case *ast.Ident:
+			if t.Name == "ObjectBase" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// references returns the top-level names of the package used by d.
+func (p *typesPackage) references(d *decl) map[string]bool {
+	refs := make(map[string]bool)
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Field:
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				ast.Inspect(n.Recv, visit)
+			}
+			ast.Inspect(n.Type, visit)
+			if n.Body != nil {
+				ast.Inspect(n.Body, visit)
+			}
+			return false
+		case *ast.Ident:
+			if n.Obj != nil && n.Obj.Decl != nil {
+				// Declared within d: a local name, or d itself.
+				if pos := n.Obj.Decl.(ast.Node).Pos(); pos >= d.node.Pos() && pos < d.node.End() {
+					return false
+				}
+			}
+			if p.byName[n.Name] != nil {
+				refs[n.Name] = true
+			}
+		}
+		return true
+	}
+	ast.Inspect(d.node, visit)
+	for _, name := range d.names {
+		delete(refs, name)
+	}
+	return refs
+}
+
+// goTypeName converts an object type name (virtual-network) to the name
+// of the generated type (VirtualNetwork).
+func goTypeName(name string) string {
+	if !strings.ContainsAny(name, "-_") && name != "" && unicode.IsUpper(rune(name[0])) {
+		return name
+	}
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// subset selects the object types listed and the declarations that they
+// use or that use them, leaving out those that use other object types.
+func (p *typesPackage) subset(typenames []string) error {
+	keep := make(map[string]bool)
+	for _, typename := range typenames {
+		name := goTypeName(strings.TrimSpace(typename))
+		if d := p.byName[name]; d == nil || !d.object {
+			return fmt.Errorf("Unknown object type %s", typename)
+		}
+		keep[name] = true
+	}
+	omitted := func(n ast.Node) bool {
+		found := false
+		ast.Inspect(n, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				if d := p.byName[ident.Name]; d != nil && d.object && !keep[ident.Name] {
+					found = true
+				}
+			}
+			return !found
+		})
+		return found
+	}
+
+	excluded := make(map[*decl]bool)
+	for _, d := range p.decls {
+		// The entries of the type map for the object types left out.
+		ast.Inspect(d.node, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.CompositeLit); ok {
+				elts := lit.Elts[:0]
+				for _, elt := range lit.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if _, ok := kv.Key.(*ast.BasicLit); ok && omitted(kv.Value) {
+							continue
+						}
+					}
+					elts = append(elts, elt)
+				}
+				lit.Elts = elts
+			}
+			return true
+		})
+		d.refs = p.references(d)
+		if d.object && !keep[d.names[0]] {
+			excluded[d] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, d := range p.decls {
+			if excluded[d] {
+				continue
+			}
+			for ref := range d.refs {
+				if excluded[p.byName[ref]] {
+					excluded[d] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var queue []*decl
+	mark := func(d *decl) {
+		if !d.keep && !excluded[d] {
+			d.keep = true
+			queue = append(queue, d)
+		}
+	}
+	for name := range keep {
+		d := p.byName[name]
+		if excluded[d] {
+			return fmt.Errorf("%s uses object types that are left out", name)
+		}
+		mark(d)
+	}
+	methods := make(map[string][]*decl)
+	for _, d := range p.decls {
+		if fn, ok := d.node.(*ast.FuncDecl); ok && fn.Recv != nil {
+			recv := receiverType(fn)
+			methods[recv] = append(methods[recv], d)
+		}
+		// init functions, and the functions that use the object types
+		// selected (e.g. VirtualNetworkByName).
+		if len(d.names) == 0 {
+			mark(d)
+		}
+		for ref := range d.refs {
+			if keep[ref] {
+				mark(d)
+			}
+		}
+	}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		for ref := range d.refs {
+			mark(p.byName[ref])
+		}
+		for _, name := range d.names {
+			for _, method := range methods[name] {
+				mark(method)
+			}
+		}
+	}
+	return nil
+}
+
+// write formats the files that hold declarations selected into dir.
+func (p *typesPackage) write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for filename, file := range p.files {
+		original := packagesUsed(file, p.byName)
+		var decls, removed []ast.Decl
+		selected := false
+		for _, node := range file.Decls {
+			if d := p.decls[node]; d != nil {
+				if !d.keep {
+					removed = append(removed, node)
+					continue
+				}
+				selected = true
+			}
+			decls = append(decls, node)
+		}
+		if !selected {
+			continue
+		}
+		file.Decls = decls
+		file.Comments = p.removeComments(file.Comments, removed)
+		removeUnusedImports(file, original, packagesUsed(file, p.byName))
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, p.fset, file); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, filename), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeComments returns the comments that are not part of the
+// declarations removed.
+func (p *typesPackage) removeComments(comments []*ast.CommentGroup, removed []ast.Decl) []*ast.CommentGroup {
+	var result []*ast.CommentGroup
+	for _, cg := range comments {
+		inside := false
+		for _, node := range removed {
+			start, end := node.Pos(), node.End()
+			switch node := node.(type) {
+			case *ast.FuncDecl:
+				if node.Doc != nil {
+					start = node.Doc.Pos()
+				}
+			case *ast.GenDecl:
+				if node.Doc != nil {
+					start = node.Doc.Pos()
+				}
+			}
+			if cg.Pos() >= start && (cg.End() <= end ||
+				p.fset.Position(cg.Pos()).Line == p.fset.Position(end).Line) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			result = append(result, cg)
+		}
+	}
+	return result
+}
+
+// packagesUsed returns the names of the imported packages used in file.
+func packagesUsed(file *ast.File, byName map[string]*decl) map[string]bool {
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil && byName[ident.Name] == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// removeUnusedImports removes the imports that are no longer used. The
+// name of an imported package is taken to be the last element of its
+// path; when that is not the case (contrail-go-api), it is the package
+// name used in the original file that matches no other import.
+func removeUnusedImports(file *ast.File, original, used map[string]bool) {
+	names := make(map[*ast.ImportSpec]string)
+	var unknown []*ast.ImportSpec
+	matched := make(map[string]bool)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if original[name] || name == "_" || name == "." {
+			names[spec] = name
+			matched[name] = true
+		} else {
+			unknown = append(unknown, spec)
+		}
+	}
+	var unmatched []string
+	for name := range original {
+		if !matched[name] {
+			unmatched = append(unmatched, name)
+		}
+	}
+	if len(unknown) == 1 && len(unmatched) == 1 {
+		names[unknown[0]] = unmatched[0]
+	}
+
+	var decls []ast.Decl
+	for _, node := range file.Decls {
+		gen, ok := node.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			decls = append(decls, node)
+			continue
+		}
+		var specs []ast.Spec
+		for _, spec := range gen.Specs {
+			name, known := names[spec.(*ast.ImportSpec)]
+			if !known || name == "_" || name == "." || used[name] {
+				specs = append(specs, spec)
+			}
+		}
+		if len(specs) == 0 {
+			continue
+		}
+		gen.Specs = specs
+		decls = append(decls, gen)
+	}
+	file.Decls = decls
+	var imports []*ast.ImportSpec
+	for _, spec := range file.Imports {
+		if name, known := names[spec]; !known || name == "_" || name == "." || used[name] {
+			imports = append(imports, spec)
+		}
+	}
+	file.Imports = imports
+}
@@ -0,0 +1,269 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The generated code, reduced to what the subset depends on.
+var generatedFiles = map[string]string{
+	"types.go": `package types
+
+import (
+	"reflect"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// TypeMap maps the object type names to the generated types.
+var TypeMap = map[string]reflect.Type{
+	"network-policy":  reflect.TypeOf(NetworkPolicy{}),
+	"project":         reflect.TypeOf(Project{}),
+	"virtual-network": reflect.TypeOf(VirtualNetwork{}),
+}
+
+func init() {
+	contrail.RegisterTypeMap(TypeMap)
+}
+`,
+	"project.go": `package types
+
+import "github.com/Juniper/contrail-go-api"
+
+type Project struct {
+	contrail.ObjectBase
+}
+
+func (obj *Project) GetType() string {
+	return "project"
+}
+`,
+	"virtual_network.go": `package types
+
+import (
+	"encoding/json"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+const (
+	virtual_network_virtual_network_properties = iota
+	virtual_network_network_policy_refs
+	virtual_network_max_
+)
+
+type VirtualNetwork struct {
+	contrail.ObjectBase
+	virtual_network_properties VirtualNetworkType
+	network_policy_refs        contrail.ReferenceList
+	valid                      [virtual_network_max_]bool
+}
+
+func (obj *VirtualNetwork) GetType() string {
+	return "virtual-network"
+}
+
+func (obj *VirtualNetwork) SetVirtualNetworkProperties(value *VirtualNetworkType) {
+	obj.virtual_network_properties = *value
+	obj.valid[virtual_network_virtual_network_properties] = true
+}
+
+// AddNetworkPolicy adds a reference to a network-policy.
+func (obj *VirtualNetwork) AddNetworkPolicy(rhs *NetworkPolicy) error {
+	obj.network_policy_refs = append(obj.network_policy_refs,
+		contrail.Reference{Uuid: rhs.GetUuid()})
+	obj.valid[virtual_network_network_policy_refs] = true
+	return nil
+}
+
+func (obj *VirtualNetwork) NetworkPolicyJSON(rhs *NetworkPolicy) ([]byte, error) {
+	return json.Marshal(rhs.network_policy_entries)
+}
+
+func VirtualNetworkByUuid(uuid string) *VirtualNetwork {
+	obj := new(VirtualNetwork)
+	return obj
+}
+`,
+	"virtual_network_type.go": `package types
+
+type VirtualNetworkType struct {
+	ForwardingMode string
+	Rpf            RpfType
+}
+
+type RpfType string
+`,
+	"network_policy.go": `package types
+
+import "github.com/Juniper/contrail-go-api"
+
+type NetworkPolicy struct {
+	contrail.ObjectBase
+	network_policy_entries PolicyEntriesType
+}
+
+func (obj *NetworkPolicy) GetType() string {
+	return "network-policy"
+}
+`,
+	"policy_entries_type.go": `package types
+
+type PolicyEntriesType struct {
+	PolicyRule []PolicyRuleType
+}
+
+type PolicyRuleType struct {
+	Direction string
+}
+`,
+}
+
+const contrailSource = `package contrail
+
+import "reflect"
+
+type ObjectBase struct{ uuid string }
+
+func (obj *ObjectBase) GetUuid() string { return obj.uuid }
+
+type Reference struct {
+	To   []string
+	Uuid string
+}
+
+type ReferenceList []Reference
+
+func RegisterTypeMap(typeMap map[string]reflect.Type) {}
+`
+
+// typeCheck type-checks the package in dir against contrailSource.
+func typeCheck(t *testing.T, dir string) *types.Package {
+	fset := token.NewFileSet()
+	stdlib := importer.ForCompiler(fset, "source", nil)
+	contrailFile, err := parser.ParseFile(fset, "contrail.go", contrailSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: stdlib}
+	contrail, err := conf.Check("github.com/Juniper/contrail-go-api", fset,
+		[]*ast.File{contrailFile}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filenames, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	var files []*ast.File
+	for _, filename := range filenames {
+		file, err := parser.ParseFile(fset, filename, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	conf.Importer = importerFunc(func(path string) (*types.Package, error) {
+		if path == contrail.Path() {
+			return contrail, nil
+		}
+		return stdlib.Import(path)
+	})
+	pkg, err := conf.Check("types", fset, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (fn importerFunc) Import(path string) (*types.Package, error) {
+	return fn(path)
+}
+
+func writeGenerated(t *testing.T) string {
+	dir := t.TempDir()
+	for filename, content := range generatedFiles {
+		if err := ioutil.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSubset(t *testing.T) {
+	src := writeGenerated(t)
+	typeCheck(t, src)
+	pkg, err := parsePackage(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkg.subset([]string{"virtual-network", "Project"}); err != nil {
+		t.Fatal(err)
+	}
+	dst := t.TempDir()
+	if err := pkg.write(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	filenames, _ := filepath.Glob(filepath.Join(dst, "*.go"))
+	for i, filename := range filenames {
+		filenames[i] = filepath.Base(filename)
+	}
+	expected := []string{"project.go", "types.go", "virtual_network.go", "virtual_network_type.go"}
+	if !reflect.DeepEqual(filenames, expected) {
+		t.Errorf("expected files %v, got %v", expected, filenames)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dst, "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "network-policy") {
+		t.Errorf("network-policy registered:\n%s", data)
+	}
+
+	subset := typeCheck(t, dst)
+	names := subset.Scope().Names()
+	expected = []string{"Project", "RpfType", "TypeMap", "VirtualNetwork",
+		"VirtualNetworkByUuid", "VirtualNetworkType",
+		"virtual_network_max_", "virtual_network_network_policy_refs",
+		"virtual_network_virtual_network_properties"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected declarations %v, got %v", expected, names)
+	}
+	network := subset.Scope().Lookup("VirtualNetwork").Type()
+	methods := types.NewMethodSet(types.NewPointer(network))
+	for _, name := range []string{"AddNetworkPolicy", "NetworkPolicyJSON"} {
+		if methods.Lookup(subset, name) != nil {
+			t.Errorf("method %s kept", name)
+		}
+	}
+	if methods.Lookup(subset, "SetVirtualNetworkProperties") == nil {
+		t.Error("method SetVirtualNetworkProperties removed")
+	}
+}
+
+func TestSubsetUnknownType(t *testing.T) {
+	pkg, err := parsePackage(writeGenerated(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkg.subset([]string{"floating-ip"}); err == nil {
+		t.Error("unknown type accepted")
+	}
+	if err := pkg.subset([]string{"VirtualNetworkType"}); err == nil {
+		t.Error("property type accepted as an object type")
+	}
+}
@@ -0,0 +1,58 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// commandClusterHeader selects the cluster a Contrail Command request
+// applies to.
+const commandClusterHeader = "X-Cluster-ID"
+
+// SetCommandProxy routes the requests through the proxy of a Contrail
+// Command server, which forwards /proxy/<cluster>/config/... to the
+// configuration API of the cluster. The client server and port are those
+// of Contrail Command (e.g. 9091, with AddEncryption); authenticate with a
+// KeystoneClient pointed at the keystone endpoint of Contrail Command
+// (https://<command>:9091/keystone/v3).
+//
+// The objects returned by the proxy carry the hrefs of the configuration
+// nodes, which are not reachable in this setup: the client rewrites them
+// to go through the proxy. An empty cluster disables the proxy.
+func (c *Client) SetCommandProxy(cluster string) {
+	c.commandCluster = cluster
+	if cluster == "" {
+		c.pathPrefix = ""
+		return
+	}
+	c.pathPrefix = "/proxy/" + url.PathEscape(cluster) + "/config"
+}
+
+// proxyURL rewrites the URLs that point outside of the Contrail Command
+// proxy (hrefs returned by the API server) to go through it.
+func (c *Client) proxyURL(rawurl string) string {
+	if c.pathPrefix == "" || strings.HasPrefix(rawurl, c.baseURL()+"/") ||
+		rawurl == c.baseURL() {
+		return rawurl
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+	result := c.baseURL() + u.EscapedPath()
+	if u.RawQuery != "" {
+		result += "?" + u.RawQuery
+	}
+	return result
+}
+
+func (c *Client) addCommandHeaders(req *http.Request) {
+	if c.commandCluster != "" {
+		req.Header.Set(commandClusterHeader, c.commandCluster)
+	}
+}
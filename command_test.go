@@ -0,0 +1,47 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCommandProxy(t *testing.T) {
+	var requests []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("X-Cluster-ID") != "c1" {
+			t.Errorf("%s %s: cluster header %q", r.Method, r.URL.Path,
+				r.Header.Get("X-Cluster-ID"))
+		}
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "u1", "name": "net", "href": "http://10.0.0.1:8082/test-network/u1"}}`)
+		}
+	})
+	defer server.Close()
+	client.SetCommandProxy("c1")
+
+	obj, err := client.FindByUuid("test-network", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Delete(obj); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"GET /proxy/c1/config/test-network/u1",
+		"DELETE /proxy/c1/config/test-network/u1",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("requests: %v, expected %v", requests, expected)
+	}
+
+	client.SetCommandProxy("")
+	if url := client.proxyURL("http://10.0.0.1:8082/x"); url != "http://10.0.0.1:8082/x" {
+		t.Errorf("proxy disabled: %s", url)
+	}
+}
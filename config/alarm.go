@@ -0,0 +1,277 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Alarm severities.
+const (
+	AlarmSeverityCritical = 0
+	AlarmSeverityMajor    = 1
+	AlarmSeverityMinor    = 2
+)
+
+// maxAlarmTerms bounds the size of the alarm rules once expanded to an OR
+// of AND lists.
+const maxAlarmTerms = 256
+
+var alarmOperations = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"in": true, "not in": true, "range": true, "size==": true, "size!=": true,
+}
+
+// UVE attributes are dotted paths (e.g. NodeStatus.process_info.state).
+var uveAttributePattern = regexp.MustCompile(
+	`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+var uveKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// AlarmOperand is the right hand side of an alarm condition: either a UVE
+// attribute or a JSON value.
+type AlarmOperand struct {
+	attribute string
+	value     string
+	err       error
+}
+
+// AlarmAttr returns an operand that refers to a UVE attribute.
+func AlarmAttr(path string) AlarmOperand {
+	return AlarmOperand{attribute: path}
+}
+
+// AlarmValue returns an operand holding a constant, encoded in JSON.
+func AlarmValue(value interface{}) AlarmOperand {
+	data, err := json.Marshal(value)
+	if err != nil {
+		// Reported when the rules are built.
+		return AlarmOperand{err: err}
+	}
+	return AlarmOperand{value: string(data)}
+}
+
+// AlarmCondition is a node of an alarm expression tree: a comparison
+// (AlarmCond) or a combination of conditions (AlarmAll, AlarmAny).
+type AlarmCondition interface {
+	// terms returns the condition as an OR of AND lists.
+	terms() ([][]AlarmComparison, error)
+}
+
+// AlarmComparison compares a UVE attribute with an operand. Variables are
+// UVE attributes reported with the alarm.
+type AlarmComparison struct {
+	Operand1  string
+	Operation string
+	Operand2  AlarmOperand
+	Variables []string
+}
+
+// AlarmCond returns the condition "<attribute> <operation> <operand>", e.g.
+// AlarmCond("NodeStatus.process_status.state", "!=", AlarmValue("Functional")).
+func AlarmCond(attribute, operation string, operand AlarmOperand,
+	variables ...string) *AlarmComparison {
+	return &AlarmComparison{attribute, operation, operand, variables}
+}
+
+func (c *AlarmComparison) validate() error {
+	if !uveAttributePattern.MatchString(c.Operand1) {
+		return fmt.Errorf("Invalid UVE attribute %q", c.Operand1)
+	}
+	if !alarmOperations[c.Operation] {
+		return fmt.Errorf("Invalid alarm operation %q", c.Operation)
+	}
+	for _, variable := range c.Variables {
+		if !uveAttributePattern.MatchString(variable) {
+			return fmt.Errorf("Invalid UVE attribute %q", variable)
+		}
+	}
+	operand := c.Operand2
+	if operand.attribute != "" {
+		if !uveAttributePattern.MatchString(operand.attribute) {
+			return fmt.Errorf("Invalid UVE attribute %q", operand.attribute)
+		}
+		return nil
+	}
+	if operand.err != nil {
+		return fmt.Errorf("%s: %v", c.Operand1, operand.err)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(operand.value), &value); err != nil {
+		return fmt.Errorf("%s: invalid JSON value %q", c.Operand1, operand.value)
+	}
+	switch c.Operation {
+	case "in", "not in":
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("%s %s: expected a list", c.Operand1, c.Operation)
+		}
+	case "range":
+		bounds, ok := value.([]interface{})
+		if !ok || len(bounds) != 2 {
+			return fmt.Errorf("%s range: expected [min, max]", c.Operand1)
+		}
+		for _, bound := range bounds {
+			if _, ok := bound.(float64); !ok {
+				return fmt.Errorf("%s range: bounds must be numbers", c.Operand1)
+			}
+		}
+	case "size==", "size!=":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s %s: expected a number", c.Operand1, c.Operation)
+		}
+	}
+	return nil
+}
+
+func (c *AlarmComparison) terms() ([][]AlarmComparison, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return [][]AlarmComparison{{*c}}, nil
+}
+
+type alarmAll []AlarmCondition
+type alarmAny []AlarmCondition
+
+// AlarmAll is satisfied when all the conditions are.
+func AlarmAll(conditions ...AlarmCondition) AlarmCondition {
+	return alarmAll(conditions)
+}
+
+// AlarmAny is satisfied when one of the conditions is.
+func AlarmAny(conditions ...AlarmCondition) AlarmCondition {
+	return alarmAny(conditions)
+}
+
+func (conditions alarmAll) terms() ([][]AlarmComparison, error) {
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("Empty alarm condition")
+	}
+	result := [][]AlarmComparison{nil}
+	for _, condition := range conditions {
+		terms, err := condition.terms()
+		if err != nil {
+			return nil, err
+		}
+		if len(result)*len(terms) > maxAlarmTerms {
+			return nil, fmt.Errorf("Alarm condition too complex")
+		}
+		var product [][]AlarmComparison
+		for _, lhs := range result {
+			for _, rhs := range terms {
+				term := append(append([]AlarmComparison(nil), lhs...), rhs...)
+				product = append(product, term)
+			}
+		}
+		result = product
+	}
+	return result, nil
+}
+
+func (conditions alarmAny) terms() ([][]AlarmComparison, error) {
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("Empty alarm condition")
+	}
+	var result [][]AlarmComparison
+	for _, condition := range conditions {
+		terms, err := condition.terms()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, terms...)
+		if len(result) > maxAlarmTerms {
+			return nil, fmt.Errorf("Alarm condition too complex")
+		}
+	}
+	return result, nil
+}
+
+// AlarmRules converts an expression tree to the OR of AND lists stored in
+// alarm_rules, validating the operands.
+func AlarmRules(condition AlarmCondition) (*types.AlarmOrList, error) {
+	terms, err := condition.terms()
+	if err != nil {
+		return nil, err
+	}
+	rules := new(types.AlarmOrList)
+	for _, term := range terms {
+		var and types.AlarmAndList
+		for _, c := range term {
+			and.AndList = append(and.AndList, types.AlarmExpression{
+				Operation: c.Operation,
+				Operand1:  c.Operand1,
+				Operand2: &types.AlarmOperand2{
+					UveAttribute: c.Operand2.attribute,
+					JsonValue:    c.Operand2.value,
+				},
+				Variables: c.Variables,
+			})
+		}
+		rules.OrList = append(rules.OrList, and)
+	}
+	return rules, nil
+}
+
+// AlarmSpec describes an alarm.
+type AlarmSpec struct {
+	Name        string
+	Description string
+	// Severity is one of the AlarmSeverity constants.
+	Severity int
+	// UveKeys are the UVE types the alarm applies to (e.g. "vrouter",
+	// "analytics-node").
+	UveKeys   []string
+	Condition AlarmCondition
+}
+
+// SetAlarmSpec applies the properties of spec to alarm.
+func SetAlarmSpec(alarm *types.Alarm, spec *AlarmSpec) error {
+	if spec.Severity < AlarmSeverityCritical || spec.Severity > AlarmSeverityMinor {
+		return fmt.Errorf("Invalid alarm severity %d", spec.Severity)
+	}
+	if len(spec.UveKeys) == 0 {
+		return fmt.Errorf("Alarm %s: no UVE keys", spec.Name)
+	}
+	for _, key := range spec.UveKeys {
+		if !uveKeyPattern.MatchString(key) {
+			return fmt.Errorf("Invalid UVE key %q", key)
+		}
+	}
+	rules, err := AlarmRules(spec.Condition)
+	if err != nil {
+		return fmt.Errorf("Alarm %s: %v", spec.Name, err)
+	}
+	alarm.SetAlarmSeverity(spec.Severity)
+	alarm.SetUveKeys(&types.UveKeysType{UveKey: spec.UveKeys})
+	alarm.SetAlarmRules(rules)
+	if spec.Description != "" {
+		return SetDescription(alarm, spec.Description)
+	}
+	return nil
+}
+
+// CreateAlarm creates an alarm under scope: the global-system-config for
+// system wide alarms, or a project.
+func CreateAlarm(client contrail.ApiClient, scope contrail.IObject,
+	spec *AlarmSpec) (*types.Alarm, error) {
+	alarm := new(types.Alarm)
+	alarm.SetParent(scope)
+	alarm.SetName(spec.Name)
+	if err := SetAlarmSpec(alarm, spec); err != nil {
+		return nil, err
+	}
+	if err := client.Create(alarm); err != nil {
+		return nil, err
+	}
+	return alarm, nil
+}
@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Annotated is implemented by the types that have an annotations property.
+type Annotated interface {
+	contrail.IObject
+	GetAnnotations() types.KeyValuePairs
+	SetAnnotations(*types.KeyValuePairs)
+}
+
+// GetAnnotation returns the value of an annotation.
+func GetAnnotation(obj Annotated, key string) (string, bool) {
+	for _, kv := range obj.GetAnnotations().KeyValuePair {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAnnotation sets an annotation in the local copy of obj. The change is
+// sent to the API server by the next Update.
+func SetAnnotation(obj Annotated, key, value string) {
+	annotations := obj.GetAnnotations()
+	pairs := make([]types.KeyValuePair, 0, len(annotations.KeyValuePair)+1)
+	found := false
+	for _, kv := range annotations.KeyValuePair {
+		if kv.Key == key {
+			kv.Value = value
+			found = true
+		}
+		pairs = append(pairs, kv)
+	}
+	if !found {
+		pairs = append(pairs, types.KeyValuePair{Key: key, Value: value})
+	}
+	obj.SetAnnotations(&types.KeyValuePairs{KeyValuePair: pairs})
+}
+
+// DeleteAnnotation removes an annotation from the local copy of obj.
+func DeleteAnnotation(obj Annotated, key string) {
+	annotations := obj.GetAnnotations()
+	pairs := make([]types.KeyValuePair, 0, len(annotations.KeyValuePair))
+	for _, kv := range annotations.KeyValuePair {
+		if kv.Key != key {
+			pairs = append(pairs, kv)
+		}
+	}
+	obj.SetAnnotations(&types.KeyValuePairs{KeyValuePair: pairs})
+}
+
+// propCollectionUpdater is implemented by contrail.Client.
+type propCollectionUpdater interface {
+	UpdatePropCollection(uuid string, updates []contrail.PropCollectionUpdate) error
+}
+
+// UpdateAnnotations sets and removes annotations of an object stored in
+// the API server, and applies the same changes to the local copy.
+//
+// When the client supports it, the annotations are modified element by
+// element (prop-collection-update), which leaves annotations set
+// concurrently by other clients untouched. Otherwise the object is updated
+// with the whole annotations property.
+func UpdateAnnotations(client contrail.ApiClient, obj Annotated,
+	set map[string]string, remove []string) error {
+	for key, value := range set {
+		SetAnnotation(obj, key, value)
+	}
+	for _, key := range remove {
+		DeleteAnnotation(obj, key)
+	}
+
+	updater, ok := client.(propCollectionUpdater)
+	if !ok {
+		return client.Update(obj)
+	}
+	var updates []contrail.PropCollectionUpdate
+	for key, value := range set {
+		updates = append(updates, contrail.PropCollectionUpdate{
+			Field:     "annotations",
+			Operation: contrail.PropCollectionSet,
+			Value:     types.KeyValuePair{Key: key, Value: value},
+		})
+	}
+	for _, key := range remove {
+		updates = append(updates, contrail.PropCollectionUpdate{
+			Field:     "annotations",
+			Operation: contrail.PropCollectionDelete,
+			Position:  key,
+		})
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return updater.UpdatePropCollection(obj.GetUuid(), updates)
+}
@@ -0,0 +1,247 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// BgpaasSpec describes a BGP session between a virtual machine and the
+// control nodes (bgp-as-a-service).
+type BgpaasSpec struct {
+	Name string
+	// AutonomousSystem of the virtual machine.
+	AutonomousSystem int
+	// PeerAddress is the address the virtual machine peers from. When
+	// empty, the address of the interface instance-ip is used and an
+	// instance-ip is allocated if the interface has none. When set, an
+	// instance-ip with that address is created unless the interface
+	// already has it.
+	PeerAddress string
+	// AddressFamilies defaults to inet and inet6.
+	AddressFamilies []string
+	// HoldTime in seconds; 0 selects the control node default.
+	HoldTime                   int
+	Shared                     bool
+	SuppressRouteAdvertisement bool
+	Ipv4MappedIpv6Nexthop      bool
+}
+
+var bgpaasFamilies = map[string]bool{"inet": true, "inet6": true}
+
+func bgpaasSessionAttributes(families []string, holdTime int) (
+	*types.BgpSessionAttributes, error) {
+	if len(families) == 0 {
+		families = []string{"inet", "inet6"}
+	}
+	for _, family := range families {
+		if !bgpaasFamilies[family] {
+			return nil, fmt.Errorf("Invalid BGPaaS address family %q", family)
+		}
+	}
+	// RFC 4271: the hold time is either zero or at least three seconds.
+	if holdTime < 0 || (holdTime > 0 && holdTime < 3) || holdTime > 65535 {
+		return nil, fmt.Errorf("Invalid BGP hold time %d", holdTime)
+	}
+	return &types.BgpSessionAttributes{
+		AddressFamilies: &types.AddressFamilies{Family: families},
+		HoldTime:        holdTime,
+	}, nil
+}
+
+// Bgpaas is a handle on a bgp-as-a-service object and the interfaces it
+// is attached to.
+type Bgpaas struct {
+	client contrail.ApiClient
+	Object *types.BgpAsAService
+	// Addresses are the peering addresses, by interface uuid.
+	Addresses map[string]string
+
+	// instanceIps created for the session, deleted with it.
+	instanceIps []*types.InstanceIp
+}
+
+// interfaceNetwork returns the virtual-network of vmi.
+func interfaceNetwork(client contrail.ApiClient,
+	vmi *types.VirtualMachineInterface) (*types.VirtualNetwork, error) {
+	refs, err := vmi.GetVirtualNetworkRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("Interface %s has no network", vmi.GetUuid())
+	}
+	obj, err := client.FindByUuid("virtual-network", refs[0].Uuid)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.VirtualNetwork), nil
+}
+
+// peerAddress returns the peering address of vmi, creating an instance-ip
+// when needed. The instance-ip is returned if one was created.
+func peerAddress(client contrail.ApiClient, vmi *types.VirtualMachineInterface,
+	address string) (string, *types.InstanceIp, error) {
+	if address != "" && net.ParseIP(address) == nil {
+		return "", nil, fmt.Errorf("%s is not a valid IP address", address)
+	}
+	refs, err := vmi.GetInstanceIpBackRefs()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, ref := range refs {
+		obj, err := client.FindByUuid("instance-ip", ref.Uuid)
+		if err != nil {
+			return "", nil, err
+		}
+		current := obj.(*types.InstanceIp).GetInstanceIpAddress()
+		if current == "" {
+			continue
+		}
+		if address == "" || net.ParseIP(current).Equal(net.ParseIP(address)) {
+			return current, nil, nil
+		}
+	}
+
+	network, err := interfaceNetwork(client, vmi)
+	if err != nil {
+		return "", nil, err
+	}
+	iip := new(types.InstanceIp)
+	iip.SetName(vmi.GetUuid() + "-bgpaas")
+	if address != "" {
+		if _, err := subnetContaining(network, net.ParseIP(address)); err != nil {
+			return "", nil, err
+		}
+		iip.SetName(vmi.GetUuid() + "-" + address)
+		iip.SetInstanceIpAddress(address)
+	}
+	iip.AddVirtualNetwork(network)
+	iip.AddVirtualMachineInterface(vmi)
+	if err := client.Create(iip); err != nil {
+		return "", nil, err
+	}
+	if iip.GetInstanceIpAddress() == "" {
+		client.Delete(iip)
+		return "", nil, fmt.Errorf("No address allocated to interface %s",
+			vmi.GetUuid())
+	}
+	return iip.GetInstanceIpAddress(), iip, nil
+}
+
+// CreateBgpaas creates a bgp-as-a-service object in project for the
+// virtual machine attached to vmi.
+func CreateBgpaas(client contrail.ApiClient, project *types.Project,
+	vmi *types.VirtualMachineInterface, spec *BgpaasSpec) (*Bgpaas, error) {
+	if spec.AutonomousSystem <= 0 || int64(spec.AutonomousSystem) > 0xffffffff {
+		return nil, fmt.Errorf("Invalid autonomous system %d",
+			spec.AutonomousSystem)
+	}
+	attributes, err := bgpaasSessionAttributes(spec.AddressFamilies,
+		spec.HoldTime)
+	if err != nil {
+		return nil, err
+	}
+	address, iip, err := peerAddress(client, vmi, spec.PeerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	bgpaas := new(types.BgpAsAService)
+	bgpaas.SetParent(project)
+	bgpaas.SetName(spec.Name)
+	bgpaas.SetAutonomousSystem(spec.AutonomousSystem)
+	bgpaas.SetBgpaasIpAddress(address)
+	bgpaas.SetBgpaasSessionAttributes(attributes)
+	bgpaas.SetBgpaasShared(spec.Shared)
+	bgpaas.SetBgpaasSuppressRouteAdvertisement(spec.SuppressRouteAdvertisement)
+	bgpaas.SetBgpaasIpv4MappedIpv6Nexthop(spec.Ipv4MappedIpv6Nexthop)
+	bgpaas.AddVirtualMachineInterface(vmi)
+	if err := client.Create(bgpaas); err != nil {
+		if iip != nil {
+			client.Delete(iip)
+		}
+		return nil, err
+	}
+
+	handle := &Bgpaas{
+		client:    client,
+		Object:    bgpaas,
+		Addresses: map[string]string{vmi.GetUuid(): address},
+	}
+	if iip != nil {
+		handle.instanceIps = append(handle.instanceIps, iip)
+	}
+	return handle, nil
+}
+
+// AddInterface attaches the session to another interface (shared
+// sessions). The interface peers from its instance-ip address.
+func (b *Bgpaas) AddInterface(vmi *types.VirtualMachineInterface) error {
+	if !b.Object.GetBgpaasShared() {
+		return fmt.Errorf("BGPaaS %s is not shared", b.Object.GetName())
+	}
+	address, iip, err := peerAddress(b.client, vmi, "")
+	if err != nil {
+		return err
+	}
+	b.Object.AddVirtualMachineInterface(vmi)
+	if err := b.client.Update(b.Object); err != nil {
+		if iip != nil {
+			b.client.Delete(iip)
+		}
+		return err
+	}
+	b.Addresses[vmi.GetUuid()] = address
+	if iip != nil {
+		b.instanceIps = append(b.instanceIps, iip)
+	}
+	return nil
+}
+
+// SetSessionAttributes updates the address families and hold time.
+func (b *Bgpaas) SetSessionAttributes(families []string, holdTime int) error {
+	attributes, err := bgpaasSessionAttributes(families, holdTime)
+	if err != nil {
+		return err
+	}
+	current := b.Object.GetBgpaasSessionAttributes()
+	current.AddressFamilies = attributes.AddressFamilies
+	current.HoldTime = attributes.HoldTime
+	b.Object.SetBgpaasSessionAttributes(&current)
+	return b.client.Update(b.Object)
+}
+
+// SetAutonomousSystem updates the autonomous system of the virtual
+// machine.
+func (b *Bgpaas) SetAutonomousSystem(asn int) error {
+	if asn <= 0 || int64(asn) > 0xffffffff {
+		return fmt.Errorf("Invalid autonomous system %d", asn)
+	}
+	b.Object.SetAutonomousSystem(asn)
+	return b.client.Update(b.Object)
+}
+
+// Delete deletes the bgp-as-a-service object and the instance-ips created
+// for it.
+func (b *Bgpaas) Delete() error {
+	if err := b.client.Delete(b.Object); err != nil {
+		return err
+	}
+	for _, iip := range b.instanceIps {
+		if err := b.client.Delete(iip); err != nil {
+			return err
+		}
+	}
+	b.instanceIps = nil
+	return nil
+}
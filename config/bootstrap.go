@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pborman/uuid"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func isNotFound(err error) bool {
+	if httpErr, ok := err.(*contrail.HTTPError); ok {
+		return httpErr.StatusCode == 404
+	}
+	return strings.HasPrefix(err.Error(), "404")
+}
+
+// findOrCreate returns the object with the fq_name of obj, creating obj if
+// it doesn't exist. A concurrent creation by another client is not an
+// error.
+func findOrCreate(client contrail.ApiClient, obj contrail.IObject) (
+	contrail.IObject, bool, error) {
+	name := strings.Join(obj.GetFQName(), ":")
+	found, err := client.FindByName(obj.GetType(), name)
+	if err == nil {
+		return found, false, nil
+	}
+	if !isNotFound(err) {
+		return nil, false, err
+	}
+	if err := client.Create(obj); err != nil {
+		if found, findErr := client.FindByName(obj.GetType(), name); findErr == nil {
+			return found, false, nil
+		}
+		return nil, false, err
+	}
+	return obj, true, nil
+}
+
+// EnsureProject returns the project domain:project, creating the domain
+// and the project if they don't exist. The quota limits (keyed by
+// resource type, or QuotaDefaults) and the permissions, when not nil, are
+// applied to the project; other quota limits are left unchanged. The
+// project default security group is created if missing, with the rules
+// the API server assigns to default groups.
+//
+// EnsureProject can be called repeatedly: the project is only updated when
+// its quotas or permissions differ.
+func EnsureProject(client contrail.ApiClient, domain, project string,
+	quotas map[string]int, perms *types.PermType) (*types.Project, error) {
+	domainObj := new(types.Domain)
+	domainObj.SetName(domain)
+	obj, _, err := findOrCreate(client, domainObj)
+	if err != nil {
+		return nil, err
+	}
+
+	projectObj := new(types.Project)
+	projectObj.SetParent(obj.(*types.Domain))
+	projectObj.SetName(project)
+	if err := applyProjectSettings(projectObj, quotas, perms); err != nil {
+		return nil, err
+	}
+	obj, created, err := findOrCreate(client, projectObj)
+	if err != nil {
+		return nil, err
+	}
+	projectObj = obj.(*types.Project)
+	if !created {
+		quota, idPerms := projectObj.GetQuota(), projectObj.GetIdPerms()
+		if err := applyProjectSettings(projectObj, quotas, perms); err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(quota, projectObj.GetQuota()) ||
+			(perms != nil && !reflect.DeepEqual(idPerms.Permissions,
+				projectObj.GetIdPerms().Permissions)) {
+			if err := client.Update(projectObj); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := ensureDefaultSecurityGroup(client, projectObj); err != nil {
+		return nil, err
+	}
+	return projectObj, nil
+}
+
+func applyProjectSettings(project *types.Project, quotas map[string]int,
+	perms *types.PermType) error {
+	for resource, limit := range quotas {
+		if err := SetProjectQuota(project, resource, limit); err != nil {
+			return err
+		}
+	}
+	if perms == nil {
+		return nil
+	}
+	return ModifyIdPerms(project, func(idPerms *types.IdPermsType) {
+		value := *perms
+		idPerms.Permissions = &value
+	})
+}
+
+// defaultSecurityGroupRules are the rules of a default security group:
+// ingress from the members of the group and egress to any address.
+func defaultSecurityGroupRules(group []string) []types.PolicyRuleType {
+	members := strings.Join(group, ":")
+	var rules []types.PolicyRuleType
+	for _, family := range []struct {
+		ethertype string
+		prefix    string
+	}{{"IPv4", "0.0.0.0"}, {"IPv6", "::"}} {
+		allPorts := []types.PortType{{StartPort: 0, EndPort: 65535}}
+		rules = append(rules, types.PolicyRuleType{
+			RuleUuid:     uuid.NewRandom().String(),
+			Direction:    ">",
+			Protocol:     "any",
+			Ethertype:    family.ethertype,
+			SrcAddresses: []types.AddressType{{SecurityGroup: members}},
+			SrcPorts:     allPorts,
+			DstAddresses: []types.AddressType{{SecurityGroup: "local"}},
+			DstPorts:     allPorts,
+		}, types.PolicyRuleType{
+			RuleUuid:     uuid.NewRandom().String(),
+			Direction:    ">",
+			Protocol:     "any",
+			Ethertype:    family.ethertype,
+			SrcAddresses: []types.AddressType{{SecurityGroup: "local"}},
+			SrcPorts:     allPorts,
+			DstAddresses: []types.AddressType{{
+				Subnet: types.SubnetType{IpPrefix: family.prefix},
+			}},
+			DstPorts: allPorts,
+		})
+	}
+	return rules
+}
+
+// ensureDefaultSecurityGroup creates the default security group of
+// project if it is missing. An existing group is left unchanged.
+func ensureDefaultSecurityGroup(client contrail.ApiClient,
+	project *types.Project) error {
+	sg := new(types.SecurityGroup)
+	sg.SetParent(project)
+	sg.SetName(DefaultSecurityGroup)
+	sg.SetSecurityGroupEntries(&types.PolicyEntriesType{
+		PolicyRule: defaultSecurityGroupRules(sg.GetFQName()),
+	})
+	_, _, err := findOrCreate(client, sg)
+	return err
+}
@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// DefaultSecurityGroup is the name of the security group the API server
+// creates in each project.
+const DefaultSecurityGroup = "default"
+
+// Defaults retrieves the objects the API server creates at initialization
+// (default project, ipam, global configuration singletons). Objects are
+// read once and cached; they are shared between callers and must not be
+// modified. Use Invalidate to discard the cache.
+type Defaults struct {
+	client  contrail.ApiClient
+	mutex   sync.Mutex
+	objects map[string]contrail.IObject
+}
+
+// NewDefaults allocates a Defaults cache for client.
+func NewDefaults(client contrail.ApiClient) *Defaults {
+	return &Defaults{
+		client:  client,
+		objects: make(map[string]contrail.IObject),
+	}
+}
+
+func (d *Defaults) get(typename string, fqn []string) (contrail.IObject, error) {
+	key := typename + " " + strings.Join(fqn, ":")
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if obj, ok := d.objects[key]; ok {
+		return obj, nil
+	}
+	obj, err := d.client.FindByName(typename, strings.Join(fqn, ":"))
+	if err != nil {
+		return nil, err
+	}
+	d.objects[key] = obj
+	return obj, nil
+}
+
+// Invalidate discards the cached objects.
+func (d *Defaults) Invalidate() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.objects = make(map[string]contrail.IObject)
+}
+
+// Project returns default-domain:default-project.
+func (d *Defaults) Project() (*types.Project, error) {
+	obj, err := d.get(contrail.TypeProject, contrail.DefaultProjectFQName())
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.Project), nil
+}
+
+// NetworkIpam returns the default network-ipam of the default project.
+func (d *Defaults) NetworkIpam() (*types.NetworkIpam, error) {
+	obj, err := d.get(contrail.TypeNetworkIpam, contrail.DefaultNetworkIpamFQName())
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.NetworkIpam), nil
+}
+
+// GlobalSystemConfig returns the global-system-config singleton.
+func (d *Defaults) GlobalSystemConfig() (*types.GlobalSystemConfig, error) {
+	obj, err := d.get(contrail.TypeGlobalSystemConfig,
+		contrail.DefaultGlobalSystemConfigFQName())
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.GlobalSystemConfig), nil
+}
+
+// GlobalVrouterConfig returns the global-vrouter-config singleton.
+func (d *Defaults) GlobalVrouterConfig() (*types.GlobalVrouterConfig, error) {
+	obj, err := d.get(contrail.TypeGlobalVrouterConfig,
+		contrail.DefaultGlobalVrouterConfigFQName())
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.GlobalVrouterConfig), nil
+}
+
+// SecurityGroup returns the default security group of project.
+func (d *Defaults) SecurityGroup(project *types.Project) (*types.SecurityGroup, error) {
+	fqn := append(append([]string(nil), project.GetFQName()...), DefaultSecurityGroup)
+	obj, err := d.get(contrail.TypeSecurityGroup, fqn)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.SecurityGroup), nil
+}
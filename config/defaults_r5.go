@@ -0,0 +1,23 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// PolicyManagement returns the global policy-management.
+func (d *Defaults) PolicyManagement() (*types.PolicyManagement, error) {
+	obj, err := d.get(contrail.TypePolicyManagement,
+		contrail.DefaultPolicyManagementFQName())
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.PolicyManagement), nil
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// DisabledByAnnotation marks the objects disabled along with their parent
+// by Disable, with the uuid of that parent, so that Enable only re-enables
+// those.
+const DisabledByAnnotation = "contrail-go-api/disabled-by"
+
+// disablePropagation lists the child types whose administrative state
+// follows that of their parent: the interfaces of a router, the members
+// of a load-balancer pool, the port tuples of a service instance.
+var disablePropagation = map[string][]string{
+	"physical-router":    {"physical-interface", "logical-interface"},
+	"physical-interface": {"logical-interface"},
+	"loadbalancer-pool":  {"loadbalancer-member"},
+	"service-instance":   {"port-tuple"},
+}
+
+// childrenToPropagate returns the children of obj whose state follows
+// that of obj.
+func childrenToPropagate(client contrail.ApiClient, obj contrail.IObject) (
+	[]IdPermsObject, error) {
+	var children []IdPermsObject
+	for _, typename := range disablePropagation[obj.GetType()] {
+		results, err := client.ListByParent(typename, obj.GetUuid())
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			child, err := client.ReadListResult(typename, &results[i])
+			if err != nil {
+				return nil, err
+			}
+			if child, ok := child.(IdPermsObject); ok {
+				children = append(children, child)
+			}
+		}
+	}
+	return children, nil
+}
+
+// Disable sets the administrative state of obj to disabled, a reversible
+// alternative to deleting it. The children whose state follows their
+// parent (e.g. the interfaces of a physical-router) are disabled first;
+// those that support annotations are marked so that Enable restores them
+// without re-enabling the children that were already disabled.
+func Disable(client contrail.ApiClient, obj IdPermsObject) error {
+	if err := disableChildren(client, obj, obj.GetUuid()); err != nil {
+		return err
+	}
+	if !obj.GetIdPerms().Enable {
+		return nil
+	}
+	return UpdateIdPerms(client, obj, func(perms *types.IdPermsType) {
+		perms.Enable = false
+	})
+}
+
+func disableChildren(client contrail.ApiClient, obj contrail.IObject,
+	root string) error {
+	children, err := childrenToPropagate(client, obj)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := disableChildren(client, child, root); err != nil {
+			return err
+		}
+		if !child.GetIdPerms().Enable {
+			continue
+		}
+		if err := ModifyIdPerms(child, func(perms *types.IdPermsType) {
+			perms.Enable = false
+		}); err != nil {
+			return err
+		}
+		if annotated, ok := child.(Annotated); ok {
+			SetAnnotation(annotated, DisabledByAnnotation, root)
+		}
+		if err := client.Update(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enable sets the administrative state of obj to enabled, and re-enables
+// the children disabled by Disable(obj). Children without annotations
+// are all re-enabled.
+func Enable(client contrail.ApiClient, obj IdPermsObject) error {
+	if !obj.GetIdPerms().Enable {
+		if err := UpdateIdPerms(client, obj, func(perms *types.IdPermsType) {
+			perms.Enable = true
+		}); err != nil {
+			return err
+		}
+	}
+	return enableChildren(client, obj, obj.GetUuid())
+}
+
+func enableChildren(client contrail.ApiClient, obj contrail.IObject,
+	root string) error {
+	children, err := childrenToPropagate(client, obj)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		restore := !child.GetIdPerms().Enable
+		if annotated, ok := child.(Annotated); ok {
+			by, _ := GetAnnotation(annotated, DisabledByAnnotation)
+			restore = by == root
+			if restore {
+				DeleteAnnotation(annotated, DisabledByAnnotation)
+			}
+		}
+		if restore {
+			if err := ModifyIdPerms(child, func(perms *types.IdPermsType) {
+				perms.Enable = true
+			}); err != nil {
+				return err
+			}
+			if err := client.Update(child); err != nil {
+				return err
+			}
+		}
+		if err := enableChildren(client, child, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
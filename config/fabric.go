@@ -0,0 +1,218 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Fabric objects (fabric, physical-router, node-profile) are children of
+// the global system config.
+const globalSystemConfig = "default-global-system-config"
+
+// PortID identifies a physical interface (e.g. "leaf1", "xe-0/0/1").
+type PortID struct {
+	Router    string
+	Interface string
+}
+
+func (p PortID) String() string {
+	return p.Router + ":" + p.Interface
+}
+
+// FabricByName retrieves a fabric given its (unqualified) name.
+func FabricByName(client contrail.ApiClient, name string) (*types.Fabric, error) {
+	obj, err := client.FindByName("fabric", globalSystemConfig+":"+name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.Fabric), nil
+}
+
+// PhysicalRouterByName retrieves a physical-router given its (unqualified)
+// name.
+func PhysicalRouterByName(client contrail.ApiClient, name string) (
+	*types.PhysicalRouter, error) {
+	obj, err := client.FindByName("physical-router", globalSystemConfig+":"+name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.PhysicalRouter), nil
+}
+
+// NodeProfileByName retrieves a node-profile given its (unqualified) name.
+func NodeProfileByName(client contrail.ApiClient, name string) (
+	*types.NodeProfile, error) {
+	obj, err := client.FindByName("node-profile", globalSystemConfig+":"+name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.NodeProfile), nil
+}
+
+// FindPhysicalInterface retrieves the physical-interface identified by port.
+// Interface names containing ':' are escaped with '_' by the device
+// manager (e.g. "xe-0/0/1:0" is stored as "xe-0/0/1_0").
+func FindPhysicalInterface(client contrail.ApiClient, port PortID) (
+	*types.PhysicalInterface, error) {
+	fqn := []string{globalSystemConfig, port.Router,
+		strings.Replace(port.Interface, ":", "_", -1)}
+	obj, err := client.FindByName("physical-interface", strings.Join(fqn, ":"))
+	if err != nil {
+		return nil, fmt.Errorf("physical-interface %s: %v", port, err)
+	}
+	return obj.(*types.PhysicalInterface), nil
+}
+
+// CreateLogicalInterface creates a VLAN sub-interface (named <pi>.<vlan>)
+// of a physical-interface. ifType is either "l2" or "l3".
+func CreateLogicalInterface(client contrail.ApiClient,
+	pi *types.PhysicalInterface, vlan int, ifType string) (
+	*types.LogicalInterface, error) {
+	if vlan < 0 || vlan > 4094 {
+		return nil, fmt.Errorf("Invalid vlan tag %d", vlan)
+	}
+	if ifType != "l2" && ifType != "l3" {
+		return nil, fmt.Errorf("Invalid logical interface type %s", ifType)
+	}
+	li := new(types.LogicalInterface)
+	li.SetParent(pi)
+	li.SetName(pi.GetName() + "." + strconv.Itoa(vlan))
+	li.SetLogicalInterfaceVlanTag(vlan)
+	li.SetLogicalInterfaceType(ifType)
+	if err := client.Create(li); err != nil {
+		return nil, err
+	}
+	return li, nil
+}
+
+// CreateVirtualPortGroup creates a virtual-port-group under a fabric with
+// references to the physical interfaces that compose it. LACP is enabled
+// when the group spans more than one interface (multihoming or LAG).
+func CreateVirtualPortGroup(client contrail.ApiClient, fabricName, name string,
+	ports []PortID) (*types.VirtualPortGroup, error) {
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("virtual-port-group %s: no interfaces", name)
+	}
+	fabric, err := FabricByName(client, fabricName)
+	if err != nil {
+		return nil, err
+	}
+
+	vpg := new(types.VirtualPortGroup)
+	vpg.SetParent(fabric)
+	vpg.SetName(name)
+	vpg.SetVirtualPortGroupLacpEnabled(len(ports) > 1)
+	vpg.SetVirtualPortGroupUserCreated(true)
+	for _, port := range ports {
+		pi, err := FindPhysicalInterface(client, port)
+		if err != nil {
+			return nil, err
+		}
+		vpg.AddPhysicalInterface(pi)
+	}
+	if err := client.Create(vpg); err != nil {
+		return nil, err
+	}
+	return vpg, nil
+}
+
+// localLinkInformation is the binding profile the API server uses to
+// associate a port with the switch ports it is connected to.
+type localLinkInformation struct {
+	SwitchInfo string `json:"switch_info"`
+	PortID     string `json:"port_id"`
+	Fabric     string `json:"fabric"`
+}
+
+// VirtualPortGroupAttachment describes the connection of a virtual-network
+// to a virtual-port-group.
+type VirtualPortGroupAttachment struct {
+	Project *types.Project
+	Network *types.VirtualNetwork
+	// VLAN tag of the traffic on the switch ports.
+	Vlan int
+	// Native sends the network traffic untagged.
+	Native bool
+}
+
+// AttachVirtualPortGroup connects a virtual-network to a virtual-port-group:
+// it creates a baremetal virtual-machine-interface with the bindings and
+// VLAN tagging the device manager expects, and references it from the
+// virtual-port-group.
+func AttachVirtualPortGroup(client contrail.ApiClient,
+	vpg *types.VirtualPortGroup, attachment *VirtualPortGroupAttachment) (
+	*types.VirtualMachineInterface, error) {
+	if attachment.Vlan < 0 || attachment.Vlan > 4094 {
+		return nil, fmt.Errorf("Invalid vlan tag %d", attachment.Vlan)
+	}
+	fabricName := vpg.GetFQName()[len(vpg.GetFQName())-2]
+
+	refs, err := vpg.GetPhysicalInterfaceRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("virtual-port-group %s has no interfaces",
+			vpg.GetName())
+	}
+	var links []localLinkInformation
+	for _, ref := range refs {
+		// physical-interface fq_name: [global-system-config, router, interface]
+		links = append(links, localLinkInformation{
+			SwitchInfo: ref.To[1],
+			PortID:     ref.To[2],
+			Fabric:     fabricName,
+		})
+	}
+	profile, err := json.Marshal(map[string]interface{}{
+		"local_link_information": links,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := new(types.KeyValuePairs)
+	bindings.AddKeyValuePair(&types.KeyValuePair{Key: "vnic_type", Value: "baremetal"})
+	bindings.AddKeyValuePair(&types.KeyValuePair{Key: "vif_type", Value: "vrouter"})
+	bindings.AddKeyValuePair(&types.KeyValuePair{Key: "vpg", Value: vpg.GetName()})
+	bindings.AddKeyValuePair(&types.KeyValuePair{Key: "profile", Value: string(profile)})
+	if attachment.Native {
+		bindings.AddKeyValuePair(&types.KeyValuePair{
+			Key: "tor_port_vlan_id", Value: strconv.Itoa(attachment.Vlan)})
+	}
+
+	vmi := new(types.VirtualMachineInterface)
+	vmi.SetParent(attachment.Project)
+	vmi.SetName(fmt.Sprintf("%s-%s-%d", vpg.GetName(),
+		attachment.Network.GetName(), attachment.Vlan))
+	vmi.SetVirtualMachineInterfaceBindings(bindings)
+	if !attachment.Native {
+		vmi.SetVirtualMachineInterfaceProperties(
+			&types.VirtualMachineInterfacePropertiesType{
+				SubInterfaceVlanTag: attachment.Vlan,
+			})
+	}
+	vmi.AddVirtualNetwork(attachment.Network)
+	if err := client.Create(vmi); err != nil {
+		return nil, err
+	}
+
+	vpg.AddVirtualMachineInterface(vmi)
+	if err := client.Update(vpg); err != nil {
+		client.Delete(vmi)
+		return nil, err
+	}
+	return vmi, nil
+}
@@ -0,0 +1,166 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Security resources are created in a policy-management scope: the global
+// scope or a project.
+const (
+	globalPolicyManagement = "default-policy-management"
+	draftPolicyManagement  = "draft-policy-management"
+)
+
+// EnableSecurityDraft turns the security draft mode on or off. In draft
+// mode the changes to the security resources of a scope are held in a
+// draft policy-management until they are committed (see
+// contrail.Client.CommitSecurityDraft) or discarded.
+func EnableSecurityDraft(client contrail.ApiClient, enable bool) error {
+	obj, err := client.FindByName("global-system-config", globalSystemConfig)
+	if err != nil {
+		return err
+	}
+	gsc := obj.(*types.GlobalSystemConfig)
+	gsc.SetEnableSecurityPolicyDraft(enable)
+	return client.Update(gsc)
+}
+
+// GlobalPolicyManagement retrieves the global security scope.
+func GlobalPolicyManagement(client contrail.ApiClient) (
+	*types.PolicyManagement, error) {
+	obj, err := client.FindByName("policy-management", globalPolicyManagement)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.PolicyManagement), nil
+}
+
+// DraftPolicyManagement retrieves the policy-management that holds the
+// pending changes of a scope (the global policy-management or a project).
+// It exists once a security resource is modified in draft mode.
+func DraftPolicyManagement(client contrail.ApiClient, scope contrail.IObject) (
+	*types.PolicyManagement, error) {
+	fqn := []string{draftPolicyManagement}
+	if scope.GetType() == "project" {
+		fqn = append(append([]string{}, scope.GetFQName()...), draftPolicyManagement)
+	}
+	obj, err := client.FindByName("policy-management", strings.Join(fqn, ":"))
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.PolicyManagement), nil
+}
+
+// CreateFirewallPolicy creates a firewall-policy in a scope. In draft mode
+// the API server creates it in the draft policy-management of the scope.
+func CreateFirewallPolicy(client contrail.ApiClient, scope contrail.IObject,
+	name string) (*types.FirewallPolicy, error) {
+	policy := new(types.FirewallPolicy)
+	policy.SetParent(scope)
+	policy.SetName(name)
+	if err := client.Create(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// FirewallRuleSpec describes a firewall rule between two sets of tagged
+// endpoints.
+type FirewallRuleSpec struct {
+	Name string
+	// Action is "pass" or "deny".
+	Action string
+	// Protocol (e.g. "tcp", "udp", "any") and destination port range.
+	Protocol string
+	Ports    [2]int
+	// Endpoints are identified by tags (e.g. "tier=web").
+	Endpoint1 []string
+	Endpoint2 []string
+	// Direction is one of "<>", ">" or "<".
+	Direction string
+}
+
+// nextSequence returns a sequence number that orders a rule after the rules
+// of a policy. Sequences are decimal numbers ("1.0"); rules may have been
+// removed, so the number of rules cannot be used.
+func nextSequence(refs contrail.ReferenceList) string {
+	next := 0
+	for _, ref := range refs {
+		var sequence string
+		switch attr := ref.Attr.(type) {
+		case types.FirewallSequence:
+			sequence = attr.Sequence
+		case *types.FirewallSequence:
+			sequence = attr.Sequence
+		}
+		value, err := strconv.ParseFloat(sequence, 64)
+		if err == nil && int(value)+1 > next {
+			next = int(value) + 1
+		}
+	}
+	return strconv.Itoa(next) + ".0"
+}
+
+// CreateFirewallRule creates a firewall-rule in a scope and appends it to
+// policy.
+func CreateFirewallRule(client contrail.ApiClient, scope contrail.IObject,
+	policy *types.FirewallPolicy, spec *FirewallRuleSpec) (
+	*types.FirewallRule, error) {
+	switch spec.Action {
+	case "pass", "deny":
+	default:
+		return nil, fmt.Errorf("Invalid firewall action %q", spec.Action)
+	}
+	direction := spec.Direction
+	if direction == "" {
+		direction = "<>"
+	}
+	protocol := spec.Protocol
+	if protocol == "" {
+		protocol = "any"
+	}
+	ports := spec.Ports
+	if ports == [2]int{} {
+		ports = [2]int{0, 65535}
+	}
+
+	rule := new(types.FirewallRule)
+	rule.SetParent(scope)
+	rule.SetName(spec.Name)
+	rule.SetActionList(&types.ActionListType{SimpleAction: spec.Action})
+	rule.SetService(&types.FirewallServiceType{
+		Protocol: protocol,
+		SrcPorts: &types.PortType{StartPort: 0, EndPort: 65535},
+		DstPorts: &types.PortType{StartPort: ports[0], EndPort: ports[1]},
+	})
+	rule.SetEndpoint1(&types.FirewallRuleEndpointType{Tags: spec.Endpoint1})
+	rule.SetEndpoint2(&types.FirewallRuleEndpointType{Tags: spec.Endpoint2})
+	rule.SetDirection(direction)
+	if err := client.Create(rule); err != nil {
+		return nil, err
+	}
+
+	refs, err := policy.GetFirewallRuleRefs()
+	if err != nil {
+		client.Delete(rule)
+		return nil, err
+	}
+	policy.AddFirewallRule(rule, types.FirewallSequence{Sequence: nextSequence(refs)})
+	if err := client.Update(policy); err != nil {
+		client.Delete(rule)
+		return nil, err
+	}
+	return rule, nil
+}
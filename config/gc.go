@@ -0,0 +1,115 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/job"
+	"github.com/Juniper/contrail-go-api/reconcile"
+)
+
+// FindOrphans returns the objects of the given types that are owned by
+// manager but are not in the desired set. Desired objects are matched by
+// type and fully qualified name, so they need not have been created.
+func FindOrphans(client contrail.ApiClient, manager string, typenames []string,
+	desired []contrail.IObject) ([]contrail.IObject, error) {
+	keep := make(map[string]bool, len(desired))
+	for _, obj := range desired {
+		keep[obj.GetType()+" "+strings.Join(obj.GetFQName(), ":")] = true
+	}
+	var orphans []contrail.IObject
+	for _, typename := range typenames {
+		owned, err := ListManagedBy(client, typename, manager)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range owned {
+			if !keep[typename+" "+strings.Join(obj.GetFQName(), ":")] {
+				orphans = append(orphans, obj)
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// PruneResult lists the orphans handled by PruneOrphans.
+type PruneResult struct {
+	// Orphans are the objects returned by FindOrphans, in deletion order.
+	Orphans []contrail.IObject
+	// Deleted are the orphans that were deleted.
+	Deleted []contrail.IObject
+	// Pending are the orphans whose deletion is postponed by finalizers,
+	// and the orphans that depend on them (their ancestors and the objects
+	// they refer to), which are not deleted yet.
+	Pending []contrail.IObject
+}
+
+// PruneOrphans deletes the objects returned by FindOrphans. The deletion
+// plan is computed by the reconcile package: children and referring objects
+// are deleted before the objects they depend on. Each deletion goes through
+// RequestDelete, so that ownership is verified against the current state
+// of the object and finalizers are honored. With dryRun set, the orphans
+// are returned but not deleted.
+func PruneOrphans(client contrail.ApiClient, manager string, typenames []string,
+	desired []contrail.IObject, dryRun bool) (*PruneResult, error) {
+	keep := make(map[string]bool, len(desired))
+	for _, obj := range desired {
+		keep[obj.GetType()+" "+strings.Join(obj.GetFQName(), ":")] = true
+	}
+	orphans := make(map[string]contrail.IObject)
+	plan, err := reconcile.ComputePlan(client, &reconcile.State{}, &reconcile.Options{
+		Prune:      true,
+		PruneTypes: typenames,
+		PruneSelector: func(obj contrail.IObject) bool {
+			annotated, ok := obj.(Annotated)
+			if !ok || ManagedBy(annotated) != manager ||
+				keep[obj.GetType()+" "+strings.Join(obj.GetFQName(), ":")] {
+				return false
+			}
+			orphans[obj.GetUuid()] = obj
+			return true
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := new(PruneResult)
+	for _, change := range plan.Changes {
+		result.Orphans = append(result.Orphans, orphans[change.Uuid])
+	}
+	if dryRun {
+		return result, nil
+	}
+	pending := make([]bool, len(plan.Changes))
+	deps := plan.Dependencies()
+	err = job.Schedule(context.Background(), deps, 1,
+		func(ctx context.Context, i int) error {
+			obj := result.Orphans[i]
+			for _, j := range deps[i] {
+				if pending[j] {
+					pending[i] = true
+					result.Pending = append(result.Pending, obj)
+					return nil
+				}
+			}
+			deleted, err := requestDelete(client, obj.(Annotated), manager)
+			if err != nil {
+				return fmt.Errorf("%s %s: %v", obj.GetType(),
+					strings.Join(obj.GetFQName(), ":"), err)
+			}
+			if deleted {
+				result.Deleted = append(result.Deleted, obj)
+			} else {
+				pending[i] = true
+				result.Pending = append(result.Pending, obj)
+			}
+			return nil
+		})
+	return result, err
+}
@@ -0,0 +1,28 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// SystemConfigChange modifies the global-system-config and reports
+// whether it changed anything. Like VrouterConfigChange, it must be
+// idempotent.
+type SystemConfigChange func(config *types.GlobalSystemConfig) (bool, error)
+
+// ModifyGlobalSystemConfig applies change to the current
+// global-system-config, retrying when the object is modified
+// concurrently.
+func ModifyGlobalSystemConfig(client contrail.ApiClient,
+	change SystemConfigChange) error {
+	return modifySingleton(client, contrail.TypeGlobalSystemConfig,
+		contrail.DefaultGlobalSystemConfigFQName(),
+		func() contrail.IObject { return new(types.GlobalSystemConfig) },
+		func(obj contrail.IObject) (bool, error) {
+			return change(obj.(*types.GlobalSystemConfig))
+		})
+}
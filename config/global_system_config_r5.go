@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Actions taken when an interface or network exceeds its MAC limit.
+const (
+	MacLimitActionLog      = "log"
+	MacLimitActionAlarm    = "alarm"
+	MacLimitActionShutdown = "shutdown"
+	MacLimitActionDrop     = "drop"
+)
+
+const maxTwoByteAS = 0xffff
+
+func validAutonomousSystem(asn int, fourByte bool) error {
+	if asn <= 0 || int64(asn) > 0xffffffff {
+		return fmt.Errorf("Invalid autonomous system %d", asn)
+	}
+	if asn > maxTwoByteAS && !fourByte {
+		return fmt.Errorf("Autonomous system %d requires 4 byte AS numbers",
+			asn)
+	}
+	return nil
+}
+
+// SetAutonomousSystem sets the autonomous system of the control nodes.
+// Numbers above 65535 require 4 byte AS numbers, enabled with
+// SetAutonomousSystem4Byte.
+func SetAutonomousSystem(client contrail.ApiClient, asn int) error {
+	return ModifyGlobalSystemConfig(client,
+		func(config *types.GlobalSystemConfig) (bool, error) {
+			if err := validAutonomousSystem(asn,
+				config.GetEnable4byteAs()); err != nil {
+				return false, err
+			}
+			if config.GetAutonomousSystem() == asn {
+				return false, nil
+			}
+			config.SetAutonomousSystem(asn)
+			return true, nil
+		})
+}
+
+// SetAutonomousSystem4Byte sets the autonomous system and enables or
+// disables 4 byte AS numbers in a single update: the two settings cannot
+// be changed separately when moving across the 2 byte boundary.
+func SetAutonomousSystem4Byte(client contrail.ApiClient, asn int,
+	fourByte bool) error {
+	if err := validAutonomousSystem(asn, fourByte); err != nil {
+		return err
+	}
+	return ModifyGlobalSystemConfig(client,
+		func(config *types.GlobalSystemConfig) (bool, error) {
+			if config.GetAutonomousSystem() == asn &&
+				config.GetEnable4byteAs() == fourByte {
+				return false, nil
+			}
+			config.SetAutonomousSystem(asn)
+			config.SetEnable4byteAs(fourByte)
+			return true, nil
+		})
+}
+
+// ValidateGracefulRestart checks the graceful restart parameters against
+// the ranges of RFC 4724 (restart and end-of-RIB times are 12 bit) and
+// the long-lived graceful restart draft (24 bit stale time).
+func ValidateGracefulRestart(params *types.GracefulRestartParametersType) error {
+	if params.RestartTime < 0 || params.RestartTime > 4095 {
+		return fmt.Errorf("Invalid graceful restart time %d", params.RestartTime)
+	}
+	if params.EndOfRibTimeout < 0 || params.EndOfRibTimeout > 4095 {
+		return fmt.Errorf("Invalid end-of-RIB timeout %d", params.EndOfRibTimeout)
+	}
+	if params.LongLivedRestartTime < 0 ||
+		params.LongLivedRestartTime > 0xffffff {
+		return fmt.Errorf("Invalid long-lived graceful restart time %d",
+			params.LongLivedRestartTime)
+	}
+	if params.Enable && params.RestartTime == 0 &&
+		params.LongLivedRestartTime == 0 {
+		return fmt.Errorf("Graceful restart enabled without a restart time")
+	}
+	if !params.Enable && (params.BgpHelperEnable || params.XmppHelperEnable) {
+		return fmt.Errorf("Graceful restart helper mode requires " +
+			"graceful restart to be enabled")
+	}
+	return nil
+}
+
+// SetGracefulRestart sets the graceful restart parameters of the control
+// nodes.
+func SetGracefulRestart(client contrail.ApiClient,
+	params *types.GracefulRestartParametersType) error {
+	if err := ValidateGracefulRestart(params); err != nil {
+		return err
+	}
+	return ModifyGlobalSystemConfig(client,
+		func(config *types.GlobalSystemConfig) (bool, error) {
+			if config.GetGracefulRestartParameters() == *params {
+				return false, nil
+			}
+			config.SetGracefulRestartParameters(params)
+			return true, nil
+		})
+}
+
+// SetMacLimit sets the default number of MAC addresses learnt per
+// interface or network and the action taken when it is exceeded. A limit
+// of 0 disables the control.
+func SetMacLimit(client contrail.ApiClient, limit int, action string) error {
+	switch action {
+	case MacLimitActionLog, MacLimitActionAlarm, MacLimitActionShutdown,
+		MacLimitActionDrop:
+	default:
+		return fmt.Errorf("Invalid MAC limit action %q", action)
+	}
+	if limit < 0 {
+		return fmt.Errorf("Invalid MAC limit %d", limit)
+	}
+	control := types.MACLimitControlType{
+		MacLimit:       limit,
+		MacLimitAction: action,
+	}
+	return ModifyGlobalSystemConfig(client,
+		func(config *types.GlobalSystemConfig) (bool, error) {
+			if config.GetMacLimitControl() == control {
+				return false, nil
+			}
+			config.SetMacLimitControl(&control)
+			return true, nil
+		})
+}
+
+// SetMacAgingTime sets the aging time, in seconds, of the learnt MAC
+// addresses; 0 disables aging.
+func SetMacAgingTime(client contrail.ApiClient, seconds int) error {
+	if seconds < 0 || seconds > 86400 {
+		return fmt.Errorf("Invalid MAC aging time %d", seconds)
+	}
+	return ModifyGlobalSystemConfig(client,
+		func(config *types.GlobalSystemConfig) (bool, error) {
+			if config.GetMacAgingTime() == seconds {
+				return false, nil
+			}
+			config.SetMacAgingTime(seconds)
+			return true, nil
+		})
+}
@@ -0,0 +1,175 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Monitors of a service-health-check.
+const (
+	HealthCheckPing = "PING"
+	HealthCheckHTTP = "HTTP"
+	HealthCheckBFD  = "BFD"
+)
+
+// Scopes of a service-health-check.
+const (
+	HealthCheckLinkLocal = "link-local"
+	HealthCheckEndToEnd  = "end-to-end"
+	HealthCheckSegment   = "segment"
+)
+
+// Expected HTTP status codes: "200", "200-299" or "200,202".
+var expectedCodesPattern = regexp.MustCompile(
+	`^[1-5][0-9]{2}(-[1-5][0-9]{2})?(,[1-5][0-9]{2}(-[1-5][0-9]{2})?)*$`)
+
+// HealthCheckSpec describes a service-health-check.
+type HealthCheckSpec struct {
+	Name string
+	// Monitor is HealthCheckPing, HealthCheckHTTP or HealthCheckBFD.
+	Monitor string
+	// Type defaults to HealthCheckLinkLocal.
+	Type string
+	// Delay between probes and Timeout of a probe. BFD requires
+	// sub-second values; the other monitors whole seconds.
+	Delay      time.Duration
+	Timeout    time.Duration
+	MaxRetries int
+	// URL checked by HTTP monitors: a path, or a URL whose host is the
+	// address checked.
+	URL string
+	// HTTPMethod defaults to GET.
+	HTTPMethod string
+	// ExpectedCodes defaults to 200.
+	ExpectedCodes string
+}
+
+func (spec *HealthCheckSpec) properties() (*types.ServiceHealthCheckType, error) {
+	properties := &types.ServiceHealthCheckType{
+		Enabled:         true,
+		MonitorType:     spec.Monitor,
+		HealthCheckType: spec.Type,
+		Delay:           int(spec.Delay / time.Second),
+		DelayUsecs:      int(spec.Delay % time.Second / time.Microsecond),
+		Timeout:         int(spec.Timeout / time.Second),
+		TimeoutUsecs:    int(spec.Timeout % time.Second / time.Microsecond),
+		MaxRetries:      spec.MaxRetries,
+	}
+	if properties.HealthCheckType == "" {
+		properties.HealthCheckType = HealthCheckLinkLocal
+	}
+	switch properties.HealthCheckType {
+	case HealthCheckLinkLocal, HealthCheckEndToEnd, HealthCheckSegment:
+	default:
+		return nil, fmt.Errorf("Invalid health check type %q", spec.Type)
+	}
+	if spec.Delay <= 0 || spec.Timeout <= 0 || spec.MaxRetries <= 0 {
+		return nil, fmt.Errorf("Health check %s: delay, timeout and retries "+
+			"must be positive", spec.Name)
+	}
+	if spec.Timeout > spec.Delay {
+		return nil, fmt.Errorf("Health check %s: timeout exceeds the delay "+
+			"between probes", spec.Name)
+	}
+
+	switch spec.Monitor {
+	case HealthCheckPing:
+	case HealthCheckHTTP:
+		if spec.URL == "" {
+			return nil, fmt.Errorf("HTTP health check %s: no URL", spec.Name)
+		}
+		if _, err := url.Parse(spec.URL); err != nil {
+			return nil, fmt.Errorf("HTTP health check %s: %v", spec.Name, err)
+		}
+		properties.UrlPath = spec.URL
+		properties.HttpMethod = spec.HTTPMethod
+		if properties.HttpMethod == "" {
+			properties.HttpMethod = "GET"
+		}
+		properties.ExpectedCodes = spec.ExpectedCodes
+		if properties.ExpectedCodes == "" {
+			properties.ExpectedCodes = "200"
+		}
+		if !expectedCodesPattern.MatchString(properties.ExpectedCodes) {
+			return nil, fmt.Errorf("HTTP health check %s: invalid expected "+
+				"codes %q", spec.Name, properties.ExpectedCodes)
+		}
+	case HealthCheckBFD:
+		if properties.HealthCheckType == HealthCheckEndToEnd {
+			return nil, fmt.Errorf("BFD health check %s: end-to-end checks "+
+				"are not supported", spec.Name)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid health check monitor %q", spec.Monitor)
+	}
+	if spec.Monitor != HealthCheckBFD &&
+		(spec.Delay%time.Second != 0 || spec.Timeout%time.Second != 0) {
+		return nil, fmt.Errorf("%s health check %s: timers must be whole "+
+			"seconds", spec.Monitor, spec.Name)
+	}
+	if spec.Monitor != HealthCheckHTTP && (spec.URL != "" ||
+		spec.HTTPMethod != "" || spec.ExpectedCodes != "") {
+		return nil, fmt.Errorf("%s health check %s: HTTP settings not "+
+			"applicable", spec.Monitor, spec.Name)
+	}
+	return properties, nil
+}
+
+// CreateHealthCheck creates a service-health-check in project.
+func CreateHealthCheck(client contrail.ApiClient, project *types.Project,
+	spec *HealthCheckSpec) (*types.ServiceHealthCheck, error) {
+	properties, err := spec.properties()
+	if err != nil {
+		return nil, err
+	}
+	check := new(types.ServiceHealthCheck)
+	check.SetParent(project)
+	check.SetName(spec.Name)
+	check.SetServiceHealthCheckProperties(properties)
+	if err := client.Create(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// AttachHealthCheck monitors a virtual-machine-interface with a
+// link-local or segment service-health-check. End-to-end checks apply to
+// service instances.
+func AttachHealthCheck(client contrail.ApiClient,
+	vmi *types.VirtualMachineInterface, check *types.ServiceHealthCheck) error {
+	if check.GetServiceHealthCheckProperties().HealthCheckType ==
+		HealthCheckEndToEnd {
+		return fmt.Errorf("Health check %s is end-to-end", check.GetName())
+	}
+	refs, err := vmi.GetServiceHealthCheckRefs()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Uuid == check.GetUuid() {
+			return nil
+		}
+	}
+	vmi.AddServiceHealthCheck(check)
+	return client.Update(vmi)
+}
+
+// DetachHealthCheck removes a service-health-check from a
+// virtual-machine-interface.
+func DetachHealthCheck(client contrail.ApiClient,
+	vmi *types.VirtualMachineInterface, check *types.ServiceHealthCheck) error {
+	vmi.DeleteServiceHealthCheck(check.GetUuid())
+	return client.Update(vmi)
+}
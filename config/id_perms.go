@@ -0,0 +1,115 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// IdPermsObject is implemented by the generated types.
+type IdPermsObject interface {
+	contrail.IObject
+	GetIdPerms() types.IdPermsType
+	SetIdPerms(value *types.IdPermsType)
+}
+
+// UuidToIdPerms splits a uuid into the most and least significant halves
+// stored in id_perms.
+func UuidToIdPerms(uuid string) (*types.UuidType, error) {
+	data, err := hex.DecodeString(strings.Replace(uuid, "-", "", -1))
+	if err != nil || len(data) != 16 {
+		return nil, fmt.Errorf("Invalid uuid %s", uuid)
+	}
+	return &types.UuidType{
+		UuidMslong: binary.BigEndian.Uint64(data[:8]),
+		UuidLslong: binary.BigEndian.Uint64(data[8:]),
+	}, nil
+}
+
+// IdPermsToUuid returns the uuid stored in id_perms, in canonical form.
+func IdPermsToUuid(value *types.UuidType) string {
+	var data [16]byte
+	binary.BigEndian.PutUint64(data[:8], value.UuidMslong)
+	binary.BigEndian.PutUint64(data[8:], value.UuidLslong)
+	s := hex.EncodeToString(data[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// CheckIdPerms verifies that the id_perms uuid mirrors the object uuid.
+func CheckIdPerms(obj IdPermsObject) error {
+	perms := obj.GetIdPerms()
+	if perms.Uuid == nil || obj.GetUuid() == "" {
+		return nil
+	}
+	if uuid := IdPermsToUuid(perms.Uuid); uuid != strings.ToLower(obj.GetUuid()) {
+		return fmt.Errorf("%s %s: id_perms uuid %s doesn't match", obj.GetType(),
+			obj.GetUuid(), uuid)
+	}
+	return nil
+}
+
+// ModifyIdPerms applies fn to the id_perms of obj. The uuid is set from
+// the object uuid, or cleared for objects that are not created yet so that
+// the API server assigns it. The timestamps, which are maintained by the
+// API server, are not sent.
+func ModifyIdPerms(obj IdPermsObject, fn func(perms *types.IdPermsType)) error {
+	perms := obj.GetIdPerms()
+	fn(&perms)
+	perms.Uuid = nil
+	if obj.GetUuid() != "" {
+		uuid, err := UuidToIdPerms(obj.GetUuid())
+		if err != nil {
+			return err
+		}
+		perms.Uuid = uuid
+	}
+	perms.Created = ""
+	perms.LastModified = ""
+	obj.SetIdPerms(&perms)
+	return nil
+}
+
+// SetEnabled sets the administrative state of obj.
+func SetEnabled(obj IdPermsObject, enabled bool) error {
+	return ModifyIdPerms(obj, func(perms *types.IdPermsType) {
+		perms.Enable = enabled
+	})
+}
+
+// SetDescription sets the description of obj.
+func SetDescription(obj IdPermsObject, description string) error {
+	return ModifyIdPerms(obj, func(perms *types.IdPermsType) {
+		perms.Description = description
+	})
+}
+
+// SetUserVisible controls whether obj is displayed to users.
+func SetUserVisible(obj IdPermsObject, visible bool) error {
+	return ModifyIdPerms(obj, func(perms *types.IdPermsType) {
+		perms.UserVisible = visible
+	})
+}
+
+// SetCreator records the creator of obj.
+func SetCreator(obj IdPermsObject, creator string) error {
+	return ModifyIdPerms(obj, func(perms *types.IdPermsType) {
+		perms.Creator = creator
+	})
+}
+
+// UpdateIdPerms applies fn to the id_perms of obj and updates the object.
+func UpdateIdPerms(client contrail.ApiClient, obj IdPermsObject,
+	fn func(perms *types.IdPermsType)) error {
+	if err := ModifyIdPerms(obj, fn); err != nil {
+		return err
+	}
+	return client.Update(obj)
+}
@@ -0,0 +1,293 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// findSubnet locates the subnet of network that matches prefix (e.g.
+// "10.0.0.0/24"). It returns the index of the ipam reference and of the
+// subnet within the reference attribute.
+func findSubnet(network *types.VirtualNetwork, prefix string) (
+	contrail.ReferenceList, int, int, error) {
+	refs, err := network.GetNetworkIpamRefs()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for i, ref := range refs {
+		attr := ref.Attr.(types.VnSubnetsType)
+		for j, entry := range attr.IpamSubnets {
+			if subnetTypeStringRepr(entry.Subnet) == prefix {
+				return refs, i, j, nil
+			}
+		}
+	}
+	return nil, 0, 0, fmt.Errorf("Prefix %s not associated with network %s",
+		prefix, network.GetName())
+}
+
+// subnetContaining returns the subnet of network that contains address.
+func subnetContaining(network *types.VirtualNetwork, address net.IP) (
+	*types.IpamSubnetType, error) {
+	refs, err := network.GetNetworkIpamRefs()
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		attr := ref.Attr.(types.VnSubnetsType)
+		for i := range attr.IpamSubnets {
+			entry := &attr.IpamSubnets[i]
+			_, ipnet, err := net.ParseCIDR(subnetTypeStringRepr(entry.Subnet))
+			if err == nil && ipnet.Contains(address) {
+				return entry, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s is not in a subnet of network %s", address,
+		network.GetName())
+}
+
+// detailLister is implemented by contrail.Client.
+type detailLister interface {
+	ListDetailWithOptions(typename string, opts ...contrail.ListOption) (
+		[]contrail.IObject, error)
+}
+
+// networkInstanceIps reads the instance-ips of a network, with their
+// address only.
+func networkInstanceIps(client contrail.ApiClient, network *types.VirtualNetwork) (
+	[]contrail.IObject, error) {
+	if lister, ok := client.(detailLister); ok {
+		return lister.ListDetailWithOptions("instance-ip",
+			contrail.ListBackRef(network.GetUuid()),
+			contrail.ListFields("instance_ip_address"))
+	}
+	if err := client.GetField(network, "instance_ip_back_refs"); err != nil {
+		return nil, err
+	}
+	refs, err := network.GetInstanceIpBackRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	uuids := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		uuids[ref.Uuid] = true
+	}
+	objects, err := client.ListDetail("instance-ip", []string{"instance_ip_address"})
+	if err != nil {
+		return nil, err
+	}
+	var result []contrail.IObject
+	for _, obj := range objects {
+		if uuids[obj.GetUuid()] {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+// UsedAddresses returns the addresses of the instance-ips of a network.
+func UsedAddresses(client contrail.ApiClient, network *types.VirtualNetwork) (
+	[]string, error) {
+	objects, err := networkInstanceIps(client, network)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, obj := range objects {
+		if address := obj.(*types.InstanceIp).GetInstanceIpAddress(); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+func compareIP(a, b net.IP) int {
+	return bytes.Compare(a.To16(), b.To16())
+}
+
+// allocationRanges returns the address ranges of a subnet from which
+// addresses are allocated: the allocation pools or, when there are none,
+// the whole subnet except the network and broadcast addresses. Subnets of
+// one or two addresses (/31, /32, /127 and /128) have neither.
+func allocationRanges(subnet *types.IpamSubnetType) ([][2]net.IP, error) {
+	var ranges [][2]net.IP
+	for _, pool := range subnet.AllocationPools {
+		start, end := net.ParseIP(pool.Start), net.ParseIP(pool.End)
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("Invalid allocation pool %s-%s",
+				pool.Start, pool.End)
+		}
+		ranges = append(ranges, [2]net.IP{start, end})
+	}
+	if len(ranges) > 0 {
+		return ranges, nil
+	}
+	_, ipnet, err := net.ParseCIDR(subnetTypeStringRepr(subnet.Subnet))
+	if err != nil {
+		return nil, err
+	}
+	first := ipnet.IP
+	if v4 := first.To4(); v4 != nil {
+		first = v4
+	}
+	last := make(net.IP, len(first))
+	for i := range first {
+		last[i] = first[i] | ^ipnet.Mask[i]
+	}
+	if ones, bits := ipnet.Mask.Size(); bits-ones <= 1 {
+		return [][2]net.IP{{first, last}}, nil
+	}
+	if first.To4() != nil {
+		// Exclude the broadcast address.
+		last[len(last)-1]--
+	}
+	return [][2]net.IP{{nextIP(first), last}}, nil
+}
+
+// FreeAddresses returns up to max addresses of a subnet (e.g.
+// "10.0.0.0/24") of network that are not used by an instance-ip. The
+// addresses are taken from the allocation pools of the subnet, if any,
+// and exclude the gateway and DNS server addresses. The result is only
+// indicative: an address can be allocated concurrently by another client.
+func FreeAddresses(client contrail.ApiClient, network *types.VirtualNetwork,
+	prefix string, max int) ([]string, error) {
+	if max <= 0 {
+		return nil, fmt.Errorf("Invalid number of addresses %d", max)
+	}
+	refs, i, j, err := findSubnet(network, prefix)
+	if err != nil {
+		return nil, err
+	}
+	subnet := refs[i].Attr.(types.VnSubnetsType).IpamSubnets[j]
+	ranges, err := allocationRanges(&subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := UsedAddresses(client, network)
+	if err != nil {
+		return nil, err
+	}
+	reserved := make(map[string]bool, len(used)+2)
+	for _, address := range append(used, subnet.DefaultGateway,
+		subnet.DnsServerAddress) {
+		if ip := net.ParseIP(address); ip != nil {
+			reserved[ip.String()] = true
+		}
+	}
+
+	var free []string
+	for _, r := range ranges {
+		for ip := r[0]; compareIP(ip, r[1]) <= 0; ip = nextIP(ip) {
+			if !reserved[ip.String()] {
+				free = append(free, ip.String())
+				if len(free) == max {
+					return free, nil
+				}
+			}
+			if ip.Equal(r[1]) {
+				break
+			}
+		}
+	}
+	return free, nil
+}
+
+func reservationName(network *types.VirtualNetwork, address net.IP) string {
+	return network.GetUuid() + "-" + address.String()
+}
+
+// ReserveAddress creates an instance-ip with a specific address in
+// network, so that the address is not allocated to other instances.
+func ReserveAddress(client contrail.ApiClient, network *types.VirtualNetwork,
+	address string) (*types.InstanceIp, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("%s is not a valid IP address", address)
+	}
+	if _, err := subnetContaining(network, ip); err != nil {
+		return nil, err
+	}
+	iip := new(types.InstanceIp)
+	iip.SetName(reservationName(network, ip))
+	iip.SetInstanceIpAddress(ip.String())
+	iip.AddVirtualNetwork(network)
+	if err := client.Create(iip); err != nil {
+		return nil, err
+	}
+	return iip, nil
+}
+
+// ReleaseAddress deletes an instance-ip created by ReserveAddress.
+func ReleaseAddress(client contrail.ApiClient, network *types.VirtualNetwork,
+	address string) error {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return fmt.Errorf("%s is not a valid IP address", address)
+	}
+	obj, err := client.FindByName("instance-ip", reservationName(network, ip))
+	if err != nil {
+		return err
+	}
+	return client.Delete(obj)
+}
+
+// SetAllocationPools replaces the allocation pools of a subnet of network.
+// Pools must be contained in the subnet.
+func SetAllocationPools(client contrail.ApiClient, network *types.VirtualNetwork,
+	prefix string, pools []types.AllocationPoolType) error {
+	refs, i, j, err := findSubnet(network, prefix)
+	if err != nil {
+		return err
+	}
+	_, ipnet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		start, end := net.ParseIP(pool.Start), net.ParseIP(pool.End)
+		if start == nil || end == nil || !ipnet.Contains(start) ||
+			!ipnet.Contains(end) || compareIP(start, end) > 0 {
+			return fmt.Errorf("Invalid allocation pool %s-%s for subnet %s",
+				pool.Start, pool.End, prefix)
+		}
+	}
+
+	attr := refs[i].Attr.(types.VnSubnetsType)
+	subnets := make([]types.IpamSubnetType, len(attr.IpamSubnets))
+	copy(subnets, attr.IpamSubnets)
+	subnets[j].AllocationPools = pools
+	attr.IpamSubnets = subnets
+
+	ipam, err := types.NetworkIpamByUuid(client, refs[i].Uuid)
+	if err != nil {
+		return err
+	}
+	network.DeleteNetworkIpam(refs[i].Uuid)
+	network.AddNetworkIpam(ipam, attr)
+	return client.Update(network)
+}
@@ -0,0 +1,154 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Directions of the mirrored traffic.
+const (
+	MirrorIngress = "ingress"
+	MirrorEgress  = "egress"
+	MirrorBoth    = "both"
+)
+
+// DefaultAnalyzerPort is the UDP port of the analyzers (e.g. the
+// analytics collector).
+const DefaultAnalyzerPort = 8099
+
+// StaticVtep is the tunnel endpoint of an analyzer reached without a
+// Juniper header, using a static next hop.
+type StaticVtep struct {
+	IP  string
+	MAC string
+	VNI int
+}
+
+// MirrorSpec describes where the traffic of an interface is mirrored.
+type MirrorSpec struct {
+	// Direction defaults to MirrorBoth.
+	Direction    string
+	AnalyzerName string
+	AnalyzerIP   string
+	// UDPPort defaults to DefaultAnalyzerPort.
+	UDPPort int
+	// AnalyzerNetwork is the virtual-network of the analyzer. Optional
+	// with a Juniper header; required without one, unless StaticVtep is
+	// set.
+	AnalyzerNetwork *types.VirtualNetwork
+	// JuniperHeader prepends the Juniper header (with the interface
+	// metadata) to the mirrored packets.
+	JuniperHeader bool
+	AnalyzerMAC   string
+	StaticVtep    *StaticVtep
+}
+
+func (spec *MirrorSpec) action() (*types.MirrorActionType, error) {
+	if net.ParseIP(spec.AnalyzerIP) == nil {
+		return nil, fmt.Errorf("Invalid analyzer address %q", spec.AnalyzerIP)
+	}
+	action := &types.MirrorActionType{
+		AnalyzerName:      spec.AnalyzerName,
+		AnalyzerIpAddress: spec.AnalyzerIP,
+		UdpPort:           spec.UDPPort,
+		JuniperHeader:     spec.JuniperHeader,
+		NhMode:            "dynamic",
+	}
+	if action.UdpPort == 0 {
+		action.UdpPort = DefaultAnalyzerPort
+	}
+	if action.UdpPort < 0 || action.UdpPort > 65535 {
+		return nil, fmt.Errorf("Invalid analyzer port %d", spec.UDPPort)
+	}
+	if spec.AnalyzerNetwork != nil {
+		fqn := spec.AnalyzerNetwork.GetFQName()
+		// The default routing-instance of a network has the network name.
+		action.RoutingInstance = strings.Join(
+			append(append([]string(nil), fqn...), fqn[len(fqn)-1]), ":")
+	}
+	if spec.AnalyzerMAC != "" {
+		if _, err := net.ParseMAC(spec.AnalyzerMAC); err != nil {
+			return nil, fmt.Errorf("Invalid analyzer MAC address %q",
+				spec.AnalyzerMAC)
+		}
+		action.AnalyzerMacAddress = spec.AnalyzerMAC
+	}
+
+	if vtep := spec.StaticVtep; vtep != nil {
+		if spec.JuniperHeader {
+			return nil, fmt.Errorf("Static next hop mirroring requires the " +
+				"Juniper header to be disabled")
+		}
+		if net.ParseIP(vtep.IP) == nil {
+			return nil, fmt.Errorf("Invalid VTEP address %q", vtep.IP)
+		}
+		if vtep.VNI <= 0 || vtep.VNI > 1<<24-1 {
+			return nil, fmt.Errorf("Invalid VNI %d", vtep.VNI)
+		}
+		action.NhMode = "static"
+		action.StaticNhHeader = &types.StaticMirrorNhType{
+			VtepDstIpAddress:  vtep.IP,
+			VtepDstMacAddress: vtep.MAC,
+			Vni:               vtep.VNI,
+		}
+		return action, nil
+	}
+	if !spec.JuniperHeader {
+		// Without the header the vrouter forwards the packets on L2, in
+		// the routing-instance of the analyzer.
+		if action.RoutingInstance == "" || action.AnalyzerMacAddress == "" {
+			return nil, fmt.Errorf("Mirroring without the Juniper header " +
+				"requires the analyzer network and MAC address")
+		}
+	}
+	return action, nil
+}
+
+// EnableMirror mirrors the traffic of vmi to the analyzer in spec.
+func EnableMirror(client contrail.ApiClient, vmi *types.VirtualMachineInterface,
+	spec *MirrorSpec) error {
+	direction := spec.Direction
+	if direction == "" {
+		direction = MirrorBoth
+	}
+	switch direction {
+	case MirrorIngress, MirrorEgress, MirrorBoth:
+	default:
+		return fmt.Errorf("Invalid mirror direction %q", spec.Direction)
+	}
+	action, err := spec.action()
+	if err != nil {
+		return err
+	}
+	properties := vmi.GetVirtualMachineInterfaceProperties()
+	properties.InterfaceMirror = &types.InterfaceMirrorType{
+		TrafficDirection: direction,
+		MirrorTo:         action,
+	}
+	vmi.SetVirtualMachineInterfaceProperties(&properties)
+	return client.Update(vmi)
+}
+
+// DisableMirror stops the mirroring of the traffic of vmi, leaving the
+// other interface properties unchanged.
+func DisableMirror(client contrail.ApiClient,
+	vmi *types.VirtualMachineInterface) error {
+	properties := vmi.GetVirtualMachineInterfaceProperties()
+	if properties.InterfaceMirror == nil {
+		return nil
+	}
+	properties.InterfaceMirror = nil
+	vmi.SetVirtualMachineInterfaceProperties(&properties)
+	return client.Update(vmi)
+}
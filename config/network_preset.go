@@ -0,0 +1,121 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Forwarding modes of a virtual-network.
+const (
+	ForwardingModeL2   = "l2"
+	ForwardingModeL3   = "l3"
+	ForwardingModeL2L3 = "l2_l3"
+)
+
+// A NetworkPreset configures a virtual-network for a common use case.
+// Presets are applied in order, then each one checks that the result is
+// still consistent with it, so that conflicting presets are reported
+// rather than silently overridden.
+type NetworkPreset struct {
+	apply func(network *types.VirtualNetwork) error
+	// check is called with the number of subnets of the network.
+	check func(network *types.VirtualNetwork, subnets int) error
+}
+
+func forwardingMode(network *types.VirtualNetwork) string {
+	return network.GetVirtualNetworkProperties().ForwardingMode
+}
+
+func setForwardingMode(network *types.VirtualNetwork, mode string) {
+	properties := network.GetVirtualNetworkProperties()
+	properties.ForwardingMode = mode
+	network.SetVirtualNetworkProperties(&properties)
+}
+
+func checkForwardingMode(preset string, network *types.VirtualNetwork,
+	mode string) error {
+	if current := forwardingMode(network); current != mode {
+		return fmt.Errorf("%s network: forwarding mode changed to %s",
+			preset, current)
+	}
+	return nil
+}
+
+// L2Only is a bridged network: traffic is switched on MAC addresses and
+// is not routed, not even between addresses of the network's subnets.
+var L2Only = NetworkPreset{
+	apply: func(network *types.VirtualNetwork) error {
+		setForwardingMode(network, ForwardingModeL2)
+		return nil
+	},
+	check: func(network *types.VirtualNetwork, subnets int) error {
+		return checkForwardingMode("L2-only", network, ForwardingModeL2)
+	},
+}
+
+// L3Only is a routed network: all traffic, including traffic within a
+// subnet, is routed by the vrouter (ARP requests are answered with the
+// vrouter's MAC address). It requires at least one subnet.
+var L3Only = NetworkPreset{
+	apply: func(network *types.VirtualNetwork) error {
+		setForwardingMode(network, ForwardingModeL3)
+		return nil
+	},
+	check: func(network *types.VirtualNetwork, subnets int) error {
+		if subnets == 0 {
+			return fmt.Errorf("L3-only network: no subnet")
+		}
+		return checkForwardingMode("L3-only", network, ForwardingModeL3)
+	},
+}
+
+// ApplyNetworkPresets configures network according to presets.
+// subnets is the number of subnets the network has.
+func ApplyNetworkPresets(network *types.VirtualNetwork, subnets int,
+	presets ...NetworkPreset) error {
+	for _, preset := range presets {
+		if err := preset.apply(network); err != nil {
+			return err
+		}
+	}
+	for _, preset := range presets {
+		if err := preset.check(network, subnets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateNetworkWithPresets creates a virtual-network in project with the
+// subnets (from the default network-ipam) and presets.
+func CreateNetworkWithPresets(client contrail.ApiClient,
+	project *types.Project, name string, subnets []string,
+	presets ...NetworkPreset) (*types.VirtualNetwork, error) {
+	network := new(types.VirtualNetwork)
+	network.SetParent(project)
+	network.SetName(name)
+	for _, prefix := range subnets {
+		subnet, err := makeSubnet(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if err := networkAddSubnet(client, project, network, subnet,
+			nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := ApplyNetworkPresets(network, len(subnets),
+		presets...); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if err := client.Create(network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
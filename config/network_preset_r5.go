@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// ProviderNetwork maps the network onto VLAN segmentationId of the
+// physical network (as named in the vrouter/SR-IOV configuration of the
+// compute nodes). A provider network is not source NATed.
+func ProviderNetwork(physicalNetwork string, segmentationId int) NetworkPreset {
+	return NetworkPreset{
+		apply: func(network *types.VirtualNetwork) error {
+			if physicalNetwork == "" {
+				return fmt.Errorf("Provider network: no physical network")
+			}
+			if segmentationId < 1 || segmentationId > 4094 {
+				return fmt.Errorf("Provider network: invalid VLAN %d",
+					segmentationId)
+			}
+			network.SetProviderProperties(&types.ProviderDetails{
+				PhysicalNetwork: physicalNetwork,
+				SegmentationId:  segmentationId,
+			})
+			network.SetIsProviderNetwork(true)
+			return nil
+		},
+		check: func(network *types.VirtualNetwork, subnets int) error {
+			if network.GetFabricSnat() {
+				return fmt.Errorf("Provider network: source NAT enabled")
+			}
+			return nil
+		},
+	}
+}
+
+// SNATRouted is a routed network whose traffic to destinations outside
+// the cluster is source NATed to the compute node's fabric address.
+var SNATRouted = NetworkPreset{
+	apply: func(network *types.VirtualNetwork) error {
+		if forwardingMode(network) == "" {
+			setForwardingMode(network, ForwardingModeL3)
+		}
+		network.SetFabricSnat(true)
+		return nil
+	},
+	check: func(network *types.VirtualNetwork, subnets int) error {
+		if subnets == 0 {
+			return fmt.Errorf("SNAT network: no subnet")
+		}
+		if forwardingMode(network) == ForwardingModeL2 {
+			return fmt.Errorf("SNAT network: source NAT requires routing")
+		}
+		if network.GetIsProviderNetwork() {
+			return fmt.Errorf("SNAT network: provider networks are not NATed")
+		}
+		return nil
+	},
+}
@@ -0,0 +1,226 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Annotations used to coordinate controllers that share an API server.
+const (
+	// ManagedByAnnotation identifies the controller instance that owns
+	// an object.
+	ManagedByAnnotation = "managed_by"
+	// FinalizerAnnotationPrefix prefixes the annotations of the
+	// controllers that must release an object before it is deleted
+	// ("finalizer.<name>"). Each controller sets and removes its own
+	// annotation, so that concurrent changes don't overwrite each other.
+	FinalizerAnnotationPrefix = "finalizer."
+	// DeletionRequestedAnnotation is set on objects that are waiting for
+	// their finalizers to be removed.
+	DeletionRequestedAnnotation = "deletion_requested"
+)
+
+// OwnershipError is returned when an operation is attempted on an object
+// owned by a different controller.
+type OwnershipError struct {
+	Type    string
+	FQName  []string
+	Owner   string
+	Manager string
+}
+
+func (e *OwnershipError) Error() string {
+	owner := e.Owner
+	if owner == "" {
+		owner = "no manager"
+	}
+	return fmt.Sprintf("%s %s is managed by %s, not %s", e.Type,
+		strings.Join(e.FQName, ":"), owner, e.Manager)
+}
+
+// SetManagedBy marks obj as owned by manager. The change is sent to the API
+// server by the next Create or Update.
+func SetManagedBy(obj Annotated, manager string) {
+	SetAnnotation(obj, ManagedByAnnotation, manager)
+}
+
+// ManagedBy returns the owner of obj, if any.
+func ManagedBy(obj Annotated) string {
+	manager, _ := GetAnnotation(obj, ManagedByAnnotation)
+	return manager
+}
+
+// CheckManagedBy returns an OwnershipError unless obj is owned by manager.
+func CheckManagedBy(obj Annotated, manager string) error {
+	if owner := ManagedBy(obj); owner != manager {
+		return &OwnershipError{obj.GetType(), obj.GetFQName(), owner, manager}
+	}
+	return nil
+}
+
+// ListManagedBy returns the objects of a type owned by manager.
+func ListManagedBy(client contrail.ApiClient, typename, manager string) (
+	[]contrail.IObject, error) {
+	objects, err := client.ListDetail(typename, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []contrail.IObject
+	for _, obj := range objects {
+		if annotated, ok := obj.(Annotated); ok && ManagedBy(annotated) == manager {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+// readAnnotated reads the current state of obj from the API server.
+func readAnnotated(client contrail.ApiClient, obj Annotated) (Annotated, error) {
+	current, err := client.FindByUuid(obj.GetType(), obj.GetUuid())
+	if err != nil {
+		return nil, err
+	}
+	annotated, ok := current.(Annotated)
+	if !ok {
+		return nil, fmt.Errorf("%s has no annotations", obj.GetType())
+	}
+	return annotated, nil
+}
+
+// updateCurrentAnnotations applies annotation changes to obj. Without
+// element updates, the whole annotations property is sent: the changes
+// are then applied to the current state of the object, so that the
+// annotations set concurrently by other clients are preserved.
+func updateCurrentAnnotations(client contrail.ApiClient, obj Annotated,
+	set map[string]string, remove []string) error {
+	if _, ok := client.(propCollectionUpdater); ok {
+		return UpdateAnnotations(client, obj, set, remove)
+	}
+	current, err := readAnnotated(client, obj)
+	if err != nil {
+		return err
+	}
+	if err := UpdateAnnotations(client, current, set, remove); err != nil {
+		return err
+	}
+	for key, value := range set {
+		SetAnnotation(obj, key, value)
+	}
+	for _, key := range remove {
+		DeleteAnnotation(obj, key)
+	}
+	return nil
+}
+
+// Finalizers returns the controllers that hold obj, sorted by name.
+func Finalizers(obj Annotated) []string {
+	var finalizers []string
+	for _, kv := range obj.GetAnnotations().KeyValuePair {
+		if strings.HasPrefix(kv.Key, FinalizerAnnotationPrefix) {
+			finalizers = append(finalizers,
+				strings.TrimPrefix(kv.Key, FinalizerAnnotationPrefix))
+		}
+	}
+	sort.Strings(finalizers)
+	return finalizers
+}
+
+// deleteIfReleased deletes obj if its deletion was requested and no
+// finalizers remain, as per its current state. It returns true if the
+// object is deleted; an object that was already deleted is not an error.
+func deleteIfReleased(client contrail.ApiClient, obj Annotated) (bool, error) {
+	current, err := readAnnotated(client, obj)
+	if err != nil {
+		if isNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if !DeletionRequested(current) || len(Finalizers(current)) > 0 {
+		return false, nil
+	}
+	if err := client.Delete(current); err != nil && !isNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddFinalizer registers name as a holder of obj: deletions requested with
+// RequestDelete are postponed until name calls RemoveFinalizer. It fails
+// once the deletion of obj has been requested.
+//
+// The finalizer is set before the deletion request is checked, and
+// RequestDelete marks the object before it checks the finalizers: of two
+// concurrent calls, at least one sees the other. When AddFinalizer sees the
+// request, it releases the object again.
+func AddFinalizer(client contrail.ApiClient, obj Annotated, name string) error {
+	key := FinalizerAnnotationPrefix + name
+	if err := updateCurrentAnnotations(client, obj,
+		map[string]string{key: name}, nil); err != nil {
+		return err
+	}
+	current, err := readAnnotated(client, obj)
+	if err != nil {
+		return err
+	}
+	if !DeletionRequested(current) {
+		return nil
+	}
+	if err := RemoveFinalizer(client, obj, name); err != nil && !isNotFound(err) {
+		return err
+	}
+	return fmt.Errorf("%s %s: deletion requested", obj.GetType(),
+		strings.Join(obj.GetFQName(), ":"))
+}
+
+// RemoveFinalizer releases obj. The object is deleted if a deletion was
+// requested and no finalizers remain.
+func RemoveFinalizer(client contrail.ApiClient, obj Annotated, name string) error {
+	key := FinalizerAnnotationPrefix + name
+	if err := updateCurrentAnnotations(client, obj, nil, []string{key}); err != nil {
+		return err
+	}
+	_, err := deleteIfReleased(client, obj)
+	return err
+}
+
+// DeletionRequested returns true if RequestDelete was called on obj.
+func DeletionRequested(obj Annotated) bool {
+	_, requested := GetAnnotation(obj, DeletionRequestedAnnotation)
+	return requested
+}
+
+// RequestDelete deletes an object owned by manager. The ownership is
+// verified against the current state of the object. The object is marked
+// for deletion first, and deleted if it has no finalizers; otherwise the
+// last finalizer to be removed deletes it.
+func RequestDelete(client contrail.ApiClient, obj Annotated, manager string) error {
+	_, err := requestDelete(client, obj, manager)
+	return err
+}
+
+// requestDelete is RequestDelete; it returns true if the object is deleted,
+// false if its deletion is postponed by finalizers.
+func requestDelete(client contrail.ApiClient, obj Annotated, manager string) (
+	bool, error) {
+	current, err := readAnnotated(client, obj)
+	if err != nil {
+		return false, err
+	}
+	if err := CheckManagedBy(current, manager); err != nil {
+		return false, err
+	}
+	if err := UpdateAnnotations(client, current, map[string]string{
+		DeletionRequestedAnnotation: manager,
+	}, nil); err != nil {
+		return false, err
+	}
+	return deleteIfReleased(client, current)
+}
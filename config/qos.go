@@ -0,0 +1,245 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Types of qos-config: project configurations apply to the interfaces
+// and networks they are attached to; vhost and fabric ones (created under
+// the global-qos-config) to the traffic of the compute nodes.
+const (
+	QosConfigProject = "project"
+	QosConfigVhost   = "vhost"
+	QosConfigFabric  = "fabric"
+)
+
+const maxForwardingClassId = 255
+
+// QosMap maps the DSCP (0-63), 802.1p (0-7) or MPLS EXP (0-7) value of
+// packets to the id of a forwarding-class.
+type QosMap map[int]int
+
+func qosPairs(field string, entries QosMap, maxKey int) (
+	*types.QosIdForwardingClassPairs, error) {
+	pairs := new(types.QosIdForwardingClassPairs)
+	keys := make([]int, 0, len(entries))
+	for key := range entries {
+		if key < 0 || key > maxKey {
+			return nil, fmt.Errorf("Invalid %s value %d (0-%d)", field, key,
+				maxKey)
+		}
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	for _, key := range keys {
+		id := entries[key]
+		if id < 0 || id > maxForwardingClassId {
+			return nil, fmt.Errorf("%s %d: invalid forwarding class %d",
+				field, key, id)
+		}
+		pairs.QosIdForwardingClassPair = append(pairs.QosIdForwardingClassPair,
+			types.QosIdForwardingClassPair{Key: key, ForwardingClassId: id})
+	}
+	return pairs, nil
+}
+
+// QosConfigSpec describes a qos-config.
+type QosConfigSpec struct {
+	Name string
+	// Type defaults to QosConfigProject.
+	Type                   string
+	DefaultForwardingClass int
+	Dscp                   QosMap
+	VlanPriority           QosMap
+	MplsExp                QosMap
+}
+
+func setQosConfigSpec(qos *types.QosConfig, spec *QosConfigSpec) error {
+	qosType := spec.Type
+	if qosType == "" {
+		qosType = QosConfigProject
+	}
+	switch qosType {
+	case QosConfigProject, QosConfigVhost, QosConfigFabric:
+	default:
+		return fmt.Errorf("Invalid qos-config type %q", spec.Type)
+	}
+	if spec.DefaultForwardingClass < 0 ||
+		spec.DefaultForwardingClass > maxForwardingClassId {
+		return fmt.Errorf("Invalid forwarding class %d",
+			spec.DefaultForwardingClass)
+	}
+	dscp, err := qosPairs("DSCP", spec.Dscp, 63)
+	if err != nil {
+		return err
+	}
+	vlan, err := qosPairs("802.1p", spec.VlanPriority, 7)
+	if err != nil {
+		return err
+	}
+	exp, err := qosPairs("MPLS EXP", spec.MplsExp, 7)
+	if err != nil {
+		return err
+	}
+	qos.SetQosConfigType(qosType)
+	qos.SetDefaultForwardingClassId(spec.DefaultForwardingClass)
+	qos.SetDscpEntries(dscp)
+	qos.SetVlanPriorityEntries(vlan)
+	qos.SetMplsExpEntries(exp)
+	return nil
+}
+
+// ApplyQosConfig creates the qos-config spec.Name under parent (a project,
+// or the global-qos-config for vhost and fabric configurations), or
+// updates it if its settings differ.
+func ApplyQosConfig(client contrail.ApiClient, parent contrail.IObject,
+	spec *QosConfigSpec) (*types.QosConfig, error) {
+	qos := new(types.QosConfig)
+	qos.SetParent(parent)
+	qos.SetName(spec.Name)
+	if err := setQosConfigSpec(qos, spec); err != nil {
+		return nil, err
+	}
+	obj, created, err := findOrCreate(client, qos)
+	if err != nil || created {
+		return qos, err
+	}
+	qos = obj.(*types.QosConfig)
+	if err := applyIfChanged(client, qos, func() error {
+		return setQosConfigSpec(qos, spec)
+	}); err != nil {
+		return nil, err
+	}
+	return qos, nil
+}
+
+// ForwardingClassSpec describes a forwarding-class: the marking of the
+// packets it applies to and the queue they are sent to.
+type ForwardingClassSpec struct {
+	Name         string
+	Id           int
+	Dscp         int
+	VlanPriority int
+	MplsExp      int
+	Queue        *types.QosQueue
+}
+
+func setForwardingClassSpec(fc *types.ForwardingClass,
+	spec *ForwardingClassSpec) error {
+	switch {
+	case spec.Id < 0 || spec.Id > maxForwardingClassId:
+		return fmt.Errorf("Invalid forwarding class id %d", spec.Id)
+	case spec.Dscp < 0 || spec.Dscp > 63:
+		return fmt.Errorf("Invalid DSCP value %d", spec.Dscp)
+	case spec.VlanPriority < 0 || spec.VlanPriority > 7:
+		return fmt.Errorf("Invalid 802.1p value %d", spec.VlanPriority)
+	case spec.MplsExp < 0 || spec.MplsExp > 7:
+		return fmt.Errorf("Invalid MPLS EXP value %d", spec.MplsExp)
+	}
+	fc.SetForwardingClassId(spec.Id)
+	fc.SetForwardingClassDscp(spec.Dscp)
+	fc.SetForwardingClassVlanPriority(spec.VlanPriority)
+	fc.SetForwardingClassMplsExp(spec.MplsExp)
+	fc.ClearQosQueue()
+	if spec.Queue != nil {
+		fc.AddQosQueue(spec.Queue)
+	}
+	return nil
+}
+
+// ApplyForwardingClass creates the forwarding-class spec.Name under the
+// global-qos-config, or updates it if its settings differ. Forwarding
+// class ids must be unique.
+func ApplyForwardingClass(client contrail.ApiClient,
+	globalQos *types.GlobalQosConfig, spec *ForwardingClassSpec) (
+	*types.ForwardingClass, error) {
+	fc := new(types.ForwardingClass)
+	fc.SetParent(globalQos)
+	fc.SetName(spec.Name)
+	if err := setForwardingClassSpec(fc, spec); err != nil {
+		return nil, err
+	}
+	classes, err := client.ListDetailByParent(contrail.TypeForwardingClass,
+		globalQos.GetUuid(), []string{"forwarding_class_id"})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range classes {
+		other := obj.(*types.ForwardingClass)
+		if other.GetName() != spec.Name &&
+			other.GetForwardingClassId() == spec.Id {
+			return nil, fmt.Errorf("Forwarding class id %d is used by %s",
+				spec.Id, other.GetName())
+		}
+	}
+	obj, created, err := findOrCreate(client, fc)
+	if err != nil || created {
+		return fc, err
+	}
+	fc = obj.(*types.ForwardingClass)
+	if err := applyIfChanged(client, fc, func() error {
+		return setForwardingClassSpec(fc, spec)
+	}); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+// SetControlTrafficDscp sets the DSCP marking of the control node, DNS
+// and analytics traffic of the compute nodes.
+func SetControlTrafficDscp(client contrail.ApiClient, control, analytics,
+	dns int) error {
+	for _, value := range []int{control, analytics, dns} {
+		if value < 0 || value > 63 {
+			return fmt.Errorf("Invalid DSCP value %d", value)
+		}
+	}
+	marking := types.ControlTrafficDscpType{
+		Control: control, Analytics: analytics, Dns: dns,
+	}
+	return modifySingleton(client, contrail.TypeGlobalQosConfig,
+		contrail.DefaultGlobalQosConfigFQName(),
+		func() contrail.IObject { return new(types.GlobalQosConfig) },
+		func(obj contrail.IObject) (bool, error) {
+			qos := obj.(*types.GlobalQosConfig)
+			if qos.GetControlTrafficDscp() == marking {
+				return false, nil
+			}
+			qos.SetControlTrafficDscp(&marking)
+			return true, nil
+		})
+}
+
+// applyIfChanged calls set on obj and updates it if that changed its
+// encoding.
+func applyIfChanged(client contrail.ApiClient, obj contrail.IObject,
+	set func() error) error {
+	before, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if err := set(); err != nil {
+		return err
+	}
+	after, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, after) {
+		return nil
+	}
+	return client.Update(obj)
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// QuotaUnlimited is the quota value that disables a limit.
+const QuotaUnlimited = -1
+
+// QuotaDefaults is the resource name of the default quota, which applies
+// to the resources that don't have a specific limit.
+const QuotaDefaults = "defaults"
+
+// QuotaExceededError is returned by CheckQuota.
+type QuotaExceededError struct {
+	Resource string
+	Limit    int
+	Count    int
+	Delta    int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("Quota exceeded for %s: limit %d, in use %d, requested %d",
+		e.Resource, e.Limit, e.Count, e.Delta)
+}
+
+// quotaField returns the QuotaType field of a resource type name
+// (e.g. virtual-network -> virtual_network).
+func quotaField(quota *types.QuotaType, resource string) (reflect.Value, error) {
+	name := strings.Replace(resource, "-", "_", -1)
+	value := reflect.ValueOf(quota).Elem()
+	xtype := value.Type()
+	for i := 0; i < xtype.NumField(); i++ {
+		tag := strings.Split(xtype.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name && value.Field(i).Kind() == reflect.Int {
+			return value.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("No quota for %s", resource)
+}
+
+// QuotaLimit returns the limit that applies to a resource: the resource
+// specific value if set, otherwise the default. A zero value is treated as
+// not set. QuotaUnlimited is returned when neither is set.
+func QuotaLimit(quota *types.QuotaType, resource string) (int, error) {
+	field, err := quotaField(quota, resource)
+	if err != nil {
+		return 0, err
+	}
+	if limit := int(field.Int()); limit != 0 {
+		return limit, nil
+	}
+	if quota.Defaults != 0 {
+		return quota.Defaults, nil
+	}
+	return QuotaUnlimited, nil
+}
+
+// GetProjectQuota returns the limit that applies to a resource of a project.
+func GetProjectQuota(project *types.Project, resource string) (int, error) {
+	quota := project.GetQuota()
+	return QuotaLimit(&quota, resource)
+}
+
+// SetProjectQuota sets the limit of a resource (or QuotaDefaults) in the
+// local copy of a project. A limit of 0 removes the resource specific
+// value, so that the default applies.
+func SetProjectQuota(project *types.Project, resource string, limit int) error {
+	quota := project.GetQuota()
+	field, err := quotaField(&quota, resource)
+	if err != nil {
+		return err
+	}
+	field.SetInt(int64(limit))
+	project.SetQuota(&quota)
+	return nil
+}
+
+// CheckQuota verifies that delta additional resources can be created in a
+// project. The usage is the number of objects of the resource type whose
+// parent is the project.
+func CheckQuota(client contrail.ApiClient, project *types.Project,
+	resource string, delta int) error {
+	limit, err := GetProjectQuota(project, resource)
+	if err != nil {
+		return err
+	}
+	if limit == QuotaUnlimited {
+		return nil
+	}
+	fields, err := contrail.TypeReferenceFields("project")
+	if err != nil {
+		return err
+	}
+	child := false
+	for _, name := range fields.Children {
+		child = child || contrail.ReferenceFieldType(name) == resource
+	}
+	if !child {
+		return fmt.Errorf("Usage of %s is not accounted per project", resource)
+	}
+	count, err := client.CountByParent(resource, project.GetUuid())
+	if err != nil {
+		return err
+	}
+	if count+delta > limit {
+		return &QuotaExceededError{resource, limit, count, delta}
+	}
+	return nil
+}
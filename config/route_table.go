@@ -0,0 +1,128 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Next hop types of network route-table entries.
+const (
+	NextHopIPAddress       = "ip-address"
+	NextHopServiceInstance = "service-instance"
+)
+
+// NewRoute builds a network route-table entry. nextHop is an IP address
+// or, for NextHopServiceInstance, the colon separated name of a
+// service-instance.
+func NewRoute(prefix, nextHop, nextHopType string) (*types.RouteType, error) {
+	if _, _, err := net.ParseCIDR(prefix); err != nil {
+		return nil, fmt.Errorf("Invalid route prefix %s", prefix)
+	}
+	switch nextHopType {
+	case NextHopIPAddress:
+		if net.ParseIP(nextHop) == nil {
+			return nil, fmt.Errorf("%s is not a valid IP address", nextHop)
+		}
+	case NextHopServiceInstance:
+		if nextHop == "" {
+			return nil, fmt.Errorf("Route %s: service-instance not specified", prefix)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid next hop type %s", nextHopType)
+	}
+	return &types.RouteType{
+		Prefix:      prefix,
+		NextHop:     nextHop,
+		NextHopType: nextHopType,
+	}, nil
+}
+
+// CreateRouteTable creates a network route-table in project. The table
+// applies to the virtual-networks it is attached to with
+// AttachRouteTable.
+func CreateRouteTable(client contrail.ApiClient, project *types.Project,
+	name string, routes []*types.RouteType) (*types.RouteTable, error) {
+	table := new(types.RouteTable)
+	table.SetParent(project)
+	table.SetName(name)
+	entries := new(types.RouteTableType)
+	for _, route := range routes {
+		entries.AddRoute(route)
+	}
+	table.SetRoutes(entries)
+	if err := client.Create(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// AttachRouteTable associates a network route-table with a
+// virtual-network.
+func AttachRouteTable(client contrail.ApiClient, network *types.VirtualNetwork,
+	table *types.RouteTable) error {
+	refs, err := network.GetRouteTableRefs()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Uuid == table.GetUuid() {
+			return nil
+		}
+	}
+	network.AddRouteTable(table)
+	return client.Update(network)
+}
+
+// DetachRouteTable removes the association between a network route-table
+// and a virtual-network.
+func DetachRouteTable(client contrail.ApiClient, network *types.VirtualNetwork,
+	table *types.RouteTable) error {
+	network.DeleteRouteTable(table.GetUuid())
+	return client.Update(network)
+}
+
+// CreateInterfaceRouteTable creates an interface-route-table in project
+// with the given prefixes. The next hop of the routes is the
+// virtual-machine-interface the table is attached to.
+func CreateInterfaceRouteTable(client contrail.ApiClient, project *types.Project,
+	name string, prefixes []string) (*types.InterfaceRouteTable, error) {
+	entries := new(types.RouteTableType)
+	for _, prefix := range prefixes {
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			return nil, fmt.Errorf("Invalid route prefix %s", prefix)
+		}
+		entries.AddRoute(&types.RouteType{Prefix: prefix})
+	}
+	table := new(types.InterfaceRouteTable)
+	table.SetParent(project)
+	table.SetName(name)
+	table.SetInterfaceRouteTableRoutes(entries)
+	if err := client.Create(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// AttachInterfaceRouteTable associates an interface-route-table with a
+// virtual-machine-interface.
+func AttachInterfaceRouteTable(client contrail.ApiClient,
+	vmi *types.VirtualMachineInterface, table *types.InterfaceRouteTable) error {
+	refs, err := vmi.GetInterfaceRouteTableRefs()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Uuid == table.GetUuid() {
+			return nil
+		}
+	}
+	vmi.AddInterfaceRouteTable(table)
+	return client.Update(vmi)
+}
@@ -0,0 +1,196 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// RouteTarget is a BGP route target extended community. The administrator
+// field is either an AS number or an IPv4 address.
+type RouteTarget struct {
+	ASN    uint32
+	IP     net.IP
+	Number uint32
+}
+
+// ParseRouteTarget parses a route target of the form "target:ASN:number"
+// or "target:IPv4:number". Two byte AS numbers allow a 32 bit assigned
+// number; four byte AS numbers and IPv4 addresses a 16 bit one.
+func ParseRouteTarget(value string) (*RouteTarget, error) {
+	elements := strings.Split(value, ":")
+	if len(elements) != 3 || elements[0] != "target" {
+		return nil, fmt.Errorf("Invalid route target %s", value)
+	}
+	rt := new(RouteTarget)
+	maxNumber := uint64(1<<32 - 1)
+	if ip := net.ParseIP(elements[1]); ip != nil {
+		if rt.IP = ip.To4(); rt.IP == nil {
+			return nil, fmt.Errorf("Invalid route target %s: not an IPv4 address", value)
+		}
+		maxNumber = 1<<16 - 1
+	} else {
+		asn, err := strconv.ParseUint(elements[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid route target %s: invalid AS number", value)
+		}
+		rt.ASN = uint32(asn)
+		if asn > 1<<16-1 {
+			maxNumber = 1<<16 - 1
+		}
+	}
+	number, err := strconv.ParseUint(elements[2], 10, 32)
+	if err != nil || number > maxNumber {
+		return nil, fmt.Errorf("Invalid route target %s: assigned number must be in range 0-%d",
+			value, maxNumber)
+	}
+	rt.Number = uint32(number)
+	return rt, nil
+}
+
+func (rt *RouteTarget) String() string {
+	if rt.IP != nil {
+		return fmt.Sprintf("target:%s:%d", rt.IP, rt.Number)
+	}
+	return fmt.Sprintf("target:%d:%d", rt.ASN, rt.Number)
+}
+
+// FourByteASN returns true if the AS number doesn't fit in 16 bits.
+func (rt *RouteTarget) FourByteASN() bool {
+	return rt.IP == nil && rt.ASN > 1<<16-1
+}
+
+// RouteTargetDirection selects the route target list of a virtual-network.
+type RouteTargetDirection int
+
+const (
+	// RouteTargetBoth is used for import and export (route_target_list).
+	RouteTargetBoth RouteTargetDirection = iota
+	RouteTargetImport
+	RouteTargetExport
+)
+
+func networkRouteTargets(network *types.VirtualNetwork,
+	direction RouteTargetDirection) []string {
+	switch direction {
+	case RouteTargetImport:
+		return network.GetImportRouteTargetList().RouteTarget
+	case RouteTargetExport:
+		return network.GetExportRouteTargetList().RouteTarget
+	}
+	return network.GetRouteTargetList().RouteTarget
+}
+
+func setNetworkRouteTargets(network *types.VirtualNetwork,
+	direction RouteTargetDirection, targets []string) {
+	list := &types.RouteTargetList{RouteTarget: targets}
+	switch direction {
+	case RouteTargetImport:
+		network.SetImportRouteTargetList(list)
+	case RouteTargetExport:
+		network.SetExportRouteTargetList(list)
+	default:
+		network.SetRouteTargetList(list)
+	}
+}
+
+// normalizeRouteTargets validates route targets and returns their
+// canonical representation.
+func normalizeRouteTargets(targets []string) ([]string, error) {
+	result := make([]string, len(targets))
+	for i, target := range targets {
+		rt, err := ParseRouteTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = rt.String()
+	}
+	return result, nil
+}
+
+// mergeRouteTargets adds and removes targets from a list, preserving the
+// order of the existing entries.
+func mergeRouteTargets(current, add, remove []string) ([]string, bool) {
+	removed := make(map[string]bool, len(remove))
+	for _, target := range remove {
+		removed[target] = true
+	}
+	var result []string
+	present := make(map[string]bool)
+	for _, target := range current {
+		if !removed[target] {
+			result = append(result, target)
+			present[target] = true
+		}
+	}
+	for _, target := range add {
+		if !present[target] {
+			result = append(result, target)
+			present[target] = true
+		}
+	}
+	return result, !sameStrings(result, current)
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateNetworkRouteTargets adds and removes route targets of a
+// virtual-network. The network is only updated if the list changes.
+func UpdateNetworkRouteTargets(client contrail.ApiClient,
+	network *types.VirtualNetwork, direction RouteTargetDirection,
+	add, remove []string) error {
+	add, err := normalizeRouteTargets(add)
+	if err != nil {
+		return err
+	}
+	remove, err = normalizeRouteTargets(remove)
+	if err != nil {
+		return err
+	}
+	targets, modified := mergeRouteTargets(
+		networkRouteTargets(network, direction), add, remove)
+	if !modified {
+		return nil
+	}
+	setNetworkRouteTargets(network, direction, targets)
+	return client.Update(network)
+}
+
+// UpdateRouterRouteTargets adds and removes the configured route targets
+// of a logical-router.
+func UpdateRouterRouteTargets(client contrail.ApiClient,
+	router *types.LogicalRouter, add, remove []string) error {
+	add, err := normalizeRouteTargets(add)
+	if err != nil {
+		return err
+	}
+	remove, err = normalizeRouteTargets(remove)
+	if err != nil {
+		return err
+	}
+	targets, modified := mergeRouteTargets(
+		router.GetConfiguredRouteTargetList().RouteTarget, add, remove)
+	if !modified {
+		return nil
+	}
+	router.SetConfiguredRouteTargetList(&types.RouteTargetList{RouteTarget: targets})
+	return client.Update(router)
+}
@@ -0,0 +1,227 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// parseRoutePrefix parses a route prefix, rejecting prefixes with host
+// bits set (e.g. 10.0.0.1/24), which the vrouter would silently truncate.
+func parseRoutePrefix(prefix string) (*net.IPNet, error) {
+	ip, ipnet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid route prefix %s", prefix)
+	}
+	if !ip.Equal(ipnet.IP) {
+		return nil, fmt.Errorf("Route prefix %s has host bits set (%s)",
+			prefix, ipnet)
+	}
+	return ipnet, nil
+}
+
+// NewInterfaceRoute builds an interface-route-table entry. The next hop
+// is the interface the table is attached to.
+func NewInterfaceRoute(prefix string) (*types.RouteType, error) {
+	ipnet, err := parseRoutePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &types.RouteType{Prefix: ipnet.String()}, nil
+}
+
+func sameRoute(a, b *types.RouteType) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+// mergeRoutes adds routes to entries, replacing the entries with the same
+// prefix. It returns false if entries already had the routes.
+func mergeRoutes(entries *types.RouteTableType, routes []*types.RouteType) (
+	bool, error) {
+	modified := false
+	for _, route := range routes {
+		ipnet, err := parseRoutePrefix(route.Prefix)
+		if err != nil {
+			return false, err
+		}
+		route.Prefix = ipnet.String()
+		found := false
+		for i := range entries.Route {
+			if entries.Route[i].Prefix != route.Prefix {
+				continue
+			}
+			found = true
+			if !sameRoute(&entries.Route[i], route) {
+				entries.Route[i] = *route
+				modified = true
+			}
+		}
+		if !found {
+			entries.AddRoute(route)
+			modified = true
+		}
+	}
+	return modified, nil
+}
+
+func removeRoutes(entries *types.RouteTableType, prefixes []string) bool {
+	remove := make(map[string]bool)
+	for _, prefix := range prefixes {
+		if _, ipnet, err := net.ParseCIDR(prefix); err == nil {
+			prefix = ipnet.String()
+		}
+		remove[prefix] = true
+	}
+	var routes []types.RouteType
+	for _, route := range entries.Route {
+		if !remove[route.Prefix] {
+			routes = append(routes, route)
+		}
+	}
+	modified := len(routes) != len(entries.Route)
+	entries.Route = routes
+	return modified
+}
+
+// AddInterfaceRoutes adds routes to an interface-route-table, replacing
+// the routes with the same prefix.
+func AddInterfaceRoutes(client contrail.ApiClient,
+	table *types.InterfaceRouteTable, routes ...*types.RouteType) error {
+	entries := table.GetInterfaceRouteTableRoutes()
+	modified, err := mergeRoutes(&entries, routes)
+	if err != nil || !modified {
+		return err
+	}
+	table.SetInterfaceRouteTableRoutes(&entries)
+	return client.Update(table)
+}
+
+// DeleteInterfaceRoutes removes the routes to prefixes from an
+// interface-route-table.
+func DeleteInterfaceRoutes(client contrail.ApiClient,
+	table *types.InterfaceRouteTable, prefixes ...string) error {
+	entries := table.GetInterfaceRouteTableRoutes()
+	if !removeRoutes(&entries, prefixes) {
+		return nil
+	}
+	table.SetInterfaceRouteTableRoutes(&entries)
+	return client.Update(table)
+}
+
+// NewHostRoute builds a host route, advertised by DHCP to the virtual
+// machines of a subnet. An empty nextHop selects the gateway of the
+// subnet the route is added to.
+func NewHostRoute(prefix, nextHop string) (*types.RouteType, error) {
+	ipnet, err := parseRoutePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if nextHop != "" && net.ParseIP(nextHop) == nil {
+		return nil, fmt.Errorf("%s is not a valid IP address", nextHop)
+	}
+	return &types.RouteType{
+		Prefix:      ipnet.String(),
+		NextHop:     nextHop,
+		NextHopType: NextHopIPAddress,
+	}, nil
+}
+
+// subnetGateway returns the gateway of subnet: the configured one, else
+// the first address, which the API server allocates by default.
+func subnetGateway(subnet *types.IpamSubnetType) (net.IP, *net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(subnetTypeStringRepr(subnet.Subnet))
+	if err != nil {
+		return nil, nil, err
+	}
+	if subnet.DefaultGateway != "" {
+		return net.ParseIP(subnet.DefaultGateway), ipnet, nil
+	}
+	gateway := make(net.IP, len(ipnet.IP))
+	copy(gateway, ipnet.IP)
+	gateway[len(gateway)-1]++
+	return gateway, ipnet, nil
+}
+
+// SetHostRoutes adds host routes to the subnet prefix of network,
+// replacing the routes with the same prefix. Next hops must be in the
+// subnet.
+func SetHostRoutes(client contrail.ApiClient, network *types.VirtualNetwork,
+	prefix string, routes ...*types.RouteType) error {
+	return modifyHostRoutes(client, network, prefix,
+		func(subnet *types.IpamSubnetType, entries *types.RouteTableType) (
+			bool, error) {
+			gateway, ipnet, err := subnetGateway(subnet)
+			if err != nil {
+				return false, err
+			}
+			for _, route := range routes {
+				if route.NextHop == "" {
+					route.NextHop = gateway.String()
+					route.NextHopType = NextHopIPAddress
+				}
+				if !ipnet.Contains(net.ParseIP(route.NextHop)) {
+					return false, fmt.Errorf("Host route %s: next hop %s "+
+						"is not in subnet %s", route.Prefix, route.NextHop,
+						ipnet)
+				}
+			}
+			return mergeRoutes(entries, routes)
+		})
+}
+
+// DeleteHostRoutes removes the host routes to prefixes from the subnet
+// prefix of network.
+func DeleteHostRoutes(client contrail.ApiClient, network *types.VirtualNetwork,
+	prefix string, prefixes ...string) error {
+	return modifyHostRoutes(client, network, prefix,
+		func(subnet *types.IpamSubnetType, entries *types.RouteTableType) (
+			bool, error) {
+			return removeRoutes(entries, prefixes), nil
+		})
+}
+
+func modifyHostRoutes(client contrail.ApiClient, network *types.VirtualNetwork,
+	prefix string, modify func(*types.IpamSubnetType, *types.RouteTableType) (
+		bool, error)) error {
+	refs, err := network.GetNetworkIpamRefs()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		attr := ref.Attr.(types.VnSubnetsType)
+		for i := range attr.IpamSubnets {
+			subnet := &attr.IpamSubnets[i]
+			if subnetTypeStringRepr(subnet.Subnet) != prefix {
+				continue
+			}
+			entries := new(types.RouteTableType)
+			if subnet.HostRoutes != nil {
+				entries.Route = append(entries.Route, subnet.HostRoutes.Route...)
+			}
+			modified, err := modify(subnet, entries)
+			if err != nil || !modified {
+				return err
+			}
+			subnet.HostRoutes = entries
+			ipam, err := types.NetworkIpamByUuid(client, ref.Uuid)
+			if err != nil {
+				return err
+			}
+			network.DeleteNetworkIpam(ref.Uuid)
+			network.AddNetworkIpam(ipam, attr)
+			return client.Update(network)
+		}
+	}
+	return fmt.Errorf("Prefix %s not associated with network %s", prefix,
+		network.GetName())
+}
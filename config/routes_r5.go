@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Well-known BGP communities (RFC 1997, RFC 7611).
+var wellKnownCommunities = map[string]bool{
+	"no-export":           true,
+	"no-advertise":        true,
+	"no-export-subconfed": true,
+	"no-reoriginate":      true,
+	"accept-own":          true,
+}
+
+func validCommunity(community string) bool {
+	if wellKnownCommunities[community] {
+		return true
+	}
+	elements := strings.Split(community, ":")
+	if len(elements) != 2 {
+		return false
+	}
+	for _, element := range elements {
+		if _, err := strconv.ParseUint(element, 10, 16); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// SetRouteCommunities sets the BGP communities the route is advertised
+// with: well-known communities (e.g. no-export) or "asn:value".
+func SetRouteCommunities(route *types.RouteType, communities ...string) error {
+	for _, community := range communities {
+		if !validCommunity(community) {
+			return fmt.Errorf("Route %s: invalid community %q", route.Prefix,
+				community)
+		}
+	}
+	if len(communities) == 0 {
+		route.CommunityAttributes = nil
+		return nil
+	}
+	route.CommunityAttributes = &types.CommunityAttributes{
+		CommunityAttribute: communities,
+	}
+	return nil
+}
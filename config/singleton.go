@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// maxConfigAttempts bounds the read-modify-write retries of
+// modifySingleton.
+const maxConfigAttempts = 5
+
+// readSingleton reads the object typename:fqn into a private copy of type
+// ptr, and returns its encoding.
+func readSingleton(client contrail.ApiClient, typename string, fqn []string,
+	ptr contrail.IObject) ([]byte, error) {
+	obj, err := client.FindByName(typename, strings.Join(fqn, ":"))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	// Modify a copy: the object returned may be shared (e.g. cached).
+	if err := json.Unmarshal(data, ptr); err != nil {
+		return nil, err
+	}
+	ptr.SetClient(client)
+	return data, nil
+}
+
+// modifySingleton applies change to a configuration singleton. The object
+// is read immediately before the change, and read again before it is
+// written: if another client modified it in the meantime the change is
+// applied again to the new contents, so that list properties written by
+// the other client are not overwritten. Once written, the object is read
+// back until change reports nothing left to do, which requires change to
+// be idempotent.
+//
+// The API server does not provide conditional updates, so this narrows
+// the window for lost updates rather than eliminating it.
+func modifySingleton(client contrail.ApiClient, typename string,
+	fqn []string, alloc func() contrail.IObject,
+	change func(contrail.IObject) (bool, error)) error {
+	for attempt := 0; attempt < maxConfigAttempts; attempt++ {
+		obj := alloc()
+		snapshot, err := readSingleton(client, typename, fqn, obj)
+		if err != nil {
+			return err
+		}
+		changed, err := change(obj)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		current, err := readSingleton(client, typename, fqn, alloc())
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(snapshot, current) {
+			continue
+		}
+		if err := client.Update(obj); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%s: concurrent modification, gave up after %d "+
+		"attempts", typename, maxConfigAttempts)
+}
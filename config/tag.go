@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// tagName returns the name of a tag: <type>=<value>.
+func tagName(tagType, value string) string {
+	return strings.ToLower(tagType) + "=" + value
+}
+
+// tagFQName returns the fq_name of a project tag, or of a global tag when
+// project is nil.
+func tagFQName(project *types.Project, tagType, value string) []string {
+	if project == nil {
+		return []string{tagName(tagType, value)}
+	}
+	fqn := append([]string{}, project.GetFQName()...)
+	return append(fqn, tagName(tagType, value))
+}
+
+// CreateTag creates a tag (e.g. "application", "web") in a project, or a
+// global tag when project is nil.
+func CreateTag(client contrail.ApiClient, project *types.Project,
+	tagType, value string) (*types.Tag, error) {
+	tag := new(types.Tag)
+	if project != nil {
+		tag.SetParent(project)
+		tag.SetName(tagName(tagType, value))
+	} else {
+		tag.SetFQName("config-root", tagFQName(nil, tagType, value))
+	}
+	tag.SetTagTypeName(strings.ToLower(tagType))
+	tag.SetTagValue(value)
+	if err := client.Create(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// TagByName retrieves a project tag, or a global tag when project is nil.
+func TagByName(client contrail.ApiClient, project *types.Project,
+	tagType, value string) (*types.Tag, error) {
+	fqn := tagFQName(project, tagType, value)
+	obj, err := client.FindByName("tag", strings.Join(fqn, ":"))
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*types.Tag), nil
+}
+
+// tagSetter is implemented by contrail.Client.
+type tagSetter interface {
+	SetTags(typename, uuid string, updates ...contrail.TagUpdate) error
+}
+
+// tagTypeOf returns the type of a tag from its fq_name.
+func tagTypeOf(fqn []string) string {
+	if len(fqn) == 0 {
+		return ""
+	}
+	name := fqn[len(fqn)-1]
+	if i := strings.Index(name, "="); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// ApplyTags associates tags with an object stored in the API server. For
+// single value tag types, the tag replaces any tag of the same type; at
+// most one tag of each of these types can be applied at once.
+//
+// When the client supports it, the set-tag action is used; otherwise the
+// tag references of the object are read, the references to the replaced
+// tags are deleted and the new ones are added individually.
+func ApplyTags(client contrail.ApiClient, obj contrail.IObject,
+	tags ...*types.Tag) error {
+	if setter, ok := client.(tagSetter); ok {
+		// set-tag accepts a single label update per request: project
+		// and global labels are sent separately.
+		var projectLabels, globalLabels []string
+		var updates []contrail.TagUpdate
+		for _, tag := range tags {
+			global := len(tag.GetFQName()) == 1
+			if tag.GetTagTypeName() == contrail.TagTypeLabel {
+				if global {
+					globalLabels = append(globalLabels, tag.GetTagValue())
+				} else {
+					projectLabels = append(projectLabels, tag.GetTagValue())
+				}
+				continue
+			}
+			updates = append(updates, contrail.TagUpdate{
+				Type:     tag.GetTagTypeName(),
+				Value:    tag.GetTagValue(),
+				IsGlobal: global,
+			})
+		}
+		if len(projectLabels) > 0 {
+			updates = append(updates, contrail.TagUpdate{
+				Type:      contrail.TagTypeLabel,
+				AddValues: projectLabels,
+			})
+		}
+		if len(updates) > 0 {
+			if err := setter.SetTags(obj.GetType(), obj.GetUuid(), updates...); err != nil {
+				return err
+			}
+		}
+		if len(globalLabels) > 0 {
+			return setter.SetTags(obj.GetType(), obj.GetUuid(), contrail.TagUpdate{
+				Type:      contrail.TagTypeLabel,
+				AddValues: globalLabels,
+				IsGlobal:  true,
+			})
+		}
+		return nil
+	}
+	replaced := make(map[string]bool)
+	applied := make(map[string]bool)
+	for _, tag := range tags {
+		applied[tag.GetUuid()] = true
+		tagType := tag.GetTagTypeName()
+		if tagType == contrail.TagTypeLabel {
+			continue
+		}
+		if replaced[tagType] {
+			return fmt.Errorf("Multiple %s tags", tagType)
+		}
+		replaced[tagType] = true
+	}
+	if len(replaced) > 0 {
+		if err := client.GetField(obj, "tag_refs"); err != nil {
+			return err
+		}
+		refs, err := contrail.GetReferenceList(obj, "tag_refs")
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if !replaced[tagTypeOf(ref.To)] || applied[ref.Uuid] {
+				continue
+			}
+			err := client.UpdateReference(&contrail.ReferenceUpdateMsg{
+				Type:      obj.GetType(),
+				Uuid:      obj.GetUuid(),
+				RefType:   "tag",
+				RefUuid:   ref.Uuid,
+				RefFQName: ref.To,
+				Operation: "DELETE",
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, tag := range tags {
+		err := client.UpdateReference(&contrail.ReferenceUpdateMsg{
+			Type:      obj.GetType(),
+			Uuid:      obj.GetUuid(),
+			RefType:   "tag",
+			RefUuid:   tag.GetUuid(),
+			RefFQName: tag.GetFQName(),
+			Operation: "ADD",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveTags dissociates tags from an object stored in the API server.
+func RemoveTags(client contrail.ApiClient, obj contrail.IObject,
+	tags ...*types.Tag) error {
+	for _, tag := range tags {
+		err := client.UpdateReference(&contrail.ReferenceUpdateMsg{
+			Type:      obj.GetType(),
+			Uuid:      obj.GetUuid(),
+			RefType:   "tag",
+			RefUuid:   tag.GetUuid(),
+			RefFQName: tag.GetFQName(),
+			Operation: "DELETE",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObjectsByTag returns the uuids of the objects of a type that are tagged
+// with tag.
+func ObjectsByTag(client contrail.ApiClient, tag *types.Tag, typename string) (
+	[]string, error) {
+	field := strings.Replace(typename, "-", "_", -1) + "_back_refs"
+	if err := client.GetField(tag, field); err != nil {
+		return nil, fmt.Errorf("%s: %v", field, err)
+	}
+	refs, err := contrail.GetReferenceList(tag, field)
+	if err != nil {
+		return nil, err
+	}
+	var uuids []string
+	for _, ref := range refs {
+		uuids = append(uuids, ref.Uuid)
+	}
+	return uuids, nil
+}
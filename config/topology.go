@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Topology is the physical topology of the fabrics: the physical routers,
+// their interfaces, and the virtual-machine-interfaces and
+// virtual-port-groups attached to them.
+type Topology struct {
+	Routers []*RouterTopology
+
+	interfaces map[string]*InterfaceTopology
+}
+
+// RouterTopology is a physical-router and its interfaces, sorted by name.
+type RouterTopology struct {
+	Router     *types.PhysicalRouter
+	Interfaces []*InterfaceTopology
+	// LogicalInterfaces defined on the router rather than on one of its
+	// physical interfaces (e.g. irb units).
+	LogicalInterfaces []*LogicalInterfaceTopology
+}
+
+// InterfaceTopology is a physical-interface, its logical interfaces and
+// the virtual-port-groups it belongs to.
+type InterfaceTopology struct {
+	Port              PortID
+	Interface         *types.PhysicalInterface
+	LogicalInterfaces []*LogicalInterfaceTopology
+	PortGroups        []*types.VirtualPortGroup
+}
+
+// LogicalInterfaceTopology is a logical-interface and the
+// virtual-machine-interfaces it is bound to.
+type LogicalInterfaceTopology struct {
+	Interface         *types.LogicalInterface
+	VirtualInterfaces []contrail.Reference
+}
+
+// Interface returns the physical interface identified by port, or nil.
+func (t *Topology) Interface(port PortID) *InterfaceTopology {
+	return t.interfaces[port.String()]
+}
+
+func fqnKey(fqn []string) string {
+	return strings.Join(fqn, ":")
+}
+
+// ReadTopology reads the physical topology with one list request per
+// object type, rather than walking the objects one at a time, and joins
+// the objects by fq_name and reference. When routers are specified only
+// their topology is returned.
+func ReadTopology(client contrail.ApiClient, routers ...string) (
+	*Topology, error) {
+	selected := make(map[string]bool)
+	for _, name := range routers {
+		selected[name] = true
+	}
+	topology := &Topology{interfaces: make(map[string]*InterfaceTopology)}
+
+	objs, err := client.ListDetail(contrail.TypePhysicalRouter, nil)
+	if err != nil {
+		return nil, err
+	}
+	byFQName := make(map[string]*RouterTopology)
+	for _, obj := range objs {
+		router := obj.(*types.PhysicalRouter)
+		if len(selected) > 0 && !selected[router.GetName()] {
+			continue
+		}
+		entry := &RouterTopology{Router: router}
+		topology.Routers = append(topology.Routers, entry)
+		byFQName[fqnKey(router.GetFQName())] = entry
+	}
+	sort.Slice(topology.Routers, func(i, j int) bool {
+		return topology.Routers[i].Router.GetName() <
+			topology.Routers[j].Router.GetName()
+	})
+
+	objs, err = client.ListDetail(contrail.TypePhysicalInterface, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Physical interfaces by uuid (references) and fq_name (children).
+	interfaces := make(map[string]*InterfaceTopology)
+	interfacesByFQName := make(map[string]*InterfaceTopology)
+	for _, obj := range objs {
+		pi := obj.(*types.PhysicalInterface)
+		fqn := pi.GetFQName()
+		router, ok := byFQName[fqnKey(fqn[:len(fqn)-1])]
+		if !ok {
+			continue
+		}
+		entry := &InterfaceTopology{
+			Port:      PortID{Router: router.Router.GetName(), Interface: pi.GetName()},
+			Interface: pi,
+		}
+		router.Interfaces = append(router.Interfaces, entry)
+		interfaces[pi.GetUuid()] = entry
+		topology.interfaces[entry.Port.String()] = entry
+		interfacesByFQName[fqnKey(fqn)] = entry
+	}
+	for _, router := range topology.Routers {
+		sort.Slice(router.Interfaces, func(i, j int) bool {
+			return router.Interfaces[i].Port.Interface <
+				router.Interfaces[j].Port.Interface
+		})
+	}
+
+	objs, err = client.ListDetail(contrail.TypeLogicalInterface,
+		[]string{"virtual_machine_interface_refs"})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		li := obj.(*types.LogicalInterface)
+		refs, err := li.GetVirtualMachineInterfaceRefs()
+		if err != nil {
+			return nil, err
+		}
+		entry := &LogicalInterfaceTopology{Interface: li, VirtualInterfaces: refs}
+		fqn := li.GetFQName()
+		parent := fqnKey(fqn[:len(fqn)-1])
+		if pi, ok := interfacesByFQName[parent]; ok {
+			pi.LogicalInterfaces = append(pi.LogicalInterfaces, entry)
+		} else if router, ok := byFQName[parent]; ok {
+			router.LogicalInterfaces = append(router.LogicalInterfaces, entry)
+		}
+	}
+
+	objs, err = client.ListDetail(contrail.TypeVirtualPortGroup,
+		[]string{"physical_interface_refs"})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		vpg := obj.(*types.VirtualPortGroup)
+		refs, err := vpg.GetPhysicalInterfaceRefs()
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if pi, ok := interfaces[ref.Uuid]; ok {
+				pi.PortGroups = append(pi.PortGroups, vpg)
+			}
+		}
+	}
+	return topology, nil
+}
@@ -0,0 +1,239 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Tunnel encapsulations, in the global-vrouter-config priority list.
+const (
+	EncapsulationMPLSoGRE = "MPLSoGRE"
+	EncapsulationMPLSoUDP = "MPLSoUDP"
+	EncapsulationVXLAN    = "VXLAN"
+)
+
+// VrouterConfigChange modifies the global-vrouter-config and reports
+// whether it changed anything. It must be idempotent: it is applied again
+// to verify that the change was stored.
+type VrouterConfigChange func(config *types.GlobalVrouterConfig) (bool, error)
+
+// ModifyGlobalVrouterConfig applies change to the current
+// global-vrouter-config, retrying when the object is modified
+// concurrently so that the list properties (link-local services, flow
+// aging timeouts) written by other clients are preserved.
+func ModifyGlobalVrouterConfig(client contrail.ApiClient,
+	change VrouterConfigChange) error {
+	return modifySingleton(client, contrail.TypeGlobalVrouterConfig,
+		contrail.DefaultGlobalVrouterConfigFQName(),
+		func() contrail.IObject { return new(types.GlobalVrouterConfig) },
+		func(obj contrail.IObject) (bool, error) {
+			return change(obj.(*types.GlobalVrouterConfig))
+		})
+}
+
+// NewLinkLocalService builds a link-local service entry: the service at
+// serviceIP:servicePort, reachable by virtual machines, is forwarded by
+// the vrouter to fabricPort on the fabric addresses. A fabric address is
+// either an IP address or, when fabric is a single name, a DNS name.
+func NewLinkLocalService(name, serviceIP string, servicePort int,
+	fabric []string, fabricPort int) (*types.LinklocalServiceEntryType, error) {
+	if name == "" {
+		return nil, fmt.Errorf("Link-local service name not specified")
+	}
+	ip := net.ParseIP(serviceIP)
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("Link-local service %s: invalid address %q",
+			name, serviceIP)
+	}
+	if servicePort <= 0 || servicePort > 65535 ||
+		fabricPort <= 0 || fabricPort > 65535 {
+		return nil, fmt.Errorf("Link-local service %s: invalid port", name)
+	}
+	entry := &types.LinklocalServiceEntryType{
+		LinklocalServiceName: name,
+		LinklocalServiceIp:   serviceIP,
+		LinklocalServicePort: servicePort,
+		IpFabricServicePort:  fabricPort,
+	}
+	switch {
+	case len(fabric) == 0:
+		return nil, fmt.Errorf("Link-local service %s: no fabric address", name)
+	case len(fabric) == 1 && net.ParseIP(fabric[0]) == nil:
+		entry.IpFabricDnsServiceName = fabric[0]
+	default:
+		for _, address := range fabric {
+			if net.ParseIP(address) == nil {
+				return nil, fmt.Errorf("Link-local service %s: invalid "+
+					"fabric address %q", name, address)
+			}
+		}
+		entry.IpFabricServiceIp = fabric
+	}
+	return entry, nil
+}
+
+// LinkLocalServices returns the link-local services.
+func LinkLocalServices(client contrail.ApiClient) (
+	[]types.LinklocalServiceEntryType, error) {
+	config := new(types.GlobalVrouterConfig)
+	_, err := readSingleton(client, contrail.TypeGlobalVrouterConfig,
+		contrail.DefaultGlobalVrouterConfigFQName(), config)
+	if err != nil {
+		return nil, err
+	}
+	return config.GetLinklocalServices().LinklocalServiceEntry, nil
+}
+
+func sameLinkLocalService(a, b *types.LinklocalServiceEntryType) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+// SetLinkLocalService adds a link-local service, replacing the service
+// with the same name.
+func SetLinkLocalService(client contrail.ApiClient,
+	entry *types.LinklocalServiceEntryType) error {
+	return ModifyGlobalVrouterConfig(client,
+		func(config *types.GlobalVrouterConfig) (bool, error) {
+			services := config.GetLinklocalServices()
+			var entries []types.LinklocalServiceEntryType
+			for i := range services.LinklocalServiceEntry {
+				current := &services.LinklocalServiceEntry[i]
+				if current.LinklocalServiceName != entry.LinklocalServiceName {
+					entries = append(entries, *current)
+					continue
+				}
+				if sameLinkLocalService(current, entry) {
+					return false, nil
+				}
+			}
+			for _, current := range entries {
+				if current.LinklocalServiceIp == entry.LinklocalServiceIp &&
+					current.LinklocalServicePort == entry.LinklocalServicePort {
+					return false, fmt.Errorf("Link-local service %s: %s:%d "+
+						"is used by %s", entry.LinklocalServiceName,
+						entry.LinklocalServiceIp, entry.LinklocalServicePort,
+						current.LinklocalServiceName)
+				}
+			}
+			services.LinklocalServiceEntry = append(entries, *entry)
+			config.SetLinklocalServices(&services)
+			return true, nil
+		})
+}
+
+// DeleteLinkLocalService removes the link-local service name, if present.
+func DeleteLinkLocalService(client contrail.ApiClient, name string) error {
+	return ModifyGlobalVrouterConfig(client,
+		func(config *types.GlobalVrouterConfig) (bool, error) {
+			services := config.GetLinklocalServices()
+			var entries []types.LinklocalServiceEntryType
+			for _, entry := range services.LinklocalServiceEntry {
+				if entry.LinklocalServiceName != name {
+					entries = append(entries, entry)
+				}
+			}
+			if len(entries) == len(services.LinklocalServiceEntry) {
+				return false, nil
+			}
+			services.LinklocalServiceEntry = entries
+			config.SetLinklocalServices(&services)
+			return true, nil
+		})
+}
+
+// SetEncapsulationPriorities sets the tunnel encapsulations, in order of
+// preference.
+func SetEncapsulationPriorities(client contrail.ApiClient,
+	encapsulations ...string) error {
+	seen := make(map[string]bool)
+	for _, encapsulation := range encapsulations {
+		switch encapsulation {
+		case EncapsulationMPLSoGRE, EncapsulationMPLSoUDP, EncapsulationVXLAN:
+		default:
+			return fmt.Errorf("Invalid encapsulation %q", encapsulation)
+		}
+		if seen[encapsulation] {
+			return fmt.Errorf("Duplicate encapsulation %s", encapsulation)
+		}
+		seen[encapsulation] = true
+	}
+	if len(encapsulations) == 0 {
+		return fmt.Errorf("No encapsulation specified")
+	}
+	return ModifyGlobalVrouterConfig(client,
+		func(config *types.GlobalVrouterConfig) (bool, error) {
+			current := config.GetEncapsulationPriorities().Encapsulation
+			if strings.Join(current, ",") == strings.Join(encapsulations, ",") {
+				return false, nil
+			}
+			config.SetEncapsulationPriorities(&types.EncapsulationPrioritiesType{
+				Encapsulation: encapsulations,
+			})
+			return true, nil
+		})
+}
+
+func validFlowProtocol(protocol string) bool {
+	switch protocol {
+	case "tcp", "udp", "icmp":
+		return true
+	}
+	number, err := strconv.Atoi(protocol)
+	return err == nil && number >= 0 && number <= 255
+}
+
+// SetFlowAgingTimeout sets the aging timeout of the flows of protocol
+// (tcp, udp, icmp or a protocol number) and port; port 0 applies to all
+// ports. A timeout of 0 removes the setting.
+func SetFlowAgingTimeout(client contrail.ApiClient, protocol string,
+	port, timeout int) error {
+	protocol = strings.ToLower(protocol)
+	if !validFlowProtocol(protocol) {
+		return fmt.Errorf("Invalid protocol %q", protocol)
+	}
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("Invalid port %d", port)
+	}
+	if timeout < 0 {
+		return fmt.Errorf("Invalid flow aging timeout %d", timeout)
+	}
+	return ModifyGlobalVrouterConfig(client,
+		func(config *types.GlobalVrouterConfig) (bool, error) {
+			list := config.GetFlowAgingTimeoutList()
+			var entries []types.FlowAgingTimeout
+			changed := timeout != 0
+			for _, entry := range list.FlowAgingTimeout {
+				if entry.Protocol != protocol || entry.Port != port {
+					entries = append(entries, entry)
+					continue
+				}
+				changed = entry.TimeoutInSeconds != timeout
+			}
+			if !changed {
+				return false, nil
+			}
+			if timeout != 0 {
+				entries = append(entries, types.FlowAgingTimeout{
+					Protocol:         protocol,
+					Port:             port,
+					TimeoutInSeconds: timeout,
+				})
+			}
+			list.FlowAgingTimeout = entries
+			config.SetFlowAgingTimeoutList(&list)
+			return true, nil
+		})
+}
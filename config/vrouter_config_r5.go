@@ -0,0 +1,37 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// Encryption modes of the traffic between vrouters.
+const (
+	EncryptionModeNone = "none"
+	EncryptionModeAll  = "all"
+)
+
+// SetEncryptionMode enables (EncryptionModeAll) or disables
+// (EncryptionModeNone) the encryption of the traffic between vrouters.
+func SetEncryptionMode(client contrail.ApiClient, mode string) error {
+	if mode != EncryptionModeNone && mode != EncryptionModeAll {
+		return fmt.Errorf("Invalid encryption mode %q", mode)
+	}
+	return ModifyGlobalVrouterConfig(client,
+		func(config *types.GlobalVrouterConfig) (bool, error) {
+			if config.GetEncryptionMode() == mode {
+				return false, nil
+			}
+			config.SetEncryptionMode(mode)
+			return true, nil
+		})
+}
@@ -0,0 +1,346 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package contrailtest verifies that an API server supports the features
+// of the client that applications rely on:
+//
+//	func TestServer(t *testing.T) {
+//		client := contrail.NewClient("contrail-api", 8082)
+//		contrailtest.RunConformance(t, client)
+//	}
+//
+// Each feature is a subtest. Optional features that the server doesn't
+// implement (e.g. list pagination in older releases) are skipped rather
+// than failed, so that the test output lists what the server supports.
+package contrailtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Options controls RunConformanceWithOptions.
+type Options struct {
+	// Domain is the fq_name of the domain under which the test project
+	// is created (default-domain by default).
+	Domain []string
+	// Prefix is prepended to the name of the test project, which is
+	// unique to each run.
+	Prefix string
+}
+
+// RunConformance runs the conformance suite with the default options.
+func RunConformance(t *testing.T, client *contrail.Client) {
+	RunConformanceWithOptions(t, client, &Options{})
+}
+
+// RunConformanceWithOptions creates a project, runs the conformance
+// subtests in it and deletes it, along with the objects the subtests
+// created.
+func RunConformanceWithOptions(t *testing.T, client *contrail.Client,
+	opts *Options) {
+	domain := opts.Domain
+	if len(domain) == 0 {
+		domain = []string{"default-domain"}
+	}
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = "contrailtest"
+	}
+	project := contrail.NewGenericObject("project")
+	project.SetDefaultParent("domain", domain)
+	project.SetName(fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano()))
+	if err := client.Create(project); err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	s := &suite{client: client, project: project}
+	defer s.cleanup(t)
+
+	t.Run("CRUD", s.testCRUD)
+	t.Run("References", s.testReferences)
+	t.Run("ReferencedDelete", s.testReferencedDelete)
+	t.Run("ParentDelete", s.testParentDelete)
+	t.Run("ListOptions", s.testListOptions)
+	t.Run("Pagination", s.testPagination)
+}
+
+type suite struct {
+	client  *contrail.Client
+	project *contrail.GenericObject
+	// created holds the objects to delete, in creation order.
+	created []contrail.IObject
+}
+
+func (s *suite) cleanup(t *testing.T) {
+	for i := len(s.created) - 1; i >= 0; i-- {
+		obj := s.created[i]
+		err := s.client.DeleteByUuid(obj.GetType(), obj.GetUuid())
+		if err != nil && !isStatus(err, 404) {
+			t.Errorf("Delete %s %s: %v", obj.GetType(), obj.GetUuid(), err)
+		}
+	}
+	if err := s.client.Delete(s.project); err != nil {
+		t.Errorf("Delete project %s: %v",
+			strings.Join(s.project.GetFQName(), ":"), err)
+	}
+}
+
+// create creates an object of typename in the test project.
+func (s *suite) create(t *testing.T, typename, name string,
+	fields map[string]interface{}) *contrail.GenericObject {
+	obj := contrail.NewGenericObject(typename)
+	obj.SetParent(s.project)
+	obj.SetName(name)
+	for field, value := range fields {
+		if err := obj.Set(field, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.client.Create(obj); err != nil {
+		t.Fatalf("Create %s %s: %v", typename, name, err)
+	}
+	s.created = append(s.created, obj)
+	return obj
+}
+
+func isStatus(err error, status int) bool {
+	if httpErr, ok := err.(*contrail.HTTPError); ok {
+		return httpErr.StatusCode == status
+	}
+	return strings.HasPrefix(err.Error(), fmt.Sprint(status))
+}
+
+// fields returns the encoding of each field of obj, regardless of
+// whether obj is a generated type or a GenericObject.
+func fields(t *testing.T, obj contrail.IObject) map[string]json.RawMessage {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func stringField(t *testing.T, obj contrail.IObject, field string) string {
+	var value string
+	if raw, ok := fields(t, obj)[field]; ok {
+		json.Unmarshal(raw, &value)
+	}
+	return value
+}
+
+func (s *suite) testCRUD(t *testing.T) {
+	vn := s.create(t, "virtual-network", "crud",
+		map[string]interface{}{"display_name": "before"})
+	if vn.GetUuid() == "" {
+		t.Fatal("No uuid assigned on create")
+	}
+	fqn := strings.Join(vn.GetFQName(), ":")
+
+	byName, err := s.client.FindByName("virtual-network", fqn)
+	if err != nil {
+		t.Fatalf("FindByName: %v", err)
+	}
+	if byName.GetUuid() != vn.GetUuid() {
+		t.Errorf("FindByName returned %s, expected %s", byName.GetUuid(),
+			vn.GetUuid())
+	}
+	if value := stringField(t, byName, "display_name"); value != "before" {
+		t.Errorf("display_name %q after create", value)
+	}
+
+	if err := vn.Set("display_name", "after"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.client.Update(vn); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	byUuid, err := s.client.FindByUuid("virtual-network", vn.GetUuid())
+	if err != nil {
+		t.Fatalf("FindByUuid: %v", err)
+	}
+	if value := stringField(t, byUuid, "display_name"); value != "after" {
+		t.Errorf("display_name %q after update", value)
+	}
+	if names, err := s.client.FQNameByUuid(vn.GetUuid()); err != nil ||
+		strings.Join(names, ":") != fqn {
+		t.Errorf("FQNameByUuid: %v %v", names, err)
+	}
+
+	if err := s.client.Delete(vn); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err = s.client.FindByUuid("virtual-network", vn.GetUuid())
+	if err == nil || !isStatus(err, 404) {
+		t.Errorf("Expected 404 after delete, got %v", err)
+	}
+}
+
+func (s *suite) testReferences(t *testing.T) {
+	ipam := s.create(t, "network-ipam", "refs-ipam", nil)
+	vn := contrail.NewGenericObject("virtual-network")
+	vn.SetParent(s.project)
+	vn.SetName("refs")
+	attr := map[string]interface{}{"ipam_subnets": []interface{}{}}
+	if err := vn.AddReference("network_ipam_refs", ipam, attr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.client.Create(vn); err != nil {
+		t.Fatalf("Create with reference: %v", err)
+	}
+	s.created = append(s.created, vn)
+
+	obj, err := s.client.FindByUuid("virtual-network", vn.GetUuid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs contrail.ReferenceList
+	json.Unmarshal(fields(t, obj)["network_ipam_refs"], &refs)
+	if len(refs) != 1 || refs[0].Uuid != ipam.GetUuid() {
+		t.Errorf("Unexpected references %+v", refs)
+	}
+
+	results, err := s.client.ListWithOptions("virtual-network",
+		contrail.ListBackRef(ipam.GetUuid()))
+	if err != nil {
+		t.Fatalf("List by back reference: %v", err)
+	}
+	if len(results) != 1 || results[0].Uuid != vn.GetUuid() {
+		t.Errorf("Unexpected back references %+v", results)
+	}
+
+	if err := vn.DeleteReference("network_ipam_refs", ipam.GetUuid()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.client.Update(vn); err != nil {
+		t.Fatalf("Update references: %v", err)
+	}
+	results, err = s.client.ListWithOptions("virtual-network",
+		contrail.ListBackRef(ipam.GetUuid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Reference not deleted: %+v", results)
+	}
+}
+
+func (s *suite) testReferencedDelete(t *testing.T) {
+	ipam := s.create(t, "network-ipam", "referenced-ipam", nil)
+	vn := contrail.NewGenericObject("virtual-network")
+	vn.SetParent(s.project)
+	vn.SetName("referrer")
+	attr := map[string]interface{}{"ipam_subnets": []interface{}{}}
+	if err := vn.AddReference("network_ipam_refs", ipam, attr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.client.Create(vn); err != nil {
+		t.Fatal(err)
+	}
+	s.created = append(s.created, vn)
+
+	err := s.client.Delete(ipam)
+	if err == nil {
+		t.Fatal("Deleted an object that is referred to")
+	}
+	if !isStatus(err, 409) {
+		t.Errorf("Expected 409, got %v", err)
+	}
+}
+
+func (s *suite) testParentDelete(t *testing.T) {
+	s.create(t, "virtual-network", "child", nil)
+	err := s.client.Delete(s.project)
+	if err == nil {
+		t.Fatal("Deleted an object that has children")
+	}
+	if !isStatus(err, 409) {
+		t.Errorf("Expected 409, got %v", err)
+	}
+}
+
+func (s *suite) testListOptions(t *testing.T) {
+	a := s.create(t, "virtual-network", "list-a",
+		map[string]interface{}{"display_name": "list-selected"})
+	b := s.create(t, "virtual-network", "list-b", nil)
+	parent := contrail.ListParent(s.project.GetUuid())
+
+	results, err := s.client.ListWithOptions("virtual-network", parent,
+		contrail.ListUuids(a.GetUuid(), b.GetUuid()),
+		contrail.ListSort(contrail.SortByName))
+	if err != nil {
+		t.Fatalf("List by uuids: %v", err)
+	}
+	if len(results) != 2 || results[0].Uuid != a.GetUuid() {
+		t.Errorf("Unexpected list by uuids %+v", results)
+	}
+
+	results, err = s.client.ListWithOptions("virtual-network", parent,
+		contrail.ListFilter("display_name", "list-selected"))
+	if err != nil {
+		t.Fatalf("List with filters: %v", err)
+	}
+	if len(results) != 1 || results[0].Uuid != a.GetUuid() {
+		t.Errorf("Unexpected filtered list %+v", results)
+	}
+
+	count, err := s.client.CountWithOptions("virtual-network", parent,
+		contrail.ListUuids(a.GetUuid(), b.GetUuid()))
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Count %d, expected 2", count)
+	}
+
+	objects, err := s.client.ListDetailWithOptions("virtual-network", parent,
+		contrail.ListUuids(a.GetUuid()), contrail.ListFields("display_name"))
+	if err != nil {
+		t.Fatalf("Detailed list: %v", err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("Detailed list returned %d objects", len(objects))
+	}
+	if value := stringField(t, objects[0], "display_name"); value != "list-selected" {
+		t.Errorf("display_name %q in detailed list", value)
+	}
+}
+
+func (s *suite) testPagination(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		s.create(t, "network-ipam", fmt.Sprintf("page-%d", i), nil)
+	}
+	parent := contrail.ListParent(s.project.GetUuid())
+	page, marker, err := s.client.ListPage("network-ipam", parent,
+		contrail.ListPageLimit(2))
+	if err != nil {
+		t.Fatalf("List page: %v", err)
+	}
+	if marker == "" && len(page) > 2 {
+		t.Skip("The server does not paginate lists")
+	}
+	if len(page) != 2 || marker == "" {
+		t.Errorf("First page: %d objects, marker %q", len(page), marker)
+	}
+	results, err := s.client.ListWithOptions("network-ipam", parent,
+		contrail.ListPageLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, result := range results {
+		seen[result.Uuid] = true
+	}
+	if len(results) < 3 || len(seen) != len(results) {
+		t.Errorf("Unexpected pages: %+v", results)
+	}
+}
@@ -0,0 +1,284 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package dbcheck audits the consistency of the configuration stored in
+// the OpenContrail API server, similarly to contrail-db-check.
+//
+// The checker reads every object of the selected types, with their
+// reference lists, using one bulk list request per type and verifies that:
+//   - forward references point to existing objects;
+//   - the parent of each object exists;
+//   - children lists only contain existing objects;
+//   - every object has a fully qualified name;
+//   - (optionally) every forward reference has a matching back reference.
+package dbcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// ProblemKind classifies the inconsistencies reported by Check.
+type ProblemKind int
+
+const (
+	// DanglingReference is a forward reference to an object that doesn't exist.
+	DanglingReference ProblemKind = iota
+	// OrphanedChild is an object whose parent doesn't exist.
+	OrphanedChild
+	// StaleChild is a children list entry for an object that doesn't exist.
+	StaleChild
+	// MissingBackReference is a forward reference without the corresponding
+	// back reference on the target object.
+	MissingBackReference
+	// MissingFQName is an object without a fully qualified name.
+	MissingFQName
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case DanglingReference:
+		return "dangling-reference"
+	case OrphanedChild:
+		return "orphaned-child"
+	case StaleChild:
+		return "stale-child"
+	case MissingBackReference:
+		return "missing-back-reference"
+	case MissingFQName:
+		return "missing-fq-name"
+	}
+	return fmt.Sprintf("unknown(%d)", int(k))
+}
+
+// Problem describes a single inconsistency.
+type Problem struct {
+	Kind   ProblemKind
+	Type   string
+	Uuid   string
+	FQName []string
+	// Field is the reference or children field involved, if any.
+	Field string
+	// Target is the uuid (or fully qualified name) that could not be resolved.
+	Target string
+}
+
+func (p Problem) String() string {
+	msg := fmt.Sprintf("%s: %s %s (%s)", p.Kind, p.Type,
+		strings.Join(p.FQName, ":"), p.Uuid)
+	if p.Field != "" {
+		msg += " " + p.Field
+	}
+	if p.Target != "" {
+		msg += " -> " + p.Target
+	}
+	return msg
+}
+
+// Options controls the scope of the check.
+type Options struct {
+	// Types restricts the check to the listed types. All registered
+	// types are checked when empty.
+	Types []string
+	// CheckBackRefs enables the verification of back references. This
+	// requires reading back reference lists, which can be very large.
+	CheckBackRefs bool
+}
+
+// Report is the result of a consistency check.
+type Report struct {
+	Objects  int
+	Problems []Problem
+}
+
+type objectEntry struct {
+	typename string
+	obj      contrail.IObject
+}
+
+type checker struct {
+	client  contrail.ApiClient
+	options *Options
+	report  *Report
+	loaded  map[string]bool
+	// backRefs holds the back reference fields read for each type.
+	backRefs map[string]map[string]bool
+	entries  []*objectEntry
+	byUuid   map[string]*objectEntry
+	byName   map[string]*objectEntry
+}
+
+// optionsLister is implemented by *contrail.Client.
+type optionsLister interface {
+	ListDetailWithOptions(typename string, opts ...contrail.ListOption) (
+		[]contrail.IObject, error)
+}
+
+func nameKey(typename string, fqn []string) string {
+	return typename + ":" + strings.Join(fqn, ":")
+}
+
+func (c *checker) load(typename string) error {
+	fields, err := contrail.TypeReferenceFields(typename)
+	if err != nil {
+		return err
+	}
+	var fieldList []string
+	fieldList = append(fieldList, fields.Refs...)
+	fieldList = append(fieldList, fields.Children...)
+	if c.options.CheckBackRefs {
+		fieldList = append(fieldList, fields.BackRefs...)
+		c.backRefs[typename] = make(map[string]bool)
+		for _, field := range fields.BackRefs {
+			c.backRefs[typename][field] = true
+		}
+	}
+	// The reference lists are taken from the list response: reading them
+	// through the objects would issue a request for each empty list.
+	var objList []contrail.IObject
+	if lister, ok := c.client.(optionsLister); ok {
+		objList, err = lister.ListDetailWithOptions(typename,
+			contrail.ListFields(fieldList...), contrail.ListGeneric())
+	} else {
+		objList, err = c.client.ListDetail(typename, fieldList)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", typename, err)
+	}
+	c.loaded[typename] = true
+	for _, obj := range objList {
+		entry := &objectEntry{typename, obj}
+		c.entries = append(c.entries, entry)
+		c.byUuid[obj.GetUuid()] = entry
+		c.byName[nameKey(typename, obj.GetFQName())] = entry
+	}
+	c.report.Objects += len(objList)
+	return nil
+}
+
+func (c *checker) addProblem(kind ProblemKind, entry *objectEntry, field, target string) {
+	c.report.Problems = append(c.report.Problems, Problem{
+		Kind:   kind,
+		Type:   entry.typename,
+		Uuid:   entry.obj.GetUuid(),
+		FQName: entry.obj.GetFQName(),
+		Field:  field,
+		Target: target,
+	})
+}
+
+func hasReference(refList contrail.ReferenceList, uuid string) bool {
+	for _, ref := range refList {
+		if ref.Uuid == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) checkObject(entry *objectEntry) error {
+	obj := entry.obj
+	fqn := obj.GetFQName()
+	if len(fqn) == 0 {
+		c.addProblem(MissingFQName, entry, "", "")
+	}
+	parentType := obj.GetParentType()
+	if len(fqn) > 0 && parentType != "" && parentType != "config-root" &&
+		c.loaded[parentType] {
+		parentName := fqn[:len(fqn)-1]
+		if _, ok := c.byName[nameKey(parentType, parentName)]; !ok {
+			c.addProblem(OrphanedChild, entry, "",
+				nameKey(parentType, parentName))
+		}
+	}
+
+	backRefField := strings.Replace(entry.typename, "-", "_", -1) + "_back_refs"
+	refMap, err := contrail.ObjectReferences(obj)
+	if err != nil {
+		return err
+	}
+	for _, field := range sortedFields(refMap) {
+		for _, ref := range refMap[field] {
+			target, ok := c.byUuid[ref.Uuid]
+			if !ok {
+				if c.loaded[contrail.ReferenceFieldType(field)] {
+					c.addProblem(DanglingReference, entry, field, ref.Uuid)
+				}
+				continue
+			}
+			if !c.backRefs[target.typename][backRefField] {
+				continue
+			}
+			backRefs, err := contrail.GetReferenceList(target.obj, backRefField)
+			if err != nil {
+				return err
+			}
+			if !hasReference(backRefs, obj.GetUuid()) {
+				c.addProblem(MissingBackReference, entry, field, ref.Uuid)
+			}
+		}
+	}
+
+	childMap, err := contrail.ObjectChildren(obj)
+	if err != nil {
+		return err
+	}
+	for _, field := range sortedFields(childMap) {
+		if !c.loaded[contrail.ReferenceFieldType(field)] {
+			continue
+		}
+		for _, ref := range childMap[field] {
+			if _, ok := c.byUuid[ref.Uuid]; !ok {
+				c.addProblem(StaleChild, entry, field, ref.Uuid)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedFields(m map[string]contrail.ReferenceList) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Check reads the configuration and returns the list of inconsistencies found.
+func Check(client contrail.ApiClient, options *Options) (*Report, error) {
+	opts := Options{}
+	if options != nil {
+		opts = *options
+	}
+	if len(opts.Types) == 0 {
+		opts.Types = contrail.TypeNames()
+	}
+	c := &checker{
+		client:   client,
+		options:  &opts,
+		report:   new(Report),
+		loaded:   make(map[string]bool),
+		backRefs: make(map[string]map[string]bool),
+		byUuid:   make(map[string]*objectEntry),
+		byName:   make(map[string]*objectEntry),
+	}
+	for _, typename := range opts.Types {
+		if err := c.load(typename); err != nil {
+			return nil, err
+		}
+	}
+	// References to types outside the scope of the check can't be
+	// verified and are ignored.
+	for _, entry := range c.entries {
+		if err := c.checkObject(entry); err != nil {
+			return nil, fmt.Errorf("%s %s: %v", entry.typename,
+				entry.obj.GetUuid(), err)
+		}
+	}
+	return c.report, nil
+}
@@ -0,0 +1,195 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package dbcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/fakeserver"
+)
+
+type testObject struct {
+	contrail.ObjectBase
+}
+
+func (*testObject) SetName(string)                {}
+func (*testObject) GetDefaultParent() []string    { return nil }
+func (*testObject) GetDefaultParentType() string  { return "" }
+func (*testObject) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testObject) UpdateReferences() error       { return nil }
+func (*testObject) UpdateDone()                   {}
+
+type TestProject struct {
+	testObject
+	test_networks          contrail.ReferenceList
+	test_network_back_refs contrail.ReferenceList
+}
+
+func (*TestProject) GetType() string { return "test-project" }
+func (obj *TestProject) GetTestNetworks() (contrail.ReferenceList, error) {
+	return obj.test_networks, nil
+}
+func (obj *TestProject) GetTestNetworkBackRefs() (contrail.ReferenceList, error) {
+	return obj.test_network_back_refs, nil
+}
+
+type TestNetwork struct {
+	testObject
+	test_project_refs contrail.ReferenceList
+}
+
+func (*TestNetwork) GetType() string { return "test-network" }
+func (obj *TestNetwork) GetTestProjectRefs() (contrail.ReferenceList, error) {
+	return obj.test_project_refs, nil
+}
+
+// unnamedNetwork is a test-network received without its fq_name.
+type unnamedNetwork struct {
+	TestNetwork
+}
+
+func (*unnamedNetwork) GetFQName() []string { return nil }
+
+type listClient struct {
+	contrail.ApiClient
+	objects map[string][]contrail.IObject
+}
+
+func (c *listClient) ListDetail(typename string, fields []string) ([]contrail.IObject, error) {
+	return c.objects[typename], nil
+}
+
+func registerTestTypes() {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"test-project": reflect.TypeOf(TestProject{}),
+		"test-network": reflect.TypeOf(TestNetwork{}),
+	})
+}
+
+func TestCheck(t *testing.T) {
+	registerTestTypes()
+
+	project := new(TestProject)
+	project.SetFQName("", []string{"p1"})
+	project.SetUuid("p1-uuid")
+	project.test_networks = contrail.ReferenceList{
+		{To: []string{"p1", "n1"}, Uuid: "n1-uuid"},
+		{To: []string{"p1", "n3"}, Uuid: "n3-uuid"},
+	}
+
+	n1 := new(TestNetwork)
+	n1.SetFQName("test-project", []string{"p1", "n1"})
+	n1.SetUuid("n1-uuid")
+	n1.test_project_refs = contrail.ReferenceList{{Uuid: "p1-uuid"}}
+
+	n2 := new(TestNetwork)
+	n2.SetFQName("test-project", []string{"p2", "n2"})
+	n2.SetUuid("n2-uuid")
+	n2.test_project_refs = contrail.ReferenceList{{Uuid: "p2-uuid"}}
+
+	n4 := new(unnamedNetwork)
+	n4.SetFQName("test-project", []string{"p1", "n4"})
+	n4.SetUuid("n4-uuid")
+
+	client := &listClient{objects: map[string][]contrail.IObject{
+		"test-project": {project},
+		"test-network": {n1, n2, n4},
+	}}
+
+	report, err := Check(client, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Objects != 4 {
+		t.Errorf("expected 4 objects, got %d", report.Objects)
+	}
+	expected := []string{
+		"orphaned-child: test-network p2:n2 (n2-uuid) -> test-project:p2",
+		"dangling-reference: test-network p2:n2 (n2-uuid) test_project_refs -> p2-uuid",
+		"missing-fq-name: test-network  (n4-uuid)",
+		"stale-child: test-project p1 (p1-uuid) test_networks -> n3-uuid",
+	}
+	var actual []string
+	for _, problem := range report.Problems {
+		actual = append(actual, problem.String())
+	}
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("unexpected problems:\n%v", actual)
+	}
+}
+
+func TestCheckRequests(t *testing.T) {
+	registerTestTypes()
+	var requests, failLists int32
+	api := fakeserver.New(nil)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				atomic.AddInt32(&requests, 1)
+				if atomic.LoadInt32(&failLists) != 0 && strings.HasSuffix(r.URL.Path, "s") {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+			}
+			api.ServeHTTP(w, r)
+		}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	client := contrail.NewClient(host, portNum)
+
+	p1 := contrail.NewGenericObject("test-project")
+	p1.SetFQName("", []string{"p1"})
+	p2 := contrail.NewGenericObject("test-project")
+	p2.SetFQName("", []string{"p2"})
+	for _, obj := range []contrail.IObject{p1, p2} {
+		if err := client.Create(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"n1", "n2"} {
+		network := contrail.NewGenericObject("test-network")
+		network.SetFQName("test-project", []string{"p1", name})
+		if err := network.AddReference("test_project_refs", p1, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Create(network); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One list per type, whatever the number of objects and of empty
+	// reference lists.
+	atomic.StoreInt32(&requests, 0)
+	options := &Options{
+		Types:         []string{"test-project", "test-network"},
+		CheckBackRefs: true,
+	}
+	report, err := Check(client, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Objects != 4 || len(report.Problems) != 0 {
+		t.Errorf("unexpected report: %d objects, problems %v",
+			report.Objects, report.Problems)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+
+	atomic.StoreInt32(&failLists, 1)
+	if _, err := Check(client, options); err == nil {
+		t.Error("list failure not reported")
+	}
+}
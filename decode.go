@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// decodeInto decodes data into obj. The object retains a copy of data
+// (see IsFieldSet).
+func decodeInto(obj IObject, data []byte, useNumber bool) (err error) {
+	defer recoverPanic("Decode "+obj.GetType(), &err)
+	// Validate the input, which decodeRaw expects, and copy it: data may
+	// be a pooled buffer.
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return decodeRaw(obj, raw, useNumber)
+}
+
+// decodeFromStream decodes the next value of decoder into obj.
+func decodeFromStream(decoder *json.Decoder, obj IObject, useNumber bool) (err error) {
+	defer recoverPanic("Decode "+obj.GetType(), &err)
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	return decodeRaw(obj, raw, useNumber)
+}
+
+// decodeRaw decodes raw, which must be valid JSON, into obj and records
+// the attributes received, and the unknown ones.
+func decodeRaw(obj IObject, raw json.RawMessage, useNumber bool) error {
+	if fields, ok := obj.(FieldUnmarshaler); ok {
+		return decodeFields(obj, fields, raw)
+	}
+	var err error
+	if useNumber {
+		err = UnmarshalUseNumber(raw, obj)
+	} else {
+		err = json.Unmarshal(raw, obj)
+	}
+	if err != nil || isNull(trimSpace(raw)) {
+		return err
+	}
+	if err := recordReceived(obj, raw); err != nil {
+		return err
+	}
+	captureUnknown(obj)
+	return nil
+}
+
+// DecodeObject decodes an object of a registered type (or, for other
+// types, a GenericObject) from its JSON representation, either as a
+// response body ({"<typename>": {...}}) or the object alone. Malformed
+// input results in an error rather than a panic.
+func DecodeObject(typename string, data []byte) (IObject, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if content, ok := m[typename]; ok && len(m) == 1 {
+		data = content
+	}
+	obj := newObjectOfType(typename)
+	if err := decodeInto(obj, data, false); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("Expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// decodeListDetail decodes the response to a detailed list request:
+//
+//	{"<typename>s": [{"<typename>": {...}}, ...]}
+//
+// The response is decoded as a stream rather than read into intermediate
+// maps first; list responses can be tens of MB. Each element is decoded
+// without reflection if its type implements FieldUnmarshaler, by its
+// UnmarshalJSON method otherwise; elements of unregistered types are
+// decoded as GenericObjects.
+func decodeListDetail(typename string, decoder *json.Decoder) ([]IObject, error) {
+	result, _, err := decodeListDetailPage(typename, decoder, newObjectOfType,
+		false)
+	return result, err
+}
+
+// decodeListDetailPage decodes a detailed list response and the marker of
+// the next page, present in paginated responses. The objects are allocated
+// by alloc.
+func decodeListDetailPage(typename string, decoder *json.Decoder,
+	alloc func(string) IObject, useNumber bool) ([]IObject, string, error) {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, "", err
+	}
+	var result []IObject
+	var marker string
+	found := false
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, "", err
+		}
+		key, _ := token.(string)
+		if key == "marker" {
+			var value *string
+			if err := decoder.Decode(&value); err != nil {
+				return nil, "", err
+			}
+			if value != nil {
+				marker = *value
+			}
+			continue
+		}
+		if key != typename+"s" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		found = true
+		if err := expectDelim(decoder, '['); err != nil {
+			return nil, "", err
+		}
+		for decoder.More() {
+			obj, err := decodeListElement(decoder, typename, alloc,
+				useNumber)
+			if err != nil {
+				return nil, "", err
+			}
+			result = append(result, obj)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, "", err
+		}
+	}
+	if !found {
+		return nil, "", fmt.Errorf("No %ss in Response", typename)
+	}
+	return result, marker, nil
+}
+
+func decodeListElement(decoder *json.Decoder, typename string,
+	alloc func(string) IObject, useNumber bool) (IObject, error) {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+	var obj IObject
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key, _ := token.(string); key != typename {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		obj = alloc(typename)
+		if err := decodeFromStream(decoder, obj, useNumber); err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("No %s in element", typename)
+	}
+	return obj, nil
+}
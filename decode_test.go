@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// nestedNetwork decodes a nested property without checking its shape, as
+// the generated code does for pointer fields.
+type nestedNetwork struct {
+	TestNetwork
+}
+
+func (*nestedNetwork) GetType() string {
+	return "nested-network"
+}
+
+func (obj *nestedNetwork) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	var subnets []*struct{ Prefix string }
+	json.Unmarshal(m["subnets"], &subnets)
+	for _, subnet := range subnets {
+		obj.display_name += subnet.Prefix
+	}
+	return nil
+}
+
+func (obj *nestedNetwork) UnmarshalField(name string, value []byte) (bool, error) {
+	if name != "subnets" {
+		return obj.TestNetwork.UnmarshalField(name, value)
+	}
+	var subnets []*struct{ Prefix string }
+	json.Unmarshal(value, &subnets)
+	for _, subnet := range subnets {
+		obj.display_name += subnet.Prefix
+	}
+	return true, nil
+}
+
+func registerDecodeTestTypes() {
+	RegisterTypeMap(TypeMap{
+		"test-network":   reflect.TypeOf(TestNetwork{}),
+		"nested-network": reflect.TypeOf(nestedNetwork{}),
+	})
+}
+
+func TestDecodeObject(t *testing.T) {
+	registerDecodeTestTypes()
+	defer registerTestTypes()
+
+	for _, data := range []string{
+		`{"test-network": {"fq_name": ["p", "n"], "uuid": "u", "name": "n", "display_name": "web"}}`,
+		`{"fq_name": ["p", "n"], "uuid": "u", "name": "n", "display_name": "web"}`,
+	} {
+		obj, err := DecodeObject("test-network", []byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		network := obj.(*TestNetwork)
+		if network.GetUuid() != "u" || network.display_name != "web" {
+			t.Errorf("unexpected object %+v", network)
+		}
+	}
+
+	obj, err := DecodeObject("custom-resource",
+		[]byte(`{"fq_name": ["cr"], "uuid": "u", "name": "cr", "size": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*GenericObject); !ok {
+		t.Errorf("expected a GenericObject, got %T", obj)
+	}
+
+	for data, message := range map[string]string{
+		`{"uuid": "u", "name": "n"}`:                                      "Missing fq_name",
+		`{"fq_name": "p:n", "uuid": "u", "name": "n"}`:                    "Invalid fq_name",
+		`{"fq_name": ["p", "n"], "uuid": "u", "name": "n`:                 "unexpected end",
+		`{"fq_name": ["n"], "uuid": "u", "name": "n", "subnets": [null]}`: "Decode nested-network: panic",
+	} {
+		_, err := DecodeObject("test-network", []byte(data))
+		if strings.Contains(data, "subnets") {
+			_, err = DecodeObject("nested-network", []byte(data))
+		}
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected %q, got %v", data, message, err)
+		}
+		if _, ok := err.(*PanicError); ok != strings.Contains(data, "subnets") {
+			t.Errorf("%s: unexpected error type %T", data, err)
+		}
+	}
+}
+
+func FuzzDecodeObject(f *testing.F) {
+	registerDecodeTestTypes()
+	defer registerTestTypes()
+
+	for _, seed := range []string{
+		`{"test-network": {"fq_name": ["p", "n"], "uuid": "u", "name": "n", "href": "http://h/test-network/u"}}`,
+		`{"fq_name": ["p", "n"], "uuid": "u", "name": "n", "test_project_refs": [{"to": ["p"], "uuid": "p1", "attr": null}]}`,
+		`{"fq_name": ["n"], "uuid": "u", "name": "n", "subnets": [{"Prefix": "10.0.0.0/8"}]}`,
+		`{"fq_name": [], "uuid": "", "name": "", "href": ""}`,
+	} {
+		f.Add("test-network", []byte(seed))
+		f.Add("nested-network", []byte(seed))
+		f.Add("custom-resource", []byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, typename string, data []byte) {
+		switch typename {
+		case "test-network", "nested-network", "custom-resource":
+		default:
+			t.Skip()
+		}
+		obj, err := DecodeObject(typename, data)
+		if err != nil {
+			return
+		}
+		// A decoded object can be encoded again.
+		if _, err := json.Marshal(obj); err != nil {
+			t.Errorf("%s: %v", data, err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"sync"
+)
+
+// readCall is a read in progress.
+type readCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// readGroup collapses concurrent reads of the same resource into a single
+// request.
+type readGroup struct {
+	mutex sync.Mutex
+	calls map[string]*readCall
+	// shared counts the reads that were served by another request.
+	shared int
+}
+
+// do invokes fn unless a call with the same key is in progress, in which
+// case it waits for that call and returns its result.
+func (g *readGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mutex.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.shared++
+		g.mutex.Unlock()
+		<-call.done
+		return call.body, call.err
+	}
+	call := &readCall{done: make(chan struct{})}
+	if g.calls == nil {
+		g.calls = make(map[string]*readCall)
+	}
+	g.calls[key] = call
+	g.mutex.Unlock()
+
+	call.body, call.err = fn()
+
+	g.mutex.Lock()
+	delete(g.calls, key)
+	g.mutex.Unlock()
+	close(call.done)
+	return call.body, call.err
+}
+
+// SetReadDeduplication controls whether concurrent identical reads
+// (FindByUuid, FindByName, UuidByName, ReadListResult, ReadReference) are
+// collapsed into a single request to the API server. Each caller decodes
+// its own copy of the object, but a read that joins a request in progress
+// may return a state of the object that precedes the call.
+//
+// This reduces the load on the API server when many goroutines resolve the
+// same objects at once, e.g. a CNI plugin handling a burst of pods that
+// all look up the same project and virtual-network.
+func (c *Client) SetReadDeduplication(enabled bool) {
+	if !enabled {
+		c.reads = nil
+	} else if c.reads == nil {
+		c.reads = new(readGroup)
+	}
+}
+
+// readDeduplicated invokes fn through the read group, if enabled.
+func (c *Client) readDeduplicated(key string, fn func() ([]byte, error)) ([]byte, error) {
+	if c.reads == nil {
+		return fn()
+	}
+	return c.reads.do(key, fn)
+}
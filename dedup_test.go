@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrentReads invokes read from n goroutines while the server
+// responses are held, and returns the number of requests received.
+func concurrentReads(t *testing.T, n int, read func(*Client) error) int32 {
+	var requests int32
+	release := make(chan struct{})
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		switch r.URL.Path {
+		case "/fqname-to-id":
+			fmt.Fprint(w, `{"uuid": "net-uuid"}`)
+		default:
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "display_name": "net"}}`)
+		}
+	})
+	defer server.Close()
+	client.SetReadDeduplication(true)
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = read(client)
+		}(i)
+	}
+	// Wait for the readers to join the request in progress.
+	for {
+		client.reads.mutex.Lock()
+		shared := client.reads.shared
+		client.reads.mutex.Unlock()
+		if shared+int(atomic.LoadInt32(&requests)) >= n {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return atomic.LoadInt32(&requests)
+}
+
+func TestReadDeduplication(t *testing.T) {
+	var mutex sync.Mutex
+	var objects []IObject
+	requests := concurrentReads(t, 20, func(client *Client) error {
+		obj, err := client.FindByUuid("test-network", "net-uuid")
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		// Each caller has its own copy.
+		for _, other := range objects {
+			if obj == other {
+				t.Error("object shared between callers")
+			}
+		}
+		objects = append(objects, obj)
+		return nil
+	})
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	requests = concurrentReads(t, 20, func(client *Client) error {
+		uuid, err := client.UuidByName("test-network", "default-project:net")
+		if err == nil && uuid != "net-uuid" {
+			err = fmt.Errorf("unexpected uuid %s", uuid)
+		}
+		return err
+	})
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestReadDeduplicationDisabled(t *testing.T) {
+	var requests int32
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net"}}`)
+	})
+	defer server.Close()
+	client.SetReadDeduplication(true)
+	client.SetReadDeduplication(false)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.FindByUuid("test-network", "net-uuid"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Address families of the connections to the API server.
+const (
+	DualStack = "tcp"
+	IPv4Only  = "tcp4"
+	IPv6Only  = "tcp6"
+)
+
+// dialOptions configures the connections of the API client.
+type dialOptions struct {
+	// network restricts the address family (IPv4Only or IPv6Only).
+	network string
+	// fallbackDelay of the dual-stack (Happy Eyeballs) dialer.
+	fallbackDelay time.Duration
+	// socket is the path of the unix socket all connections are made
+	// to, when set.
+	socket string
+}
+
+// hostPort joins a host name or address, IPv6 literals being bracketed or
+// not, and a port.
+func hostPort(host string, port int) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func (o dialOptions) dialContext(dialer *net.Dialer) func(context.Context,
+	string, string) (net.Conn, error) {
+	dialer.FallbackDelay = o.fallbackDelay
+	if o.socket != "" {
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", o.socket)
+		}
+	}
+	if o.network == "" || o.network == DualStack {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, o.network, address)
+	}
+}
+
+// SetAddressFamily restricts the connections to the API server to IPv4
+// (IPv4Only) or IPv6 (IPv6Only) addresses. By default (DualStack) a host
+// name that resolves to addresses of both families is reached over
+// either: see SetFallbackDelay.
+func (c *Client) SetAddressFamily(network string) error {
+	switch network {
+	case DualStack, IPv4Only, IPv6Only:
+	default:
+		return fmt.Errorf("Invalid address family %q", network)
+	}
+	c.dial.network = network
+	c.updateTransport()
+	return nil
+}
+
+// SetFallbackDelay sets how long a connection attempt to the first
+// address family returned by the resolver (usually IPv6) may take before
+// an attempt is made in parallel to the other family ("Happy Eyeballs",
+// RFC 6555). A delay of 0 selects the default of 300ms; a negative delay
+// disables the parallel attempt, addresses are then tried in order.
+func (c *Client) SetFallbackDelay(delay time.Duration) {
+	c.dial.fallbackDelay = delay
+	c.updateTransport()
+}
+
+// SetUnixSocket connects to the API server (or a local proxy) through the
+// unix socket at path rather than over TCP, whatever the host of the
+// request URLs; they still set the Host header. HTTP proxies configured
+// in the environment are not used. An empty path restores TCP
+// connections.
+func (c *Client) SetUnixSocket(path string) {
+	c.dial.socket = path
+	c.updateTransport()
+}
+
+// NewUnixSocketClient allocates a client of the API server listening on
+// the unix socket at path.
+func NewUnixSocketClient(path string) *Client {
+	client := NewClient("localhost", 8082)
+	client.SetUnixSocket(path)
+	return client
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHostPort(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"contrail-api", "contrail-api:8082"},
+		{"10.0.0.1", "10.0.0.1:8082"},
+		{"fd00::1", "[fd00::1]:8082"},
+		{"[fd00::1]", "[fd00::1]:8082"},
+	}
+	for _, test := range tests {
+		if result := hostPort(test.host, 8082); result != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.host, result, test.expected)
+		}
+	}
+
+	keystone := NewKeystoneClient("http://[fd00::1]:5000/v3", "", "", "", "", "", "", "")
+	keystone.AddEncryption("", "", "", true)
+	if keystone.osAuthURL != "https://[fd00::1]:5000/v3" {
+		t.Errorf("unexpected auth URL %s", keystone.osAuthURL)
+	}
+}
+
+func TestIPv6Server(t *testing.T) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 not available:", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, rootDocument)
+		}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	for _, host := range []string{"::1", "[::1]"} {
+		client := NewClient(host, port)
+		if err := client.Ping(context.Background()); err != nil {
+			t.Errorf("%s: %v", host, err)
+		}
+	}
+
+	client := NewClient("::1", port)
+	if err := client.SetAddressFamily("ipv6"); err == nil {
+		t.Error("expected error for invalid address family")
+	}
+	client.SetFallbackDelay(-1)
+	if err := client.SetAddressFamily(IPv4Only); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("IPv6 server reached over IPv4")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contrail-api.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets not available:", err)
+	}
+	var host string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			host = r.Host
+			fmt.Fprint(w, rootDocument)
+		}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client := NewUnixSocketClient(path)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if host != "localhost:8082" {
+		t.Errorf("unexpected Host header %q", host)
+	}
+}
@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+)
+
+// JobRequest is a request to run a job-template (e.g. fabric onboarding,
+// image upgrade, RMA) through the job manager.
+type JobRequest struct {
+	// The job template is identified either by uuid or by fq_name, e.g.
+	// ["default-global-system-config", "image_upgrade_template"].
+	TemplateID     string
+	TemplateFQName []string
+	// Input must match the input schema of the job template. It is
+	// encoded as JSON.
+	Input interface{}
+	// Devices restricts the job to the listed physical-router uuids.
+	Devices []string
+}
+
+// JobExecution identifies a running job.
+type JobExecution struct {
+	ExecutionID string `json:"job_execution_id"`
+	ProcessID   int    `json:"job_manager_process_id,omitempty"`
+}
+
+// ExecuteJob starts a job. The job status is reported to the analytics
+// service (see analytics.WaitForJob).
+func (c *Client) ExecuteJob(request *JobRequest) (*JobExecution, error) {
+	if request.TemplateID == "" && len(request.TemplateFQName) == 0 {
+		return nil, fmt.Errorf("execute-job: job template not specified")
+	}
+	type jobParams struct {
+		DeviceList []string `json:"device_list,omitempty"`
+	}
+	msg := struct {
+		TemplateID     string      `json:"job_template_id,omitempty"`
+		TemplateFQName []string    `json:"job_template_fq_name,omitempty"`
+		Input          interface{} `json:"input"`
+		Params         *jobParams  `json:"params,omitempty"`
+	}{
+		TemplateID:     request.TemplateID,
+		TemplateFQName: request.TemplateFQName,
+		Input:          request.Input,
+	}
+	if msg.Input == nil {
+		msg.Input = map[string]interface{}{}
+	}
+	if len(request.Devices) > 0 {
+		msg.Params = &jobParams{request.Devices}
+	}
+	execution := new(JobExecution)
+	err := c.DoJSON(context.Background(), "POST", "execute-job", &msg, execution)
+	if err != nil {
+		return nil, err
+	}
+	if execution.ExecutionID == "" {
+		return nil, fmt.Errorf("execute-job: no job_execution_id in response")
+	}
+	return execution, nil
+}
+
+// AbortJob stops running jobs. A graceful abort lets the current task
+// complete; otherwise the job is terminated immediately.
+func (c *Client) AbortJob(executionIDs []string, graceful bool) error {
+	mode := "force"
+	if graceful {
+		mode = "graceful"
+	}
+	msg := map[string]interface{}{
+		"job_execution_ids": executionIDs,
+		"abort_mode":        mode,
+	}
+	return c.DoJSON(context.Background(), "POST", "abort-job", msg, nil)
+}
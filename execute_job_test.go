@@ -0,0 +1,46 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestExecuteJob(t *testing.T) {
+	var request map[string]interface{}
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/execute-job" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		fmt.Fprint(w, `{"job_execution_id": "1545234", "job_manager_process_id": 42}`)
+	})
+	defer server.Close()
+
+	execution, err := client.ExecuteJob(&JobRequest{
+		TemplateFQName: []string{"default-global-system-config", "image_upgrade_template"},
+		Input:          map[string]string{"image_uuid": "img-1"},
+		Devices:        []string{"pr-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if execution.ExecutionID != "1545234" || execution.ProcessID != 42 {
+		t.Errorf("unexpected execution %+v", execution)
+	}
+	input, _ := request["input"].(map[string]interface{})
+	params, _ := request["params"].(map[string]interface{})
+	if input["image_uuid"] != "img-1" || params == nil {
+		t.Errorf("unexpected request %v", request)
+	}
+
+	if _, err := client.ExecuteJob(&JobRequest{}); err == nil {
+		t.Error("expected error without job template")
+	}
+}
@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package fakeserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// listFilter is a filters query parameter element: field==value.
+type listFilter struct {
+	field string
+	value interface{}
+}
+
+func parseFilters(r *http.Request) ([]listFilter, error) {
+	var filters []listFilter
+	for _, element := range queryList(r, "filters") {
+		parts := strings.SplitN(element, "==", 2)
+		if len(parts) != 2 {
+			return nil, errorf(http.StatusBadRequest, "Invalid filter %q", element)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			// Unquoted strings are accepted, as by the API server.
+			value = parts[1]
+		}
+		filters = append(filters, listFilter{parts[0], value})
+	}
+	return filters, nil
+}
+
+// jsonEqual compares two values by their encoding.
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+// matchFilters returns true if obj matches the filters: the values of a
+// field are alternatives, the fields must all match.
+func matchFilters(obj *object, filters []listFilter) bool {
+	matched := make(map[string]bool)
+	for _, filter := range filters {
+		if _, ok := matched[filter.field]; !ok {
+			matched[filter.field] = false
+		}
+		raw, ok := obj.fields[filter.field]
+		if !ok {
+			continue
+		}
+		var value interface{}
+		json.Unmarshal(raw, &value)
+		if jsonEqual(value, filter.value) {
+			matched[filter.field] = true
+		}
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// refersTo returns true if obj has a reference to one of uuids.
+func refersTo(obj *object, uuids map[string]bool) bool {
+	for _, refs := range obj.references() {
+		for _, ref := range refs {
+			if uuids[ref.Uuid] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) list(typename string, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	parents := fieldSet(queryList(r, "parent_id"))
+	backRefs := fieldSet(queryList(r, "back_ref_id"))
+	uuids := fieldSet(queryList(r, "obj_uuids"))
+	filters, err := parseFilters(r)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*object
+	for _, obj := range s.sortedObjects() {
+		if obj.typename != typename ||
+			(parents != nil && !parents[obj.parentUuid]) ||
+			(backRefs != nil && !refersTo(obj, backRefs)) ||
+			(uuids != nil && !uuids[obj.uuid]) ||
+			!matchFilters(obj, filters) {
+			continue
+		}
+		matches = append(matches, obj)
+	}
+	collection := typename + "s"
+	if query.Get("count") == "true" {
+		return map[string]interface{}{
+			collection: map[string]int{"count": len(matches)},
+		}, nil
+	}
+
+	// Objects are sorted by uuid: the marker is the last uuid returned.
+	if marker := query.Get("page_marker"); marker != "" {
+		for len(matches) > 0 && matches[0].uuid <= marker {
+			matches = matches[1:]
+		}
+	}
+	var marker interface{}
+	if limit, err := strconv.Atoi(query.Get("page_limit")); err == nil &&
+		limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+		marker = matches[limit-1].uuid
+	}
+
+	elements := make([]interface{}, 0, len(matches))
+	if query.Get("detail") == "true" {
+		// Back references and children are only returned when they are
+		// requested explicitly.
+		fields := fieldSet(queryList(r, "fields"))
+		opts := &readOptions{
+			fields:          fields,
+			excludeBackRefs: fields == nil,
+			excludeChildren: fields == nil,
+		}
+		for _, obj := range matches {
+			elements = append(elements, map[string]interface{}{
+				typename: s.encode(obj, r.Host, opts),
+			})
+		}
+	} else {
+		excludeHrefs := query.Get("exclude_hrefs") == "true"
+		for _, obj := range matches {
+			element := map[string]interface{}{
+				"fq_name": obj.fqName,
+				"uuid":    obj.uuid,
+			}
+			if !excludeHrefs {
+				element["href"] = s.href(r.Host, typename, obj.uuid)
+			}
+			elements = append(elements, element)
+		}
+	}
+	return map[string]interface{}{collection: elements, "marker": marker}, nil
+}
@@ -0,0 +1,738 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package fakeserver implements the REST interface of the API server
+// against an in-memory store, for tests:
+//
+//	server := httptest.NewServer(fakeserver.New(contrail.Schema()))
+//	defer server.Close()
+//
+// With a schema (the types registered by the generated types library),
+// requests are validated: unknown types and fields are rejected, and
+// properties and reference attributes must decode into the generated
+// types. Without one, any type and field is accepted.
+//
+// The server checks parents and reference targets, refuses to delete
+// objects that have children or are referred to, and computes back
+// references and children. It doesn't implement the type specific logic
+// of the API server (e.g. address allocation or routing instances).
+//
+// The contrail-fake-apiserver command serves it over HTTP.
+package fakeserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// timestampFormat is the format of the id_perms timestamps.
+const timestampFormat = "2006-01-02T15:04:05.000000"
+
+// Fields that the server manages.
+var commonFields = map[string]bool{
+	"fq_name":     true,
+	"uuid":        true,
+	"name":        true,
+	"href":        true,
+	"parent_type": true,
+	"parent_uuid": true,
+	"parent_href": true,
+}
+
+// Objects created by the API server when it initializes.
+var defaultObjects = []struct {
+	typename   string
+	parentType string
+	fqName     []string
+}{
+	{"domain", "config-root", []string{"default-domain"}},
+	{"project", "domain", []string{"default-domain", "default-project"}},
+	{"network-ipam", "project", []string{"default-domain", "default-project",
+		"default-network-ipam"}},
+	{"global-system-config", "config-root",
+		[]string{"default-global-system-config"}},
+	{"global-vrouter-config", "global-system-config",
+		[]string{"default-global-system-config", "default-global-vrouter-config"}},
+}
+
+type object struct {
+	typename   string
+	uuid       string
+	fqName     []string
+	parentType string
+	parentUuid string
+	fields     map[string]json.RawMessage
+}
+
+// reference is the stored representation of a reference.
+type reference struct {
+	To   []string        `json:"to"`
+	Uuid string          `json:"uuid"`
+	Href string          `json:"href,omitempty"`
+	Attr json.RawMessage `json:"attr,omitempty"`
+}
+
+// Server is an in-memory API server. It implements http.Handler.
+type Server struct {
+	schema *contrail.SchemaInfo
+
+	mutex   sync.Mutex
+	objects map[string]*object
+	// names maps the type and fq_name of each object to its uuid.
+	names map[string]string
+}
+
+// New allocates a server that validates requests against schema, if not
+// nil. The default objects (default-domain, default-project, ...) are
+// created.
+func New(schema *contrail.SchemaInfo) *Server {
+	s := &Server{
+		schema:  schema,
+		objects: make(map[string]*object),
+		names:   make(map[string]string),
+	}
+	for _, obj := range defaultObjects {
+		if schema != nil && schema.Type(obj.typename) == nil {
+			continue
+		}
+		s.insert(&object{
+			typename:   obj.typename,
+			uuid:       newUuid(),
+			fqName:     obj.fqName,
+			parentType: obj.parentType,
+			fields:     map[string]json.RawMessage{"id_perms": idPerms(nil)},
+		})
+	}
+	return s
+}
+
+// httpError is an error reported with an HTTP status.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status, fmt.Sprintf(format, args...)}
+}
+
+func newUuid() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func nameKey(typename string, fqName []string) string {
+	return typename + " " + strings.Join(fqName, ":")
+}
+
+// fieldName returns the name of the field that lists the objects of
+// typename, with the given suffix (e.g. _refs).
+func fieldName(typename, suffix string) string {
+	return strings.Replace(typename, "-", "_", -1) + suffix
+}
+
+// isRefField returns true for forward reference fields.
+func isRefField(name string) bool {
+	return strings.HasSuffix(name, "_refs") && !strings.HasSuffix(name, "_back_refs")
+}
+
+func refTarget(name string) string {
+	return strings.Replace(strings.TrimSuffix(name, "_refs"), "_", "-", -1)
+}
+
+// idPerms returns the id_perms property with the timestamps set.
+func idPerms(current json.RawMessage) json.RawMessage {
+	perms := make(map[string]interface{})
+	if len(current) > 0 {
+		json.Unmarshal(current, &perms)
+	}
+	now := time.Now().UTC().Format(timestampFormat)
+	if _, ok := perms["created"]; !ok {
+		perms["created"] = now
+	}
+	if _, ok := perms["enable"]; !ok {
+		perms["enable"] = true
+	}
+	perms["last_modified"] = now
+	data, _ := json.Marshal(perms)
+	return data
+}
+
+func (s *Server) insert(obj *object) {
+	s.objects[obj.uuid] = obj
+	s.names[nameKey(obj.typename, obj.fqName)] = obj.uuid
+}
+
+func (s *Server) href(host, typename, uuid string) string {
+	return fmt.Sprintf("http://%s/%s/%s", host, typename, uuid)
+}
+
+// decodeStrict decodes data into a value of type xtype, rejecting unknown
+// fields.
+func decodeStrict(data []byte, xtype reflect.Type) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(reflect.New(xtype).Interface())
+}
+
+// validate checks the fields of a create or update request and resolves
+// the references. Fields managed by the server are ignored.
+func (s *Server) validate(typename string, fields map[string]json.RawMessage) (
+	map[string]json.RawMessage, error) {
+	var schema *contrail.TypeSchema
+	if s.schema != nil {
+		schema = s.schema.Type(typename)
+	}
+	result := make(map[string]json.RawMessage)
+	for name, value := range fields {
+		if commonFields[name] {
+			continue
+		}
+		var field *contrail.FieldSchema
+		if s.schema != nil {
+			field = schema.Field(name)
+			if field == nil {
+				return nil, errorf(http.StatusBadRequest,
+					"Unknown field %s in %s", name, typename)
+			}
+			switch field.Kind {
+			case contrail.FieldBackRef, contrail.FieldChildren:
+				return nil, errorf(http.StatusBadRequest,
+					"%s is computed by the server", name)
+			case contrail.FieldProperty:
+				if err := decodeStrict(value, field.Type); err != nil {
+					return nil, errorf(http.StatusBadRequest,
+						"Invalid %s: %v", name, err)
+				}
+			}
+		}
+		if !isRefField(name) {
+			result[name] = value
+			continue
+		}
+		refs, err := s.resolveRefs(name, value, field)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = refs
+	}
+	return result, nil
+}
+
+// resolveRefs checks that the targets of the references exist, and
+// returns the references with their uuid and fq_name.
+func (s *Server) resolveRefs(name string, value json.RawMessage,
+	field *contrail.FieldSchema) (json.RawMessage, error) {
+	var refs []reference
+	if err := json.Unmarshal(value, &refs); err != nil {
+		return nil, errorf(http.StatusBadRequest, "Invalid %s: %v", name, err)
+	}
+	target := refTarget(name)
+	for i := range refs {
+		ref := &refs[i]
+		if field != nil && field.AttrType != nil && len(ref.Attr) > 0 &&
+			string(ref.Attr) != "null" {
+			if err := decodeStrict(ref.Attr, field.AttrType); err != nil {
+				return nil, errorf(http.StatusBadRequest,
+					"Invalid %s attribute: %v", name, err)
+			}
+		}
+		if ref.Uuid == "" {
+			ref.Uuid = s.names[nameKey(target, ref.To)]
+		}
+		obj, ok := s.objects[ref.Uuid]
+		if !ok || obj.typename != target {
+			return nil, errorf(http.StatusNotFound, "%s %s %s not found",
+				name, strings.Join(ref.To, ":"), ref.Uuid)
+		}
+		ref.To = obj.fqName
+		ref.Href = ""
+	}
+	data, _ := json.Marshal(refs)
+	return data, nil
+}
+
+// references returns the references of obj, by field.
+func (obj *object) references() map[string][]reference {
+	result := make(map[string][]reference)
+	for name, value := range obj.fields {
+		if isRefField(name) {
+			var refs []reference
+			json.Unmarshal(value, &refs)
+			result[name] = refs
+		}
+	}
+	return result
+}
+
+// sortedObjects returns the objects sorted by uuid.
+func (s *Server) sortedObjects() []*object {
+	objects := make([]*object, 0, len(s.objects))
+	for _, obj := range s.objects {
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].uuid < objects[j].uuid
+	})
+	return objects
+}
+
+// backRefs returns the back references and children of obj, by field.
+func (s *Server) backRefs(obj *object, host string) (
+	map[string][]reference, map[string][]reference) {
+	backRefs := make(map[string][]reference)
+	children := make(map[string][]reference)
+	for _, other := range s.sortedObjects() {
+		if other.parentUuid == obj.uuid {
+			name := fieldName(other.typename, "s")
+			children[name] = append(children[name], reference{
+				To:   other.fqName,
+				Uuid: other.uuid,
+				Href: s.href(host, other.typename, other.uuid),
+			})
+		}
+		for _, refs := range other.references() {
+			for _, ref := range refs {
+				if ref.Uuid != obj.uuid {
+					continue
+				}
+				name := fieldName(other.typename, "_back_refs")
+				backRefs[name] = append(backRefs[name], reference{
+					To:   other.fqName,
+					Uuid: other.uuid,
+					Href: s.href(host, other.typename, other.uuid),
+					Attr: ref.Attr,
+				})
+			}
+		}
+	}
+	return backRefs, children
+}
+
+// readOptions select the fields returned for an object.
+type readOptions struct {
+	fields          map[string]bool
+	excludeBackRefs bool
+	excludeChildren bool
+}
+
+func (o *readOptions) include(name string) bool {
+	return o.fields == nil || o.fields[name]
+}
+
+// encode returns the representation of obj.
+func (s *Server) encode(obj *object, host string, opts *readOptions) map[string]interface{} {
+	m := map[string]interface{}{
+		"fq_name": obj.fqName,
+		"uuid":    obj.uuid,
+		"name":    obj.fqName[len(obj.fqName)-1],
+		"href":    s.href(host, obj.typename, obj.uuid),
+	}
+	if obj.parentType != "" {
+		m["parent_type"] = obj.parentType
+	}
+	if parent, ok := s.objects[obj.parentUuid]; ok {
+		m["parent_uuid"] = parent.uuid
+		m["parent_href"] = s.href(host, parent.typename, parent.uuid)
+	}
+	for name, value := range obj.fields {
+		if !opts.include(name) {
+			continue
+		}
+		if !isRefField(name) {
+			m[name] = value
+			continue
+		}
+		var refs []reference
+		json.Unmarshal(value, &refs)
+		for i := range refs {
+			refs[i].Href = s.href(host, refTarget(name), refs[i].Uuid)
+		}
+		m[name] = refs
+	}
+	backRefs, children := s.backRefs(obj, host)
+	if !opts.excludeBackRefs {
+		for name, refs := range backRefs {
+			if opts.include(name) {
+				m[name] = refs
+			}
+		}
+	}
+	if !opts.excludeChildren {
+		for name, refs := range children {
+			if opts.include(name) {
+				m[name] = refs
+			}
+		}
+	}
+	return m
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	response, err := s.dispatch(r, body)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if httpErr, ok := err.(*httpError); ok {
+			status = httpErr.status
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, response)
+}
+
+func (s *Server) dispatch(r *http.Request, body []byte) (interface{}, error) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "" && r.Method == "GET":
+		return s.rootDocument(r.Host), nil
+	case path == "fqname-to-id" && r.Method == "POST":
+		return s.fqNameToID(body)
+	case path == "id-to-fqname" && r.Method == "POST":
+		return s.idToFQName(body)
+	case path == "ref-update" && r.Method == "POST":
+		return s.refUpdate(body)
+	}
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1 && strings.HasSuffix(path, "s"):
+		typename := strings.TrimSuffix(path, "s")
+		if err := s.checkType(typename); err != nil {
+			return nil, err
+		}
+		switch r.Method {
+		case "GET":
+			return s.list(typename, r)
+		case "POST":
+			return s.create(typename, body, r.Host)
+		}
+	case len(parts) == 2:
+		switch r.Method {
+		case "GET":
+			return s.read(parts[0], parts[1], r)
+		case "PUT":
+			return s.update(parts[0], parts[1], body, r.Host)
+		case "DELETE":
+			return s.delete(parts[0], parts[1])
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "%s %s not found", r.Method, r.URL.Path)
+}
+
+func (s *Server) checkType(typename string) error {
+	if s.schema != nil && s.schema.Type(typename) == nil {
+		return errorf(http.StatusNotFound, "Unknown type %s", typename)
+	}
+	return nil
+}
+
+func (s *Server) rootDocument(host string) interface{} {
+	type link struct {
+		Href string `json:"href"`
+		Name string `json:"name"`
+		Rel  string `json:"rel"`
+	}
+	var links []map[string]link
+	if s.schema != nil {
+		for _, typename := range s.schema.Types() {
+			links = append(links, map[string]link{"link": {
+				Href: fmt.Sprintf("http://%s/%ss", host, typename),
+				Name: typename,
+				Rel:  "collection",
+			}})
+		}
+	}
+	for _, action := range []string{"fqname-to-id", "id-to-fqname", "ref-update"} {
+		links = append(links, map[string]link{"link": {
+			Href: fmt.Sprintf("http://%s/%s", host, action),
+			Name: action,
+			Rel:  "action",
+		}})
+	}
+	return map[string]interface{}{
+		"href":       "http://" + host,
+		"links":      links,
+		"build_info": `{"build-info": [{"build-version": "fake"}]}`,
+	}
+}
+
+func (s *Server) fqNameToID(body []byte) (interface{}, error) {
+	var request struct {
+		Type   string   `json:"type"`
+		FQName []string `json:"fq_name"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	uuid, ok := s.names[nameKey(request.Type, request.FQName)]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "Name %s not found",
+			strings.Join(request.FQName, ":"))
+	}
+	return map[string]string{"uuid": uuid}, nil
+}
+
+func (s *Server) idToFQName(body []byte) (interface{}, error) {
+	var request struct {
+		Uuid string `json:"uuid"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	obj, ok := s.objects[request.Uuid]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "UUID %s not found", request.Uuid)
+	}
+	return map[string]interface{}{"type": obj.typename, "fq_name": obj.fqName}, nil
+}
+
+// lookup returns the object of type typename with the given uuid.
+func (s *Server) lookup(typename, uuid string) (*object, error) {
+	obj, ok := s.objects[uuid]
+	if !ok || obj.typename != typename {
+		return nil, errorf(http.StatusNotFound, "%s %s not found", typename, uuid)
+	}
+	return obj, nil
+}
+
+// decodeObject decodes the {"<type>": {...}} body of create and update
+// requests.
+func decodeObject(typename string, body []byte) (map[string]json.RawMessage, error) {
+	var msg map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	fields, ok := msg[typename]
+	if !ok {
+		return nil, errorf(http.StatusBadRequest, "No %s in request", typename)
+	}
+	return fields, nil
+}
+
+func (s *Server) create(typename string, body []byte, host string) (interface{}, error) {
+	fields, err := decodeObject(typename, body)
+	if err != nil {
+		return nil, err
+	}
+	obj := &object{typename: typename}
+	var parentUuid string
+	for name, ptr := range map[string]interface{}{
+		"fq_name": &obj.fqName, "uuid": &obj.uuid,
+		"parent_type": &obj.parentType, "parent_uuid": &parentUuid,
+	} {
+		if value, ok := fields[name]; ok {
+			if err := json.Unmarshal(value, ptr); err != nil {
+				return nil, errorf(http.StatusBadRequest, "Invalid %s: %v", name, err)
+			}
+		}
+	}
+	if len(obj.fqName) == 0 {
+		return nil, errorf(http.StatusBadRequest, "%s: fq_name not specified", typename)
+	}
+	if obj.parentType == "" && s.schema != nil {
+		obj.parentType = s.schema.Type(typename).DefaultParentType
+	}
+	if len(obj.fqName) > 1 {
+		if obj.parentType == "" {
+			return nil, errorf(http.StatusBadRequest, "%s: parent_type not specified", typename)
+		}
+		parentFQName := obj.fqName[:len(obj.fqName)-1]
+		uuid, ok := s.names[nameKey(obj.parentType, parentFQName)]
+		if !ok || (parentUuid != "" && parentUuid != uuid) {
+			return nil, errorf(http.StatusNotFound, "Parent %s %s not found",
+				obj.parentType, strings.Join(parentFQName, ":"))
+		}
+		obj.parentUuid = uuid
+	}
+	if _, ok := s.names[nameKey(typename, obj.fqName)]; ok {
+		return nil, errorf(http.StatusConflict, "%s %s already exists",
+			typename, strings.Join(obj.fqName, ":"))
+	}
+	if obj.uuid == "" {
+		obj.uuid = newUuid()
+	} else if _, ok := s.objects[obj.uuid]; ok {
+		return nil, errorf(http.StatusConflict, "UUID %s already in use", obj.uuid)
+	}
+	if obj.fields, err = s.validate(typename, fields); err != nil {
+		return nil, err
+	}
+	obj.fields["id_perms"] = idPerms(obj.fields["id_perms"])
+	s.insert(obj)
+	return map[string]interface{}{
+		typename: s.encode(obj, host, &readOptions{fields: map[string]bool{}}),
+	}, nil
+}
+
+// queryList returns the values of a query parameter, which may be repeated
+// or comma separated.
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, value := range r.URL.Query()[name] {
+		for _, element := range strings.Split(value, ",") {
+			if element != "" {
+				values = append(values, element)
+			}
+		}
+	}
+	return values
+}
+
+func fieldSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+func (s *Server) read(typename, uuid string, r *http.Request) (interface{}, error) {
+	obj, err := s.lookup(typename, uuid)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	opts := &readOptions{
+		fields:          fieldSet(queryList(r, "fields")),
+		excludeBackRefs: query.Get("exclude_back_refs") == "true",
+		excludeChildren: query.Get("exclude_children") == "true",
+	}
+	return map[string]interface{}{typename: s.encode(obj, r.Host, opts)}, nil
+}
+
+func (s *Server) update(typename, uuid string, body []byte, host string) (interface{}, error) {
+	obj, err := s.lookup(typename, uuid)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := decodeObject(typename, body)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := fields["fq_name"]; ok {
+		var fqName []string
+		json.Unmarshal(value, &fqName)
+		if strings.Join(fqName, ":") != strings.Join(obj.fqName, ":") {
+			return nil, errorf(http.StatusBadRequest, "fq_name can't be modified")
+		}
+	}
+	changes, err := s.validate(typename, fields)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range changes {
+		obj.fields[name] = value
+	}
+	obj.fields["id_perms"] = idPerms(obj.fields["id_perms"])
+	return map[string]interface{}{typename: map[string]string{
+		"uuid": obj.uuid,
+		"href": s.href(host, typename, obj.uuid),
+	}}, nil
+}
+
+func (s *Server) delete(typename, uuid string) (interface{}, error) {
+	obj, err := s.lookup(typename, uuid)
+	if err != nil {
+		return nil, err
+	}
+	backRefs, children := s.backRefs(obj, "")
+	for name, refs := range children {
+		return nil, errorf(http.StatusConflict, "Delete of %s %s: children %s %v",
+			typename, uuid, name, refs[0].To)
+	}
+	for name, refs := range backRefs {
+		return nil, errorf(http.StatusConflict, "Delete of %s %s: back references %s %v",
+			typename, uuid, name, refs[0].To)
+	}
+	delete(s.objects, uuid)
+	delete(s.names, nameKey(typename, obj.fqName))
+	return map[string]interface{}{}, nil
+}
+
+func (s *Server) refUpdate(body []byte) (interface{}, error) {
+	var msg struct {
+		Type      string          `json:"type"`
+		Uuid      string          `json:"uuid"`
+		RefType   string          `json:"ref-type"`
+		RefUuid   string          `json:"ref-uuid"`
+		RefFQName []string        `json:"ref-fq-name"`
+		Operation string          `json:"operation"`
+		Attr      json.RawMessage `json:"attr"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	obj, err := s.lookup(msg.Type, msg.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	name := fieldName(msg.RefType, "_refs")
+	var refs []reference
+	if value, ok := obj.fields[name]; ok {
+		json.Unmarshal(value, &refs)
+	}
+	if msg.RefUuid == "" {
+		msg.RefUuid = s.names[nameKey(msg.RefType, msg.RefFQName)]
+	}
+	var kept []reference
+	for _, ref := range refs {
+		if ref.Uuid != msg.RefUuid {
+			kept = append(kept, ref)
+		}
+	}
+	switch strings.ToUpper(msg.Operation) {
+	case "ADD":
+		ref := reference{Uuid: msg.RefUuid, To: msg.RefFQName}
+		if len(msg.Attr) > 0 && string(msg.Attr) != "null" {
+			ref.Attr = msg.Attr
+		}
+		kept = append(kept, ref)
+	case "DELETE":
+	default:
+		return nil, errorf(http.StatusBadRequest, "Invalid operation %q", msg.Operation)
+	}
+	data, _ := json.Marshal(kept)
+	changes, err := s.validate(msg.Type, map[string]json.RawMessage{name: data})
+	if err != nil {
+		return nil, err
+	}
+	if len(kept) == 0 {
+		delete(obj.fields, name)
+	} else {
+		obj.fields[name] = changes[name]
+	}
+	return map[string]string{"uuid": obj.uuid}, nil
+}
@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package fakeserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/contrailtest"
+)
+
+func newClient(t *testing.T, schema *contrail.SchemaInfo) (*httptest.Server, *contrail.Client) {
+	server := httptest.NewServer(New(schema))
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	return server, contrail.NewClient(host, portNum)
+}
+
+func TestConformance(t *testing.T) {
+	server, client := newClient(t, nil)
+	defer server.Close()
+	contrailtest.RunConformance(t, client)
+}
+
+// testNetwork is a minimal generated type, registered to validate
+// requests.
+type testNetwork struct {
+	contrail.ObjectBase
+	display_name      string
+	network_ipam_refs contrail.ReferenceList
+}
+
+type testSubnets struct {
+	Subnets []string `json:"subnets"`
+}
+
+func (*testNetwork) GetType() string                       { return "virtual-network" }
+func (*testNetwork) GetDefaultParent() []string            { return []string{"default-domain", "default-project"} }
+func (*testNetwork) GetDefaultParentType() string          { return "project" }
+func (*testNetwork) SetName(string)                        {}
+func (*testNetwork) UpdateObject() ([]byte, error)         { return nil, nil }
+func (*testNetwork) UpdateReferences() error               { return nil }
+func (*testNetwork) UpdateDone()                           {}
+func (*testNetwork) AddNetworkIpam(*testIpam, testSubnets) {}
+
+type testProject struct {
+	contrail.ObjectBase
+	virtual_networks contrail.ReferenceList
+}
+
+func (*testProject) GetType() string               { return "project" }
+func (*testProject) GetDefaultParent() []string    { return []string{"default-domain"} }
+func (*testProject) GetDefaultParentType() string  { return "domain" }
+func (*testProject) SetName(string)                {}
+func (*testProject) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testProject) UpdateReferences() error       { return nil }
+func (*testProject) UpdateDone()                   {}
+
+type testIpam struct {
+	testProject
+}
+
+func (*testIpam) GetType() string { return "network-ipam" }
+
+func TestSchemaValidation(t *testing.T) {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"virtual-network": reflect.TypeOf(testNetwork{}),
+		"project":         reflect.TypeOf(testProject{}),
+		"network-ipam":    reflect.TypeOf(testIpam{}),
+	})
+	defer contrail.RegisterTypeMap(nil)
+	server, client := newClient(t, contrail.Schema())
+	defer server.Close()
+
+	create := func(fields map[string]interface{}) error {
+		obj := contrail.NewGenericObject("virtual-network")
+		obj.SetDefaultParent("project", []string{"default-domain", "default-project"})
+		obj.SetName("vn")
+		for name, value := range fields {
+			obj.Set(name, value)
+		}
+		err := client.Create(obj)
+		if err == nil {
+			client.Delete(obj)
+		}
+		return err
+	}
+	ipamUuid, err := client.UuidByName("network-ipam",
+		"default-domain:default-project:default-network-ipam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := func(attr interface{}) []map[string]interface{} {
+		return []map[string]interface{}{{"uuid": ipamUuid, "attr": attr}}
+	}
+
+	for _, test := range []struct {
+		fields map[string]interface{}
+		status string
+	}{
+		{map[string]interface{}{"display_name": "web"}, ""},
+		{map[string]interface{}{"display_name": 10}, "400"},
+		{map[string]interface{}{"mtu": 1500}, "400"},
+		{map[string]interface{}{"network_ipam_refs": ref(testSubnets{[]string{"10.0.0.0/24"}})}, ""},
+		{map[string]interface{}{"network_ipam_refs": ref(map[string]int{"prefix": 24})}, "400"},
+		{map[string]interface{}{"network_ipam_refs": []map[string]string{{"uuid": "missing"}}}, "404"},
+	} {
+		err := create(test.fields)
+		if test.status == "" && err != nil {
+			t.Errorf("%v: %v", test.fields, err)
+		} else if test.status != "" && (err == nil || !strings.HasPrefix(err.Error(), test.status)) {
+			t.Errorf("%v: expected %s, got %v", test.fields, test.status, err)
+		}
+	}
+
+	vn := contrail.NewGenericObject("virtual-network")
+	vn.SetDefaultParent("project", []string{"default-domain", "no-such-project"})
+	vn.SetName("orphan")
+	if err := client.Create(vn); err == nil || !strings.HasPrefix(err.Error(), "404") {
+		t.Errorf("expected a missing parent error, got %v", err)
+	}
+	if _, err := client.List("virtual-router"); err == nil {
+		t.Error("expected an error listing an unknown type")
+	}
+
+	info, err := client.ServerInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.HasCollection("virtual-network") || !info.HasAction("ref-update") {
+		t.Errorf("unexpected root document %+v", info)
+	}
+	data, _ := json.Marshal(info.Collections)
+	if string(data) != `["network-ipam","project","virtual-network"]` {
+		t.Errorf("collections %s", data)
+	}
+}
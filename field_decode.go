@@ -0,0 +1,332 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Decoding without reflection.
+//
+// Decoding an object with encoding/json goes through reflection for each
+// attribute, and list-detail processing spends most of its time there.
+// Types that implement FieldUnmarshaler are decoded by the client with a
+// scanner that splits each object into its attributes: the common
+// attributes (fq_name, uuid, ...) are decoded by the library and the
+// others are passed to UnmarshalField, which can use DecodeString,
+// DecodeStringList and DecodeReferenceList for the usual attribute types.
+
+// FieldUnmarshaler is implemented by types that decode their attributes
+// without reflection. UnmarshalField decodes the JSON value of attribute
+// name; value is only valid during the call. It returns false for the
+// attributes the type doesn't define, which are kept as unknown fields
+// (see UnknownFields).
+type FieldUnmarshaler interface {
+	UnmarshalField(name string, value []byte) (bool, error)
+}
+
+// baseObject is implemented by the types that embed ObjectBase.
+type baseObject interface {
+	objectBase() *ObjectBase
+}
+
+func (obj *ObjectBase) objectBase() *ObjectBase {
+	return obj
+}
+
+// skipSpace returns the position of the first non-space byte from i.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// scanString returns the end of the string that starts at i, and whether
+// it must be unquoted by encoding/json (escapes or non-ASCII characters).
+func scanString(data []byte, i int) (int, bool, error) {
+	slow := false
+	for j := i + 1; j < len(data); j++ {
+		switch c := data[j]; {
+		case c == '"':
+			return j + 1, slow, nil
+		case c == '\\':
+			slow = true
+			j++
+		case c >= 0x80:
+			slow = true
+		}
+	}
+	return 0, false, fmt.Errorf("Unterminated string")
+}
+
+// skipValue returns the end of the value that starts at i. The input must
+// be valid JSON.
+func skipValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, fmt.Errorf("Unexpected end of JSON input")
+	}
+	switch data[i] {
+	case '"':
+		end, _, err := scanString(data, i)
+		return end, err
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(data); j++ {
+			switch data[j] {
+			case '"':
+				end, _, err := scanString(data, j)
+				if err != nil {
+					return 0, err
+				}
+				j = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("Unexpected end of JSON input")
+	}
+	j := i
+	for j < len(data) && !isSpace(data[j]) && data[j] != ',' &&
+		data[j] != '}' && data[j] != ']' {
+		j++
+	}
+	return j, nil
+}
+
+func isNull(value []byte) bool {
+	return len(value) == 4 && string(value) == "null"
+}
+
+// forEachField invokes fn for each attribute of the JSON object data.
+func forEachField(data []byte, fn func(name string, value []byte) error) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return fmt.Errorf("Expected JSON object")
+	}
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return nil
+	}
+	for i < len(data) {
+		if data[i] != '"' {
+			return fmt.Errorf("Expected attribute name at offset %d", i)
+		}
+		end, slow, err := scanString(data, i)
+		if err != nil {
+			return err
+		}
+		name, err := decodeString(data[i:end], slow)
+		if err != nil {
+			return err
+		}
+		i = skipSpace(data, end)
+		if i >= len(data) || data[i] != ':' {
+			return fmt.Errorf("Expected ':' at offset %d", i)
+		}
+		start := skipSpace(data, i+1)
+		end, err = skipValue(data, start)
+		if err != nil {
+			return err
+		}
+		if err := fn(name, data[start:end]); err != nil {
+			return err
+		}
+		i = skipSpace(data, end)
+		if i < len(data) && data[i] == '}' {
+			return nil
+		}
+		if i >= len(data) || data[i] != ',' {
+			return fmt.Errorf("Expected ',' at offset %d", i)
+		}
+		i = skipSpace(data, i+1)
+	}
+	return fmt.Errorf("Unexpected end of JSON input")
+}
+
+// forEachElement invokes fn for each element of the JSON array data.
+func forEachElement(data []byte, fn func(value []byte) error) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '[' {
+		return fmt.Errorf("Expected JSON array")
+	}
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return nil
+	}
+	for i < len(data) {
+		end, err := skipValue(data, i)
+		if err != nil {
+			return err
+		}
+		if err := fn(data[i:end]); err != nil {
+			return err
+		}
+		i = skipSpace(data, end)
+		if i < len(data) && data[i] == ']' {
+			return nil
+		}
+		if i >= len(data) || data[i] != ',' {
+			return fmt.Errorf("Expected ',' at offset %d", i)
+		}
+		i = skipSpace(data, i+1)
+	}
+	return fmt.Errorf("Unexpected end of JSON input")
+}
+
+func decodeString(value []byte, slow bool) (string, error) {
+	if slow {
+		var s string
+		err := json.Unmarshal(value, &s)
+		return s, err
+	}
+	return string(value[1 : len(value)-1]), nil
+}
+
+// DecodeString decodes a JSON string (or null, as "").
+func DecodeString(value []byte) (string, error) {
+	if isNull(value) {
+		return "", nil
+	}
+	if len(value) < 2 || value[0] != '"' {
+		return "", fmt.Errorf("Expected JSON string, got %.20s", value)
+	}
+	end, slow, err := scanString(value, 0)
+	if err != nil {
+		return "", err
+	}
+	if end != len(value) {
+		return "", fmt.Errorf("Invalid JSON string %.20s", value)
+	}
+	return decodeString(value, slow)
+}
+
+// DecodeStringList decodes a JSON array of strings (or null, as nil).
+func DecodeStringList(value []byte) ([]string, error) {
+	if isNull(value) {
+		return nil, nil
+	}
+	list := []string{}
+	err := forEachElement(value, func(element []byte) error {
+		s, err := DecodeString(element)
+		list = append(list, s)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DecodeReferenceList decodes a reference list (or null, as nil). The
+// attributes of the references are decoded as with encoding/json into an
+// interface{} value.
+func DecodeReferenceList(value []byte) (ReferenceList, error) {
+	if isNull(value) {
+		return nil, nil
+	}
+	refs := ReferenceList{}
+	err := forEachElement(value, func(element []byte) error {
+		var ref Reference
+		err := forEachField(element, func(name string, value []byte) error {
+			var err error
+			switch name {
+			case "to":
+				ref.To, err = DecodeStringList(value)
+			case "uuid":
+				ref.Uuid, err = DecodeString(value)
+			case "href":
+				ref.Href, err = DecodeString(value)
+			case "attr":
+				if !isNull(value) {
+					var attr interface{}
+					err = json.Unmarshal(value, &attr)
+					ref.Attr = attr
+				}
+			}
+			return err
+		})
+		refs = append(refs, ref)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// decodeFields decodes data into obj through UnmarshalField. Like
+// UnmarshalCommon, it requires fq_name, uuid and name. The input must be
+// valid JSON; it is retained as the attributes received.
+func decodeFields(obj IObject, fields FieldUnmarshaler, data []byte) error {
+	base, ok := obj.(baseObject)
+	if !ok {
+		return json.Unmarshal(data, obj)
+	}
+	b := base.objectBase()
+	var unknown, received map[string]json.RawMessage
+	var haveFQName, haveUuid, haveName, haveHref bool
+	err := forEachField(data, func(name string, value []byte) error {
+		var err error
+		switch name {
+		case "fq_name":
+			b.fq_name, err = DecodeStringList(value)
+			haveFQName = true
+		case "uuid":
+			b.uuid, err = DecodeString(value)
+			haveUuid = true
+		case "name":
+			b.name, err = DecodeString(value)
+			haveName = true
+		case "href":
+			b.href, err = DecodeString(value)
+			haveHref = true
+		case "parent_type", "parent_uuid", "parent_href":
+		default:
+			if received == nil {
+				received = make(map[string]json.RawMessage)
+			}
+			received[name] = value
+			known, err := fields.UnmarshalField(name, value)
+			if err != nil {
+				return fmt.Errorf("Invalid %s: %v", name, err)
+			}
+			if !known {
+				if unknown == nil {
+					unknown = make(map[string]json.RawMessage)
+				}
+				unknown[name] = append(json.RawMessage(nil), value...)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid %s: %v", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case !haveFQName:
+		return fmt.Errorf("Missing fq_name")
+	case !haveUuid:
+		return fmt.Errorf("Missing uuid")
+	case !haveName:
+		return fmt.Errorf("Missing name")
+	}
+	if haveHref {
+		b.fixHref()
+	}
+	b.unknown = unknown
+	b.received = received
+	return nil
+}
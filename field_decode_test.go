@@ -0,0 +1,129 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// jsonNetwork decodes test-networks through encoding/json only.
+type jsonNetwork struct {
+	TestNetwork
+}
+
+func (obj *jsonNetwork) decode(data []byte) error {
+	if err := json.Unmarshal(data, &obj.TestNetwork); err != nil {
+		return err
+	}
+	return recordReceived(&obj.TestNetwork, data)
+}
+
+func TestDecodeFields(t *testing.T) {
+	inputs := []string{
+		`{"fq_name": ["default-project", "n1"], "uuid": "u1", "name": "n1"}`,
+		` { "fq_name" : [ "p", "n\u00e9" ] , "uuid":"u1","name":"n\u00e9",
+		  "href": "http://localhost:8082/test-network/u1",
+		  "display_name": "a \"quoted\" name",
+		  "test_project_refs": [{"to": ["p"], "uuid": "p1", "attr": {"n": 1, "l": [true, null]}},
+			{"to": ["q"], "uuid": "q1", "href": "h", "attr": null}],
+		  "test_port_back_refs": [],
+		  "new_property": {"a": [1, "}]"], "b": "\\"},
+		  "parent_type": "project"} `,
+		`{"fq_name": null, "uuid": "u1", "name": "n1", "display_name": null,
+		  "test_project_refs": null}`,
+		`{"fq_name": ["p", "n1"], "uuid": "u2", "name": "n1",
+		  "href": "http://localhost:8082/test-network/u1"}`,
+		`{"fq_name": ["p", "n1"], "uuid": "u1", "name": "n1", "x": 1.5e3, "y": false}`,
+	}
+	for _, input := range inputs {
+		expected := new(jsonNetwork)
+		if err := expected.decode([]byte(input)); err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		actual := new(TestNetwork)
+		if err := decodeFields(actual, actual, []byte(input)); err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if !reflect.DeepEqual(&expected.TestNetwork, actual) {
+			t.Errorf("%s:\nexpected %+v\ngot      %+v", input,
+				expected.TestNetwork, *actual)
+		}
+	}
+
+	for _, input := range []string{
+		`{"uuid": "u1", "name": "n1"}`,
+		`{"fq_name": ["p", "n1"], "uuid": 1, "name": "n1"}`,
+		`{"fq_name": ["p", 1], "uuid": "u1", "name": "n1"}`,
+		`{"fq_name": ["p", "n1"], "uuid": "u1", "name": "n1",
+		  "test_project_refs": {"uuid": "p1"}}`,
+		`{"fq_name": ["p", "n1"], "uuid": "u1", "name": "n1"`,
+		`["fq_name"]`,
+	} {
+		obj := new(TestNetwork)
+		if err := decodeInto(obj, []byte(input), false); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestDecodeStringList(t *testing.T) {
+	for input, expected := range map[string][]string{
+		`[]`:                {},
+		`null`:              nil,
+		`["a", "b\/c"]`:     {"a", "b/c"},
+		` [ "\u263a" , ""]`: {"\u263a", ""},
+	} {
+		list, err := DecodeStringList([]byte(input))
+		if err != nil || !reflect.DeepEqual(list, expected) {
+			t.Errorf("%s: got %q, %v", input, list, err)
+		}
+	}
+}
+
+func listElements(count int) [][]byte {
+	elements := make([][]byte, count)
+	for i := range elements {
+		elements[i] = []byte(fmt.Sprintf(`{"fq_name": ["default-domain", "default-project", "net%d"], "uuid": "uuid-%d", "name": "net%d", "href": "http://localhost:8082/test-network/uuid-%d", "parent_type": "project", "display_name": "Network %d", "test_project_refs": [{"to": ["default-domain", "default-project"], "uuid": "p1", "href": "http://localhost:8082/project/p1"}], "test_port_back_refs": [{"to": ["default-domain", "default-project", "port-a"], "uuid": "a", "href": "http://localhost:8082/test-port/a"}, {"to": ["default-domain", "default-project", "port-b"], "uuid": "b", "href": "http://localhost:8082/test-port/b"}]}`, i, i, i, i, i))
+	}
+	return elements
+}
+
+// BenchmarkDecodeObject compares the decoding of the objects of a list
+// through encoding/json and through UnmarshalField.
+func BenchmarkDecodeObject(b *testing.B) {
+	elements := listElements(1000)
+	size := 0
+	for _, data := range elements {
+		size += len(data)
+	}
+	b.Run("json.Unmarshal", func(b *testing.B) {
+		b.SetBytes(int64(size))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, data := range elements {
+				obj := new(jsonNetwork)
+				if err := obj.decode(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("UnmarshalField", func(b *testing.B) {
+		b.SetBytes(int64(size))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, data := range elements {
+				obj := new(TestNetwork)
+				if err := decodeFields(obj, obj, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,246 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// GenericObject is an object of a type that the generated types library
+// doesn't define (e.g. a resource added by a newer schema or a custom
+// resource). Properties and reference lists are stored as JSON values,
+// keyed by their API server attribute name.
+//
+// The client reads objects of unregistered types as GenericObjects.
+type GenericObject struct {
+	ObjectBase
+	typename          string
+	defaultParentType string
+	defaultParent     []string
+	fields            map[string]json.RawMessage
+	modified          map[string]bool
+	fetched           map[string]bool
+}
+
+// NewGenericObject allocates a transient object of the given type. The
+// object is created under the default parent, if one is set with
+// SetDefaultParent, or under the parent set with SetParent or SetFQName.
+func NewGenericObject(typename string) *GenericObject {
+	return &GenericObject{
+		typename: typename,
+		fields:   make(map[string]json.RawMessage),
+		modified: make(map[string]bool),
+		fetched:  make(map[string]bool),
+	}
+}
+
+// newObjectOfType allocates an object of a registered type or, for unknown
+// types, a GenericObject.
+func newObjectOfType(typename string) IObject {
+	xtype, ok := registeredTypes()[typename]
+	if !ok {
+		return NewGenericObject(typename)
+	}
+	return reflect.New(xtype).Interface().(IObject)
+}
+
+func (obj *GenericObject) GetType() string {
+	return obj.typename
+}
+
+func (obj *GenericObject) GetDefaultParent() []string {
+	return obj.defaultParent
+}
+
+func (obj *GenericObject) GetDefaultParentType() string {
+	return obj.defaultParentType
+}
+
+// SetDefaultParent sets the parent used by SetName.
+func (obj *GenericObject) SetDefaultParent(parentType string, fqn []string) {
+	obj.defaultParentType = parentType
+	obj.defaultParent = fqn
+}
+
+func (obj *GenericObject) SetName(name string) {
+	obj.VSetName(obj, name)
+}
+
+func (obj *GenericObject) SetParent(parent IObject) {
+	obj.VSetParent(obj, parent)
+}
+
+// Fields returns the sorted names of the fields the object holds.
+func (obj *GenericObject) Fields() []string {
+	names := make([]string, 0, len(obj.fields))
+	for name := range obj.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get decodes a field into v. Fields that were not received when the
+// object was read (e.g. reference lists) are retrieved from the API
+// server. Absent fields leave v unchanged.
+func (obj *GenericObject) Get(field string, v interface{}) error {
+	value, ok := obj.fields[field]
+	if !ok && !obj.IsTransient() && !obj.fetched[field] {
+		if err := obj.GetField(obj, field); err != nil {
+			return err
+		}
+		obj.fetched[field] = true
+		value, ok = obj.fields[field]
+	}
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(value, v)
+}
+
+// Set assigns a field. The field is sent to the API server on the next
+// Create or Update.
+func (obj *GenericObject) Set(field string, value interface{}) error {
+	if commonFields[field] {
+		return fmt.Errorf("%s: %s can't be set as a field", obj.typename, field)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	obj.fields[field] = data
+	obj.modified[field] = true
+	return nil
+}
+
+// IsFieldSet reports whether the object holds a value other than null for
+// a field, received from the API server or assigned with Set.
+func (obj *GenericObject) IsFieldSet(field string) bool {
+	value, ok := obj.fields[field]
+	return ok && !isNull(trimSpace(value))
+}
+
+// Unset clears a field. Unlike a zero value, an unset field is omitted
+// when the object is created; Update sends it as null, which removes the
+// property on the API server.
+func (obj *GenericObject) Unset(field string) {
+	delete(obj.fields, field)
+	obj.modified[field] = true
+	obj.fetched[field] = true
+}
+
+// GetReferences returns a reference list field.
+func (obj *GenericObject) GetReferences(field string) (ReferenceList, error) {
+	var refs ReferenceList
+	err := obj.Get(field, &refs)
+	return refs, err
+}
+
+// AddReference appends a reference to ref (and the optional attribute) to
+// a reference list field.
+func (obj *GenericObject) AddReference(field string, ref IObject, attr LinkAttribute) error {
+	refs, err := obj.GetReferences(field)
+	if err != nil {
+		return err
+	}
+	refs = append(refs, Reference{
+		To:   ref.GetFQName(),
+		Uuid: ref.GetUuid(),
+		Attr: attr,
+	})
+	return obj.Set(field, refs)
+}
+
+// DeleteReference removes the reference to the object with the given uuid
+// from a reference list field.
+func (obj *GenericObject) DeleteReference(field string, uuid string) error {
+	refs, err := obj.GetReferences(field)
+	if err != nil {
+		return err
+	}
+	for i, ref := range refs {
+		if ref.Uuid == uuid {
+			refs = append(refs[:i], refs[i+1:]...)
+			return obj.Set(field, refs)
+		}
+	}
+	return fmt.Errorf("%s: no reference to %s in %s", obj.typename, uuid, field)
+}
+
+func (obj *GenericObject) MarshalJSON() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalCommon(m); err != nil {
+		return nil, err
+	}
+	for key, value := range obj.fields {
+		data := value
+		m[key] = &data
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes an object. Fields are merged with the ones already
+// held, so that the result of a partial read (GetField) adds to the object.
+func (obj *GenericObject) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	if obj.fields == nil {
+		obj.fields = make(map[string]json.RawMessage)
+		obj.modified = make(map[string]bool)
+		obj.fetched = make(map[string]bool)
+	}
+	for key, value := range m {
+		if !commonFields[key] {
+			obj.fields[key] = value
+		}
+	}
+	return nil
+}
+
+// UnmarshalField implements FieldUnmarshaler: the fields are stored
+// without being decoded.
+func (obj *GenericObject) UnmarshalField(name string, value []byte) (bool, error) {
+	if obj.fields == nil {
+		obj.fields = make(map[string]json.RawMessage)
+		obj.modified = make(map[string]bool)
+		obj.fetched = make(map[string]bool)
+	}
+	obj.fields[name] = append(json.RawMessage(nil), value...)
+	return true, nil
+}
+
+// UpdateObject encodes the fields assigned since the object was read or
+// last updated.
+func (obj *GenericObject) UpdateObject() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalId(m); err != nil {
+		return nil, err
+	}
+	for key := range obj.modified {
+		data, ok := obj.fields[key]
+		if !ok {
+			data = json.RawMessage("null")
+		}
+		m[key] = &data
+	}
+	return json.Marshal(m)
+}
+
+// UpdateReferences is a no-op: reference lists are sent by UpdateObject.
+func (obj *GenericObject) UpdateReferences() error {
+	return nil
+}
+
+func (obj *GenericObject) UpdateDone() {
+	obj.modified = make(map[string]bool)
+}
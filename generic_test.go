@@ -0,0 +1,115 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenericObject(t *testing.T) {
+	var requests []string
+	var bodies []map[string]json.RawMessage
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		switch r.Method {
+		case "POST":
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			bodies = append(bodies, msg["custom-resource"])
+			fmt.Fprint(w, `{"custom-resource": {"fq_name": ["default-project", "cr"], "uuid": "cr-uuid", "name": "cr", "href": "http://localhost/custom-resource/cr-uuid"}}`)
+		case "GET":
+			if r.URL.Query().Get("fields") != "" {
+				fmt.Fprint(w, `{"custom-resource": {"fq_name": ["default-project", "cr"], "uuid": "cr-uuid", "name": "cr", "test_project_refs": [{"to": ["default-project"], "uuid": "p1"}]}}`)
+				return
+			}
+			fmt.Fprint(w, `{"custom-resource": {"fq_name": ["default-project", "cr"], "uuid": "cr-uuid", "name": "cr", "href": "http://localhost/custom-resource/cr-uuid", "size": 10, "labels": {"app": "web"}}}`)
+		case "PUT":
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			bodies = append(bodies, msg["custom-resource"])
+			fmt.Fprint(w, `{"custom-resource": {"uuid": "cr-uuid"}}`)
+		}
+	})
+	defer server.Close()
+
+	obj := NewGenericObject("custom-resource")
+	obj.SetDefaultParent("test-project", []string{"default-project"})
+	obj.SetName("cr")
+	if err := obj.Set("size", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.Set("uuid", "x"); err == nil {
+		t.Error("expected an error setting uuid")
+	}
+	if err := client.Create(obj); err != nil {
+		t.Fatal(err)
+	}
+	if string(bodies[0]["size"]) != "10" ||
+		string(bodies[0]["parent_type"]) != `"test-project"` {
+		t.Errorf("unexpected create body %v", bodies[0])
+	}
+
+	read, err := client.FindByUuid("custom-resource", "cr-uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic, ok := read.(*GenericObject)
+	if !ok {
+		t.Fatalf("expected a GenericObject, got %T", read)
+	}
+	if !reflect.DeepEqual(generic.Fields(), []string{"labels", "size"}) {
+		t.Errorf("fields %v", generic.Fields())
+	}
+	var labels map[string]string
+	if err := generic.Get("labels", &labels); err != nil || labels["app"] != "web" {
+		t.Errorf("labels %v: %v", labels, err)
+	}
+
+	generic.href = server.URL + "/custom-resource/cr-uuid"
+	refs, err := generic.GetReferences("test_project_refs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].Uuid != "p1" {
+		t.Errorf("refs %v", refs)
+	}
+	project := NewGenericObject("test-project")
+	project.SetFQName("domain", []string{"other"})
+	project.uuid = "p2"
+	if err := generic.AddReference("test_project_refs", project, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := generic.DeleteReference("test_project_refs", "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Update(generic); err != nil {
+		t.Fatal(err)
+	}
+	update := bodies[1]
+	if _, ok := update["size"]; ok {
+		t.Errorf("unmodified field in update %v", update)
+	}
+	var updated ReferenceList
+	json.Unmarshal(update["test_project_refs"], &updated)
+	if len(updated) != 1 || updated[0].Uuid != "p2" {
+		t.Errorf("unexpected references in update %s",
+			update["test_project_refs"])
+	}
+
+	var fieldReads int
+	for _, request := range requests {
+		if strings.Contains(request, "fields=") {
+			fieldReads++
+		}
+	}
+	if fieldReads != 1 {
+		t.Errorf("expected the reference list to be read once: %v", requests)
+	}
+}
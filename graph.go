@@ -0,0 +1,166 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GraphNode is an object of a Graph.
+type GraphNode struct {
+	Uuid   string   `json:"uuid"`
+	Type   string   `json:"type"`
+	FQName []string `json:"fq_name"`
+}
+
+// GraphEdge is a relationship between two objects of a Graph. Edges of kind
+// "ref" go from the referrer to the referred object (back references are
+// reported in that direction as well); "child" edges go from the parent to
+// the child.
+type GraphEdge struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Kind  string      `json:"kind"`
+	Field string      `json:"field"`
+	Attr  interface{} `json:"attr,omitempty"`
+}
+
+// Graph is a set of objects and the relationships between them.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// BuildGraph traverses the objects reachable from start (see Traverse)
+// and returns them with all the relationships among them.
+func BuildGraph(client ApiClient, start IObject, options *TraverseOptions) (
+	*Graph, error) {
+	var objects []IObject
+	err := Traverse(client, start, options, func(v *Visit) error {
+		objects = append(objects, v.Object)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	kinds := map[EdgeKind]bool{EdgeRef: true, EdgeBackRef: true, EdgeChild: true}
+	if options != nil && len(options.Kinds) > 0 {
+		kinds = make(map[EdgeKind]bool)
+		for _, kind := range options.Kinds {
+			kinds[kind] = true
+		}
+	}
+
+	graph := new(Graph)
+	nodes := make(map[string]bool)
+	for _, obj := range objects {
+		nodes[obj.GetUuid()] = true
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Uuid:   obj.GetUuid(),
+			Type:   obj.GetType(),
+			FQName: obj.GetFQName(),
+		})
+	}
+	edges := make(map[string]bool)
+	add := func(edge GraphEdge) {
+		key := edge.From + " " + edge.To + " " + edge.Kind + " " + edge.Field
+		if !nodes[edge.To] || !nodes[edge.From] || edges[key] {
+			return
+		}
+		edges[key] = true
+		graph.Edges = append(graph.Edges, edge)
+	}
+	for _, obj := range objects {
+		if kinds[EdgeRef] {
+			refMap, err := ObjectReferences(obj)
+			if err != nil {
+				return nil, err
+			}
+			for field, refs := range refMap {
+				for _, ref := range refs {
+					add(GraphEdge{obj.GetUuid(), ref.Uuid, "ref", field, ref.Attr})
+				}
+			}
+		}
+		if kinds[EdgeBackRef] {
+			// The field of the referrer is <type>_refs.
+			field := strings.Replace(obj.GetType(), "-", "_", -1) + "_refs"
+			backRefMap, err := ObjectBackReferences(obj)
+			if err != nil {
+				return nil, err
+			}
+			for _, refs := range backRefMap {
+				for _, ref := range refs {
+					add(GraphEdge{ref.Uuid, obj.GetUuid(), "ref", field, ref.Attr})
+				}
+			}
+		}
+		if kinds[EdgeChild] {
+			childMap, err := ObjectChildren(obj)
+			if err != nil {
+				return nil, err
+			}
+			for field, children := range childMap {
+				for _, child := range children {
+					add(GraphEdge{obj.GetUuid(), child.Uuid, "child", field, nil})
+				}
+			}
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.To < b.To
+	})
+	return graph, nil
+}
+
+// WriteJSON encodes the graph as a JSON node and edge list.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+// WriteDOT encodes the graph in the GraphViz DOT language, e.g.
+//
+//	graph.WriteDOT(os.Stdout)  // | dot -Tsvg > topology.svg
+//
+// Nodes are labeled with their type and fq_name. Child relationships are
+// drawn as dashed edges.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph contrail {")
+	fmt.Fprintln(out, "\tnode [shape=box];")
+	for _, node := range g.Nodes {
+		label := node.Type + `\n` + strings.Join(node.FQName, ":")
+		fmt.Fprintf(out, "\t%s [label=%s];\n", dotQuote(node.Uuid), dotQuote(label))
+	}
+	for _, edge := range g.Edges {
+		attrs := "label=" + dotQuote(edge.Field)
+		if edge.Kind == "child" {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(out, "\t%s -> %s [%s];\n", dotQuote(edge.From),
+			dotQuote(edge.To), attrs)
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuildGraph(t *testing.T) {
+	client := newGraphClient()
+	graph, err := BuildGraph(client, client.objects["project"], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes) != 6 {
+		t.Errorf("expected 6 nodes, got %v", graph.Nodes)
+	}
+	var edges []string
+	for _, edge := range graph.Edges {
+		edges = append(edges, edge.From+"-"+edge.Kind+"->"+edge.To)
+	}
+	// Back references are reported as references of the ports, and the
+	// reference of net1 to the policy is included although the policy was
+	// reached through net0.
+	expected := "net0-ref->policy net1-ref->policy port0-ref->net0 port1-ref->net1 project-child->net0 project-child->net1"
+	if strings.Join(edges, " ") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(edges, " "))
+	}
+	if graph.Edges[2].Field != "graph_network_refs" {
+		t.Errorf("unexpected field %s", graph.Edges[2].Field)
+	}
+
+	var buf bytes.Buffer
+	if err := graph.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Graph
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != 6 || len(decoded.Edges) != 6 {
+		t.Errorf("unexpected JSON %s", buf.String())
+	}
+}
+
+func TestGraphWriteDOT(t *testing.T) {
+	graph := &Graph{
+		Nodes: []GraphNode{
+			{Uuid: "p", Type: "project", FQName: []string{"default-domain", `a"b`}},
+			{Uuid: "n", Type: "virtual-network", FQName: []string{"default-domain", `a"b`, "net"}},
+		},
+		Edges: []GraphEdge{{From: "p", To: "n", Kind: "child", Field: "virtual_networks"}},
+	}
+	var buf bytes.Buffer
+	if err := graph.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph contrail {
+	node [shape=box];
+	"p" [label="project\ndefault-domain:a\"b"];
+	"n" [label="virtual-network\ndefault-domain:a\"b:net"];
+	"p" -> "n" [label="virtual_networks", style=dashed];
+}
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
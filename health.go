@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ServerInfo describes the API server as advertised by its root document.
+type ServerInfo struct {
+	Href string
+	// Collections lists the object types that can be listed and created.
+	Collections []string
+	// Actions lists the non-CRUD endpoints (e.g. ref-update, fqname-to-id).
+	Actions []string
+	// Version is the build version of the API server, when advertised.
+	Version string
+	// BuildInfo is the raw build information, when advertised.
+	BuildInfo map[string]interface{}
+	// Latency is the response time of the request.
+	Latency time.Duration
+}
+
+// HasCollection returns true if the server supports the specified type.
+func (info *ServerInfo) HasCollection(typename string) bool {
+	for _, name := range info.Collections {
+		if name == typename {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAction returns true if the server advertises the specified endpoint.
+func (info *ServerInfo) HasAction(name string) bool {
+	for _, action := range info.Actions {
+		if action == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) getRootDocument(ctx context.Context) ([]byte, error) {
+	resp, err := c.httpRequest(ctx, "GET", c.baseURL()+"/", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, body)
+	}
+	return body, nil
+}
+
+// Ping verifies that the API server is reachable and responsive. It is
+// intended to back readiness probes.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.getRootDocument(ctx)
+	return err
+}
+
+// parseBuildInfo extracts the version from the sandesh build info string:
+// {"build-info": [{"build-version": "...", ...}]}
+func parseBuildInfo(data string) (string, map[string]interface{}) {
+	var buildInfo map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &buildInfo); err != nil {
+		return "", nil
+	}
+	var version string
+	if list, ok := buildInfo["build-info"].([]interface{}); ok && len(list) > 0 {
+		if item, ok := list[0].(map[string]interface{}); ok {
+			version, _ = item["build-version"].(string)
+		}
+	}
+	return version, buildInfo
+}
+
+// ServerInfo retrieves the API server root document.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	start := time.Now()
+	body, err := c.getRootDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Href  string
+		Links []struct {
+			Link struct {
+				Href string
+				Name string
+				Rel  string
+			}
+		}
+		BuildInfo string `json:"build_info"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	info := &ServerInfo{
+		Href:    response.Href,
+		Latency: time.Since(start),
+	}
+	for _, element := range response.Links {
+		switch element.Link.Rel {
+		case "collection":
+			info.Collections = append(info.Collections, element.Link.Name)
+		case "action":
+			info.Actions = append(info.Actions, element.Link.Name)
+		}
+	}
+	if len(response.BuildInfo) > 0 {
+		info.Version, info.BuildInfo = parseBuildInfo(response.BuildInfo)
+	}
+	return info, nil
+}
@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const rootDocument = `{
+	"href": "http://localhost:8082",
+	"links": [
+		{"link": {"href": "http://localhost:8082/virtual-networks", "name": "virtual-network", "rel": "collection"}},
+		{"link": {"href": "http://localhost:8082/virtual-network", "name": "virtual-network", "rel": "resource-base"}},
+		{"link": {"href": "http://localhost:8082/ref-update", "name": "ref-update", "rel": "action"}}
+	],
+	"build_info": "{\"build-info\": [{\"build-version\": \"5.1.0\", \"build-number\": \"42\"}]}"
+}`
+
+func TestServerInfo(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, rootDocument)
+	})
+	defer server.Close()
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	info, err := client.ServerInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.HasCollection("virtual-network") || info.HasCollection("tag") {
+		t.Errorf("unexpected collections %v", info.Collections)
+	}
+	if !info.HasAction("ref-update") {
+		t.Errorf("unexpected actions %v", info.Actions)
+	}
+	if info.Version != "5.1.0" {
+		t.Errorf("unexpected version %q", info.Version)
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.Ping(ctx); err == nil {
+		t.Error("expected error on cancelled context")
+	}
+}
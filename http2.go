@@ -0,0 +1,43 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// SetHTTP2 controls the use of HTTP/2. It is enabled by default and is
+// negotiated (ALPN) on TLS connections, either with the API server or with
+// a TLS terminating proxy in front of it; plain HTTP connections always
+// use HTTP/1.1.
+//
+// With HTTP/2, concurrent requests are multiplexed over a single
+// connection instead of each requiring a connection of its own, which
+// avoids the TLS handshakes and connection churn that dominate the latency
+// of highly concurrent controllers (see BenchmarkConcurrentRequests).
+// Disabling it forces HTTP/1.1, for servers or proxies with broken HTTP/2
+// implementations.
+func (c *Client) SetHTTP2(enabled bool) {
+	c.disableHTTP2 = !enabled
+	c.updateTransport()
+}
+
+// configureHTTP2 enables or disables HTTP/2 on a transport. Transports with
+// a custom TLS configuration or dialer only attempt HTTP/2 when forced to.
+func configureHTTP2(transport *http.Transport, enabled bool) {
+	// The transport records the negotiated protocols in its TLS
+	// configuration, which is shared with the client.
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+	if enabled {
+		transport.ForceAttemptHTTP2 = true
+		return
+	}
+	transport.ForceAttemptHTTP2 = false
+	// A non-nil empty map disables HTTP/2.
+	transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+}
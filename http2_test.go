@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func newTLSTestServer(t testing.TB, handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	client := NewClient(host, portNum)
+	client.AddEncryption("", "", "", true)
+	return server, client
+}
+
+func TestHTTP2(t *testing.T) {
+	var proto string
+	server, client := newTLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+		fmt.Fprint(w, rootDocument)
+	})
+	defer server.Close()
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if proto != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2.0, got %s", proto)
+	}
+
+	client.SetHTTP2(false)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if proto != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1, got %s", proto)
+	}
+}
+
+// BenchmarkConcurrentRequests compares HTTP/1.1 and HTTP/2 for bursts of
+// concurrent requests on TLS connections.
+func BenchmarkConcurrentRequests(b *testing.B) {
+	for _, http2 := range []bool{false, true} {
+		b.Run(fmt.Sprintf("http2=%v", http2), func(b *testing.B) {
+			server, client := newTLSTestServer(b, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, rootDocument)
+			})
+			defer server.Close()
+			client.SetHTTP2(http2)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for j := 0; j < 50; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						client.Ping(context.Background())
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// SetIdempotentCreate enables client assigned uuids on Create. Objects
+// created without a uuid are assigned a random one before the request is
+// sent; when a POST is retried (by a middleware or by calling Create again
+// with the same object) after the API server already created the object,
+// the conflict is recognized by the uuid and Create succeeds instead of
+// creating a duplicate or failing.
+func (c *Client) SetIdempotentCreate(enabled bool) {
+	c.idempotentCreate = enabled
+}
+
+// newUuid returns a random (version 4) uuid.
+func newUuid() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10],
+		b[10:]), nil
+}
+
+func (c *Client) assignCreateUuid(ptr IObject) error {
+	if !c.idempotentCreate || ptr.GetUuid() != "" {
+		return nil
+	}
+	uuid, err := newUuid()
+	if err != nil {
+		return err
+	}
+	ptr.SetUuid(uuid)
+	return nil
+}
+
+// createdByPreviousAttempt is called when a create with a client assigned
+// uuid conflicts. It reads the object with that uuid and, when it has the
+// fq_name of ptr, decodes it into ptr.
+func (c *Client) createdByPreviousAttempt(typename string, ptr IObject) bool {
+	if !c.idempotentCreate || ptr.GetUuid() == "" {
+		return false
+	}
+	url := fmt.Sprintf("%s/%s/%s", c.baseURL(), typename, ptr.GetUuid())
+	body, err := c.readObjectData(url)
+	if err != nil {
+		return false
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return false
+	}
+	var current struct {
+		FQName []string `json:"fq_name"`
+	}
+	if err := json.Unmarshal(m[typename], &current); err != nil ||
+		!reflect.DeepEqual(current.FQName, ptr.GetFQName()) {
+		return false
+	}
+	if err := c.unmarshal(m[typename], ptr); err != nil {
+		return false
+	}
+	ptr.SetClient(c)
+	return true
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	created := make(map[string]json.RawMessage)
+	posts := 0
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			posts++
+			var msg map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			var obj map[string]interface{}
+			json.Unmarshal(msg["test-network"], &obj)
+			uuid := obj["uuid"].(string)
+			if _, exists := created[uuid]; exists {
+				http.Error(w, "uuid already exists", http.StatusConflict)
+				return
+			}
+			fqn := obj["fq_name"].([]interface{})
+			obj["name"] = fqn[len(fqn)-1]
+			created[uuid], _ = json.Marshal(obj)
+			fmt.Fprintf(w, `{"test-network": %s}`, created[uuid])
+		case "GET":
+			uuid := r.URL.Path[len("/test-network/"):]
+			data, ok := created[uuid]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"test-network": %s}`, data)
+		}
+	})
+	defer server.Close()
+
+	// Lose the response to the first POST, as a network failure would,
+	// and retry.
+	lost := false
+	client.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if req.Method != "POST" || lost {
+				return resp, err
+			}
+			lost = true
+			resp.Body.Close()
+			body, _ := req.GetBody()
+			req.Body = body
+			return next(req)
+		}
+	})
+	client.SetIdempotentCreate(true)
+
+	network := new(TestNetwork)
+	network.SetName("net")
+	if err := client.Create(network); err != nil {
+		t.Fatal(err)
+	}
+	if posts != 2 || len(created) != 1 {
+		t.Errorf("expected 2 posts creating 1 object, got %d and %d",
+			posts, len(created))
+	}
+	uuidPattern := regexp.MustCompile(
+		`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuidPattern.MatchString(network.GetUuid()) {
+		t.Errorf("unexpected uuid %q", network.GetUuid())
+	}
+	if network.IsTransient() {
+		t.Error("object not marked as persistent")
+	}
+
+	// A different object with an existing uuid is still a conflict.
+	other := new(TestNetwork)
+	other.SetName("other")
+	other.SetUuid(network.GetUuid())
+	err := client.Create(other)
+	if err == nil {
+		t.Fatal("expected a conflict")
+	}
+	if !strings.HasPrefix(err.Error(), "409") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package introspect
+
+import (
+	"encoding/xml"
+	"net/url"
+)
+
+// InterfaceData describes an interface known to the vrouter agent.
+// see: controller/src/vnsw/agent/oper/agent.sandesh
+// struct ItfSandeshData
+type InterfaceData struct {
+	Index            int    `xml:"index"`
+	Name             string `xml:"name"`
+	Uuid             string `xml:"uuid"`
+	VrfName          string `xml:"vrf_name"`
+	Status           string `xml:"active"`
+	Type             string `xml:"type"`
+	NetworkName      string `xml:"vn_name"`
+	InstanceName     string `xml:"vm_name"`
+	IpAddress        string `xml:"ip_addr"`
+	MacAddress       string `xml:"mac_addr"`
+	LinkLocalAddress string `xml:"mdata_ip_addr"`
+	Label            int    `xml:"label"`
+}
+
+// VrfData describes a routing instance known to the vrouter agent.
+// see: controller/src/vnsw/agent/oper/agent.sandesh
+// struct VrfSandeshData
+type VrfData struct {
+	Name        string `xml:"name"`
+	UcIndex     int    `xml:"ucindex"`
+	McIndex     int    `xml:"mcindex"`
+	L2Index     int    `xml:"l2index"`
+	NetworkName string `xml:"vn"`
+	Table       string `xml:"table_label"`
+}
+
+// AgentInterfaceList retrieves the interfaces of a vrouter agent (ItfReq).
+// If name is not empty, the result is restricted to that interface.
+func (c *Client) AgentInterfaceList(name string) ([]InterfaceData, error) {
+	type ItfResp struct {
+		Data []InterfaceData `xml:"itf_list>list>ItfSandeshData"`
+	}
+	params := make(url.Values, 0)
+	if len(name) > 0 {
+		params.Add("name", name)
+	}
+	var result []InterfaceData
+	err := c.Request("ItfReq", params, "ItfResp",
+		func(d *xml.Decoder, start *xml.StartElement) error {
+			var resp ItfResp
+			if err := d.DecodeElement(&resp, start); err != nil {
+				return err
+			}
+			result = append(result, resp.Data...)
+			return nil
+		})
+	return result, err
+}
+
+// AgentVrfList retrieves the routing instances of a vrouter agent (VrfListReq).
+func (c *Client) AgentVrfList(name string) ([]VrfData, error) {
+	type VrfListResp struct {
+		Data []VrfData `xml:"vrf_list>list>VrfSandeshData"`
+	}
+	params := make(url.Values, 0)
+	if len(name) > 0 {
+		params.Add("name", name)
+	}
+	var result []VrfData
+	err := c.Request("VrfListReq", params, "VrfListResp",
+		func(d *xml.Decoder, start *xml.StartElement) error {
+			var resp VrfListResp
+			if err := d.DecodeElement(&resp, start); err != nil {
+				return err
+			}
+			result = append(result, resp.Data...)
+			return nil
+		})
+	return result, err
+}
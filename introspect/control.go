@@ -0,0 +1,48 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package introspect
+
+import (
+	"encoding/xml"
+	"net/url"
+)
+
+// BgpNeighborData describes a BGP or XMPP peering session of a control node.
+// see: controller/src/bgp/bgp_peer.sandesh
+// struct BgpNeighborResp
+type BgpNeighborData struct {
+	PeerAddress  string `xml:"peer_address"`
+	Peer         string `xml:"peer"`
+	PeerAsn      int    `xml:"peer_asn"`
+	Encoding     string `xml:"encoding"`
+	PeerType     string `xml:"peer_type"`
+	State        string `xml:"state"`
+	LastError    string `xml:"last_error"`
+	FlapCount    int    `xml:"flap_count"`
+	LocalAddress string `xml:"local_address"`
+}
+
+// ControlNeighborList retrieves the BGP and XMPP peers of a control node
+// (BgpNeighborReq).
+func (c *Client) ControlNeighborList(search string) ([]BgpNeighborData, error) {
+	type BgpNeighborListResp struct {
+		Data []BgpNeighborData `xml:"neighbors>list>BgpNeighborResp"`
+	}
+	params := make(url.Values, 0)
+	if len(search) > 0 {
+		params.Add("search_string", search)
+	}
+	var result []BgpNeighborData
+	err := c.Request("BgpNeighborReq", params, "BgpNeighborListResp",
+		func(d *xml.Decoder, start *xml.StartElement) error {
+			var resp BgpNeighborListResp
+			if err := d.DecodeElement(&resp, start); err != nil {
+				return err
+			}
+			result = append(result, resp.Data...)
+			return nil
+		})
+	return result, err
+}
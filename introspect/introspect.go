@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package introspect implements a client for the Sandesh HTTP introspect
+// pages exposed by the OpenContrail processes.
+package introspect
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Default introspect ports.
+const (
+	AgentIntrospectPort   = 8085
+	ControlIntrospectPort = 8083
+	ConfigIntrospectPort  = 8084
+)
+
+// Client retrieves and decodes introspect pages from a single process.
+type Client struct {
+	server     string
+	port       int
+	httpClient *http.Client
+}
+
+// NewClient allocates and initializes an introspect client.
+func NewClient(server string, port int) *Client {
+	client := new(Client)
+	client.server = server
+	client.port = port
+	client.httpClient = new(http.Client)
+	return client
+}
+
+// SetHTTPClient replaces the http.Client used to issue requests.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// Request issues the Sandesh request (e.g. "ItfReq") and decodes every
+// response element with the given name (e.g. "ItfResp") by calling fn.
+//
+// Responses may be delivered as a single element or wrapped in a
+// list (e.g. __ItfResp_list), depending on the release; both are handled.
+func (c *Client) Request(request string, params url.Values, response string,
+	fn func(*xml.Decoder, *xml.StartElement) error) error {
+	host := strings.TrimSuffix(strings.TrimPrefix(c.server, "["), "]")
+	url := fmt.Sprintf("http://%s/Snh_%s",
+		net.JoinHostPort(host, strconv.Itoa(c.port)), request)
+	if len(params) > 0 {
+		url += "?" + params.Encode()
+	}
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, url)
+	}
+
+	found := false
+	decoder := xml.NewDecoder(resp.Body)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != response {
+			continue
+		}
+		found = true
+		if err := fn(decoder, &start); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("No %s in response to %s", response, request)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package introspect
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const itfResponse = `<?xml-stylesheet type="text/xsl" href="/universal_parse.xsl"?>
+<__ItfResp_list type="slist">
+<ItfResp type="sandesh">
+<itf_list type="list" identifier="1"><list type="struct" size="2">
+<ItfSandeshData><index type="i32">3</index><name type="string">tap1</name><uuid type="string">7e4f3a2c-0000-0000-0000-000000000001</uuid><vn_name type="string">default-domain:p1:n1</vn_name><ip_addr type="string">10.0.0.3</ip_addr></ItfSandeshData>
+<ItfSandeshData><index type="i32">4</index><name type="string">tap2</name></ItfSandeshData>
+</list></itf_list>
+</ItfResp>
+<ItfResp type="sandesh">
+<itf_list type="list" identifier="1"><list type="struct" size="1">
+<ItfSandeshData><index type="i32">5</index><name type="string">tap3</name></ItfSandeshData>
+</list></itf_list>
+</ItfResp>
+</__ItfResp_list>`
+
+func TestAgentInterfaceList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Snh_ItfReq" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, itfResponse)
+	}))
+	defer server.Close()
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	client := NewClient(host, portNum)
+
+	interfaces, err := client.AgentInterfaceList("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interfaces) != 3 {
+		t.Fatalf("expected 3 interfaces, got %d", len(interfaces))
+	}
+	if interfaces[0].IpAddress != "10.0.0.3" || interfaces[0].Index != 3 {
+		t.Errorf("unexpected interface data: %+v", interfaces[0])
+	}
+	if interfaces[2].Name != "tap3" {
+		t.Errorf("unexpected interface data: %+v", interfaces[2])
+	}
+
+	_, err = client.AgentVrfList("")
+	if err == nil {
+		t.Error("expected error on missing page")
+	}
+}
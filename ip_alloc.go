@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+)
+
+// VirtualNetworkIpAlloc reserves count addresses in a subnet (e.g.
+// "10.0.0.0/24") of a virtual-network. An empty subnet selects any subnet
+// of the network. Reserved addresses are not associated with an
+// instance-ip; they must be returned with VirtualNetworkIpFree.
+func (c *Client) VirtualNetworkIpAlloc(uuid, subnet string, count int) (
+	[]string, error) {
+	msg := map[string]interface{}{"count": count}
+	if subnet != "" {
+		msg["subnet"] = subnet
+	}
+	var response struct {
+		Addresses []string `json:"ip_addr"`
+	}
+	err := c.DoJSON(context.Background(), "POST",
+		"virtual-network/"+uuid+"/ip-alloc", msg, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Addresses) != count {
+		return response.Addresses, fmt.Errorf(
+			"ip-alloc: %d addresses requested, %d allocated", count,
+			len(response.Addresses))
+	}
+	return response.Addresses, nil
+}
+
+// VirtualNetworkIpFree releases addresses reserved with
+// VirtualNetworkIpAlloc.
+func (c *Client) VirtualNetworkIpFree(uuid string, addresses []string) error {
+	msg := map[string]interface{}{"ip_addr": addresses}
+	return c.DoJSON(context.Background(), "POST",
+		"virtual-network/"+uuid+"/ip-free", msg, nil)
+}
+
+// VirtualNetworkSubnetIpCount returns the number of addresses in use in
+// each of the subnets of a virtual-network.
+func (c *Client) VirtualNetworkSubnetIpCount(uuid string, subnets []string) (
+	[]int, error) {
+	msg := map[string]interface{}{"subnet_list": subnets}
+	var response struct {
+		Counts []int `json:"ip_count_list"`
+	}
+	err := c.DoJSON(context.Background(), "POST",
+		"virtual-network/"+uuid+"/subnet-ip-count", msg, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Counts) != len(subnets) {
+		return nil, fmt.Errorf("subnet-ip-count: %d subnets, %d counts",
+			len(subnets), len(response.Counts))
+	}
+	return response.Counts, nil
+}
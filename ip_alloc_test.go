@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestVirtualNetworkIpAlloc(t *testing.T) {
+	used := map[string]bool{}
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Subnet  string   `json:"subnet"`
+			Count   int      `json:"count"`
+			Addrs   []string `json:"ip_addr"`
+			Subnets []string `json:"subnet_list"`
+		}
+		json.NewDecoder(r.Body).Decode(&msg)
+		switch r.URL.Path {
+		case "/virtual-network/vn-1/ip-alloc":
+			var addrs []string
+			for _, addr := range []string{"10.0.0.3", "10.0.0.4", "10.0.0.5"} {
+				if len(addrs) < msg.Count && !used[addr] {
+					used[addr] = true
+					addrs = append(addrs, addr)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string][]string{"ip_addr": addrs})
+		case "/virtual-network/vn-1/ip-free":
+			for _, addr := range msg.Addrs {
+				delete(used, addr)
+			}
+		case "/virtual-network/vn-1/subnet-ip-count":
+			counts := make([]int, len(msg.Subnets))
+			counts[0] = len(used)
+			json.NewEncoder(w).Encode(map[string][]int{"ip_count_list": counts})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	addrs, err := client.VirtualNetworkIpAlloc("vn-1", "10.0.0.0/24", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(addrs, []string{"10.0.0.3", "10.0.0.4"}) {
+		t.Errorf("unexpected addresses %v", addrs)
+	}
+	if _, err := client.VirtualNetworkIpAlloc("vn-1", "", 2); err == nil {
+		t.Error("expected error on short allocation")
+	}
+	counts, err := client.VirtualNetworkSubnetIpCount("vn-1",
+		[]string{"10.0.0.0/24", "10.0.1.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(counts, []int{3, 0}) {
+		t.Errorf("unexpected counts %v", counts)
+	}
+	if err := client.VirtualNetworkIpFree("vn-1", addrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(used) != 1 {
+		t.Errorf("addresses not released: %v", used)
+	}
+	if _, err := client.VirtualNetworkIpAlloc("vn-2", "", 1); err == nil {
+		t.Error("expected error for unknown network")
+	}
+}
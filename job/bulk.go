@@ -0,0 +1,240 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package job
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+func objectName(typename string, fqn []string) string {
+	return typename + " " + strings.Join(fqn, ":")
+}
+
+// BulkCreate creates the objects in order. Failures are recorded and do not
+// stop the job.
+func BulkCreate(client contrail.ApiClient, objects []contrail.IObject,
+	callback ProgressFunc) *Job {
+	return Start(func(ctx context.Context, job *Job) error {
+		job.SetTotal(len(objects))
+		for _, obj := range objects {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			name := objectName(obj.GetType(), obj.GetFQName())
+			job.Begin(name)
+			job.Step(name, client.Create(obj))
+		}
+		return nil
+	}, callback)
+}
+
+// DeleteEntry identifies an object to be deleted.
+type DeleteEntry struct {
+	Type   string
+	Uuid   string
+	FQName []string
+}
+
+// DeletePlan computes the list of objects that must be deleted in order to
+// delete the object identified by typename and uuid: all its descendants
+// and the object itself. Children are listed before their parents, and
+// objects before the objects of the subtree they refer to. Reference
+// cycles are broken arbitrarily.
+func DeletePlan(client contrail.ApiClient, typename, uuid string) (
+	[]DeleteEntry, error) {
+	fields, err := contrail.TypeReferenceFields(typename)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.FindByUuid(typename, uuid)
+	if err != nil {
+		return nil, err
+	}
+	planner := &deletePlanner{
+		client:  client,
+		parents: make(map[string]string),
+		refs:    make(map[string][]string),
+	}
+	if err := planner.add(obj, fields); err != nil {
+		return nil, err
+	}
+	return planner.order(), nil
+}
+
+// deletePlanner collects the objects of a subtree.
+type deletePlanner struct {
+	client  contrail.ApiClient
+	entries []DeleteEntry
+	// Parent of each object, by uuid.
+	parents map[string]string
+	// Uuids of the objects each object refers to.
+	refs map[string][]string
+}
+
+// add records obj, after its descendants. obj holds the forward reference
+// lists of fields.
+func (p *deletePlanner) add(obj contrail.IObject, fields *contrail.ReferenceFields) error {
+	for _, field := range fields.Refs {
+		refs, err := contrail.GetReferenceList(obj, field)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			p.refs[obj.GetUuid()] = append(p.refs[obj.GetUuid()], ref.Uuid)
+		}
+	}
+	for _, field := range fields.Children {
+		childType := contrail.ReferenceFieldType(field)
+		childFields, err := contrail.TypeReferenceFields(childType)
+		if err != nil {
+			return err
+		}
+		children, err := p.client.ListDetailByParent(childType, obj.GetUuid(),
+			childFields.Refs)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			p.parents[child.GetUuid()] = obj.GetUuid()
+			if err := p.add(child, childFields); err != nil {
+				return err
+			}
+		}
+	}
+	p.entries = append(p.entries, DeleteEntry{obj.GetType(), obj.GetUuid(),
+		obj.GetFQName()})
+	return nil
+}
+
+// order sorts the entries such that the children and the objects that
+// refer to an object come first. The relative order of independent
+// entries is preserved.
+func (p *deletePlanner) order() []DeleteEntry {
+	index := make(map[string]int, len(p.entries))
+	for i, entry := range p.entries {
+		index[entry.Uuid] = i
+	}
+	deps := make([][]int, len(p.entries))
+	for i, entry := range p.entries {
+		if j, ok := index[p.parents[entry.Uuid]]; ok {
+			deps[j] = append(deps[j], i)
+		}
+		for _, uuid := range p.refs[entry.Uuid] {
+			if j, ok := index[uuid]; ok && j != i {
+				deps[j] = append(deps[j], i)
+			}
+		}
+	}
+	done := make([]bool, len(p.entries))
+	plan := make([]DeleteEntry, 0, len(p.entries))
+	for len(plan) < len(p.entries) {
+		progress := false
+		for i := range p.entries {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, j := range deps[i] {
+				ready = ready && done[j]
+			}
+			if ready {
+				done[i] = true
+				plan = append(plan, p.entries[i])
+				progress = true
+			}
+		}
+		if progress {
+			continue
+		}
+		// Cycle: the first remaining entry is deleted first.
+		for i := range p.entries {
+			if !done[i] {
+				done[i] = true
+				plan = append(plan, p.entries[i])
+				break
+			}
+		}
+	}
+	return plan
+}
+
+// DeleteOptions controls the execution of a deletion plan.
+type DeleteOptions struct {
+	// DryRun computes the plan and passes it to Plan without deleting
+	// anything.
+	DryRun bool
+	// Plan, when set, is called with the plan before it is executed.
+	Plan func([]DeleteEntry)
+	// ChunkSize splits the deletions into chunks separated by
+	// ChunkDelay, to limit the request rate. 0 deletes without pausing.
+	ChunkSize  int
+	ChunkDelay time.Duration
+}
+
+// CascadeDelete deletes an object and all its descendants.
+//
+// Objects that fail to be deleted (e.g. because they are referred to by
+// objects outside the subtree) are recorded as errors; the deletion of
+// their ancestors will fail as well.
+func CascadeDelete(client contrail.ApiClient, typename, uuid string,
+	callback ProgressFunc) *Job {
+	return CascadeDeleteWithOptions(client, typename, uuid, DeleteOptions{},
+		callback)
+}
+
+// CascadeDeleteWithOptions is CascadeDelete with a dry-run mode and rate
+// limiting. In dry-run mode the job completes once the plan is computed,
+// with a total equal to the number of objects that would be deleted.
+func CascadeDeleteWithOptions(client contrail.ApiClient, typename, uuid string,
+	opts DeleteOptions, callback ProgressFunc) *Job {
+	return Start(func(ctx context.Context, job *Job) error {
+		job.Begin(objectName(typename, []string{uuid}))
+		plan, err := DeletePlan(client, typename, uuid)
+		if err != nil {
+			return err
+		}
+		return deleteEntries(ctx, client, job, plan, opts)
+	}, callback)
+}
+
+// DeleteEntries deletes the objects of a plan in order, e.g. a plan
+// computed by DeletePlan and reviewed before it is executed.
+func DeleteEntries(client contrail.ApiClient, plan []DeleteEntry,
+	opts DeleteOptions, callback ProgressFunc) *Job {
+	return Start(func(ctx context.Context, job *Job) error {
+		return deleteEntries(ctx, client, job, plan, opts)
+	}, callback)
+}
+
+func deleteEntries(ctx context.Context, client contrail.ApiClient, job *Job,
+	plan []DeleteEntry, opts DeleteOptions) error {
+	if opts.Plan != nil {
+		opts.Plan(plan)
+	}
+	job.SetTotal(len(plan))
+	if opts.DryRun {
+		return nil
+	}
+	for i, entry := range plan {
+		if opts.ChunkSize > 0 && i > 0 && i%opts.ChunkSize == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(opts.ChunkDelay):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := objectName(entry.Type, entry.FQName)
+		job.Begin(name)
+		job.Step(name, client.DeleteByUuid(entry.Type, entry.Uuid))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package job runs long operations (bulk create, cascade delete, snapshot
+// import) asynchronously and reports their progress.
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Progress is a point-in-time view of the state of a job.
+type Progress struct {
+	Done  int
+	Total int
+	// Current identifies the object being processed.
+	Current string
+	// Errors lists the failures on individual objects.
+	Errors []error
+}
+
+// ProgressFunc is invoked each time the progress of a job changes, from
+// the goroutine that reports the change: jobs that process objects in
+// parallel (see Schedule) report from several goroutines. The invocations
+// are serialized, in the order of the changes. It should not block.
+type ProgressFunc func(Progress)
+
+// Func is the body of a job. It should return promptly when ctx is
+// cancelled and use the job to report progress.
+type Func func(ctx context.Context, job *Job) error
+
+// Job is the handle of an asynchronous operation.
+type Job struct {
+	// notify serializes the callback invocations.
+	notify   sync.Mutex
+	mutex    sync.Mutex
+	progress Progress
+	callback ProgressFunc
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+}
+
+// Start runs fn in a new goroutine. The callback may be nil.
+func Start(fn Func, callback ProgressFunc) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		callback: callback,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(job.done)
+		defer cancel()
+		err := fn(ctx, job)
+		job.mutex.Lock()
+		job.err = err
+		job.mutex.Unlock()
+	}()
+	return job
+}
+
+func (j *Job) update(fn func(*Progress)) {
+	j.notify.Lock()
+	defer j.notify.Unlock()
+	j.mutex.Lock()
+	fn(&j.progress)
+	progress := j.snapshot()
+	j.mutex.Unlock()
+	if j.callback != nil {
+		j.callback(progress)
+	}
+}
+
+func (j *Job) snapshot() Progress {
+	progress := j.progress
+	progress.Errors = append([]error(nil), j.progress.Errors...)
+	return progress
+}
+
+// SetTotal sets the number of units of work of the job.
+func (j *Job) SetTotal(total int) {
+	j.update(func(p *Progress) {
+		p.Total = total
+	})
+}
+
+// Begin records the object that is currently being processed.
+func (j *Job) Begin(current string) {
+	j.update(func(p *Progress) {
+		p.Current = current
+	})
+}
+
+// Step marks a unit of work as completed; err records a failure.
+func (j *Job) Step(current string, err error) {
+	j.update(func(p *Progress) {
+		p.Done++
+		p.Current = current
+		if err != nil {
+			p.Errors = append(p.Errors, fmt.Errorf("%s: %v", current, err))
+		}
+	})
+}
+
+// Progress returns the current progress of the job.
+func (j *Job) Progress() Progress {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.snapshot()
+}
+
+// Cancel requests the job to stop. Use Wait to determine when it does.
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+// Done returns a channel that is closed when the job terminates.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the job terminates. It returns the error returned by
+// the job or, if none, the first error recorded on an individual object.
+func (j *Job) Wait() error {
+	<-j.done
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.err != nil {
+		return j.err
+	}
+	if len(j.progress.Errors) > 0 {
+		return j.progress.Errors[0]
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package job
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type testObject struct {
+	contrail.ObjectBase
+}
+
+func (*testObject) SetName(string)                {}
+func (*testObject) GetDefaultParent() []string    { return nil }
+func (*testObject) GetDefaultParentType() string  { return "" }
+func (*testObject) GetType() string               { return "test-object" }
+func (*testObject) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testObject) UpdateReferences() error       { return nil }
+func (*testObject) UpdateDone()                   {}
+
+type createClient struct {
+	contrail.ApiClient
+	created []string
+}
+
+func (c *createClient) Create(obj contrail.IObject) error {
+	if obj.GetName() == "bad" {
+		return errors.New("409 Conflict")
+	}
+	c.created = append(c.created, obj.GetName())
+	return nil
+}
+
+func TestBulkCreate(t *testing.T) {
+	var objects []contrail.IObject
+	for _, name := range []string{"a", "bad", "c"} {
+		obj := new(testObject)
+		obj.SetFQName("", []string{name})
+		objects = append(objects, obj)
+	}
+
+	client := new(createClient)
+	var updates []Progress
+	job := BulkCreate(client, objects, func(p Progress) {
+		updates = append(updates, p)
+	})
+	err := job.Wait()
+	if err == nil || err.Error() != "test-object bad: 409 Conflict" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	progress := job.Progress()
+	if progress.Done != 3 || progress.Total != 3 || len(progress.Errors) != 1 {
+		t.Errorf("unexpected progress: %+v", progress)
+	}
+	if len(client.created) != 2 {
+		t.Errorf("expected 2 objects, got %v", client.created)
+	}
+	if len(updates) == 0 || updates[len(updates)-1].Done != 3 {
+		t.Errorf("progress callback not invoked")
+	}
+}
+
+type deleteClient struct {
+	contrail.ApiClient
+	deleted []time.Time
+}
+
+func (c *deleteClient) DeleteByUuid(typename, uuid string) error {
+	c.deleted = append(c.deleted, time.Now())
+	return nil
+}
+
+func TestDeleteEntries(t *testing.T) {
+	var plan []DeleteEntry
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		plan = append(plan, DeleteEntry{"test-object", name, []string{name}})
+	}
+
+	client := new(deleteClient)
+	var planned []DeleteEntry
+	job := DeleteEntries(client, plan, DeleteOptions{
+		DryRun: true,
+		Plan:   func(p []DeleteEntry) { planned = p },
+	}, nil)
+	if err := job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 5 || len(client.deleted) != 0 || job.Progress().Total != 5 {
+		t.Errorf("dry run: planned %d, deleted %d", len(planned), len(client.deleted))
+	}
+
+	delay := 20 * time.Millisecond
+	job = DeleteEntries(client, plan, DeleteOptions{
+		ChunkSize: 2, ChunkDelay: delay,
+	}, nil)
+	if err := job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.deleted) != 5 || job.Progress().Done != 5 {
+		t.Fatalf("deleted %d", len(client.deleted))
+	}
+	// Pauses before the 3rd and 5th deletions.
+	for _, i := range []int{2, 4} {
+		if gap := client.deleted[i].Sub(client.deleted[i-1]); gap < delay {
+			t.Errorf("deletion %d: %v after the previous one", i, gap)
+		}
+	}
+
+	job = DeleteEntries(client, plan, DeleteOptions{
+		ChunkSize: 1, ChunkDelay: time.Hour,
+	}, nil)
+	job.Cancel()
+	if err := job.Wait(); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	started := make(chan struct{})
+	job := Start(func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil)
+	<-started
+	job.Cancel()
+	if err := job.Wait(); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	// 0 <- 1 <- 3, 0 <- 2
+	deps := [][]int{nil, {0}, {0}, {1}}
+	var mutex sync.Mutex
+	done := make(map[int]bool)
+	err := Schedule(context.Background(), deps, 2, func(ctx context.Context, task int) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, dep := range deps[task] {
+			if !done[dep] {
+				t.Errorf("task %d started before %d", task, dep)
+			}
+		}
+		done[task] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 4 {
+		t.Errorf("expected 4 tasks, got %d", len(done))
+	}
+
+	failed := errors.New("failed")
+	var started []int
+	err = Schedule(context.Background(), deps, 1, func(ctx context.Context, task int) error {
+		started = append(started, task)
+		if task == 1 {
+			return failed
+		}
+		return nil
+	})
+	if err != failed {
+		t.Errorf("expected failure, got %v", err)
+	}
+	if len(started) != 2 {
+		t.Errorf("tasks started after failure: %v", started)
+	}
+
+	err = Schedule(context.Background(), [][]int{{1}, {0}}, 1,
+		func(ctx context.Context, task int) error { return nil })
+	if err == nil {
+		t.Error("expected circular dependency error")
+	}
+}
+
+type testTree struct {
+	testObject
+	test_nodes contrail.ReferenceList
+}
+
+func (*testTree) GetType() string { return "test-tree" }
+
+type testNode struct {
+	testObject
+	test_node_refs contrail.ReferenceList
+}
+
+func (*testNode) GetType() string { return "test-node" }
+
+func (obj *testNode) GetTestNodeRefs() (contrail.ReferenceList, error) {
+	return obj.test_node_refs, nil
+}
+
+func init() {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"test-tree": reflect.TypeOf(testTree{}),
+		"test-node": reflect.TypeOf(testNode{}),
+	})
+}
+
+type treeClient struct {
+	contrail.ApiClient
+	root  *testTree
+	nodes []contrail.IObject
+}
+
+func (c *treeClient) FindByUuid(typename, uuid string) (contrail.IObject, error) {
+	return c.root, nil
+}
+
+func (c *treeClient) ListDetailByParent(typename, parentID string, fields []string) (
+	[]contrail.IObject, error) {
+	if typename != "test-node" || parentID != c.root.GetUuid() {
+		return nil, nil
+	}
+	return c.nodes, nil
+}
+
+func TestDeletePlanReferences(t *testing.T) {
+	client := &treeClient{root: new(testTree)}
+	client.root.SetFQName("", []string{"root"})
+	client.root.SetUuid("root")
+	refs := map[string]string{"b": "outside", "c": "a"}
+	for _, name := range []string{"a", "b", "c"} {
+		node := new(testNode)
+		node.SetFQName("", []string{"root", name})
+		node.SetUuid(name)
+		if target, ok := refs[name]; ok {
+			node.test_node_refs = contrail.ReferenceList{
+				{To: []string{"root", target}, Uuid: target},
+			}
+		}
+		client.nodes = append(client.nodes, node)
+	}
+
+	plan, err := DeletePlan(client, "test-tree", "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, entry := range plan {
+		order = append(order, entry.Uuid)
+	}
+	// c refers to a: it is deleted first.
+	expected := []string{"b", "c", "a", "root"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected %v, got %v", expected, order)
+	}
+}
+
+func TestProgressSerialized(t *testing.T) {
+	var active int32
+	last := 0
+	job := Start(func(ctx context.Context, job *Job) error {
+		job.SetTotal(100)
+		return Schedule(ctx, make([][]int, 100), 8,
+			func(ctx context.Context, task int) error {
+				job.Step("task", nil)
+				return nil
+			})
+	}, func(p Progress) {
+		if !atomic.CompareAndSwapInt32(&active, 0, 1) {
+			t.Error("concurrent progress callbacks")
+		}
+		if p.Done < last {
+			t.Errorf("progress went back from %d to %d", last, p.Done)
+		}
+		last = p.Done
+		time.Sleep(time.Millisecond)
+		atomic.StoreInt32(&active, 0)
+	})
+	if err := job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if last != 100 {
+		t.Errorf("expected 100 done, got %d", last)
+	}
+}
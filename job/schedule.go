@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package job
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Schedule runs tasks 0..len(deps)-1 with at most workers tasks in
+// parallel. deps[i] lists the tasks that must complete before task i
+// starts. Ready tasks are started in index order.
+//
+// Schedule stops starting tasks after the first failure; it waits for the
+// tasks in progress and returns that failure.
+func Schedule(ctx context.Context, deps [][]int, workers int,
+	fn func(ctx context.Context, task int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	pending := make([]int, len(deps))
+	dependents := make([][]int, len(deps))
+	for i, list := range deps {
+		for _, j := range list {
+			if j < 0 || j >= len(deps) {
+				return fmt.Errorf("task %d: invalid dependency %d", i, j)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	var ready []int
+	for i := range deps {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type result struct {
+		task int
+		err  error
+	}
+	results := make(chan result)
+	running, completed := 0, 0
+	var failure error
+	for {
+		for failure == nil && ctx.Err() == nil && running < workers && len(ready) > 0 {
+			task := ready[0]
+			ready = ready[1:]
+			running++
+			go func() {
+				results <- result{task, fn(ctx, task)}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+		r := <-results
+		running--
+		completed++
+		if r.err != nil {
+			if failure == nil {
+				failure = r.err
+			}
+			continue
+		}
+		for _, i := range dependents[r.task] {
+			pending[i]--
+			if pending[i] == 0 {
+				ready = append(ready, i)
+				sort.Ints(ready)
+			}
+		}
+	}
+	if failure != nil {
+		return failure
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if completed < len(deps) {
+		return fmt.Errorf("Circular dependencies between tasks")
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Decoding JSON into interface{} values represents numbers as float64,
+// which only holds integers up to 2^53 exactly. Properties such as 4-byte
+// AS numbers combined in route targets, MAC ageing times or 64-bit
+// counters must be decoded as json.Number in generic (untyped) code paths.
+
+// UnmarshalUseNumber decodes data into v representing the numbers held in
+// interface{} values as json.Number.
+func UnmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid character after top-level value")
+	}
+	return nil
+}
+
+// SetUseNumber configures the client to decode numbers held in untyped
+// (interface{} and map) values as json.Number rather than float64. It
+// applies to DoJSON responses and to the objects returned by Create,
+// FindByUuid, FindByName, ReadListResult, ListDetail and GetField. Types
+// that implement UnmarshalJSON or FieldUnmarshaler decode their own fields
+// and are not affected.
+func (c *Client) SetUseNumber(enabled bool) {
+	c.useNumber = enabled
+}
+
+func (c *Client) unmarshal(data []byte, v interface{}) error {
+	if obj, ok := v.(IObject); ok {
+		return decodeInto(obj, data, c.useNumber)
+	}
+	if c.useNumber {
+		return UnmarshalUseNumber(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *Client) newDecoder(r io.Reader) *json.Decoder {
+	decoder := json.NewDecoder(r)
+	if c.useNumber {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+// Int64Value converts a number decoded from JSON (json.Number, float64 or
+// a decimal string) to an int64.
+func Int64Value(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Int64()
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("%v (%T) is not a number", value, value)
+}
+
+// Uint64Value converts a number decoded from JSON (json.Number, float64
+// or a decimal string) to a uint64.
+func Uint64Value(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseUint(string(v), 10, 64)
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return 0, fmt.Errorf("%v is not an unsigned integer", v)
+		}
+		return uint64(v), nil
+	case int:
+		if v < 0 {
+			return 0, fmt.Errorf("%d is not an unsigned integer", v)
+		}
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("%v (%T) is not a number", value, value)
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// 2^63 - 1 cannot be represented by a float64.
+const largeNumber = "9223372036854775807"
+
+func TestUnmarshalUseNumber(t *testing.T) {
+	var m map[string]interface{}
+	if err := UnmarshalUseNumber([]byte(`{"value": `+largeNumber+`}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	n, err := Int64Value(m["value"])
+	if err != nil || fmt.Sprint(n) != largeNumber {
+		t.Errorf("expected %s, got %d (%v)", largeNumber, n, err)
+	}
+	if err := UnmarshalUseNumber([]byte(`{} {}`), &m); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestDoJSONUseNumber(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"value": %s}`, largeNumber)
+	})
+	defer server.Close()
+
+	var response map[string]interface{}
+	if err := client.DoJSON(context.Background(), "GET", "counter", nil, &response); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := response["value"].(float64); !ok {
+		t.Errorf("expected float64, got %T", response["value"])
+	}
+	client.SetUseNumber(true)
+	if err := client.DoJSON(context.Background(), "GET", "counter", nil, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response["value"] != json.Number(largeNumber) {
+		t.Errorf("expected %s, got %v", largeNumber, response["value"])
+	}
+}
+
+func TestNumberValues(t *testing.T) {
+	for _, value := range []interface{}{json.Number("42"), "42", float64(42), 42} {
+		if n, err := Int64Value(value); err != nil || n != 42 {
+			t.Errorf("Int64Value(%#v) = %d, %v", value, n, err)
+		}
+		if n, err := Uint64Value(value); err != nil || n != 42 {
+			t.Errorf("Uint64Value(%#v) = %d, %v", value, n, err)
+		}
+	}
+	if n, err := Uint64Value(json.Number("18446744073709551615")); err != nil || n != 1<<64-1 {
+		t.Errorf("Uint64Value: %d, %v", n, err)
+	}
+	for _, value := range []interface{}{1.5, "x", nil, -1} {
+		if _, err := Uint64Value(value); err == nil {
+			t.Errorf("Uint64Value(%#v): expected error", value)
+		}
+	}
+}
+
+// testCounters decodes its properties with the default decoder, as the
+// objects of types that hold untyped values.
+type testCounters struct {
+	ObjectBase
+	Counters map[string]interface{} `json:"counters"`
+}
+
+func (*testCounters) GetType() string {
+	return "test-counters"
+}
+func (*testCounters) GetDefaultParent() []string {
+	return []string{"default-project"}
+}
+func (*testCounters) GetDefaultParentType() string {
+	return "test-project"
+}
+func (obj *testCounters) SetName(name string) {
+	obj.VSetName(obj, name)
+}
+func (obj *testCounters) UpdateObject() ([]byte, error) {
+	return json.Marshal(obj)
+}
+func (*testCounters) UpdateReferences() error {
+	return nil
+}
+func (*testCounters) UpdateDone() {
+}
+
+func TestReadUseNumber(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		element := fmt.Sprintf(`{"uuid": "c1", "counters": {"bytes": %s}}`, largeNumber)
+		if r.URL.Path == "/test-counterss" {
+			fmt.Fprintf(w, `{"test-counterss": [{"test-counters": %s}]}`, element)
+			return
+		}
+		fmt.Fprintf(w, `{"test-counters": %s}`, element)
+	})
+	defer server.Close()
+	RegisterTypeMap(TypeMap{
+		"test-counters": reflect.TypeOf(testCounters{}),
+	})
+	client.SetUseNumber(true)
+
+	check := func(name string, obj IObject) {
+		value := obj.(*testCounters).Counters["bytes"]
+		if value != json.Number(largeNumber) {
+			t.Errorf("%s: expected %s, got %v (%T)", name, largeNumber, value, value)
+		}
+	}
+	obj, err := client.FindByUuid("test-counters", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("FindByUuid", obj)
+	obj, err = client.ReadListResult("test-counters", &ListResult{Uuid: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("ReadListResult", obj)
+	list, err := client.ListDetail("test-counters", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(list))
+	}
+	check("ListDetail", list[0])
+}
@@ -6,13 +6,13 @@ package contrail
 
 import (
 	"bytes"
-	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
+	"net/url"
+	"sync"
 	"time"
 )
 
@@ -27,17 +27,43 @@ type KeystoneClient struct {
 	osDomainName        string
 	osProjectName       string
 	osProjectDomainName string
+	osDomainID          string
+	osProjectDomainID   string
+	defaultDomainName   string
+	tlsFiles            *keystoneTLSFiles
 	current             *KeystoneToken
 	httpClient          *http.Client
 	tokenID             string
 	isv3Client          bool
 	issuedAt            string
 	expiresAt           string
+	tokenCache          TokenCache
+	region              string
+	catalog             []CatalogEndpoint
+	passcode            PasscodeProvider
+	tokenInfo           *TokenInfo
+	// systemScope, if set, replaces the project scope of v3 tokens.
+	systemScope string
+	// iface is the default interface of Endpoint.
+	iface string
+	// tlsConfigured is set once the transport has been configured by
+	// AddEncryption.
+	tlsConfigured bool
+	// mutex serializes authentication and protects the current token
+	// (tokenID, issuedAt, expiresAt, catalog and tokenInfo).
+	mutex sync.Mutex
 }
 
+// PasscodeProvider returns the current one-time passcode of the user, e.g.
+// from an authenticator application or a TOTP secret held by the service.
+type PasscodeProvider func() (string, error)
+
 // KeepaliveKeystoneClient embeds KeystoneClient
 type KeepaliveKeystoneClient struct {
 	KeystoneClient
+	// background is set while the token is refreshed in the background
+	// (protected by the mutex).
+	background *backgroundRefresh
 }
 
 // KeystoneToken represents an auth token issued by OpenStack keystone service.
@@ -56,8 +82,28 @@ type KeystoneToken struct {
 
 type KeystoneTokenv3 struct {
 	Token struct {
-		ExpiresAt string `json:"expires_at"`
-		IssuedAt  string `json:"issued_at"`
+		ExpiresAt string           `json:"expires_at"`
+		IssuedAt  string           `json:"issued_at"`
+		Catalog   []catalogEntryV3 `json:"catalog"`
+		User      struct {
+			Id     string `json:"id"`
+			Name   string `json:"name"`
+			Domain struct {
+				Id   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"domain"`
+		} `json:"user"`
+		Project struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"project"`
+		System struct {
+			All bool `json:"all"`
+		} `json:"system"`
+		Roles []struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"roles"`
 	} `json:"token"`
 }
 
@@ -73,14 +119,14 @@ func NewKeystoneClient(auth_url, tenant_name, username, password, token, domain_
 		osProjectName:       project_name,
 		osProjectDomainName: project_domain_name,
 		current:             nil,
-		httpClient:          &http.Client{},
+		httpClient:          &http.Client{Timeout: DefaultTimeout},
 	}
 }
 
 // NewKeepaliveKeystoneClient allocates and initializes a KeepaliveKeystoneClient
 func NewKeepaliveKeystoneClient(auth_url, tenant_name, username, password, token, domain_name string) *KeepaliveKeystoneClient {
 	return &KeepaliveKeystoneClient{
-		KeystoneClient{
+		KeystoneClient: KeystoneClient{
 			osAuthURL:    auth_url,
 			osTenantName: tenant_name,
 			osUsername:   username,
@@ -88,35 +134,58 @@ func NewKeepaliveKeystoneClient(auth_url, tenant_name, username, password, token
 			osAdminToken: token,
 			osDomainName: domain_name,
 			current:      nil,
-			httpClient:   &http.Client{},
+			httpClient:   &http.Client{Timeout: DefaultTimeout},
 		},
 	}
 }
 
-// Authenticate sends an authentication request to keystone.
+// AuthenticateV3 sends an authentication request to keystone using the
+// v3 identity API.
 func (kClient *KeystoneClient) AuthenticateV3() error {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	return kClient.authenticateV3()
+}
+
+func (kClient *KeystoneClient) authenticateV3() error {
 	kClient.isv3Client = true
+	// A domain is identified by id or by name.
+	type domainv3 struct {
+		Id   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+	}
+	type passwordv3 struct {
+		User struct {
+			Domain   domainv3 `json:"domain"`
+			Name     string   `json:"name"`
+			Password string   `json:"password"`
+		} `json:"user"`
+	}
+	type tokenv3 struct {
+		Id string `json:"id"`
+	}
+	type totpv3 struct {
+		User struct {
+			Domain   domainv3 `json:"domain"`
+			Name     string   `json:"name"`
+			Passcode string   `json:"passcode"`
+		} `json:"user"`
+	}
+	type projectv3 struct {
+		Name   string   `json:"name"`
+		Domain domainv3 `json:"domain"`
+	}
 	type AuthCredentialsRequestv3 struct {
 		Auth struct {
 			Identity struct {
-				Methods  []string `json:"methods"`
-				Password struct {
-					User struct {
-						Domain struct {
-							Name string `json:"name"`
-						} `json:"domain"`
-						Name     string `json:"name"`
-						Password string `json:"password"`
-					} `json:"user"`
-				} `json:"password"`
+				Methods  []string    `json:"methods"`
+				Password *passwordv3 `json:"password,omitempty"`
+				Token    *tokenv3    `json:"token,omitempty"`
+				TOTP     *totpv3     `json:"totp,omitempty"`
 			} `json:"identity"`
 			Scope struct {
-				Project struct {
-					Name   string `json:"name"`
-					Domain struct {
-						Name string `json:"name"`
-					} `json:"domain"`
-				} `json:"project"`
+				Project *projectv3      `json:"project,omitempty"`
+				System  map[string]bool `json:"system,omitempty"`
 			} `json:"scope"`
 		} `json:"auth"`
 	}
@@ -130,19 +199,47 @@ func (kClient *KeystoneClient) AuthenticateV3() error {
 	var data []byte
 	var err error
 	request := AuthCredentialsRequestv3{}
-	request.Auth.Identity.Password.User.Name = kClient.osUsername
-	request.Auth.Identity.Password.User.Password = kClient.osPassword
-	request.Auth.Identity.Password.User.Domain.Name = kClient.osDomainName
-	request.Auth.Identity.Methods = append(request.Auth.Identity.Methods, "password")
-	request.Auth.Scope.Project.Name = kClient.osProjectName
-	request.Auth.Scope.Project.Domain.Name = kClient.osProjectDomainName
+	if len(kClient.osAdminToken) > 0 {
+		// Exchange the token for a project scoped token.
+		request.Auth.Identity.Methods = []string{"token"}
+		request.Auth.Identity.Token = &tokenv3{kClient.osAdminToken}
+	} else {
+		password := new(passwordv3)
+		password.User.Name = kClient.osUsername
+		password.User.Password = kClient.osPassword
+		password.User.Domain = domainv3{kClient.osDomainID, kClient.osDomainName}
+		request.Auth.Identity.Methods = []string{"password"}
+		request.Auth.Identity.Password = password
+		if kClient.passcode != nil {
+			passcode, err := kClient.passcode()
+			if err != nil {
+				return fmt.Errorf("keystone: totp passcode: %v", err)
+			}
+			totp := new(totpv3)
+			totp.User.Name = kClient.osUsername
+			totp.User.Domain = password.User.Domain
+			totp.User.Passcode = passcode
+			request.Auth.Identity.Methods = append(
+				request.Auth.Identity.Methods, "totp")
+			request.Auth.Identity.TOTP = totp
+		}
+	}
+	if kClient.systemScope != "" {
+		request.Auth.Scope.System = map[string]bool{kClient.systemScope: true}
+	} else {
+		request.Auth.Scope.Project = &projectv3{kClient.osProjectName,
+			domainv3{kClient.osProjectDomainID, kClient.osProjectDomainName}}
+	}
 
 	if data, err = json.Marshal(&request); err != nil {
 		return err
 	}
 
-	// Encryption for insecure access only here
-	kClient.AddEncryption("", "", "", true)
+	// Encryption for insecure access only here, unless the transport is
+	// already configured.
+	if !kClient.tlsConfigured {
+		kClient.AddEncryption("", "", "", true)
+	}
 
 	resp, err := kClient.httpClient.Post(url, "application/json",
 		bytes.NewReader(data))
@@ -170,12 +267,20 @@ func (kClient *KeystoneClient) AuthenticateV3() error {
 	kClient.tokenID = resp.Header.Get("X-Subject-Token")
 	kClient.issuedAt = response.Token.IssuedAt
 	kClient.expiresAt = response.Token.ExpiresAt
+	kClient.catalog = catalogFromV3(response.Token.Catalog)
+	kClient.tokenInfo = tokenInfoFromV3(&response)
 	return nil
 
 }
 
 // Authenticate sends an authentication request to keystone.
 func (kClient *KeystoneClient) Authenticate() error {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	return kClient.authenticate()
+}
+
+func (kClient *KeystoneClient) authenticate() error {
 	// identity:CredentialType
 	type AuthTokenRequest struct {
 		Auth struct {
@@ -202,8 +307,11 @@ func (kClient *KeystoneClient) Authenticate() error {
 			User  struct {
 				Id       string
 				Username string
+				Roles    []struct {
+					Name string
+				}
 			}
-			// ServiceCatalog
+			ServiceCatalog []catalogEntryV2 `json:"serviceCatalog"`
 		}
 	}
 	url := kClient.osAuthURL
@@ -259,6 +367,18 @@ func (kClient *KeystoneClient) Authenticate() error {
 	kClient.expiresAt = response.Access.Token.Expires
 	kClient.issuedAt = response.Access.Token.Issued_At
 	kClient.tokenID = response.Access.Token.Id
+	kClient.catalog = catalogFromV2(response.Access.ServiceCatalog)
+	info := &TokenInfo{
+		UserID:      response.Access.User.Id,
+		UserName:    response.Access.User.Username,
+		ProjectID:   response.Access.Token.Tenant.Id,
+		ProjectName: response.Access.Token.Tenant.Name,
+	}
+	for _, role := range response.Access.User.Roles {
+		info.Roles = append(info.Roles, role.Name)
+	}
+	info.setTimes(kClient.issuedAt, kClient.expiresAt)
+	kClient.tokenInfo = info
 	return nil
 }
 
@@ -284,6 +404,8 @@ func (kClient *KeepaliveKeystoneClient) needsRefreshing() (bool, error) {
 
 // AddAuthentication adds authentication token to the HTTP header of the KeepaliveKeystoneClient
 func (kClient *KeepaliveKeystoneClient) AddAuthentication(req *http.Request) error {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
 	needsRefreshing, err := kClient.needsRefreshing()
 	if err != nil {
 		return err
@@ -293,20 +415,38 @@ func (kClient *KeepaliveKeystoneClient) AddAuthentication(req *http.Request) err
 		kClient.tokenID = ""
 	}
 
-	return kClient.KeystoneClient.AddAuthentication(req)
+	return kClient.addAuthentication(req)
+}
+
+// Identity returns the name of the user the client authenticates as. It
+// is empty when authenticating with a token.
+func (kClient *KeystoneClient) Identity() string {
+	return kClient.osUsername
 }
 
 // AddAuthentication adds the authentication token to the HTTP header.
+// It is safe for concurrent use.
 func (kClient *KeystoneClient) AddAuthentication(req *http.Request) error {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	return kClient.addAuthentication(req)
+}
+
+// addAuthentication must be called with the mutex held.
+func (kClient *KeystoneClient) addAuthentication(req *http.Request) error {
 	if kClient.tokenID == "" {
-		if kClient.isv3Client {
-			if err := kClient.AuthenticateV3(); err != nil {
-				return err
-			}
-		} else {
-			if err := kClient.Authenticate(); err != nil {
-				return err
+		key := kClient.tokenCacheKey()
+		if !kClient.loadCachedToken(key) {
+			if kClient.isv3Client {
+				if err := kClient.authenticateV3(); err != nil {
+					return err
+				}
+			} else {
+				if err := kClient.authenticate(); err != nil {
+					return err
+				}
 			}
+			kClient.storeCachedToken(key)
 		}
 	}
 	req.Header.Set("X-Auth-Token", kClient.tokenID)
@@ -314,9 +454,13 @@ func (kClient *KeystoneClient) AddAuthentication(req *http.Request) error {
 }
 
 // AddEncryption implements the Encryptor interface for Client.
+// The modification time of certFile and keyFile is checked at each TLS
+// handshake (the files are not watched): a rotated client certificate is
+// used by the connections established after the change.
 func (kClient *KeystoneClient) AddEncryption(caFile string, keyFile string, certFile string, insecure bool) error {
-	if !strings.HasPrefix(kClient.osAuthURL, "https") {
-		kClient.osAuthURL = strings.Replace(kClient.osAuthURL, "http", "https", 1)
+	if u, err := url.Parse(kClient.osAuthURL); err == nil && u.Scheme == "http" {
+		u.Scheme = "https"
+		kClient.osAuthURL = u.String()
 	}
 
 	customTransport := http.DefaultTransport.(*http.Transport).Clone()
@@ -331,14 +475,19 @@ func (kClient *KeystoneClient) AddEncryption(caFile string, keyFile string, cert
 		caCertPool.AppendCertsFromPEM(caCert)
 		customTransport.TLSClientConfig.RootCAs = caCertPool
 		if certFile != "" && keyFile != "" {
-			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			reloader, err := newCertReloader(certFile, keyFile)
 			if err != nil {
 				return nil
 			}
-			customTransport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+			customTransport.TLSClientConfig.GetClientCertificate = reloader.GetClientCertificate
 		}
 	}
-	kClient.httpClient.Transport = customTransport
+	// The http.Client may have been supplied by the caller (see
+	// WithHTTPClient): configure a copy.
+	httpClient := *kClient.httpClient
+	httpClient.Transport = customTransport
+	kClient.httpClient = &httpClient
+	kClient.tlsConfigured = true
 
 	return nil
 }
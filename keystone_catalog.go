@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+)
+
+// Endpoint interfaces.
+const (
+	InterfacePublic   = "public"
+	InterfaceInternal = "internal"
+	InterfaceAdmin    = "admin"
+)
+
+// CatalogEndpoint is an endpoint listed in the keystone service catalog.
+type CatalogEndpoint struct {
+	ServiceType string
+	ServiceName string
+	Interface   string
+	Region      string
+	URL         string
+}
+
+// catalogEntryV3 is a service of the catalog of a v3 token.
+type catalogEntryV3 struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Endpoints []struct {
+		Interface string `json:"interface"`
+		Region    string `json:"region"`
+		RegionID  string `json:"region_id"`
+		URL       string `json:"url"`
+	} `json:"endpoints"`
+}
+
+// catalogEntryV2 is a service of the serviceCatalog of a v2 token.
+type catalogEntryV2 struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Endpoints []struct {
+		Region      string `json:"region"`
+		PublicURL   string `json:"publicURL"`
+		InternalURL string `json:"internalURL"`
+		AdminURL    string `json:"adminURL"`
+	} `json:"endpoints"`
+}
+
+func catalogFromV3(entries []catalogEntryV3) []CatalogEndpoint {
+	var catalog []CatalogEndpoint
+	for _, entry := range entries {
+		for _, endpoint := range entry.Endpoints {
+			region := endpoint.RegionID
+			if region == "" {
+				region = endpoint.Region
+			}
+			catalog = append(catalog, CatalogEndpoint{
+				ServiceType: entry.Type,
+				ServiceName: entry.Name,
+				Interface:   endpoint.Interface,
+				Region:      region,
+				URL:         endpoint.URL,
+			})
+		}
+	}
+	return catalog
+}
+
+func catalogFromV2(entries []catalogEntryV2) []CatalogEndpoint {
+	var catalog []CatalogEndpoint
+	for _, entry := range entries {
+		for _, endpoint := range entry.Endpoints {
+			for iface, url := range map[string]string{
+				InterfacePublic:   endpoint.PublicURL,
+				InterfaceInternal: endpoint.InternalURL,
+				InterfaceAdmin:    endpoint.AdminURL,
+			} {
+				if url == "" {
+					continue
+				}
+				catalog = append(catalog, CatalogEndpoint{
+					ServiceType: entry.Type,
+					ServiceName: entry.Name,
+					Interface:   iface,
+					Region:      endpoint.Region,
+					URL:         url,
+				})
+			}
+		}
+	}
+	return catalog
+}
+
+// Catalog returns the service catalog received with the current token.
+// It is empty when the token was loaded from a TokenCache.
+func (kClient *KeystoneClient) Catalog() []CatalogEndpoint {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	return append([]CatalogEndpoint(nil), kClient.catalog...)
+}
+
+// Endpoint returns the URL of a service (e.g. "opencontrail") for the
+// given interface (if empty, the one selected with WithInterface), in the
+// region selected with WithRegion. When no region
+// is configured, the catalog must list a single region for the service.
+// The client authenticates if it doesn't hold a catalog.
+func (kClient *KeystoneClient) Endpoint(serviceType, iface string) (string, error) {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	if kClient.catalog == nil {
+		var err error
+		if kClient.isv3Client {
+			err = kClient.authenticateV3()
+		} else {
+			err = kClient.authenticate()
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if iface == "" {
+		iface = kClient.iface
+	}
+	if iface == "" {
+		iface = InterfacePublic
+	}
+	var matches []CatalogEndpoint
+	regions := make(map[string]bool)
+	for _, endpoint := range kClient.catalog {
+		if endpoint.ServiceType != serviceType || endpoint.Interface != iface {
+			continue
+		}
+		if kClient.region != "" && endpoint.Region != kClient.region {
+			continue
+		}
+		matches = append(matches, endpoint)
+		regions[endpoint.Region] = true
+	}
+	if len(matches) == 0 {
+		if kClient.region != "" {
+			return "", fmt.Errorf("keystone: no %s endpoint for %s in region %s",
+				iface, serviceType, kClient.region)
+		}
+		return "", fmt.Errorf("keystone: no %s endpoint for %s", iface, serviceType)
+	}
+	if len(regions) > 1 {
+		return "", fmt.Errorf("keystone: %s is available in several regions; select one with WithRegion",
+			serviceType)
+	}
+	return matches[0].URL, nil
+}
@@ -0,0 +1,251 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// keystoneSettings is the set of authentication parameters shared by the
+// OS_* environment variables and the clouds.yaml file format.
+type keystoneSettings struct {
+	authURL           string
+	username          string
+	password          string
+	token             string
+	projectName       string
+	userDomainName    string
+	userDomainID      string
+	projectDomainName string
+	projectDomainID   string
+	domainName        string
+	identityVersion   string
+	caFile            string
+	certFile          string
+	keyFile           string
+	insecure          bool
+	region            string
+	systemScope       string
+	iface             string
+}
+
+// options translates the settings into KeystoneOptions. The identity API
+// version is selected explicitly, by a "/v3" suffix on the auth URL or by
+// the presence of domain information.
+func (s *keystoneSettings) options() []KeystoneOption {
+	authURL := strings.TrimSuffix(s.authURL, "/")
+	v3 := strings.HasPrefix(s.identityVersion, "3")
+	if s.identityVersion == "" {
+		v3 = strings.HasSuffix(authURL, "/v3") ||
+			s.userDomainName != "" || s.projectDomainName != "" ||
+			s.userDomainID != "" || s.projectDomainID != "" ||
+			s.domainName != "" || s.systemScope != ""
+	}
+	if v3 {
+		// AuthenticateV3 appends the version to the URL.
+		authURL = strings.TrimSuffix(authURL, "/v3")
+	}
+
+	opts := []KeystoneOption{
+		WithAuthURL(authURL),
+		WithCredentials(s.username, s.password),
+	}
+	if s.token != "" {
+		opts = append(opts, WithAdminToken(s.token))
+	}
+	if v3 {
+		opts = append(opts,
+			WithProjectScope(s.projectName, s.projectDomainName),
+			WithUserDomain(s.userDomainName),
+			WithUserDomainID(s.userDomainID),
+			WithProjectDomainID(s.projectDomainID),
+			WithDomain(s.domainName))
+	} else {
+		opts = append(opts, WithTenant(s.projectName))
+	}
+	if s.systemScope != "" {
+		opts = append(opts, WithSystemScope(s.systemScope))
+	}
+	if s.region != "" {
+		opts = append(opts, WithRegion(s.region))
+	}
+	if s.iface != "" {
+		opts = append(opts, WithInterface(s.iface))
+	}
+	if s.insecure || s.caFile != "" || strings.HasPrefix(authURL, "https") {
+		opts = append(opts,
+			WithCertificates(s.caFile, s.keyFile, s.certFile, s.insecure))
+	}
+	return opts
+}
+
+// firstEnv returns the value of the first environment variable that is set.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// AuthFromEnv builds a KeystoneClient from the standard OpenStack
+// environment variables (OS_AUTH_URL, OS_USERNAME, OS_PASSWORD,
+// OS_PROJECT_NAME or OS_TENANT_NAME, OS_USER_DOMAIN_NAME or
+// OS_USER_DOMAIN_ID, OS_PROJECT_DOMAIN_NAME or OS_PROJECT_DOMAIN_ID,
+// OS_DOMAIN_NAME, OS_IDENTITY_API_VERSION, OS_TOKEN, OS_CACERT, OS_CERT,
+// OS_KEY, OS_INSECURE, OS_REGION_NAME, OS_SYSTEM_SCOPE and OS_INTERFACE).
+// When OS_TOKEN is set it is used instead of the username and password.
+func AuthFromEnv() (*KeystoneClient, error) {
+	settings := &keystoneSettings{
+		authURL:           os.Getenv("OS_AUTH_URL"),
+		username:          os.Getenv("OS_USERNAME"),
+		password:          os.Getenv("OS_PASSWORD"),
+		token:             os.Getenv("OS_TOKEN"),
+		projectName:       firstEnv("OS_PROJECT_NAME", "OS_TENANT_NAME"),
+		userDomainName:    os.Getenv("OS_USER_DOMAIN_NAME"),
+		userDomainID:      os.Getenv("OS_USER_DOMAIN_ID"),
+		projectDomainName: os.Getenv("OS_PROJECT_DOMAIN_NAME"),
+		projectDomainID:   os.Getenv("OS_PROJECT_DOMAIN_ID"),
+		domainName:        firstEnv("OS_DOMAIN_NAME", "OS_DEFAULT_DOMAIN"),
+		identityVersion:   os.Getenv("OS_IDENTITY_API_VERSION"),
+		caFile:            os.Getenv("OS_CACERT"),
+		certFile:          os.Getenv("OS_CERT"),
+		keyFile:           os.Getenv("OS_KEY"),
+		region:            os.Getenv("OS_REGION_NAME"),
+		systemScope:       os.Getenv("OS_SYSTEM_SCOPE"),
+		iface:             firstEnv("OS_INTERFACE", "OS_ENDPOINT_TYPE"),
+	}
+	if value := os.Getenv("OS_INSECURE"); value != "" {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("OS_INSECURE: %v", err)
+		}
+		settings.insecure = insecure
+	}
+	if settings.authURL == "" {
+		return nil, fmt.Errorf("OS_AUTH_URL not set")
+	}
+	return NewKeystoneClientWithOptions(settings.options()...)
+}
+
+// cloudConfig is an entry of the "clouds" section of clouds.yaml.
+type cloudConfig struct {
+	Auth struct {
+		AuthURL           string `yaml:"auth_url"`
+		Username          string `yaml:"username"`
+		Password          string `yaml:"password"`
+		Token             string `yaml:"token"`
+		ProjectName       string `yaml:"project_name"`
+		TenantName        string `yaml:"tenant_name"`
+		UserDomainName    string `yaml:"user_domain_name"`
+		UserDomainID      string `yaml:"user_domain_id"`
+		ProjectDomainName string `yaml:"project_domain_name"`
+		ProjectDomainID   string `yaml:"project_domain_id"`
+		DomainName        string `yaml:"domain_name"`
+		SystemScope       string `yaml:"system_scope"`
+	} `yaml:"auth"`
+	IdentityAPIVersion interface{} `yaml:"identity_api_version"`
+	CACert             string      `yaml:"cacert"`
+	Cert               string      `yaml:"cert"`
+	Key                string      `yaml:"key"`
+	Verify             *bool       `yaml:"verify"`
+	RegionName         string      `yaml:"region_name"`
+	Interface          string      `yaml:"interface"`
+}
+
+// cloudsYAMLPaths returns the locations searched for clouds.yaml, in order
+// of precedence. OS_CLIENT_CONFIG_FILE overrides the search.
+func cloudsYAMLPaths() []string {
+	if path := os.Getenv("OS_CLIENT_CONFIG_FILE"); path != "" {
+		return []string{path}
+	}
+	paths := []string{"clouds.yaml"}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths,
+			filepath.Join(home, ".config", "openstack", "clouds.yaml"))
+	}
+	return append(paths, "/etc/openstack/clouds.yaml")
+}
+
+// AuthFromCloudsYAML builds a KeystoneClient from the named cloud in
+// clouds.yaml. The file is searched in the current directory,
+// ~/.config/openstack and /etc/openstack, unless OS_CLIENT_CONFIG_FILE is
+// set. When cloudName is empty, OS_CLOUD is used.
+func AuthFromCloudsYAML(cloudName string) (*KeystoneClient, error) {
+	if cloudName == "" {
+		cloudName = os.Getenv("OS_CLOUD")
+	}
+	if cloudName == "" {
+		return nil, fmt.Errorf("clouds.yaml: cloud name not specified")
+	}
+
+	var data []byte
+	var filename string
+	for _, path := range cloudsYAMLPaths() {
+		content, err := ioutil.ReadFile(path)
+		if err == nil {
+			data, filename = content, path
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if filename == "" {
+		return nil, fmt.Errorf("clouds.yaml: file not found")
+	}
+
+	var config struct {
+		Clouds map[string]cloudConfig `yaml:"clouds"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	cloud, ok := config.Clouds[cloudName]
+	if !ok {
+		return nil, fmt.Errorf("%s: cloud %q not found", filename, cloudName)
+	}
+	if cloud.Auth.AuthURL == "" {
+		return nil, fmt.Errorf("%s: cloud %q: auth_url not set",
+			filename, cloudName)
+	}
+
+	settings := &keystoneSettings{
+		authURL:           cloud.Auth.AuthURL,
+		username:          cloud.Auth.Username,
+		password:          cloud.Auth.Password,
+		token:             cloud.Auth.Token,
+		projectName:       cloud.Auth.ProjectName,
+		userDomainName:    cloud.Auth.UserDomainName,
+		userDomainID:      cloud.Auth.UserDomainID,
+		projectDomainName: cloud.Auth.ProjectDomainName,
+		projectDomainID:   cloud.Auth.ProjectDomainID,
+		domainName:        cloud.Auth.DomainName,
+		caFile:            cloud.CACert,
+		certFile:          cloud.Cert,
+		keyFile:           cloud.Key,
+		region:            cloud.RegionName,
+		systemScope:       cloud.Auth.SystemScope,
+		iface:             cloud.Interface,
+	}
+	if settings.projectName == "" {
+		settings.projectName = cloud.Auth.TenantName
+	}
+	if cloud.IdentityAPIVersion != nil {
+		settings.identityVersion = fmt.Sprint(cloud.IdentityAPIVersion)
+	}
+	if cloud.Verify != nil {
+		settings.insecure = !*cloud.Verify
+	}
+	return NewKeystoneClientWithOptions(settings.options()...)
+}
@@ -0,0 +1,241 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// KeystoneOption configures a KeystoneClient.
+type KeystoneOption func(*KeystoneClient) error
+
+// WithAuthURL sets the keystone endpoint (e.g. http://keystone:5000).
+func WithAuthURL(url string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osAuthURL = url
+		return nil
+	}
+}
+
+// WithCredentials sets the username and password.
+func WithCredentials(username, password string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osUsername = username
+		kClient.osPassword = password
+		return nil
+	}
+}
+
+// WithAdminToken authenticates with a token instead of credentials. With
+// keystone v3 the token is exchanged for a token scoped to the project.
+func WithAdminToken(token string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osAdminToken = token
+		return nil
+	}
+}
+
+// WithTenant sets the tenant name used by keystone v2 authentication.
+func WithTenant(tenant string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osTenantName = tenant
+		return nil
+	}
+}
+
+// WithProjectScope sets the project (and the domain it belongs to) a
+// keystone v3 token is scoped to. It also selects v3 authentication.
+func WithProjectScope(project, projectDomain string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osProjectName = project
+		kClient.osProjectDomainName = projectDomain
+		kClient.isv3Client = true
+		return nil
+	}
+}
+
+// SystemScopeAll is the system scope of the tokens that grant access to
+// the whole deployment, the only system scope supported by keystone.
+const SystemScopeAll = "all"
+
+// WithSystemScope requests system-scoped tokens (keystone v3, Queens and
+// later) rather than project-scoped ones, for operator tooling. It takes
+// precedence over the project scope. It also selects v3 authentication.
+func WithSystemScope(scope string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		if scope != SystemScopeAll {
+			return fmt.Errorf("keystone: unsupported system scope %q", scope)
+		}
+		kClient.systemScope = scope
+		kClient.isv3Client = true
+		return nil
+	}
+}
+
+// WithInterface selects the interface (InterfacePublic, InterfaceInternal
+// or InterfaceAdmin) of the endpoints returned by Endpoint when none is
+// specified. The default is InterfacePublic.
+func WithInterface(iface string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		iface = strings.TrimSuffix(iface, "URL")
+		switch iface {
+		case InterfacePublic, InterfaceInternal, InterfaceAdmin:
+		default:
+			return fmt.Errorf("keystone: invalid interface %q", iface)
+		}
+		kClient.iface = iface
+		return nil
+	}
+}
+
+// WithUserDomain sets the domain of the user (keystone v3).
+func WithUserDomain(domain string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osDomainName = domain
+		return nil
+	}
+}
+
+// WithUserDomainID sets the domain of the user by id (keystone v3).
+func WithUserDomainID(domainID string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osDomainID = domainID
+		return nil
+	}
+}
+
+// WithProjectDomainID sets the domain of the project scope by id
+// (keystone v3).
+func WithProjectDomainID(domainID string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.osProjectDomainID = domainID
+		return nil
+	}
+}
+
+// WithDomain sets the default domain: it is used as the user domain and as
+// the project domain unless these are set explicitly.
+func WithDomain(domain string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.defaultDomainName = domain
+		return nil
+	}
+}
+
+// WithTOTP adds the totp method to keystone v3 password authentication,
+// for users that require multi-factor authentication. provider is called
+// for each authentication request.
+func WithTOTP(provider PasscodeProvider) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.passcode = provider
+		return nil
+	}
+}
+
+// WithRegion selects the region of the endpoints returned by Endpoint.
+// Keystone tokens are not scoped to a region; the region only applies to
+// the service catalog.
+func WithRegion(region string) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.region = region
+		return nil
+	}
+}
+
+// WithIdentityV3 selects keystone v3 authentication.
+func WithIdentityV3() KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.isv3Client = true
+		return nil
+	}
+}
+
+// keystoneTLSFiles holds the TLS configuration set by WithCertificates.
+type keystoneTLSFiles struct {
+	caFile   string
+	keyFile  string
+	certFile string
+	insecure bool
+}
+
+// WithCertificates configures TLS for the keystone endpoint: the auth URL
+// is switched to https and the client uses a new transport with the given
+// CA and client certificate. It can't be combined with WithHTTPClient,
+// whose transport it would replace.
+func WithCertificates(caFile, keyFile, certFile string, insecure bool) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.tlsFiles = &keystoneTLSFiles{caFile, keyFile, certFile, insecure}
+		return nil
+	}
+}
+
+// WithHTTPClient replaces the http.Client used to talk to keystone. The
+// client and its transport are used as is: TLS must be configured on the
+// transport, and WithCertificates is rejected.
+func WithHTTPClient(httpClient *http.Client) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTokenCache enables the reuse of tokens between process runs.
+func WithTokenCache(cache TokenCache) KeystoneOption {
+	return func(kClient *KeystoneClient) error {
+		kClient.tokenCache = cache
+		return nil
+	}
+}
+
+// NewKeystoneClientWithOptions allocates and initializes a KeystoneClient.
+//
+//	keystone, err := contrail.NewKeystoneClientWithOptions(
+//		contrail.WithAuthURL("https://keystone:5000"),
+//		contrail.WithCredentials("admin", "secret"),
+//		contrail.WithDomain("Default"),
+//		contrail.WithProjectScope("admin", ""),
+//	)
+//
+// Unlike NewKeystoneClient, the server certificate is verified unless
+// WithCertificates is given with insecure set, and the auth URL is used
+// as is.
+func NewKeystoneClientWithOptions(opts ...KeystoneOption) (*KeystoneClient, error) {
+	defaultClient := &http.Client{Timeout: DefaultTimeout}
+	kClient := &KeystoneClient{
+		httpClient: defaultClient,
+	}
+	for _, opt := range opts {
+		if err := opt(kClient); err != nil {
+			return nil, err
+		}
+	}
+	if kClient.osAuthURL == "" {
+		return nil, fmt.Errorf("keystone: auth URL not specified")
+	}
+	if files := kClient.tlsFiles; files != nil {
+		if kClient.httpClient != defaultClient {
+			return nil, fmt.Errorf(
+				"keystone: WithCertificates can't be used with WithHTTPClient")
+		}
+		err := kClient.AddEncryption(
+			files.caFile, files.keyFile, files.certFile, files.insecure)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The default transport verifies the server certificate.
+	kClient.tlsConfigured = true
+	if kClient.defaultDomainName != "" {
+		if kClient.osDomainName == "" && kClient.osDomainID == "" {
+			kClient.osDomainName = kClient.defaultDomainName
+		}
+		if kClient.osProjectDomainName == "" && kClient.osProjectDomainID == "" {
+			kClient.osProjectDomainName = kClient.defaultDomainName
+		}
+	}
+	return kClient, nil
+}
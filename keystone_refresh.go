@@ -0,0 +1,197 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Retry intervals of the background token refresh after a failure.
+const (
+	minRefreshRetry = time.Second
+	maxRefreshRetry = time.Minute
+)
+
+// backgroundRefresh is the state of the goroutine started by
+// StartBackgroundRefresh.
+type backgroundRefresh struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// refreshTime returns the time at which the current token reaches the
+// refresh threshold of the request path (half of its lifetime) and the
+// lifetime itself. Must be called with the mutex held.
+func (kClient *KeepaliveKeystoneClient) refreshTime() (time.Time, time.Duration, error) {
+	issuedAt, err := time.Parse(time.RFC3339, kClient.issuedAt)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, kClient.expiresAt)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	lifetime := expiresAt.Sub(issuedAt)
+	return issuedAt.Add(lifetime / 2), lifetime, nil
+}
+
+// nextRefresh returns the delay until the background refresh: up to a
+// tenth of the token lifetime before the request path would refresh it,
+// so that clients started together don't authenticate at the same time.
+func (kClient *KeepaliveKeystoneClient) nextRefresh() (time.Duration, error) {
+	kClient.mutex.Lock()
+	threshold, lifetime, err := kClient.refreshTime()
+	kClient.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	jitter := time.Duration(rand.Int63n(int64(lifetime/10) + 1))
+	return time.Until(threshold.Add(-jitter)), nil
+}
+
+// credentials returns a client with the settings of kClient and no token.
+// Must be called with the mutex held.
+func (kClient *KeystoneClient) credentials() *KeystoneClient {
+	return &KeystoneClient{
+		osAuthURL:           kClient.osAuthURL,
+		osTenantName:        kClient.osTenantName,
+		osUsername:          kClient.osUsername,
+		osPassword:          kClient.osPassword,
+		osAdminToken:        kClient.osAdminToken,
+		osDomainName:        kClient.osDomainName,
+		osProjectName:       kClient.osProjectName,
+		osProjectDomainName: kClient.osProjectDomainName,
+		osDomainID:          kClient.osDomainID,
+		osProjectDomainID:   kClient.osProjectDomainID,
+		defaultDomainName:   kClient.defaultDomainName,
+		tlsFiles:            kClient.tlsFiles,
+		httpClient:          kClient.httpClient,
+		isv3Client:          kClient.isv3Client,
+		tokenCache:          kClient.tokenCache,
+		region:              kClient.region,
+		passcode:            kClient.passcode,
+		systemScope:         kClient.systemScope,
+		iface:               kClient.iface,
+		tlsConfigured:       kClient.tlsConfigured,
+	}
+}
+
+// refresh obtains a new token. The request is made without holding the
+// mutex: requests keep using the current token in the meantime.
+func (kClient *KeepaliveKeystoneClient) refresh() error {
+	kClient.mutex.Lock()
+	key := kClient.tokenCacheKey()
+	fresh := kClient.credentials()
+	kClient.mutex.Unlock()
+
+	var err error
+	if fresh.isv3Client {
+		err = fresh.authenticateV3()
+	} else {
+		err = fresh.authenticate()
+	}
+	if err != nil {
+		return err
+	}
+
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	kClient.osAuthURL = fresh.osAuthURL
+	kClient.httpClient = fresh.httpClient
+	kClient.tlsConfigured = fresh.tlsConfigured
+	kClient.tokenID = fresh.tokenID
+	kClient.issuedAt = fresh.issuedAt
+	kClient.expiresAt = fresh.expiresAt
+	kClient.catalog = fresh.catalog
+	kClient.tokenInfo = fresh.tokenInfo
+	kClient.storeCachedToken(key)
+	return nil
+}
+
+func (kClient *KeepaliveKeystoneClient) runRefresh(state *backgroundRefresh) {
+	defer close(state.done)
+	var retry time.Duration
+	for {
+		delay := retry
+		if retry == 0 {
+			var err error
+			if delay, err = kClient.nextRefresh(); err != nil {
+				fmt.Fprintf(os.Stderr, "WARN keystone token refresh: %v\n", err)
+				delay = maxRefreshRetry
+			}
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-state.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := kClient.refresh(); err != nil {
+			// The request path refreshes the token once it reaches the
+			// threshold, if this keeps failing.
+			fmt.Fprintf(os.Stderr, "WARN keystone token refresh: %v\n", err)
+			retry *= 2
+			if retry < minRefreshRetry {
+				retry = minRefreshRetry
+			} else if retry > maxRefreshRetry {
+				retry = maxRefreshRetry
+			}
+			continue
+		}
+		retry = 0
+	}
+}
+
+// StartBackgroundRefresh authenticates, if the client has no token yet,
+// and starts a goroutine that obtains a new token shortly before the
+// current one would be refreshed on the request path. Requests then don't
+// wait for keystone, except if the background refresh keeps failing.
+// The goroutine stops when Close is called.
+func (kClient *KeepaliveKeystoneClient) StartBackgroundRefresh() error {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	if kClient.background != nil {
+		return nil
+	}
+	if kClient.tokenID == "" {
+		key := kClient.tokenCacheKey()
+		if !kClient.loadCachedToken(key) {
+			var err error
+			if kClient.isv3Client {
+				err = kClient.authenticateV3()
+			} else {
+				err = kClient.authenticate()
+			}
+			if err != nil {
+				return err
+			}
+			kClient.storeCachedToken(key)
+		}
+	}
+	kClient.background = &backgroundRefresh{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go kClient.runRefresh(kClient.background)
+	return nil
+}
+
+// Close stops the background refresh, if it was started. The client
+// remains usable; tokens are then refreshed on the request path.
+func (kClient *KeepaliveKeystoneClient) Close() error {
+	kClient.mutex.Lock()
+	state := kClient.background
+	kClient.background = nil
+	kClient.mutex.Unlock()
+	if state != nil {
+		close(state.stop)
+		<-state.done
+	}
+	return nil
+}
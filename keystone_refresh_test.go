@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeystoneBackgroundRefresh(t *testing.T) {
+	handler := &keystoneV3Handler{lifetime: 4 * time.Second}
+	authenticated := make(chan int, 10)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r)
+			authenticated <- handler.count
+		}))
+	defer server.Close()
+
+	keystone := NewKeepaliveKeystoneClient(server.URL, "", "admin", "secret", "", "Default")
+	keystone.isv3Client = true
+	// Plain HTTP: don't switch the auth URL to https.
+	keystone.tlsConfigured = true
+	if err := keystone.StartBackgroundRefresh(); err != nil {
+		t.Fatal(err)
+	}
+	<-authenticated
+	select {
+	case count := <-authenticated:
+		if count != 2 {
+			t.Errorf("unexpected authentication %d", count)
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("token not refreshed")
+	}
+
+	// The request path uses the refreshed token, once stored.
+	var token string
+	for i := 0; i < 100 && token != "token-2"; i++ {
+		time.Sleep(10 * time.Millisecond)
+		req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+		if err := keystone.AddAuthentication(req); err != nil {
+			t.Fatal(err)
+		}
+		token = req.Header.Get("X-Auth-Token")
+	}
+	if token != "token-2" {
+		t.Errorf("unexpected token %q", token)
+	}
+	select {
+	case <-authenticated:
+		t.Error("token refreshed on the request path")
+	default:
+	}
+
+	keystone.Close()
+	select {
+	case <-authenticated:
+		t.Error("token refreshed after Close")
+	case <-time.After(2500 * time.Millisecond):
+	}
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// TokenRevoker is implemented by the authenticators that can revoke the
+// token they hold.
+type TokenRevoker interface {
+	RevokeToken() error
+}
+
+// RevokeToken invalidates the current token in keystone (v3 identity
+// API), and removes it from the token cache. The client obtains a new
+// token at its next request. A token that keystone no longer knows
+// (e.g. expired) is not an error.
+func (kClient *KeystoneClient) RevokeToken() error {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	if kClient.tokenID == "" {
+		return nil
+	}
+	if !kClient.isv3Client {
+		return fmt.Errorf("Token revocation requires the keystone v3 API")
+	}
+	url := kClient.osAuthURL
+	if url == "" {
+		return fmt.Errorf("keystone: auth URL not specified")
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	url += "v3/auth/tokens"
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Auth-Token", kClient.tokenID)
+	req.Header.Set("X-Subject-Token", kClient.tokenID)
+	resp, err := kClient.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent &&
+		resp.StatusCode != http.StatusOK &&
+		resp.StatusCode != http.StatusNotFound {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	key := kClient.tokenCacheKey()
+	kClient.tokenID = ""
+	kClient.issuedAt = ""
+	kClient.expiresAt = ""
+	kClient.tokenInfo = nil
+	// An empty token is not usable.
+	kClient.storeCachedToken(key)
+	return nil
+}
+
+// SetRevokeTokenOnClose controls whether Close revokes the token of the
+// authenticator, if it implements TokenRevoker (e.g. a KeystoneClient),
+// so that short-lived programs don't leave valid tokens behind. The
+// token is not revoked by default: it may be shared through a token
+// cache or with other clients.
+func (c *Client) SetRevokeTokenOnClose(enabled bool) {
+	c.revokeTokenOnClose = enabled
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRevokeTokenOnClose(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	var revoked []string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "DELETE" && r.URL.Path == "/v3/auth/tokens" {
+				revoked = append(revoked, r.Header.Get("X-Subject-Token"))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}))
+	defer server.Close()
+
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+		WithIdentityV3(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystone.tlsConfigured = true
+	if err := keystone.RevokeToken(); err != nil || len(revoked) != 0 {
+		t.Errorf("revoked %v without a token: %v", revoked, err)
+	}
+	if err := keystone.AuthenticateV3(); err != nil {
+		t.Fatal(err)
+	}
+
+	apiServer, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer apiServer.Close()
+	client.SetAuthenticator(keystone)
+	client.SetRevokeTokenOnClose(true)
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 1 || revoked[0] != "token-1" {
+		t.Errorf("unexpected revocations %v", revoked)
+	}
+
+	// The next request obtains a new token.
+	req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+	if err := keystone.AddAuthentication(req); err != nil {
+		t.Fatal(err)
+	}
+	if token := req.Header.Get("X-Auth-Token"); token != "token-2" {
+		t.Errorf("unexpected token %q", token)
+	}
+}
+
+func TestRevokeTokenNoAuthURL(t *testing.T) {
+	keystone := &KeystoneClient{tokenID: "token-1", isv3Client: true}
+	if err := keystone.RevokeToken(); err == nil {
+		t.Error("token revoked without an auth URL")
+	}
+}
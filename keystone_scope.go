@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+// Rescope returns a KeystoneClient holding a token scoped to another
+// project. The token of kClient (e.g. the one of an admin service
+// account) is exchanged with the keystone v3 token method, so that
+// requests are attributed to the user of kClient with the roles it has
+// on the target project.
+//
+// The scoped token doesn't outlive the token it was obtained from and
+// is not refreshed: call Rescope again once it expires.
+func (kClient *KeystoneClient) Rescope(project, projectDomain string) (
+	*KeystoneClient, error) {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	if kClient.tokenID == "" {
+		var err error
+		if kClient.isv3Client {
+			err = kClient.authenticateV3()
+		} else {
+			err = kClient.authenticate()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	scoped := &KeystoneClient{
+		osAuthURL:           kClient.osAuthURL,
+		osAdminToken:        kClient.tokenID,
+		osProjectName:       project,
+		osProjectDomainName: projectDomain,
+		httpClient:          kClient.httpClient,
+		tlsConfigured:       kClient.tlsConfigured,
+		region:              kClient.region,
+		iface:               kClient.iface,
+		isv3Client:          true,
+	}
+	if err := scoped.authenticateV3(); err != nil {
+		return nil, err
+	}
+	return scoped, nil
+}
+
+// ForProject returns a copy of the client that authenticates with a token
+// scoped to project, obtained from admin with Rescope. The copy shares the
+// connections of the original client but not its read deduplication.
+//
+//	tenant, err := client.ForProject(keystone, "demo", "Default")
+func (c *Client) ForProject(admin *KeystoneClient, project, projectDomain string) (
+	*Client, error) {
+	scoped, err := admin.Rescope(project, projectDomain)
+	if err != nil {
+		return nil, err
+	}
+	clone := *c
+	clone.auth = scoped
+	if c.reads != nil {
+		clone.reads = new(readGroup)
+	}
+	return &clone, nil
+}
@@ -0,0 +1,549 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keystoneV3Handler emulates the keystone v3 token API and records the
+// last authentication request.
+type keystoneV3Handler struct {
+	request map[string]interface{}
+	count   int
+	token   string
+	// catalog is the JSON service catalog returned with the token.
+	catalog string
+	// extra holds additional JSON members of the token.
+	extra string
+	// lifetime of the tokens, an hour if not set.
+	lifetime time.Duration
+}
+
+func (h *keystoneV3Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v3/auth/tokens" || r.Method != "POST" {
+		http.NotFound(w, r)
+		return
+	}
+	h.request = nil
+	if err := json.NewDecoder(r.Body).Decode(&h.request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.count++
+	token := h.token
+	if token == "" {
+		token = fmt.Sprintf("token-%d", h.count)
+	}
+	now := time.Now().UTC()
+	lifetime := h.lifetime
+	if lifetime == 0 {
+		lifetime = time.Hour
+	}
+	w.Header().Set("X-Subject-Token", token)
+	w.WriteHeader(http.StatusCreated)
+	catalog := h.catalog
+	if catalog == "" {
+		catalog = "[]"
+	}
+	fmt.Fprintf(w, `{"token": {"issued_at": %q, "expires_at": %q, "catalog": %s%s}}`,
+		now.Format(time.RFC3339), now.Add(lifetime).Format(time.RFC3339), catalog,
+		h.extra)
+}
+
+// lookup returns the value at the given path in the recorded request.
+func (h *keystoneV3Handler) lookup(path ...string) interface{} {
+	var value interface{} = h.request
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func TestKeystoneOptions(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithDomain("Default"),
+		WithProjectScope("demo", ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+	if err := keystone.AddAuthentication(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("X-Auth-Token") != "token-1" {
+		t.Errorf("unexpected token %q", req.Header.Get("X-Auth-Token"))
+	}
+	user := []string{"auth", "identity", "password", "user"}
+	if handler.lookup(append(user, "name")...) != "admin" ||
+		handler.lookup(append(user, "domain", "name")...) != "Default" {
+		t.Errorf("unexpected user %v", handler.lookup(user...))
+	}
+	if handler.lookup("auth", "scope", "project", "name") != "demo" ||
+		handler.lookup("auth", "scope", "project", "domain", "name") != "Default" {
+		t.Errorf("unexpected scope %v", handler.lookup("auth", "scope"))
+	}
+
+	if _, err := NewKeystoneClientWithOptions(WithCredentials("a", "b")); err == nil {
+		t.Error("expected error without auth URL")
+	}
+}
+
+func TestAuthFromEnv(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for _, name := range []string{"OS_TENANT_NAME", "OS_TOKEN", "OS_DOMAIN_NAME",
+		"OS_IDENTITY_API_VERSION", "OS_CACERT", "OS_INSECURE",
+		"OS_USER_DOMAIN_ID", "OS_PROJECT_DOMAIN_ID", "OS_SYSTEM_SCOPE",
+		"OS_INTERFACE", "OS_ENDPOINT_TYPE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("OS_AUTH_URL", server.URL+"/v3")
+	t.Setenv("OS_USERNAME", "admin")
+	t.Setenv("OS_PASSWORD", "secret")
+	t.Setenv("OS_PROJECT_NAME", "demo")
+	t.Setenv("OS_USER_DOMAIN_NAME", "users")
+	t.Setenv("OS_PROJECT_DOMAIN_NAME", "projects")
+	t.Setenv("OS_REGION_NAME", "east")
+
+	keystone, err := AuthFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keystone.region != "east" {
+		t.Errorf("unexpected region %q", keystone.region)
+	}
+	req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+	if err := keystone.AddAuthentication(req); err != nil {
+		t.Fatal(err)
+	}
+	if handler.count != 1 {
+		t.Fatalf("expected one authentication request, got %d", handler.count)
+	}
+	user := []string{"auth", "identity", "password", "user"}
+	if handler.lookup(append(user, "password")...) != "secret" ||
+		handler.lookup(append(user, "domain", "name")...) != "users" {
+		t.Errorf("unexpected user %v", handler.lookup(user...))
+	}
+	if handler.lookup("auth", "scope", "project", "name") != "demo" ||
+		handler.lookup("auth", "scope", "project", "domain", "name") != "projects" {
+		t.Errorf("unexpected scope %v", handler.lookup("auth", "scope"))
+	}
+
+	// Domains identified by id.
+	t.Setenv("OS_USER_DOMAIN_NAME", "")
+	t.Setenv("OS_PROJECT_DOMAIN_NAME", "")
+	t.Setenv("OS_USER_DOMAIN_ID", "default")
+	t.Setenv("OS_PROJECT_DOMAIN_ID", "3f2c")
+	keystone, err = AuthFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keystone.AuthenticateV3(); err != nil {
+		t.Fatal(err)
+	}
+	if handler.lookup(append(user, "domain", "id")...) != "default" ||
+		handler.lookup(append(user, "domain", "name")...) != nil ||
+		handler.lookup("auth", "scope", "project", "domain", "id") != "3f2c" {
+		t.Errorf("unexpected request %v", handler.request)
+	}
+
+	// Token authentication.
+	t.Setenv("OS_TOKEN", "unscoped")
+	keystone, err = AuthFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keystone.AuthenticateV3(); err != nil {
+		t.Fatal(err)
+	}
+	methods, _ := handler.lookup("auth", "identity", "methods").([]interface{})
+	if len(methods) != 1 || methods[0] != "token" ||
+		handler.lookup("auth", "identity", "token", "id") != "unscoped" ||
+		handler.lookup("auth", "identity", "password") != nil {
+		t.Errorf("unexpected request %v", handler.request)
+	}
+
+	t.Setenv("OS_AUTH_URL", "")
+	if _, err := AuthFromEnv(); err == nil {
+		t.Error("expected error without OS_AUTH_URL")
+	}
+}
+
+func TestAuthFromCloudsYAML(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	filename := filepath.Join(t.TempDir(), "clouds.yaml")
+	content := fmt.Sprintf(`
+clouds:
+  lab:
+    auth:
+      auth_url: %s/v3
+      username: admin
+      password: secret
+      project_name: demo
+      domain_name: Default
+    identity_api_version: 3
+`, server.URL)
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OS_CLIENT_CONFIG_FILE", filename)
+
+	keystone, err := AuthFromCloudsYAML("lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+	if err := keystone.AddAuthentication(req); err != nil {
+		t.Fatal(err)
+	}
+	if handler.lookup("auth", "identity", "password", "user", "domain", "name") != "Default" ||
+		handler.lookup("auth", "scope", "project", "domain", "name") != "Default" {
+		t.Errorf("unexpected request %v", handler.request)
+	}
+
+	if _, err := AuthFromCloudsYAML("missing"); err == nil {
+		t.Error("expected error for unknown cloud")
+	}
+}
+
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	count int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestKeystoneV3HTTPClient(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	transport := new(countingTransport)
+	httpClient := &http.Client{Transport: transport}
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+		WithHTTPClient(httpClient),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := keystone.AuthenticateV3(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if httpClient.Transport != transport || transport.count != 2 {
+		t.Errorf("http client not used as is: %d requests", transport.count)
+	}
+}
+
+func TestKeystoneV3HTTPClientCertificates(t *testing.T) {
+	_, err := NewKeystoneClientWithOptions(
+		WithAuthURL("https://localhost:5000"),
+		WithCredentials("admin", "secret"),
+		WithHTTPClient(&http.Client{}),
+		WithCertificates("", "", "", true),
+	)
+	if err == nil {
+		t.Error("WithCertificates accepted with WithHTTPClient")
+	}
+}
+
+func TestKeystoneOptionsVerifyTLS(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keystone.AuthenticateV3(); err == nil {
+		t.Error("self-signed server certificate accepted")
+	}
+
+	keystone, err = NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+		WithCertificates("", "", "", true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keystone.AuthenticateV3(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeystoneConcurrentAuthentication(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := make([]string, 8)
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+			if err := keystone.AddAuthentication(req); err != nil {
+				t.Error(err)
+			}
+			tokens[i] = req.Header.Get("X-Auth-Token")
+		}(i)
+	}
+	wg.Wait()
+	if handler.count != 1 {
+		t.Errorf("expected 1 authentication, got %d", handler.count)
+	}
+	for _, token := range tokens {
+		if token != "token-1" {
+			t.Errorf("unexpected tokens %v", tokens)
+			break
+		}
+	}
+}
+
+func TestKeystoneEndpointRegion(t *testing.T) {
+	handler := &keystoneV3Handler{catalog: `[
+		{"type": "opencontrail", "name": "contrail", "endpoints": [
+			{"interface": "public", "region": "east", "region_id": "east", "url": "http://east:8082"},
+			{"interface": "internal", "region": "east", "region_id": "east", "url": "http://east-int:8082"},
+			{"interface": "public", "region": "west", "region_id": "west", "url": "http://west:8082"}]}]`}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	endpoint := func(region, iface string) (string, error) {
+		options := []KeystoneOption{
+			WithAuthURL(server.URL),
+			WithCredentials("admin", "secret"),
+			WithProjectScope("demo", "Default"),
+		}
+		if region != "" {
+			options = append(options, WithRegion(region))
+		}
+		keystone, err := NewKeystoneClientWithOptions(options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keystone.Endpoint("opencontrail", iface)
+	}
+	if url, err := endpoint("west", InterfacePublic); err != nil || url != "http://west:8082" {
+		t.Errorf("west: %s, %v", url, err)
+	}
+	if url, err := endpoint("east", InterfaceInternal); err != nil || url != "http://east-int:8082" {
+		t.Errorf("east: %s, %v", url, err)
+	}
+	if _, err := endpoint("west", InterfaceInternal); err == nil {
+		t.Error("expected error for missing endpoint")
+	}
+	if _, err := endpoint("", InterfacePublic); err == nil {
+		t.Error("expected error for ambiguous region")
+	}
+}
+
+func TestKeystoneSystemScope(t *testing.T) {
+	handler := &keystoneV3Handler{
+		extra: `, "system": {"all": true}`,
+		catalog: `[
+			{"type": "identity", "name": "keystone", "endpoints": [
+				{"interface": "public", "region_id": "east", "url": "http://keystone:5000"},
+				{"interface": "admin", "region_id": "east", "url": "http://keystone:35357"}]}]`,
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	if _, err := NewKeystoneClientWithOptions(WithAuthURL(server.URL),
+		WithSystemScope("domain")); err == nil {
+		t.Error("expected error for unsupported system scope")
+	}
+	if _, err := NewKeystoneClientWithOptions(WithAuthURL(server.URL),
+		WithInterface("private")); err == nil {
+		t.Error("expected error for invalid interface")
+	}
+
+	for _, name := range []string{"OS_TOKEN", "OS_DOMAIN_NAME",
+		"OS_IDENTITY_API_VERSION", "OS_CACERT", "OS_INSECURE", "OS_REGION_NAME",
+		"OS_USER_DOMAIN_ID", "OS_PROJECT_DOMAIN_ID", "OS_PROJECT_DOMAIN_NAME",
+		"OS_ENDPOINT_TYPE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("OS_AUTH_URL", server.URL)
+	t.Setenv("OS_USERNAME", "admin")
+	t.Setenv("OS_PASSWORD", "secret")
+	t.Setenv("OS_USER_DOMAIN_NAME", "Default")
+	t.Setenv("OS_PROJECT_NAME", "admin")
+	t.Setenv("OS_SYSTEM_SCOPE", "all")
+	t.Setenv("OS_INTERFACE", "admin")
+	keystone, err := AuthFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := keystone.Endpoint("identity", "")
+	if err != nil || url != "http://keystone:35357" {
+		t.Errorf("admin endpoint: %s, %v", url, err)
+	}
+	if handler.lookup("auth", "scope", "system", "all") != true ||
+		handler.lookup("auth", "scope", "project") != nil {
+		t.Errorf("unexpected scope %v", handler.lookup("auth", "scope"))
+	}
+	if info := keystone.TokenInfo(); info == nil || !info.SystemScope {
+		t.Errorf("unexpected token info %+v", info)
+	}
+	if url, err := keystone.Endpoint("identity", InterfacePublic); err != nil ||
+		url != "http://keystone:5000" {
+		t.Errorf("public endpoint: %s, %v", url, err)
+	}
+}
+
+func TestKeystoneTOTP(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	passcodes := []string{"123456", "654321"}
+	keystone, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithUserDomain("users"),
+		WithProjectScope("demo", "Default"),
+		WithHTTPClient(new(http.Client)),
+		WithTOTP(func() (string, error) {
+			passcode := passcodes[0]
+			passcodes = passcodes[1:]
+			return passcode, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"123456", "654321"} {
+		if err := keystone.AuthenticateV3(); err != nil {
+			t.Fatal(err)
+		}
+		identity := []string{"auth", "identity"}
+		methods := handler.lookup(append(identity, "methods")...)
+		if fmt.Sprint(methods) != "[password totp]" {
+			t.Errorf("unexpected methods %v", methods)
+		}
+		totp := append(identity, "totp", "user")
+		if handler.lookup(append(totp, "passcode")...) != expected ||
+			handler.lookup(append(totp, "name")...) != "admin" ||
+			handler.lookup(append(totp, "domain", "name")...) != "users" {
+			t.Errorf("unexpected request %v", handler.request)
+		}
+	}
+
+	failing, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("demo", "Default"),
+		WithTOTP(func() (string, error) {
+			return "", fmt.Errorf("no device")
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := failing.AuthenticateV3(); err == nil {
+		t.Error("expected passcode error")
+	}
+	if handler.count != 2 {
+		t.Errorf("unexpected authentication count %d", handler.count)
+	}
+}
+
+func TestKeystoneRescope(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	admin, err := NewKeystoneClientWithOptions(
+		WithAuthURL(server.URL),
+		WithCredentials("admin", "secret"),
+		WithProjectScope("admin", "Default"),
+		WithHTTPClient(new(http.Client)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	api, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("X-Auth-Token"))
+		fmt.Fprint(w, `{"href": "", "links": []}`)
+	})
+	defer api.Close()
+	client.SetAuthenticator(admin)
+	client.SetReadDeduplication(true)
+
+	tenant, err := client.ForProject(admin, "demo", "projects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handler.count != 2 {
+		t.Fatalf("expected 2 authentications, got %d", handler.count)
+	}
+	identity := []string{"auth", "identity"}
+	if fmt.Sprint(handler.lookup(append(identity, "methods")...)) != "[token]" ||
+		handler.lookup(append(identity, "token", "id")...) != "token-1" ||
+		handler.lookup("auth", "scope", "project", "name") != "demo" ||
+		handler.lookup("auth", "scope", "project", "domain", "name") != "projects" {
+		t.Errorf("unexpected rescope request %v", handler.request)
+	}
+	if tenant.reads == client.reads {
+		t.Error("read deduplication shared across projects")
+	}
+	tenant.DoJSON(context.Background(), "GET", "", nil, nil)
+	client.DoJSON(context.Background(), "GET", "", nil, nil)
+	if fmt.Sprint(tokens) != "[token-2 token-1]" {
+		t.Errorf("unexpected tokens %v", tokens)
+	}
+}
@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned by the requests made after Close, and by
+// those that Close interrupted.
+var ErrClientClosed = errors.New("Client closed")
+
+// Flusher is implemented by the audit sinks that buffer records.
+type Flusher interface {
+	Flush() error
+}
+
+// lifetime tracks the requests in flight so that Close can cancel them.
+// It is shared with the copies of the client made by WithTimeout.
+type lifetime struct {
+	mutex    sync.Mutex
+	closed   bool
+	done     chan struct{}
+	next     int
+	inflight map[int]context.CancelFunc
+}
+
+func newLifetime() *lifetime {
+	return &lifetime{
+		done:     make(chan struct{}),
+		inflight: make(map[int]context.CancelFunc),
+	}
+}
+
+// begin returns the context of a new request and the function that
+// releases it, once its response has been read.
+func (l *lifetime) begin(ctx context.Context) (context.Context, func(), error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return nil, nil, ErrClientClosed
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	id := l.next
+	l.next++
+	l.inflight[id] = cancel
+	release := func() {
+		l.mutex.Lock()
+		delete(l.inflight, id)
+		l.mutex.Unlock()
+		cancel()
+	}
+	return ctx, release, nil
+}
+
+func (l *lifetime) isClosed() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.closed
+}
+
+// close cancels the requests in flight. It returns false if it was
+// already closed.
+func (l *lifetime) close() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return false
+	}
+	l.closed = true
+	close(l.done)
+	for id, cancel := range l.inflight {
+		cancel()
+		delete(l.inflight, id)
+	}
+	return true
+}
+
+// releaseBody releases the request when the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// track sends the request within the lifetime of the client.
+func (c *Client) track(req *http.Request) (*http.Response, error) {
+	ctx, release, err := c.lifetime.begin(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req.WithContext(ctx))
+	if err != nil {
+		release()
+		if c.lifetime.isClosed() {
+			return nil, ErrClientClosed
+		}
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// Done returns a channel that is closed when the client is closed. The
+// watches and other background tasks started on the client stop then.
+func (c *Client) Done() <-chan struct{} {
+	return c.lifetime.done
+}
+
+// Close releases the resources of the client: the requests in flight are
+// cancelled and the requests made afterwards fail with ErrClientClosed;
+// watches and background tasks watching Done stop; the authenticator is
+// closed, if it implements io.Closer (e.g. to stop refreshing its token),
+// and its token revoked if enabled with SetRevokeTokenOnClose; the audit
+// sink is flushed, if it implements Flusher; and idle connections are
+// closed.
+//
+// Close applies to the copies of the client made by WithTimeout. Calling
+// it again has no effect.
+func (c *Client) Close() error {
+	if !c.lifetime.close() {
+		return nil
+	}
+	var result error
+	if closer, ok := c.auth.(io.Closer); ok {
+		result = closer.Close()
+	}
+	if revoker, ok := c.auth.(TokenRevoker); ok && c.revokeTokenOnClose {
+		if err := revoker.RevokeToken(); err != nil && result == nil {
+			result = err
+		}
+	}
+	if flusher, ok := c.audit.(Flusher); ok {
+		if err := flusher.Flush(); err != nil && result == nil {
+			result = err
+		}
+	}
+	c.httpClient.CloseIdleConnections()
+	return result
+}
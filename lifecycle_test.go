@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type flushingAuditSink struct {
+	testAuditSink
+	flushed int
+}
+
+func (s *flushingAuditSink) Flush() error {
+	s.flushed++
+	return nil
+}
+
+type closingAuthenticator struct {
+	NopAuthenticator
+	closed int
+}
+
+func (a *closingAuthenticator) Close() error {
+	a.closed++
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	started := make(chan struct{})
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") == "" {
+			w.Write([]byte(`{"test-network": {"fq_name": ["p", "n"], "uuid": "n", "name": "n"}}`))
+			return
+		}
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	defer server.Close()
+	auth := new(closingAuthenticator)
+	client.SetAuthenticator(auth)
+	sink := new(flushingAuditSink)
+	client.SetAuditSink(sink)
+
+	if _, err := client.FindByUuid("test-network", "n"); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.lifetime.inflight) != 0 {
+		t.Errorf("%d requests not released", len(client.lifetime.inflight))
+	}
+
+	result := make(chan error)
+	go func() {
+		result <- client.DoJSON(context.Background(), "GET",
+			"/test-network/n?block=1", nil, nil)
+	}()
+	<-started
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-result:
+		if err != ErrClientClosed {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request in flight not cancelled")
+	}
+	select {
+	case <-client.Done():
+	default:
+		t.Error("Done not closed")
+	}
+	if _, err := client.FindByUuid("test-network", "n"); err != ErrClientClosed {
+		t.Errorf("unexpected error %v", err)
+	}
+	client.Close()
+	if auth.closed != 1 || sink.flushed != 1 {
+		t.Errorf("authenticator closed %d times, sink flushed %d times",
+			auth.closed, sink.flushed)
+	}
+}
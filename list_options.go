@@ -0,0 +1,397 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ListOptions are the parameters of a list request.
+type ListOptions struct {
+	// ParentIDs restricts the list to the children of these objects.
+	ParentIDs []string
+	// BackRefIDs restricts the list to the objects that refer to these
+	// objects.
+	BackRefIDs []string
+	// Uuids restricts the list to the given objects.
+	Uuids []string
+	// Filters restricts the list to the objects whose property has one
+	// of the values.
+	Filters map[string][]interface{}
+	// Fields are the properties and references included in detailed
+	// lists.
+	Fields []string
+	// Shared includes the objects other tenants share with the caller.
+	Shared bool
+	// ExcludeHrefs omits the href of each object from the response.
+	ExcludeHrefs bool
+	// PageLimit is the maximum number of objects per response, and
+	// PageMarker the position to start from (the marker returned by the
+	// previous page).
+	PageLimit  int
+	PageMarker string
+	// Sort is the order of the results (see ListSort).
+	Sort string
+	// Generic decodes detailed lists as GenericObjects (see ListGeneric).
+	Generic bool
+}
+
+// ListOption sets a list parameter.
+type ListOption func(*ListOptions)
+
+// NewListOptions returns the options set by opts.
+func NewListOptions(opts ...ListOption) *ListOptions {
+	options := new(ListOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// ListParent restricts a list to the children of the given objects.
+func ListParent(uuids ...string) ListOption {
+	return func(o *ListOptions) {
+		o.ParentIDs = append(o.ParentIDs, uuids...)
+	}
+}
+
+// ListBackRef restricts a list to the objects that refer to the given
+// objects.
+func ListBackRef(uuids ...string) ListOption {
+	return func(o *ListOptions) {
+		o.BackRefIDs = append(o.BackRefIDs, uuids...)
+	}
+}
+
+// ListUuids restricts a list to the given objects.
+func ListUuids(uuids ...string) ListOption {
+	return func(o *ListOptions) {
+		o.Uuids = append(o.Uuids, uuids...)
+	}
+}
+
+// ListFilter restricts a list to the objects whose field has one of the
+// values, e.g. ListFilter("display_name", "web").
+func ListFilter(field string, values ...interface{}) ListOption {
+	return func(o *ListOptions) {
+		if o.Filters == nil {
+			o.Filters = make(map[string][]interface{})
+		}
+		o.Filters[field] = append(o.Filters[field], values...)
+	}
+}
+
+// ListFields selects the fields returned by detailed lists.
+func ListFields(fields ...string) ListOption {
+	return func(o *ListOptions) {
+		o.Fields = append(o.Fields, fields...)
+	}
+}
+
+// ListGeneric decodes the objects of a detailed list as GenericObjects,
+// whatever their registered type. The fields selected with ListFields are
+// then read from the response only: those absent from it are empty rather
+// than read from the API server one object at a time.
+func ListGeneric() ListOption {
+	return func(o *ListOptions) {
+		o.Generic = true
+	}
+}
+
+// ListShared includes shared objects.
+func ListShared() ListOption {
+	return func(o *ListOptions) {
+		o.Shared = true
+	}
+}
+
+// ListExcludeHrefs omits hrefs from the response.
+func ListExcludeHrefs() ListOption {
+	return func(o *ListOptions) {
+		o.ExcludeHrefs = true
+	}
+}
+
+// ListPageLimit sets the number of objects per response.
+func ListPageLimit(limit int) ListOption {
+	return func(o *ListOptions) {
+		o.PageLimit = limit
+	}
+}
+
+// ListPageMarker sets the position of the first object of the page.
+func ListPageMarker(marker string) ListOption {
+	return func(o *ListOptions) {
+		o.PageMarker = marker
+	}
+}
+
+// listOptionValues encodes the options as query parameters, combined with the
+// client defaults.
+func (c *Client) listOptionValues(o *ListOptions) (url.Values, error) {
+	switch o.Sort {
+	case "", SortByName, SortByUuid, SortByCreated:
+	default:
+		return nil, fmt.Errorf("Invalid sort order %q", o.Sort)
+	}
+	values := make(url.Values)
+	if len(o.ParentIDs) > 0 {
+		values.Add("parent_id", strings.Join(o.ParentIDs, ","))
+	}
+	if len(o.BackRefIDs) > 0 {
+		values.Add("back_ref_id", strings.Join(o.BackRefIDs, ","))
+	}
+	if len(o.Uuids) > 0 {
+		values.Add("obj_uuids", strings.Join(o.Uuids, ","))
+	}
+	if len(o.Filters) > 0 {
+		names := make([]string, 0, len(o.Filters))
+		for name := range o.Filters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var filters []string
+		for _, name := range names {
+			for _, value := range o.Filters[name] {
+				data, err := json.Marshal(value)
+				if err != nil {
+					return nil, fmt.Errorf("filter %s: %v", name, err)
+				}
+				filters = append(filters, name+"=="+string(data))
+			}
+		}
+		values.Add("filters", strings.Join(filters, ","))
+	}
+	for _, field := range o.Fields {
+		values.Add("fields", field)
+	}
+	if o.Shared || c.listShared {
+		values.Add("shared", "true")
+	}
+	if o.ExcludeHrefs || c.listExcludeHrefs {
+		values.Add("exclude_hrefs", "true")
+	}
+	if o.PageLimit > 0 {
+		values.Add("page_limit", strconv.Itoa(o.PageLimit))
+		if o.PageMarker != "" {
+			values.Add("page_marker", o.PageMarker)
+		}
+	}
+	return values, nil
+}
+
+func (c *Client) listURL(typename string, values url.Values) string {
+	url := fmt.Sprintf("%s/%ss", c.baseURL(), typename)
+	if len(values) > 0 {
+		url += fmt.Sprintf("?%s", values.Encode())
+	}
+	return url
+}
+
+// ListPage retrieves a page of the identifiers of the objects that match
+// the options, and the marker of the next page; the marker is empty after
+// the last page.
+func (c *Client) ListPage(typename string, opts ...ListOption) (
+	[]ListResult, string, error) {
+	values, err := c.listOptionValues(NewListOptions(opts...))
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := c.httpGet(c.listURL(typename, values))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", responseError(resp, body)
+	}
+
+	var m map[string]*json.RawMessage
+	err = json.Unmarshal(body, &m)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, ok := m[typename+"s"]
+	if !ok {
+		return nil, "", fmt.Errorf("No %ss in Response", typename)
+	}
+	var rlist []ListResult
+	if err := json.Unmarshal(*content, &rlist); err != nil {
+		return nil, "", err
+	}
+	var marker string
+	if raw, ok := m["marker"]; ok && raw != nil {
+		json.Unmarshal(*raw, &marker)
+	}
+	return rlist, marker, nil
+}
+
+// ListWithOptions retrieves the identifiers of the objects that match the
+// options. With a page limit, the pages are requested in sequence.
+func (c *Client) ListWithOptions(typename string, opts ...ListOption) (
+	[]ListResult, error) {
+	if NewListOptions(opts...).Sort == SortByCreated {
+		return nil, SortListResults(nil, SortByCreated)
+	}
+	var result []ListResult
+	for {
+		page, marker, err := c.ListPage(typename, opts...)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+		if marker == "" || len(page) == 0 {
+			break
+		}
+		opts = append(opts[:len(opts):len(opts)], ListPageMarker(marker))
+	}
+	if key := NewListOptions(opts...).Sort; key != "" {
+		if err := SortListResults(result, key); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// CountWithOptions returns the number of objects that match the options.
+func (c *Client) CountWithOptions(typename string, opts ...ListOption) (
+	int, error) {
+	values, err := c.listOptionValues(NewListOptions(opts...))
+	if err != nil {
+		return 0, err
+	}
+	values.Del("page_limit")
+	values.Del("page_marker")
+	values.Add("count", "true")
+
+	resp, err := c.httpGet(c.listURL(typename, values))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseError(resp, body)
+	}
+
+	var m map[string]struct {
+		Count *int `json:"count"`
+	}
+	err = json.Unmarshal(body, &m)
+	if err != nil {
+		return 0, err
+	}
+	content, ok := m[typename+"s"]
+	if !ok || content.Count == nil {
+		return 0, fmt.Errorf("No %ss count in Response", typename)
+	}
+	return *content.Count, nil
+}
+
+// ListDetailWithOptions reads the objects that match the options. With a
+// page limit, the pages are requested in sequence.
+func (c *Client) ListDetailWithOptions(typename string, opts ...ListOption) (
+	[]IObject, error) {
+	var result []IObject
+	for {
+		page, marker, err := c.listDetailPage(typename, opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+		if marker == "" || len(page) == 0 {
+			break
+		}
+		opts = append(opts[:len(opts):len(opts)], ListPageMarker(marker))
+	}
+	if key := NewListOptions(opts...).Sort; key != "" {
+		if err := SortObjects(result, key); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) listDetailPage(typename string, opts []ListOption) (
+	[]IObject, string, error) {
+	options := NewListOptions(opts...)
+	values, err := c.listOptionValues(options)
+	if err != nil {
+		return nil, "", err
+	}
+	values.Add("detail", "true")
+
+	resp, err := c.httpGet(c.listURL(typename, values))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		return nil, "", responseError(resp, body)
+	}
+
+	alloc := newObjectOfType
+	if options.Generic {
+		alloc = func(typename string) IObject {
+			return NewGenericObject(typename)
+		}
+	}
+	result, marker, err := decodeListDetailPage(typename,
+		c.newDecoder(resp.Body), alloc, c.useNumber)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, obj := range result {
+		if generic, ok := obj.(*GenericObject); ok {
+			for _, field := range options.Fields {
+				generic.fetched[field] = true
+			}
+		}
+		if obj.GetHref() == "" {
+			if base, ok := obj.(interface{ setHref(string) }); ok {
+				base.setHref(fmt.Sprintf("%s/%s/%s", c.baseURL(),
+					typename, obj.GetUuid()))
+			}
+		}
+		obj.SetClient(c)
+	}
+	return result, marker, nil
+}
+
+// parentOptions returns the options of the ByParent list methods.
+func parentOptions(parentID string, fields []string) []ListOption {
+	var opts []ListOption
+	if parentID != "" {
+		opts = append(opts, ListParent(parentID))
+	}
+	if len(fields) > 0 {
+		opts = append(opts, ListFields(fields...))
+	}
+	return opts
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListOptionsQuery(t *testing.T) {
+	var query string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, `{"test-networks": [], "marker": null}`)
+	})
+	defer server.Close()
+	client.SetListExcludeHrefs(true)
+
+	_, err := client.ListWithOptions("test-network",
+		ListParent("p1", "p2"),
+		ListBackRef("ipam"),
+		ListFilter("display_name", "web", "db"),
+		ListFilter("is_shared", true),
+		ListShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "back_ref_id=ipam&exclude_hrefs=true" +
+		"&filters=display_name%3D%3D%22web%22%2Cdisplay_name%3D%3D%22db%22%2Cis_shared%3D%3Dtrue" +
+		"&parent_id=p1%2Cp2&shared=true"
+	if query != expected {
+		t.Errorf("query %s, expected %s", query, expected)
+	}
+
+	client.CountWithOptions("test-network", ListUuids("a", "b"),
+		ListPageLimit(10))
+	if query != "count=true&exclude_hrefs=true&obj_uuids=a%2Cb" {
+		t.Errorf("unexpected count query %s", query)
+	}
+}
+
+func TestListOptionsPages(t *testing.T) {
+	var queries []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		detail := r.URL.Query().Get("detail") == "true"
+		element := func(uuid string) string {
+			if detail {
+				return fmt.Sprintf(`{"test-network": {"fq_name": ["p", "%s"], "uuid": "%s", "name": "%s"}}`,
+					uuid, uuid, uuid)
+			}
+			return fmt.Sprintf(`{"fq_name": ["p", "%s"], "uuid": "%s"}`, uuid, uuid)
+		}
+		switch r.URL.Query().Get("page_marker") {
+		case "":
+			fmt.Fprintf(w, `{"test-networks": [%s, %s], "marker": "n2"}`,
+				element("n1"), element("n2"))
+		case "n2":
+			fmt.Fprintf(w, `{"test-networks": [%s], "marker": null}`,
+				element("n3"))
+		}
+	})
+	defer server.Close()
+
+	results, err := client.ListWithOptions("test-network", ListPageLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[2].Uuid != "n3" {
+		t.Errorf("unexpected results %v", results)
+	}
+	objects, err := client.ListDetailWithOptions("test-network",
+		ListPageLimit(2), ListFields("display_name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 || objects[2].GetUuid() != "n3" {
+		t.Errorf("unexpected objects %v", objects)
+	}
+	expected := []string{
+		"page_limit=2",
+		"page_limit=2&page_marker=n2",
+		"detail=true&fields=display_name&page_limit=2",
+		"detail=true&fields=display_name&page_limit=2&page_marker=n2",
+	}
+	if fmt.Sprint(queries) != fmt.Sprint(expected) {
+		t.Errorf("queries %v, expected %v", queries, expected)
+	}
+
+	page, marker, err := client.ListPage("test-network", ListPageLimit(2))
+	if err != nil || len(page) != 2 || marker != "n2" {
+		t.Errorf("unexpected page %v %q %v", page, marker, err)
+	}
+}
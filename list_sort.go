@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Sort orders of list results.
+const (
+	SortByName    = "name"
+	SortByUuid    = "uuid"
+	SortByCreated = "created"
+)
+
+// ListSort sorts the results of ListWithOptions and ListDetailWithOptions
+// by fq_name, uuid or creation time, so that they are the same from one
+// run to the next. The API server returns the objects in the order of its
+// database, so the results are sorted by the client once all the pages
+// are read. Sorting by creation time requires the id_perms property, i.e.
+// a detailed list.
+func ListSort(key string) ListOption {
+	return func(o *ListOptions) {
+		o.Sort = key
+	}
+}
+
+func compareFQName(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// SortListResults sorts results by SortByName or SortByUuid.
+func SortListResults(results []ListResult, key string) error {
+	switch key {
+	case SortByName:
+		sort.SliceStable(results, func(i, j int) bool {
+			if c := compareFQName(results[i].Fq_name, results[j].Fq_name); c != 0 {
+				return c < 0
+			}
+			return results[i].Uuid < results[j].Uuid
+		})
+	case SortByUuid:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Uuid < results[j].Uuid
+		})
+	case SortByCreated:
+		return fmt.Errorf("Sorting by creation time requires a detailed list")
+	default:
+		return fmt.Errorf("Invalid sort order %q", key)
+	}
+	return nil
+}
+
+// createdTime returns the id_perms.created timestamp of obj. The API
+// server's timestamps (e.g. 2019-01-31T12:34:56.123456) sort as strings.
+func createdTime(obj IObject) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	var m struct {
+		IdPerms *struct {
+			Created string `json:"created"`
+		} `json:"id_perms"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", err
+	}
+	if m.IdPerms == nil || m.IdPerms.Created == "" {
+		return "", fmt.Errorf("%s %s: no creation time", obj.GetType(),
+			obj.GetUuid())
+	}
+	return m.IdPerms.Created, nil
+}
+
+// SortObjects sorts objects by SortByName, SortByUuid or SortByCreated.
+// Objects created at the same time are sorted by uuid.
+func SortObjects(objects []IObject, key string) error {
+	switch key {
+	case SortByName:
+		sort.SliceStable(objects, func(i, j int) bool {
+			c := compareFQName(objects[i].GetFQName(), objects[j].GetFQName())
+			if c != 0 {
+				return c < 0
+			}
+			return objects[i].GetUuid() < objects[j].GetUuid()
+		})
+	case SortByUuid:
+		sort.SliceStable(objects, func(i, j int) bool {
+			return objects[i].GetUuid() < objects[j].GetUuid()
+		})
+	case SortByCreated:
+		created := make(map[IObject]string, len(objects))
+		for _, obj := range objects {
+			timestamp, err := createdTime(obj)
+			if err != nil {
+				return err
+			}
+			created[obj] = timestamp
+		}
+		sort.SliceStable(objects, func(i, j int) bool {
+			x, y := created[objects[i]], created[objects[j]]
+			if x != y {
+				return x < y
+			}
+			return objects[i].GetUuid() < objects[j].GetUuid()
+		})
+	default:
+		return fmt.Errorf("Invalid sort order %q", key)
+	}
+	return nil
+}
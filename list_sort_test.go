@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListSort(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("detail") != "true" {
+			fmt.Fprint(w, `{"test-networks": [
+				{"fq_name": ["p", "b"], "uuid": "u1"},
+				{"fq_name": ["p", "a"], "uuid": "u3"},
+				{"fq_name": ["o", "z"], "uuid": "u2"}]}`)
+			return
+		}
+		element := func(name, uuid, created string) string {
+			return fmt.Sprintf(`{"test-network": {"fq_name": ["p", "%s"], "uuid": "%s", "name": "%s", "id_perms": {"created": "%s"}}}`,
+				name, uuid, name, created)
+		}
+		fmt.Fprintf(w, `{"test-networks": [%s, %s, %s]}`,
+			element("b", "u1", "2019-01-02T00:00:00.000001"),
+			element("a", "u3", "2019-01-01T00:00:00.000000"),
+			element("c", "u2", "2019-01-02T00:00:00.000001"))
+	})
+	defer server.Close()
+
+	results, err := client.ListWithOptions("test-network", ListSort(SortByName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Uuid != "u2" || results[1].Uuid != "u3" || results[2].Uuid != "u1" {
+		t.Errorf("unexpected order by name %v", results)
+	}
+	results, err = client.ListWithOptions("test-network", ListSort(SortByUuid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Uuid != "u1" || results[1].Uuid != "u2" || results[2].Uuid != "u3" {
+		t.Errorf("unexpected order by uuid %v", results)
+	}
+	if _, err := client.ListWithOptions("test-network", ListSort(SortByCreated)); err == nil {
+		t.Error("expected an error sorting identifiers by creation time")
+	}
+	if _, err := client.ListWithOptions("test-network", ListSort("size")); err == nil {
+		t.Error("expected an error for an invalid sort order")
+	}
+
+	objects, err := client.ListDetailWithOptions("test-network",
+		ListSort(SortByCreated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uuids []string
+	for _, obj := range objects {
+		uuids = append(uuids, obj.GetUuid())
+	}
+	if fmt.Sprint(uuids) != "[u3 u1 u2]" {
+		t.Errorf("unexpected order by creation time %v", uuids)
+	}
+}
@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"net/http"
+)
+
+// Handler sends a request to the API server and returns its response.
+type Handler func(*http.Request) (*http.Response, error)
+
+// Middleware decorates a Handler. It may inspect or modify the request,
+// short-circuit it, or process the response returned by next.
+//
+//	client.Use(func(next contrail.Handler) contrail.Handler {
+//		return func(req *http.Request) (*http.Response, error) {
+//			start := time.Now()
+//			resp, err := next(req)
+//			log.Printf("%s %s %v", req.Method, req.URL, time.Since(start))
+//			return resp, err
+//		}
+//	})
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain applied to each request. The first
+// middleware added is the outermost one. Requests reach the chain once
+// authenticated; responses are decompressed before being returned to it.
+func (c *Client) Use(middleware ...Middleware) {
+	chain := make([]Middleware, 0, len(c.middleware)+len(middleware))
+	chain = append(chain, c.middleware...)
+	c.middleware = append(chain, middleware...)
+}
+
+// send issues the request through the middleware chain.
+func (c *Client) send(req *http.Request) (resp *http.Response, err error) {
+	defer recoverPanic(req.Method+" "+req.URL.Path, &err)
+	handler := Handler(func(req *http.Request) (*http.Response, error) {
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if err := gzipDecodeResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	})
+	if c.breaker != nil {
+		handler = c.breaker.wrap(handler)
+	}
+	if c.responseCache != nil {
+		handler = c.responseCache.wrap(handler)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+	return handler(req)
+}
@@ -0,0 +1,57 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "demo" {
+			http.Error(w, "missing header", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, rootDocument)
+	})
+	defer server.Close()
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next(req)
+			}
+		}
+	}
+	client.Use(trace("outer"), trace("inner"))
+	client.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Tenant", "demo")
+			return next(req)
+		}
+	})
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != "outer" || calls[1] != "inner" {
+		t.Errorf("unexpected call order %v", calls)
+	}
+
+	injected := errors.New("injected fault")
+	client.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, injected
+		}
+	})
+	if err := client.Ping(context.Background()); !errors.Is(err, injected) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -234,6 +234,23 @@ func (m *ApiClient) listByParentImpl(typename string, parentID uuid.UUID) ([]con
 	return result, nil
 }
 
+// Count returns the number of objects of a given type.
+func (m *ApiClient) Count(typename string) (int, error) {
+	result, err := m.listByParentImpl(typename, nil)
+	return len(result), err
+}
+
+// CountByParent returns the number of objects of the specified type that are
+// descendents of parent. All the objects of the type are counted when
+// parentID is empty.
+func (m *ApiClient) CountByParent(typename string, parentID string) (int, error) {
+	if parentID == "" {
+		return m.Count(typename)
+	}
+	result, err := m.ListByParent(typename, parentID)
+	return len(result), err
+}
+
 // ListDetail reads all the objects of a given type.
 func (m *ApiClient) ListDetail(typename string, fields []string) ([]contrail.IObject, error) {
 	nilList := []contrail.IObject{}
@@ -265,3 +282,25 @@ func (m *ApiClient) ListDetailByParent(typename string, parentID string, fields
 	}
 	return elements, nil
 }
+
+// ReadListResult retrieves the object identified by a List result.
+func (m *ApiClient) ReadListResult(typename string, result *contrail.ListResult) (contrail.IObject, error) {
+	return m.FindByUuid(typename, result.Uuid)
+}
+
+// ReadReference retrieves the object pointed to by a reference.
+func (m *ApiClient) ReadReference(typename string, ref *contrail.Reference) (contrail.IObject, error) {
+	return m.FindByUuid(typename, ref.Uuid)
+}
+
+// GetField populates the reference lists (refs, back_refs, children) of an object.
+func (m *ApiClient) GetField(obj contrail.IObject, field string) error {
+	return m.updater.GetField(obj, field)
+}
+
+// UpdateReference adds or deletes a reference.
+func (m *ApiClient) UpdateReference(msg *contrail.ReferenceUpdateMsg) error {
+	return m.updater.UpdateReference(msg)
+}
+
+var _ contrail.ApiClient = (*ApiClient)(nil)
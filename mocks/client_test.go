@@ -58,6 +58,16 @@ func TestListByParent(t *testing.T) {
 	for _, element := range elements {
 		assert.Equal(t, "p2", element.Fq_name[1])
 	}
+
+	count, err := client.CountByParent("virtual-machine", projects[1].GetUuid())
+	assert.NoError(t, err)
+	assert.Equal(t, len(vmNames), count)
+	count, err = client.Count("virtual-machine")
+	assert.NoError(t, err)
+	assert.Equal(t, len(projectNames)*len(vmNames), count)
+	count, err = client.CountByParent("virtual-machine", "")
+	assert.NoError(t, err)
+	assert.Equal(t, len(projectNames)*len(vmNames), count)
 }
 
 func TestListAny(t *testing.T) {
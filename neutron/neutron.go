@@ -0,0 +1,355 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package neutron provides bindings for the neutron plugin endpoints of the
+// contrail API server (/neutron/<resource>), which expose the configuration
+// in the OpenStack Neutron data model.
+package neutron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Resource types.
+const (
+	ResourceNetwork           = "network"
+	ResourceSubnet            = "subnet"
+	ResourcePort              = "port"
+	ResourceSecurityGroup     = "security_group"
+	ResourceSecurityGroupRule = "security_group_rule"
+)
+
+// Operations understood by the neutron plugin.
+const (
+	opCreate    = "CREATE"
+	opRead      = "READ"
+	opReadAll   = "READALL"
+	opReadCount = "READCOUNT"
+	opUpdate    = "UPDATE"
+	opDelete    = "DELETE"
+)
+
+// Filters restrict list operations, e.g. {"name": {"public"}}. Each
+// attribute matches any of the listed values.
+type Filters map[string][]interface{}
+
+// requestContext identifies the caller to the neutron plugin.
+type requestContext struct {
+	TenantID  string   `json:"tenant_id"`
+	UserID    string   `json:"user_id,omitempty"`
+	IsAdmin   bool     `json:"is_admin"`
+	Roles     []string `json:"roles,omitempty"`
+	Operation string   `json:"operation"`
+	Type      string   `json:"type"`
+}
+
+type requestData struct {
+	ID       string      `json:"id,omitempty"`
+	Fields   []string    `json:"fields,omitempty"`
+	Filters  Filters     `json:"filters,omitempty"`
+	Resource interface{} `json:"resource,omitempty"`
+}
+
+type request struct {
+	Context requestContext `json:"context"`
+	Data    requestData    `json:"data"`
+}
+
+// Error is a failure reported by the neutron plugin.
+type Error struct {
+	StatusCode int
+	Exception  string `json:"exception"`
+	Message    string `json:"msg"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("neutron: %s", e.Exception)
+	}
+	return fmt.Sprintf("neutron: %s: %s", e.Exception, e.Message)
+}
+
+// IsNotFound returns true if err reports a missing resource.
+func IsNotFound(err error) bool {
+	nerr, ok := err.(*Error)
+	if !ok {
+		return false
+	}
+	return nerr.StatusCode == http.StatusNotFound ||
+		strings.HasSuffix(nerr.Exception, "NotFound")
+}
+
+// Requester sends JSON requests to the API server. It is implemented by
+// *contrail.Client.
+type Requester interface {
+	DoJSON(ctx context.Context, method, path string,
+		request, response interface{}) error
+}
+
+// Client issues neutron plugin requests through a contrail API client,
+// sharing its authentication and transport configuration.
+type Client struct {
+	api      Requester
+	tenantID string
+	userID   string
+	roles    []string
+	isAdmin  bool
+}
+
+// NewClient allocates a neutron Client. Requests are made on behalf of
+// tenantID, which is the project uuid without dashes in the neutron
+// data model; use SetAdmin to access the resources of all tenants.
+func NewClient(api Requester, tenantID string) *Client {
+	return &Client{
+		api:      api,
+		tenantID: strings.Replace(tenantID, "-", "", -1),
+	}
+}
+
+// SetAdmin controls whether requests are made with admin privileges.
+func (c *Client) SetAdmin(isAdmin bool) {
+	c.isAdmin = isAdmin
+}
+
+// SetUser sets the user and roles reported in the request context.
+func (c *Client) SetUser(userID string, roles ...string) {
+	c.userID = userID
+	c.roles = roles
+}
+
+func (c *Client) do(resource, operation string, data requestData,
+	response interface{}) error {
+	req := &request{
+		Context: requestContext{
+			TenantID:  c.tenantID,
+			UserID:    c.userID,
+			IsAdmin:   c.isAdmin,
+			Roles:     c.roles,
+			Operation: operation,
+			Type:      resource,
+		},
+		Data: data,
+	}
+	err := c.api.DoJSON(context.Background(), "POST", "neutron/"+resource,
+		req, response)
+	if herr, ok := err.(*contrail.HTTPError); ok {
+		nerr := &Error{StatusCode: herr.StatusCode}
+		if json.Unmarshal(herr.Body, nerr) != nil || nerr.Exception == "" {
+			nerr.Exception = herr.Status
+			nerr.Message = string(herr.Body)
+		}
+		return nerr
+	}
+	return err
+}
+
+func (c *Client) list(resource string, filters Filters, result interface{}) error {
+	return c.do(resource, opReadAll, requestData{Filters: filters}, result)
+}
+
+func (c *Client) read(resource, id string, result interface{}) error {
+	return c.do(resource, opRead, requestData{ID: id}, result)
+}
+
+func (c *Client) create(resource string, obj, result interface{}) error {
+	return c.do(resource, opCreate, requestData{Resource: obj}, result)
+}
+
+func (c *Client) update(resource, id string, changes map[string]interface{},
+	result interface{}) error {
+	return c.do(resource, opUpdate,
+		requestData{ID: id, Resource: changes}, result)
+}
+
+func (c *Client) delete(resource, id string) error {
+	return c.do(resource, opDelete, requestData{ID: id}, nil)
+}
+
+// Count returns the number of resources of a given type that match filters.
+func (c *Client) Count(resource string, filters Filters) (int, error) {
+	var response struct {
+		Count int `json:"count"`
+	}
+	err := c.do(resource, opReadCount, requestData{Filters: filters}, &response)
+	return response.Count, err
+}
+
+// ListNetworks retrieves the networks that match filters.
+func (c *Client) ListNetworks(filters Filters) ([]Network, error) {
+	var networks []Network
+	err := c.list(ResourceNetwork, filters, &networks)
+	return networks, err
+}
+
+// GetNetwork retrieves a network by id.
+func (c *Client) GetNetwork(id string) (*Network, error) {
+	network := new(Network)
+	if err := c.read(ResourceNetwork, id, network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// CreateNetwork creates a network and returns it as stored by the server.
+func (c *Client) CreateNetwork(network *Network) (*Network, error) {
+	result := new(Network)
+	if err := c.create(ResourceNetwork, network, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateNetwork modifies the specified attributes of a network.
+func (c *Client) UpdateNetwork(id string, changes map[string]interface{}) (
+	*Network, error) {
+	result := new(Network)
+	if err := c.update(ResourceNetwork, id, changes, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteNetwork deletes a network.
+func (c *Client) DeleteNetwork(id string) error {
+	return c.delete(ResourceNetwork, id)
+}
+
+// ListSubnets retrieves the subnets that match filters.
+func (c *Client) ListSubnets(filters Filters) ([]Subnet, error) {
+	var subnets []Subnet
+	err := c.list(ResourceSubnet, filters, &subnets)
+	return subnets, err
+}
+
+// GetSubnet retrieves a subnet by id.
+func (c *Client) GetSubnet(id string) (*Subnet, error) {
+	subnet := new(Subnet)
+	if err := c.read(ResourceSubnet, id, subnet); err != nil {
+		return nil, err
+	}
+	return subnet, nil
+}
+
+// CreateSubnet creates a subnet and returns it as stored by the server.
+func (c *Client) CreateSubnet(subnet *Subnet) (*Subnet, error) {
+	result := new(Subnet)
+	if err := c.create(ResourceSubnet, subnet, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateSubnet modifies the specified attributes of a subnet.
+func (c *Client) UpdateSubnet(id string, changes map[string]interface{}) (
+	*Subnet, error) {
+	result := new(Subnet)
+	if err := c.update(ResourceSubnet, id, changes, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteSubnet deletes a subnet.
+func (c *Client) DeleteSubnet(id string) error {
+	return c.delete(ResourceSubnet, id)
+}
+
+// ListPorts retrieves the ports that match filters.
+func (c *Client) ListPorts(filters Filters) ([]Port, error) {
+	var ports []Port
+	err := c.list(ResourcePort, filters, &ports)
+	return ports, err
+}
+
+// GetPort retrieves a port by id.
+func (c *Client) GetPort(id string) (*Port, error) {
+	port := new(Port)
+	if err := c.read(ResourcePort, id, port); err != nil {
+		return nil, err
+	}
+	return port, nil
+}
+
+// CreatePort creates a port and returns it as stored by the server.
+func (c *Client) CreatePort(port *Port) (*Port, error) {
+	result := new(Port)
+	if err := c.create(ResourcePort, port, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdatePort modifies the specified attributes of a port.
+func (c *Client) UpdatePort(id string, changes map[string]interface{}) (
+	*Port, error) {
+	result := new(Port)
+	if err := c.update(ResourcePort, id, changes, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeletePort deletes a port.
+func (c *Client) DeletePort(id string) error {
+	return c.delete(ResourcePort, id)
+}
+
+// ListSecurityGroups retrieves the security groups that match filters.
+func (c *Client) ListSecurityGroups(filters Filters) ([]SecurityGroup, error) {
+	var groups []SecurityGroup
+	err := c.list(ResourceSecurityGroup, filters, &groups)
+	return groups, err
+}
+
+// GetSecurityGroup retrieves a security group, including its rules, by id.
+func (c *Client) GetSecurityGroup(id string) (*SecurityGroup, error) {
+	group := new(SecurityGroup)
+	if err := c.read(ResourceSecurityGroup, id, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// CreateSecurityGroup creates a security group. The server adds the
+// default egress rules.
+func (c *Client) CreateSecurityGroup(group *SecurityGroup) (*SecurityGroup, error) {
+	result := new(SecurityGroup)
+	if err := c.create(ResourceSecurityGroup, group, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteSecurityGroup deletes a security group.
+func (c *Client) DeleteSecurityGroup(id string) error {
+	return c.delete(ResourceSecurityGroup, id)
+}
+
+// ListSecurityGroupRules retrieves the security group rules that match filters.
+func (c *Client) ListSecurityGroupRules(filters Filters) ([]SecurityGroupRule, error) {
+	var rules []SecurityGroupRule
+	err := c.list(ResourceSecurityGroupRule, filters, &rules)
+	return rules, err
+}
+
+// CreateSecurityGroupRule adds a rule to a security group.
+func (c *Client) CreateSecurityGroupRule(rule *SecurityGroupRule) (
+	*SecurityGroupRule, error) {
+	result := new(SecurityGroupRule)
+	if err := c.create(ResourceSecurityGroupRule, rule, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteSecurityGroupRule deletes a security group rule.
+func (c *Client) DeleteSecurityGroupRule(id string) error {
+	return c.delete(ResourceSecurityGroupRule, id)
+}
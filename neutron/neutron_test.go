@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package neutron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewServer(handler)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	api := contrail.NewClient(host, portNum)
+	return server, NewClient(api, "8d4f1e2c-0b6a-4f4e-9e3a-5c1d2b3a4f5e")
+}
+
+func TestNetworks(t *testing.T) {
+	var requests []request
+	server, client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, req)
+		if r.URL.Path != "/neutron/network" {
+			http.NotFound(w, r)
+			return
+		}
+		switch req.Context.Operation {
+		case "READALL":
+			fmt.Fprint(w, `[{"id": "n1", "name": "public", "router:external": true, "subnets": ["s1"]}]`)
+		case "CREATE":
+			fmt.Fprint(w, `{"id": "n2", "name": "private", "admin_state_up": true, "status": "ACTIVE"}`)
+		case "READ":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"exception": "NetworkNotFound", "msg": "Network %s could not be found"}`,
+				req.Data.ID)
+		case "DELETE":
+		}
+	})
+	defer server.Close()
+
+	networks, err := client.ListNetworks(Filters{"router:external": {true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 1 || !networks[0].External || networks[0].Subnets[0] != "s1" {
+		t.Errorf("unexpected networks %+v", networks)
+	}
+
+	network, err := client.CreateNetwork(&Network{Name: "private", AdminStateUp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.ID != "n2" || network.Status != "ACTIVE" {
+		t.Errorf("unexpected network %+v", network)
+	}
+	resource, _ := requests[1].Data.Resource.(map[string]interface{})
+	if resource["name"] != "private" {
+		t.Errorf("unexpected resource %v", requests[1].Data.Resource)
+	}
+
+	_, err = client.GetNetwork("missing")
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err := client.DeleteNetwork("n2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, req := range requests {
+		if req.Context.TenantID != "8d4f1e2c0b6a4f4e9e3a5c1d2b3a4f5e" ||
+			req.Context.Type != "network" {
+			t.Errorf("unexpected context %+v", req.Context)
+		}
+	}
+}
+
+// recordingRequester serves neutron requests without an API server.
+type recordingRequester struct {
+	paths    []string
+	requests []*request
+}
+
+func (r *recordingRequester) DoJSON(ctx context.Context, method, path string,
+	req, response interface{}) error {
+	r.paths = append(r.paths, method+" "+path)
+	r.requests = append(r.requests, req.(*request))
+	return json.Unmarshal([]byte(`[{"id": "n1", "name": "public"}]`), response)
+}
+
+func TestRequester(t *testing.T) {
+	api := &recordingRequester{}
+	client := NewClient(api, "8d4f1e2c-0b6a-4f4e-9e3a-5c1d2b3a4f5e")
+	networks, err := client.ListNetworks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 1 || networks[0].ID != "n1" {
+		t.Errorf("unexpected networks %+v", networks)
+	}
+	if len(api.paths) != 1 || api.paths[0] != "POST neutron/network" {
+		t.Errorf("unexpected requests %v", api.paths)
+	}
+	ctx := api.requests[0].Context
+	if ctx.Operation != "READALL" || ctx.TenantID != "8d4f1e2c0b6a4f4e9e3a5c1d2b3a4f5e" {
+		t.Errorf("unexpected context %+v", ctx)
+	}
+}
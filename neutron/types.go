@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package neutron
+
+// Resources in the Neutron data model, as translated by the contrail
+// neutron plugin (vnc_openstack). Fields are named after the Neutron API.
+
+// Network is a Neutron network (virtual-network).
+type Network struct {
+	ID                  string   `json:"id,omitempty"`
+	Name                string   `json:"name,omitempty"`
+	Description         string   `json:"description,omitempty"`
+	TenantID            string   `json:"tenant_id,omitempty"`
+	AdminStateUp        bool     `json:"admin_state_up"`
+	Shared              bool     `json:"shared"`
+	External            bool     `json:"router:external"`
+	PortSecurityEnabled *bool    `json:"port_security_enabled,omitempty"`
+	Status              string   `json:"status,omitempty"`
+	Subnets             []string `json:"subnets,omitempty"`
+}
+
+// AllocationPool is a range of addresses available for allocation.
+type AllocationPool struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// HostRoute is a route advertised to hosts via DHCP.
+type HostRoute struct {
+	Destination string `json:"destination"`
+	NextHop     string `json:"nexthop"`
+}
+
+// Subnet is a Neutron subnet (an ipam subnet of a virtual-network).
+type Subnet struct {
+	ID              string           `json:"id,omitempty"`
+	Name            string           `json:"name,omitempty"`
+	TenantID        string           `json:"tenant_id,omitempty"`
+	NetworkID       string           `json:"network_id"`
+	CIDR            string           `json:"cidr"`
+	IPVersion       int              `json:"ip_version"`
+	GatewayIP       string           `json:"gateway_ip,omitempty"`
+	EnableDHCP      bool             `json:"enable_dhcp"`
+	AllocationPools []AllocationPool `json:"allocation_pools,omitempty"`
+	DNSNameservers  []string         `json:"dns_nameservers,omitempty"`
+	HostRoutes      []HostRoute      `json:"host_routes,omitempty"`
+}
+
+// FixedIP is an address assigned to a port.
+type FixedIP struct {
+	SubnetID  string `json:"subnet_id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+// AddressPair is an additional address allowed on a port.
+type AddressPair struct {
+	IPAddress  string `json:"ip_address"`
+	MACAddress string `json:"mac_address,omitempty"`
+}
+
+// Port is a Neutron port (virtual-machine-interface).
+type Port struct {
+	ID                  string        `json:"id,omitempty"`
+	Name                string        `json:"name,omitempty"`
+	TenantID            string        `json:"tenant_id,omitempty"`
+	NetworkID           string        `json:"network_id"`
+	MACAddress          string        `json:"mac_address,omitempty"`
+	AdminStateUp        bool          `json:"admin_state_up"`
+	Status              string        `json:"status,omitempty"`
+	DeviceID            string        `json:"device_id,omitempty"`
+	DeviceOwner         string        `json:"device_owner,omitempty"`
+	FixedIPs            []FixedIP     `json:"fixed_ips,omitempty"`
+	SecurityGroups      []string      `json:"security_groups,omitempty"`
+	AllowedAddressPairs []AddressPair `json:"allowed_address_pairs,omitempty"`
+	PortSecurityEnabled *bool         `json:"port_security_enabled,omitempty"`
+	HostID              string        `json:"binding:host_id,omitempty"`
+}
+
+// SecurityGroupRule is a rule of a Neutron security group. Unset port
+// ranges and protocol match any value.
+type SecurityGroupRule struct {
+	ID              string `json:"id,omitempty"`
+	TenantID        string `json:"tenant_id,omitempty"`
+	SecurityGroupID string `json:"security_group_id"`
+	Direction       string `json:"direction"`
+	EtherType       string `json:"ethertype,omitempty"`
+	Protocol        string `json:"protocol,omitempty"`
+	PortRangeMin    *int   `json:"port_range_min,omitempty"`
+	PortRangeMax    *int   `json:"port_range_max,omitempty"`
+	RemoteIPPrefix  string `json:"remote_ip_prefix,omitempty"`
+	RemoteGroupID   string `json:"remote_group_id,omitempty"`
+}
+
+// SecurityGroup is a Neutron security group.
+type SecurityGroup struct {
+	ID          string              `json:"id,omitempty"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	TenantID    string              `json:"tenant_id,omitempty"`
+	Rules       []SecurityGroupRule `json:"security_group_rules,omitempty"`
+}
@@ -47,6 +47,13 @@ type ObjectBase struct {
 	// or for objects that are retrieved via Read/GET
 	clientPtr objectInterface
 	parent    IObject
+
+	// unknown holds the fields received from the API server that the
+	// type doesn't define.
+	unknown map[string]json.RawMessage
+	// received holds the attributes of the object as last read from or
+	// written to the API server (see IsFieldSet).
+	received map[string]json.RawMessage
 }
 
 // VSetName implements IObject.SetName methods.
@@ -103,6 +110,10 @@ func (obj *ObjectBase) GetHref() string {
 	return obj.href
 }
 
+func (obj *ObjectBase) setHref(href string) {
+	obj.href = href
+}
+
 // SetFQName sets the fully qualified domain name. This implies that the parent is
 // being specified also.
 func (obj *ObjectBase) SetFQName(parentType string, fqn []string) {
@@ -139,42 +150,51 @@ func (obj *ObjectBase) IsTransient() bool {
 // explicitly retrieves a reference field. It is used implicitly when reading and/or modifying
 // reference fields via the generated types library.
 func (obj *ObjectBase) GetField(ptr IObject, field string) error {
+	if obj.clientPtr == nil {
+		return fmt.Errorf("%s %s: can't read %s of a transient object",
+			ptr.GetType(), strings.Join(obj.fq_name, ":"), field)
+	}
 	return obj.clientPtr.GetField(ptr, field)
 }
 
 // UnmarshalCommon is used to unmarshal the JSON data on ObjectBase.
 func (obj *ObjectBase) UnmarshalCommon(m map[string]json.RawMessage) error {
-	var err error
-	err = json.Unmarshal(m["fq_name"], &obj.fq_name)
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(m["uuid"], &obj.uuid)
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(m["name"], &obj.name)
-	if err != nil {
-		return err
+	for _, field := range []struct {
+		name string
+		ptr  interface{}
+	}{
+		{"fq_name", &obj.fq_name},
+		{"uuid", &obj.uuid},
+		{"name", &obj.name},
+	} {
+		value, ok := m[field.name]
+		if !ok {
+			return fmt.Errorf("Missing %s", field.name)
+		}
+		if err := json.Unmarshal(value, field.ptr); err != nil {
+			return fmt.Errorf("Invalid %s: %v", field.name, err)
+		}
 	}
 	if href, ok := m["href"]; ok {
-		err = json.Unmarshal(href, &obj.href)
-		if err != nil {
-			return err
-		}
-
-		// Older versions of the API server have a bug generating the href
-		// on list commands
-		helements := strings.Split(obj.href, "/")
-		if helements[len(helements)-1] != obj.uuid {
-			fmt.Fprintf(os.Stderr, "WARN invalid href: %s\n", obj.href)
-			helements[len(helements)-1] = obj.uuid
-			obj.href = strings.Join(helements, "/")
+		if err := json.Unmarshal(href, &obj.href); err != nil {
+			return fmt.Errorf("Invalid href: %v", err)
 		}
+		obj.fixHref()
 	}
 	return nil
 }
 
+// fixHref corrects the href received from the API server: older versions
+// of the API server have a bug generating the href on list commands.
+func (obj *ObjectBase) fixHref() {
+	helements := strings.Split(obj.href, "/")
+	if helements[len(helements)-1] != obj.uuid {
+		fmt.Fprintf(os.Stderr, "WARN invalid href: %s\n", obj.href)
+		helements[len(helements)-1] = obj.uuid
+		obj.href = strings.Join(helements, "/")
+	}
+}
+
 // MarshalId encodes fq_name and uuid.
 func (obj *ObjectBase) MarshalId(m map[string]*json.RawMessage) error {
 	{
@@ -276,6 +296,10 @@ func attributeEqual(lhs, rhs LinkAttribute) bool {
 // ref-update URL on the API server.
 func (obj *ObjectBase) UpdateReference(
 	ptr IObject, field string, current, prev ReferenceList) error {
+	if obj.clientPtr == nil {
+		return fmt.Errorf("%s %s: can't update %s of a transient object",
+			ptr.GetType(), strings.Join(obj.fq_name, ":"), field)
+	}
 
 	sort.Sort(referenceUUIDSorter(current))
 	sort.Sort(referenceUUIDSorter(prev))
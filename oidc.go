@@ -0,0 +1,205 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oidcExpiryMargin is subtracted from the token lifetime so that a token
+// is not used right before it expires.
+const oidcExpiryMargin = 30 * time.Second
+
+// OIDCAuthenticator obtains access tokens from an OpenID Connect issuer
+// and adds them to the API requests. Tokens are requested with either the
+// client credentials or the refresh token grant and are cached until they
+// expire.
+type OIDCAuthenticator struct {
+	issuer        string
+	tokenEndpoint string
+	clientID      string
+	clientSecret  string
+	refreshToken  string
+	scopes        []string
+	header        string
+	httpClient    *http.Client
+
+	mutex       sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewOIDCClientCredentials allocates an OIDCAuthenticator that uses the
+// client credentials grant.
+func NewOIDCClientCredentials(issuer, clientID, clientSecret string,
+	scopes ...string) *OIDCAuthenticator {
+	return &OIDCAuthenticator{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		header:       "Authorization",
+		httpClient:   &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// NewOIDCRefreshToken allocates an OIDCAuthenticator that uses the refresh
+// token grant. clientSecret may be empty for public clients.
+func NewOIDCRefreshToken(issuer, clientID, clientSecret,
+	refreshToken string) *OIDCAuthenticator {
+	auth := NewOIDCClientCredentials(issuer, clientID, clientSecret)
+	auth.refreshToken = refreshToken
+	return auth
+}
+
+// SetHTTPClient replaces the http.Client used to talk to the issuer.
+func (a *OIDCAuthenticator) SetHTTPClient(httpClient *http.Client) {
+	a.httpClient = httpClient
+}
+
+// SetTokenEndpoint sets the token endpoint, bypassing the issuer discovery
+// document.
+func (a *OIDCAuthenticator) SetTokenEndpoint(endpoint string) {
+	a.tokenEndpoint = endpoint
+}
+
+// SetTokenHeader selects the request header that carries the token. The
+// default, "Authorization", sends a bearer token; deployments that federate
+// keystone typically expect "X-Auth-Token".
+func (a *OIDCAuthenticator) SetTokenHeader(header string) {
+	a.header = header
+}
+
+// discover reads the token endpoint from the issuer discovery document.
+func (a *OIDCAuthenticator) discover() error {
+	resp, err := a.httpClient.Get(a.issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	var config struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return err
+	}
+	if config.TokenEndpoint == "" {
+		return fmt.Errorf("%s: token_endpoint not advertised", a.issuer)
+	}
+	a.tokenEndpoint = config.TokenEndpoint
+	return nil
+}
+
+// requestToken obtains a new access token from the issuer.
+func (a *OIDCAuthenticator) requestToken() error {
+	if a.tokenEndpoint == "" {
+		if err := a.discover(); err != nil {
+			return err
+		}
+	}
+
+	values := make(url.Values, 0)
+	if a.refreshToken != "" {
+		values.Set("grant_type", "refresh_token")
+		values.Set("refresh_token", a.refreshToken)
+	} else {
+		values.Set("grant_type", "client_credentials")
+	}
+	if len(a.scopes) > 0 {
+		values.Set("scope", strings.Join(a.scopes, " "))
+	}
+	if a.clientSecret == "" {
+		values.Set("client_id", a.clientID)
+	}
+	req, err := http.NewRequest("POST", a.tokenEndpoint,
+		strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if a.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(a.clientID),
+			url.QueryEscape(a.clientSecret))
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+
+	var response struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	if response.AccessToken == "" {
+		return fmt.Errorf("%s: no access_token in response", a.tokenEndpoint)
+	}
+	a.accessToken = response.AccessToken
+	if response.ExpiresIn > 0 {
+		a.expiresAt = time.Now().Add(
+			time.Duration(response.ExpiresIn)*time.Second - oidcExpiryMargin)
+	} else {
+		a.expiresAt = time.Time{}
+	}
+	// Issuers may rotate refresh tokens.
+	if a.refreshToken != "" && response.RefreshToken != "" {
+		a.refreshToken = response.RefreshToken
+	}
+	return nil
+}
+
+// token returns a valid access token, requesting a new one when needed.
+func (a *OIDCAuthenticator) token() (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.accessToken == "" ||
+		(!a.expiresAt.IsZero() && time.Now().After(a.expiresAt)) {
+		if err := a.requestToken(); err != nil {
+			return "", fmt.Errorf("oidc: %v", err)
+		}
+	}
+	return a.accessToken, nil
+}
+
+// AddAuthentication implements the Authenticator interface for
+// OIDCAuthenticator.
+func (a *OIDCAuthenticator) AddAuthentication(req *http.Request) error {
+	token, err := a.token()
+	if err != nil {
+		return err
+	}
+	if http.CanonicalHeaderKey(a.header) == "Authorization" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set(a.header, token)
+	}
+	return nil
+}
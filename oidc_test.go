@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOIDCClientCredentials(t *testing.T) {
+	var issuer *httptest.Server
+	requests := 0
+	issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer": %q, "token_endpoint": "%s/token"}`,
+				issuer.URL, issuer.URL)
+		case "/token":
+			user, password, _ := r.BasicAuth()
+			if r.FormValue("grant_type") != "client_credentials" ||
+				user != "contrail" || password != "secret" {
+				http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+				return
+			}
+			requests++
+			fmt.Fprintf(w, `{"access_token": "jwt-%d", "token_type": "Bearer", "expires_in": 3600}`,
+				requests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer issuer.Close()
+
+	auth := NewOIDCClientCredentials(issuer.URL, "contrail", "secret", "openid")
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+		if err := auth.AddAuthentication(req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Header.Get("Authorization") != "Bearer jwt-1" {
+			t.Errorf("unexpected header %q", req.Header.Get("Authorization"))
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the token to be cached, got %d requests", requests)
+	}
+
+	bad := NewOIDCClientCredentials(issuer.URL, "contrail", "wrong")
+	req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+	if err := bad.AddAuthentication(req); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestOIDCRefreshToken(t *testing.T) {
+	var refreshTokens []string
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "refresh_token" ||
+			r.FormValue("client_id") != "cli" {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		refreshTokens = append(refreshTokens, r.FormValue("refresh_token"))
+		// An expired token forces a refresh on each request.
+		fmt.Fprintf(w, `{"access_token": "jwt", "expires_in": 1, "refresh_token": "rt-%d"}`,
+			len(refreshTokens))
+	}))
+	defer issuer.Close()
+
+	auth := NewOIDCRefreshToken("", "cli", "", "rt-0")
+	auth.SetTokenEndpoint(issuer.URL + "/token")
+	auth.SetTokenHeader("X-Auth-Token")
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+		if err := auth.AddAuthentication(req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Header.Get("X-Auth-Token") != "jwt" {
+			t.Errorf("unexpected header %q", req.Header.Get("X-Auth-Token"))
+		}
+	}
+	if len(refreshTokens) != 2 || refreshTokens[1] != "rt-1" {
+		t.Errorf("unexpected refresh tokens %v", refreshTokens)
+	}
+}
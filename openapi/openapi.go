@@ -0,0 +1,461 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package openapi describes the API server REST resources as an OpenAPI 3
+// document.
+//
+// The document is derived from the types registered by the generated types
+// library (contrail.RegisterTypeMap): object fields become schema
+// properties and the structures used as property values become component
+// schemas. It can be used to generate clients in other languages or to
+// validate requests in API gateways.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Version of the OpenAPI specification the document conforms to.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document. Only the elements used to describe the
+// API server are defined.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the API metadata.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Server is an API server URL.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem describes the operations on a path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+}
+
+// Operation describes an API call.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the JSON body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes the response to a request.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is a JSON schema.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+func refSchema(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// Generate builds the document describing the registered types.
+func Generate(info Info) (*Document, error) {
+	g := &generator{
+		doc: &Document{
+			OpenAPI:    Version,
+			Info:       info,
+			Paths:      make(map[string]*PathItem),
+			Components: Components{Schemas: make(map[string]*Schema)},
+		},
+	}
+	g.addCommonSchemas()
+	for _, typename := range contrail.TypeNames() {
+		obj, err := contrail.NewObject(typename)
+		if err != nil {
+			return nil, err
+		}
+		g.addObjectType(typename, reflect.TypeOf(obj).Elem())
+	}
+	g.addUtilityPaths()
+	return g.doc, nil
+}
+
+// Marshal encodes the document as indented JSON.
+func (doc *Document) Marshal() ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type generator struct {
+	doc *Document
+}
+
+// Generated types name their fields after the JSON attributes; other
+// fields are internal state (e.g. the bitmask of valid fields).
+var attributeName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var internalFields = map[string]bool{
+	"valid":    true,
+	"modified": true,
+}
+
+func (g *generator) addCommonSchemas() {
+	stringList := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	schemas := g.doc.Components.Schemas
+	schemas["Reference"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"to":   stringList,
+			"uuid": {Type: "string", Format: "uuid"},
+			"href": {Type: "string", ReadOnly: true},
+			"attr": {Description: "Link attribute"},
+		},
+	}
+	schemas["ListResult"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"fq_name": stringList,
+			"uuid":    {Type: "string", Format: "uuid"},
+			"href":    {Type: "string"},
+		},
+	}
+	schemas["Error"] = &Schema{Type: "string"}
+}
+
+// objectBaseProperties are the attributes encoded by contrail.ObjectBase.
+func objectBaseProperties() map[string]*Schema {
+	return map[string]*Schema{
+		"uuid":        {Type: "string", Format: "uuid"},
+		"fq_name":     {Type: "array", Items: &Schema{Type: "string"}},
+		"name":        {Type: "string"},
+		"href":        {Type: "string", ReadOnly: true},
+		"parent_type": {Type: "string"},
+		"parent_uuid": {Type: "string", Format: "uuid"},
+		"parent_href": {Type: "string", ReadOnly: true},
+	}
+}
+
+// schemaName returns the component name of a type (e.g.
+// virtual-network -> VirtualNetwork).
+func schemaName(typename string) string {
+	var name string
+	for _, word := range strings.FieldsFunc(typename, func(r rune) bool {
+		return r == '-' || r == '_'
+	}) {
+		name += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return name
+}
+
+func (g *generator) addObjectType(typename string, xtype reflect.Type) {
+	schema := &Schema{
+		Type:       "object",
+		Properties: objectBaseProperties(),
+	}
+	referenceList := reflect.TypeOf(contrail.ReferenceList{})
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if field.Anonymous || internalFields[field.Name] ||
+			!attributeName.MatchString(field.Name) {
+			continue
+		}
+		var property *Schema
+		if field.Type == referenceList {
+			property = &Schema{Type: "array", Items: refSchema("Reference")}
+			if !strings.HasSuffix(field.Name, "_refs") ||
+				strings.HasSuffix(field.Name, "_back_refs") {
+				// Back references and children are maintained by the
+				// API server.
+				property.ReadOnly = true
+			}
+		} else {
+			property = g.typeSchema(field.Type)
+		}
+		schema.Properties[field.Name] = property
+	}
+	name := schemaName(typename)
+	g.doc.Components.Schemas[name] = schema
+	g.addObjectPaths(typename, name)
+}
+
+// typeSchema returns the schema of a go type, adding component schemas for
+// structures.
+func (g *generator) typeSchema(xtype reflect.Type) *Schema {
+	switch xtype.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(xtype.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.typeSchema(xtype.Elem())}
+	case reflect.Map:
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: g.typeSchema(xtype.Elem()),
+		}
+	case reflect.Struct:
+		name := xtype.Name()
+		if name == "" {
+			return g.structSchema(xtype)
+		}
+		if _, exists := g.doc.Components.Schemas[name]; !exists {
+			// Register the name first: structures may be recursive.
+			g.doc.Components.Schemas[name] = &Schema{}
+			*g.doc.Components.Schemas[name] = *g.structSchema(xtype)
+		}
+		return refSchema(name)
+	}
+	return &Schema{}
+}
+
+func (g *generator) structSchema(xtype reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = g.typeSchema(field.Type)
+		omitempty := false
+		for _, option := range tag[1:] {
+			omitempty = omitempty || option == "omitempty"
+		}
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+var uuidParameter = &Parameter{
+	Name: "id", In: "path", Required: true,
+	Schema: &Schema{Type: "string", Format: "uuid"},
+}
+
+// wrapped returns the schema of the body {"<typename>": {...}}.
+func wrapped(typename, name string) *Schema {
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{typename: refSchema(name)},
+		Required:   []string{typename},
+	}
+}
+
+func errorResponses(responses map[string]*Response) map[string]*Response {
+	responses["default"] = &Response{
+		Description: "Error",
+		Content:     jsonContent(refSchema("Error")),
+	}
+	return responses
+}
+
+func (g *generator) addObjectPaths(typename, name string) {
+	tags := []string{typename}
+	listSchema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			typename + "s": {Type: "array", Items: refSchema("ListResult")},
+		},
+	}
+	g.doc.Paths["/"+typename+"s"] = &PathItem{
+		Get: &Operation{
+			OperationID: "list" + name,
+			Summary:     "List " + typename + " objects",
+			Tags:        tags,
+			Parameters: []*Parameter{
+				{Name: "parent_id", In: "query", Schema: &Schema{Type: "string"}},
+				{Name: "detail", In: "query", Schema: &Schema{Type: "boolean"}},
+				{Name: "fields", In: "query", Schema: &Schema{Type: "string"}},
+			},
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK", Content: jsonContent(listSchema)},
+			}),
+		},
+		Post: &Operation{
+			OperationID: "create" + name,
+			Summary:     "Create a " + typename,
+			Tags:        tags,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  jsonContent(wrapped(typename, name)),
+			},
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK", Content: jsonContent(wrapped(typename, name))},
+			}),
+		},
+	}
+	g.doc.Paths["/"+typename+"/{id}"] = &PathItem{
+		Parameters: []*Parameter{uuidParameter},
+		Get: &Operation{
+			OperationID: "read" + name,
+			Summary:     "Read a " + typename,
+			Tags:        tags,
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK", Content: jsonContent(wrapped(typename, name))},
+			}),
+		},
+		Put: &Operation{
+			OperationID: "update" + name,
+			Summary:     "Update a " + typename,
+			Tags:        tags,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  jsonContent(wrapped(typename, name)),
+			},
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK"},
+			}),
+		},
+		Delete: &Operation{
+			OperationID: "delete" + name,
+			Summary:     "Delete a " + typename,
+			Tags:        tags,
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK"},
+			}),
+		},
+	}
+}
+
+// addUtilityPaths describes the non-resource calls used by the client.
+func (g *generator) addUtilityPaths() {
+	stringList := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	g.doc.Paths["/fqname-to-id"] = &PathItem{
+		Post: &Operation{
+			OperationID: "fqnameToId",
+			Summary:     "Retrieve the uuid of an object given its fq_name",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"type":    {Type: "string"},
+						"fq_name": stringList,
+					},
+					Required: []string{"fq_name", "type"},
+				}),
+			},
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK", Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"uuid": {Type: "string", Format: "uuid"},
+					},
+				})},
+			}),
+		},
+	}
+	g.doc.Paths["/id-to-fqname"] = &PathItem{
+		Post: &Operation{
+			OperationID: "idToFqname",
+			Summary:     "Retrieve the fq_name of an object given its uuid",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"uuid": {Type: "string", Format: "uuid"},
+					},
+					Required: []string{"uuid"},
+				}),
+			},
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK", Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"type":    {Type: "string"},
+						"fq_name": stringList,
+					},
+				})},
+			}),
+		},
+	}
+	g.doc.Paths["/ref-update"] = &PathItem{
+		Post: &Operation{
+			OperationID: "refUpdate",
+			Summary:     "Add or delete a reference",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  jsonContent(g.typeSchema(reflect.TypeOf(contrail.ReferenceUpdateMsg{}))),
+			},
+			Responses: errorResponses(map[string]*Response{
+				"200": {Description: "OK"},
+			}),
+		},
+	}
+}
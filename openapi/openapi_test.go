@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type testNetworkType struct {
+	AllowTransit bool   `json:"allow_transit,omitempty"`
+	VxlanID      int    `json:"vxlan_network_identifier"`
+	ForwardMode  string `json:"forwarding_mode,omitempty"`
+}
+
+// testNetwork follows the layout of the generated types.
+type testNetwork struct {
+	contrail.ObjectBase
+	virtual_network_properties testNetworkType
+	route_target_list          []string
+	network_ipam_refs          contrail.ReferenceList
+	instance_ip_back_refs      contrail.ReferenceList
+	valid                      uint64
+}
+
+func (*testNetwork) GetType() string               { return "virtual-network" }
+func (*testNetwork) GetDefaultParent() []string    { return nil }
+func (*testNetwork) GetDefaultParentType() string  { return "project" }
+func (obj *testNetwork) SetName(name string)       { obj.VSetName(obj, name) }
+func (*testNetwork) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testNetwork) UpdateReferences() error       { return nil }
+func (*testNetwork) UpdateDone()                   {}
+
+func TestGenerate(t *testing.T) {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"virtual-network": reflect.TypeOf(testNetwork{}),
+	})
+	doc, err := Generate(Info{Title: "Contrail", Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network, ok := doc.Components.Schemas["VirtualNetwork"]
+	if !ok {
+		t.Fatal("no VirtualNetwork schema")
+	}
+	if _, ok := network.Properties["valid"]; ok {
+		t.Error("internal field in schema")
+	}
+	if _, ok := network.Properties["fq_name"]; !ok {
+		t.Error("no fq_name property")
+	}
+	properties := network.Properties["virtual_network_properties"]
+	if properties.Ref != "#/components/schemas/testNetworkType" {
+		t.Errorf("unexpected properties schema %+v", properties)
+	}
+	if refs := network.Properties["network_ipam_refs"]; refs.Type != "array" || refs.ReadOnly {
+		t.Errorf("unexpected refs schema %+v", refs)
+	}
+	if backRefs := network.Properties["instance_ip_back_refs"]; !backRefs.ReadOnly {
+		t.Error("back references must be read-only")
+	}
+
+	propertyType := doc.Components.Schemas["testNetworkType"]
+	if propertyType == nil || propertyType.Properties["vxlan_network_identifier"].Type != "integer" {
+		t.Fatalf("unexpected property type %+v", propertyType)
+	}
+	if !reflect.DeepEqual(propertyType.Required, []string{"vxlan_network_identifier"}) {
+		t.Errorf("unexpected required %v", propertyType.Required)
+	}
+
+	for _, path := range []string{"/virtual-networks", "/virtual-network/{id}",
+		"/fqname-to-id", "/ref-update"} {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Errorf("no path %s", path)
+		}
+	}
+	if doc.Paths["/virtual-network/{id}"].Put.OperationID != "updateVirtualNetwork" {
+		t.Error("unexpected operation id")
+	}
+
+	data, err := doc.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["openapi"] != Version {
+		t.Errorf("unexpected version %v", m["openapi"])
+	}
+}
@@ -0,0 +1,32 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError reports a panic recovered by the library, e.g. in the
+// decoding code of a generated type, in a reflection based helper or in a
+// middleware, so that it fails the operation rather than the process.
+type PanicError struct {
+	// Context describes the operation that panicked.
+	Context string
+	Value   interface{}
+	Stack   []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: panic: %v", e.Context, e.Value)
+}
+
+// recoverPanic converts a panic into a PanicError returned through err.
+// It must be deferred directly.
+func recoverPanic(context string, err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Context: context, Value: r, Stack: debug.Stack()}
+	}
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// panickingNetwork has a reference setter that panics.
+type panickingNetwork struct {
+	TestNetwork
+	projects map[string]bool
+}
+
+func (obj *panickingNetwork) AddTestProject(rhs *GenericObject) error {
+	obj.projects[rhs.GetUuid()] = true
+	return nil
+}
+
+func TestPanicRecovery(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"uuid": "u"}`))
+	})
+	defer server.Close()
+
+	client.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			var m map[string]int
+			m["requests"]++
+			return next(req)
+		}
+	})
+	_, err := client.UuidByName("test-network", "p:n")
+	panicErr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if panicErr.Context != "POST /fqname-to-id" || len(panicErr.Stack) == 0 {
+		t.Errorf("unexpected error %v", panicErr)
+	}
+
+	err = addReference(new(panickingNetwork), NewGenericObject("test-project"), nil)
+	if _, ok := err.(*PanicError); !ok {
+		t.Errorf("expected a PanicError, got %v", err)
+	}
+
+	network := new(TestNetwork)
+	network.SetName("n")
+	if err := network.GetField(network, "test_project_refs"); err == nil ||
+		!strings.Contains(err.Error(), "transient") {
+		t.Errorf("expected a transient object error, got %v", err)
+	}
+}
+
+func TestRegisterInvalidTypes(t *testing.T) {
+	defer registerTestTypes()
+	RegisterTypeMap(TypeMap{
+		"test-network": reflect.TypeOf(TestNetwork{}),
+		"string":       reflect.TypeOf(""),
+		"reference":    reflect.TypeOf(Reference{}),
+	})
+	if names := TypeNames(); len(names) != 1 || names[0] != "test-network" {
+		t.Errorf("registered types %v", names)
+	}
+	if _, err := NewObject("reference"); err == nil {
+		t.Error("expected an error allocating an invalid type")
+	}
+}
@@ -0,0 +1,41 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+)
+
+// Operations on collection properties.
+const (
+	// Map properties (e.g. annotations, keyed by key).
+	PropCollectionSet    = "set"
+	PropCollectionDelete = "delete"
+	// List properties.
+	PropCollectionAdd    = "add"
+	PropCollectionModify = "modify"
+)
+
+// PropCollectionUpdate modifies an element of a list or map property.
+// Position is the element key for map properties and the element index
+// for list properties; it is ignored by "set" and optional for "add".
+type PropCollectionUpdate struct {
+	Field     string      `json:"field"`
+	Operation string      `json:"operation"`
+	Value     interface{} `json:"value,omitempty"`
+	Position  interface{} `json:"position,omitempty"`
+}
+
+// UpdatePropCollection applies element updates to collection properties
+// of an object. Unlike Update, it doesn't rewrite the whole property and
+// therefore doesn't race with concurrent changes to other elements.
+func (c *Client) UpdatePropCollection(uuid string,
+	updates []PropCollectionUpdate) error {
+	msg := struct {
+		Uuid    string                 `json:"uuid"`
+		Updates []PropCollectionUpdate `json:"updates"`
+	}{uuid, updates}
+	return c.DoJSON(context.Background(), "POST", "prop-collection-update", &msg, nil)
+}
@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestUpdatePropCollection(t *testing.T) {
+	var request map[string]interface{}
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prop-collection-update" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+	})
+	defer server.Close()
+
+	err := client.UpdatePropCollection("net-uuid", []PropCollectionUpdate{
+		{Field: "annotations", Operation: PropCollectionSet,
+			Value: map[string]string{"key": "owner", "value": "a"}},
+		{Field: "annotations", Operation: PropCollectionDelete, Position: "stale"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"uuid": "net-uuid",
+		"updates": []interface{}{
+			map[string]interface{}{
+				"field":     "annotations",
+				"operation": "set",
+				"value":     map[string]interface{}{"key": "owner", "value": "a"},
+			},
+			map[string]interface{}{
+				"field":     "annotations",
+				"operation": "delete",
+				"position":  "stale",
+			},
+		},
+	}
+	if !reflect.DeepEqual(request, expected) {
+		t.Errorf("unexpected request %v", request)
+	}
+}
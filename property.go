@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Property presence.
+//
+// The accessors of the generated types return the zero value of a
+// property that is not set, so a property that was never received can't
+// be told apart from one that holds "", 0 or false. The client records
+// the attributes of each object as received from the API server, or as
+// sent when the object is created or updated; IsFieldSet reports the
+// attributes that hold a value.
+//
+// Update uses the same record to send only the attributes that changed:
+// an attribute encoded by UpdateObject with the value last received is
+// left out of the request, which keeps concurrent updates of other
+// attributes intact.
+
+// receivedFields returns the attributes of the JSON object data, other
+// than the common ones. The values are slices of data. The input must be
+// valid JSON.
+func receivedFields(data []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	err := forEachField(data, func(name string, value []byte) error {
+		if commonFields[name] {
+			return nil
+		}
+		if fields == nil {
+			fields = make(map[string]json.RawMessage)
+		}
+		fields[name] = value
+		return nil
+	})
+	return fields, err
+}
+
+// mergeFields adds to dst the entries of src that it doesn't hold.
+func mergeFields(dst, src map[string]json.RawMessage) map[string]json.RawMessage {
+	for key, value := range src {
+		if _, exists := dst[key]; exists {
+			continue
+		}
+		if dst == nil {
+			dst = make(map[string]json.RawMessage)
+		}
+		dst[key] = value
+	}
+	return dst
+}
+
+// recordReceived stores the attributes of data, the encoding of obj as
+// received from the API server. data is retained.
+func recordReceived(obj IObject, data []byte) error {
+	base, ok := obj.(baseObject)
+	if !ok {
+		return nil
+	}
+	fields, err := receivedFields(data)
+	if err != nil {
+		return err
+	}
+	base.objectBase().received = fields
+	return nil
+}
+
+// recordSent adds the attributes of data, the encoding of obj sent to the
+// API server by a successful Create or Update, to the received ones.
+func recordSent(obj IObject, data []byte) error {
+	base, ok := obj.(baseObject)
+	if !ok {
+		return nil
+	}
+	fields, err := receivedFields(data)
+	if err != nil {
+		return err
+	}
+	b := base.objectBase()
+	b.received = mergeFields(fields, b.received)
+	return nil
+}
+
+// IsFieldSet reports whether the attribute name (a property or reference
+// list, by its API server name) held a value other than null when the
+// object was last read from or written to the API server. Properties
+// assigned since then are not taken into account.
+func (obj *ObjectBase) IsFieldSet(name string) bool {
+	value, ok := obj.received[name]
+	return ok && !isNull(value)
+}
+
+// jsonValueEqual compares two JSON values, ignoring formatting and the
+// order of object members.
+func jsonValueEqual(lhs, rhs []byte) bool {
+	if bytes.Equal(lhs, rhs) {
+		return true
+	}
+	var lv, rv interface{}
+	if UnmarshalUseNumber(lhs, &lv) != nil || UnmarshalUseNumber(rhs, &rv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(lv, rv)
+}
+
+// minimalUpdate removes from data, the encoding of obj by UpdateObject,
+// the attributes that are unchanged since the object was last read from
+// or written to the API server. The identifiers are kept.
+func minimalUpdate(obj IObject, data []byte) ([]byte, error) {
+	base, ok := obj.(baseObject)
+	if !ok {
+		return data, nil
+	}
+	received := base.objectBase().received
+	if len(received) == 0 {
+		return data, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	unchanged := 0
+	for key, value := range m {
+		if previous, ok := received[key]; ok && !commonFields[key] &&
+			jsonValueEqual(value, previous) {
+			delete(m, key)
+			unchanged++
+		}
+	}
+	if unchanged == 0 {
+		return data, nil
+	}
+	return json.Marshal(m)
+}
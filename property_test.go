@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIsFieldSet(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST":
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "new"], "uuid": "new-uuid", "name": "new"}}`)
+		case r.URL.Query().Get("fields") != "":
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "empty", "name": "net", "test_project_refs": [{"to": ["default-project"], "uuid": "p1"}]}}`)
+		case strings.HasSuffix(r.URL.Path, "/empty"):
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "empty", "name": "net", "display_name": ""}}`)
+		case strings.HasSuffix(r.URL.Path, "/null"):
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "null", "name": "net", "display_name": null}}`)
+		default:
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "unset", "name": "net"}}`)
+		}
+	})
+	defer server.Close()
+
+	for uuid, expected := range map[string]bool{
+		"empty": true,
+		"null":  false,
+		"unset": false,
+	} {
+		obj, err := client.FindByUuid("test-network", uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		network := obj.(*TestNetwork)
+		if network.display_name != "" || network.IsFieldSet("display_name") != expected {
+			t.Errorf("%s: display_name %q, set %v", uuid, network.display_name,
+				network.IsFieldSet("display_name"))
+		}
+		if uuid != "empty" {
+			continue
+		}
+		network.href = server.URL + "/test-network/empty"
+		if err := client.GetField(network, "test_project_refs"); err != nil {
+			t.Fatal(err)
+		}
+		if !network.IsFieldSet("display_name") || !network.IsFieldSet("test_project_refs") {
+			t.Errorf("fields lost by GetField: %v", network.received)
+		}
+	}
+
+	network := new(TestNetwork)
+	network.SetName("new")
+	network.display_name = "x"
+	if network.IsFieldSet("display_name") {
+		t.Error("field of a transient object set")
+	}
+	if err := client.Create(network); err != nil {
+		t.Fatal(err)
+	}
+	if !network.IsFieldSet("display_name") || network.IsFieldSet("test_project_refs") {
+		t.Errorf("unexpected fields after create: %v", network.received)
+	}
+}
+
+func TestGenericObjectUnset(t *testing.T) {
+	var bodies []map[string]json.RawMessage
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST", "PUT":
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			bodies = append(bodies, msg["custom-resource"])
+		}
+		fmt.Fprint(w, `{"custom-resource": {"fq_name": ["default-project", "cr"], "uuid": "cr-uuid", "name": "cr"}}`)
+	})
+	defer server.Close()
+
+	obj := NewGenericObject("custom-resource")
+	obj.SetDefaultParent("test-project", []string{"default-project"})
+	obj.SetName("cr")
+	obj.Set("size", 0)
+	obj.Set("description", "")
+	obj.Unset("description")
+	if !obj.IsFieldSet("size") || obj.IsFieldSet("description") {
+		t.Errorf("unexpected fields %v", obj.Fields())
+	}
+	if err := client.Create(obj); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bodies[0]["description"]; ok || string(bodies[0]["size"]) != "0" {
+		t.Errorf("unexpected create body %v", bodies[0])
+	}
+
+	obj.href = server.URL + "/custom-resource/cr-uuid"
+	obj.Unset("size")
+	if err := client.Update(obj); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := bodies[1]["size"]; !ok || string(value) != "null" {
+		t.Errorf("unexpected update body %v", bodies[1])
+	}
+	if obj.IsFieldSet("size") {
+		t.Error("unset field still set")
+	}
+}
+
+func TestMinimalUpdate(t *testing.T) {
+	var updates []map[string]json.RawMessage
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			updates = append(updates, msg["test-network"])
+		}
+		fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "display_name": "net", "test_project_refs": [{"to": ["default-project"], "uuid": "p1", "attr": {"a": 1, "b": 2}}]}}`)
+	})
+	defer server.Close()
+
+	obj, err := client.FindByUuid("test-network", "net-uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := obj.(*TestNetwork)
+	network.href = server.URL + "/test-network/net-uuid"
+	// An equal value, formatted differently by the server, is unchanged.
+	network.test_project_refs[0].Attr = map[string]interface{}{"b": 2, "a": 1}
+	network.display_name = "renamed"
+	if err := client.Update(network); err != nil {
+		t.Fatal(err)
+	}
+	network.test_project_refs = append(network.test_project_refs,
+		Reference{To: []string{"other"}, Uuid: "p2"})
+	if err := client.Update(network); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Update(network); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"display_name", "fq_name", "uuid"},
+		{"fq_name", "test_project_refs", "uuid"},
+		{"fq_name", "uuid"},
+	}
+	if len(updates) != len(expected) {
+		t.Fatalf("expected %d updates, got %d", len(expected), len(updates))
+	}
+	for i, update := range updates {
+		var keys []string
+		for key := range update {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, expected[i]) {
+			t.Errorf("update %d: expected %v, got %v", i, expected[i], keys)
+		}
+	}
+}
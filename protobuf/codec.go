@@ -0,0 +1,395 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package protobuf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// ToProto encodes obj in the protobuf wire format.
+func ToProto(obj contrail.IObject) ([]byte, error) {
+	msg, err := defaultRegistry.objectMessage(obj.GetType())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	var buf []byte
+	return encodeMessage(buf, msg, m)
+}
+
+// FromProto decodes an object of the registered type typename.
+func FromProto(typename string, data []byte) (contrail.IObject, error) {
+	msg, err := defaultRegistry.objectMessage(typename)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decodeMessage(msg, data)
+	if err != nil {
+		return nil, err
+	}
+	// Attributes decoded unconditionally by ObjectBase.UnmarshalCommon.
+	if _, ok := m["uuid"]; !ok {
+		m["uuid"] = ""
+	}
+	fqn, _ := m["fq_name"].([]interface{})
+	m["fq_name"] = append([]interface{}{}, fqn...)
+	if _, ok := m["name"]; !ok {
+		m["name"] = ""
+		if len(fqn) > 0 {
+			m["name"] = fqn[len(fqn)-1]
+		}
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := contrail.NewObject(typename)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func appendTag(buf []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+func appendBytes(buf []byte, number int, data []byte) []byte {
+	buf = appendTag(buf, number, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+func encodeMessage(buf []byte, msg *message, m map[string]interface{}) ([]byte, error) {
+	for _, f := range msg.fields {
+		value, ok := m[f.name]
+		if !ok || value == nil {
+			continue
+		}
+		var err error
+		if list, isList := value.([]interface{}); isList && f.repeated {
+			for _, element := range list {
+				if buf, err = encodeValue(buf, f, element); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if buf, err = encodeValue(buf, f, value); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func encodeValue(buf []byte, f *field, value interface{}) ([]byte, error) {
+	switch f.kind {
+	case kindBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected bool, got %T", f.name, value)
+		}
+		n := uint64(0)
+		if b {
+			n = 1
+		}
+		buf = appendTag(buf, f.number, wireVarint)
+		return binary.AppendUvarint(buf, n), nil
+	case kindInt, kindUint:
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected number, got %T", f.name, value)
+		}
+		var n uint64
+		var err error
+		if f.kind == kindInt {
+			var i int64
+			i, err = number.Int64()
+			n = uint64(i)
+		} else {
+			n, err = strconv.ParseUint(number.String(), 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		buf = appendTag(buf, f.number, wireVarint)
+		return binary.AppendUvarint(buf, n), nil
+	case kindDouble:
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected number, got %T", f.name, value)
+		}
+		d, err := number.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		buf = appendTag(buf, f.number, wireFixed64)
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(d)), nil
+	case kindString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected string, got %T", f.name, value)
+		}
+		return appendBytes(buf, f.number, []byte(s)), nil
+	case kindBytes:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected string, got %T", f.name, value)
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		return appendBytes(buf, f.number, data), nil
+	case kindJSON:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buf, f.number, data), nil
+	case kindMessage:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object, got %T", f.name, value)
+		}
+		data, err := encodeMessage(nil, f.message, m)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buf, f.number, data), nil
+	case kindMap:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object, got %T", f.name, value)
+		}
+		for key, v := range m {
+			entry := appendBytes(nil, 1, []byte(key))
+			entry, err := encodeValue(entry, f.value, v)
+			if err != nil {
+				return nil, err
+			}
+			buf = appendBytes(buf, f.number, entry)
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("%s: unknown field kind", f.name)
+}
+
+// decoder reads wire format fields.
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 {
+		return 0, fmt.Errorf("invalid varint")
+	}
+	d.data = d.data[size:]
+	return n, nil
+}
+
+func (d *decoder) fixed(size int) ([]byte, error) {
+	if len(d.data) < size {
+		return nil, fmt.Errorf("unexpected end of message")
+	}
+	value := d.data[:size]
+	d.data = d.data[size:]
+	return value, nil
+}
+
+// next reads a field; the value is either a varint, fixed64/32 or the
+// bytes of a length delimited field.
+func (d *decoder) next() (number int, wireType int, varint uint64, data []byte, err error) {
+	tag, err := d.uvarint()
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	number, wireType = int(tag>>3), int(tag&7)
+	switch wireType {
+	case wireVarint:
+		varint, err = d.uvarint()
+	case wireFixed64:
+		data, err = d.fixed(8)
+	case wireFixed32:
+		data, err = d.fixed(4)
+	case wireBytes:
+		var size uint64
+		if size, err = d.uvarint(); err == nil {
+			if size > uint64(len(d.data)) {
+				err = fmt.Errorf("unexpected end of message")
+			} else {
+				data, err = d.fixed(int(size))
+			}
+		}
+	default:
+		err = fmt.Errorf("unsupported wire type %d", wireType)
+	}
+	return number, wireType, varint, data, err
+}
+
+func decodeMessage(msg *message, data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	d := &decoder{data}
+	for len(d.data) > 0 {
+		number, wireType, varint, data, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		f, ok := msg.byIndex[number]
+		if !ok {
+			// Unknown fields are skipped (forward compatibility).
+			continue
+		}
+		if f.kind == kindMap {
+			entries, _ := m[f.name].(map[string]interface{})
+			if entries == nil {
+				entries = make(map[string]interface{})
+				m[f.name] = entries
+			}
+			if err := decodeMapEntry(f, data, entries); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if f.repeated && wireType == wireBytes && isPackable(f.kind) {
+			// Packed repeated scalars.
+			values, err := decodePacked(f, data)
+			if err != nil {
+				return nil, err
+			}
+			list, _ := m[f.name].([]interface{})
+			m[f.name] = append(list, values...)
+			continue
+		}
+		value, err := decodeValue(f, wireType, varint, data)
+		if err != nil {
+			return nil, err
+		}
+		if f.repeated {
+			list, _ := m[f.name].([]interface{})
+			m[f.name] = append(list, value)
+		} else {
+			m[f.name] = value
+		}
+	}
+	return m, nil
+}
+
+func isPackable(kind fieldKind) bool {
+	return kind == kindBool || kind == kindInt || kind == kindUint ||
+		kind == kindDouble
+}
+
+func decodePacked(f *field, data []byte) ([]interface{}, error) {
+	var values []interface{}
+	d := &decoder{data}
+	for len(d.data) > 0 {
+		var value interface{}
+		var err error
+		if f.kind == kindDouble {
+			var fixed []byte
+			if fixed, err = d.fixed(8); err == nil {
+				value, err = decodeValue(f, wireFixed64, 0, fixed)
+			}
+		} else {
+			var n uint64
+			if n, err = d.uvarint(); err == nil {
+				value, err = decodeValue(f, wireVarint, n, nil)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func decodeMapEntry(f *field, data []byte, entries map[string]interface{}) error {
+	var key string
+	var value interface{}
+	d := &decoder{data}
+	for len(d.data) > 0 {
+		number, wireType, varint, data, err := d.next()
+		if err != nil {
+			return err
+		}
+		switch number {
+		case 1:
+			key = string(data)
+		case 2:
+			if value, err = decodeValue(f.value, wireType, varint, data); err != nil {
+				return err
+			}
+		}
+	}
+	entries[key] = value
+	return nil
+}
+
+func decodeValue(f *field, wireType int, varint uint64, data []byte) (interface{}, error) {
+	expected := wireBytes
+	switch f.kind {
+	case kindBool, kindInt, kindUint:
+		expected = wireVarint
+	case kindDouble:
+		expected = wireFixed64
+	}
+	if wireType != expected {
+		return nil, fmt.Errorf("%s: unexpected wire type %d", f.name, wireType)
+	}
+	switch f.kind {
+	case kindBool:
+		return varint != 0, nil
+	case kindInt:
+		return int64(varint), nil
+	case kindUint:
+		return varint, nil
+	case kindDouble:
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+	case kindString:
+		return string(data), nil
+	case kindBytes:
+		return base64.StdEncoding.EncodeToString(data), nil
+	case kindJSON:
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		return value, nil
+	case kindMessage:
+		return decodeMessage(f.message, data)
+	}
+	return nil, fmt.Errorf("%s: unknown field kind", f.name)
+}
@@ -0,0 +1,120 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package protobuf
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Message is an object in its protobuf representation. It implements the
+// proto.Message interface of github.com/golang/protobuf, and the Marshal
+// and Unmarshal methods that the protobuf runtimes and the gRPC codecs use
+// for messages that encode themselves, so that gRPC services can exchange
+// objects without generated Go code:
+//
+//	reply := protobuf.NewMessage("virtual-network")
+//	err := conn.Invoke(ctx, "/contrail.Config/GetNetwork", request, reply)
+//	network := reply.Object.(*types.VirtualNetwork)
+type Message struct {
+	typename string
+	// Object is the object encoded by Marshal, or decoded by Unmarshal.
+	Object contrail.IObject
+}
+
+// NewMessage allocates an empty message for objects of the registered
+// type typename.
+func NewMessage(typename string) *Message {
+	return &Message{typename: typename}
+}
+
+// MessageOf returns the message that holds obj.
+func MessageOf(obj contrail.IObject) *Message {
+	return &Message{typename: obj.GetType(), Object: obj}
+}
+
+// Type returns the type of the objects the message holds.
+func (m *Message) Type() string {
+	return m.typename
+}
+
+// Reset implements proto.Message.
+func (m *Message) Reset() {
+	m.Object = nil
+}
+
+// String implements proto.Message: it returns the JSON encoding of the
+// object.
+func (m *Message) String() string {
+	if m.Object == nil {
+		return m.typename + " {}"
+	}
+	data, err := json.Marshal(m.Object)
+	if err != nil {
+		return fmt.Sprintf("%s %v", m.typename, err)
+	}
+	return m.typename + " " + string(data)
+}
+
+// ProtoMessage implements proto.Message.
+func (*Message) ProtoMessage() {}
+
+// Marshal encodes the object in the protobuf wire format. An empty
+// message encodes as no bytes.
+func (m *Message) Marshal() ([]byte, error) {
+	if m.Object == nil {
+		return nil, nil
+	}
+	if m.Object.GetType() != m.typename {
+		return nil, fmt.Errorf("%s message holds a %s", m.typename,
+			m.Object.GetType())
+	}
+	return ToProto(m.Object)
+}
+
+// Unmarshal decodes an object from the protobuf wire format.
+func (m *Message) Unmarshal(data []byte) error {
+	obj, err := FromProto(m.typename, data)
+	if err != nil {
+		return err
+	}
+	m.Object = obj
+	return nil
+}
+
+// Codec encodes Messages for gRPC. It implements the encoding.Codec
+// interface of google.golang.org/grpc; Marshal also accepts objects. Its
+// name is "proto", since the encoding is the protobuf wire format: pass
+// it to grpc.ForceCodec (or grpc.ForceServerCodec) rather than register
+// it, which would replace the default codec.
+type Codec struct{}
+
+// Name implements encoding.Codec.
+func (Codec) Name() string {
+	return "proto"
+}
+
+// Marshal implements encoding.Codec.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case *Message:
+		return v.Marshal()
+	case contrail.IObject:
+		return ToProto(v)
+	}
+	return nil, fmt.Errorf("protobuf: can't encode %T", v)
+}
+
+// Unmarshal implements encoding.Codec. v must be a *Message, which
+// determines the type of the object.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(*Message)
+	if !ok {
+		return fmt.Errorf("protobuf: can't decode into %T", v)
+	}
+	return m.Unmarshal(data)
+}
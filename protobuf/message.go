@@ -0,0 +1,387 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package protobuf provides a protocol buffers representation of the
+// config objects, for services that exchange them over gRPC.
+//
+// Message definitions are derived from the registered generated types:
+// Schema emits the corresponding .proto file and ToProto/FromProto convert
+// objects to and from the wire format. Message wraps an object as a
+// proto.Message for gRPC services, and Codec encodes it.
+//
+// Field numbers are taken from the tables registered with
+// RegisterFieldNumbers; fields that are not in a table are numbered after
+// its highest number, in the field order of the generated types. A
+// service exports the numbering with FieldNumbers once and registers it
+// at startup, so that the numbers stay the same when the schema adds,
+// removes or reorders fields. The numbers of removed fields are reserved
+// in the .proto file.
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type fieldKind int
+
+const (
+	kindBool fieldKind = iota
+	kindInt
+	kindUint
+	kindDouble
+	kindString
+	kindBytes
+	kindMessage
+	kindMap
+	// Values without a static type (e.g. link attributes) are encoded as
+	// JSON strings.
+	kindJSON
+)
+
+// field describes a message field. Names are the JSON attribute names.
+type field struct {
+	name     string
+	number   int
+	kind     fieldKind
+	repeated bool
+	message  *message
+	// Map entries: key is always a string.
+	value *field
+}
+
+type message struct {
+	name    string
+	fields  []*field
+	byName  map[string]*field
+	byIndex map[int]*field
+	// numbers is the registered numbering of the message, if any.
+	numbers map[string]int
+	next    int
+}
+
+func newMessage(name string) *message {
+	return &message{
+		name:    name,
+		byName:  make(map[string]*field),
+		byIndex: make(map[int]*field),
+		next:    1,
+	}
+}
+
+// setNumbers applies a numbering table: the fields it doesn't hold are
+// numbered after its highest number, or after first.
+func (m *message) setNumbers(numbers map[string]int, first int) {
+	m.numbers = numbers
+	m.next = first
+	for _, number := range numbers {
+		if number >= m.next {
+			m.next = number + 1
+		}
+	}
+}
+
+func (m *message) add(f *field) {
+	if number, ok := m.numbers[f.name]; ok {
+		f.number = number
+	} else {
+		f.number = m.next
+		m.next++
+		if m.next == firstReservedNumber {
+			m.next = lastReservedNumber + 1
+		}
+	}
+	m.fields = append(m.fields, f)
+	m.byName[f.name] = f
+	m.byIndex[f.number] = f
+}
+
+// reserved returns the numbers of the table entries that the message
+// doesn't have a field for, in increasing order.
+func (m *message) reserved() []int {
+	var numbers []int
+	for name, number := range m.numbers {
+		if _, ok := m.byName[name]; !ok {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// Field numbers reserved for the protobuf implementation.
+const (
+	firstReservedNumber = 19000
+	lastReservedNumber  = 19999
+	maxFieldNumber      = 1<<29 - 1
+)
+
+// registry holds the messages derived from the go types.
+type registry struct {
+	mutex    sync.Mutex
+	messages map[reflect.Type]*message
+	// Messages in definition order.
+	order []*message
+	// numbers holds the registered numbering tables, by message name.
+	numbers map[string]map[string]int
+}
+
+func newRegistry() *registry {
+	return &registry{
+		messages: make(map[reflect.Type]*message),
+		numbers:  make(map[string]map[string]int),
+	}
+}
+
+var defaultRegistry = newRegistry()
+
+// commonFields are the attributes encoded by contrail.ObjectBase. They
+// are numbered from 1 in every object message.
+var commonFields = []string{"uuid", "name", "href", "parent_type",
+	"parent_uuid", "parent_href", "fq_name"}
+
+// RegisterFieldNumbers sets the field numbers of a message, named after
+// the generated type (e.g. "VirtualNetwork" or "IdPermsType"), by JSON
+// attribute name. Object messages number the common attributes (uuid,
+// name, href, parent_type, parent_uuid, parent_href and fq_name) from 1 to
+// 7; the table can't use these numbers for other fields. Tables must be
+// registered before the message is first used.
+func RegisterFieldNumbers(messageName string, numbers map[string]int) error {
+	return defaultRegistry.registerNumbers(messageName, numbers)
+}
+
+func (r *registry) registerNumbers(messageName string, numbers map[string]int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, msg := range r.messages {
+		if msg.name == messageName {
+			return fmt.Errorf("%s: message already in use", messageName)
+		}
+	}
+	table := make(map[string]int, len(numbers))
+	used := make(map[int]string, len(numbers))
+	for name, number := range numbers {
+		if number < 1 || number > maxFieldNumber ||
+			(number >= firstReservedNumber && number <= lastReservedNumber) {
+			return fmt.Errorf("%s.%s: invalid field number %d", messageName,
+				name, number)
+		}
+		if other, ok := used[number]; ok {
+			return fmt.Errorf("%s: fields %s and %s both use number %d",
+				messageName, name, other, number)
+		}
+		used[number] = name
+		table[name] = number
+	}
+	r.numbers[messageName] = table
+	return nil
+}
+
+// FieldNumbers returns the numbering of the messages that represent the
+// registered types, by message name, in the format expected by
+// RegisterFieldNumbers.
+func FieldNumbers() (map[string]map[string]int, error) {
+	for _, typename := range contrail.TypeNames() {
+		if _, err := defaultRegistry.objectMessage(typename); err != nil {
+			return nil, err
+		}
+	}
+	defaultRegistry.mutex.Lock()
+	defer defaultRegistry.mutex.Unlock()
+	result := make(map[string]map[string]int)
+	for _, msg := range defaultRegistry.order {
+		numbers := make(map[string]int, len(msg.fields))
+		for _, f := range msg.fields {
+			numbers[f.name] = f.number
+		}
+		result[msg.name] = numbers
+	}
+	return result, nil
+}
+
+// Generated types name their fields after the JSON attributes; other
+// fields are internal state (e.g. the bitmask of valid fields).
+var attributeName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var internalFields = map[string]bool{
+	"valid":    true,
+	"modified": true,
+}
+
+var referenceType = reflect.TypeOf(contrail.Reference{})
+
+// objectMessage returns the message of a registered type.
+func (r *registry) objectMessage(typename string) (*message, error) {
+	obj, err := contrail.NewObject(typename)
+	if err != nil {
+		return nil, err
+	}
+	xtype := reflect.TypeOf(obj).Elem()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if msg, ok := r.messages[xtype]; ok {
+		return msg, nil
+	}
+	msg := newMessage(xtype.Name())
+	numbers := r.numbers[msg.name]
+	for i, name := range commonFields {
+		if number, ok := numbers[name]; ok && number != i+1 {
+			return nil, fmt.Errorf("%s: %s must be numbered %d", msg.name,
+				name, i+1)
+		}
+	}
+	for name, number := range numbers {
+		if number <= len(commonFields) && !isCommonField(name) {
+			return nil, fmt.Errorf("%s.%s: number %d is reserved", msg.name,
+				name, number)
+		}
+	}
+	r.messages[xtype] = msg
+	// Attributes encoded by contrail.ObjectBase.
+	for _, name := range commonFields[:len(commonFields)-1] {
+		msg.add(&field{name: name, kind: kindString})
+	}
+	msg.add(&field{name: "fq_name", kind: kindString, repeated: true})
+	msg.setNumbers(numbers, len(commonFields)+1)
+	for i := 0; i < xtype.NumField(); i++ {
+		sf := xtype.Field(i)
+		if sf.Anonymous || internalFields[sf.Name] ||
+			!attributeName.MatchString(sf.Name) {
+			continue
+		}
+		f, err := r.typeField(sf.Name, sf.Type)
+		if err != nil {
+			delete(r.messages, xtype)
+			return nil, fmt.Errorf("%s.%s: %v", typename, sf.Name, err)
+		}
+		msg.add(f)
+	}
+	r.order = append(r.order, msg)
+	return msg, nil
+}
+
+// typeField describes a field given its go type. The caller must hold the
+// mutex.
+func (r *registry) typeField(name string, xtype reflect.Type) (*field, error) {
+	for xtype.Kind() == reflect.Ptr {
+		xtype = xtype.Elem()
+	}
+	f := &field{name: name}
+	switch xtype.Kind() {
+	case reflect.Bool:
+		f.kind = kindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.kind = kindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.kind = kindUint
+	case reflect.Float32, reflect.Float64:
+		f.kind = kindDouble
+	case reflect.String:
+		f.kind = kindString
+	case reflect.Interface:
+		f.kind = kindJSON
+	case reflect.Slice, reflect.Array:
+		if xtype.Elem().Kind() == reflect.Uint8 {
+			f.kind = kindBytes
+			break
+		}
+		element, err := r.typeField(name, xtype.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if element.repeated || element.kind == kindMap {
+			// Nested lists have no protobuf representation.
+			f.kind = kindJSON
+			break
+		}
+		*f = *element
+		f.repeated = true
+	case reflect.Map:
+		if xtype.Key().Kind() != reflect.String {
+			f.kind = kindJSON
+			break
+		}
+		value, err := r.typeField("value", xtype.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if value.repeated || value.kind == kindMap {
+			f.kind = kindJSON
+			break
+		}
+		value.number = 2
+		f.kind = kindMap
+		f.value = value
+	case reflect.Struct:
+		if xtype.Name() == "" {
+			f.kind = kindJSON
+			break
+		}
+		f.kind = kindMessage
+		f.message = r.structMessage(xtype)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", xtype)
+	}
+	return f, nil
+}
+
+// structMessage returns the message of a property structure. The caller
+// must hold the mutex.
+func (r *registry) structMessage(xtype reflect.Type) *message {
+	if msg, ok := r.messages[xtype]; ok {
+		return msg
+	}
+	msg := newMessage(xtype.Name())
+	if xtype == referenceType {
+		msg.name = "Reference"
+	}
+	msg.setNumbers(r.numbers[msg.name], 1)
+	// Register the message first: structures may be recursive.
+	r.messages[xtype] = msg
+	for i := 0; i < xtype.NumField(); i++ {
+		sf := xtype.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		f, err := r.typeField(name, sf.Type)
+		if err != nil {
+			f = &field{name: name, kind: kindJSON}
+		}
+		msg.add(f)
+	}
+	r.order = append(r.order, msg)
+	return msg
+}
+
+func isCommonField(name string) bool {
+	for _, common := range commonFields {
+		if name == common {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(sf reflect.StructField) string {
+	tag := sf.Tag.Get("json")
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == ',' {
+			tag = tag[:i]
+			break
+		}
+	}
+	if tag == "" {
+		return sf.Name
+	}
+	return tag
+}
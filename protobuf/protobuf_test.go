@@ -0,0 +1,292 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package protobuf
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type testNetworkType struct {
+	AllowTransit bool    `json:"allow_transit,omitempty"`
+	VxlanID      int     `json:"vxlan_network_identifier,omitempty"`
+	Weight       float64 `json:"weight,omitempty"`
+}
+
+// testNetwork follows the layout of the generated types.
+type testNetwork struct {
+	contrail.ObjectBase
+	virtual_network_properties *testNetworkType
+	route_target_list          []string
+	annotations                map[string]string
+	network_ipam_refs          contrail.ReferenceList
+	valid                      uint64
+}
+
+func (*testNetwork) GetType() string               { return "virtual-network" }
+func (*testNetwork) GetDefaultParent() []string    { return []string{"default-project"} }
+func (*testNetwork) GetDefaultParentType() string  { return "project" }
+func (obj *testNetwork) SetName(name string)       { obj.VSetName(obj, name) }
+func (*testNetwork) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testNetwork) UpdateReferences() error       { return nil }
+func (*testNetwork) UpdateDone()                   {}
+
+func (obj *testNetwork) fields() map[string]interface{} {
+	return map[string]interface{}{
+		"virtual_network_properties": &obj.virtual_network_properties,
+		"route_target_list":          &obj.route_target_list,
+		"annotations":                &obj.annotations,
+		"network_ipam_refs":          &obj.network_ipam_refs,
+	}
+}
+
+func (obj *testNetwork) MarshalJSON() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalCommon(m); err != nil {
+		return nil, err
+	}
+	for name, ptr := range obj.fields() {
+		if reflect.ValueOf(ptr).Elem().IsNil() {
+			continue
+		}
+		data, _ := json.Marshal(ptr)
+		raw := json.RawMessage(data)
+		m[name] = &raw
+	}
+	return json.Marshal(m)
+}
+
+func (obj *testNetwork) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	for name, ptr := range obj.fields() {
+		if value, ok := m[name]; ok {
+			if err := json.Unmarshal(value, ptr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func registerTestTypes() {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"virtual-network": reflect.TypeOf(testNetwork{}),
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+	registerTestTypes()
+	obj := new(testNetwork)
+	obj.SetName("net")
+	obj.SetUuid("net-uuid")
+	obj.virtual_network_properties = &testNetworkType{
+		AllowTransit: true, VxlanID: -5, Weight: 0.5}
+	obj.route_target_list = []string{"target:64512:1", "target:64512:2"}
+	obj.annotations = map[string]string{"owner": "team-a"}
+	obj.network_ipam_refs = contrail.ReferenceList{{
+		To:   []string{"default-domain", "default-project", "ipam"},
+		Uuid: "ipam-uuid",
+		Attr: map[string]interface{}{"ipam_subnets": []interface{}{}},
+	}}
+
+	data, err := ToProto(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := FromProto("virtual-network", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := result.(*testNetwork)
+	if network.GetName() != "net" || network.GetUuid() != "net-uuid" ||
+		!reflect.DeepEqual(network.GetFQName(), obj.GetFQName()) {
+		t.Errorf("unexpected identity %s %s %v", network.GetName(),
+			network.GetUuid(), network.GetFQName())
+	}
+	if !reflect.DeepEqual(network.virtual_network_properties, obj.virtual_network_properties) {
+		t.Errorf("unexpected properties %+v", network.virtual_network_properties)
+	}
+	if !reflect.DeepEqual(network.route_target_list, obj.route_target_list) {
+		t.Errorf("unexpected route targets %v", network.route_target_list)
+	}
+	if !reflect.DeepEqual(network.annotations, obj.annotations) {
+		t.Errorf("unexpected annotations %v", network.annotations)
+	}
+	if !reflect.DeepEqual(network.network_ipam_refs, obj.network_ipam_refs) {
+		t.Errorf("unexpected refs %+v", network.network_ipam_refs)
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	registerTestTypes()
+	obj := new(testNetwork)
+	obj.SetFQName("", []string{"a"})
+	obj.SetUuid("u")
+	data, err := ToProto(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// uuid = 1, fq_name = 7.
+	expected := []byte{0x0a, 0x01, 'u', 0x3a, 0x01, 'a'}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("expected %x, got %x", expected, data)
+	}
+
+	// Packed repeated scalars and unknown fields.
+	msg := newMessage("test")
+	msg.add(&field{name: "values", kind: kindInt, repeated: true})
+	m, err := decodeMessage(msg, []byte{0x0a, 0x02, 0x01, 0x02, 0x10, 0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m["values"], []interface{}{int64(1), int64(2)}) {
+		t.Errorf("unexpected values %v", m)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	registerTestTypes()
+	schema, err := Schema("contrail.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"package contrail.config;",
+		"message testNetwork {",
+		"  testNetworkType virtual_network_properties = 8;",
+		"  repeated string route_target_list = 9;",
+		"  map<string, string> annotations = 10;",
+		"  repeated Reference network_ipam_refs = 11;",
+		"  string attr = 4; // JSON encoded",
+	} {
+		if !strings.Contains(schema, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, schema)
+		}
+	}
+	if strings.Contains(schema, "valid") {
+		t.Error("internal field in schema")
+	}
+}
+
+func TestFieldNumbers(t *testing.T) {
+	registerTestTypes()
+	r := newRegistry()
+	err := r.registerNumbers("testNetwork", map[string]int{
+		"network_ipam_refs": 8,
+		"removed_property":  10,
+		"annotations":       12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := r.objectMessage("virtual-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, number := range map[string]int{
+		"uuid":                       1,
+		"fq_name":                    7,
+		"network_ipam_refs":          8,
+		"annotations":                12,
+		"virtual_network_properties": 13,
+		"route_target_list":          14,
+	} {
+		if f := msg.byName[name]; f == nil || f.number != number {
+			t.Errorf("%s: expected %d, got %+v", name, number, f)
+		}
+	}
+	if reserved := msg.reserved(); !reflect.DeepEqual(reserved, []int{10}) {
+		t.Errorf("unexpected reserved numbers %v", reserved)
+	}
+	if err := r.registerNumbers("testNetwork", nil); err == nil {
+		t.Error("numbering of a message in use replaced")
+	}
+
+	for _, numbers := range []map[string]int{
+		{"a": 8, "b": 8},
+		{"a": 0},
+		{"a": 19500},
+	} {
+		if err := newRegistry().registerNumbers("testNetwork", numbers); err == nil {
+			t.Errorf("%v: expected error", numbers)
+		}
+	}
+	for _, numbers := range []map[string]int{
+		{"uuid": 2},
+		{"annotations": 3},
+	} {
+		r := newRegistry()
+		if err := r.registerNumbers("testNetwork", numbers); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.objectMessage("virtual-network"); err == nil {
+			t.Errorf("%v: expected error", numbers)
+		}
+	}
+
+	exported, err := FieldNumbers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported["testNetwork"]["route_target_list"] != 9 ||
+		exported["Reference"]["uuid"] != 2 {
+		t.Errorf("unexpected numbering %v", exported)
+	}
+}
+
+// protoMessage is the proto.Message interface of github.com/golang/protobuf.
+type protoMessage interface {
+	Reset()
+	String() string
+	ProtoMessage()
+}
+
+func TestMessage(t *testing.T) {
+	registerTestTypes()
+	obj := new(testNetwork)
+	obj.SetName("net")
+	obj.SetUuid("net-uuid")
+	obj.route_target_list = []string{"target:64512:1"}
+
+	var codec Codec
+	var request protoMessage = MessageOf(obj)
+	data, err := codec.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if direct, _ := ToProto(obj); !bytes.Equal(data, direct) {
+		t.Errorf("expected %x, got %x", direct, data)
+	}
+	reply := NewMessage("virtual-network")
+	if err := codec.Unmarshal(data, reply); err != nil {
+		t.Fatal(err)
+	}
+	network, ok := reply.Object.(*testNetwork)
+	if !ok || network.GetUuid() != "net-uuid" ||
+		!reflect.DeepEqual(network.route_target_list, obj.route_target_list) {
+		t.Errorf("unexpected object %v", reply)
+	}
+	reply.Reset()
+	if data, err := reply.Marshal(); err != nil || len(data) != 0 {
+		t.Errorf("empty message encoded as %x, %v", data, err)
+	}
+	if _, err := codec.Marshal("string"); err == nil {
+		t.Error("expected error encoding a string")
+	}
+	if err := codec.Unmarshal(data, obj); err == nil {
+		t.Error("expected error decoding into an object")
+	}
+}
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package protobuf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+var scalarTypes = map[fieldKind]string{
+	kindBool:   "bool",
+	kindInt:    "int64",
+	kindUint:   "uint64",
+	kindDouble: "double",
+	kindString: "string",
+	kindBytes:  "bytes",
+	kindJSON:   "string",
+}
+
+func fieldType(f *field) string {
+	if f.kind == kindMessage {
+		return f.message.name
+	}
+	return scalarTypes[f.kind]
+}
+
+// Schema returns the .proto definition of the messages that represent the
+// registered types, in the given protobuf package.
+func Schema(packageName string) (string, error) {
+	for _, typename := range contrail.TypeNames() {
+		if _, err := defaultRegistry.objectMessage(typename); err != nil {
+			return "", err
+		}
+	}
+	defaultRegistry.mutex.Lock()
+	messages := append([]*message(nil), defaultRegistry.order...)
+	defaultRegistry.mutex.Unlock()
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].name < messages[j].name
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "syntax = \"proto3\";\n\npackage %s;\n", packageName)
+	for _, msg := range messages {
+		fmt.Fprintf(&buf, "\nmessage %s {\n", msg.name)
+		for _, f := range msg.fields {
+			var decl string
+			switch {
+			case f.kind == kindMap:
+				decl = fmt.Sprintf("map<string, %s>", fieldType(f.value))
+			case f.repeated:
+				decl = "repeated " + fieldType(f)
+			default:
+				decl = fieldType(f)
+			}
+			comment := ""
+			if f.kind == kindJSON || (f.kind == kindMap && f.value.kind == kindJSON) {
+				comment = " // JSON encoded"
+			}
+			fmt.Fprintf(&buf, "  %s %s = %d;%s\n", decl, f.name, f.number, comment)
+		}
+		if reserved := msg.reserved(); len(reserved) > 0 {
+			numbers := make([]string, len(reserved))
+			for i, number := range reserved {
+				numbers[i] = strconv.Itoa(number)
+			}
+			fmt.Fprintf(&buf, "  reserved %s;\n", strings.Join(numbers, ", "))
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.String(), nil
+}
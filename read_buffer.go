@@ -0,0 +1,81 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity above which buffers are not returned to
+// the pool, so that an occasional large response doesn't stay allocated.
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// readBuffer reads a response body into a pooled buffer.
+func readBuffer(resp *http.Response) (*bytes.Buffer, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if resp.ContentLength > 0 && resp.ContentLength < maxPooledBuffer {
+		buf.Grow(int(resp.ContentLength) + bytes.MinRead)
+	}
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		releaseBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func trimSpace(data []byte) []byte {
+	for len(data) > 0 && isSpace(data[0]) {
+		data = data[1:]
+	}
+	for len(data) > 0 && isSpace(data[len(data)-1]) {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+// objectContent returns the object in a {"<typename>": {...}} response
+// without decoding the envelope, or nil if the response has another
+// shape.
+func objectContent(typename string, body []byte) []byte {
+	data := trimSpace(body)
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return nil
+	}
+	data = trimSpace(data[1 : len(data)-1])
+	key := len(typename) + 2
+	if len(data) < key || data[0] != '"' || data[key-1] != '"' ||
+		string(data[1:key-1]) != typename {
+		return nil
+	}
+	data = trimSpace(data[key:])
+	if len(data) == 0 || data[0] != ':' {
+		return nil
+	}
+	content := trimSpace(data[1:])
+	// Other members would follow the object: the content is then not a
+	// single value.
+	if len(content) == 0 || content[0] != '{' || !json.Valid(content) {
+		return nil
+	}
+	return content
+}
@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func findByUuidServer(b testing.TB) (func(), *Client) {
+	refs := make([]string, 50)
+	for i := range refs {
+		refs[i] = fmt.Sprintf(`{"to": ["default-project", "p%d"], "uuid": "p%d"}`, i, i)
+	}
+	format := fmt.Sprintf(`{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "display_name": "net-%%04d", "test_project_refs": [%s]}}`,
+		strings.Join(refs, ", "))
+	requests := 0
+	server, client := newTestServer(b, func(w http.ResponseWriter, r *http.Request) {
+		// Each response differs, at the same offsets.
+		response := fmt.Sprintf(format, requests%10000)
+		requests++
+		w.Header().Set("Content-Length", fmt.Sprint(len(response)))
+		w.Write([]byte(response))
+	})
+	return server.Close, client
+}
+
+func BenchmarkFindByUuid(b *testing.B) {
+	done, client := findByUuidServer(b)
+	defer done()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.FindByUuid("test-network", "net-uuid"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestObjectContent(t *testing.T) {
+	for body, expected := range map[string]string{
+		`{"test-network": {"uuid": "u"}}`:                `{"uuid": "u"}`,
+		" {\n \"test-network\" :\n{\"uuid\": \"u\"} }\n": `{"uuid": "u"}`,
+		`{"test-network": {"uuid": "u"}, "x": {}}`:       "",
+		`{"x": {}, "test-network": {"uuid": "u"}}`:       "",
+		`{"test-networks": {"uuid": "u"}}`:               "",
+		`{"test-network": {"uuid": "u"`:                  "",
+		`{"test-network": null}`:                         "",
+		`[]`:                                             "",
+	} {
+		if content := objectContent("test-network", []byte(body)); string(content) != expected {
+			t.Errorf("%q: got %q, expected %q", body, content, expected)
+		}
+	}
+}
+
+func TestFindByUuidBuffers(t *testing.T) {
+	done, client := findByUuidServer(t)
+	defer done()
+	for _, dedup := range []bool{false, true} {
+		client.SetReadDeduplication(dedup)
+		first, err := client.FindByUuid("test-network", "net-uuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := first.(*TestNetwork).display_name
+		// Decode other responses into the released buffers.
+		var last string
+		for i := 0; i < 10; i++ {
+			obj, err := client.FindByUuid("test-network", "net-uuid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			last = obj.(*TestNetwork).display_name
+		}
+		network := first.(*TestNetwork)
+		if network.display_name != name || name == last || len(network.test_project_refs) != 50 ||
+			network.test_project_refs[49].Uuid != "p49" {
+			t.Errorf("object modified after its buffer was reused: %+v", network)
+		}
+	}
+}
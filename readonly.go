@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ReadOnlyError is returned by a read-only client for requests that would
+// modify the configuration. The request is not sent.
+type ReadOnlyError struct {
+	Method string
+	Path   string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("read-only client: %s %s rejected", e.Method, e.Path)
+}
+
+// POST endpoints that don't modify the configuration.
+var readOnlyActions = []string{
+	"fqname-to-id",
+	"id-to-fqname",
+	"list-bulk-collection",
+	"subnet-ip-count",
+}
+
+// Neutron plugin operations (/neutron/<resource>) that don't modify the
+// configuration.
+var readOnlyNeutronOperations = map[string]bool{
+	"READ":      true,
+	"READALL":   true,
+	"READCOUNT": true,
+}
+
+// SetReadOnly rejects, without contacting the API server, all requests
+// that may modify the configuration: Create, Update, Delete, reference
+// updates and actions such as execute-job. Lookups by name and uuid,
+// lists, reads and neutron plugin read operations are allowed.
+func (c *Client) SetReadOnly(enabled bool) {
+	c.readOnly = enabled
+}
+
+// mayModify returns true if a request may modify the configuration. data
+// is the request body, which identifies the operation of neutron plugin
+// requests.
+func mayModify(method, path string, data []byte) bool {
+	if method == "GET" || method == "HEAD" {
+		return false
+	}
+	if method == "POST" {
+		for _, action := range readOnlyActions {
+			if path == "/"+action || strings.HasSuffix(path, "/"+action) {
+				return false
+			}
+		}
+		if isNeutronRead(path, data) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkReadOnly returns a ReadOnlyError if the client is read-only and
+// the request may modify the configuration.
+func (c *Client) checkReadOnly(method, path string, data []byte) error {
+	if !c.readOnly || !mayModify(method, path, data) {
+		return nil
+	}
+	return &ReadOnlyError{method, path}
+}
+
+func isNeutronRead(path string, data []byte) bool {
+	elements := strings.Split(strings.Trim(path, "/"), "/")
+	if len(elements) < 2 || elements[len(elements)-2] != "neutron" {
+		return false
+	}
+	var msg struct {
+		Context struct {
+			Operation string `json:"operation"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return false
+	}
+	return readOnlyNeutronOperations[msg.Context.Operation]
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	var requests []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/fqname-to-id":
+			w.Write([]byte(`{"uuid": "vn-uuid"}`))
+		case "/id-to-fqname":
+			w.Write([]byte(`{"fq_name": ["default-domain", "p", "vn"], "type": "virtual-network"}`))
+		}
+	})
+	defer server.Close()
+	client.SetReadOnly(true)
+
+	if _, err := client.UuidByName("virtual-network", "default-domain:p:vn"); err != nil {
+		t.Error(err)
+	}
+	if _, err := client.FQNameByUuid("vn-uuid"); err != nil {
+		t.Error(err)
+	}
+	err := client.DeleteByUuid("virtual-network", "vn-uuid")
+	if _, ok := err.(*ReadOnlyError); !ok {
+		t.Errorf("expected ReadOnlyError, got %v", err)
+	}
+	if _, err := client.ExecuteJob(&JobRequest{TemplateID: "t"}); err == nil {
+		t.Error("execute-job allowed on read-only client")
+	}
+	if len(requests) != 2 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+
+	client.SetReadOnly(false)
+	if err := client.DeleteByUuid("virtual-network", "vn-uuid"); err != nil {
+		t.Error(err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
+
+func TestReadOnlyNeutron(t *testing.T) {
+	var requests []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`[]`))
+	})
+	defer server.Close()
+	client.SetReadOnly(true)
+	client.SetRequestCompressionThreshold(1)
+
+	for _, op := range []string{"READ", "READALL", "READCOUNT"} {
+		msg := map[string]interface{}{
+			"context": map[string]string{"operation": op, "type": "network"},
+			"data":    map[string]interface{}{},
+		}
+		if err := client.DoJSON(context.Background(), "POST", "neutron/network", msg, nil); err != nil {
+			t.Errorf("%s: %v", op, err)
+		}
+	}
+	for _, op := range []string{"CREATE", "UPDATE", "DELETE", "ADDINTERFACE", ""} {
+		msg := map[string]interface{}{
+			"context": map[string]string{"operation": op, "type": "network"},
+		}
+		err := client.DoJSON(context.Background(), "POST", "neutron/network", msg, nil)
+		if _, ok := err.(*ReadOnlyError); !ok {
+			t.Errorf("%s: expected ReadOnlyError, got %v", op, err)
+		}
+	}
+	msg := map[string]interface{}{"context": map[string]string{"operation": "READ"}}
+	err := client.DoJSON(context.Background(), "POST", "virtual-network", msg, nil)
+	if _, ok := err.(*ReadOnlyError); !ok {
+		t.Errorf("expected ReadOnlyError, got %v", err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
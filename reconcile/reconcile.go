@@ -0,0 +1,535 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package reconcile converges the configuration of an API server towards a
+// desired object graph: objects that are missing are created, objects that
+// differ are updated and, optionally, objects that are no longer desired
+// are deleted.
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/job"
+)
+
+// Action is the operation of a Change.
+type Action string
+
+const (
+	Create Action = "create"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// Change is an operation required to converge an object.
+type Change struct {
+	Action Action
+	Type   string
+	FQName []string
+	// Uuid of the existing object; set after the object is created.
+	Uuid string
+	// Fields that differ from the desired state (updates).
+	Fields []string
+
+	resource *Resource
+	current  map[string]interface{}
+}
+
+func (c *Change) String() string {
+	s := string(c.Action) + " " + objectKey(c.Type, c.FQName)
+	if len(c.Fields) > 0 {
+		s += " (" + strings.Join(c.Fields, ", ") + ")"
+	}
+	return s
+}
+
+// Plan is the list of changes computed by ComputePlan, in an order that
+// satisfies their dependencies.
+type Plan struct {
+	Changes []*Change
+}
+
+// Options controls a reconciliation.
+type Options struct {
+	// DryRun computes the plan without applying it.
+	DryRun bool
+	// Prune deletes the objects of PruneTypes that are not in the
+	// desired state and for which PruneSelector returns true. A selector
+	// is required; it typically checks an ownership annotation.
+	Prune         bool
+	PruneTypes    []string
+	PruneSelector func(obj contrail.IObject) bool
+	// OnChange is invoked after each change is applied, with the error if
+	// it failed. In dry-run mode it is invoked for each planned change.
+	// Invocations are serialized.
+	OnChange func(change *Change, err error)
+	// Parallelism is the maximum number of changes applied concurrently.
+	// Independent changes are applied in parallel while objects are still
+	// created after their parent and the objects they refer to. The
+	// default is to apply one change at a time.
+	Parallelism int
+}
+
+func isNotFound(err error) bool {
+	if httpErr, ok := err.(*contrail.HTTPError); ok {
+		return httpErr.StatusCode == 404
+	}
+	return strings.HasPrefix(err.Error(), "404")
+}
+
+// Reconcile computes the changes required to converge the API server to
+// the desired state and, unless in dry-run mode, applies them.
+func Reconcile(client contrail.ApiClient, state *State, options *Options) (
+	*Plan, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	plan, err := ComputePlan(client, state, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.DryRun {
+		if options.OnChange != nil {
+			for _, change := range plan.Changes {
+				options.OnChange(change, nil)
+			}
+		}
+		return plan, nil
+	}
+	return plan, Apply(client, plan, options)
+}
+
+// ComputePlan compares the desired state with the API server configuration.
+func ComputePlan(client contrail.ApiClient, state *State, options *Options) (
+	*Plan, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	plan := new(Plan)
+	desired := make(map[string]bool, len(state.Resources))
+	for i := range state.Resources {
+		resource := &state.Resources[i]
+		key := resource.key()
+		if desired[key] {
+			return nil, fmt.Errorf("%s: duplicate resource", key)
+		}
+		desired[key] = true
+		change, err := diff(client, resource)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		if change != nil {
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	if options.Prune {
+		if options.PruneSelector == nil {
+			return nil, fmt.Errorf("Prune requires a PruneSelector")
+		}
+		for _, typename := range options.PruneTypes {
+			objects, err := client.ListDetail(typename, nil)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objects {
+				if desired[objectKey(typename, obj.GetFQName())] ||
+					!options.PruneSelector(obj) {
+					continue
+				}
+				current, err := objectMap(obj)
+				if err != nil {
+					return nil, err
+				}
+				plan.Changes = append(plan.Changes, &Change{
+					Action:  Delete,
+					Type:    typename,
+					FQName:  obj.GetFQName(),
+					Uuid:    obj.GetUuid(),
+					current: current,
+				})
+			}
+		}
+	}
+
+	order, err := plan.order()
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]*Change, len(order))
+	for i, index := range order {
+		changes[i] = plan.Changes[index]
+	}
+	plan.Changes = changes
+	return plan, nil
+}
+
+// diff returns the change required to converge a resource, if any.
+func diff(client contrail.ApiClient, resource *Resource) (*Change, error) {
+	uuid, err := client.UuidByName(resource.Type, strings.Join(resource.FQName, ":"))
+	if err != nil {
+		if !isNotFound(err) {
+			return nil, err
+		}
+		return &Change{
+			Action:   Create,
+			Type:     resource.Type,
+			FQName:   resource.FQName,
+			resource: resource,
+		}, nil
+	}
+	obj, err := client.FindByUuid(resource.Type, uuid)
+	if err != nil {
+		return nil, err
+	}
+	for field := range resource.Spec {
+		if strings.HasSuffix(field, "_refs") {
+			if err := client.GetField(obj, field); err != nil {
+				return nil, err
+			}
+		}
+	}
+	current, err := objectMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for field, value := range resource.Spec {
+		if !fieldEqual(field, value, current[field]) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	sort.Strings(fields)
+	return &Change{
+		Action:   Update,
+		Type:     resource.Type,
+		FQName:   resource.FQName,
+		Uuid:     uuid,
+		Fields:   fields,
+		resource: resource,
+		current:  current,
+	}, nil
+}
+
+// jsonValue normalizes a value to the types produced by the JSON decoder.
+func jsonValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var result interface{}
+	contrail.UnmarshalUseNumber(data, &result)
+	return result
+}
+
+// fieldEqual compares a desired field value with the current one. Objects
+// are compared on the keys of the desired value, so that defaults filled
+// in by the API server don't cause differences. References are compared by
+// target name and attribute, regardless of order.
+func fieldEqual(field string, desired, current interface{}) bool {
+	desired = jsonValue(desired)
+	if strings.HasSuffix(field, "_refs") {
+		return refKeys(desired) == refKeys(current)
+	}
+	return valueEqual(desired, current)
+}
+
+func valueEqual(desired, current interface{}) bool {
+	d, ok := desired.(map[string]interface{})
+	if !ok {
+		if desired == nil {
+			return current == nil
+		}
+		return reflect.DeepEqual(desired, current)
+	}
+	c, _ := current.(map[string]interface{})
+	for key, value := range d {
+		if !valueEqual(value, c[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeValue completes a desired field value with the current one: objects
+// keep the current keys that the desired value doesn't hold, which
+// valueEqual doesn't compare.
+func mergeValue(desired, current interface{}) interface{} {
+	d, ok := desired.(map[string]interface{})
+	if !ok {
+		return desired
+	}
+	c, ok := current.(map[string]interface{})
+	if !ok {
+		return desired
+	}
+	m := make(map[string]interface{}, len(c)+len(d))
+	for key, value := range c {
+		m[key] = value
+	}
+	for key, value := range d {
+		m[key] = mergeValue(value, c[key])
+	}
+	return m
+}
+
+// desiredValue returns the value of a field sent by an update.
+func (c *Change) desiredValue(field string) interface{} {
+	value := jsonValue(c.resource.Spec[field])
+	if strings.HasSuffix(field, "_refs") {
+		return value
+	}
+	return mergeValue(value, c.current[field])
+}
+
+// isRefField returns true for the forward reference lists.
+func isRefField(field string) bool {
+	return strings.HasSuffix(field, "_refs") && !strings.HasSuffix(field, "_back_refs")
+}
+
+// refTargets returns the names of the objects a reference list refers to.
+func refTargets(value interface{}) []string {
+	refs, _ := jsonValue(value).([]interface{})
+	targets := make([]string, 0, len(refs))
+	for _, element := range refs {
+		ref, _ := element.(map[string]interface{})
+		to, _ := ref["to"].([]interface{})
+		names := make([]string, len(to))
+		for k, name := range to {
+			names[k], _ = name.(string)
+		}
+		targets = append(targets, strings.Join(names, ":"))
+	}
+	return targets
+}
+
+// refKeys returns a canonical representation of a reference list.
+func refKeys(value interface{}) string {
+	list, _ := value.([]interface{})
+	keys := make([]string, 0, len(list))
+	for _, element := range list {
+		ref, _ := element.(map[string]interface{})
+		data, _ := json.Marshal([]interface{}{ref["to"], ref["attr"]})
+		keys = append(keys, string(data))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+// Dependencies returns, for each change, the indices of the changes that
+// must be applied before it: objects are created after their parent and
+// the objects they refer to; objects are deleted after their children,
+// after the deleted objects that refer to them and after all creates and
+// updates, which may remove references to them.
+func (p *Plan) Dependencies() [][]int {
+	created := make(map[string]int)
+	deleted := make(map[string]int)
+	for i, change := range p.Changes {
+		switch change.Action {
+		case Create:
+			created[strings.Join(change.FQName, ":")] = i
+		case Delete:
+			deleted[strings.Join(change.FQName, ":")] = i
+		}
+	}
+	deps := make([][]int, len(p.Changes))
+	for i, change := range p.Changes {
+		switch change.Action {
+		case Create, Update:
+			if change.Action == Create && len(change.FQName) > 1 {
+				parent := strings.Join(change.FQName[:len(change.FQName)-1], ":")
+				if j, ok := created[parent]; ok {
+					deps[i] = append(deps[i], j)
+				}
+			}
+			for field, value := range change.resource.Spec {
+				if !strings.HasSuffix(field, "_refs") {
+					continue
+				}
+				for _, target := range refTargets(value) {
+					if j, ok := created[target]; ok && j != i {
+						deps[i] = append(deps[i], j)
+					}
+				}
+			}
+		case Delete:
+			for field, value := range change.current {
+				if !isRefField(field) {
+					continue
+				}
+				for _, target := range refTargets(value) {
+					if j, ok := deleted[target]; ok && j != i {
+						deps[j] = append(deps[j], i)
+					}
+				}
+			}
+			for j, other := range p.Changes {
+				if other.Action != Delete {
+					deps[i] = append(deps[i], j)
+					continue
+				}
+				if len(other.FQName) > len(change.FQName) &&
+					strings.HasPrefix(strings.Join(other.FQName, ":"),
+						strings.Join(change.FQName, ":")+":") {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// order returns the change indices sorted such that dependencies come
+// first. The relative order of independent changes is preserved.
+func (p *Plan) order() ([]int, error) {
+	deps := p.Dependencies()
+	done := make([]bool, len(p.Changes))
+	result := make([]int, 0, len(p.Changes))
+	for len(result) < len(p.Changes) {
+		progress := false
+		for i := range p.Changes {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, j := range deps[i] {
+				ready = ready && done[j]
+			}
+			if ready {
+				done[i] = true
+				result = append(result, i)
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, fmt.Errorf("Circular dependencies in desired state")
+		}
+	}
+	return result, nil
+}
+
+// Apply executes the changes of a plan, in parallel when
+// options.Parallelism allows it. It stops at the first failure.
+func Apply(client contrail.ApiClient, plan *Plan, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	var mutex sync.Mutex
+	return job.Schedule(context.Background(), plan.Dependencies(),
+		options.Parallelism, func(ctx context.Context, i int) error {
+			change := plan.Changes[i]
+			err := applyChange(client, change)
+			if options.OnChange != nil {
+				mutex.Lock()
+				options.OnChange(change, err)
+				mutex.Unlock()
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %v", change, err)
+			}
+			return nil
+		})
+}
+
+func applyChange(client contrail.ApiClient, change *Change) error {
+	switch change.Action {
+	case Create:
+		obj, err := newObject(change.resource)
+		if err != nil {
+			return err
+		}
+		if err := client.Create(obj); err != nil {
+			return err
+		}
+		change.Uuid = obj.GetUuid()
+		return nil
+	case Update:
+		return update(client, change)
+	case Delete:
+		return client.DeleteByUuid(change.Type, change.Uuid)
+	}
+	return fmt.Errorf("unknown action %s", change.Action)
+}
+
+// newObject allocates an object from a resource.
+func newObject(resource *Resource) (contrail.IObject, error) {
+	obj, err := contrail.NewObject(resource.Type)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(resource.Spec)+4)
+	for key, value := range resource.Spec {
+		m[key] = value
+	}
+	m["uuid"] = ""
+	m["fq_name"] = resource.FQName
+	m["name"] = resource.FQName[len(resource.FQName)-1]
+	parentType := resource.ParentType
+	if parentType == "" {
+		parentType = obj.GetDefaultParentType()
+	}
+	if parentType != "" && len(resource.FQName) > 1 {
+		m["parent_type"] = parentType
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// jsonDoer is implemented by contrail.Client.
+type jsonDoer interface {
+	DoJSON(ctx context.Context, method, path string, request, response interface{}) error
+}
+
+// update sends the fields that differ. The desired value of an object
+// property is merged into the current one, so that the keys it doesn't
+// hold are preserved. When the client doesn't support partial updates, the
+// desired fields are merged into the current object.
+func update(client contrail.ApiClient, change *Change) error {
+	if doer, ok := client.(jsonDoer); ok {
+		m := map[string]interface{}{
+			"uuid":    change.Uuid,
+			"fq_name": change.FQName,
+		}
+		for _, field := range change.Fields {
+			m[field] = change.desiredValue(field)
+		}
+		msg := map[string]interface{}{change.Type: m}
+		return doer.DoJSON(context.Background(), "PUT",
+			change.Type+"/"+change.Uuid, msg, nil)
+	}
+
+	obj, err := client.FindByUuid(change.Type, change.Uuid)
+	if err != nil {
+		return err
+	}
+	for _, field := range change.Fields {
+		change.current[field] = change.desiredValue(field)
+	}
+	change.current["name"] = change.FQName[len(change.FQName)-1]
+	data, err := json.Marshal(change.current)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return err
+	}
+	return client.Update(obj)
+}
@@ -0,0 +1,401 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// testObject stores its properties and references as raw JSON fields.
+type testObject struct {
+	contrail.ObjectBase
+	fields map[string]json.RawMessage
+}
+
+func (*testObject) GetDefaultParent() []string    { return []string{"default-project"} }
+func (*testObject) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testObject) UpdateReferences() error       { return nil }
+func (*testObject) UpdateDone()                   {}
+
+var commonFields = map[string]bool{
+	"uuid": true, "name": true, "fq_name": true, "href": true,
+	"parent_type": true, "parent_uuid": true, "parent_href": true,
+}
+
+func (obj *testObject) MarshalJSON() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalCommon(m); err != nil {
+		return nil, err
+	}
+	for key, value := range obj.fields {
+		raw := value
+		m[key] = &raw
+	}
+	return json.Marshal(m)
+}
+
+func (obj *testObject) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	obj.fields = make(map[string]json.RawMessage)
+	for key, value := range m {
+		if !commonFields[key] {
+			obj.fields[key] = value
+		}
+	}
+	return nil
+}
+
+type testNetwork struct{ testObject }
+
+func (*testNetwork) GetType() string              { return "test-network" }
+func (*testNetwork) GetDefaultParentType() string { return "project" }
+func (obj *testNetwork) SetName(name string)      { obj.VSetName(obj, name) }
+
+type testIpam struct{ testObject }
+
+func (*testIpam) GetType() string              { return "test-ipam" }
+func (*testIpam) GetDefaultParentType() string { return "project" }
+func (obj *testIpam) SetName(name string)      { obj.VSetName(obj, name) }
+
+func init() {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"test-network": reflect.TypeOf(testNetwork{}),
+		"test-ipam":    reflect.TypeOf(testIpam{}),
+	})
+}
+
+// fakeClient is an in-memory API server that records the operations.
+type fakeClient struct {
+	contrail.ApiClient
+	mutex   sync.Mutex
+	objects map[string]contrail.IObject
+	log     []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{objects: make(map[string]contrail.IObject)}
+}
+
+func (c *fakeClient) Create(obj contrail.IObject) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	obj.SetUuid(strings.Join(obj.GetFQName(), ":") + "-uuid")
+	c.objects[obj.GetUuid()] = obj
+	c.log = append(c.log, "create "+obj.GetName())
+	return nil
+}
+
+func (c *fakeClient) Update(obj contrail.IObject) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.objects[obj.GetUuid()] = obj
+	c.log = append(c.log, "update "+obj.GetName())
+	return nil
+}
+
+func (c *fakeClient) DeleteByUuid(typename, uuid string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	obj, ok := c.objects[uuid]
+	if !ok {
+		return fmt.Errorf("404 Not Found")
+	}
+	delete(c.objects, uuid)
+	c.log = append(c.log, "delete "+obj.GetName())
+	return nil
+}
+
+func (c *fakeClient) UuidByName(typename, fqn string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for uuid, obj := range c.objects {
+		if obj.GetType() == typename && strings.Join(obj.GetFQName(), ":") == fqn {
+			return uuid, nil
+		}
+	}
+	return "", fmt.Errorf("404 Not Found: %s %s", typename, fqn)
+}
+
+func (c *fakeClient) FindByUuid(typename, uuid string) (contrail.IObject, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	obj, ok := c.objects[uuid]
+	if !ok {
+		return nil, fmt.Errorf("404 Not Found")
+	}
+	return obj, nil
+}
+
+func (c *fakeClient) GetField(obj contrail.IObject, field string) error {
+	return nil
+}
+
+func (c *fakeClient) ListDetail(typename string, fields []string) (
+	[]contrail.IObject, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var objects []contrail.IObject
+	for _, obj := range c.objects {
+		if obj.GetType() == typename {
+			objects = append(objects, obj)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].GetUuid() < objects[j].GetUuid()
+	})
+	return objects, nil
+}
+
+// add stores an object with the given JSON fields.
+func (c *fakeClient) add(obj contrail.IObject, name, fields string) {
+	data := fmt.Sprintf(`{"fq_name": ["default-project", %q], "uuid": "", "name": %q, %s}`,
+		name, name, fields)
+	if err := json.Unmarshal([]byte(data), obj); err != nil {
+		panic(err)
+	}
+	c.Create(obj)
+	c.log = nil
+}
+
+const testState = `
+resources:
+- type: test-network
+  fq_name: [default-project, web]
+  spec:
+    route_target_list: {route_target: ["target:64512:1"]}
+    network_ipam_refs:
+    - to: [default-project, ipam]
+      attr: {ipam_subnets: [{subnet: {ip_prefix: 10.0.0.0, ip_prefix_len: 24}}]}
+- type: test-ipam
+  fq_name: [default-project, ipam]
+- type: test-network
+  fq_name: [default-project, db]
+  spec:
+    route_target_list: {route_target: ["target:64512:2"]}
+`
+
+func loadTestState(t *testing.T) *State {
+	state, err := LoadYAML(strings.NewReader(testState))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestLoadYAML(t *testing.T) {
+	state := loadTestState(t)
+	if len(state.Resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(state.Resources))
+	}
+	refs := state.Resources[0].Spec["network_ipam_refs"].([]interface{})
+	attr := refs[0].(map[string]interface{})["attr"].(map[string]interface{})
+	subnet := attr["ipam_subnets"].([]interface{})[0].(map[string]interface{})["subnet"]
+	if length := subnet.(map[string]interface{})["ip_prefix_len"]; length != json.Number("24") {
+		t.Errorf("ip_prefix_len: %#v", length)
+	}
+
+	if _, err := LoadYAML(strings.NewReader("resources: [{type: test-network}]")); err == nil {
+		t.Error("expected error for resource without fq_name")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	client := newFakeClient()
+	client.add(new(testNetwork), "db", `"route_target_list": {"route_target": ["target:64512:1"]}`)
+	client.add(new(testNetwork), "old",
+		`"annotations": {"key_value_pair": [{"key": "managed_by", "value": "test"}]}`)
+	client.add(new(testNetwork), "other", `"route_target_list": {}`)
+
+	var changes []string
+	options := &Options{
+		DryRun:     true,
+		Prune:      true,
+		PruneTypes: []string{"test-network"},
+		PruneSelector: func(obj contrail.IObject) bool {
+			m, _ := objectMap(obj)
+			return m["annotations"] != nil
+		},
+		OnChange: func(change *Change, err error) {
+			if err != nil {
+				t.Errorf("%s: %v", change, err)
+			}
+			changes = append(changes, change.String())
+		},
+	}
+	state := loadTestState(t)
+	if _, err := Reconcile(client, state, options); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"create test-ipam default-project:ipam",
+		"update test-network default-project:db (route_target_list)",
+		"create test-network default-project:web",
+		"delete test-network default-project:old",
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("dry-run: expected %v, got %v", expected, changes)
+	}
+	if len(client.log) != 0 {
+		t.Errorf("dry-run modified the configuration: %v", client.log)
+	}
+
+	options.DryRun = false
+	changes = nil
+	if _, err := Reconcile(client, state, options); err != nil {
+		t.Fatal(err)
+	}
+	expectedLog := []string{"create ipam", "update db", "create web", "delete old"}
+	if !reflect.DeepEqual(client.log, expectedLog) {
+		t.Errorf("expected %v, got %v", expectedLog, client.log)
+	}
+
+	// The configuration converged.
+	plan, err := ComputePlan(client, state, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("unexpected changes: %v", plan.Changes)
+	}
+	uuid, _ := client.UuidByName("test-network", "default-project:web")
+	resource, err := ResourceFromObject(client.objects[uuid])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resource.Spec) != 2 {
+		t.Errorf("unexpected resource %+v", resource)
+	}
+}
+
+func TestPlanCircularDependency(t *testing.T) {
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"network_ipam_refs": []interface{}{
+				map[string]interface{}{"to": []string{"default-project", name}},
+			},
+		}
+	}
+	state := &State{Resources: []Resource{
+		{Type: "test-network", FQName: []string{"default-project", "a"}, Spec: ref("b")},
+		{Type: "test-network", FQName: []string{"default-project", "b"}, Spec: ref("a")},
+	}}
+	if _, err := ComputePlan(newFakeClient(), state, nil); err == nil {
+		t.Error("expected circular dependency error")
+	}
+}
+
+func TestApplyParallel(t *testing.T) {
+	state := &State{Resources: []Resource{
+		{Type: "test-ipam", FQName: []string{"default-project", "ipam"}},
+	}}
+	for i := 0; i < 10; i++ {
+		state.Resources = append(state.Resources, Resource{
+			Type:   "test-network",
+			FQName: []string{"default-project", fmt.Sprintf("net%d", i)},
+			Spec: map[string]interface{}{
+				"network_ipam_refs": []interface{}{
+					map[string]interface{}{"to": []string{"default-project", "ipam"}},
+				},
+			},
+		})
+	}
+	client := newFakeClient()
+	if _, err := Reconcile(client, state, &Options{Parallelism: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.log) != 11 || client.log[0] != "create ipam" {
+		t.Errorf("unexpected operations %v", client.log)
+	}
+}
+
+// doerClient records the partial updates sent with DoJSON.
+type doerClient struct {
+	*fakeClient
+	requests []interface{}
+}
+
+func (c *doerClient) DoJSON(ctx context.Context, method, path string,
+	request, response interface{}) error {
+	c.requests = append(c.requests, jsonValue(request))
+	return nil
+}
+
+func TestUpdateMergesObjects(t *testing.T) {
+	state := &State{Resources: []Resource{{
+		Type:   "test-network",
+		FQName: []string{"default-project", "net"},
+		Spec: map[string]interface{}{
+			"id_perms": map[string]interface{}{"description": "new"},
+		},
+	}}}
+	expected := map[string]interface{}{
+		"description": "new",
+		"enable":      true,
+		"permissions": map[string]interface{}{"owner": "admin"},
+	}
+
+	client := newFakeClient()
+	client.add(new(testNetwork), "net",
+		`"id_perms": {"description": "old", "enable": true, "permissions": {"owner": "admin"}}`)
+	if _, err := Reconcile(client, state, nil); err != nil {
+		t.Fatal(err)
+	}
+	uuid, _ := client.UuidByName("test-network", "default-project:net")
+	m, _ := objectMap(client.objects[uuid])
+	if !reflect.DeepEqual(m["id_perms"], expected) {
+		t.Errorf("expected %v, got %v", expected, m["id_perms"])
+	}
+
+	doer := &doerClient{fakeClient: newFakeClient()}
+	doer.add(new(testNetwork), "net",
+		`"id_perms": {"description": "old", "enable": true, "permissions": {"owner": "admin"}}`)
+	if _, err := Reconcile(doer, state, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(doer.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(doer.requests))
+	}
+	msg, _ := doer.requests[0].(map[string]interface{})
+	body, _ := msg["test-network"].(map[string]interface{})
+	if !reflect.DeepEqual(body["id_perms"], expected) {
+		t.Errorf("expected %v, got %v", expected, body["id_perms"])
+	}
+}
+
+func TestPruneReferenceOrder(t *testing.T) {
+	client := newFakeClient()
+	client.add(new(testNetwork), "a",
+		`"annotations": {"key_value_pair": [{"key": "managed_by", "value": "test"}]}`)
+	client.add(new(testNetwork), "b",
+		`"annotations": {"key_value_pair": [{"key": "managed_by", "value": "test"}]},
+		"network_ipam_refs": [{"to": ["default-project", "a"], "uuid": "default-project:a-uuid"}]`)
+	options := &Options{
+		Prune:         true,
+		PruneTypes:    []string{"test-network"},
+		PruneSelector: func(obj contrail.IObject) bool { return true },
+	}
+	if _, err := Reconcile(client, &State{}, options); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"delete b", "delete a"}
+	if !reflect.DeepEqual(client.log, expected) {
+		t.Errorf("expected %v, got %v", expected, client.log)
+	}
+}
@@ -0,0 +1,201 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Resource is the desired state of an object.
+type Resource struct {
+	Type   string   `json:"type" yaml:"type"`
+	FQName []string `json:"fq_name" yaml:"fq_name"`
+	// ParentType defaults to the default parent type of Type.
+	ParentType string `json:"parent_type,omitempty" yaml:"parent_type,omitempty"`
+	// Spec holds properties and forward references in the API server JSON
+	// representation (e.g. "virtual_network_properties"). References are
+	// lists of {"to": <fq_name>, "attr": <attribute>}. Fields that are not
+	// in Spec are not managed.
+	Spec map[string]interface{} `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
+func (r *Resource) key() string {
+	return objectKey(r.Type, r.FQName)
+}
+
+func objectKey(typename string, fqn []string) string {
+	return typename + " " + strings.Join(fqn, ":")
+}
+
+// State is a desired object graph.
+type State struct {
+	Resources []Resource `json:"resources" yaml:"resources"`
+}
+
+// Add appends the current configuration of objects to the state. Fields
+// assigned by the API server (uuids, hrefs, timestamps) are omitted, as
+// well as back references and children.
+func (s *State) Add(objects ...contrail.IObject) error {
+	for _, obj := range objects {
+		resource, err := ResourceFromObject(obj)
+		if err != nil {
+			return err
+		}
+		s.Resources = append(s.Resources, *resource)
+	}
+	return nil
+}
+
+// LoadYAML reads a State in YAML (or JSON) format:
+//
+//	resources:
+//	- type: virtual-network
+//	  fq_name: [default-domain, demo, web]
+//	  spec:
+//	    network_ipam_refs:
+//	    - to: [default-domain, default-project, default-network-ipam]
+func LoadYAML(r io.Reader) (*State, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	state := new(State)
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	for i := range state.Resources {
+		resource := &state.Resources[i]
+		if resource.Type == "" || len(resource.FQName) == 0 {
+			return nil, fmt.Errorf("resource %d: type and fq_name are required", i)
+		}
+		spec, err := normalize(resource.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", resource.key(), err)
+		}
+		resource.Spec, _ = spec.(map[string]interface{})
+	}
+	return state, nil
+}
+
+// normalize converts a decoded YAML value to the types produced by the JSON
+// decoder.
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid key %v", key)
+			}
+			var err error
+			if m[name], err = normalize(element); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			var err error
+			if m[key], err = normalize(element); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, element := range v {
+			var err error
+			if list[i], err = normalize(element); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	// Numbers and other scalars: round trip through JSON.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = contrail.UnmarshalUseNumber(data, &result)
+	return result, err
+}
+
+// Fields that are assigned by the API server.
+var serverAssignedFields = []string{
+	"uuid", "href", "name", "fq_name", "parent_type", "parent_uuid",
+	"parent_href",
+}
+
+var serverAssignedIdPerms = []string{
+	"uuid", "created", "last_modified",
+}
+
+// ResourceFromObject returns the desired state that corresponds to the
+// current configuration of obj.
+func ResourceFromObject(obj contrail.IObject) (*Resource, error) {
+	m, err := objectMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range serverAssignedFields {
+		delete(m, field)
+	}
+	if idPerms, ok := m["id_perms"].(map[string]interface{}); ok {
+		for _, field := range serverAssignedIdPerms {
+			delete(idPerms, field)
+		}
+	}
+	for key, value := range m {
+		if strings.HasSuffix(key, "_back_refs") {
+			delete(m, key)
+			continue
+		}
+		if !strings.HasSuffix(key, "_refs") {
+			continue
+		}
+		refs, _ := value.([]interface{})
+		for _, ref := range refs {
+			if r, ok := ref.(map[string]interface{}); ok {
+				delete(r, "uuid")
+				delete(r, "href")
+			}
+		}
+	}
+	if fields, err := contrail.TypeReferenceFields(obj.GetType()); err == nil {
+		for _, child := range fields.Children {
+			delete(m, child)
+		}
+	}
+	return &Resource{
+		Type:       obj.GetType(),
+		FQName:     obj.GetFQName(),
+		ParentType: obj.GetParentType(),
+		Spec:       m,
+	}, nil
+}
+
+// objectMap returns the JSON representation of an object.
+func objectMap(obj contrail.IObject) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := contrail.UnmarshalUseNumber(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,278 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package reconcile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// maxExpansion bounds the lists generated by the seq and subnets template
+// functions.
+const maxExpansion = 4096
+
+// Parameter is an input of a Template.
+type Parameter struct {
+	Description string      `yaml:"description,omitempty"`
+	Default     interface{} `yaml:"default,omitempty"`
+}
+
+// Template is a parameterized bundle of resources. The template text
+// consists of an optional YAML document that declares the parameters,
+// followed by a text/template that renders a State:
+//
+//	parameters:
+//	  project: {default: [default-domain, demo]}
+//	  vlans: {default: [100, 101]}
+//	---
+//	resources:
+//	{{- range $i, $subnet := subnets "10.1.0.0/16" 24 (len .vlans) }}
+//	- type: virtual-network
+//	  fq_name: {{ append $.project (printf "vlan-%v" (index $.vlans $i)) | json }}
+//	  spec:
+//	    network_ipam_refs:
+//	    - to: [default-domain, default-project, default-network-ipam]
+//	      attr:
+//	        ipam_subnets:
+//	        - subnet:
+//	            ip_prefix: {{ prefix $subnet }}
+//	            ip_prefix_len: {{ prefixLen $subnet }}
+//	{{- end }}
+//
+// Besides the text/template builtins, templates can use:
+//
+//	seq start end          the integers from start to end, inclusive
+//	subnets cidr len n     the first n subnets of cidr with prefix length len
+//	prefix cidr            the network address of cidr
+//	prefixLen cidr         the prefix length of cidr
+//	host cidr n            the n-th address of cidr (negative from the end)
+//	append list values...  a new list with values appended
+//	json value             value in JSON (and YAML flow) syntax
+type Template struct {
+	Parameters map[string]Parameter
+	tmpl       *template.Template
+}
+
+// ParseTemplate parses the text of a template.
+func ParseTemplate(name string, text string) (*Template, error) {
+	t := &Template{Parameters: make(map[string]Parameter)}
+	body := text
+	if header, rest, ok := splitHeader(text); ok {
+		var decl struct {
+			Parameters map[string]Parameter `yaml:"parameters"`
+		}
+		if err := yaml.Unmarshal([]byte(header), &decl); err != nil {
+			return nil, fmt.Errorf("%s: parameters: %v", name, err)
+		}
+		for key, param := range decl.Parameters {
+			t.Parameters[key] = param
+		}
+		body = rest
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").
+		Funcs(templateFuncs).Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// LoadTemplate reads and parses a template.
+func LoadTemplate(name string, r io.Reader) (*Template, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(name, string(data))
+}
+
+// splitHeader separates the parameters document from the template body.
+func splitHeader(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "parameters:") {
+		return "", "", false
+	}
+	index := strings.Index(text, "\n---\n")
+	if index < 0 {
+		return "", "", false
+	}
+	return text[:index], text[index+len("\n---\n"):], true
+}
+
+// Render executes the template with values for its parameters and decodes
+// the result. Parameters that are not in values take their default;
+// values for undeclared parameters are rejected.
+func (t *Template) Render(values map[string]interface{}) (*State, error) {
+	data := make(map[string]interface{}, len(t.Parameters))
+	for key, value := range values {
+		if _, ok := t.Parameters[key]; !ok {
+			return nil, fmt.Errorf("%s: unknown parameter %s",
+				t.tmpl.Name(), key)
+		}
+		data[key] = value
+	}
+	var missing []string
+	for key, param := range t.Parameters {
+		if _, ok := data[key]; ok {
+			continue
+		}
+		if param.Default == nil {
+			missing = append(missing, key)
+			continue
+		}
+		data[key] = param.Default
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%s: missing parameters: %s",
+			t.tmpl.Name(), strings.Join(missing, ", "))
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	state, err := LoadYAML(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", t.tmpl.Name(), err)
+	}
+	return state, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"seq":       seq,
+	"subnets":   subnets,
+	"prefix":    prefix,
+	"prefixLen": prefixLen,
+	"host":      host,
+	"append":    appendList,
+	"json":      toJSON,
+}
+
+func seq(start, end int) ([]int, error) {
+	if end < start {
+		return nil, nil
+	}
+	if end-start >= maxExpansion {
+		return nil, fmt.Errorf("seq %d %d: too many elements", start, end)
+	}
+	list := make([]int, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		list = append(list, i)
+	}
+	return list, nil
+}
+
+func subnets(cidr string, length int, count int) ([]string, error) {
+	ip, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ones, bits := network.Mask.Size()
+	if length < ones || length > bits {
+		return nil, fmt.Errorf("subnets %s: invalid prefix length %d",
+			cidr, length)
+	}
+	if count > maxExpansion ||
+		(length-ones < 31 && count > 1<<uint(length-ones)) {
+		return nil, fmt.Errorf("subnets %s: cannot allocate %d /%d subnets",
+			cidr, count, length)
+	}
+	if ip.To4() != nil {
+		network.IP = network.IP.To4()
+	}
+	base := new(big.Int).SetBytes(network.IP)
+	step := new(big.Int).Lsh(big.NewInt(1), uint(bits-length))
+	mask := net.CIDRMask(length, bits)
+	list := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		address := bigToIP(base, len(network.IP))
+		list = append(list, (&net.IPNet{IP: address, Mask: mask}).String())
+		base.Add(base, step)
+	}
+	return list, nil
+}
+
+func bigToIP(value *big.Int, size int) net.IP {
+	data := value.Bytes()
+	ip := make(net.IP, size)
+	copy(ip[size-len(data):], data)
+	return ip
+}
+
+func prefix(cidr string) (string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	return network.IP.String(), nil
+}
+
+func prefixLen(cidr string) (int, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0, err
+	}
+	ones, _ := network.Mask.Size()
+	return ones, nil
+}
+
+func host(cidr string, n int) (string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	if ip4 := network.IP.To4(); ip4 != nil {
+		network.IP = ip4
+	}
+	ones, bits := network.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	offset := big.NewInt(int64(n))
+	if n < 0 {
+		offset.Add(offset, size)
+	}
+	if offset.Sign() < 0 || offset.Cmp(size) >= 0 {
+		return "", fmt.Errorf("host %s %d: out of range", cidr, n)
+	}
+	value := new(big.Int).SetBytes(network.IP)
+	value.Add(value, offset)
+	return bigToIP(value, len(network.IP)).String(), nil
+}
+
+func appendList(list interface{}, values ...interface{}) ([]interface{}, error) {
+	var result []interface{}
+	switch v := list.(type) {
+	case []interface{}:
+		result = append(result, v...)
+	case []string:
+		for _, element := range v {
+			result = append(result, element)
+		}
+	case []int:
+		for _, element := range v {
+			result = append(result, element)
+		}
+	default:
+		return nil, fmt.Errorf("append: %T is not a list", list)
+	}
+	return append(result, values...), nil
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package reconcile
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const vlanTemplate = `parameters:
+  project:
+    description: parent project
+    default: [default-domain, demo]
+  vlans:
+    description: VLAN ids, one network each
+  supernet:
+    default: 10.1.0.0/16
+---
+resources:
+{{- range $i, $subnet := subnets .supernet 24 (len .vlans) }}
+- type: virtual-network
+  fq_name: {{ append $.project (printf "vlan-%v" (index $.vlans $i)) | json }}
+  spec:
+    network_ipam_refs:
+    - to: [default-domain, default-project, default-network-ipam]
+      attr:
+        ipam_subnets:
+        - subnet:
+            ip_prefix: {{ prefix $subnet }}
+            ip_prefix_len: {{ prefixLen $subnet }}
+          default_gateway: {{ host $subnet 1 }}
+{{- end }}
+`
+
+func TestTemplateRender(t *testing.T) {
+	tmpl, err := ParseTemplate("vlans", vlanTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := tmpl.Render(map[string]interface{}{
+		"vlans": []int{100, 200},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Resources) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(state.Resources))
+	}
+	resource := state.Resources[1]
+	expected := []string{"default-domain", "demo", "vlan-200"}
+	if !reflect.DeepEqual(resource.FQName, expected) {
+		t.Errorf("fq_name %v, expected %v", resource.FQName, expected)
+	}
+	refs := resource.Spec["network_ipam_refs"].([]interface{})
+	attr := refs[0].(map[string]interface{})["attr"].(map[string]interface{})
+	subnet := attr["ipam_subnets"].([]interface{})[0].(map[string]interface{})
+	if gw := subnet["default_gateway"]; gw != "10.1.1.1" {
+		t.Errorf("default_gateway %v", gw)
+	}
+	prefix := subnet["subnet"].(map[string]interface{})
+	if prefix["ip_prefix"] != "10.1.1.0" ||
+		prefix["ip_prefix_len"] != json.Number("24") {
+		t.Errorf("subnet %v", prefix)
+	}
+}
+
+func TestTemplateParameters(t *testing.T) {
+	tmpl, err := ParseTemplate("vlans", vlanTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Render(nil); err == nil ||
+		!strings.Contains(err.Error(), "missing parameters: vlans") {
+		t.Errorf("expected missing parameter error, got %v", err)
+	}
+	_, err = tmpl.Render(map[string]interface{}{
+		"vlans": []int{100}, "vlan": 1,
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown parameter vlan") {
+		t.Errorf("expected unknown parameter error, got %v", err)
+	}
+	_, err = tmpl.Render(map[string]interface{}{
+		"vlans": []int{100, 200}, "supernet": "10.1.0.0/24",
+	})
+	if err == nil {
+		t.Error("expected an error for an exhausted supernet")
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	list, err := subnets("fd00::/48", 64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list, []string{"fd00::/64", "fd00:0:0:1::/64"}) {
+		t.Errorf("subnets %v", list)
+	}
+	if address, _ := host("192.168.0.0/24", -2); address != "192.168.0.254" {
+		t.Errorf("host %s", address)
+	}
+	if _, err := host("192.168.0.0/24", 256); err == nil {
+		t.Error("expected an out of range error")
+	}
+	if values, _ := seq(10, 12); !reflect.DeepEqual(values, []int{10, 11, 12}) {
+		t.Errorf("seq %v", values)
+	}
+	if _, err := seq(0, maxExpansion); err == nil {
+		t.Error("expected a seq size error")
+	}
+}
@@ -4,6 +4,13 @@
 
 package contrail
 
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
 // LinkAttribute is an attribute on a link between two objects.
 type LinkAttribute interface {
 }
@@ -43,3 +50,186 @@ type ReferenceUpdateMsg struct {
 	// Attribute
 	Attr LinkAttribute `json:"attr"`
 }
+
+// ReferenceFields lists the names of the reference list fields of a type.
+//
+// Field names follow the API server JSON representation: forward references
+// end in _refs, back references in _back_refs and children lists are the
+// plural form of the child type (e.g. virtual_networks).
+type ReferenceFields struct {
+	Refs     []string
+	BackRefs []string
+	Children []string
+}
+
+var referenceListType = reflect.TypeOf(ReferenceList{})
+
+const (
+	refField = iota
+	backRefField
+	childrenField
+)
+
+func classifyReferenceField(name string) int {
+	if strings.HasSuffix(name, "_back_refs") {
+		return backRefField
+	}
+	if strings.HasSuffix(name, "_refs") {
+		return refField
+	}
+	return childrenField
+}
+
+// TypeReferenceFields returns the reference list fields of a registered type.
+func TypeReferenceFields(typename string) (*ReferenceFields, error) {
+	xtype, ok := registeredTypes()[typename]
+	if !ok {
+		return nil, fmt.Errorf("Unknown type %s", typename)
+	}
+	fields := new(ReferenceFields)
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if field.Type != referenceListType {
+			continue
+		}
+		switch classifyReferenceField(field.Name) {
+		case refField:
+			fields.Refs = append(fields.Refs, field.Name)
+		case backRefField:
+			fields.BackRefs = append(fields.BackRefs, field.Name)
+		default:
+			fields.Children = append(fields.Children, field.Name)
+		}
+	}
+	return fields, nil
+}
+
+// TypeNames returns the sorted list of registered type names.
+func TypeNames() []string {
+	types := registeredTypes()
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// referenceGetter returns the name of the generated method that returns
+// a reference list field, e.g. network_ipam_refs -> GetNetworkIpamRefs.
+func referenceGetter(field string) string {
+	name := "Get"
+	for _, word := range strings.Split(field, "_") {
+		if word != "" {
+			name += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return name
+}
+
+// callReferenceGetter invokes a generated reference list getter.
+func callReferenceGetter(obj IObject, field string,
+	getter func() (ReferenceList, error)) (refs ReferenceList, err error) {
+	defer recoverPanic(obj.GetType()+" "+field, &err)
+	return getter()
+}
+
+// GetReferenceList returns the reference list field of obj (e.g.
+// network_ipam_refs), through the generated Get<Field> method or, for a
+// GenericObject, GetReferences. The list is read from the API server
+// unless the object holds it: objects read with the field (ListDetail with
+// fields, ListGeneric) or after GetField don't issue a request.
+func GetReferenceList(obj IObject, field string) (ReferenceList, error) {
+	if generic, ok := obj.(*GenericObject); ok {
+		return generic.GetReferences(field)
+	}
+	method := reflect.ValueOf(obj).MethodByName(referenceGetter(field))
+	if !method.IsValid() {
+		return nil, fmt.Errorf("%s: no reference list %s", obj.GetType(), field)
+	}
+	getter, ok := method.Interface().(func() (ReferenceList, error))
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is not a reference list", obj.GetType(),
+			field)
+	}
+	return callReferenceGetter(obj, field, getter)
+}
+
+// objectReferenceFields returns the reference list fields of obj of the
+// given kind. Unregistered GenericObjects only report the forward and back
+// reference lists they hold.
+func objectReferenceFields(obj IObject, kind int) []string {
+	var names []string
+	if generic, ok := obj.(*GenericObject); ok {
+		fields, err := TypeReferenceFields(generic.GetType())
+		if err != nil {
+			for _, name := range generic.Fields() {
+				if strings.HasSuffix(name, "_refs") &&
+					classifyReferenceField(name) == kind {
+					names = append(names, name)
+				}
+			}
+			return names
+		}
+		switch kind {
+		case refField:
+			return fields.Refs
+		case backRefField:
+			return fields.BackRefs
+		}
+		return fields.Children
+	}
+	xtype := reflect.TypeOf(obj).Elem()
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if field.Type == referenceListType &&
+			classifyReferenceField(field.Name) == kind {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+func objectReferenceLists(obj IObject, kind int) (map[string]ReferenceList, error) {
+	result := make(map[string]ReferenceList)
+	for _, field := range objectReferenceFields(obj, kind) {
+		refList, err := GetReferenceList(obj, field)
+		if err != nil {
+			return nil, err
+		}
+		if len(refList) > 0 {
+			result[field] = refList
+		}
+	}
+	return result, nil
+}
+
+// ObjectReferences returns the non-empty forward reference lists of obj,
+// keyed by field name. See GetReferenceList for the requests it issues.
+func ObjectReferences(obj IObject) (map[string]ReferenceList, error) {
+	return objectReferenceLists(obj, refField)
+}
+
+// ObjectBackReferences returns the non-empty back reference lists of obj.
+func ObjectBackReferences(obj IObject) (map[string]ReferenceList, error) {
+	return objectReferenceLists(obj, backRefField)
+}
+
+// ObjectChildren returns the non-empty children lists of obj.
+func ObjectChildren(obj IObject) (map[string]ReferenceList, error) {
+	return objectReferenceLists(obj, childrenField)
+}
+
+// ReferenceFieldType returns the type name a reference field points to
+// (e.g. network_ipam_refs -> network-ipam).
+func ReferenceFieldType(field string) string {
+	name := field
+	if strings.HasSuffix(name, "_back_refs") {
+		name = name[:len(name)-len("_back_refs")]
+	} else if strings.HasSuffix(name, "_refs") {
+		name = name[:len(name)-len("_refs")]
+	} else if strings.HasSuffix(name, "s") {
+		name = name[:len(name)-1]
+	}
+	return strings.Replace(name, "_", "-", -1)
+}
@@ -0,0 +1,150 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RefByName is a reference to an object identified by its fq_name, which
+// is resolved (and optionally created) when the referring object is
+// created.
+type RefByName struct {
+	Type   string
+	FQName []string
+	// Attr is the attribute of the reference, for reference types that
+	// have one (e.g. the subnets of a network-ipam reference).
+	Attr interface{}
+	// CreateMissing creates the target when it doesn't exist, under
+	// ParentType (by default, the default parent type of Type). Init,
+	// when set, is applied to the new object before it is created.
+	CreateMissing bool
+	ParentType    string
+	Init          func(IObject) error
+}
+
+func (r *RefByName) String() string {
+	return r.Type + " " + strings.Join(r.FQName, ":")
+}
+
+// refAdderName returns the name of the generated method that adds a
+// reference, e.g. network-ipam -> AddNetworkIpam.
+func refAdderName(target string) string {
+	name := "Add"
+	for _, word := range strings.Split(target, "-") {
+		if word != "" {
+			name += strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+		}
+	}
+	return name
+}
+
+// addReference adds a reference from ptr to target, using the generated
+// Add<Target> method.
+func addReference(ptr IObject, target IObject, attr interface{}) (err error) {
+	defer recoverPanic("Add "+target.GetType()+" reference", &err)
+	if generic, ok := ptr.(*GenericObject); ok {
+		field := strings.Replace(target.GetType(), "-", "_", -1) + "_refs"
+		return generic.AddReference(field, target, attr)
+	}
+	method := reflect.ValueOf(ptr).MethodByName(refAdderName(target.GetType()))
+	if !method.IsValid() {
+		return fmt.Errorf("%s has no reference to %s", typename(ptr),
+			target.GetType())
+	}
+	mtype := method.Type()
+	targetValue := reflect.ValueOf(target)
+	if mtype.NumIn() < 1 || !targetValue.Type().AssignableTo(mtype.In(0)) {
+		return fmt.Errorf("%s: unexpected type %T for a %s reference",
+			typename(ptr), target, target.GetType())
+	}
+	args := []reflect.Value{targetValue}
+	if mtype.NumIn() == 2 {
+		attrValue := reflect.Zero(mtype.In(1))
+		if attr != nil {
+			attrValue = reflect.ValueOf(attr)
+			if attrValue.Kind() == reflect.Ptr &&
+				!attrValue.Type().AssignableTo(mtype.In(1)) {
+				attrValue = attrValue.Elem()
+			}
+			if !attrValue.Type().AssignableTo(mtype.In(1)) {
+				return fmt.Errorf("%s: attribute of %s reference must be %v",
+					typename(ptr), target.GetType(), mtype.In(1))
+			}
+		}
+		args = append(args, attrValue)
+	} else if attr != nil {
+		return fmt.Errorf("%s: %s references have no attribute",
+			typename(ptr), target.GetType())
+	}
+	results := method.Call(args)
+	if len(results) > 0 {
+		if err, ok := results[len(results)-1].Interface().(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveRef returns the target of ref, creating it if allowed.
+func (c *Client) resolveRef(ref *RefByName) (IObject, error) {
+	name := strings.Join(ref.FQName, ":")
+	target, err := c.FindByName(ref.Type, name)
+	if err == nil || !ref.CreateMissing || !isNotFoundError(err) {
+		return target, err
+	}
+	obj := newObjectOfType(ref.Type)
+	parentType := ref.ParentType
+	if parentType == "" {
+		parentType = obj.GetDefaultParentType()
+	}
+	obj.SetFQName(parentType, ref.FQName)
+	if ref.Init != nil {
+		if err := ref.Init(obj); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Create(obj); err != nil {
+		// Created concurrently by another client.
+		if target, findErr := c.FindByName(ref.Type, name); findErr == nil {
+			return target, nil
+		}
+		return nil, fmt.Errorf("%s: %v", ref, err)
+	}
+	return obj, nil
+}
+
+func isNotFoundError(err error) bool {
+	if httpErr, ok := err.(*HTTPError); ok {
+		return httpErr.StatusCode == 404
+	}
+	return strings.HasPrefix(err.Error(), "404")
+}
+
+// ResolveRefs adds references to ptr for each of refs, looking up the
+// targets by fq_name and creating the missing ones that allow it.
+func (c *Client) ResolveRefs(ptr IObject, refs ...RefByName) error {
+	for i := range refs {
+		target, err := c.resolveRef(&refs[i])
+		if err != nil {
+			return err
+		}
+		if err := addReference(ptr, target, refs[i].Attr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateWithRefs resolves refs (see ResolveRefs) and creates ptr. Targets
+// created on the way are not deleted if the creation of ptr fails.
+func (c *Client) CreateWithRefs(ptr IObject, refs ...RefByName) error {
+	if err := c.ResolveRefs(ptr, refs...); err != nil {
+		return err
+	}
+	return c.Create(ptr)
+}
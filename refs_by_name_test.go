@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func (obj *TestNetwork) AddTestProject(rhs IObject) error {
+	obj.test_project_refs = append(obj.test_project_refs,
+		Reference{To: rhs.GetFQName(), Uuid: rhs.GetUuid()})
+	return nil
+}
+
+func TestCreateWithRefs(t *testing.T) {
+	projects := map[string]string{"default-project": "p1"}
+	var created []string
+	var network map[string]json.RawMessage
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/fqname-to-id":
+			var msg struct {
+				FQName []string `json:"fq_name"`
+			}
+			json.NewDecoder(r.Body).Decode(&msg)
+			uuid, ok := projects[strings.Join(msg.FQName, ":")]
+			if !ok {
+				http.Error(w, "Name not found", http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"uuid": "%s"}`, uuid)
+		case r.Method == "POST" && r.URL.Path == "/test-projects":
+			var msg map[string]struct {
+				FQName []string `json:"fq_name"`
+			}
+			json.NewDecoder(r.Body).Decode(&msg)
+			name := strings.Join(msg["test-project"].FQName, ":")
+			projects[name] = "p2"
+			created = append(created, name)
+			fmt.Fprintf(w, `{"test-project": {"fq_name": ["%s"], "uuid": "p2", "name": "%s", "href": "http://%s/test-project/p2"}}`, name, name, r.Host)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/test-project/"):
+			uuid := strings.TrimPrefix(r.URL.Path, "/test-project/")
+			for name, id := range projects {
+				if id == uuid {
+					fqn, _ := json.Marshal(strings.Split(name, ":"))
+					fmt.Fprintf(w, `{"test-project": {"fq_name": %s, "uuid": "%s", "name": "x"}}`, fqn, uuid)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case r.Method == "POST" && r.URL.Path == "/test-networks":
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			network = msg["test-network"]
+			fmt.Fprintf(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "href": "http://%s/test-network/net-uuid"}}`, r.Host)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	net := new(TestNetwork)
+	net.SetName("net")
+	err := client.CreateWithRefs(net, RefByName{
+		Type: "test-project", FQName: []string{"missing"},
+	})
+	if err == nil || created != nil {
+		t.Errorf("expected a not found error, got %v (created %v)", err, created)
+	}
+
+	net = new(TestNetwork)
+	net.SetName("net")
+	err = client.CreateWithRefs(net,
+		RefByName{Type: "test-project", FQName: []string{"default-project"}},
+		RefByName{Type: "test-project", FQName: []string{"new-project"},
+			CreateMissing: true, ParentType: "domain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0] != "new-project" {
+		t.Errorf("created %v", created)
+	}
+	var refs ReferenceList
+	json.Unmarshal(network["test_project_refs"], &refs)
+	if len(refs) != 2 || refs[0].Uuid != "p1" || refs[1].Uuid != "p2" {
+		t.Errorf("unexpected references %+v", refs)
+	}
+
+	err = client.ResolveRefs(new(TestNetwork), RefByName{
+		Type: "test-project", FQName: []string{"default-project"}, Attr: 1,
+	})
+	if err == nil {
+		t.Error("expected an error for an attribute on a reference without one")
+	}
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+)
+
+var (
+	// typeMap holds the registered types. It is replaced, never modified,
+	// so that the maps returned by registeredTypes can be read without
+	// locking. Protected by schemaMutex.
+	typeMap TypeMap
+	// typeLoaders are the pending lazy registrations.
+	typeLoaders []func() TypeMap
+)
+
+var iobjectType = reflect.TypeOf((*IObject)(nil)).Elem()
+
+// validTypes returns the object types of m. Types that don't implement
+// IObject (through a pointer) are ignored, with a warning, rather than
+// causing panics when objects are allocated.
+func validTypes(m TypeMap) TypeMap {
+	valid := make(TypeMap, len(m))
+	for name, xtype := range m {
+		if xtype == nil || xtype.Kind() != reflect.Struct ||
+			!reflect.PtrTo(xtype).Implements(iobjectType) {
+			fmt.Fprintf(os.Stderr, "WARN type %s: %v is not an object type\n",
+				name, xtype)
+			continue
+		}
+		valid[name] = xtype
+	}
+	return valid
+}
+
+// withTypes returns a copy of typeMap with the types of m added.
+func withTypes(m TypeMap) TypeMap {
+	result := make(TypeMap, len(typeMap)+len(m))
+	for name, xtype := range typeMap {
+		result[name] = xtype
+	}
+	for name, xtype := range validTypes(m) {
+		result[name] = xtype
+	}
+	return result
+}
+
+// RegisterTypeMap is used by the generated types library to register the
+// list of known object types. It replaces the types registered
+// previously, including the pending lazy registrations.
+func RegisterTypeMap(m TypeMap) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	typeMap = validTypes(m)
+	typeLoaders = nil
+	schemaInfo = nil
+}
+
+// RegisterType adds a single type, e.g. from the file that defines it.
+func RegisterType(typename string, xtype reflect.Type) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	typeMap = withTypes(TypeMap{typename: xtype})
+	schemaInfo = nil
+}
+
+// RegisterTypeLoader registers types lazily: loader is invoked the first
+// time the registered types are needed (to allocate, decode or describe an
+// object), rather than when the program starts. Programs that don't use
+// the types don't pay for building the map. loader must not register
+// types itself.
+func RegisterTypeLoader(loader func() TypeMap) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	typeLoaders = append(typeLoaders, loader)
+	schemaInfo = nil
+}
+
+// loadTypes runs the pending loaders and returns the registered types.
+// schemaMutex must be held.
+func loadTypes() TypeMap {
+	for len(typeLoaders) > 0 {
+		loader := typeLoaders[0]
+		typeLoaders = typeLoaders[1:]
+		typeMap = withTypes(loader())
+	}
+	return typeMap
+}
+
+// registeredTypes returns the registered types. The map must not be
+// modified.
+func registeredTypes() TypeMap {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	return loadTypes()
+}
@@ -0,0 +1,42 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegisterTypeLoader(t *testing.T) {
+	defer registerTestTypes()
+	RegisterTypeMap(nil)
+
+	loads := 0
+	RegisterTypeLoader(func() TypeMap {
+		loads++
+		return TypeMap{"test-network": reflect.TypeOf(TestNetwork{})}
+	})
+	RegisterType("nested-network", reflect.TypeOf(nestedNetwork{}))
+	if loads != 0 {
+		t.Fatal("types loaded on registration")
+	}
+	if _, ok := newObjectOfType("test-network").(*TestNetwork); !ok {
+		t.Error("lazily registered type not allocated")
+	}
+	if names := TypeNames(); len(names) != 2 {
+		t.Errorf("registered types %v", names)
+	}
+	if Schema().Type("test-network") == nil {
+		t.Error("lazily registered type not in the schema")
+	}
+	if loads != 1 {
+		t.Errorf("loader invoked %d times", loads)
+	}
+
+	RegisterTypeMap(TypeMap{"nested-network": reflect.TypeOf(nestedNetwork{})})
+	if _, ok := newObjectOfType("test-network").(*GenericObject); !ok {
+		t.Error("RegisterTypeMap didn't replace the registered types")
+	}
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is returned by DoJSON when the API server responds with an
+// unexpected status.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	// RequestID identifies the request in the API server logs.
+	RequestID string
+}
+
+func (e *HTTPError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s: %s (request %s)", e.Status, e.Body, e.RequestID)
+	}
+	return fmt.Sprintf("%s: %s", e.Status, e.Body)
+}
+
+// DoJSON sends a request to an API server endpoint that is not covered by
+// the object API (e.g. the neutron plugin or the execute-job action).
+// The request is JSON encoded unless it is nil; the response is decoded
+// into response unless it is nil. path is relative to the server root.
+func (c *Client) DoJSON(ctx context.Context, method, path string,
+	request, response interface{}) error {
+	var data []byte
+	var bodyType string
+	if request != nil {
+		var err error
+		data, err = json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		bodyType = "application/json"
+	}
+	url := c.baseURL() + "/" + strings.TrimPrefix(path, "/")
+	resp, err := c.httpRequest(ctx, method, url, bodyType, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
+		return &HTTPError{resp.StatusCode, resp.Status, body, RequestID(resp)}
+	}
+	if response == nil || len(body) == 0 {
+		return nil
+	}
+	return c.unmarshal(body, response)
+}
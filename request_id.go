@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// LibraryVersion is the version of the library reported in the
+// User-Agent header.
+const LibraryVersion = "1.1.0"
+
+// RequestIDHeader carries the identifier of each request, so that the
+// API server logs can be correlated with the client's.
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose requests are sent with the given
+// request ID, rather than a generated one (e.g. to propagate the ID of
+// the caller's own request).
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// SetComponent sets the name (and optionally the version, e.g.
+// "kube-manager/2.1") of the program using the client. It is sent at
+// the start of the User-Agent header.
+func (c *Client) SetComponent(name string) {
+	c.component = name
+}
+
+// UserAgent returns the User-Agent header sent by the client.
+func (c *Client) UserAgent() string {
+	agent := "contrail-go-api/" + LibraryVersion
+	if c.component != "" {
+		agent = c.component + " " + agent
+	}
+	return agent
+}
+
+// addRequestHeaders sets the User-Agent header and the request ID.
+func (c *Client) addRequestHeaders(req *http.Request) error {
+	req.Header.Set("User-Agent", c.UserAgent())
+	id, _ := req.Context().Value(requestIDKey{}).(string)
+	if id == "" {
+		uuid, err := newUuid()
+		if err != nil {
+			return err
+		}
+		id = "req-" + uuid
+	}
+	req.Header.Set(RequestIDHeader, id)
+	return nil
+}
+
+// RequestID returns the ID of the request of resp: the one echoed by the
+// server, if any, or the one sent.
+func RequestID(resp *http.Response) string {
+	if id := resp.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	if resp.Request != nil {
+		return resp.Request.Header.Get(RequestIDHeader)
+	}
+	return ""
+}
+
+// responseError is the error reported for an unexpected response.
+func responseError(resp *http.Response, body []byte) error {
+	if id := RequestID(resp); id != "" {
+		return fmt.Errorf("%s: %s (request %s)", resp.Status, body, id)
+	}
+	return fmt.Errorf("%s: %s", resp.Status, body)
+}
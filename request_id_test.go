@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestHeaders(t *testing.T) {
+	var agents, ids []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		ids = append(ids, r.Header.Get(RequestIDHeader))
+		if r.URL.Query().Get("echo") != "" {
+			w.Header().Set(RequestIDHeader, "req-server")
+		}
+		http.Error(w, "failed", http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	_, err := client.FindByUuid("test-network", "x")
+	if err == nil || !strings.HasPrefix(err.Error(), "500") ||
+		!strings.HasSuffix(err.Error(), "(request "+ids[0]+")") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if agents[0] != "contrail-go-api/"+LibraryVersion {
+		t.Errorf("unexpected User-Agent %q", agents[0])
+	}
+
+	client.SetComponent("kube-manager/2.1")
+	ctx := WithRequestID(context.Background(), "req-caller")
+	err = client.DoJSON(ctx, "GET", "/virtual-networks", nil, nil)
+	if httpErr, ok := err.(*HTTPError); !ok || httpErr.RequestID != "req-caller" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if agents[1] != "kube-manager/2.1 contrail-go-api/"+LibraryVersion {
+		t.Errorf("unexpected User-Agent %q", agents[1])
+	}
+
+	err = client.DoJSON(context.Background(), "GET", "/virtual-networks?echo=1", nil, nil)
+	if err == nil || !strings.HasSuffix(err.Error(), "(request req-server)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if ids[0] == ids[2] || !strings.HasPrefix(ids[0], "req-") || len(ids[0]) != 40 {
+		t.Errorf("unexpected request IDs %v", ids)
+	}
+}
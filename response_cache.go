@@ -0,0 +1,197 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// cachedResponse is a GET response with its validators.
+type cachedResponse struct {
+	key          string
+	path         string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// responseCache keeps the most recent GET responses that carry validators
+// (ETag or Last-Modified) and revalidates them with conditional requests.
+type responseCache struct {
+	mutex   sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+	hits    int
+	misses  int
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// cacheKey identifies a response: the same URL may return different
+// contents to different users.
+func cacheKey(req *http.Request) string {
+	return req.URL.String() + "\x00" + req.Header.Get("X-Auth-Token") +
+		"\x00" + req.Header.Get("Authorization")
+}
+
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*cachedResponse)
+}
+
+func (c *responseCache) put(entry *cachedResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[entry.key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// invalidate discards the responses for path, which was modified.
+func (c *responseCache) invalidate(path string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, element := range c.entries {
+		if element.Value.(*cachedResponse).path == path {
+			c.lru.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *responseCache) count(hit bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+func (entry *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+// wrap returns a handler that serves GET requests from the cache when the
+// server confirms (304 Not Modified) that the cached response is current.
+// Servers that return no validators, or ignore conditional requests, are
+// unaffected.
+func (c *responseCache) wrap(next Handler) Handler {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			resp, err := next(req)
+			if err == nil {
+				c.invalidate(req.URL.Path)
+			}
+			return resp, err
+		}
+		key := cacheKey(req)
+		entry := c.get(key)
+		if entry != nil {
+			req = req.Clone(req.Context())
+			if entry.etag != "" {
+				req.Header.Set("If-None-Match", entry.etag)
+			}
+			if entry.lastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.lastModified)
+			}
+		}
+		resp, err := next(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotModified && entry != nil {
+			resp.Body.Close()
+			c.count(true)
+			return entry.response(req), nil
+		}
+		c.count(false)
+		etag, lastModified := resp.Header.Get("ETag"),
+			resp.Header.Get("Last-Modified")
+		if resp.StatusCode != http.StatusOK ||
+			(etag == "" && lastModified == "") {
+			return resp, nil
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		c.put(&cachedResponse{
+			key:          key,
+			path:         req.URL.Path,
+			etag:         etag,
+			lastModified: lastModified,
+			header:       resp.Header.Clone(),
+			body:         body,
+		})
+		return resp, nil
+	}
+}
+
+// SetResponseCache enables the caching of up to size GET responses for
+// which the API server returns validators (ETag or Last-Modified). Cached
+// responses are revalidated with a conditional request at each read, so
+// a read still costs a round trip but unchanged objects (e.g. the large
+// global-system-config) are not transferred again. Servers that don't
+// return validators are unaffected. A size of 0 disables the cache.
+//
+// The cache is shared with the copies of the client made by WithTimeout.
+func (c *Client) SetResponseCache(size int) {
+	if size <= 0 {
+		c.responseCache = nil
+		return
+	}
+	c.responseCache = newResponseCache(size)
+}
+
+// ResponseCacheStats returns the number of GET requests served from the
+// response cache (hits) and from the server (misses).
+func (c *Client) ResponseCacheStats() (hits, misses int) {
+	if c.responseCache == nil {
+		return 0, 0
+	}
+	c.responseCache.mutex.Lock()
+	defer c.responseCache.mutex.Unlock()
+	return c.responseCache.hits, c.responseCache.misses
+}
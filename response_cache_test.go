@@ -0,0 +1,72 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestResponseCache(t *testing.T) {
+	version := 1
+	transfers := 0
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			version++
+			return
+		case "GET":
+		default:
+			t.Errorf("unexpected %s", r.Method)
+			return
+		}
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.URL.Path == "/test-network/u2" {
+			etag = ""
+		} else {
+			w.Header().Set("ETag", etag)
+		}
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		transfers++
+		fmt.Fprintf(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "u1", "name": "net-v%d", "href": "http://%s%s"}}`,
+			version, r.Host, r.URL.Path)
+	})
+	defer server.Close()
+	client.SetResponseCache(10)
+
+	read := func(uuid string) *TestNetwork {
+		obj, err := client.FindByUuid("test-network", uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj.(*TestNetwork)
+	}
+	read("u1")
+	network := read("u1")
+	if hits, misses := client.ResponseCacheStats(); hits != 1 || misses != 1 {
+		t.Errorf("hits %d, misses %d", hits, misses)
+	}
+	if transfers != 1 {
+		t.Errorf("%d transfers", transfers)
+	}
+
+	if err := client.Update(network); err != nil {
+		t.Fatal(err)
+	}
+	if read("u1").GetName() != "net-v2" {
+		t.Error("stale response after update")
+	}
+
+	// No validators: not cached.
+	read("u2")
+	read("u2")
+	if transfers != 4 {
+		t.Errorf("%d transfers", transfers)
+	}
+}
@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FieldKind classifies the fields of an object type.
+type FieldKind int
+
+const (
+	FieldProperty FieldKind = iota
+	FieldRef
+	FieldBackRef
+	FieldChildren
+)
+
+func (k FieldKind) String() string {
+	switch k {
+	case FieldRef:
+		return "ref"
+	case FieldBackRef:
+		return "back-ref"
+	case FieldChildren:
+		return "children"
+	}
+	return "property"
+}
+
+// FieldSchema describes a field of an object type. Names follow the API
+// server JSON representation.
+type FieldSchema struct {
+	Name string
+	Kind FieldKind
+	// Type is the go type of the field: the property value type, or
+	// ReferenceList.
+	Type reflect.Type
+	// Target is the type name that references, back references and
+	// children point to.
+	Target string
+	// AttrType is the type of the attribute of a forward reference, or nil
+	// when the reference has no attribute.
+	AttrType reflect.Type
+}
+
+// TypeSchema describes a registered object type.
+type TypeSchema struct {
+	Name              string
+	DefaultParentType string
+	DefaultParent     []string
+	// Parents lists the types that have this type as children.
+	Parents  []string
+	Children []string
+
+	fields []FieldSchema
+}
+
+// Fields returns the fields of the type, in declaration order.
+func (t *TypeSchema) Fields() []FieldSchema {
+	if t == nil {
+		return nil
+	}
+	return t.fields
+}
+
+// Field returns the field with the given name, or nil.
+func (t *TypeSchema) Field(name string) *FieldSchema {
+	if t == nil {
+		return nil
+	}
+	for i := range t.fields {
+		if t.fields[i].Name == name {
+			return &t.fields[i]
+		}
+	}
+	return nil
+}
+
+// FieldsOfKind returns the fields of the given kind.
+func (t *TypeSchema) FieldsOfKind(kind FieldKind) []FieldSchema {
+	var fields []FieldSchema
+	for _, field := range t.Fields() {
+		if field.Kind == kind {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// SchemaInfo describes the registered object types.
+type SchemaInfo struct {
+	types map[string]*TypeSchema
+}
+
+var (
+	schemaMutex sync.Mutex
+	schemaInfo  *SchemaInfo
+)
+
+// Schema returns the metadata of the types registered by the generated
+// types library. It is derived from the go types on first use.
+func Schema() *SchemaInfo {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	if schemaInfo == nil {
+		schemaInfo = buildSchema(loadTypes())
+	}
+	return schemaInfo
+}
+
+// Types returns the sorted list of type names.
+func (s *SchemaInfo) Types() []string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Type returns the schema of a type, or nil if the type is not registered.
+func (s *SchemaInfo) Type(name string) *TypeSchema {
+	return s.types[name]
+}
+
+// Fields of the generated types that are not attributes.
+var schemaInternalFields = map[string]bool{
+	"valid":    true,
+	"modified": true,
+}
+
+func buildSchema(types TypeMap) *SchemaInfo {
+	s := &SchemaInfo{types: make(map[string]*TypeSchema, len(types))}
+	for name, xtype := range types {
+		obj := reflect.New(xtype).Interface().(IObject)
+		s.types[name] = &TypeSchema{
+			Name:              name,
+			DefaultParentType: obj.GetDefaultParentType(),
+			DefaultParent:     obj.GetDefaultParent(),
+			fields:            typeFields(obj),
+		}
+	}
+	for name, t := range s.types {
+		for _, field := range t.fields {
+			if field.Kind != FieldChildren {
+				continue
+			}
+			t.Children = append(t.Children, field.Target)
+			if child, ok := s.types[field.Target]; ok {
+				child.Parents = append(child.Parents, name)
+			}
+		}
+	}
+	for _, t := range s.types {
+		sort.Strings(t.Parents)
+	}
+	return s
+}
+
+func typeFields(obj IObject) []FieldSchema {
+	value := reflect.ValueOf(obj)
+	xtype := value.Elem().Type()
+	var fields []FieldSchema
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if field.Anonymous || schemaInternalFields[field.Name] ||
+			strings.ToLower(field.Name) != field.Name {
+			continue
+		}
+		schema := FieldSchema{Name: field.Name, Type: field.Type}
+		if field.Type == referenceListType {
+			schema.Target = ReferenceFieldType(field.Name)
+			switch classifyReferenceField(field.Name) {
+			case refField:
+				schema.Kind = FieldRef
+				schema.AttrType = refAttrType(value, schema.Target)
+			case backRefField:
+				schema.Kind = FieldBackRef
+			default:
+				schema.Kind = FieldChildren
+			}
+		}
+		fields = append(fields, schema)
+	}
+	return fields
+}
+
+// refAttrType returns the type of the attribute argument of the generated
+// Add<Target> method, e.g. AddNetworkIpam(*NetworkIpam, VnSubnetsType).
+func refAttrType(value reflect.Value, target string) reflect.Type {
+	method := value.MethodByName(refAdderName(target))
+	if !method.IsValid() || method.Type().NumIn() != 2 {
+		return nil
+	}
+	return method.Type().In(1)
+}
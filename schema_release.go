@@ -0,0 +1,11 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build !contrail_r5
+// +build !contrail_r5
+
+package contrail
+
+// generatedSchemaRelease is the schema release of the published types.
+const generatedSchemaRelease = "2.20"
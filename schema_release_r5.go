@@ -0,0 +1,12 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail
+
+// generatedSchemaRelease is the oldest schema release the contrail_r5
+// types may have been generated from.
+const generatedSchemaRelease = "5.0"
@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"reflect"
+	"testing"
+)
+
+type schemaSubnets struct {
+	Subnets []string `json:"subnets"`
+}
+
+type schemaNetwork struct {
+	graphBase
+	valid                [1]uint64
+	network_mtu          int
+	graph_policy_refs    ReferenceList
+	graph_port_back_refs ReferenceList
+	schema_ipam_refs     ReferenceList
+	schema_subnets       ReferenceList
+}
+
+func (*schemaNetwork) GetType() string                   { return "schema-network" }
+func (*schemaNetwork) GetDefaultParentType() string      { return "graph-project" }
+func (*schemaNetwork) AddGraphPolicy(*graphPolicy) error { return nil }
+func (*schemaNetwork) AddSchemaIpam(obj IObject, data schemaSubnets) error {
+	return nil
+}
+
+type schemaProject struct {
+	graphBase
+	schema_networks ReferenceList
+}
+
+func (*schemaProject) GetType() string { return "schema-project" }
+
+func TestSchema(t *testing.T) {
+	RegisterTypeMap(TypeMap{
+		"schema-network": reflect.TypeOf(schemaNetwork{}),
+		"schema-project": reflect.TypeOf(schemaProject{}),
+		"graph-policy":   reflect.TypeOf(graphPolicy{}),
+	})
+	defer registerTestTypes()
+
+	schema := Schema()
+	if types := schema.Types(); !reflect.DeepEqual(types,
+		[]string{"graph-policy", "schema-network", "schema-project"}) {
+		t.Errorf("types %v", types)
+	}
+	if schema.Type("unknown").Fields() != nil {
+		t.Error("expected no fields for an unknown type")
+	}
+
+	network := schema.Type("schema-network")
+	if network.DefaultParentType != "graph-project" {
+		t.Errorf("default parent type %q", network.DefaultParentType)
+	}
+	var names []string
+	for _, field := range network.Fields() {
+		names = append(names, field.Name+" "+field.Kind.String())
+	}
+	expected := []string{
+		"network_mtu property",
+		"graph_policy_refs ref",
+		"graph_port_back_refs back-ref",
+		"schema_ipam_refs ref",
+		"schema_subnets children",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("fields %v, expected %v", names, expected)
+	}
+	if field := network.Field("network_mtu"); field.Type.Kind() != reflect.Int {
+		t.Errorf("network_mtu type %v", field.Type)
+	}
+	ipam := network.Field("schema_ipam_refs")
+	if ipam.Target != "schema-ipam" ||
+		ipam.AttrType != reflect.TypeOf(schemaSubnets{}) {
+		t.Errorf("schema_ipam_refs: %+v", ipam)
+	}
+	policy := network.Field("graph_policy_refs")
+	if policy.Target != "graph-policy" || policy.AttrType != nil {
+		t.Errorf("graph_policy_refs: %+v", policy)
+	}
+
+	project := schema.Type("schema-project")
+	if !reflect.DeepEqual(project.Children, []string{"schema-network"}) {
+		t.Errorf("project children %v", project.Children)
+	}
+	if !reflect.DeepEqual(network.Parents, []string{"schema-project"}) {
+		t.Errorf("network parents %v", network.Parents)
+	}
+
+	registerTestTypes()
+	if schema := Schema(); schema.Type("schema-network") != nil {
+		t.Error("schema not rebuilt after RegisterTypeMap")
+	}
+}
@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	schemaVersionMutex sync.Mutex
+	schemaVersion      = generatedSchemaRelease
+)
+
+// SetSchemaVersion records the release of the schema the types were
+// generated from, when it differs from the default (2.20, or 5.0 with the
+// contrail_r5 build tag). It is meant to be called by the generated types
+// library, or by programs vendoring types generated from another release.
+func SetSchemaVersion(version string) {
+	schemaVersionMutex.Lock()
+	defer schemaVersionMutex.Unlock()
+	schemaVersion = version
+}
+
+// SchemaVersion returns the release of the schema the types were
+// generated from.
+func SchemaVersion() string {
+	schemaVersionMutex.Lock()
+	defer schemaVersionMutex.Unlock()
+	return schemaVersion
+}
+
+// SchemaReport compares the types of the client with the resources of the
+// API server.
+type SchemaReport struct {
+	ClientSchema APIVersion
+	// ServerVersion is zero if the server doesn't advertise its release.
+	ServerVersion APIVersion
+	// ClientOnly lists the registered types the server doesn't provide:
+	// requests for them fail with 404.
+	ClientOnly []string
+	// ServerOnly lists the server resources without a registered type:
+	// they can only be read as GenericObject.
+	ServerOnly []string
+	// Warnings describe the differences, for logging.
+	Warnings []string
+}
+
+// Compatible returns true if the server provides all the registered types.
+func (r *SchemaReport) Compatible() bool {
+	return len(r.ClientOnly) == 0
+}
+
+func (r *SchemaReport) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// CheckSchema compares the schema the types were generated from with the
+// API server: its release, and the resource types present on one side
+// only. There is no connection step in this client, so applications that
+// want the report call CheckSchema after creating the client. An error is
+// only returned if the server cannot be reached.
+func (c *Client) CheckSchema(ctx context.Context) (*SchemaReport, error) {
+	info, err := c.ServerInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := new(SchemaReport)
+	report.ClientSchema, err = ParseAPIVersion(SchemaVersion())
+	if err != nil {
+		report.warn("Client schema version: %v", err)
+	}
+	if info.Version == "" {
+		report.warn("API server doesn't advertise its version")
+	} else if report.ServerVersion, err = ParseAPIVersion(info.Version); err != nil {
+		report.warn("API server version: %v", err)
+	}
+
+	server := make(map[string]bool)
+	for _, name := range info.Collections {
+		server[name] = true
+	}
+	client := make(map[string]bool)
+	for _, name := range Schema().Types() {
+		client[name] = true
+		if !server[name] {
+			report.ClientOnly = append(report.ClientOnly, name)
+		}
+	}
+	for name := range server {
+		if !client[name] {
+			report.ServerOnly = append(report.ServerOnly, name)
+		}
+	}
+	sort.Strings(report.ServerOnly)
+
+	if report.ServerVersion.Raw != "" && report.ClientSchema.Raw != "" &&
+		!report.ServerVersion.AtLeast(report.ClientSchema.Major,
+			report.ClientSchema.Minor) {
+		report.warn("Types generated from schema %s are more recent than "+
+			"the API server (%s)", report.ClientSchema, report.ServerVersion)
+	}
+	if len(report.ClientOnly) > 0 {
+		report.warn("%d types not provided by the API server: %v",
+			len(report.ClientOnly), report.ClientOnly)
+	}
+	if len(report.ServerOnly) > 0 {
+		report.warn("%d API server resources without types (schema %s is "+
+			"older than the server?): %v", len(report.ServerOnly),
+			report.ClientSchema, report.ServerOnly)
+	}
+	return report, nil
+}
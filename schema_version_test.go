@@ -0,0 +1,55 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCheckSchema(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+	"links": [
+		{"link": {"name": "test-network", "rel": "collection"}},
+		{"link": {"name": "tag", "rel": "collection"}}
+	],
+	"build_info": "{\"build-info\": [{\"build-version\": \"5.1.0\"}]}"
+}`)
+	})
+	defer server.Close()
+	RegisterTypeMap(TypeMap{
+		"test-network": typeMap["test-network"],
+		"generic-test": typeMap["test-network"],
+	})
+
+	report, err := client.CheckSchema(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ServerVersion.Major != 5 || report.Compatible() {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if fmt.Sprint(report.ClientOnly) != "[generic-test]" ||
+		fmt.Sprint(report.ServerOnly) != "[tag]" {
+		t.Errorf("client only %v, server only %v", report.ClientOnly,
+			report.ServerOnly)
+	}
+	if len(report.Warnings) != 2 {
+		t.Errorf("warnings: %q", report.Warnings)
+	}
+
+	defer SetSchemaVersion(SchemaVersion())
+	SetSchemaVersion("2011")
+	report, err = client.CheckSchema(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Warnings) != 3 {
+		t.Errorf("warnings: %q", report.Warnings)
+	}
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// searchParallelism is the number of types listed concurrently by
+// SearchByName.
+const searchParallelism = 4
+
+// SearchResult identifies an object found by SearchByName.
+type SearchResult struct {
+	Type   string
+	FQName []string
+	Uuid   string
+}
+
+// nameMatcher returns a case-insensitive matcher for a SearchByName
+// pattern.
+func nameMatcher(pattern string) (func(fqn []string) bool, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("Invalid pattern %q: %v", pattern, err)
+	}
+	fullName := strings.Contains(pattern, ":")
+	glob := strings.ContainsAny(pattern, "*?[")
+	return func(fqn []string) bool {
+		if len(fqn) == 0 {
+			return false
+		}
+		name := fqn[len(fqn)-1]
+		if fullName {
+			name = strings.Join(fqn, ":")
+		}
+		name = strings.ToLower(name)
+		if !glob {
+			return strings.Contains(name, pattern)
+		}
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// SearchByName lists the objects of the given types (by default, all the
+// registered types) and returns those whose name matches pattern, sorted
+// by type and fq_name. The match is case-insensitive; the pattern is a
+// glob (e.g. "*web*") or, without wildcards, a substring of the name. A
+// pattern that contains ':' is matched against the whole fq_name.
+//
+// The API server only filters on exact property values, so every object
+// of the types is listed: restrict the types when possible.
+func (c *Client) SearchByName(pattern string, types ...string) (
+	[]SearchResult, error) {
+	match, err := nameMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		types = Schema().Types()
+		if len(types) == 0 {
+			return nil, fmt.Errorf("No types to search")
+		}
+	}
+	matches := make([][]SearchResult, len(types))
+	err = parallelDo(len(types), searchParallelism, func(i int) error {
+		results, err := c.ListWithOptions(types[i], ListExcludeHrefs())
+		if err != nil {
+			return fmt.Errorf("%s: %v", types[i], err)
+		}
+		for _, result := range results {
+			if match(result.Fq_name) {
+				matches[i] = append(matches[i], SearchResult{
+					Type:   types[i],
+					FQName: result.Fq_name,
+					Uuid:   result.Uuid,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var found []SearchResult
+	for _, list := range matches {
+		found = append(found, list...)
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Type != found[j].Type {
+			return found[i].Type < found[j].Type
+		}
+		return compareFQName(found[i].FQName, found[j].FQName) < 0
+	})
+	return found, nil
+}
@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSearchByName(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/test-networks":
+			fmt.Fprint(w, `{"test-networks": [
+				{"fq_name": ["default-project", "Web-front"], "uuid": "n1"},
+				{"fq_name": ["default-project", "db"], "uuid": "n2"},
+				{"fq_name": ["admin", "web"], "uuid": "n3"}]}`)
+		case "/test-ports":
+			fmt.Fprint(w, `{"test-ports": [
+				{"fq_name": ["default-project", "web-port"], "uuid": "p1"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	names := func(results []SearchResult) string {
+		var s []string
+		for _, result := range results {
+			s = append(s, fmt.Sprintf("%s/%s", result.Type, result.Uuid))
+		}
+		return fmt.Sprint(s)
+	}
+	results, err := client.SearchByName("web", "test-port", "test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names(results) != "[test-network/n3 test-network/n1 test-port/p1]" {
+		t.Errorf("unexpected results %s", names(results))
+	}
+	results, err = client.SearchByName("web*", "test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names(results) != "[test-network/n3 test-network/n1]" {
+		t.Errorf("unexpected glob results %s", names(results))
+	}
+	results, err = client.SearchByName("default-project:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names(results) != "[test-network/n1 test-network/n2]" {
+		t.Errorf("unexpected fq_name results %s", names(results))
+	}
+	if _, err := client.SearchByName("[", "test-network"); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if _, err := client.SearchByName("web", "unknown"); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
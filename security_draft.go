@@ -0,0 +1,30 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+)
+
+func (c *Client) securityPolicyDraft(scopeUUID, action string) error {
+	msg := map[string]string{
+		"scope_uuid": scopeUUID,
+		"action":     action,
+	}
+	return c.DoJSON(context.Background(), "POST", "security-policy-draft", msg, nil)
+}
+
+// CommitSecurityDraft applies the pending security resource changes
+// (firewall policies, rules, address groups, service groups, tags) of a
+// scope. The scope is the global policy-management or a project.
+func (c *Client) CommitSecurityDraft(scopeUUID string) error {
+	return c.securityPolicyDraft(scopeUUID, "commit")
+}
+
+// DiscardSecurityDraft drops the pending security resource changes of a
+// scope.
+func (c *Client) DiscardSecurityDraft(scopeUUID string) error {
+	return c.securityPolicyDraft(scopeUUID, "discard")
+}
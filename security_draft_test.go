@@ -0,0 +1,42 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSecurityDraft(t *testing.T) {
+	var actions []string
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/security-policy-draft" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		var msg map[string]string
+		json.NewDecoder(r.Body).Decode(&msg)
+		if msg["scope_uuid"] != "scope-1" {
+			http.Error(w, "Scope not found", http.StatusNotFound)
+			return
+		}
+		actions = append(actions, msg["action"])
+	})
+	defer server.Close()
+
+	if err := client.CommitSecurityDraft("scope-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DiscardSecurityDraft("scope-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 || actions[0] != "commit" || actions[1] != "discard" {
+		t.Errorf("unexpected actions %v", actions)
+	}
+	if err := client.CommitSecurityDraft("scope-2"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,262 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/job"
+)
+
+// ImportOptions controls how a snapshot is replayed.
+type ImportOptions struct {
+	// RootFQName renames the snapshot root. The names of all descendants
+	// and of references into the subtree are updated accordingly.
+	RootFQName []string
+	// PreserveUuids creates the objects with the uuids in the snapshot.
+	// By default the target API server assigns new uuids.
+	PreserveUuids bool
+	// SkipExisting skips objects that already exist on the target instead
+	// of failing.
+	SkipExisting bool
+	// Parallelism is the maximum number of objects created concurrently.
+	// Objects are always created after their parent and the objects they
+	// refer to. The default is to create one object at a time.
+	Parallelism int
+}
+
+// ImportResult reports the outcome of an Import.
+type ImportResult struct {
+	// UuidMap maps the uuids in the snapshot to the uuids on the target.
+	UuidMap map[string]string
+	Created int
+	Skipped int
+}
+
+type importEntry struct {
+	object    *Object
+	fqn       []string
+	data      json.RawMessage
+	dependsOn []int
+}
+
+// prepare rewrites the serialized data of an object for the target cluster.
+func (s *Snapshot) prepare(entry *Object, options *ImportOptions,
+	inSnapshot map[string]int) (*importEntry, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(entry.Data, &m); err != nil {
+		return nil, err
+	}
+	result := &importEntry{
+		object: entry,
+		fqn:    rename(entry.FQName, s.RootFQName, options.RootFQName),
+	}
+	for _, field := range serverAssignedFields {
+		delete(m, field)
+	}
+	uuid := ""
+	if options.PreserveUuids {
+		uuid = entry.Uuid
+	} else if idPerms, ok := m["id_perms"]; ok {
+		// id_perms.uuid must mirror the object uuid.
+		var perms map[string]json.RawMessage
+		if err := json.Unmarshal(idPerms, &perms); err != nil {
+			return nil, err
+		}
+		delete(perms, "uuid")
+		data, _ := json.Marshal(perms)
+		m["id_perms"] = data
+	}
+	m["uuid"], _ = json.Marshal(uuid)
+	m["fq_name"], _ = json.Marshal(result.fqn)
+
+	for key, value := range m {
+		if !strings.HasSuffix(key, "_refs") {
+			continue
+		}
+		var refList []reference
+		if err := json.Unmarshal(value, &refList); err != nil {
+			return nil, err
+		}
+		for i := range refList {
+			ref := &refList[i]
+			if index, ok := inSnapshot[ref.Uuid]; ok {
+				result.dependsOn = append(result.dependsOn, index)
+			}
+			ref.To = rename(ref.To, s.RootFQName, options.RootFQName)
+			ref.Href = ""
+			if !options.PreserveUuids {
+				ref.Uuid = ""
+			}
+		}
+		m[key], _ = json.Marshal(refList)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	result.data = data
+	return result, nil
+}
+
+// order sorts the entries such that parents and reference targets are
+// created first. The relative order of independent objects is preserved.
+func order(entries []*importEntry, parentOf []int) ([]*importEntry, error) {
+	done := make([]bool, len(entries))
+	result := make([]*importEntry, 0, len(entries))
+	for len(result) < len(entries) {
+		progress := false
+		for i, entry := range entries {
+			if done[i] {
+				continue
+			}
+			ready := parentOf[i] < 0 || done[parentOf[i]]
+			for _, dep := range entry.dependsOn {
+				if dep != i && !done[dep] {
+					ready = false
+				}
+			}
+			if ready {
+				done[i] = true
+				result = append(result, entry)
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, fmt.Errorf("Circular reference dependencies in snapshot")
+		}
+	}
+	return result, nil
+}
+
+// Import creates the objects in the snapshot on the API server.
+func Import(client contrail.ApiClient, snapshot *Snapshot,
+	options *ImportOptions) (*ImportResult, error) {
+	result := new(ImportResult)
+	err := importSnapshot(context.Background(), client, snapshot, options, result, nil)
+	return result, err
+}
+
+// ImportAsync starts a job that creates the objects in the snapshot. The
+// returned result is only valid after the job terminates.
+func ImportAsync(client contrail.ApiClient, snapshot *Snapshot,
+	options *ImportOptions, callback job.ProgressFunc) (*job.Job, *ImportResult) {
+	result := new(ImportResult)
+	j := job.Start(func(ctx context.Context, j *job.Job) error {
+		return importSnapshot(ctx, client, snapshot, options, result, j)
+	}, callback)
+	return j, result
+}
+
+func importSnapshot(ctx context.Context, client contrail.ApiClient,
+	snapshot *Snapshot, options *ImportOptions, result *ImportResult,
+	j *job.Job) error {
+	opts := ImportOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if len(opts.RootFQName) == 0 {
+		opts.RootFQName = snapshot.RootFQName
+	}
+
+	inSnapshot := make(map[string]int, len(snapshot.Objects))
+	byName := make(map[string]int, len(snapshot.Objects))
+	for i, obj := range snapshot.Objects {
+		inSnapshot[obj.Uuid] = i
+		byName[fqnKey(obj.FQName)] = i
+	}
+
+	entries := make([]*importEntry, len(snapshot.Objects))
+	parentOf := make([]int, len(snapshot.Objects))
+	for i := range snapshot.Objects {
+		obj := &snapshot.Objects[i]
+		entry, err := snapshot.prepare(obj, &opts, inSnapshot)
+		if err != nil {
+			return fmt.Errorf("%s %s: %v", obj.Type, fqnKey(obj.FQName), err)
+		}
+		entries[i] = entry
+		parentOf[i] = -1
+		if len(obj.FQName) > 1 {
+			if index, ok := byName[fqnKey(obj.FQName[:len(obj.FQName)-1])]; ok {
+				parentOf[i] = index
+			}
+		}
+	}
+
+	ordered, err := order(entries, parentOf)
+	if err != nil {
+		return err
+	}
+
+	// Dependencies in terms of positions in the ordered list.
+	position := make(map[*importEntry]int, len(ordered))
+	for i, entry := range ordered {
+		position[entry] = i
+	}
+	deps := make([][]int, len(ordered))
+	for i, entry := range entries {
+		if parentOf[i] >= 0 {
+			deps[position[entry]] = append(deps[position[entry]],
+				position[entries[parentOf[i]]])
+		}
+		for _, dep := range entry.dependsOn {
+			if dep != i {
+				deps[position[entry]] = append(deps[position[entry]],
+					position[entries[dep]])
+			}
+		}
+	}
+
+	result.UuidMap = make(map[string]string)
+	if j != nil {
+		j.SetTotal(len(ordered))
+	}
+	var mutex sync.Mutex
+	return job.Schedule(ctx, deps, opts.Parallelism, func(ctx context.Context, i int) error {
+		entry := ordered[i]
+		name := entry.object.Type + " " + fqnKey(entry.fqn)
+		if j != nil {
+			j.Begin(name)
+		}
+		if opts.SkipExisting {
+			uuid, err := client.UuidByName(entry.object.Type, fqnKey(entry.fqn))
+			if err == nil {
+				mutex.Lock()
+				result.UuidMap[entry.object.Uuid] = uuid
+				result.Skipped++
+				mutex.Unlock()
+				if j != nil {
+					j.Step(name, nil)
+				}
+				return nil
+			}
+		}
+		obj, err := contrail.NewObject(entry.object.Type)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(entry.data, obj); err != nil {
+			return err
+		}
+		err = client.Create(obj)
+		if j != nil {
+			j.Step(name, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		mutex.Lock()
+		result.UuidMap[entry.object.Uuid] = obj.GetUuid()
+		result.Created++
+		mutex.Unlock()
+		return nil
+	})
+}
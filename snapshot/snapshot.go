@@ -0,0 +1,151 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package snapshot captures a configuration subtree (e.g. a project and
+// all its descendants) and replays it on another API server.
+//
+// Objects are captured with their properties and forward references.
+// References are recorded by fully qualified name so that they can be
+// resolved on the target cluster, where UUIDs are normally different.
+package snapshot
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Object is the serialized representation of a configuration object.
+type Object struct {
+	Type   string          `json:"type"`
+	Uuid   string          `json:"uuid"`
+	FQName []string        `json:"fq_name"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Snapshot is a configuration subtree. Objects are stored in depth-first
+// order: parents always precede their children.
+type Snapshot struct {
+	RootType   string    `json:"root_type"`
+	RootFQName []string  `json:"root_fq_name"`
+	Created    time.Time `json:"created"`
+	Objects    []Object  `json:"objects"`
+}
+
+// Fields that are assigned by the API server and must not be replayed.
+var serverAssignedFields = []string{
+	"uuid", "href", "parent_uuid", "parent_href",
+}
+
+type reference struct {
+	To   []string        `json:"to"`
+	Uuid string          `json:"uuid,omitempty"`
+	Href string          `json:"href,omitempty"`
+	Attr json.RawMessage `json:"attr,omitempty"`
+}
+
+// encodeObject serializes an object keeping only properties and forward
+// references.
+func encodeObject(obj contrail.IObject) (*Object, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for key := range m {
+		if strings.HasSuffix(key, "_back_refs") {
+			delete(m, key)
+		}
+	}
+	fields, err := contrail.TypeReferenceFields(obj.GetType())
+	if err == nil {
+		for _, child := range fields.Children {
+			delete(m, child)
+		}
+	}
+	data, err = json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{
+		Type:   obj.GetType(),
+		Uuid:   obj.GetUuid(),
+		FQName: obj.GetFQName(),
+		Data:   data,
+	}, nil
+}
+
+// Export reads the object of type rootType identified by rootFQName and all
+// its descendants.
+func Export(client contrail.ApiClient, rootType string, rootFQName []string) (
+	*Snapshot, error) {
+	root, err := client.FindByName(rootType, strings.Join(rootFQName, ":"))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		RootType:   rootType,
+		RootFQName: rootFQName,
+		Created:    time.Now().UTC(),
+	}
+	if err := snapshot.export(client, root); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (s *Snapshot) export(client contrail.ApiClient, obj contrail.IObject) error {
+	entry, err := encodeObject(obj)
+	if err != nil {
+		return err
+	}
+	s.Objects = append(s.Objects, *entry)
+
+	fields, err := contrail.TypeReferenceFields(obj.GetType())
+	if err != nil {
+		return err
+	}
+	for _, childField := range fields.Children {
+		childType := contrail.ReferenceFieldType(childField)
+		childFields, err := contrail.TypeReferenceFields(childType)
+		if err != nil {
+			continue
+		}
+		children, err := client.ListDetailByParent(
+			childType, obj.GetUuid(), childFields.Refs)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if err := s.export(client, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fqnKey(fqn []string) string {
+	return strings.Join(fqn, ":")
+}
+
+// rename replaces the prefix "from" of a fully qualified name by "to".
+func rename(fqn, from, to []string) []string {
+	if len(from) == 0 || len(fqn) < len(from) {
+		return fqn
+	}
+	for i := range from {
+		if fqn[i] != from[i] {
+			return fqn
+		}
+	}
+	result := make([]string, 0, len(to)+len(fqn)-len(from))
+	result = append(result, to...)
+	return append(result, fqn[len(from):]...)
+}
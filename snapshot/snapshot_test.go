@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package snapshot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPrepareRenames(t *testing.T) {
+	snapshot := &Snapshot{
+		RootType:   "project",
+		RootFQName: []string{"default-domain", "staging"},
+		Objects: []Object{
+			{
+				Type:   "virtual-network",
+				Uuid:   "vn-uuid",
+				FQName: []string{"default-domain", "staging", "net"},
+				Data: json.RawMessage(`{
+					"uuid": "vn-uuid",
+					"href": "http://localhost:8082/virtual-network/vn-uuid",
+					"fq_name": ["default-domain", "staging", "net"],
+					"id_perms": {"enable": true, "uuid": {"uuid_mslong": 1, "uuid_lslong": 2}},
+					"network_policy_refs": [{"to": ["default-domain", "staging", "pol"], "uuid": "pol-uuid"}],
+					"network_ipam_refs": [{"to": ["default-domain", "default-project", "default-network-ipam"], "uuid": "ipam-uuid", "attr": {"ipam_subnets": []}}]
+				}`),
+			},
+			{
+				Type:   "network-policy",
+				Uuid:   "pol-uuid",
+				FQName: []string{"default-domain", "staging", "pol"},
+				Data:   json.RawMessage(`{"uuid": "pol-uuid", "fq_name": ["default-domain", "staging", "pol"]}`),
+			},
+		},
+	}
+	options := &ImportOptions{RootFQName: []string{"default-domain", "prod"}}
+	inSnapshot := map[string]int{"vn-uuid": 0, "pol-uuid": 1}
+	entry, err := snapshot.prepare(&snapshot.Objects[0], options, inSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entry.fqn, []string{"default-domain", "prod", "net"}) {
+		t.Errorf("unexpected fq_name %v", entry.fqn)
+	}
+	if !reflect.DeepEqual(entry.dependsOn, []int{1}) {
+		t.Errorf("unexpected dependencies %v", entry.dependsOn)
+	}
+
+	var m struct {
+		Uuid    string
+		Href    string
+		IdPerms map[string]interface{} `json:"id_perms"`
+		Refs    []reference            `json:"network_policy_refs"`
+		Ipams   []reference            `json:"network_ipam_refs"`
+	}
+	if err := json.Unmarshal(entry.data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Uuid != "" || m.Href != "" {
+		t.Errorf("server assigned fields not removed: %s", entry.data)
+	}
+	if _, ok := m.IdPerms["uuid"]; ok {
+		t.Errorf("id_perms uuid not removed: %s", entry.data)
+	}
+	if len(m.Refs) != 1 || m.Refs[0].Uuid != "" ||
+		!reflect.DeepEqual(m.Refs[0].To, []string{"default-domain", "prod", "pol"}) {
+		t.Errorf("unexpected policy refs %+v", m.Refs)
+	}
+	if len(m.Ipams) != 1 || m.Ipams[0].To[1] != "default-project" || len(m.Ipams[0].Attr) == 0 {
+		t.Errorf("unexpected ipam refs %+v", m.Ipams)
+	}
+
+	entries := []*importEntry{entry, {object: &snapshot.Objects[1]}}
+	ordered, err := order(entries, []int{-1, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ordered[0].object.Type != "network-policy" {
+		t.Errorf("policy must be created before the network")
+	}
+}
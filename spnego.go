@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// GSSAPIProvider is the GSSAPI backend used by SPNEGOAuthenticator to
+// obtain Kerberos tokens. Implementations typically wrap the system GSSAPI
+// library or a pure Go Kerberos client; this package doesn't depend on any.
+type GSSAPIProvider interface {
+	// InitSecContext returns the initial context token for the specified
+	// host based service name (e.g. "HTTP@contrail.example.com").
+	InitSecContext(serviceName string) ([]byte, error)
+}
+
+// SPNEGOAuthenticator authenticates requests to a Kerberos protected API
+// server (or proxy) using the HTTP Negotiate scheme (RFC 4559).
+//
+// A token is generated for each request; the server is expected to accept
+// it without further round trips, as is the case with Kerberos.
+type SPNEGOAuthenticator struct {
+	provider    GSSAPIProvider
+	serviceName string
+	mutex       sync.Mutex
+}
+
+// NewSPNEGOAuthenticator allocates a SPNEGOAuthenticator that obtains
+// tokens from provider.
+func NewSPNEGOAuthenticator(provider GSSAPIProvider) *SPNEGOAuthenticator {
+	return &SPNEGOAuthenticator{provider: provider}
+}
+
+// SetServiceName overrides the service name tokens are requested for. By
+// default it is "HTTP@" followed by the host name of the request URL, which
+// is not adequate when the server is addressed by IP or by an alias.
+func (a *SPNEGOAuthenticator) SetServiceName(name string) {
+	a.serviceName = name
+}
+
+// AddAuthentication implements the Authenticator interface for
+// SPNEGOAuthenticator.
+func (a *SPNEGOAuthenticator) AddAuthentication(req *http.Request) error {
+	name := a.serviceName
+	if name == "" {
+		name = "HTTP@" + req.URL.Hostname()
+	}
+	// GSSAPI implementations are not required to be thread safe.
+	a.mutex.Lock()
+	token, err := a.provider.InitSecContext(name)
+	a.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("spnego: %s: %v", name, err)
+	}
+	req.Header.Set("Authorization",
+		"Negotiate "+base64.StdEncoding.EncodeToString(token))
+	return nil
+}
@@ -0,0 +1,58 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type testGSSAPIProvider struct {
+	names []string
+	err   error
+}
+
+func (p *testGSSAPIProvider) InitSecContext(serviceName string) ([]byte, error) {
+	p.names = append(p.names, serviceName)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []byte("krb5-token"), nil
+}
+
+func TestSPNEGOAuthenticator(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// base64("krb5-token")
+		if r.Header.Get("Authorization") != "Negotiate a3JiNS10b2tlbg==" {
+			w.Header().Set("WWW-Authenticate", "Negotiate")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, rootDocument)
+	})
+	defer server.Close()
+
+	provider := new(testGSSAPIProvider)
+	auth := NewSPNEGOAuthenticator(provider)
+	client.SetAuthenticator(auth)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.names) != 1 || provider.names[0] != "HTTP@"+client.GetServer() {
+		t.Errorf("unexpected service names %v", provider.names)
+	}
+
+	auth.SetServiceName("HTTP@contrail.example.com")
+	provider.err = errors.New("no credentials cache found")
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected error")
+	}
+	if provider.names[1] != "HTTP@contrail.example.com" {
+		t.Errorf("unexpected service name %s", provider.names[1])
+	}
+}
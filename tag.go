@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+)
+
+// Predefined tag types. Objects have at most one tag of each type, except
+// for labels.
+const (
+	TagTypeApplication = "application"
+	TagTypeTier        = "tier"
+	TagTypeDeployment  = "deployment"
+	TagTypeSite        = "site"
+	TagTypeLabel       = "label"
+)
+
+// TagUpdate describes a change to the tags of an object.
+//
+// For single value tag types, Value replaces the current tag; an empty
+// Value removes it. Labels are added and removed with AddValues and
+// DeleteValues. IsGlobal selects the global tags rather than the tags of
+// the object's project.
+type TagUpdate struct {
+	Type         string
+	Value        string
+	AddValues    []string
+	DeleteValues []string
+	IsGlobal     bool
+}
+
+// SetTags modifies the tags of an object (set-tag). The tags are created by
+// the API server when needed. A request holds a single update per tag
+// type: SetTags fails if several updates have the same type.
+func (c *Client) SetTags(typename, uuid string, updates ...TagUpdate) error {
+	msg := map[string]interface{}{
+		"obj_type": typename,
+		"obj_uuid": uuid,
+	}
+	for _, update := range updates {
+		if _, exists := msg[update.Type]; exists {
+			return fmt.Errorf("Duplicate %s tag update", update.Type)
+		}
+		tag := map[string]interface{}{"is_global": update.IsGlobal}
+		if update.Type == TagTypeLabel ||
+			len(update.AddValues) > 0 || len(update.DeleteValues) > 0 {
+			if len(update.AddValues) > 0 {
+				tag["add_values"] = update.AddValues
+			}
+			if len(update.DeleteValues) > 0 {
+				tag["delete_values"] = update.DeleteValues
+			}
+		} else if update.Value != "" {
+			tag["value"] = update.Value
+		} else {
+			tag["value"] = nil
+		}
+		msg[update.Type] = tag
+	}
+	return c.DoJSON(context.Background(), "POST", "set-tag", msg, nil)
+}
@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSetTags(t *testing.T) {
+	var request map[string]interface{}
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/set-tag" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+	})
+	defer server.Close()
+
+	err := client.SetTags("virtual-network", "net-uuid",
+		TagUpdate{Type: TagTypeApplication, Value: "crm", IsGlobal: true},
+		TagUpdate{Type: TagTypeTier},
+		TagUpdate{Type: TagTypeLabel, AddValues: []string{"pci"},
+			DeleteValues: []string{"dev"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"obj_type":    "virtual-network",
+		"obj_uuid":    "net-uuid",
+		"application": map[string]interface{}{"is_global": true, "value": "crm"},
+		"tier":        map[string]interface{}{"is_global": false, "value": nil},
+		"label": map[string]interface{}{
+			"is_global":     false,
+			"add_values":    []interface{}{"pci"},
+			"delete_values": []interface{}{"dev"},
+		},
+	}
+	if !reflect.DeepEqual(request, expected) {
+		t.Errorf("unexpected request %v", request)
+	}
+}
+
+func TestSetTagsDuplicate(t *testing.T) {
+	requests := 0
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	defer server.Close()
+
+	err := client.SetTags("virtual-network", "net-uuid",
+		TagUpdate{Type: TagTypeTier, Value: "web"},
+		TagUpdate{Type: TagTypeTier, Value: "db"})
+	if err == nil {
+		t.Error("duplicate tier updates accepted")
+	}
+	if requests != 0 {
+		t.Errorf("unexpected requests: %d", requests)
+	}
+}
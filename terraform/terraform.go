@@ -0,0 +1,213 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package terraform converts API server objects to and from the
+// map[string]interface{} representation used by Terraform resource data.
+//
+// Attribute names are the API server JSON field names (e.g.
+// virtual_network_properties, network_ipam_refs). Nested structures are
+// represented as single element lists, following the Terraform convention
+// for blocks (TypeList with MaxItems 1); lists of structures (e.g.
+// references) are lists of maps. Link attributes, whose type is not known
+// to this package, are JSON encoded strings. Back references and children lists are
+// not included since they are not part of the object configuration.
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Flatten returns the Terraform representation of obj.
+func Flatten(obj contrail.IObject) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	if fields, err := contrail.TypeReferenceFields(obj.GetType()); err == nil {
+		for _, name := range fields.BackRefs {
+			delete(m, name)
+		}
+		for _, name := range fields.Children {
+			delete(m, name)
+		}
+	}
+	return flattenMap(m, reflect.TypeOf(obj)), nil
+}
+
+func flattenMap(m map[string]interface{}, xtype reflect.Type) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		var ftype reflect.Type
+		if xtype != nil {
+			ftype = fieldType(xtype, key)
+		}
+		if value = flattenValue(value, ftype); value != nil {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// flattenValue converts a decoded JSON value given the type of the field
+// it was encoded from. Values of interface fields (e.g. link attributes)
+// have no schema and are encoded as JSON strings.
+func flattenValue(value interface{}, xtype reflect.Type) interface{} {
+	for xtype != nil && xtype.Kind() == reflect.Ptr {
+		xtype = xtype.Elem()
+	}
+	if xtype != nil && xtype.Kind() == reflect.Interface && value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil
+		}
+		return string(data)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []interface{}{flattenMap(v, xtype)}
+	case []interface{}:
+		var etype reflect.Type
+		if xtype != nil && (xtype.Kind() == reflect.Slice || xtype.Kind() == reflect.Array) {
+			etype = xtype.Elem()
+		}
+		list := make([]interface{}, 0, len(v))
+		for _, element := range v {
+			// Elements of a list are not wrapped.
+			if m, ok := element.(map[string]interface{}); ok {
+				list = append(list, flattenMap(m, etype))
+			} else if element != nil {
+				list = append(list, flattenValue(element, etype))
+			}
+		}
+		return list
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// Expand allocates an object of the registered type typename from its
+// Terraform representation.
+func Expand(typename string, m map[string]interface{}) (contrail.IObject, error) {
+	obj, err := contrail.NewObject(typename)
+	if err != nil {
+		return nil, err
+	}
+	xtype := reflect.TypeOf(obj).Elem()
+	data := make(map[string]interface{}, len(m)+3)
+	for key, value := range m {
+		data[key] = expandValue(value, fieldType(xtype, key))
+	}
+	// Fields decoded unconditionally by ObjectBase.UnmarshalCommon.
+	if _, ok := data["uuid"]; !ok {
+		data["uuid"] = ""
+	}
+	if _, ok := data["fq_name"]; !ok {
+		data["fq_name"] = []string{}
+	}
+	if _, ok := data["name"]; !ok {
+		name := ""
+		if fqn, ok := data["fq_name"].([]interface{}); ok && len(fqn) > 0 {
+			name, _ = fqn[len(fqn)-1].(string)
+		}
+		data["name"] = name
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// fieldType returns the type of the field that encodes the JSON attribute
+// name, or nil when the field is unknown. Generated object types name
+// their (unexported) fields after the attribute; structures used as
+// property values have json tags.
+func fieldType(xtype reflect.Type, name string) reflect.Type {
+	for xtype.Kind() == reflect.Ptr {
+		xtype = xtype.Elem()
+	}
+	if xtype.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if field.Anonymous {
+			if t := fieldType(field.Type, name); t != nil {
+				return t
+			}
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == name || (tag == "" && field.Name == name) {
+			return field.Type
+		}
+	}
+	return nil
+}
+
+// expandValue reverses flattenValue, given the type of the destination.
+// Values of unknown fields are left unchanged.
+func expandValue(value interface{}, xtype reflect.Type) interface{} {
+	for xtype != nil && xtype.Kind() == reflect.Ptr {
+		xtype = xtype.Elem()
+	}
+	if xtype == nil {
+		return value
+	}
+	switch xtype.Kind() {
+	case reflect.Interface:
+		if s, ok := value.(string); ok {
+			var v interface{}
+			if err := json.Unmarshal([]byte(s), &v); err == nil {
+				return v
+			}
+		}
+	case reflect.Struct:
+		if list, ok := value.([]interface{}); ok {
+			if len(list) == 0 {
+				return nil
+			}
+			value = list[0]
+		}
+		if m, ok := value.(map[string]interface{}); ok {
+			return expandMap(m, xtype)
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := value.([]interface{}); ok {
+			result := make([]interface{}, len(list))
+			for i, element := range list {
+				result[i] = expandValue(element, xtype.Elem())
+			}
+			return result
+		}
+	}
+	return value
+}
+
+func expandMap(m map[string]interface{}, xtype reflect.Type) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		result[key] = expandValue(value, fieldType(xtype, key))
+	}
+	return result
+}
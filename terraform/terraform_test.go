@@ -0,0 +1,189 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package terraform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type testNetworkType struct {
+	AllowTransit bool   `json:"allow_transit,omitempty"`
+	VxlanID      int    `json:"vxlan_network_identifier,omitempty"`
+	ForwardMode  string `json:"forwarding_mode,omitempty"`
+}
+
+type testSubnetType struct {
+	IPPrefix    string `json:"ip_prefix"`
+	IPPrefixLen int    `json:"ip_prefix_len"`
+}
+
+type testIpamSubnets struct {
+	Subnets []testSubnetType `json:"ipam_subnets"`
+}
+
+// testNetwork follows the layout of the generated types.
+type testNetwork struct {
+	contrail.ObjectBase
+	virtual_network_properties *testNetworkType
+	route_target_list          []string
+	network_ipam_refs          contrail.ReferenceList
+	instance_ip_back_refs      contrail.ReferenceList
+}
+
+func (*testNetwork) GetType() string               { return "test-network" }
+func (*testNetwork) GetDefaultParent() []string    { return []string{"default-project"} }
+func (*testNetwork) GetDefaultParentType() string  { return "project" }
+func (obj *testNetwork) SetName(name string)       { obj.VSetName(obj, name) }
+func (*testNetwork) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testNetwork) UpdateReferences() error       { return nil }
+func (*testNetwork) UpdateDone()                   {}
+
+func (obj *testNetwork) MarshalJSON() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalCommon(m); err != nil {
+		return nil, err
+	}
+	set := func(name string, value interface{}) {
+		data, _ := json.Marshal(value)
+		raw := json.RawMessage(data)
+		m[name] = &raw
+	}
+	if obj.virtual_network_properties != nil {
+		set("virtual_network_properties", obj.virtual_network_properties)
+	}
+	if len(obj.route_target_list) > 0 {
+		set("route_target_list", obj.route_target_list)
+	}
+	if len(obj.network_ipam_refs) > 0 {
+		set("network_ipam_refs", obj.network_ipam_refs)
+	}
+	if len(obj.instance_ip_back_refs) > 0 {
+		set("instance_ip_back_refs", obj.instance_ip_back_refs)
+	}
+	return json.Marshal(m)
+}
+
+func (obj *testNetwork) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"virtual_network_properties": &obj.virtual_network_properties,
+		"route_target_list":          &obj.route_target_list,
+		"network_ipam_refs":          &obj.network_ipam_refs,
+	}
+	for name, ptr := range fields {
+		if value, ok := m[name]; ok {
+			if err := json.Unmarshal(value, ptr); err != nil {
+				return err
+			}
+		}
+	}
+	// Link attributes are decoded by the generated types.
+	for i, ref := range obj.network_ipam_refs {
+		if ref.Attr == nil {
+			continue
+		}
+		data, _ := json.Marshal(ref.Attr)
+		attr := new(testIpamSubnets)
+		if err := json.Unmarshal(data, attr); err != nil {
+			return err
+		}
+		obj.network_ipam_refs[i].Attr = attr
+	}
+	return nil
+}
+
+func newTestNetwork() *testNetwork {
+	obj := new(testNetwork)
+	obj.SetName("net")
+	obj.SetUuid("net-uuid")
+	obj.virtual_network_properties = &testNetworkType{
+		VxlanID: 5, ForwardMode: "l2_l3"}
+	obj.route_target_list = []string{"target:64512:1"}
+	obj.network_ipam_refs = contrail.ReferenceList{{
+		To:   []string{"default-domain", "default-project", "ipam"},
+		Uuid: "ipam-uuid",
+		Attr: &testIpamSubnets{[]testSubnetType{{"10.0.0.0", 24}}},
+	}}
+	obj.instance_ip_back_refs = contrail.ReferenceList{{Uuid: "iip-uuid"}}
+	return obj
+}
+
+func TestFlatten(t *testing.T) {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"test-network": reflect.TypeOf(testNetwork{}),
+	})
+	m, err := Flatten(newTestNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"uuid":        "net-uuid",
+		"fq_name":     []interface{}{"default-project", "net"},
+		"parent_type": "project",
+		"virtual_network_properties": []interface{}{
+			map[string]interface{}{
+				"vxlan_network_identifier": 5,
+				"forwarding_mode":          "l2_l3",
+			},
+		},
+		"route_target_list": []interface{}{"target:64512:1"},
+		"network_ipam_refs": []interface{}{
+			map[string]interface{}{
+				"to":   []interface{}{"default-domain", "default-project", "ipam"},
+				"uuid": "ipam-uuid",
+				"attr": `{"ipam_subnets":[{"ip_prefix":"10.0.0.0","ip_prefix_len":24}]}`,
+			},
+		},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("unexpected result %#v", m)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	contrail.RegisterTypeMap(contrail.TypeMap{
+		"test-network": reflect.TypeOf(testNetwork{}),
+	})
+	original := newTestNetwork()
+	m, err := Flatten(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := Expand("test-network", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := obj.(*testNetwork)
+	original.instance_ip_back_refs = nil
+	if network.GetName() != "net" {
+		t.Errorf("unexpected name %s", network.GetName())
+	}
+	if !reflect.DeepEqual(network.virtual_network_properties,
+		original.virtual_network_properties) {
+		t.Errorf("unexpected properties %+v", network.virtual_network_properties)
+	}
+	if !reflect.DeepEqual(network.network_ipam_refs, original.network_ipam_refs) {
+		t.Errorf("unexpected refs %+v", network.network_ipam_refs)
+	}
+	if !reflect.DeepEqual(network.route_target_list, original.route_target_list) {
+		t.Errorf("unexpected route targets %v", network.route_target_list)
+	}
+
+	if _, err := Expand("test-network", map[string]interface{}{
+		"virtual_network_properties": []interface{}{},
+	}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestAlarmRules(t *testing.T) {
+	down := config.AlarmCond("NodeStatus.process_status.state", "!=",
+		config.AlarmValue("Functional"), "NodeStatus.process_status.module_id")
+	rules, err := config.AlarmRules(config.AlarmAll(
+		config.AlarmAny(down,
+			config.AlarmCond("NodeStatus.disk_usage_info.percentage_partition_space_used",
+				"range", config.AlarmValue([]int{90, 100}))),
+		config.AlarmCond("ContrailConfig.elements.role", "in",
+			config.AlarmValue([]string{"control", "config"}))))
+	require.NoError(t, err)
+	require.Len(t, rules.OrList, 2)
+	and := rules.OrList[0].AndList
+	require.Len(t, and, 2)
+	assert.Equal(t, "!=", and[0].Operation)
+	assert.Equal(t, `"Functional"`, and[0].Operand2.JsonValue)
+	assert.Equal(t, []string{"NodeStatus.process_status.module_id"}, and[0].Variables)
+	assert.Equal(t, "in", and[1].Operation)
+	assert.Equal(t, "range", rules.OrList[1].AndList[0].Operation)
+
+	invalid := []config.AlarmCondition{
+		config.AlarmCond("NodeStatus..state", "==", config.AlarmValue(1)),
+		config.AlarmCond("NodeStatus.state", "=~", config.AlarmValue(1)),
+		config.AlarmCond("NodeStatus.state", "in", config.AlarmValue("x")),
+		config.AlarmCond("NodeStatus.state", "range", config.AlarmValue([]int{1})),
+		config.AlarmCond("NodeStatus.state", "==", config.AlarmAttr("bad attr")),
+		config.AlarmCond("NodeStatus.state", "==", config.AlarmValue(make(chan int))),
+		config.AlarmAny(),
+	}
+	for _, condition := range invalid {
+		_, err := config.AlarmRules(condition)
+		assert.Error(t, err)
+	}
+}
+
+func TestCreateAlarm(t *testing.T) {
+	client := newTestClient()
+	gsc := new(types.GlobalSystemConfig)
+	gsc.SetName("default-global-system-config")
+	require.NoError(t, client.Create(gsc))
+	defer client.Delete(gsc)
+
+	spec := &config.AlarmSpec{
+		Name:        "process-down",
+		Description: "A process is not running",
+		Severity:    config.AlarmSeverityMajor,
+		UveKeys:     []string{"control-node", "config-node"},
+		Condition: config.AlarmCond("NodeStatus.process_info.process_state",
+			"!=", config.AlarmValue("PROCESS_STATE_RUNNING")),
+	}
+	alarm, err := config.CreateAlarm(client, gsc, spec)
+	require.NoError(t, err)
+	defer client.Delete(alarm)
+	assert.Equal(t, []string{"control-node", "config-node"}, alarm.GetUveKeys().UveKey)
+	assert.Equal(t, config.AlarmSeverityMajor, alarm.GetAlarmSeverity())
+	assert.Equal(t, "A process is not running", alarm.GetIdPerms().Description)
+
+	spec.UveKeys = []string{"Control Node"}
+	_, err = config.CreateAlarm(client, gsc, spec)
+	assert.Error(t, err)
+}
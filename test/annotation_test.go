@@ -0,0 +1,57 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestAnnotations(t *testing.T) {
+	network := new(types.VirtualNetwork)
+	_, ok := config.GetAnnotation(network, "owner")
+	assert.False(t, ok)
+
+	config.SetAnnotation(network, "owner", "a")
+	config.SetAnnotation(network, "tier", "web")
+	config.SetAnnotation(network, "owner", "b")
+	value, ok := config.GetAnnotation(network, "owner")
+	assert.True(t, ok)
+	assert.Equal(t, "b", value)
+	assert.Len(t, network.GetAnnotations().KeyValuePair, 2)
+
+	config.DeleteAnnotation(network, "owner")
+	_, ok = config.GetAnnotation(network, "owner")
+	assert.False(t, ok)
+	value, _ = config.GetAnnotation(network, "tier")
+	assert.Equal(t, "web", value)
+}
+
+func TestUpdateAnnotations(t *testing.T) {
+	client := newTestClient()
+	network := new(types.VirtualNetwork)
+	network.SetFQName("project", []string{"default-domain", "default-project", "annotated"})
+	config.SetAnnotation(network, "stale", "x")
+	require.NoError(t, client.Create(network))
+	defer client.Delete(network)
+
+	err := config.UpdateAnnotations(client, network,
+		map[string]string{"owner": "a"}, []string{"stale"})
+	require.NoError(t, err)
+
+	obj, err := client.FindByUuid("virtual-network", network.GetUuid())
+	require.NoError(t, err)
+	stored := obj.(*types.VirtualNetwork)
+	value, ok := config.GetAnnotation(stored, "owner")
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	_, ok = config.GetAnnotation(stored, "stale")
+	assert.False(t, ok)
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestBgpaas(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+
+	netId, err := config.CreateNetworkWithSubnet(client, projectId, "subnet-test", "10.1.0.0/24")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+
+	vmi := new(types.VirtualMachineInterface)
+	vmi.SetParent(project)
+	vmi.SetName("bgp-vm-port")
+	vmi.AddVirtualNetwork(network)
+	require.NoError(t, client.Create(vmi))
+	defer client.Delete(vmi)
+
+	spec := &config.BgpaasSpec{
+		Name:             "vm-bgp",
+		AutonomousSystem: 65001,
+		PeerAddress:      "10.1.0.10",
+		HoldTime:         90,
+		Shared:           true,
+	}
+	_, err = config.CreateBgpaas(client, project, vmi, &config.BgpaasSpec{
+		Name: "invalid", AutonomousSystem: 65001, HoldTime: 2,
+	})
+	assert.Error(t, err, "hold time below 3 seconds")
+	_, err = config.CreateBgpaas(client, project, vmi, &config.BgpaasSpec{
+		Name: "invalid", AutonomousSystem: 65001, PeerAddress: "10.2.0.1",
+	})
+	assert.Error(t, err, "peer address outside of the network")
+
+	session, err := config.CreateBgpaas(client, project, vmi, spec)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.0.10", session.Addresses[vmi.GetUuid()])
+	assert.Equal(t, "10.1.0.10", session.Object.GetBgpaasIpAddress())
+	attributes := session.Object.GetBgpaasSessionAttributes()
+	assert.Equal(t, 90, attributes.HoldTime)
+	assert.Equal(t, []string{"inet", "inet6"}, attributes.AddressFamilies.Family)
+	used, err := config.UsedAddresses(client, network)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.10"}, used)
+
+	require.NoError(t, session.SetSessionAttributes([]string{"inet"}, 30))
+	assert.Error(t, session.SetSessionAttributes([]string{"evpn"}, 30))
+	obj, err := client.FindByUuid("bgp-as-a-service", session.Object.GetUuid())
+	require.NoError(t, err)
+	attributes = obj.(*types.BgpAsAService).GetBgpaasSessionAttributes()
+	assert.Equal(t, 30, attributes.HoldTime)
+
+	require.NoError(t, session.Delete())
+	used, err = config.UsedAddresses(client, network)
+	require.NoError(t, err)
+	assert.Empty(t, used)
+}
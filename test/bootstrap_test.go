@@ -0,0 +1,58 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// updateCountingClient counts the updates.
+type updateCountingClient struct {
+	contrail.ApiClient
+	updates int
+}
+
+func (c *updateCountingClient) Update(obj contrail.IObject) error {
+	c.updates++
+	return c.ApiClient.Update(obj)
+}
+
+func TestEnsureProject(t *testing.T) {
+	client := &updateCountingClient{ApiClient: newTestClient()}
+	quotas := map[string]int{"virtual-network": 5}
+
+	project, err := config.EnsureProject(client, "tenants", "blue", quotas, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenants", "blue"}, project.GetFQName())
+	limit, err := config.GetProjectQuota(project, "virtual-network")
+	require.NoError(t, err)
+	assert.Equal(t, 5, limit)
+
+	obj, err := client.FindByName("security-group", "tenants:blue:default")
+	require.NoError(t, err)
+	entries := obj.(*types.SecurityGroup).GetSecurityGroupEntries()
+	assert.Len(t, entries.PolicyRule, 4)
+
+	again, err := config.EnsureProject(client, "tenants", "blue", quotas, nil)
+	require.NoError(t, err)
+	assert.Equal(t, project.GetUuid(), again.GetUuid())
+	assert.Equal(t, 0, client.updates)
+
+	perms := &types.PermType{Owner: "admin", OwnerAccess: 7}
+	quotas["security-group"] = 2
+	updated, err := config.EnsureProject(client, "tenants", "blue", quotas, perms)
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.updates)
+	limit, _ = config.GetProjectQuota(updated, "security-group")
+	assert.Equal(t, 2, limit)
+	assert.Equal(t, "admin", updated.GetIdPerms().Permissions.Owner)
+}
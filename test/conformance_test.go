@@ -0,0 +1,19 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/contrailtest"
+)
+
+func TestConformance(t *testing.T) {
+	if !runIntegrationTest() {
+		t.Skip("Skipping integration test")
+	}
+	contrailtest.RunConformance(t, contrail.NewClient(testApiServer, testApiPort))
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// fuzzTypes are the generated types whose decoding is fuzzed: they have
+// nested properties and reference attributes.
+var fuzzTypes = []string{
+	"virtual-network",
+	"virtual-machine-interface",
+	"network-policy",
+	"network-ipam",
+	"security-group",
+}
+
+func FuzzDecodeGeneratedTypes(f *testing.F) {
+	for _, seed := range []string{
+		`{"fq_name": ["d", "p", "n"], "uuid": "u", "name": "n",
+		  "id_perms": {"enable": true, "created": "2019-01-01T00:00:00.000000"},
+		  "network_ipam_refs": [{"to": ["d", "p", "ipam"], "uuid": "i",
+		    "attr": {"ipam_subnets": [{"subnet": {"ip_prefix": "10.0.0.0", "ip_prefix_len": 24}}]}}]}`,
+		`{"fq_name": ["d", "p", "vmi"], "uuid": "u", "name": "vmi",
+		  "virtual_machine_interface_mac_addresses": {"mac_address": ["02:00:00:00:00:01"]},
+		  "virtual_machine_interface_allowed_address_pairs": {"allowed_address_pair": [{"ip": {"ip_prefix": "10.0.0.5", "ip_prefix_len": 32}}]}}`,
+		`{"fq_name": ["d", "p", "pol"], "uuid": "u", "name": "pol",
+		  "network_policy_entries": {"policy_rule": [{"direction": "<>", "protocol": "any",
+		    "src_addresses": [{"virtual_network": "any"}], "src_ports": [{"start_port": -1, "end_port": -1}],
+		    "action_list": {"simple_action": "pass"}}]}}`,
+		`{"fq_name": ["d", "p", "sg"], "uuid": "u", "name": "sg",
+		  "security_group_entries": {"policy_rule": [null, {}]}}`,
+		`{"fq_name": null, "uuid": null, "name": null, "id_perms": null}`,
+	} {
+		for i := range fuzzTypes {
+			f.Add(i, []byte(seed))
+		}
+	}
+	f.Fuzz(func(t *testing.T, index int, data []byte) {
+		if index < 0 || index >= len(fuzzTypes) {
+			t.Skip()
+		}
+		obj, err := contrail.DecodeObject(fuzzTypes[index], data)
+		if err != nil {
+			return
+		}
+		if _, err := json.Marshal(obj); err != nil {
+			t.Errorf("%s: %v", data, err)
+		}
+	})
+}
+
+func TestDecodeMalformedResponse(t *testing.T) {
+	_, err := contrail.DecodeObject("virtual-network", []byte(
+		`{"virtual-network": {"fq_name": ["d", "p", "n"], "uuid": "u", "name": "n",
+		  "network_ipam_refs": [{"to": ["d", "p", "ipam"], "uuid": "i", "attr": {"ipam_subnets": 1}}]}}`))
+	if err == nil {
+		t.Error("expected an error for a malformed reference attribute")
+	}
+	obj, err := contrail.DecodeObject("virtual-network", []byte(
+		`{"fq_name": ["d", "p", "n"], "uuid": "u", "name": "n"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*types.VirtualNetwork); !ok {
+		t.Errorf("expected a VirtualNetwork, got %T", obj)
+	}
+}
@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// countingClient counts the lookups by name.
+type countingClient struct {
+	contrail.ApiClient
+	lookups int
+}
+
+func (c *countingClient) FindByName(typename, fqn string) (contrail.IObject, error) {
+	c.lookups++
+	return c.ApiClient.FindByName(typename, fqn)
+}
+
+func TestDefaults(t *testing.T) {
+	client := &countingClient{ApiClient: newTestClient()}
+	defaults := config.NewDefaults(client)
+
+	project, err := defaults.Project()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default-domain", "default-project"}, project.GetFQName())
+	ipam, err := defaults.NetworkIpam()
+	require.NoError(t, err)
+	assert.Equal(t, "default-network-ipam", ipam.GetName())
+
+	cached, err := defaults.Project()
+	require.NoError(t, err)
+	assert.True(t, cached == project)
+	assert.Equal(t, 2, client.lookups)
+
+	_, err = defaults.SecurityGroup(project)
+	assert.Error(t, err)
+	sg := new(types.SecurityGroup)
+	sg.SetParent(project)
+	sg.SetName(config.DefaultSecurityGroup)
+	require.NoError(t, client.Create(sg))
+	defer client.Delete(sg)
+	found, err := defaults.SecurityGroup(project)
+	require.NoError(t, err)
+	assert.Equal(t, sg.GetUuid(), found.GetUuid())
+
+	defaults.Invalidate()
+	_, err = defaults.Project()
+	require.NoError(t, err)
+	assert.Equal(t, 5, client.lookups)
+}
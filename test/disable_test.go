@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestDisableRouter(t *testing.T) {
+	client := fabricTestSetup(t)
+	router, err := config.PhysicalRouterByName(client, "leaf1")
+	require.NoError(t, err)
+	require.NoError(t, config.SetEnabled(router, true))
+	require.NoError(t, client.Update(router))
+
+	pi, err := config.FindPhysicalInterface(client,
+		config.PortID{Router: "leaf1", Interface: "xe-0/0/1"})
+	require.NoError(t, err)
+	require.NoError(t, config.SetEnabled(pi, true))
+	require.NoError(t, client.Update(pi))
+	li, err := config.CreateLogicalInterface(client, pi, 10, "l2")
+	require.NoError(t, err)
+	require.NoError(t, config.SetEnabled(li, true))
+	require.NoError(t, client.Update(li))
+
+	// Disabled independently: must stay disabled.
+	spare := new(types.PhysicalInterface)
+	spare.SetParent(router)
+	spare.SetName("xe-0/0/2")
+	require.NoError(t, config.SetEnabled(spare, false))
+	require.NoError(t, client.Create(spare))
+
+	enabled := func(typename, uuid string) bool {
+		obj, err := client.FindByUuid(typename, uuid)
+		require.NoError(t, err)
+		return obj.(config.IdPermsObject).GetIdPerms().Enable
+	}
+	require.NoError(t, config.Disable(client, router))
+	assert.False(t, enabled("physical-router", router.GetUuid()))
+	assert.False(t, enabled("physical-interface", pi.GetUuid()))
+	assert.False(t, enabled("logical-interface", li.GetUuid()))
+
+	require.NoError(t, config.Enable(client, router))
+	assert.True(t, enabled("physical-router", router.GetUuid()))
+	assert.True(t, enabled("physical-interface", pi.GetUuid()))
+	assert.True(t, enabled("logical-interface", li.GetUuid()))
+	assert.False(t, enabled("physical-interface", spare.GetUuid()))
+	obj, err := client.FindByUuid("physical-interface", pi.GetUuid())
+	require.NoError(t, err)
+	_, marked := config.GetAnnotation(obj.(config.Annotated),
+		config.DisabledByAnnotation)
+	assert.False(t, marked)
+}
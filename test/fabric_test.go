@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func fabricTestSetup(t *testing.T) contrail.ApiClient {
+	client := newTestClient()
+
+	gsc := new(types.GlobalSystemConfig)
+	gsc.SetName("default-global-system-config")
+	require.NoError(t, client.Create(gsc))
+
+	fabric := new(types.Fabric)
+	fabric.SetFQName("global-system-config",
+		[]string{"default-global-system-config", "dc1"})
+	require.NoError(t, client.Create(fabric))
+
+	for _, name := range []string{"leaf1", "leaf2"} {
+		router := new(types.PhysicalRouter)
+		router.SetFQName("global-system-config",
+			[]string{"default-global-system-config", name})
+		require.NoError(t, client.Create(router))
+
+		pi := new(types.PhysicalInterface)
+		pi.SetFQName("physical-router",
+			[]string{"default-global-system-config", name, "xe-0/0/1"})
+		require.NoError(t, client.Create(pi))
+	}
+	return client
+}
+
+func TestVirtualPortGroup(t *testing.T) {
+	client := fabricTestSetup(t)
+
+	_, err := config.CreateVirtualPortGroup(client, "dc1", "vpg-bad",
+		[]config.PortID{{"leaf3", "xe-0/0/1"}})
+	assert.Error(t, err)
+
+	vpg, err := config.CreateVirtualPortGroup(client, "dc1", "vpg1",
+		[]config.PortID{{"leaf1", "xe-0/0/1"}, {"leaf2", "xe-0/0/1"}})
+	require.NoError(t, err)
+	assert.True(t, vpg.GetVirtualPortGroupLacpEnabled())
+	refs, err := vpg.GetPhysicalInterfaceRefs()
+	require.NoError(t, err)
+	assert.Len(t, refs, 2)
+
+	project, err := types.ProjectByName(client, "default-domain:default-project")
+	require.NoError(t, err)
+	network := new(types.VirtualNetwork)
+	network.SetFQName("project", []string{"default-domain", "default-project", "bms"})
+	require.NoError(t, client.Create(network))
+
+	vmi, err := config.AttachVirtualPortGroup(client, vpg,
+		&config.VirtualPortGroupAttachment{
+			Project: project,
+			Network: network,
+			Vlan:    100,
+		})
+	require.NoError(t, err)
+	assert.Equal(t, 100, vmi.GetVirtualMachineInterfaceProperties().SubInterfaceVlanTag)
+
+	bindings := make(map[string]string)
+	for _, kv := range vmi.GetVirtualMachineInterfaceBindings().KeyValuePair {
+		bindings[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "baremetal", bindings["vnic_type"])
+	assert.Equal(t, "vpg1", bindings["vpg"])
+	var profile struct {
+		Links []map[string]string `json:"local_link_information"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(bindings["profile"]), &profile))
+	require.Len(t, profile.Links, 2)
+	assert.Equal(t, "leaf1", profile.Links[0]["switch_info"])
+	assert.Equal(t, "dc1", profile.Links[0]["fabric"])
+
+	vmiRefs, err := vpg.GetVirtualMachineInterfaceRefs()
+	require.NoError(t, err)
+	assert.Len(t, vmiRefs, 1)
+}
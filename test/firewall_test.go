@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestFirewallRule(t *testing.T) {
+	client := newTestClient()
+	project := new(types.Project)
+	project.SetFQName("domain", []string{"default-domain", "fw-test"})
+	require.NoError(t, client.Create(project))
+	defer client.Delete(project)
+
+	policy, err := config.CreateFirewallPolicy(client, project, "web-policy")
+	require.NoError(t, err)
+	defer client.Delete(policy)
+
+	_, err = config.CreateFirewallRule(client, project, policy,
+		&config.FirewallRuleSpec{Name: "bad", Action: "allow"})
+	assert.Error(t, err)
+
+	rule, err := config.CreateFirewallRule(client, project, policy,
+		&config.FirewallRuleSpec{
+			Name:      "http",
+			Action:    "pass",
+			Protocol:  "tcp",
+			Ports:     [2]int{80, 80},
+			Endpoint1: []string{"tier=lb"},
+			Endpoint2: []string{"tier=web"},
+		})
+	require.NoError(t, err)
+	defer client.Delete(rule)
+	assert.Equal(t, "<>", rule.GetDirection())
+	assert.Equal(t, 80, rule.GetService().DstPorts.StartPort)
+
+	refs, err := policy.GetFirewallRuleRefs()
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	assert.Equal(t, rule.GetUuid(), refs[0].Uuid)
+
+	// After a rule is removed, new rules are still ordered last.
+	second, err := config.CreateFirewallRule(client, project, policy,
+		&config.FirewallRuleSpec{Name: "https", Action: "pass", Ports: [2]int{443, 443}})
+	require.NoError(t, err)
+	defer client.Delete(second)
+	policy.DeleteFirewallRule(rule.GetUuid())
+	require.NoError(t, client.Update(policy))
+	third, err := config.CreateFirewallRule(client, project, policy,
+		&config.FirewallRuleSpec{Name: "ssh", Action: "deny", Ports: [2]int{22, 22}})
+	require.NoError(t, err)
+	defer client.Delete(third)
+
+	refs, err = policy.GetFirewallRuleRefs()
+	require.NoError(t, err)
+	sequences := make(map[string]string)
+	for _, ref := range refs {
+		sequences[ref.Uuid] = ref.Attr.(types.FirewallSequence).Sequence
+	}
+	assert.Equal(t, map[string]string{
+		second.GetUuid(): "1.0",
+		third.GetUuid():  "2.0",
+	}, sequences)
+}
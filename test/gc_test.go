@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestPruneOrphans(t *testing.T) {
+	client := newTestClient()
+	keep := createManagedNetwork(t, client, "gc-keep", "cni")
+	orphan := createManagedNetwork(t, client, "gc-orphan", "cni")
+	foreign := createManagedNetwork(t, client, "gc-foreign", "other")
+	defer client.Delete(keep)
+	defer client.Delete(foreign)
+
+	vmi := new(types.VirtualMachineInterface)
+	vmi.SetFQName("project", []string{"default-domain", "default-project", "gc-port"})
+	vmi.AddVirtualNetwork(orphan)
+	config.SetManagedBy(vmi, "cni")
+	require.NoError(t, client.Create(vmi))
+
+	typenames := []string{"virtual-network", "virtual-machine-interface"}
+	desired := []contrail.IObject{keep}
+	result, err := config.PruneOrphans(client, "cni", typenames, desired, true)
+	require.NoError(t, err)
+	assert.Len(t, result.Orphans, 2)
+	assert.Empty(t, result.Deleted)
+	_, err = client.FindByUuid("virtual-network", orphan.GetUuid())
+	assert.NoError(t, err, "dry run deleted an object")
+
+	// The interface refers to the network and must be deleted first.
+	result, err = config.PruneOrphans(client, "cni", typenames, desired, false)
+	require.NoError(t, err)
+	require.Len(t, result.Deleted, 2)
+	assert.Equal(t, vmi.GetUuid(), result.Deleted[0].GetUuid())
+	assert.Empty(t, result.Pending)
+	_, err = client.FindByUuid("virtual-machine-interface", vmi.GetUuid())
+	assert.Error(t, err)
+	_, err = client.FindByUuid("virtual-network", orphan.GetUuid())
+	assert.Error(t, err)
+	_, err = client.FindByUuid("virtual-network", keep.GetUuid())
+	assert.NoError(t, err)
+	_, err = client.FindByUuid("virtual-network", foreign.GetUuid())
+	assert.NoError(t, err)
+}
+
+func TestPruneOrphansPending(t *testing.T) {
+	client := newTestClient()
+	project := new(types.Project)
+	project.SetFQName("domain", []string{"default-domain", "gc-pending"})
+	config.SetManagedBy(project, "cni")
+	require.NoError(t, client.Create(project))
+
+	network := new(types.VirtualNetwork)
+	network.SetParent(project)
+	network.SetName("held")
+	config.SetManagedBy(network, "cni")
+	require.NoError(t, client.Create(network))
+	require.NoError(t, config.AddFinalizer(client, network, "ipam-controller"))
+
+	// The network is held by a finalizer: the project, its parent, is
+	// not deleted either.
+	typenames := []string{"project", "virtual-network"}
+	result, err := config.PruneOrphans(client, "cni", typenames, nil, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Deleted)
+	require.Len(t, result.Pending, 2)
+	assert.Equal(t, network.GetUuid(), result.Pending[0].GetUuid())
+	assert.Equal(t, project.GetUuid(), result.Pending[1].GetUuid())
+	_, err = client.FindByUuid("project", project.GetUuid())
+	assert.NoError(t, err)
+
+	require.NoError(t, config.RemoveFinalizer(client, network, "ipam-controller"))
+	_, err = client.FindByUuid("virtual-network", network.GetUuid())
+	assert.Error(t, err)
+	result, err = config.PruneOrphans(client, "cni", typenames, nil, false)
+	require.NoError(t, err)
+	require.Len(t, result.Deleted, 1)
+	assert.Equal(t, project.GetUuid(), result.Deleted[0].GetUuid())
+}
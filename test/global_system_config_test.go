@@ -0,0 +1,57 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestGlobalSystemConfigSettings(t *testing.T) {
+	client := newTestClient()
+	gsc := new(types.GlobalSystemConfig)
+	gsc.SetName(contrail.DefaultGlobalSystemConfig)
+	require.NoError(t, client.Create(gsc))
+	defer client.Delete(gsc)
+
+	require.NoError(t, config.SetAutonomousSystem(client, 64512))
+	assert.Error(t, config.SetAutonomousSystem(client, 4200000))
+	require.NoError(t, config.SetAutonomousSystem4Byte(client, 4200000, true))
+	assert.Error(t, config.SetAutonomousSystem4Byte(client, 4200000, false))
+
+	assert.Error(t, config.SetGracefulRestart(client,
+		&types.GracefulRestartParametersType{Enable: true}))
+	assert.Error(t, config.SetGracefulRestart(client,
+		&types.GracefulRestartParametersType{BgpHelperEnable: true}))
+	assert.Error(t, config.SetGracefulRestart(client,
+		&types.GracefulRestartParametersType{Enable: true, RestartTime: 5000}))
+	require.NoError(t, config.SetGracefulRestart(client,
+		&types.GracefulRestartParametersType{
+			Enable: true, RestartTime: 300, XmppHelperEnable: true,
+		}))
+
+	assert.Error(t, config.SetMacLimit(client, 1024, "flood"))
+	require.NoError(t, config.SetMacLimit(client, 1024, config.MacLimitActionDrop))
+	assert.Error(t, config.SetMacAgingTime(client, -1))
+	require.NoError(t, config.SetMacAgingTime(client, 300))
+
+	obj, err := client.FindByUuid(contrail.TypeGlobalSystemConfig, gsc.GetUuid())
+	require.NoError(t, err)
+	current := obj.(*types.GlobalSystemConfig)
+	assert.Equal(t, 4200000, current.GetAutonomousSystem())
+	assert.True(t, current.GetEnable4byteAs())
+	assert.Equal(t, 300, current.GetGracefulRestartParameters().RestartTime)
+	assert.Equal(t, "drop", current.GetMacLimitControl().MacLimitAction)
+	assert.Equal(t, 300, current.GetMacAgingTime())
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestHealthCheck(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+
+	invalid := []*config.HealthCheckSpec{
+		{Name: "no-url", Monitor: config.HealthCheckHTTP,
+			Delay: 3 * time.Second, Timeout: time.Second, MaxRetries: 3},
+		{Name: "codes", Monitor: config.HealthCheckHTTP, URL: "/health",
+			ExpectedCodes: "2xx",
+			Delay:         3 * time.Second, Timeout: time.Second, MaxRetries: 3},
+		{Name: "ping-ms", Monitor: config.HealthCheckPing,
+			Delay: 300 * time.Millisecond, Timeout: 100 * time.Millisecond,
+			MaxRetries: 3},
+		{Name: "ping-url", Monitor: config.HealthCheckPing, URL: "/",
+			Delay: 3 * time.Second, Timeout: time.Second, MaxRetries: 3},
+		{Name: "timeout", Monitor: config.HealthCheckPing,
+			Delay: time.Second, Timeout: 2 * time.Second, MaxRetries: 3},
+		{Name: "bfd-e2e", Monitor: config.HealthCheckBFD,
+			Type:  config.HealthCheckEndToEnd,
+			Delay: 300 * time.Millisecond, Timeout: 100 * time.Millisecond,
+			MaxRetries: 3},
+	}
+	for _, spec := range invalid {
+		_, err := config.CreateHealthCheck(client, project, spec)
+		assert.Error(t, err, spec.Name)
+	}
+
+	bfd, err := config.CreateHealthCheck(client, project, &config.HealthCheckSpec{
+		Name: "bfd", Monitor: config.HealthCheckBFD,
+		Delay: 1500 * time.Millisecond, Timeout: 500 * time.Millisecond,
+		MaxRetries: 3,
+	})
+	require.NoError(t, err)
+	defer client.Delete(bfd)
+	properties := bfd.GetServiceHealthCheckProperties()
+	assert.Equal(t, 1, properties.Delay)
+	assert.Equal(t, 500000, properties.DelayUsecs)
+	assert.Equal(t, config.HealthCheckLinkLocal, properties.HealthCheckType)
+
+	http, err := config.CreateHealthCheck(client, project, &config.HealthCheckSpec{
+		Name: "http", Monitor: config.HealthCheckHTTP, URL: "/health",
+		Type:  config.HealthCheckEndToEnd,
+		Delay: 5 * time.Second, Timeout: 2 * time.Second, MaxRetries: 2,
+	})
+	require.NoError(t, err)
+	defer client.Delete(http)
+	assert.Equal(t, "GET", http.GetServiceHealthCheckProperties().HttpMethod)
+
+	netId, err := config.CreateNetworkWithSubnet(client, projectId, "subnet-test",
+		"10.1.0.0/24")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+	vmi := new(types.VirtualMachineInterface)
+	vmi.SetParent(project)
+	vmi.SetName("vnf-port")
+	vmi.AddVirtualNetwork(network)
+	require.NoError(t, client.Create(vmi))
+	defer client.Delete(vmi)
+
+	assert.Error(t, config.AttachHealthCheck(client, vmi, http))
+	require.NoError(t, config.AttachHealthCheck(client, vmi, bfd))
+	require.NoError(t, config.AttachHealthCheck(client, vmi, bfd))
+	refs, err := vmi.GetServiceHealthCheckRefs()
+	require.NoError(t, err)
+	assert.Len(t, refs, 1)
+	require.NoError(t, config.DetachHealthCheck(client, vmi, bfd))
+}
@@ -0,0 +1,54 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestUuidIdPerms(t *testing.T) {
+	uuid := "0123ab45-cdef-4012-8345-6789abcdef01"
+	value, err := config.UuidToIdPerms(uuid)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x0123ab45cdef4012), value.UuidMslong)
+	assert.Equal(t, uint64(0x83456789abcdef01), value.UuidLslong)
+	assert.Equal(t, uuid, config.IdPermsToUuid(value))
+
+	_, err = config.UuidToIdPerms("0123")
+	assert.Error(t, err)
+}
+
+func TestIdPerms(t *testing.T) {
+	client := newTestClient()
+	network := new(types.VirtualNetwork)
+	network.SetFQName("project", []string{"default-domain", "default-project", "perms"})
+	require.NoError(t, config.SetEnabled(network, true))
+	require.NoError(t, config.SetDescription(network, "test network"))
+	assert.Nil(t, network.GetIdPerms().Uuid)
+	require.NoError(t, client.Create(network))
+	defer client.Delete(network)
+
+	err := config.UpdateIdPerms(client, network, func(perms *types.IdPermsType) {
+		perms.Enable = false
+		perms.UserVisible = true
+	})
+	require.NoError(t, err)
+	perms := network.GetIdPerms()
+	assert.False(t, perms.Enable)
+	assert.True(t, perms.UserVisible)
+	assert.Equal(t, "test network", perms.Description)
+	require.NotNil(t, perms.Uuid)
+	assert.NoError(t, config.CheckIdPerms(network))
+
+	perms.Uuid = &types.UuidType{UuidMslong: 1, UuidLslong: 2}
+	network.SetIdPerms(&perms)
+	assert.Error(t, config.CheckIdPerms(network))
+}
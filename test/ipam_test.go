@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestIpamAddresses(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+
+	netId, err := config.CreateNetworkWithSubnet(client, projectId, "subnet-test", "10.1.0.0/29")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+
+	_, err = config.ReserveAddress(client, network, "10.2.0.1")
+	assert.Error(t, err, "address outside of the network subnets")
+	iip, err := config.ReserveAddress(client, network, "10.1.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.0.2", iip.GetInstanceIpAddress())
+
+	used, err := config.UsedAddresses(client, network)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.2"}, used)
+
+	free, err := config.FreeAddresses(client, network, "10.1.0.0/29", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.1", "10.1.0.3", "10.1.0.4", "10.1.0.5", "10.1.0.6"}, free)
+	free, err = config.FreeAddresses(client, network, "10.1.0.0/29", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.1", "10.1.0.3"}, free)
+
+	err = config.SetAllocationPools(client, network, "10.1.0.0/29",
+		[]types.AllocationPoolType{{Start: "10.1.0.5", End: "10.1.0.9"}})
+	assert.Error(t, err, "pool outside of the subnet")
+	err = config.SetAllocationPools(client, network, "10.1.0.0/29",
+		[]types.AllocationPoolType{{Start: "10.1.0.4", End: "10.1.0.5"}})
+	require.NoError(t, err)
+	free, err = config.FreeAddresses(client, network, "10.1.0.0/29", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.4", "10.1.0.5"}, free)
+
+	require.NoError(t, config.ReleaseAddress(client, network, "10.1.0.2"))
+	used, err = config.UsedAddresses(client, network)
+	require.NoError(t, err)
+	assert.Empty(t, used)
+}
+
+func TestIpamSmallSubnets(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+
+	for i, tc := range []struct {
+		prefix   string
+		expected []string
+	}{
+		{"10.3.0.0/32", []string{"10.3.0.0"}},
+		{"10.3.1.0/31", []string{"10.3.1.0", "10.3.1.1"}},
+		{"10.3.2.0/30", []string{"10.3.2.1", "10.3.2.2"}},
+	} {
+		netId, err := config.CreateNetworkWithSubnet(client, projectId,
+			fmt.Sprintf("small-%d", i), tc.prefix)
+		require.NoError(t, err)
+		defer client.DeleteByUuid("virtual-network", netId)
+		network, err := types.VirtualNetworkByUuid(client, netId)
+		require.NoError(t, err)
+		free, err := config.FreeAddresses(client, network, tc.prefix, 10)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, free, tc.prefix)
+	}
+}
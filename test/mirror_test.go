@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestMirror(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+	netId, err := config.CreateNetworkWithSubnet(client, projectId, "subnet-test",
+		"10.1.0.0/24")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+
+	vmi := new(types.VirtualMachineInterface)
+	vmi.SetParent(project)
+	vmi.SetName("mirrored")
+	vmi.AddVirtualNetwork(network)
+	vmi.SetVirtualMachineInterfaceProperties(
+		&types.VirtualMachineInterfacePropertiesType{SubInterfaceVlanTag: 10})
+	require.NoError(t, client.Create(vmi))
+	defer client.Delete(vmi)
+
+	invalid := []*config.MirrorSpec{
+		{AnalyzerIP: "collector"},
+		{AnalyzerIP: "10.1.0.5", Direction: "inbound", JuniperHeader: true},
+		{AnalyzerIP: "10.1.0.5"},
+		{AnalyzerIP: "10.1.0.5", JuniperHeader: true,
+			StaticVtep: &config.StaticVtep{IP: "192.168.0.1", VNI: 10}},
+		{AnalyzerIP: "10.1.0.5",
+			StaticVtep: &config.StaticVtep{IP: "192.168.0.1", VNI: 1 << 24}},
+	}
+	for _, spec := range invalid {
+		assert.Error(t, config.EnableMirror(client, vmi, spec))
+	}
+
+	require.NoError(t, config.EnableMirror(client, vmi, &config.MirrorSpec{
+		AnalyzerName:    "analyzer",
+		AnalyzerIP:      "10.1.0.5",
+		AnalyzerNetwork: network,
+		AnalyzerMAC:     "02:00:00:00:00:05",
+	}))
+	properties := vmi.GetVirtualMachineInterfaceProperties()
+	require.NotNil(t, properties.InterfaceMirror)
+	mirror := properties.InterfaceMirror.MirrorTo
+	assert.Equal(t, config.DefaultAnalyzerPort, mirror.UdpPort)
+	assert.Equal(t, "dynamic", mirror.NhMode)
+	assert.Equal(t, "default-domain:test:subnet-test:subnet-test",
+		mirror.RoutingInstance)
+
+	require.NoError(t, config.DisableMirror(client, vmi))
+	obj, err := client.FindByUuid("virtual-machine-interface", vmi.GetUuid())
+	require.NoError(t, err)
+	properties = obj.(*types.VirtualMachineInterface).
+		GetVirtualMachineInterfaceProperties()
+	assert.Nil(t, properties.InterfaceMirror)
+	assert.Equal(t, 10, properties.SubInterfaceVlanTag)
+}
@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestProviderAndSNATPresets(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+
+	_, err = config.CreateNetworkWithPresets(client, project, "vlan", nil,
+		config.ProviderNetwork("physnet1", 4095))
+	assert.Error(t, err)
+	provider, err := config.CreateNetworkWithPresets(client, project, "vlan",
+		[]string{"192.168.10.0/24"}, config.ProviderNetwork("physnet1", 100))
+	require.NoError(t, err)
+	defer client.Delete(provider)
+	assert.True(t, provider.GetIsProviderNetwork())
+	assert.Equal(t, 100, provider.GetProviderProperties().SegmentationId)
+
+	_, err = config.CreateNetworkWithPresets(client, project, "nat",
+		[]string{"10.2.0.0/24"}, config.ProviderNetwork("physnet1", 101),
+		config.SNATRouted)
+	assert.Error(t, err)
+	_, err = config.CreateNetworkWithPresets(client, project, "nat",
+		[]string{"10.2.0.0/24"}, config.L2Only, config.SNATRouted)
+	assert.Error(t, err)
+
+	nat, err := config.CreateNetworkWithPresets(client, project, "nat",
+		[]string{"10.2.0.0/24"}, config.SNATRouted)
+	require.NoError(t, err)
+	defer client.Delete(nat)
+	assert.True(t, nat.GetFabricSnat())
+	assert.Equal(t, config.ForwardingModeL3,
+		nat.GetVirtualNetworkProperties().ForwardingMode)
+}
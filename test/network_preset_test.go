@@ -0,0 +1,43 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestNetworkPresets(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+
+	bridged, err := config.CreateNetworkWithPresets(client, project, "bridged",
+		nil, config.L2Only)
+	require.NoError(t, err)
+	defer client.Delete(bridged)
+	assert.Equal(t, config.ForwardingModeL2,
+		bridged.GetVirtualNetworkProperties().ForwardingMode)
+
+	_, err = config.CreateNetworkWithPresets(client, project, "routed", nil,
+		config.L3Only)
+	assert.Error(t, err, "L3-only network without subnet")
+	_, err = config.CreateNetworkWithPresets(client, project, "both",
+		[]string{"10.1.0.0/24"}, config.L2Only, config.L3Only)
+	assert.Error(t, err, "conflicting presets")
+
+	routed, err := config.CreateNetworkWithPresets(client, project, "routed",
+		[]string{"10.1.0.0/24"}, config.L3Only)
+	require.NoError(t, err)
+	defer client.Delete(routed)
+	assert.Equal(t, config.ForwardingModeL3,
+		routed.GetVirtualNetworkProperties().ForwardingMode)
+}
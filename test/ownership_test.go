@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func createManagedNetwork(t *testing.T, client contrail.ApiClient,
+	name, manager string) *types.VirtualNetwork {
+	network := new(types.VirtualNetwork)
+	network.SetFQName("project", []string{"default-domain", "default-project", name})
+	if manager != "" {
+		config.SetManagedBy(network, manager)
+	}
+	require.NoError(t, client.Create(network))
+	return network
+}
+
+func TestManagedBy(t *testing.T) {
+	client := newTestClient()
+	mine := createManagedNetwork(t, client, "mine", "controller-a")
+	foreign := createManagedNetwork(t, client, "foreign", "controller-b")
+	unmanaged := createManagedNetwork(t, client, "unmanaged", "")
+	defer client.Delete(foreign)
+	defer client.Delete(unmanaged)
+
+	owned, err := config.ListManagedBy(client, "virtual-network", "controller-a")
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, mine.GetUuid(), owned[0].GetUuid())
+
+	err = config.RequestDelete(client, foreign, "controller-a")
+	assert.IsType(t, &config.OwnershipError{}, err)
+	err = config.RequestDelete(client, unmanaged, "controller-a")
+	assert.Error(t, err)
+
+	require.NoError(t, config.RequestDelete(client, mine, "controller-a"))
+	_, err = client.FindByUuid("virtual-network", mine.GetUuid())
+	assert.Error(t, err)
+}
+
+func TestFinalizers(t *testing.T) {
+	client := newTestClient()
+	network := createManagedNetwork(t, client, "finalized", "controller-a")
+
+	require.NoError(t, config.AddFinalizer(client, network, "ipam-controller"))
+	require.NoError(t, config.AddFinalizer(client, network, "dns-controller"))
+	assert.Equal(t, []string{"dns-controller", "ipam-controller"},
+		config.Finalizers(network))
+
+	require.NoError(t, config.RequestDelete(client, network, "controller-a"))
+	obj, err := client.FindByUuid("virtual-network", network.GetUuid())
+	require.NoError(t, err)
+	assert.True(t, config.DeletionRequested(obj.(*types.VirtualNetwork)))
+
+	require.NoError(t, config.RemoveFinalizer(client, network, "ipam-controller"))
+	_, err = client.FindByUuid("virtual-network", network.GetUuid())
+	require.NoError(t, err)
+
+	require.NoError(t, config.RemoveFinalizer(client, network, "dns-controller"))
+	_, err = client.FindByUuid("virtual-network", network.GetUuid())
+	assert.Error(t, err)
+}
+
+func TestFinalizerAnnotations(t *testing.T) {
+	client := newTestClient()
+	network := createManagedNetwork(t, client, "finalizer-keys", "controller-a")
+
+	// Each finalizer has its own annotation: a stale copy doesn't
+	// overwrite the finalizers added through another one.
+	obj, err := client.FindByUuid("virtual-network", network.GetUuid())
+	require.NoError(t, err)
+	stale := obj.(*types.VirtualNetwork)
+	require.NoError(t, config.AddFinalizer(client, network, "ipam-controller"))
+	require.NoError(t, config.AddFinalizer(client, stale, "dns-controller"))
+	obj, err = client.FindByUuid("virtual-network", network.GetUuid())
+	require.NoError(t, err)
+	current := obj.(*types.VirtualNetwork)
+	assert.Equal(t, []string{"dns-controller", "ipam-controller"},
+		config.Finalizers(current))
+	value, ok := config.GetAnnotation(current, config.FinalizerAnnotationPrefix+"dns-controller")
+	assert.True(t, ok)
+	assert.Equal(t, "dns-controller", value)
+
+	// A finalizer can't be added once the deletion is requested.
+	require.NoError(t, config.RequestDelete(client, network, "controller-a"))
+	assert.Error(t, config.AddFinalizer(client, network, "late-controller"))
+	obj, err = client.FindByUuid("virtual-network", network.GetUuid())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dns-controller", "ipam-controller"},
+		config.Finalizers(obj.(*types.VirtualNetwork)))
+
+	require.NoError(t, config.RemoveFinalizer(client, network, "dns-controller"))
+	require.NoError(t, config.RemoveFinalizer(client, network, "ipam-controller"))
+	_, err = client.FindByUuid("virtual-network", network.GetUuid())
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestQos(t *testing.T) {
+	client := &updateCountingClient{ApiClient: newTestClient()}
+	gsc := new(types.GlobalSystemConfig)
+	gsc.SetName(contrail.DefaultGlobalSystemConfig)
+	require.NoError(t, client.Create(gsc))
+	defer client.Delete(gsc)
+	globalQos := new(types.GlobalQosConfig)
+	globalQos.SetParent(gsc)
+	globalQos.SetName(contrail.DefaultGlobalQosConfig)
+	require.NoError(t, client.Create(globalQos))
+	defer client.Delete(globalQos)
+
+	gold := &config.ForwardingClassSpec{Name: "gold", Id: 1, Dscp: 46}
+	fc, err := config.ApplyForwardingClass(client, globalQos, gold)
+	require.NoError(t, err)
+	defer client.Delete(fc)
+	_, err = config.ApplyForwardingClass(client, globalQos,
+		&config.ForwardingClassSpec{Name: "silver", Id: 1})
+	assert.Error(t, err, "duplicate id")
+	_, err = config.ApplyForwardingClass(client, globalQos,
+		&config.ForwardingClassSpec{Name: "bronze", Id: 2, VlanPriority: 8})
+	assert.Error(t, err)
+
+	project, err := types.ProjectByName(client, "default-domain:default-project")
+	require.NoError(t, err)
+	spec := &config.QosConfigSpec{
+		Name:    "voice",
+		Dscp:    config.QosMap{46: 1, 0: 0},
+		MplsExp: config.QosMap{5: 1},
+	}
+	qos, err := config.ApplyQosConfig(client, project, spec)
+	require.NoError(t, err)
+	defer client.Delete(qos)
+	pairs := qos.GetDscpEntries().QosIdForwardingClassPair
+	require.Len(t, pairs, 2)
+	assert.Equal(t, 0, pairs[0].Key)
+	assert.Equal(t, 46, pairs[1].Key)
+	assert.Equal(t, config.QosConfigProject, qos.GetQosConfigType())
+
+	_, err = config.ApplyQosConfig(client, project, spec)
+	require.NoError(t, err)
+	_, err = config.ApplyForwardingClass(client, globalQos, gold)
+	require.NoError(t, err)
+	assert.Equal(t, 0, client.updates)
+	spec.VlanPriority = config.QosMap{5: 1}
+	_, err = config.ApplyQosConfig(client, project, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.updates)
+
+	spec.Dscp = config.QosMap{64: 1}
+	_, err = config.ApplyQosConfig(client, project, spec)
+	assert.Error(t, err)
+
+	require.NoError(t, config.SetControlTrafficDscp(client, 48, 40, 32))
+	assert.Error(t, config.SetControlTrafficDscp(client, 64, 0, 0))
+	obj, err := client.FindByUuid(contrail.TypeGlobalQosConfig, globalQos.GetUuid())
+	require.NoError(t, err)
+	assert.Equal(t, 48, obj.(*types.GlobalQosConfig).GetControlTrafficDscp().Control)
+}
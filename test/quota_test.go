@@ -0,0 +1,55 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestProjectQuota(t *testing.T) {
+	project := new(types.Project)
+	limit, err := config.GetProjectQuota(project, "virtual-network")
+	require.NoError(t, err)
+	assert.Equal(t, config.QuotaUnlimited, limit)
+
+	require.NoError(t, config.SetProjectQuota(project, config.QuotaDefaults, 10))
+	require.NoError(t, config.SetProjectQuota(project, "security-group", 3))
+	limit, _ = config.GetProjectQuota(project, "virtual-network")
+	assert.Equal(t, 10, limit)
+	limit, _ = config.GetProjectQuota(project, "security-group")
+	assert.Equal(t, 3, limit)
+
+	require.NoError(t, config.SetProjectQuota(project, "security-group", 0))
+	limit, _ = config.GetProjectQuota(project, "security-group")
+	assert.Equal(t, 10, limit)
+
+	assert.Error(t, config.SetProjectQuota(project, "no-such-resource", 1))
+}
+
+func TestCheckQuota(t *testing.T) {
+	client := newTestClient()
+	project := new(types.Project)
+	project.SetFQName("domain", []string{"default-domain", "quota-test"})
+	require.NoError(t, config.SetProjectQuota(project, "virtual-network", 2))
+	require.NoError(t, client.Create(project))
+	defer client.Delete(project)
+
+	network := new(types.VirtualNetwork)
+	network.SetParent(project)
+	network.SetName("net1")
+	require.NoError(t, client.Create(network))
+	defer client.Delete(network)
+
+	assert.NoError(t, config.CheckQuota(client, project, "virtual-network", 1))
+	err := config.CheckQuota(client, project, "virtual-network", 2)
+	require.IsType(t, &config.QuotaExceededError{}, err)
+	assert.Equal(t, 1, err.(*config.QuotaExceededError).Count)
+}
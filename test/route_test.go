@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestParseRouteTarget(t *testing.T) {
+	valid := map[string]bool{
+		"target:64512:1":          false,
+		"target:64512:4294967295": false,
+		"target:4200000000:65535": true,
+		"target:10.1.1.1:65535":   false,
+		"target:0100:7":           false,
+	}
+	for value, fourByte := range valid {
+		rt, err := config.ParseRouteTarget(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, fourByte, rt.FourByteASN(), value)
+	}
+	rt, _ := config.ParseRouteTarget("target:0100:7")
+	assert.Equal(t, "target:100:7", rt.String())
+
+	for _, value := range []string{
+		"64512:1",
+		"target:64512",
+		"target:4200000000:65536",
+		"target:10.1.1.1:65536",
+		"target:fe80::1:1",
+		"target:4294967296:1",
+		"target:64512:-1",
+	} {
+		_, err := config.ParseRouteTarget(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestNetworkRouteTargets(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+
+	netId, err := config.CreateNetwork(client, projectId, "subnet-test")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+
+	err = config.UpdateNetworkRouteTargets(client, network, config.RouteTargetImport,
+		[]string{"target:64512:100", "target:64512:101"}, nil)
+	require.NoError(t, err)
+	err = config.UpdateNetworkRouteTargets(client, network, config.RouteTargetImport,
+		[]string{"target:64512:102"}, []string{"target:64512:100"})
+	require.NoError(t, err)
+	err = config.UpdateNetworkRouteTargets(client, network, config.RouteTargetBoth,
+		[]string{"bad"}, nil)
+	assert.Error(t, err)
+
+	network, err = types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"target:64512:101", "target:64512:102"},
+		network.GetImportRouteTargetList().RouteTarget)
+	assert.Empty(t, network.GetRouteTargetList().RouteTarget)
+}
+
+func TestRouteTable(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+	netId, err := config.CreateNetwork(client, projectId, "subnet-test")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+
+	_, err = config.NewRoute("10.0.0.0", "192.168.0.1", config.NextHopIPAddress)
+	assert.Error(t, err)
+	_, err = config.NewRoute("10.0.0.0/8", "gateway", config.NextHopIPAddress)
+	assert.Error(t, err)
+	route, err := config.NewRoute("10.0.0.0/8", "192.168.0.1", config.NextHopIPAddress)
+	require.NoError(t, err)
+
+	table, err := config.CreateRouteTable(client, project, "rt", []*types.RouteType{route})
+	require.NoError(t, err)
+	defer client.Delete(table)
+	require.NoError(t, config.AttachRouteTable(client, network, table))
+	require.NoError(t, config.AttachRouteTable(client, network, table))
+	refs, err := network.GetRouteTableRefs()
+	require.NoError(t, err)
+	assert.Len(t, refs, 1)
+	assert.Equal(t, "10.0.0.0/8", table.GetRoutes().Route[0].Prefix)
+	require.NoError(t, config.DetachRouteTable(client, network, table))
+}
+
+func TestInterfaceRoutes(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	project, err := types.ProjectByUuid(client, projectId)
+	require.NoError(t, err)
+
+	_, err = config.NewInterfaceRoute("10.0.0.1/24")
+	assert.Error(t, err, "host bits set")
+	table, err := config.CreateInterfaceRouteTable(client, project, "irt",
+		[]string{"10.0.0.0/24"})
+	require.NoError(t, err)
+	defer client.Delete(table)
+
+	route, err := config.NewInterfaceRoute("10.0.1.0/24")
+	require.NoError(t, err)
+	require.NoError(t, config.AddInterfaceRoutes(client, table, route))
+	require.NoError(t, config.AddInterfaceRoutes(client, table, route))
+	assert.Len(t, table.GetInterfaceRouteTableRoutes().Route, 2)
+	require.NoError(t, config.DeleteInterfaceRoutes(client, table, "10.0.0.0/24"))
+	routes := table.GetInterfaceRouteTableRoutes().Route
+	require.Len(t, routes, 1)
+	assert.Equal(t, "10.0.1.0/24", routes[0].Prefix)
+}
+
+func TestHostRoutes(t *testing.T) {
+	client, projectId := networkTestSetup(t)
+	defer networkTestTeardown(client)
+	netId, err := config.CreateNetworkWithSubnet(client, projectId, "subnet-test",
+		"10.1.0.0/24")
+	require.NoError(t, err)
+	network, err := types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+
+	viaGateway, err := config.NewHostRoute("172.16.0.0/16", "")
+	require.NoError(t, err)
+	viaRouter, err := config.NewHostRoute("172.17.0.0/16", "10.1.0.254")
+	require.NoError(t, err)
+	outside, err := config.NewHostRoute("172.18.0.0/16", "10.2.0.1")
+	require.NoError(t, err)
+	assert.Error(t, config.SetHostRoutes(client, network, "10.1.0.0/24", outside))
+	assert.Error(t, config.SetHostRoutes(client, network, "10.9.0.0/24", viaGateway))
+	require.NoError(t, config.SetHostRoutes(client, network, "10.1.0.0/24",
+		viaGateway, viaRouter))
+	require.NoError(t, config.DeleteHostRoutes(client, network, "10.1.0.0/24",
+		"172.17.0.0/16"))
+
+	network, err = types.VirtualNetworkByUuid(client, netId)
+	require.NoError(t, err)
+	refs, err := network.GetNetworkIpamRefs()
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	subnet := refs[0].Attr.(types.VnSubnetsType).IpamSubnets[0]
+	require.NotNil(t, subnet.HostRoutes)
+	require.Len(t, subnet.HostRoutes.Route, 1)
+	assert.Equal(t, "10.1.0.1", subnet.HostRoutes.Route[0].NextHop)
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestTags(t *testing.T) {
+	client := newTestClient()
+	project := new(types.Project)
+	project.SetFQName("domain", []string{"default-domain", "tag-test"})
+	require.NoError(t, client.Create(project))
+	defer client.Delete(project)
+
+	web, err := config.CreateTag(client, project, "tier", "web")
+	require.NoError(t, err)
+	defer client.Delete(web)
+	assert.Equal(t, []string{"default-domain", "tag-test", "tier=web"}, web.GetFQName())
+
+	tag, err := config.TagByName(client, project, "tier", "web")
+	require.NoError(t, err)
+	assert.Equal(t, web.GetUuid(), tag.GetUuid())
+
+	network := new(types.VirtualNetwork)
+	network.SetParent(project)
+	network.SetName("tagged")
+	require.NoError(t, client.Create(network))
+	defer client.Delete(network)
+
+	require.NoError(t, config.ApplyTags(client, network, web))
+	uuids, err := config.ObjectsByTag(client, web, "virtual-network")
+	require.NoError(t, err)
+	assert.Equal(t, []string{network.GetUuid()}, uuids)
+
+	require.NoError(t, config.RemoveTags(client, network, web))
+	uuids, err = config.ObjectsByTag(client, web, "virtual-network")
+	require.NoError(t, err)
+	assert.Empty(t, uuids)
+}
+
+// tagSettingClient records the set-tag requests.
+type tagSettingClient struct {
+	contrail.ApiClient
+	requests [][]contrail.TagUpdate
+}
+
+func (c *tagSettingClient) SetTags(typename, uuid string,
+	updates ...contrail.TagUpdate) error {
+	c.requests = append(c.requests, updates)
+	return nil
+}
+
+func localTag(fqn []string, tagType, value string) *types.Tag {
+	tag := new(types.Tag)
+	tag.SetFQName("", fqn)
+	tag.SetTagTypeName(tagType)
+	tag.SetTagValue(value)
+	return tag
+}
+
+func TestApplyTagsLabels(t *testing.T) {
+	client := &tagSettingClient{ApiClient: newTestClient()}
+	network := new(types.VirtualNetwork)
+	network.SetUuid("net-uuid")
+
+	project := []string{"default-domain", "tag-test"}
+	tags := []*types.Tag{
+		localTag(append(project, "tier=web"), "tier", "web"),
+		localTag(append(project, "label=blue"), "label", "blue"),
+		localTag([]string{"label=prod"}, "label", "prod"),
+	}
+	require.NoError(t, config.ApplyTags(client, network, tags...))
+	require.Len(t, client.requests, 2)
+	assert.Equal(t, []contrail.TagUpdate{
+		{Type: "tier", Value: "web"},
+		{Type: "label", AddValues: []string{"blue"}},
+	}, client.requests[0])
+	assert.Equal(t, []contrail.TagUpdate{
+		{Type: "label", AddValues: []string{"prod"}, IsGlobal: true},
+	}, client.requests[1])
+}
+
+func TestApplyTagsReplace(t *testing.T) {
+	client := newTestClient()
+	project := new(types.Project)
+	project.SetFQName("domain", []string{"default-domain", "tag-replace"})
+	require.NoError(t, client.Create(project))
+	defer client.Delete(project)
+
+	var tags []*types.Tag
+	for _, spec := range [][2]string{{"tier", "web"}, {"tier", "db"}, {"label", "blue"}} {
+		tag, err := config.CreateTag(client, project, spec[0], spec[1])
+		require.NoError(t, err)
+		defer client.Delete(tag)
+		tags = append(tags, tag)
+	}
+	web, db, blue := tags[0], tags[1], tags[2]
+
+	network := new(types.VirtualNetwork)
+	network.SetParent(project)
+	network.SetName("retagged")
+	require.NoError(t, client.Create(network))
+	defer client.Delete(network)
+
+	require.NoError(t, config.ApplyTags(client, network, web, blue))
+	require.NoError(t, config.ApplyTags(client, network, db))
+	for _, tc := range []struct {
+		tag      *types.Tag
+		expected []string
+	}{
+		{web, nil},
+		{db, []string{network.GetUuid()}},
+		{blue, []string{network.GetUuid()}},
+	} {
+		uuids, err := config.ObjectsByTag(client, tc.tag, "virtual-network")
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, uuids, tc.tag.GetName())
+	}
+
+	assert.Error(t, config.ApplyTags(client, network, web, db))
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api/config"
+)
+
+func TestReadTopology(t *testing.T) {
+	client := fabricTestSetup(t)
+	port := config.PortID{Router: "leaf1", Interface: "xe-0/0/1"}
+	pi, err := config.FindPhysicalInterface(client, port)
+	require.NoError(t, err)
+	_, err = config.CreateLogicalInterface(client, pi, 100, "l2")
+	require.NoError(t, err)
+	vpg, err := config.CreateVirtualPortGroup(client, "dc1", "vpg1",
+		[]config.PortID{port, {Router: "leaf2", Interface: "xe-0/0/1"}})
+	require.NoError(t, err)
+
+	topology, err := config.ReadTopology(client)
+	require.NoError(t, err)
+	require.Len(t, topology.Routers, 2)
+	assert.Equal(t, "leaf1", topology.Routers[0].Router.GetName())
+	leaf1 := topology.Interface(port)
+	require.NotNil(t, leaf1)
+	require.Len(t, leaf1.LogicalInterfaces, 1)
+	assert.Equal(t, "xe-0/0/1.100", leaf1.LogicalInterfaces[0].Interface.GetName())
+	require.Len(t, leaf1.PortGroups, 1)
+	assert.Equal(t, vpg.GetUuid(), leaf1.PortGroups[0].GetUuid())
+
+	topology, err = config.ReadTopology(client, "leaf2")
+	require.NoError(t, err)
+	require.Len(t, topology.Routers, 1)
+	assert.Nil(t, topology.Interface(port))
+	leaf2 := topology.Routers[0].Interfaces[0]
+	assert.Empty(t, leaf2.LogicalInterfaces)
+	assert.Len(t, leaf2.PortGroups, 1)
+}
@@ -0,0 +1,32 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+//go:build contrail_r5
+// +build contrail_r5
+
+package contrail_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestTypeNameConstantsR5(t *testing.T) {
+	objects := map[string]contrail.IObject{
+		contrail.TypePolicyManagement: new(types.PolicyManagement),
+		contrail.TypeFirewallPolicy:   new(types.FirewallPolicy),
+		contrail.TypeFirewallRule:     new(types.FirewallRule),
+		contrail.TypeTag:              new(types.Tag),
+		contrail.TypeFabric:           new(types.Fabric),
+		contrail.TypeNodeProfile:      new(types.NodeProfile),
+		contrail.TypeVirtualPortGroup: new(types.VirtualPortGroup),
+	}
+	for typename, obj := range objects {
+		assert.Equal(t, typename, obj.GetType())
+	}
+}
@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+func TestTypeNameConstants(t *testing.T) {
+	objects := map[string]contrail.IObject{
+		contrail.TypeDomain:                  new(types.Domain),
+		contrail.TypeProject:                 new(types.Project),
+		contrail.TypeVirtualNetwork:          new(types.VirtualNetwork),
+		contrail.TypeVirtualMachine:          new(types.VirtualMachine),
+		contrail.TypeVirtualMachineInterface: new(types.VirtualMachineInterface),
+		contrail.TypeInstanceIp:              new(types.InstanceIp),
+		contrail.TypeFloatingIp:              new(types.FloatingIp),
+		contrail.TypeNetworkIpam:             new(types.NetworkIpam),
+		contrail.TypeNetworkPolicy:           new(types.NetworkPolicy),
+		contrail.TypeLogicalRouter:           new(types.LogicalRouter),
+		contrail.TypeRouteTable:              new(types.RouteTable),
+		contrail.TypeInterfaceRouteTable:     new(types.InterfaceRouteTable),
+		contrail.TypeGlobalSystemConfig:      new(types.GlobalSystemConfig),
+		contrail.TypePhysicalRouter:          new(types.PhysicalRouter),
+		contrail.TypePhysicalInterface:       new(types.PhysicalInterface),
+		contrail.TypeLogicalInterface:        new(types.LogicalInterface),
+		contrail.TypeVirtualRouter:           new(types.VirtualRouter),
+	}
+	for typename, obj := range objects {
+		assert.Equal(t, typename, obj.GetType())
+	}
+}
+
+func TestDefaultFQNames(t *testing.T) {
+	client := newTestClient()
+	fqn := strings.Join(contrail.DefaultNetworkIpamFQName(), ":")
+	_, err := client.FindByName(contrail.TypeNetworkIpam, fqn)
+	require.NoError(t, err)
+
+	network := new(types.VirtualNetwork)
+	assert.Equal(t, contrail.DefaultProjectFQName(), network.GetDefaultParent())
+}
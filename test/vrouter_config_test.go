@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Juniper/contrail-go-api"
+	"github.com/Juniper/contrail-go-api/config"
+	"github.com/Juniper/contrail-go-api/types"
+)
+
+// racingClient modifies the global-vrouter-config behind the caller's
+// back on the second lookup.
+type racingClient struct {
+	contrail.ApiClient
+	lookups int
+}
+
+func (c *racingClient) FindByName(typename, fqn string) (contrail.IObject, error) {
+	c.lookups++
+	if c.lookups == 2 {
+		entry, _ := config.NewLinkLocalService("other", "169.254.1.2", 80,
+			[]string{"10.0.0.2"}, 8080)
+		if err := config.SetLinkLocalService(c.ApiClient, entry); err != nil {
+			return nil, err
+		}
+	}
+	return c.ApiClient.FindByName(typename, fqn)
+}
+
+func createGlobalVrouterConfig(t *testing.T, client contrail.ApiClient) func() {
+	gsc := new(types.GlobalSystemConfig)
+	gsc.SetName(contrail.DefaultGlobalSystemConfig)
+	require.NoError(t, client.Create(gsc))
+	gvc := new(types.GlobalVrouterConfig)
+	gvc.SetParent(gsc)
+	gvc.SetName(contrail.DefaultGlobalVrouterConfig)
+	require.NoError(t, client.Create(gvc))
+	return func() {
+		client.Delete(gvc)
+		client.Delete(gsc)
+	}
+}
+
+func TestLinkLocalServices(t *testing.T) {
+	client := newTestClient()
+	defer createGlobalVrouterConfig(t, client)()
+
+	_, err := config.NewLinkLocalService("bad", "10.0.0.1", 0, []string{"x"}, 80)
+	assert.Error(t, err)
+	metadata, err := config.NewLinkLocalService("metadata", "169.254.169.254",
+		80, []string{"10.0.0.1"}, 8775)
+	require.NoError(t, err)
+	ntp, err := config.NewLinkLocalService("ntp", "169.254.1.1", 123,
+		[]string{"pool.ntp.org"}, 123)
+	require.NoError(t, err)
+	assert.Equal(t, "pool.ntp.org", ntp.IpFabricDnsServiceName)
+
+	require.NoError(t, config.SetLinkLocalService(client, metadata))
+	require.NoError(t, config.SetLinkLocalService(client, ntp))
+	require.NoError(t, config.SetLinkLocalService(client, metadata))
+	conflict := *ntp
+	conflict.LinklocalServiceName = "ntp2"
+	assert.Error(t, config.SetLinkLocalService(client, &conflict))
+
+	services, err := config.LinkLocalServices(client)
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Equal(t, "metadata", services[0].LinklocalServiceName)
+	assert.Equal(t, "ntp", services[1].LinklocalServiceName)
+
+	racing := &racingClient{ApiClient: client}
+	require.NoError(t, config.DeleteLinkLocalService(racing, "ntp"))
+	services, err = config.LinkLocalServices(client)
+	require.NoError(t, err)
+	var names []string
+	for _, service := range services {
+		names = append(names, service.LinklocalServiceName)
+	}
+	assert.Equal(t, "metadata,other", strings.Join(names, ","))
+}
+
+func TestGlobalVrouterSettings(t *testing.T) {
+	client := newTestClient()
+	defer createGlobalVrouterConfig(t, client)()
+
+	assert.Error(t, config.SetEncapsulationPriorities(client, "VXLAN", "VXLAN"))
+	assert.Error(t, config.SetEncapsulationPriorities(client, "GRE"))
+	require.NoError(t, config.SetEncapsulationPriorities(client,
+		config.EncapsulationVXLAN, config.EncapsulationMPLSoUDP))
+
+	assert.Error(t, config.SetFlowAgingTimeout(client, "sctp", 0, 60))
+	require.NoError(t, config.SetFlowAgingTimeout(client, "TCP", 22, 3600))
+	require.NoError(t, config.SetFlowAgingTimeout(client, "udp", 0, 60))
+	require.NoError(t, config.SetFlowAgingTimeout(client, "tcp", 22, 0))
+
+	obj, err := client.FindByName(contrail.TypeGlobalVrouterConfig,
+		strings.Join(contrail.DefaultGlobalVrouterConfigFQName(), ":"))
+	require.NoError(t, err)
+	gvc := obj.(*types.GlobalVrouterConfig)
+	assert.Equal(t, []string{"VXLAN", "MPLSoUDP"},
+		gvc.GetEncapsulationPriorities().Encapsulation)
+	timeouts := gvc.GetFlowAgingTimeoutList().FlowAgingTimeout
+	require.Len(t, timeouts, 1)
+	assert.Equal(t, "udp", timeouts[0].Protocol)
+	assert.Equal(t, 60, timeouts[0].TimeoutInSeconds)
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default timeouts of the API and keystone clients.
+const (
+	// DefaultTimeout bounds a complete request, including reading the
+	// response body.
+	DefaultTimeout = 60 * time.Second
+	// DefaultConnectTimeout bounds connection establishment, including
+	// the TLS handshake.
+	DefaultConnectTimeout = 10 * time.Second
+)
+
+// newTransport builds the transport used by the API client.
+func newTransport(connectTimeout, readTimeout time.Duration,
+	tlsConfig *tls.Config, dial dialOptions) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	proxy := http.ProxyFromEnvironment
+	if dial.socket != "" {
+		proxy = nil
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial.dialContext(dialer),
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: readTimeout,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+	}
+}
+
+// sharedTransport is the http.RoundTripper of a client and of the copies
+// made by WithTimeout. The underlying transport is replaced when the
+// configuration changes, so that the copies use the new one as well.
+type sharedTransport struct {
+	mutex     sync.Mutex
+	transport *http.Transport
+}
+
+func (t *sharedTransport) current() *http.Transport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.transport
+}
+
+func (t *sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current().RoundTrip(req)
+}
+
+// CloseIdleConnections is called by http.Client.CloseIdleConnections.
+func (t *sharedTransport) CloseIdleConnections() {
+	t.current().CloseIdleConnections()
+}
+
+// replace installs transport and closes the idle connections of the
+// previous one; the requests in progress complete on their connections.
+func (t *sharedTransport) replace(transport *http.Transport) {
+	t.mutex.Lock()
+	previous := t.transport
+	t.transport = transport
+	t.mutex.Unlock()
+	if previous != nil {
+		previous.CloseIdleConnections()
+	}
+}
+
+// updateTransport rebuilds the transport after a configuration change.
+func (c *Client) updateTransport() {
+	transport := newTransport(c.connectTimeout, c.readTimeout, c.tlsConfig,
+		c.dial)
+	configureHTTP2(transport, !c.disableHTTP2)
+	if c.transport == nil {
+		c.transport = new(sharedTransport)
+		c.httpClient.Transport = c.transport
+	}
+	c.transport.replace(transport)
+}
+
+// SetTimeout sets the maximum duration of a request, including reading
+// the response body. A value of 0 disables the timeout.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetConnectTimeout sets the maximum time spent establishing a connection
+// to the API server.
+func (c *Client) SetConnectTimeout(timeout time.Duration) {
+	c.connectTimeout = timeout
+	c.updateTransport()
+}
+
+// SetReadTimeout sets the maximum time to wait for the response headers
+// once the request is sent. A value of 0, the default, only limits the
+// wait by the overall timeout.
+func (c *Client) SetReadTimeout(timeout time.Duration) {
+	c.readTimeout = timeout
+	c.updateTransport()
+}
+
+// WithTimeout returns a copy of the client that uses a different overall
+// request timeout. The copy shares the connections and the transport of
+// the original client, including later changes to the connection
+// settings; it is intended for per-call overrides:
+//
+//	client.WithTimeout(5 * time.Minute).Delete(obj)
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	clone := *c
+	clone.httpClient = &http.Client{
+		Transport:     c.httpClient.Transport,
+		CheckRedirect: c.httpClient.CheckRedirect,
+		Jar:           c.httpClient.Jar,
+		Timeout:       timeout,
+	}
+	return &clone
+}
@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, rootDocument)
+	})
+	defer server.Close()
+
+	client.SetTimeout(20 * time.Millisecond)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected timeout")
+	}
+	if err := client.WithTimeout(time.Second).Ping(context.Background()); err != nil {
+		t.Errorf("per-call override: %v", err)
+	}
+
+	client.SetTimeout(0)
+	client.SetReadTimeout(20 * time.Millisecond)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected read timeout")
+	}
+	client.SetReadTimeout(0)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransportUpdate(t *testing.T) {
+	var closed int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(w, rootDocument)
+		}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			atomic.AddInt32(&closed, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	client := NewClient(host, portNum)
+	clone := client.WithTimeout(time.Minute)
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The idle connection of the previous transport is closed, and the
+	// copy uses the new transport.
+	client.SetReadTimeout(10 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&closed) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&closed) != 1 {
+		t.Error("idle connection not closed")
+	}
+	if err := clone.Ping(context.Background()); err == nil {
+		t.Error("expected read timeout on the copy")
+	}
+}
@@ -0,0 +1,224 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CachedToken is a keystone token persisted between process runs.
+type CachedToken struct {
+	ID        string     `json:"id"`
+	IssuedAt  string     `json:"issued_at"`
+	ExpiresAt string     `json:"expires_at"`
+	Info      *TokenInfo `json:"info,omitempty"`
+}
+
+// usable returns true if the token is within the first half of its
+// lifetime, the period during which KeepaliveKeystoneClient uses a token.
+func (t *CachedToken) usable(now time.Time) bool {
+	issued, err := time.Parse(time.RFC3339, t.IssuedAt)
+	if err != nil {
+		return false
+	}
+	expires, err := time.Parse(time.RFC3339, t.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return t.ID != "" && now.Before(issued.Add(expires.Sub(issued)/2))
+}
+
+// TokenCache stores keystone tokens. key identifies the credentials and
+// scope the token was issued for. Implementations backed by an OS keyring
+// can be supplied by the application.
+type TokenCache interface {
+	// Load returns the cached token for key, or nil if there is none.
+	Load(key string) (*CachedToken, error)
+	Store(key string, token *CachedToken) error
+}
+
+// FileTokenCache stores tokens in a file encrypted with AES-GCM. The
+// encryption key is derived from a secret supplied by the application.
+type FileTokenCache struct {
+	path  string
+	key   [32]byte
+	mutex sync.Mutex
+}
+
+// NewFileTokenCache returns a cache stored at path. The file is created,
+// with mode 0600, on the first Store.
+func NewFileTokenCache(path, secret string) *FileTokenCache {
+	return &FileTokenCache{path: path, key: sha256.Sum256([]byte(secret))}
+}
+
+func (c *FileTokenCache) read() (map[string]*CachedToken, error) {
+	tokens := make(map[string]*CachedToken)
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s: invalid token cache", c.path)
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid token cache: %v", c.path, err)
+	}
+	if err := json.Unmarshal(plain, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (c *FileTokenCache) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Load implements TokenCache.
+func (c *FileTokenCache) Load(key string) (*CachedToken, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	return tokens[key], nil
+}
+
+// Store implements TokenCache. Expired tokens are removed from the file.
+func (c *FileTokenCache) Store(key string, token *CachedToken) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		// Replace a corrupt cache or one encrypted with another secret.
+		tokens = make(map[string]*CachedToken)
+	}
+	now := time.Now()
+	for k, t := range tokens {
+		if !t.usable(now) {
+			delete(tokens, k)
+		}
+	}
+	tokens[key] = token
+	plain, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	gcm, err := c.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := gcm.Seal(nonce, nonce, plain, nil)
+
+	// Write atomically so that concurrent processes see a complete file.
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), ".token-cache")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// SetTokenCache enables the reuse of tokens between process runs.
+func (kClient *KeystoneClient) SetTokenCache(cache TokenCache) {
+	kClient.tokenCache = cache
+}
+
+// tokenCacheKey identifies the credentials and scope of the client. The
+// password and token are included through a digest salted with the
+// identity fields: a cached token is not reused once the credentials
+// change, and users with the same password don't share a digest.
+func (kClient *KeystoneClient) tokenCacheKey() string {
+	hash := sha256.New()
+	for _, s := range []string{kClient.osAuthURL, kClient.osUsername,
+		kClient.osDomainName, kClient.osDomainID, kClient.osTenantName,
+		kClient.osProjectName, kClient.osProjectDomainName,
+		kClient.osProjectDomainID} {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
+	}
+	if kClient.systemScope != "" {
+		hash.Write([]byte("system:" + kClient.systemScope))
+	}
+	identity := hash.Sum(nil)
+	mac := hmac.New(sha256.New, identity)
+	mac.Write([]byte(kClient.osPassword))
+	mac.Write([]byte{0})
+	mac.Write([]byte(kClient.osAdminToken))
+	hash.Write(mac.Sum(nil))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// loadCachedToken sets the current token from the cache. It returns false
+// if there is no usable cached token. The key must be computed before
+// authenticating, which may modify the auth URL.
+func (kClient *KeystoneClient) loadCachedToken(key string) bool {
+	if kClient.tokenCache == nil {
+		return false
+	}
+	token, err := kClient.tokenCache.Load(key)
+	if err != nil || token == nil || !token.usable(time.Now()) {
+		return false
+	}
+	kClient.tokenID = token.ID
+	kClient.issuedAt = token.IssuedAt
+	kClient.expiresAt = token.ExpiresAt
+	kClient.tokenInfo = token.Info
+	return true
+}
+
+// storeCachedToken saves the current token. Failures are not fatal: the
+// cache is an optimization.
+func (kClient *KeystoneClient) storeCachedToken(key string) {
+	if kClient.tokenCache == nil {
+		return
+	}
+	kClient.tokenCache.Store(key, &CachedToken{
+		ID:        kClient.tokenID,
+		IssuedAt:  kClient.issuedAt,
+		ExpiresAt: kClient.expiresAt,
+		Info:      kClient.tokenInfo,
+	})
+}
@@ -0,0 +1,164 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	handler := new(keystoneV3Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "token-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tokens")
+
+	authenticate := func(cache TokenCache, username string) string {
+		keystone, err := NewKeystoneClientWithOptions(
+			WithAuthURL(server.URL),
+			WithCredentials(username, "secret"),
+			WithDomain("Default"),
+			WithProjectScope("demo", ""),
+			WithTokenCache(cache),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+		if err := keystone.AddAuthentication(req); err != nil {
+			t.Fatal(err)
+		}
+		return req.Header.Get("X-Auth-Token")
+	}
+
+	// Successive "process runs" reuse the token.
+	if token := authenticate(NewFileTokenCache(path, "key"), "admin"); token != "token-1" {
+		t.Errorf("unexpected token %q", token)
+	}
+	if token := authenticate(NewFileTokenCache(path, "key"), "admin"); token != "token-1" {
+		t.Errorf("cached token not used: %q", token)
+	}
+	if handler.count != 1 {
+		t.Errorf("expected 1 authentication, got %d", handler.count)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected mode %v", info.Mode())
+	}
+
+	// Different credentials get a different token.
+	if token := authenticate(NewFileTokenCache(path, "key"), "demo"); token != "token-2" {
+		t.Errorf("unexpected token %q", token)
+	}
+	// The file can't be read with a different secret.
+	if _, err := NewFileTokenCache(path, "other").Load("any"); err == nil {
+		t.Error("expected decryption error")
+	}
+	if token := authenticate(NewFileTokenCache(path, "other"), "admin"); token != "token-3" {
+		t.Errorf("unexpected token %q", token)
+	}
+}
+
+func TestCachedTokenUsable(t *testing.T) {
+	token := &CachedToken{
+		ID:        "t",
+		IssuedAt:  "2020-01-01T00:00:00Z",
+		ExpiresAt: "2020-01-01T02:00:00.000000Z",
+	}
+	now, _ := time.Parse(time.RFC3339, "2020-01-01T00:59:00Z")
+	if !token.usable(now) {
+		t.Error("token should be usable")
+	}
+	now, _ = time.Parse(time.RFC3339, "2020-01-01T01:01:00Z")
+	if token.usable(now) {
+		t.Error("token past half of its lifetime should not be used")
+	}
+}
+
+func TestTokenCacheKey(t *testing.T) {
+	key := func(options ...KeystoneOption) string {
+		keystone, err := NewKeystoneClientWithOptions(append([]KeystoneOption{
+			WithAuthURL("http://keystone:5000/v3"),
+			WithProjectScope("demo", "Default"),
+		}, options...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keystone.tokenCacheKey()
+	}
+	base := key(WithCredentials("admin", "secret"))
+	if key(WithCredentials("admin", "secret")) != base {
+		t.Error("key is not stable")
+	}
+	for _, other := range []string{
+		key(WithCredentials("admin", "changed")),
+		key(WithCredentials("admin", "secret"), WithUserDomainID("d1")),
+		key(WithCredentials("admin", "secret"), WithProjectDomainID("d1")),
+		key(WithCredentials("demo", "secret")),
+		key(WithCredentials("admin", "secret"), WithSystemScope(SystemScopeAll)),
+	} {
+		if other == base {
+			t.Error("different credentials share a cache key")
+		}
+	}
+}
+
+func TestTokenInfo(t *testing.T) {
+	handler := &keystoneV3Handler{extra: `,
+		"user": {"id": "u1", "name": "admin", "domain": {"id": "default", "name": "Default"}},
+		"project": {"id": "p1", "name": "demo"},
+		"roles": [{"id": "r1", "name": "admin"}, {"id": "r2", "name": "member"}]`}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "token-info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewFileTokenCache(filepath.Join(dir, "tokens"), "key")
+
+	for i := 0; i < 2; i++ {
+		keystone, err := NewKeystoneClientWithOptions(
+			WithAuthURL(server.URL),
+			WithCredentials("admin", "secret"),
+			WithProjectScope("demo", "Default"),
+			WithTokenCache(cache),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keystone.TokenInfo() != nil {
+			t.Error("token info before authentication")
+		}
+		req, _ := http.NewRequest("GET", "http://localhost:8082/", nil)
+		if err := keystone.AddAuthentication(req); err != nil {
+			t.Fatal(err)
+		}
+		info := keystone.TokenInfo()
+		if info == nil || info.UserID != "u1" || info.ProjectID != "p1" ||
+			info.DomainID != "default" || !info.HasRole("member") ||
+			info.HasRole("reader") || info.ExpiresAt.Before(time.Now()) {
+			t.Errorf("unexpected token info %+v", info)
+		}
+	}
+	if handler.count != 1 {
+		t.Errorf("expected 1 authentication, got %d", handler.count)
+	}
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"time"
+)
+
+// TokenInfo describes the identity and scope of a keystone token.
+type TokenInfo struct {
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	DomainID    string `json:"domain_id,omitempty"`
+	ProjectID   string `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	// SystemScope is set for system-scoped tokens.
+	SystemScope bool      `json:"system_scope,omitempty"`
+	Roles       []string  `json:"roles"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// HasRole returns true if the token grants the named role.
+func (info *TokenInfo) HasRole(name string) bool {
+	for _, role := range info.Roles {
+		if role == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setTimes parses the keystone RFC 3339 timestamps. Timestamps that can't
+// be parsed are left unset.
+func (info *TokenInfo) setTimes(issuedAt, expiresAt string) {
+	info.IssuedAt, _ = time.Parse(time.RFC3339, issuedAt)
+	info.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+}
+
+func tokenInfoFromV3(response *KeystoneTokenv3) *TokenInfo {
+	token := &response.Token
+	info := &TokenInfo{
+		UserID:      token.User.Id,
+		UserName:    token.User.Name,
+		DomainID:    token.User.Domain.Id,
+		ProjectID:   token.Project.Id,
+		ProjectName: token.Project.Name,
+		SystemScope: token.System.All,
+	}
+	for _, role := range token.Roles {
+		info.Roles = append(info.Roles, role.Name)
+	}
+	info.setTimes(token.IssuedAt, token.ExpiresAt)
+	return info
+}
+
+// TokenInfo returns the identity, scope and roles of the current token,
+// or nil before the client has authenticated.
+func (kClient *KeystoneClient) TokenInfo() *TokenInfo {
+	kClient.mutex.Lock()
+	defer kClient.mutex.Unlock()
+	if kClient.tokenInfo == nil {
+		return nil
+	}
+	info := *kClient.tokenInfo
+	info.Roles = append([]string(nil), kClient.tokenInfo.Roles...)
+	return &info
+}
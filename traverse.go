@@ -0,0 +1,257 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// EdgeKind is the kind of relationship between two objects.
+type EdgeKind int
+
+const (
+	// EdgeRef is a forward reference (e.g. virtual_network_refs).
+	EdgeRef EdgeKind = iota
+	// EdgeBackRef is a reference from another object.
+	EdgeBackRef
+	// EdgeChild is a parent to child relationship.
+	EdgeChild
+)
+
+func (k EdgeKind) String() string {
+	switch k {
+	case EdgeRef:
+		return "ref"
+	case EdgeBackRef:
+		return "back_ref"
+	case EdgeChild:
+		return "child"
+	}
+	return "unknown"
+}
+
+// TraverseOptions controls a Traverse.
+type TraverseOptions struct {
+	// MaxDepth limits the distance from the start object. 0 means no
+	// limit.
+	MaxDepth int
+	// Kinds lists the relationships to follow. All are followed by
+	// default.
+	Kinds []EdgeKind
+	// Types restricts the traversal to objects of the listed types (the
+	// start object is always visited).
+	Types []string
+	// Parallelism is the number of concurrent reads. Defaults to 4.
+	Parallelism int
+}
+
+// Visit describes an object reached by Traverse. From, Field and Kind
+// identify the relationship the object was reached through; they are not
+// set for the start object.
+type Visit struct {
+	Object IObject
+	Depth  int
+	From   IObject
+	Field  string
+	Kind   EdgeKind
+	// Attr is the reference attribute, for EdgeRef and EdgeBackRef.
+	Attr interface{}
+}
+
+// SkipObject can be returned by a Traverse visitor to not follow the
+// relationships of the visited object.
+var SkipObject = errors.New("skip object")
+
+// parallelDo invokes fn for 0..n-1 with at most workers concurrent calls.
+// It returns the first error.
+func parallelDo(n, workers int, fn func(i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	indices := make(chan int)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type traverseEdge struct {
+	from  IObject
+	field string
+	kind  EdgeKind
+	ref   Reference
+}
+
+// edgeFields returns the reference fields of an object type to follow,
+// sorted by name.
+func edgeFields(typename string, kinds map[EdgeKind]bool) (
+	map[string]EdgeKind, []string, error) {
+	fields, err := TypeReferenceFields(typename)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make(map[string]EdgeKind)
+	add := func(names []string, kind EdgeKind) {
+		if kinds[kind] {
+			for _, name := range names {
+				result[name] = kind
+			}
+		}
+	}
+	add(fields.Refs, EdgeRef)
+	add(fields.BackRefs, EdgeBackRef)
+	add(fields.Children, EdgeChild)
+	names := make([]string, 0, len(result))
+	for name := range result {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return result, names, nil
+}
+
+// Traverse walks the graph of objects reachable from start through
+// references, back references and children, breadth first, and invokes
+// visit once for each object. The reads of each level of the traversal
+// are issued concurrently. Returning SkipObject from visit prunes the
+// traversal at that object; any other error stops it.
+//
+//	// What depends on this virtual-network?
+//	err := contrail.Traverse(client, network, &contrail.TraverseOptions{
+//		MaxDepth: 2,
+//		Kinds:    []contrail.EdgeKind{contrail.EdgeBackRef},
+//	}, func(v *contrail.Visit) error {
+//		fmt.Println(v.Depth, v.Object.GetType(), v.Object.GetFQName())
+//		return nil
+//	})
+func Traverse(client ApiClient, start IObject, options *TraverseOptions,
+	visit func(*Visit) error) error {
+	opts := TraverseOptions{Parallelism: 4}
+	if options != nil {
+		opts = *options
+		if opts.Parallelism <= 0 {
+			opts.Parallelism = 4
+		}
+	}
+	kinds := map[EdgeKind]bool{EdgeRef: true, EdgeBackRef: true, EdgeChild: true}
+	if len(opts.Kinds) > 0 {
+		kinds = make(map[EdgeKind]bool)
+		for _, kind := range opts.Kinds {
+			kinds[kind] = true
+		}
+	}
+	var types map[string]bool
+	if len(opts.Types) > 0 {
+		types = make(map[string]bool)
+		for _, typename := range opts.Types {
+			types[typename] = true
+		}
+	}
+
+	visited := map[string]bool{start.GetUuid(): true}
+	if err := visit(&Visit{Object: start}); err != nil {
+		if err == SkipObject {
+			return nil
+		}
+		return err
+	}
+	level := []IObject{start}
+	for depth := 1; len(level) > 0 && (opts.MaxDepth <= 0 || depth <= opts.MaxDepth); depth++ {
+		// Load the relationships of the objects of the current level.
+		edges := make([][]traverseEdge, len(level))
+		err := parallelDo(len(level), opts.Parallelism, func(i int) error {
+			obj := level[i]
+			fieldKinds, names, err := edgeFields(obj.GetType(), kinds)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if types != nil && !types[ReferenceFieldType(name)] {
+					continue
+				}
+				// Forward references are read with the object; back
+				// references and children are read once, here.
+				if fieldKinds[name] != EdgeRef {
+					if err := client.GetField(obj, name); err != nil {
+						return err
+					}
+				}
+				refs, err := GetReferenceList(obj, name)
+				if err != nil {
+					return err
+				}
+				for _, ref := range refs {
+					edges[i] = append(edges[i], traverseEdge{obj, name, fieldKinds[name], ref})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Read the objects that have not been visited yet.
+		var pending []traverseEdge
+		seen := make(map[string]bool)
+		for _, list := range edges {
+			for _, edge := range list {
+				if !visited[edge.ref.Uuid] && !seen[edge.ref.Uuid] {
+					seen[edge.ref.Uuid] = true
+					pending = append(pending, edge)
+				}
+			}
+		}
+		objects := make([]IObject, len(pending))
+		err = parallelDo(len(pending), opts.Parallelism, func(i int) error {
+			var err error
+			objects[i], err = client.FindByUuid(
+				ReferenceFieldType(pending[i].field), pending[i].ref.Uuid)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		level = nil
+		for i, edge := range pending {
+			visited[edge.ref.Uuid] = true
+			err := visit(&Visit{
+				Object: objects[i],
+				Depth:  depth,
+				From:   edge.from,
+				Field:  edge.field,
+				Kind:   edge.kind,
+				Attr:   edge.ref.Attr,
+			})
+			if err == SkipObject {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			level = append(level, objects[i])
+		}
+	}
+	return nil
+}
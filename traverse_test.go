@@ -0,0 +1,261 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// graphBase implements the IObject methods common to the graph test types.
+type graphBase struct {
+	ObjectBase
+}
+
+func (*graphBase) GetDefaultParent() []string    { return nil }
+func (*graphBase) GetDefaultParentType() string  { return "" }
+func (obj *graphBase) SetName(name string)       { obj.name = name }
+func (*graphBase) UpdateObject() ([]byte, error) { return nil, nil }
+func (*graphBase) UpdateReferences() error       { return nil }
+func (*graphBase) UpdateDone()                   {}
+
+// graphGets counts the calls of the reference list getters, by uuid and
+// field.
+var graphGets = struct {
+	sync.Mutex
+	calls map[string]int
+}{calls: make(map[string]int)}
+
+func countGet(obj IObject, field string) {
+	graphGets.Lock()
+	defer graphGets.Unlock()
+	graphGets.calls[obj.GetUuid()+"."+field]++
+}
+
+type graphProject struct {
+	graphBase
+	graph_networks ReferenceList
+}
+
+func (*graphProject) GetType() string { return "graph-project" }
+func (obj *graphProject) GetGraphNetworks() (ReferenceList, error) {
+	countGet(obj, "graph_networks")
+	return obj.graph_networks, nil
+}
+
+type graphNetwork struct {
+	graphBase
+	graph_policy_refs    ReferenceList
+	graph_port_back_refs ReferenceList
+}
+
+func (*graphNetwork) GetType() string { return "graph-network" }
+func (obj *graphNetwork) GetGraphPolicyRefs() (ReferenceList, error) {
+	countGet(obj, "graph_policy_refs")
+	return obj.graph_policy_refs, nil
+}
+func (obj *graphNetwork) GetGraphPortBackRefs() (ReferenceList, error) {
+	countGet(obj, "graph_port_back_refs")
+	return obj.graph_port_back_refs, nil
+}
+
+type graphPort struct {
+	graphBase
+	graph_network_refs ReferenceList
+}
+
+func (*graphPort) GetType() string { return "graph-port" }
+func (obj *graphPort) GetGraphNetworkRefs() (ReferenceList, error) {
+	countGet(obj, "graph_network_refs")
+	return obj.graph_network_refs, nil
+}
+
+type graphPolicy struct {
+	graphBase
+}
+
+func (*graphPolicy) GetType() string { return "graph-policy" }
+
+// graphClient serves the objects of a test graph. Back references are
+// only returned by GetField.
+type graphClient struct {
+	ApiClient
+	mutex    sync.Mutex
+	objects  map[string]IObject
+	backRefs map[string]ReferenceList
+	reads    []string
+	fields   []string
+}
+
+func newGraphClient() *graphClient {
+	RegisterTypeMap(TypeMap{
+		"graph-project": reflect.TypeOf(graphProject{}),
+		"graph-network": reflect.TypeOf(graphNetwork{}),
+		"graph-port":    reflect.TypeOf(graphPort{}),
+		"graph-policy":  reflect.TypeOf(graphPolicy{}),
+	})
+	client := &graphClient{
+		objects:  make(map[string]IObject),
+		backRefs: make(map[string]ReferenceList),
+	}
+	project := &graphProject{}
+	client.add(project, "project")
+	policy := &graphPolicy{}
+	client.add(policy, "policy")
+	for i := 0; i < 2; i++ {
+		network := &graphNetwork{}
+		client.add(network, fmt.Sprintf("net%d", i))
+		project.graph_networks = append(project.graph_networks,
+			Reference{To: network.GetFQName(), Uuid: network.GetUuid()})
+		network.graph_policy_refs = ReferenceList{
+			{To: policy.GetFQName(), Uuid: policy.GetUuid()}}
+		port := &graphPort{}
+		client.add(port, fmt.Sprintf("port%d", i))
+		port.graph_network_refs = ReferenceList{
+			{To: network.GetFQName(), Uuid: network.GetUuid()}}
+		client.backRefs[network.GetUuid()] = ReferenceList{
+			{To: port.GetFQName(), Uuid: port.GetUuid()}}
+	}
+	return client
+}
+
+func (c *graphClient) add(obj IObject, name string) {
+	obj.SetUuid(name)
+	obj.SetFQName("", []string{name})
+	obj.SetName(name)
+	c.objects[name] = obj
+}
+
+func (c *graphClient) FindByUuid(typename, uuid string) (IObject, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reads = append(c.reads, uuid)
+	obj, ok := c.objects[uuid]
+	if !ok || obj.GetType() != typename {
+		return nil, fmt.Errorf("404 Not Found: %s %s", typename, uuid)
+	}
+	return obj, nil
+}
+
+func (c *graphClient) GetField(obj IObject, field string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.fields = append(c.fields, obj.GetUuid()+"."+field)
+	if network, ok := obj.(*graphNetwork); ok && field == "graph_port_back_refs" {
+		network.graph_port_back_refs = c.backRefs[network.GetUuid()]
+	}
+	return nil
+}
+
+func traverseAll(t *testing.T, client ApiClient, start IObject,
+	options *TraverseOptions) []string {
+	var visits []string
+	err := Traverse(client, start, options, func(v *Visit) error {
+		entry := fmt.Sprintf("%d:%s", v.Depth, v.Object.GetName())
+		if v.From != nil {
+			entry += fmt.Sprintf(" %s<-%s.%s", v.Kind, v.From.GetName(), v.Field)
+		}
+		visits = append(visits, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return visits
+}
+
+func TestTraverse(t *testing.T) {
+	client := newGraphClient()
+	start := client.objects["project"]
+
+	visits := traverseAll(t, client, start, nil)
+	expected := []string{
+		"0:project",
+		"1:net0 child<-project.graph_networks",
+		"1:net1 child<-project.graph_networks",
+		"2:policy ref<-net0.graph_policy_refs",
+		"2:port0 back_ref<-net0.graph_port_back_refs",
+		"2:port1 back_ref<-net1.graph_port_back_refs",
+	}
+	if !reflect.DeepEqual(visits, expected) {
+		t.Errorf("expected %v, got %v", expected, visits)
+	}
+
+	// Each object is read once.
+	reads := append([]string(nil), client.reads...)
+	sort.Strings(reads)
+	if strings.Join(reads, ",") != "net0,net1,policy,port0,port1" {
+		t.Errorf("unexpected reads %v", reads)
+	}
+}
+
+func TestTraverseOptions(t *testing.T) {
+	client := newGraphClient()
+	start := client.objects["project"]
+
+	visits := traverseAll(t, client, start, &TraverseOptions{MaxDepth: 1})
+	if len(visits) != 3 {
+		t.Errorf("depth 1: %v", visits)
+	}
+
+	visits = traverseAll(t, client, start, &TraverseOptions{
+		Kinds: []EdgeKind{EdgeChild, EdgeRef},
+	})
+	if strings.Join(visits, ";") != "0:project;1:net0 child<-project.graph_networks;1:net1 child<-project.graph_networks;2:policy ref<-net0.graph_policy_refs" {
+		t.Errorf("kinds: %v", visits)
+	}
+
+	visits = traverseAll(t, client, start, &TraverseOptions{
+		Types: []string{"graph-network", "graph-port"},
+	})
+	if len(visits) != 5 {
+		t.Errorf("types: %v", visits)
+	}
+}
+
+func TestTraverseSkip(t *testing.T) {
+	client := newGraphClient()
+	var visits []string
+	err := Traverse(client, client.objects["project"], nil, func(v *Visit) error {
+		visits = append(visits, v.Object.GetName())
+		if v.Object.GetName() == "net0" {
+			return SkipObject
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(visits, ",") != "project,net0,net1,policy,port1" {
+		t.Errorf("unexpected visits %v", visits)
+	}
+}
+
+func TestTraverseFieldReads(t *testing.T) {
+	client := newGraphClient()
+	start := client.objects["project"]
+	graphGets.Lock()
+	graphGets.calls = make(map[string]int)
+	graphGets.Unlock()
+
+	visits := traverseAll(t, client, start, &TraverseOptions{
+		Types: []string{"graph-network"},
+	})
+	if len(visits) != 3 {
+		t.Errorf("unexpected visits %v", visits)
+	}
+	// Only the fields of the selected types are read, once.
+	if strings.Join(client.fields, ",") != "project.graph_networks" {
+		t.Errorf("unexpected fields read %v", client.fields)
+	}
+	expected := map[string]int{"project.graph_networks": 1}
+	if !reflect.DeepEqual(graphGets.calls, expected) {
+		t.Errorf("expected getter calls %v, got %v", expected, graphGets.calls)
+	}
+}
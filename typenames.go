@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+// Object type names, as used in API server URLs and by the generated types
+// library. Using the constants instead of literals turns typos into
+// compilation errors rather than 404 responses.
+const (
+	TypeAccessControlList         = "access-control-list"
+	TypeAddressGroup              = "address-group"
+	TypeAlarm                     = "alarm"
+	TypeAliasIp                   = "alias-ip"
+	TypeAliasIpPool               = "alias-ip-pool"
+	TypeAnalyticsNode             = "analytics-node"
+	TypeApiAccessList             = "api-access-list"
+	TypeApplicationPolicySet      = "application-policy-set"
+	TypeBgpAsAService             = "bgp-as-a-service"
+	TypeBgpRouter                 = "bgp-router"
+	TypeBgpvpn                    = "bgpvpn"
+	TypeBridgeDomain              = "bridge-domain"
+	TypeConfigNode                = "config-node"
+	TypeConfigRoot                = "config-root"
+	TypeDatabaseNode              = "database-node"
+	TypeDomain                    = "domain"
+	TypeFabric                    = "fabric"
+	TypeFabricNamespace           = "fabric-namespace"
+	TypeFirewallPolicy            = "firewall-policy"
+	TypeFirewallRule              = "firewall-rule"
+	TypeFloatingIp                = "floating-ip"
+	TypeFloatingIpPool            = "floating-ip-pool"
+	TypeForwardingClass           = "forwarding-class"
+	TypeGlobalQosConfig           = "global-qos-config"
+	TypeGlobalSystemConfig        = "global-system-config"
+	TypeGlobalVrouterConfig       = "global-vrouter-config"
+	TypeInstanceIp                = "instance-ip"
+	TypeInterfaceRouteTable       = "interface-route-table"
+	TypeJobTemplate               = "job-template"
+	TypeLoadbalancer              = "loadbalancer"
+	TypeLoadbalancerHealthmonitor = "loadbalancer-healthmonitor"
+	TypeLoadbalancerListener      = "loadbalancer-listener"
+	TypeLoadbalancerMember        = "loadbalancer-member"
+	TypeLoadbalancerPool          = "loadbalancer-pool"
+	TypeLogicalInterface          = "logical-interface"
+	TypeLogicalRouter             = "logical-router"
+	TypeNamespace                 = "namespace"
+	TypeNetworkIpam               = "network-ipam"
+	TypeNetworkPolicy             = "network-policy"
+	TypeNodeProfile               = "node-profile"
+	TypePhysicalInterface         = "physical-interface"
+	TypePhysicalRouter            = "physical-router"
+	TypePolicyManagement          = "policy-management"
+	TypePortTuple                 = "port-tuple"
+	TypeProject                   = "project"
+	TypeQosConfig                 = "qos-config"
+	TypeQosQueue                  = "qos-queue"
+	TypeRouteAggregate            = "route-aggregate"
+	TypeRouteTable                = "route-table"
+	TypeRouteTarget               = "route-target"
+	TypeRoutingInstance           = "routing-instance"
+	TypeRoutingPolicy             = "routing-policy"
+	TypeSecurityGroup             = "security-group"
+	TypeSecurityLoggingObject     = "security-logging-object"
+	TypeServiceAppliance          = "service-appliance"
+	TypeServiceApplianceSet       = "service-appliance-set"
+	TypeServiceGroup              = "service-group"
+	TypeServiceHealthCheck        = "service-health-check"
+	TypeServiceInstance           = "service-instance"
+	TypeServiceTemplate           = "service-template"
+	TypeSubnet                    = "subnet"
+	TypeTag                       = "tag"
+	TypeTagType                   = "tag-type"
+	TypeVirtualDns                = "virtual-DNS"
+	TypeVirtualDnsRecord          = "virtual-DNS-record"
+	TypeVirtualIp                 = "virtual-ip"
+	TypeVirtualMachine            = "virtual-machine"
+	TypeVirtualMachineInterface   = "virtual-machine-interface"
+	TypeVirtualNetwork            = "virtual-network"
+	TypeVirtualPortGroup          = "virtual-port-group"
+	TypeVirtualRouter             = "virtual-router"
+)
+
+// Names of the objects created by the API server at initialization.
+const (
+	DefaultDomain              = "default-domain"
+	DefaultProject             = "default-project"
+	DefaultNetworkIpam         = "default-network-ipam"
+	DefaultGlobalSystemConfig  = "default-global-system-config"
+	DefaultGlobalVrouterConfig = "default-global-vrouter-config"
+	DefaultGlobalQosConfig     = "default-global-qos-config"
+	DefaultPolicyManagement    = "default-policy-management"
+)
+
+// DefaultProjectFQName returns the fq_name of the default project.
+func DefaultProjectFQName() []string {
+	return []string{DefaultDomain, DefaultProject}
+}
+
+// DefaultNetworkIpamFQName returns the fq_name of the default network-ipam.
+func DefaultNetworkIpamFQName() []string {
+	return []string{DefaultDomain, DefaultProject, DefaultNetworkIpam}
+}
+
+// DefaultGlobalSystemConfigFQName returns the fq_name of the
+// global-system-config singleton.
+func DefaultGlobalSystemConfigFQName() []string {
+	return []string{DefaultGlobalSystemConfig}
+}
+
+// DefaultGlobalVrouterConfigFQName returns the fq_name of the
+// global-vrouter-config singleton.
+func DefaultGlobalVrouterConfigFQName() []string {
+	return []string{DefaultGlobalSystemConfig, DefaultGlobalVrouterConfig}
+}
+
+// DefaultGlobalQosConfigFQName returns the fq_name of the global-qos-config
+// singleton.
+func DefaultGlobalQosConfigFQName() []string {
+	return []string{DefaultGlobalSystemConfig, DefaultGlobalQosConfig}
+}
+
+// DefaultPolicyManagementFQName returns the fq_name of the global
+// policy-management.
+func DefaultPolicyManagementFQName() []string {
+	return []string{DefaultPolicyManagement}
+}
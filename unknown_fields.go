@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// Unknown field preservation.
+//
+// A server running a newer schema than the one the types were generated
+// from returns properties the types don't have fields for. The client
+// keeps them in the ObjectBase when it decodes an object and sends them
+// back when it creates or updates the object, so that reading and writing
+// an object with an older client doesn't strip the new attributes. The
+// generated types also keep them when they are decoded and encoded with
+// encoding/json (see UnmarshalUnknown and MarshalUnknown).
+
+// commonFields are the keys decoded by UnmarshalCommon or computed by the
+// API server.
+var commonFields = map[string]bool{
+	"fq_name":     true,
+	"uuid":        true,
+	"name":        true,
+	"href":        true,
+	"parent_type": true,
+	"parent_uuid": true,
+	"parent_href": true,
+}
+
+// knownFieldsCache maps a type to the set of JSON keys it has fields for.
+var knownFieldsCache sync.Map
+
+// knownFields returns the JSON keys of a generated type: the generated
+// struct fields are named after the schema properties and references.
+func knownFields(xtype reflect.Type) map[string]bool {
+	if fields, ok := knownFieldsCache.Load(xtype); ok {
+		return fields.(map[string]bool)
+	}
+	fields := make(map[string]bool)
+	for i := 0; i < xtype.NumField(); i++ {
+		field := xtype.Field(i)
+		if !field.Anonymous {
+			fields[field.Name] = true
+		}
+	}
+	knownFieldsCache.Store(xtype, fields)
+	return fields
+}
+
+// UnmarshalUnknown stores the keys of m that vPtr has no field for. It is
+// called by the generated UnmarshalJSON methods.
+func (obj *ObjectBase) UnmarshalUnknown(vPtr IObject, m map[string]json.RawMessage) {
+	xtype := reflect.TypeOf(vPtr)
+	if xtype.Kind() == reflect.Ptr {
+		xtype = xtype.Elem()
+	}
+	known := knownFields(xtype)
+	obj.unknown = nil
+	for key, value := range m {
+		if commonFields[key] || known[key] {
+			continue
+		}
+		if obj.unknown == nil {
+			obj.unknown = make(map[string]json.RawMessage)
+		}
+		obj.unknown[key] = value
+	}
+}
+
+// MarshalUnknown adds the stored unknown fields to m. It is called by the
+// generated MarshalJSON and UpdateObject methods.
+func (obj *ObjectBase) MarshalUnknown(m map[string]*json.RawMessage) {
+	for key, value := range obj.unknown {
+		if _, exists := m[key]; exists {
+			continue
+		}
+		data := value
+		m[key] = &data
+	}
+}
+
+// captureUnknown stores the attributes of obj received from the API server
+// (see recordReceived) that its type has no field for. GenericObjects hold
+// all their attributes.
+func captureUnknown(obj IObject) {
+	if _, ok := obj.(*GenericObject); ok {
+		return
+	}
+	base, ok := obj.(baseObject)
+	if !ok {
+		return
+	}
+	xtype := reflect.TypeOf(obj)
+	if xtype.Kind() != reflect.Ptr || xtype.Elem().Kind() != reflect.Struct {
+		return
+	}
+	known := knownFields(xtype.Elem())
+	b := base.objectBase()
+	b.unknown = nil
+	for key, value := range b.received {
+		if commonFields[key] || known[key] {
+			continue
+		}
+		if b.unknown == nil {
+			b.unknown = make(map[string]json.RawMessage)
+		}
+		b.unknown[key] = value
+	}
+}
+
+// addUnknown adds the unknown fields of obj that data, the encoding of obj
+// sent to the API server, doesn't hold.
+func addUnknown(obj IObject, data []byte) ([]byte, error) {
+	base, ok := obj.(baseObject)
+	if !ok {
+		return data, nil
+	}
+	unknown := base.objectBase().unknown
+	if len(unknown) == 0 {
+		return data, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	added := 0
+	for key, value := range unknown {
+		if _, exists := m[key]; exists {
+			continue
+		}
+		m[key] = value
+		added++
+	}
+	if added == 0 {
+		return data, nil
+	}
+	return json.Marshal(m)
+}
+
+// marshalObject encodes obj for a create request, with its unknown
+// fields.
+func marshalObject(obj IObject) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return addUnknown(obj, data)
+}
+
+// mergeUnknownFields restores fields that were stored before a partial
+// read of the object; fields received in the partial read take precedence.
+func (obj *ObjectBase) mergeUnknownFields(fields map[string]json.RawMessage) {
+	for key, value := range fields {
+		if _, exists := obj.unknown[key]; exists {
+			continue
+		}
+		if obj.unknown == nil {
+			obj.unknown = make(map[string]json.RawMessage)
+		}
+		obj.unknown[key] = value
+	}
+}
+
+// UnknownFields returns the fields received from the API server that the
+// object type doesn't define.
+func (obj *ObjectBase) UnknownFields() map[string]json.RawMessage {
+	result := make(map[string]json.RawMessage, len(obj.unknown))
+	for key, value := range obj.unknown {
+		result[key] = value
+	}
+	return result
+}
+
+// ClearUnknownFields discards the unknown fields; they are no longer sent
+// to the API server.
+func (obj *ObjectBase) ClearUnknownFields() {
+	obj.unknown = nil
+}
@@ -0,0 +1,194 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFields(t *testing.T) {
+	var updates []map[string]json.RawMessage
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if r.URL.Query().Get("fields") != "" {
+				fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "test_project_refs": [{"to": ["default-project"], "uuid": "p1"}, {"to": ["other"], "uuid": "p2"}]}}`)
+				return
+			}
+			fmt.Fprint(w, `{"test-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "href": "http://localhost:8082/test-network/net-uuid", "display_name": "net", "test_project_refs": [{"to": ["default-project"], "uuid": "p1"}], "mtu": 9000, "new_refs": [{"to": ["x"], "uuid": "x1"}]}}`)
+		case "PUT":
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			updates = append(updates, msg["test-network"])
+			fmt.Fprint(w, `{"test-network": {"uuid": "net-uuid"}}`)
+		}
+	})
+	defer server.Close()
+
+	obj, err := client.FindByUuid("test-network", "net-uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := obj.(*TestNetwork)
+	unknown := network.UnknownFields()
+	if len(unknown) != 2 || string(unknown["mtu"]) != "9000" {
+		t.Fatalf("unexpected unknown fields %v", unknown)
+	}
+
+	data, err := json.Marshal(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded map[string]json.RawMessage
+	json.Unmarshal(data, &encoded)
+	if string(encoded["mtu"]) != "9000" || encoded["new_refs"] == nil {
+		t.Errorf("unknown fields not encoded: %s", data)
+	}
+
+	network.href = server.URL + "/test-network/net-uuid"
+	if err := client.GetField(network, "test_project_refs"); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.test_project_refs) != 2 || len(network.UnknownFields()) != 2 {
+		t.Errorf("unknown fields lost by GetField: %v", network.UnknownFields())
+	}
+	// The unknown fields are unchanged: the update leaves them out.
+	network.display_name = "renamed"
+	if err := client.Update(network); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(updates))
+	}
+	if _, ok := updates[0]["mtu"]; ok ||
+		string(updates[0]["display_name"]) != `"renamed"` {
+		t.Errorf("unexpected update %v", updates[0])
+	}
+}
+
+func TestUnknownFieldsReuse(t *testing.T) {
+	network := new(TestNetwork)
+	if err := json.Unmarshal([]byte(`{"fq_name": ["p", "net-1"], "uuid": "net-1", "name": "net-1", "mtu": 9000}`), network); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.UnknownFields()) != 1 {
+		t.Fatalf("unexpected unknown fields %v", network.UnknownFields())
+	}
+	if err := json.Unmarshal([]byte(`{"fq_name": ["p", "net-2"], "uuid": "net-2", "name": "net-2", "vxlan_id": 5}`), network); err != nil {
+		t.Fatal(err)
+	}
+	unknown := network.UnknownFields()
+	if _, ok := unknown["mtu"]; ok || len(unknown) != 1 {
+		t.Errorf("stale unknown fields after decode: %v", unknown)
+	}
+	data, err := json.Marshal(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded map[string]json.RawMessage
+	json.Unmarshal(data, &encoded)
+	if _, ok := encoded["mtu"]; ok || string(encoded["vxlan_id"]) != "5" {
+		t.Errorf("unexpected encoding %s", data)
+	}
+}
+
+// legacyNetwork is a generated type that predates unknown field
+// preservation: it neither calls UnmarshalUnknown nor MarshalUnknown.
+type legacyNetwork struct {
+	ObjectBase
+	display_name string
+}
+
+func (*legacyNetwork) GetType() string                   { return "legacy-network" }
+func (*legacyNetwork) GetDefaultParent() []string        { return []string{"default-project"} }
+func (*legacyNetwork) GetDefaultParentType() string      { return "test-project" }
+func (obj *legacyNetwork) SetName(name string)           { obj.VSetName(obj, name) }
+func (obj *legacyNetwork) UpdateObject() ([]byte, error) { return obj.MarshalJSON() }
+func (*legacyNetwork) UpdateReferences() error           { return nil }
+func (*legacyNetwork) UpdateDone()                       {}
+
+func (obj *legacyNetwork) MarshalJSON() ([]byte, error) {
+	m := make(map[string]*json.RawMessage)
+	if err := obj.MarshalCommon(m); err != nil {
+		return nil, err
+	}
+	value, _ := json.Marshal(obj.display_name)
+	raw := json.RawMessage(value)
+	m["display_name"] = &raw
+	return json.Marshal(m)
+}
+
+func (obj *legacyNetwork) UnmarshalJSON(body []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalCommon(m); err != nil {
+		return err
+	}
+	if value, ok := m["display_name"]; ok {
+		return json.Unmarshal(value, &obj.display_name)
+	}
+	return nil
+}
+
+func TestUnknownFieldsLegacyType(t *testing.T) {
+	var requests []map[string]json.RawMessage
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"legacy-network": {"fq_name": ["default-project", "net"], "uuid": "net-uuid", "name": "net", "display_name": "net", "mtu": 9000}}`)
+		case "POST", "PUT":
+			var msg map[string]map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			requests = append(requests, msg["legacy-network"])
+			fmt.Fprint(w, `{"legacy-network": {"fq_name": ["default-project", "copy"], "uuid": "copy-uuid", "name": "copy"}}`)
+		}
+	})
+	defer server.Close()
+	RegisterTypeMap(TypeMap{
+		"test-network":   reflect.TypeOf(TestNetwork{}),
+		"legacy-network": reflect.TypeOf(legacyNetwork{}),
+	})
+
+	obj, err := client.FindByUuid("legacy-network", "net-uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := obj.(*legacyNetwork)
+	unknown := network.UnknownFields()
+	if len(unknown) != 1 || string(unknown["mtu"]) != "9000" {
+		t.Fatalf("unexpected unknown fields %v", unknown)
+	}
+
+	// The unchanged unknown fields are left out of updates.
+	network.href = server.URL + "/legacy-network/net-uuid"
+	network.display_name = "renamed"
+	if err := client.Update(network); err != nil {
+		t.Fatal(err)
+	}
+	// They are sent on create.
+	created, err := DecodeObject("legacy-network", []byte(`{"fq_name": ["default-project", "copy"], "uuid": "", "name": "copy", "mtu": 9000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Create(created); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if _, ok := requests[0]["mtu"]; ok ||
+		string(requests[0]["display_name"]) != `"renamed"` {
+		t.Errorf("unexpected update %v", requests[0])
+	}
+	if string(requests[1]["mtu"]) != "9000" {
+		t.Errorf("unknown fields not created: %v", requests[1])
+	}
+}
@@ -0,0 +1,242 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package vcr records the interactions of a client with the API server to
+// a fixture file and replays them in tests, which then run without a
+// Contrail cluster:
+//
+//	client := contrail.NewClient(host, port)
+//	if *record {
+//		recorder := vcr.NewRecorder()
+//		client.Use(recorder.Middleware())
+//		defer recorder.Save("testdata/network.json")
+//	} else {
+//		cassette, err := vcr.Load("testdata/network.json")
+//		...
+//		client.Use(cassette.Middleware())
+//	}
+//
+// Fixtures are sanitized: credentials are redacted (see
+// contrail.CaptureBodies) and the API server address is removed from the
+// URLs and bodies, so that a fixture replays regardless of the address the
+// client is configured with.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// hostPlaceholder replaces the API server address in fixtures.
+const hostPlaceholder = "contrail-api.invalid"
+
+// Headers kept in fixtures; the others (dates, server versions) vary from
+// one run to the next.
+var recordedHeaders = []string{
+	"Content-Type", "Etag", "Last-Modified", "Location",
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Method string `json:"method"`
+	// URL is the request path and query.
+	URL            string          `json:"url"`
+	RequestBody    json.RawMessage `json:"request_body,omitempty"`
+	Status         int             `json:"status"`
+	ResponseHeader http.Header     `json:"response_header,omitempty"`
+	ResponseBody   json.RawMessage `json:"response_body,omitempty"`
+}
+
+// rawBody encodes a body as JSON, or as a JSON string if it isn't JSON.
+func rawBody(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	if json.Valid(data) {
+		return json.RawMessage(data)
+	}
+	encoded, _ := json.Marshal(string(data))
+	return json.RawMessage(encoded)
+}
+
+// body decodes a body encoded by rawBody.
+func body(raw json.RawMessage) []byte {
+	var s string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return []byte(s)
+	}
+	return []byte(raw)
+}
+
+// Recorder is an ExchangeRecorder that builds a fixture from the
+// exchanges captured by contrail.CaptureBodies.
+type Recorder struct {
+	mutex        sync.Mutex
+	interactions []*Interaction
+	// Sanitize, when set, is applied to each interaction before it is
+	// recorded, e.g. to replace names specific to the test environment.
+	Sanitize func(*Interaction)
+}
+
+// NewRecorder allocates a Recorder.
+func NewRecorder() *Recorder {
+	return new(Recorder)
+}
+
+// Middleware returns the middleware that records the client requests.
+func (r *Recorder) Middleware() contrail.Middleware {
+	return contrail.CaptureBodies(r)
+}
+
+// Record implements contrail.ExchangeRecorder.
+func (r *Recorder) Record(exchange *contrail.Exchange) {
+	if exchange.Err != nil {
+		return
+	}
+	u, err := url.Parse(exchange.URL)
+	if err != nil {
+		return
+	}
+	host := []byte(u.Host)
+	placeholder := []byte(hostPlaceholder)
+	interaction := &Interaction{
+		Method: exchange.Method,
+		URL:    u.RequestURI(),
+		RequestBody: rawBody(bytes.Replace(exchange.RequestBody, host,
+			placeholder, -1)),
+		Status: exchange.Status,
+		ResponseBody: rawBody(bytes.Replace(exchange.ResponseBody, host,
+			placeholder, -1)),
+	}
+	for _, name := range recordedHeaders {
+		if value := exchange.ResponseHeader.Get(name); value != "" {
+			if interaction.ResponseHeader == nil {
+				interaction.ResponseHeader = make(http.Header)
+			}
+			interaction.ResponseHeader.Set(name, value)
+		}
+	}
+	if r.Sanitize != nil {
+		r.Sanitize(interaction)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.interactions = append(r.interactions, interaction)
+}
+
+// Cassette returns the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return &Cassette{
+		Interactions: append([]*Interaction(nil), r.interactions...),
+	}
+}
+
+// Save writes the interactions recorded so far to a fixture file.
+func (r *Recorder) Save(filename string) error {
+	return r.Cassette().Save(filename)
+}
+
+// Cassette is a sequence of interactions, replayed in order.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	mutex sync.Mutex
+	used  []bool
+}
+
+// Load reads a fixture file written by Save.
+func Load(filename string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cassette := new(Cassette)
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette to a fixture file.
+func (c *Cassette) Save(filename string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// next returns the first unused interaction for the request. Requests are
+// matched by method and URL only: request bodies may contain values that
+// change from one run to the next, such as uuids allocated by the client.
+func (c *Cassette) next(method, uri string) *Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.used == nil {
+		c.used = make([]bool, len(c.Interactions))
+	}
+	for i, interaction := range c.Interactions {
+		if !c.used[i] && interaction.Method == method && interaction.URL == uri {
+			c.used[i] = true
+			return interaction
+		}
+	}
+	return nil
+}
+
+// Unused returns the interactions that weren't replayed, which usually
+// means that the code under test no longer issues the requests recorded.
+func (c *Cassette) Unused() []*Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var unused []*Interaction
+	for i, interaction := range c.Interactions {
+		if c.used == nil || !c.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// Middleware returns a middleware that answers the client requests with
+// the recorded responses, without contacting the API server. A request
+// that wasn't recorded fails.
+func (c *Cassette) Middleware() contrail.Middleware {
+	return func(next contrail.Handler) contrail.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			interaction := c.next(req.Method, req.URL.RequestURI())
+			if interaction == nil {
+				return nil, fmt.Errorf("No recorded interaction for %s %s",
+					req.Method, req.URL.RequestURI())
+			}
+			data := bytes.Replace(body(interaction.ResponseBody),
+				[]byte(hostPlaceholder), []byte(req.URL.Host), -1)
+			header := make(http.Header)
+			for name, values := range interaction.ResponseHeader {
+				header[name] = append([]string(nil), values...)
+			}
+			return &http.Response{
+				Status: fmt.Sprintf("%d %s", interaction.Status,
+					http.StatusText(interaction.Status)),
+				StatusCode:    interaction.Status,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        header,
+				Body:          ioutil.NopCloser(bytes.NewReader(data)),
+				ContentLength: int64(len(data)),
+				Request:       req,
+			}, nil
+		}
+	}
+}
@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package vcr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type tokenAuthenticator string
+
+func (a tokenAuthenticator) AddAuthentication(req *http.Request) error {
+	req.Header.Set("X-Auth-Token", string(a))
+	return nil
+}
+
+func newClient(t *testing.T, address string) *contrail.Client {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	client := contrail.NewClient(host, portNum)
+	client.SetAuthenticator(tokenAuthenticator("s3cret-token"))
+	return client
+}
+
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/fqname-to-id":
+			fmt.Fprint(w, `{"uuid": "vn-uuid"}`)
+		case "/virtual-networks":
+			fmt.Fprintf(w, `{"virtual-networks": [{"fq_name": ["p", "vn"], "uuid": "vn-uuid", "href": "http://%s/virtual-network/vn-uuid"}]}`, r.Host)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	dir, err := ioutil.TempDir("", "vcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fixture.json")
+
+	client := newClient(t, server.Listener.Addr().String())
+	recorder := NewRecorder()
+	client.Use(recorder.Middleware())
+	if _, err := client.UuidByName("virtual-network", "p:vn"); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := client.List("virtual-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.FindByUuid("virtual-network", "missing"); err == nil {
+		t.Fatal("expected a not found error")
+	}
+	if err := recorder.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") ||
+		strings.Contains(string(data), server.Listener.Addr().String()) {
+		t.Errorf("fixture not sanitized:\n%s", data)
+	}
+
+	cassette, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = newClient(t, "192.0.2.1:8082")
+	client.Use(cassette.Middleware())
+	uuid, err := client.UuidByName("virtual-network", "p:vn")
+	if err != nil || uuid != "vn-uuid" {
+		t.Errorf("replayed uuid %q: %v", uuid, err)
+	}
+	if len(cassette.Unused()) != 2 {
+		t.Errorf("%d unused interactions", len(cassette.Unused()))
+	}
+	replayed, err := client.List("virtual-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed[0].Href != "http://192.0.2.1:8082/virtual-network/vn-uuid" {
+		t.Errorf("unexpected href %s (recorded %s)", replayed[0].Href, recorded[0].Href)
+	}
+	_, err = client.FindByUuid("virtual-network", "missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "404") {
+		t.Errorf("expected the recorded 404, got %v", err)
+	}
+	if _, err := client.List("virtual-network"); err == nil {
+		t.Error("expected an error for a request that wasn't recorded")
+	}
+}
@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// APIVersion is the release of an API server, e.g. "5.1.0" or "2011.L1"
+// (releases are numbered by year and month since 2008).
+type APIVersion struct {
+	Major int
+	Minor int
+	Patch int
+	Raw   string
+}
+
+// ParseAPIVersion parses a build version. Non numeric suffixes (e.g.
+// "L1", "-rc2") are ignored.
+func ParseAPIVersion(version string) (APIVersion, error) {
+	result := APIVersion{Raw: version}
+	parts := strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '-'
+	})
+	var numbers []int
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimPrefix(part, "R"))
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) == 0 {
+		return result, fmt.Errorf("Invalid version %q", version)
+	}
+	numbers = append(numbers, 0, 0)
+	result.Major, result.Minor, result.Patch = numbers[0], numbers[1], numbers[2]
+	return result, nil
+}
+
+// AtLeast returns true if the version is the same or more recent than
+// major.minor.
+func (v APIVersion) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+func (v APIVersion) String() string {
+	return v.Raw
+}
+
+// Feature is an optional API server capability.
+type Feature string
+
+const (
+	// FeatureTags is the tag resource and the set-tag endpoint.
+	FeatureTags Feature = "tags"
+	// FeatureSecurityDraft is the security policy draft mode (commit and
+	// discard of pending firewall policy changes).
+	FeatureSecurityDraft Feature = "security-draft"
+	// FeaturePropCollectionUpdate is the partial update of list and map
+	// properties.
+	FeaturePropCollectionUpdate Feature = "prop-collection-update"
+	// FeatureRefRelax allows references to be ignored when deleting the
+	// referred object.
+	FeatureRefRelax Feature = "ref-relax-for-delete"
+	// FeatureJobs is the job manager (execute-job, abort-job).
+	FeatureJobs Feature = "jobs"
+	// FeatureFirewall is the firewall policy model.
+	FeatureFirewall Feature = "firewall"
+)
+
+// featureRequirements lists the collections and actions the root document
+// advertises for each feature.
+var featureRequirements = map[Feature]struct {
+	collections []string
+	actions     []string
+}{
+	FeatureTags:                 {[]string{"tag"}, []string{"set-tag"}},
+	FeatureSecurityDraft:        {nil, []string{"security-policy-draft"}},
+	FeaturePropCollectionUpdate: {nil, []string{"prop-collection-update"}},
+	FeatureRefRelax:             {nil, []string{"ref-relax-for-delete"}},
+	FeatureJobs:                 {nil, []string{"execute-job"}},
+	FeatureFirewall:             {[]string{"firewall-policy", "firewall-rule"}, nil},
+}
+
+// Supports returns true if the server advertises the feature.
+func (info *ServerInfo) Supports(feature Feature) bool {
+	requirements, ok := featureRequirements[feature]
+	if !ok {
+		return false
+	}
+	for _, name := range requirements.collections {
+		if !info.HasCollection(name) {
+			return false
+		}
+	}
+	for _, name := range requirements.actions {
+		if !info.HasAction(name) {
+			return false
+		}
+	}
+	return true
+}
+
+type serverInfoCache struct {
+	mutex sync.Mutex
+	info  *ServerInfo
+}
+
+// cachedServerInfo retrieves the root document once.
+func (c *Client) cachedServerInfo() (*ServerInfo, error) {
+	if c.serverInfo == nil {
+		return c.ServerInfo(context.Background())
+	}
+	c.serverInfo.mutex.Lock()
+	defer c.serverInfo.mutex.Unlock()
+	if c.serverInfo.info != nil {
+		return c.serverInfo.info, nil
+	}
+	info, err := c.ServerInfo(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	c.serverInfo.info = info
+	return info, nil
+}
+
+// ResetServerInfo discards the server information cached by Supports and
+// ServerVersion, e.g. after the API server is upgraded.
+func (c *Client) ResetServerInfo() {
+	if c.serverInfo == nil {
+		return
+	}
+	c.serverInfo.mutex.Lock()
+	defer c.serverInfo.mutex.Unlock()
+	c.serverInfo.info = nil
+}
+
+// Supports returns true if the API server supports the feature. The root
+// document is retrieved on first use and cached; when it can't be
+// retrieved, the error is returned and the next call tries again.
+func (c *Client) Supports(feature Feature) (bool, error) {
+	info, err := c.cachedServerInfo()
+	if err != nil {
+		return false, err
+	}
+	return info.Supports(feature), nil
+}
+
+// ServerVersion returns the release of the API server.
+func (c *Client) ServerVersion() (APIVersion, error) {
+	info, err := c.cachedServerInfo()
+	if err != nil {
+		return APIVersion{}, err
+	}
+	if info.Version == "" {
+		return APIVersion{}, fmt.Errorf("API server doesn't advertise its version")
+	}
+	return ParseAPIVersion(info.Version)
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package contrail
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+		minor   int
+		patch   int
+	}{
+		{"5.1.0", 5, 1, 0},
+		{"2011.L1", 2011, 0, 0},
+		{"21.4.L3", 21, 4, 0},
+		{"R1912", 1912, 0, 0},
+		{"3.2.3.0-rc2", 3, 2, 3},
+	}
+	for _, test := range tests {
+		v, err := ParseAPIVersion(test.version)
+		if err != nil {
+			t.Errorf("%s: %v", test.version, err)
+			continue
+		}
+		if v.Major != test.major || v.Minor != test.minor || v.Patch != test.patch {
+			t.Errorf("%s: unexpected %+v", test.version, v)
+		}
+	}
+	if _, err := ParseAPIVersion("master"); err == nil {
+		t.Error("expected error")
+	}
+	v, _ := ParseAPIVersion("5.1.0")
+	if !v.AtLeast(5, 0) || !v.AtLeast(5, 1) || v.AtLeast(5, 2) || v.AtLeast(2011, 0) {
+		t.Errorf("AtLeast %v", v)
+	}
+}
+
+const taggedRootDocument = `{
+	"href": "http://localhost:8082",
+	"links": [
+		{"link": {"href": "http://localhost:8082/tags", "name": "tag", "rel": "collection"}},
+		{"link": {"href": "http://localhost:8082/set-tag", "name": "set-tag", "rel": "action"}},
+		{"link": {"href": "http://localhost:8082/firewall-policys", "name": "firewall-policy", "rel": "collection"}}
+	],
+	"build_info": "{\"build-info\": [{\"build-version\": \"5.1.0\"}]}"
+}`
+
+func TestSupports(t *testing.T) {
+	requests := 0
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, taggedRootDocument)
+	})
+	defer server.Close()
+
+	if supported, err := client.Supports(FeatureTags); err != nil || !supported {
+		t.Errorf("expected tag support, got %v", err)
+	}
+	// firewall-rule is not advertised.
+	for _, feature := range []Feature{FeatureFirewall, FeatureSecurityDraft} {
+		if supported, err := client.Supports(feature); err != nil || supported {
+			t.Errorf("unexpected support of %v: %v", feature, err)
+		}
+	}
+	version, err := client.ServerVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !version.AtLeast(5, 1) {
+		t.Errorf("unexpected version %v", version)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+	client.ResetServerInfo()
+	client.Supports(FeatureTags)
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestSupportsUnreachable(t *testing.T) {
+	available := false
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, taggedRootDocument)
+	})
+	defer server.Close()
+	if _, err := client.Supports(FeatureTags); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := client.ServerVersion(); err == nil {
+		t.Error("expected error")
+	}
+
+	// The failure is not cached.
+	available = true
+	if supported, err := client.Supports(FeatureTags); err != nil || !supported {
+		t.Errorf("expected tag support, got %v", err)
+	}
+}
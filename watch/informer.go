@@ -0,0 +1,474 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// ResourceEventHandler receives the notifications of an informer.
+// Handlers are invoked sequentially and must not block.
+type ResourceEventHandler interface {
+	OnAdd(obj contrail.IObject)
+	OnUpdate(oldObj, newObj contrail.IObject)
+	OnDelete(obj contrail.IObject)
+}
+
+// ResourceEventHandlerFuncs adapts functions to the ResourceEventHandler
+// interface. Nil functions are ignored.
+type ResourceEventHandlerFuncs struct {
+	AddFunc    func(obj contrail.IObject)
+	UpdateFunc func(oldObj, newObj contrail.IObject)
+	DeleteFunc func(obj contrail.IObject)
+}
+
+// OnAdd implements ResourceEventHandler.
+func (f ResourceEventHandlerFuncs) OnAdd(obj contrail.IObject) {
+	if f.AddFunc != nil {
+		f.AddFunc(obj)
+	}
+}
+
+// OnUpdate implements ResourceEventHandler.
+func (f ResourceEventHandlerFuncs) OnUpdate(oldObj, newObj contrail.IObject) {
+	if f.UpdateFunc != nil {
+		f.UpdateFunc(oldObj, newObj)
+	}
+}
+
+// OnDelete implements ResourceEventHandler.
+func (f ResourceEventHandlerFuncs) OnDelete(obj contrail.IObject) {
+	if f.DeleteFunc != nil {
+		f.DeleteFunc(obj)
+	}
+}
+
+// Informer maintains a cache of the objects of a given type and notifies
+// handlers of changes. The cache is populated by a full list and kept up
+// to date by a watch. When no watch source is available, or when a watch
+// terminates, the informer lists the objects again and computes the
+// differences.
+//
+// Every resync period the handlers receive an update notification for
+// each cached object, so that controllers can periodically reconcile.
+type Informer struct {
+	client   contrail.ApiClient
+	source   Source
+	typename string
+	resync   time.Duration
+
+	mutex    sync.RWMutex
+	objects  map[string]contrail.IObject
+	byName   map[string]string
+	handlers []ResourceEventHandler
+	synced   bool
+}
+
+// NewInformer allocates an informer for objects of typename. source may
+// be nil, in which case changes are detected by listing the objects every
+// resync period.
+func NewInformer(client contrail.ApiClient, source Source, typename string,
+	resync time.Duration) *Informer {
+	return &Informer{
+		client:   client,
+		source:   source,
+		typename: typename,
+		resync:   resync,
+		objects:  make(map[string]contrail.IObject),
+		byName:   make(map[string]string),
+	}
+}
+
+// AddEventHandler registers a handler. Handlers added after the cache has
+// synced receive an add notification for each cached object.
+func (i *Informer) AddEventHandler(handler ResourceEventHandler) {
+	i.mutex.Lock()
+	i.handlers = append(i.handlers, handler)
+	var objects []contrail.IObject
+	if i.synced {
+		objects = i.sortedObjects()
+	}
+	i.mutex.Unlock()
+	for _, obj := range objects {
+		handler.OnAdd(obj)
+	}
+}
+
+// notification is a change to be delivered to the handlers. Handlers are
+// invoked without holding the mutex so that they can use the Lister.
+type notification struct {
+	oldObj contrail.IObject
+	newObj contrail.IObject
+}
+
+func (i *Informer) dispatch(notifications []notification) {
+	i.mutex.RLock()
+	handlers := i.handlers
+	i.mutex.RUnlock()
+	for _, n := range notifications {
+		for _, handler := range handlers {
+			switch {
+			case n.oldObj == nil:
+				handler.OnAdd(n.newObj)
+			case n.newObj == nil:
+				handler.OnDelete(n.oldObj)
+			default:
+				handler.OnUpdate(n.oldObj, n.newObj)
+			}
+		}
+	}
+}
+
+// HasSynced returns true once the initial list has been loaded.
+func (i *Informer) HasSynced() bool {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.synced
+}
+
+// Lister returns a read-only view of the cache.
+func (i *Informer) Lister() *Lister {
+	return &Lister{i}
+}
+
+// Run populates the cache and processes changes until ctx is done or the
+// client is closed. The watch is started before the objects are listed,
+// so that no change made in between is missed; the events that predate
+// the list are recognized by their id_perms.last_modified and dropped.
+func (i *Informer) Run(ctx context.Context) error {
+	closed := clientDone(i.client)
+	retry := time.Second
+	for {
+		w := i.startWatch()
+		if err := i.relist(); err != nil {
+			if w != nil {
+				w.Stop()
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-closed:
+				return contrail.ErrClientClosed
+			case <-time.After(retry):
+			}
+			if retry < time.Minute {
+				retry *= 2
+			}
+			continue
+		}
+		retry = time.Second
+		if err := i.watch(ctx, w); err != nil {
+			return err
+		}
+		select {
+		case <-closed:
+			return contrail.ErrClientClosed
+		default:
+		}
+	}
+}
+
+// startWatch starts a watch, or returns nil if there is no watch source or
+// the watch can't be started.
+func (i *Informer) startWatch() Interface {
+	if i.source == nil {
+		return nil
+	}
+	w, err := i.source.Watch(i.typename)
+	if err != nil {
+		return nil
+	}
+	return w
+}
+
+// watch processes the events of w until it terminates or the client is
+// closed (returns nil), or ctx is done. Without a watch it returns at the
+// next resync.
+func (i *Informer) watch(ctx context.Context, w Interface) error {
+	closed := clientDone(i.client)
+	var tick <-chan time.Time
+	if i.resync > 0 {
+		ticker := time.NewTicker(i.resync)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	if w == nil {
+		// Poll: the caller lists the objects again.
+		period := i.resync
+		if period <= 0 {
+			period = defaultRelistPeriod
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-closed:
+			return nil
+		case <-time.After(period):
+			return nil
+		}
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-closed:
+			return nil
+		case <-tick:
+			i.resyncAll()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			i.handleEvent(event)
+		}
+	}
+}
+
+// defaultRelistPeriod is the polling period of informers that have neither
+// a watch source nor a resync period.
+const defaultRelistPeriod = 30 * time.Second
+
+// objectKey is the key of an object in the informer cache.
+func objectKey(obj contrail.IObject) string {
+	return obj.GetUuid()
+}
+
+func (i *Informer) store(obj contrail.IObject) contrail.IObject {
+	key := objectKey(obj)
+	old := i.objects[key]
+	if old != nil {
+		delete(i.byName, strings.Join(old.GetFQName(), ":"))
+	}
+	i.objects[key] = obj
+	i.byName[strings.Join(obj.GetFQName(), ":")] = key
+	return old
+}
+
+func (i *Informer) remove(key string) contrail.IObject {
+	old := i.objects[key]
+	if old != nil {
+		delete(i.objects, key)
+		delete(i.byName, strings.Join(old.GetFQName(), ":"))
+	}
+	return old
+}
+
+// handleEvent applies an event to the cache. Events that predate the
+// cached state are dropped: modifications of an object with an older or
+// equal last_modified, and deletions of objects that are not cached.
+func (i *Informer) handleEvent(event Event) {
+	var n notification
+	i.mutex.Lock()
+	switch event.Type {
+	case Added, Modified:
+		if cached, ok := i.objects[objectKey(event.Object)]; ok {
+			modified, previous := lastModified(event.Object), lastModified(cached)
+			if modified != "" && previous != "" && modified <= previous {
+				break
+			}
+		}
+		n = notification{i.store(event.Object), event.Object}
+	case Deleted:
+		n.oldObj = i.remove(objectKey(event.Object))
+	}
+	i.mutex.Unlock()
+	if n.oldObj != nil || n.newObj != nil {
+		i.dispatch([]notification{n})
+	}
+}
+
+// objectEqual compares the encoded state of two objects.
+func objectEqual(a, b contrail.IObject) bool {
+	data1, err1 := json.Marshal(a)
+	data2, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(data1, data2)
+}
+
+// relist reads all objects and notifies the differences with the cache.
+// Objects that didn't change are not notified.
+func (i *Informer) relist() error {
+	objects, err := i.client.ListDetail(i.typename, nil)
+	if err != nil {
+		return err
+	}
+	var notifications []notification
+	i.mutex.Lock()
+	current := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		key := objectKey(obj)
+		current[key] = true
+		if old, exists := i.objects[key]; exists && objectEqual(old, obj) {
+			continue
+		}
+		notifications = append(notifications, notification{i.store(obj), obj})
+	}
+	for key := range i.objects {
+		if !current[key] {
+			notifications = append(notifications, notification{i.remove(key), nil})
+		}
+	}
+	i.synced = true
+	i.mutex.Unlock()
+
+	i.dispatch(notifications)
+	return nil
+}
+
+// resyncAll delivers an update notification for each cached object.
+func (i *Informer) resyncAll() {
+	i.mutex.RLock()
+	objects := i.sortedObjects()
+	i.mutex.RUnlock()
+	notifications := make([]notification, len(objects))
+	for n, obj := range objects {
+		notifications[n] = notification{obj, obj}
+	}
+	i.dispatch(notifications)
+}
+
+// sortedObjects returns the cached objects sorted by name. The caller
+// must hold the mutex.
+func (i *Informer) sortedObjects() []contrail.IObject {
+	names := make([]string, 0, len(i.byName))
+	for name := range i.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	objects := make([]contrail.IObject, 0, len(names))
+	for _, name := range names {
+		objects = append(objects, i.objects[i.byName[name]])
+	}
+	return objects
+}
+
+// Lister provides read access to an informer cache. The objects returned
+// are shared and must not be modified.
+type Lister struct {
+	informer *Informer
+}
+
+// Get retrieves an object by uuid.
+func (l *Lister) Get(uuid string) (contrail.IObject, bool) {
+	l.informer.mutex.RLock()
+	defer l.informer.mutex.RUnlock()
+	obj, ok := l.informer.objects[uuid]
+	return obj, ok
+}
+
+// GetByKey retrieves an object given its Key.
+func (l *Lister) GetByKey(key string) (contrail.IObject, bool) {
+	_, uuid := SplitKey(key)
+	return l.Get(uuid)
+}
+
+// GetByName retrieves an object by colon separated fully qualified name.
+func (l *Lister) GetByName(fqn string) (contrail.IObject, bool) {
+	l.informer.mutex.RLock()
+	defer l.informer.mutex.RUnlock()
+	key, ok := l.informer.byName[fqn]
+	if !ok {
+		return nil, false
+	}
+	return l.informer.objects[key], true
+}
+
+// List returns the cached objects, sorted by name, for which selector
+// returns true. A nil selector selects all objects.
+func (l *Lister) List(selector func(contrail.IObject) bool) []contrail.IObject {
+	l.informer.mutex.RLock()
+	defer l.informer.mutex.RUnlock()
+	var result []contrail.IObject
+	for _, obj := range l.informer.sortedObjects() {
+		if selector == nil || selector(obj) {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
+// InformerFactory shares informers, and therefore caches and watches,
+// between the components of an application.
+type InformerFactory struct {
+	client contrail.ApiClient
+	source Source
+	resync time.Duration
+
+	mutex     sync.Mutex
+	informers map[string]*Informer
+	started   map[string]bool
+}
+
+// NewInformerFactory allocates an InformerFactory.
+func NewInformerFactory(client contrail.ApiClient, source Source,
+	resync time.Duration) *InformerFactory {
+	return &InformerFactory{
+		client:    client,
+		source:    source,
+		resync:    resync,
+		informers: make(map[string]*Informer),
+		started:   make(map[string]bool),
+	}
+}
+
+// Informer returns the shared informer for typename.
+func (f *InformerFactory) Informer(typename string) *Informer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	informer, ok := f.informers[typename]
+	if !ok {
+		informer = NewInformer(f.client, f.source, typename, f.resync)
+		f.informers[typename] = informer
+	}
+	return informer
+}
+
+// Start runs the informers that are not running yet. They stop when ctx
+// is done.
+func (f *InformerFactory) Start(ctx context.Context) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for typename, informer := range f.informers {
+		if f.started[typename] {
+			continue
+		}
+		f.started[typename] = true
+		go informer.Run(ctx)
+	}
+}
+
+// WaitForCacheSync waits until all the informers have synced. It returns
+// false if ctx is done first.
+func (f *InformerFactory) WaitForCacheSync(ctx context.Context) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		synced := true
+		f.mutex.Lock()
+		for _, informer := range f.informers {
+			if !informer.HasSynced() {
+				synced = false
+				break
+			}
+		}
+		f.mutex.Unlock()
+		if synced {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,261 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type testObject struct {
+	contrail.ObjectBase
+	Value string `json:"value"`
+}
+
+func (*testObject) GetType() string               { return "test-network" }
+func (*testObject) SetName(string)                {}
+func (*testObject) GetDefaultParent() []string    { return nil }
+func (*testObject) GetDefaultParentType() string  { return "" }
+func (*testObject) UpdateObject() ([]byte, error) { return nil, nil }
+func (*testObject) UpdateReferences() error       { return nil }
+func (*testObject) UpdateDone()                   {}
+
+func newTestObject(name, value string) *testObject {
+	obj := &testObject{Value: value}
+	obj.SetFQName("", []string{"default-project", name})
+	obj.SetUuid(name + "-uuid")
+	return obj
+}
+
+// listClient serves ListDetail from a mutable list of objects.
+type listClient struct {
+	contrail.ApiClient
+	mutex   sync.Mutex
+	objects []contrail.IObject
+}
+
+func (c *listClient) ListDetail(typename string, fields []string) ([]contrail.IObject, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]contrail.IObject(nil), c.objects...), nil
+}
+
+func (c *listClient) set(objects ...contrail.IObject) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.objects = objects
+}
+
+type chanWatch chan Event
+
+func (w chanWatch) ResultChan() <-chan Event { return w }
+func (w chanWatch) Stop()                    {}
+
+type chanSource struct {
+	watch chanWatch
+}
+
+func (s *chanSource) Watch(typename string) (Interface, error) {
+	return s.watch, nil
+}
+
+// recorder collects notifications as strings.
+type recorder struct {
+	events chan string
+}
+
+func newRecorder() *recorder {
+	return &recorder{events: make(chan string, 100)}
+}
+
+func (r *recorder) OnAdd(obj contrail.IObject) {
+	r.events <- "add " + obj.GetName()
+}
+func (r *recorder) OnUpdate(oldObj, newObj contrail.IObject) {
+	r.events <- fmt.Sprintf("update %s %s", newObj.GetName(), newObj.(*testObject).Value)
+}
+func (r *recorder) OnDelete(obj contrail.IObject) {
+	r.events <- "delete " + obj.GetName()
+}
+
+func (r *recorder) expect(t *testing.T, expected ...string) {
+	for _, event := range expected {
+		select {
+		case actual := <-r.events:
+			if actual != event {
+				t.Errorf("expected %q, got %q", event, actual)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for %q", event)
+		}
+	}
+}
+
+func TestInformerWatch(t *testing.T) {
+	client := &listClient{}
+	client.set(newTestObject("a", "1"), newTestObject("b", "1"))
+	source := &chanSource{make(chanWatch)}
+
+	factory := NewInformerFactory(client, source, 0)
+	informer := factory.Informer("test-network")
+	if factory.Informer("test-network") != informer {
+		t.Fatal("informer not shared")
+	}
+	events := newRecorder()
+	informer.AddEventHandler(events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx)
+	if !factory.WaitForCacheSync(ctx) {
+		t.Fatal("cache not synced")
+	}
+	events.expect(t, "add a", "add b")
+
+	source.watch <- Event{Type: Modified, Object: newTestObject("a", "2")}
+	source.watch <- Event{Type: Deleted, Object: newTestObject("b", "")}
+	source.watch <- Event{Type: Added, Object: newTestObject("c", "1")}
+	events.expect(t, "update a 2", "delete b", "add c")
+
+	lister := informer.Lister()
+	if obj, ok := lister.GetByName("default-project:a"); !ok || obj.(*testObject).Value != "2" {
+		t.Errorf("unexpected object %v", obj)
+	}
+	if _, ok := lister.GetByKey("test-network/b-uuid"); ok {
+		t.Error("deleted object still cached")
+	}
+	if objects := lister.List(nil); len(objects) != 2 {
+		t.Errorf("unexpected objects %v", objects)
+	}
+
+	late := newRecorder()
+	informer.AddEventHandler(late)
+	late.expect(t, "add a", "add c")
+}
+
+func TestInformerPoll(t *testing.T) {
+	client := &listClient{}
+	client.set(newTestObject("a", "1"), newTestObject("b", "1"))
+	informer := NewInformer(client, nil, "test-network", 10*time.Millisecond)
+	events := newRecorder()
+	informer.AddEventHandler(events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go informer.Run(ctx)
+	events.expect(t, "add a", "add b")
+
+	client.set(newTestObject("a", "1"), newTestObject("c", "1"))
+	events.expect(t, "add c", "delete b")
+}
+
+func TestSplitKey(t *testing.T) {
+	typename, uuid := SplitKey(Key(newTestObject("a", "")))
+	if typename != "test-network" || uuid != "a-uuid" {
+		t.Errorf("unexpected key %s %s", typename, uuid)
+	}
+}
+
+// closingClient reports its closing, like contrail.Client.
+type closingClient struct {
+	listClient
+	done chan struct{}
+}
+
+func (c *closingClient) Done() <-chan struct{} {
+	return c.done
+}
+
+func TestInformerClientClosed(t *testing.T) {
+	client := &closingClient{done: make(chan struct{})}
+	client.set(newTestObject("a", "1"))
+	informer := NewInformer(client, &chanSource{make(chanWatch)}, "test-network", 0)
+	result := make(chan error)
+	go func() {
+		result <- informer.Run(context.Background())
+	}()
+	close(client.done)
+	select {
+	case err := <-result:
+		if err != contrail.ErrClientClosed {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("informer still running")
+	}
+}
+
+// orderedSource records whether a watch was started.
+type orderedSource struct {
+	chanSource
+	mutex   sync.Mutex
+	started bool
+}
+
+func (s *orderedSource) Watch(typename string) (Interface, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.started = true
+	return s.watch, nil
+}
+
+// orderedClient fails the lists made before a watch is started.
+type orderedClient struct {
+	listClient
+	source *orderedSource
+}
+
+func (c *orderedClient) ListDetail(typename string, fields []string) ([]contrail.IObject, error) {
+	c.source.mutex.Lock()
+	defer c.source.mutex.Unlock()
+	if !c.source.started {
+		return nil, fmt.Errorf("listed before the watch was started")
+	}
+	return c.listClient.ListDetail(typename, fields)
+}
+
+func TestInformerWatchBeforeList(t *testing.T) {
+	source := &orderedSource{chanSource: chanSource{make(chanWatch, 10)}}
+	client := &orderedClient{source: source}
+	client.set(newPolledObject("a", "2"))
+	// Changes made before the list are received by the watch.
+	source.watch <- Event{Type: Added, Object: newPolledObject("a", "1")}
+	source.watch <- Event{Type: Modified, Object: newPolledObject("a", "2")}
+	source.watch <- Event{Type: Deleted, Object: newPolledObject("c", "1")}
+	source.watch <- Event{Type: Modified, Object: newPolledObject("a", "3")}
+
+	events := make(chan string, 10)
+	informer := NewInformer(client, source, "test-network", 0)
+	informer.AddEventHandler(ResourceEventHandlerFuncs{
+		AddFunc: func(obj contrail.IObject) {
+			events <- "add " + obj.GetName() + " " + lastModified(obj)
+		},
+		UpdateFunc: func(oldObj, newObj contrail.IObject) {
+			events <- "update " + newObj.GetName() + " " + lastModified(newObj)
+		},
+		DeleteFunc: func(obj contrail.IObject) {
+			events <- "delete " + obj.GetName()
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go informer.Run(ctx)
+
+	for _, expected := range []string{"add a 2", "update a 3"} {
+		select {
+		case actual := <-events:
+			if actual != expected {
+				t.Errorf("expected %q, got %q", expected, actual)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for %q", expected)
+		}
+	}
+}
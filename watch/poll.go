@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// Default polling intervals of a Poller.
+const (
+	DefaultMinPollInterval = time.Second
+	DefaultMaxPollInterval = time.Minute
+)
+
+// Poller is a Source that detects changes by listing objects. It is meant
+// for clients that can't reach the message bus.
+//
+// Each poll lists only the id_perms of the objects and compares their
+// last_modified timestamp with the previous poll; objects that changed are
+// then read individually. The polling interval starts at the minimum and
+// doubles after each poll that finds no change, up to the maximum.
+type Poller struct {
+	client      contrail.ApiClient
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
+// NewPoller allocates a Poller with the default intervals.
+func NewPoller(client contrail.ApiClient) *Poller {
+	return &Poller{
+		client:      client,
+		minInterval: DefaultMinPollInterval,
+		maxInterval: DefaultMaxPollInterval,
+	}
+}
+
+// SetInterval sets the minimum and maximum polling intervals.
+func (p *Poller) SetInterval(min, max time.Duration) {
+	if max < min {
+		max = min
+	}
+	p.minInterval = min
+	p.maxInterval = max
+}
+
+// Watch implements Source. The objects are listed once before it returns;
+// changes made after that are reported.
+func (p *Poller) Watch(typename string) (Interface, error) {
+	objects, err := p.client.ListDetail(typename, []string{"id_perms"})
+	if err != nil {
+		return nil, err
+	}
+	w := &pollWatch{
+		poller:   p,
+		typename: typename,
+		objects:  make(map[string]contrail.IObject, len(objects)),
+		modified: make(map[string]string, len(objects)),
+		result:   make(chan Event),
+		done:     make(chan struct{}),
+	}
+	for _, obj := range objects {
+		w.objects[obj.GetUuid()] = obj
+		w.modified[obj.GetUuid()] = lastModified(obj)
+	}
+	go w.run()
+	return w, nil
+}
+
+type pollWatch struct {
+	poller   *Poller
+	typename string
+	// Last known state and id_perms.last_modified, by uuid.
+	objects  map[string]contrail.IObject
+	modified map[string]string
+	result   chan Event
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (w *pollWatch) ResultChan() <-chan Event {
+	return w.result
+}
+
+func (w *pollWatch) Stop() {
+	w.stopOnce.Do(func() { close(w.done) })
+}
+
+func (w *pollWatch) run() {
+	defer close(w.result)
+	closed := clientDone(w.poller.client)
+	interval := w.poller.minInterval
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-closed:
+			return
+		case <-time.After(interval):
+		}
+		events, err := w.poll()
+		if err != nil {
+			// The consumer is expected to relist and watch again.
+			return
+		}
+		if len(events) == 0 {
+			interval *= 2
+			if interval > w.poller.maxInterval {
+				interval = w.poller.maxInterval
+			}
+			continue
+		}
+		interval = w.poller.minInterval
+		for _, event := range events {
+			select {
+			case <-w.done:
+				return
+			case <-closed:
+				return
+			case w.result <- event:
+			}
+		}
+	}
+}
+
+// poll lists the objects and returns the changes since the previous poll.
+func (w *pollWatch) poll() ([]Event, error) {
+	client := w.poller.client
+	objects, err := client.ListDetail(w.typename, []string{"id_perms"})
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	current := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		uuid := obj.GetUuid()
+		current[uuid] = true
+		timestamp := lastModified(obj)
+		previous, exists := w.modified[uuid]
+		if exists && timestamp == previous {
+			continue
+		}
+		full, err := client.FindByUuid(w.typename, uuid)
+		if err != nil {
+			// Deleted since the list; reported by the next poll.
+			continue
+		}
+		eventType := Modified
+		if !exists {
+			eventType = Added
+		}
+		w.objects[uuid] = full
+		w.modified[uuid] = timestamp
+		events = append(events, Event{Type: eventType, Object: full})
+	}
+	for uuid, obj := range w.objects {
+		if !current[uuid] {
+			delete(w.objects, uuid)
+			delete(w.modified, uuid)
+			events = append(events, Event{Type: Deleted, Object: obj})
+		}
+	}
+	return events, nil
+}
+
+// lastModified returns id_perms.last_modified. The generated types are not
+// known to this package; the value is retrieved through the GetIdPerms
+// accessor.
+func lastModified(obj contrail.IObject) string {
+	method := reflect.ValueOf(obj).MethodByName("GetIdPerms")
+	if !method.IsValid() || method.Type().NumIn() != 0 ||
+		method.Type().NumOut() != 1 {
+		return ""
+	}
+	value := method.Call(nil)[0]
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+	field := value.FieldByName("LastModified")
+	if field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
+// Fallback returns a Source that watches through primary and, when primary
+// fails to start a watch, through secondary. A typical configuration uses
+// a Poller as secondary.
+func Fallback(primary, secondary Source) Source {
+	return &fallbackSource{primary, secondary}
+}
+
+type fallbackSource struct {
+	primary   Source
+	secondary Source
+}
+
+func (s *fallbackSource) Watch(typename string) (Interface, error) {
+	w, err := s.primary.Watch(typename)
+	if err == nil {
+		return w, nil
+	}
+	return s.secondary.Watch(typename)
+}
@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+type testIdPerms struct {
+	LastModified string
+}
+
+type polledObject struct {
+	testObject
+	modified string
+}
+
+func (obj *polledObject) GetIdPerms() testIdPerms {
+	return testIdPerms{obj.modified}
+}
+
+func newPolledObject(name, modified string) *polledObject {
+	obj := &polledObject{modified: modified}
+	obj.Value = modified
+	obj.SetFQName("", []string{"default-project", name})
+	obj.SetUuid(name + "-uuid")
+	return obj
+}
+
+// pollClient counts the individual reads.
+type pollClient struct {
+	listClient
+	reads int
+}
+
+func (c *pollClient) FindByUuid(typename, uuid string) (contrail.IObject, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reads++
+	for _, obj := range c.objects {
+		if obj.GetUuid() == uuid {
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("404 Not Found")
+}
+
+func expectEvent(t *testing.T, w Interface, eventType EventType, name string) {
+	select {
+	case event := <-w.ResultChan():
+		if event.Type != eventType || event.Object.GetName() != name {
+			t.Errorf("expected %s %s, got %s %s", eventType, name,
+				event.Type, event.Object.GetName())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for %s %s", eventType, name)
+	}
+}
+
+func TestPoller(t *testing.T) {
+	client := &pollClient{}
+	client.set(newPolledObject("a", "t1"), newPolledObject("b", "t1"))
+	poller := NewPoller(client)
+	poller.SetInterval(5*time.Millisecond, 20*time.Millisecond)
+	w, err := poller.Watch("test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	client.set(newPolledObject("a", "t2"), newPolledObject("c", "t1"))
+	events := map[string]EventType{}
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-w.ResultChan():
+			events[event.Object.GetName()] = event.Type
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	expected := map[string]EventType{"a": Modified, "b": Deleted, "c": Added}
+	for name, eventType := range expected {
+		if events[name] != eventType {
+			t.Errorf("%s: expected %s, got %s", name, eventType, events[name])
+		}
+	}
+	client.mutex.Lock()
+	if client.reads != 2 {
+		t.Errorf("expected 2 reads, got %d", client.reads)
+	}
+	client.mutex.Unlock()
+
+	// Unchanged objects are not read again.
+	time.Sleep(50 * time.Millisecond)
+	client.set(newPolledObject("a", "t2"), newPolledObject("c", "t3"))
+	expectEvent(t, w, Modified, "c")
+	client.mutex.Lock()
+	if client.reads != 3 {
+		t.Errorf("expected 3 reads, got %d", client.reads)
+	}
+	client.mutex.Unlock()
+}
+
+type failingSource struct{}
+
+func (failingSource) Watch(typename string) (Interface, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestFallback(t *testing.T) {
+	client := &pollClient{}
+	poller := NewPoller(client)
+	poller.SetInterval(5*time.Millisecond, 5*time.Millisecond)
+	w, err := Fallback(failingSource{}, poller).Watch("test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	client.set(newPolledObject("a", "t1"))
+	expectEvent(t, w, Added, "a")
+}
@@ -0,0 +1,230 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultReplaySize is the number of events buffered by a Replay.
+const DefaultReplaySize = 1024
+
+// ErrSeqUnavailable is returned by Replay.WatchFrom when the events that
+// follow a sequence number are no longer buffered. The consumer must list
+// the objects again.
+var ErrSeqUnavailable = errors.New("Sequence number not available: resync required")
+
+// Replay buffers the recent events of a type with increasing sequence
+// numbers, so that a consumer that was briefly disconnected can resume
+// with WatchFrom instead of listing all the objects again.
+//
+// The events are numbered from 1. When the watch of the source terminates,
+// events may have been lost: a sequence number is skipped and the watches
+// started from earlier sequence numbers are closed.
+type Replay struct {
+	source   Source
+	typename string
+
+	mutex  sync.Mutex
+	buffer []Event
+	// The buffered events are those with first < Seq < next.
+	first   uint64
+	next    uint64
+	changed chan struct{}
+	stopped bool
+}
+
+// NewReplay allocates a Replay that buffers up to size events of typename
+// received from source. It watches the source once Run is called.
+func NewReplay(source Source, typename string, size int) *Replay {
+	if size <= 0 {
+		size = DefaultReplaySize
+	}
+	return &Replay{
+		source:   source,
+		typename: typename,
+		buffer:   make([]Event, size),
+		next:     1,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Last returns the sequence number of the last event received.
+func (r *Replay) Last() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.next - 1
+}
+
+// notify wakes up the watches. Requires the mutex.
+func (r *Replay) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *Replay) append(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	event.Seq = r.next
+	r.buffer[event.Seq%uint64(len(r.buffer))] = event
+	r.next++
+	if r.next-1-r.first > uint64(len(r.buffer)) {
+		r.first = r.next - 1 - uint64(len(r.buffer))
+	}
+	r.notify()
+}
+
+// reset drops the buffered events after a terminated watch.
+func (r *Replay) reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.buffer {
+		r.buffer[i] = Event{}
+	}
+	r.first = r.next
+	r.next++
+	r.notify()
+}
+
+// Run receives the events from the source until ctx is done, watching
+// again when the watch terminates. The watches of the Replay are closed
+// when it returns.
+func (r *Replay) Run(ctx context.Context) error {
+	defer func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.stopped = true
+		r.notify()
+	}()
+	retry := time.Second
+	for {
+		w, err := r.source.Watch(r.typename)
+		if err == nil {
+			retry = time.Second
+			if err := r.forward(ctx, w); err != nil {
+				return err
+			}
+			r.reset()
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+		if retry < time.Minute {
+			retry *= 2
+		}
+	}
+}
+
+// forward buffers the events of w until it terminates (returns nil) or
+// ctx is done.
+func (r *Replay) forward(ctx context.Context, w Interface) error {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			r.append(event)
+		}
+	}
+}
+
+// WatchFrom streams the events that follow seq, starting with the buffered
+// ones. seq is normally the sequence number of the last event processed by
+// the consumer, or 0 for all the events since the Replay started. The
+// watch is closed when the consumer falls behind by more than the buffer
+// size or when events are lost; WatchFrom then returns ErrSeqUnavailable.
+func (r *Replay) WatchFrom(seq uint64) (Interface, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stopped {
+		return nil, fmt.Errorf("%s: replay stopped", r.typename)
+	}
+	if seq < r.first || seq >= r.next {
+		return nil, ErrSeqUnavailable
+	}
+	w := &replayWatch{
+		replay: r,
+		seq:    seq,
+		result: make(chan Event),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Watch implements Source: it streams the events received after the call.
+func (r *Replay) Watch(typename string) (Interface, error) {
+	if typename != r.typename {
+		return nil, fmt.Errorf("%s: replay of %s", typename, r.typename)
+	}
+	return r.WatchFrom(r.Last())
+}
+
+type replayWatch struct {
+	replay *Replay
+	// Sequence number of the last event delivered.
+	seq      uint64
+	result   chan Event
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (w *replayWatch) ResultChan() <-chan Event {
+	return w.result
+}
+
+func (w *replayWatch) Stop() {
+	w.stopOnce.Do(func() { close(w.done) })
+}
+
+// next returns the event that follows w.seq, or the channel to wait on
+// when there is none yet. ok is false when the watch must terminate.
+func (w *replayWatch) next() (event Event, changed <-chan struct{}, ok bool) {
+	r := w.replay
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stopped || w.seq < r.first {
+		return Event{}, nil, false
+	}
+	if w.seq+1 < r.next {
+		return r.buffer[(w.seq+1)%uint64(len(r.buffer))], nil, true
+	}
+	return Event{}, r.changed, true
+}
+
+func (w *replayWatch) run() {
+	defer close(w.result)
+	for {
+		event, changed, ok := w.next()
+		if !ok {
+			return
+		}
+		if changed != nil {
+			select {
+			case <-w.done:
+				return
+			case <-changed:
+			}
+			continue
+		}
+		select {
+		case <-w.done:
+			return
+		case w.result <- event:
+			w.seq = event.Seq
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func expectReplayed(t *testing.T, w Interface, seq uint64, name string) {
+	select {
+	case event, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatalf("watch closed, expected %d %s", seq, name)
+		}
+		if event.Seq != seq || event.Object.GetName() != name {
+			t.Errorf("expected %d %s, got %d %s", seq, name, event.Seq,
+				event.Object.GetName())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for %d %s", seq, name)
+	}
+}
+
+func expectClosed(t *testing.T, w Interface) {
+	select {
+	case event, ok := <-w.ResultChan():
+		if ok {
+			t.Errorf("unexpected event %d %s", event.Seq, event.Object.GetName())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for the watch to close")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	source := &syncSource{watches: make(chan chanWatch, 10)}
+	replay := NewReplay(source, "test-network", 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error)
+	go func() { stopped <- replay.Run(ctx) }()
+	upstream := <-source.watches
+
+	if _, err := replay.Watch("other"); err == nil {
+		t.Error("watch of another type accepted")
+	}
+	live, err := replay.Watch("test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		upstream <- Event{Type: Added, Object: newTestObject(name, "")}
+		expectReplayed(t, live, uint64(i+1), name)
+	}
+	if last := replay.Last(); last != 4 {
+		t.Errorf("expected last 4, got %d", last)
+	}
+
+	// Catch up after a disconnection.
+	w, err := replay.WatchFrom(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectReplayed(t, w, 3, "c")
+	expectReplayed(t, w, 4, "d")
+	w.Stop()
+	expectClosed(t, w)
+
+	// Event 1 is no longer buffered.
+	if _, err := replay.WatchFrom(0); err != ErrSeqUnavailable {
+		t.Errorf("expected ErrSeqUnavailable, got %v", err)
+	}
+	if _, err := replay.WatchFrom(5); err != ErrSeqUnavailable {
+		t.Errorf("expected ErrSeqUnavailable, got %v", err)
+	}
+
+	// Events may be lost when the upstream watch terminates.
+	close(upstream)
+	expectClosed(t, live)
+	upstream = <-source.watches
+	if _, err := replay.WatchFrom(4); err != ErrSeqUnavailable {
+		t.Errorf("expected ErrSeqUnavailable, got %v", err)
+	}
+	w, err = replay.WatchFrom(replay.Last())
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream <- Event{Type: Deleted, Object: newTestObject("a", "")}
+	expectReplayed(t, w, 6, "a")
+
+	cancel()
+	if err := <-stopped; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	expectClosed(t, w)
+	if _, err := replay.WatchFrom(replay.Last()); err == nil {
+		t.Error("watch of a stopped replay accepted")
+	}
+}
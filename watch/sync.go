@@ -0,0 +1,224 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// SyncType selects the objects of a type that Sync keeps in the cache.
+type SyncType struct {
+	Type string
+	// ListOptions narrow the lists on the server side (e.g.
+	// contrail.ListParent). They require a client that implements
+	// ListDetailWithOptions, such as *contrail.Client. Watch events are
+	// not filtered by the server: Filter must select the same objects.
+	ListOptions []contrail.ListOption
+	// Filter selects the objects that are kept, among those listed and
+	// those received from the watch. nil keeps all of them.
+	Filter func(contrail.IObject) bool
+}
+
+// SyncCache receives the objects of the types kept in sync. The calls for
+// a given type are sequential; those for different types are concurrent.
+type SyncCache interface {
+	// Replace sets the complete list of objects of typename, on the
+	// initial list and after a gap.
+	Replace(typename string, objects []contrail.IObject)
+	// Apply applies a change received from the watch.
+	Apply(event Event)
+}
+
+// optionsLister is implemented by *contrail.Client.
+type optionsLister interface {
+	ListDetailWithOptions(typename string, opts ...contrail.ListOption) (
+		[]contrail.IObject, error)
+}
+
+// typeSync keeps the objects of one type in sync.
+type typeSync struct {
+	client contrail.ApiClient
+	source Source
+	cache  SyncCache
+	spec   SyncType
+	// known maps the uuids of the cached objects to their
+	// id_perms.last_modified.
+	known map[string]string
+}
+
+func (s *typeSync) list() ([]contrail.IObject, error) {
+	var objects []contrail.IObject
+	var err error
+	if len(s.spec.ListOptions) > 0 {
+		lister, ok := s.client.(optionsLister)
+		if !ok {
+			return nil, fmt.Errorf("%s: list options are not supported by %T",
+				s.spec.Type, s.client)
+		}
+		objects, err = lister.ListDetailWithOptions(s.spec.Type,
+			s.spec.ListOptions...)
+	} else {
+		objects, err = s.client.ListDetail(s.spec.Type, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var selected []contrail.IObject
+	for _, obj := range objects {
+		if s.spec.Filter == nil || s.spec.Filter(obj) {
+			selected = append(selected, obj)
+		}
+	}
+	return selected, nil
+}
+
+// bootstrap starts a watch, then replaces the cached objects by a list.
+// The watch is started first so that no change made after the list is
+// missed; the events that predate the list are discarded by apply.
+func (s *typeSync) bootstrap() (Interface, error) {
+	w, err := s.source.Watch(s.spec.Type)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := s.list()
+	if err != nil {
+		w.Stop()
+		return nil, err
+	}
+	s.known = make(map[string]string, len(objects))
+	for _, obj := range objects {
+		s.known[obj.GetUuid()] = lastModified(obj)
+	}
+	s.cache.Replace(s.spec.Type, objects)
+	return w, nil
+}
+
+// apply passes an event to the cache. It returns false when the event
+// reveals a gap: a modification of an object that was never seen.
+func (s *typeSync) apply(event Event) bool {
+	obj := event.Object
+	uuid := obj.GetUuid()
+	previous, known := s.known[uuid]
+	if event.Type == Deleted {
+		if known {
+			delete(s.known, uuid)
+			s.cache.Apply(event)
+		}
+		return true
+	}
+	if s.spec.Filter != nil && !s.spec.Filter(obj) {
+		// The object no longer matches.
+		if known {
+			delete(s.known, uuid)
+			s.cache.Apply(Event{Type: Deleted, Object: obj})
+		}
+		return true
+	}
+	modified := lastModified(obj)
+	if known && modified != "" && previous != "" && modified <= previous {
+		// Already listed, or delivered out of order.
+		return true
+	}
+	if !known && event.Type == Modified && s.spec.Filter == nil {
+		return false
+	}
+	s.known[uuid] = modified
+	if known {
+		s.cache.Apply(Event{Type: Modified, Object: obj})
+	} else {
+		s.cache.Apply(Event{Type: Added, Object: obj})
+	}
+	return true
+}
+
+// run applies the events of w until ctx is done or the client is closed,
+// bootstrapping again when the watch terminates or a gap is detected.
+func (s *typeSync) run(ctx context.Context, w Interface) {
+	closed := clientDone(s.client)
+	retry := time.Second
+	for {
+		for w == nil {
+			var err error
+			if w, err = s.bootstrap(); err == nil {
+				retry = time.Second
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-closed:
+				return
+			case <-time.After(retry):
+			}
+			if retry < time.Minute {
+				retry *= 2
+			}
+		}
+		select {
+		case <-ctx.Done():
+			w.Stop()
+			return
+		case <-closed:
+			w.Stop()
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				w = nil
+			} else if !s.apply(event) {
+				w.Stop()
+				w = nil
+			}
+		}
+	}
+}
+
+// Sync bootstraps a cache for a controller: for each type it starts a
+// watch, lists the objects and hands them to cache.Replace, then hands
+// the watch events to cache.Apply. It returns once all the types have
+// been listed, or with the first error; the cache is then kept up to date
+// in the background until ctx is done or the client is closed. A nil
+// source watches by polling (see Poller).
+//
+// Consistency model: for each type, the cache converges to the state of
+// the API server. The watch is started before the list, so no change
+// is lost between the two; events that predate the list are recognized by
+// their id_perms.last_modified and discarded. Events are applied in the
+// order of the watch within a type, with no ordering across types.
+// Modifications of objects that match Filter but were never listed or
+// added, and the termination of the watch, are treated as gaps: the type
+// is listed again and passed to Replace, which must then reconcile the
+// complete set (objects may have been deleted in the meantime).
+func Sync(ctx context.Context, client contrail.ApiClient, source Source,
+	cache SyncCache, types ...SyncType) error {
+	if source == nil {
+		source = NewPoller(client)
+	}
+	syncs := make([]*typeSync, len(types))
+	watches := make([]Interface, len(types))
+	for i, spec := range types {
+		syncs[i] = &typeSync{
+			client: client,
+			source: source,
+			cache:  cache,
+			spec:   spec,
+		}
+		w, err := syncs[i].bootstrap()
+		if err != nil {
+			for _, w := range watches[:i] {
+				w.Stop()
+			}
+			return fmt.Errorf("%s: %v", spec.Type, err)
+		}
+		watches[i] = w
+	}
+	for i := range syncs {
+		go syncs[i].run(ctx, watches[i])
+	}
+	return nil
+}
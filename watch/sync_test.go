@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+package watch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Juniper/contrail-go-api"
+)
+
+// syncSource starts a new watch on each call.
+type syncSource struct {
+	watches chan chanWatch
+}
+
+func (s *syncSource) Watch(typename string) (Interface, error) {
+	w := make(chanWatch, 10)
+	s.watches <- w
+	return w, nil
+}
+
+// syncRecorder collects the calls of Sync as strings.
+type syncRecorder struct {
+	events chan string
+}
+
+func (r *syncRecorder) Replace(typename string, objects []contrail.IObject) {
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.GetName())
+	}
+	r.events <- "REPLACE " + strings.Join(names, ",")
+}
+
+func (r *syncRecorder) Apply(event Event) {
+	r.events <- string(event.Type) + " " + event.Object.GetName()
+}
+
+func (r *syncRecorder) expect(t *testing.T, expected ...string) {
+	for _, event := range expected {
+		select {
+		case actual := <-r.events:
+			if actual != event {
+				t.Errorf("expected %q, got %q", event, actual)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for %q", event)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	client := &listClient{}
+	a := newPolledObject("a", "t1")
+	b := newPolledObject("b", "t1")
+	client.set(a, b)
+	source := &syncSource{watches: make(chan chanWatch, 10)}
+	cache := &syncRecorder{events: make(chan string, 100)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := Sync(ctx, client, source, cache,
+		SyncType{Type: "test-network"}); err != nil {
+		t.Fatal(err)
+	}
+	cache.expect(t, "REPLACE a,b")
+	w := <-source.watches
+
+	b2 := newPolledObject("b", "t2")
+	c := newPolledObject("c", "t1")
+	w <- Event{Type: Added, Object: a}
+	w <- Event{Type: Modified, Object: b2}
+	w <- Event{Type: Modified, Object: b}
+	w <- Event{Type: Added, Object: c}
+	w <- Event{Type: Deleted, Object: a}
+	w <- Event{Type: Deleted, Object: a}
+	cache.expect(t, "MODIFIED b", "ADDED c", "DELETED a")
+
+	// A modification of an unknown object is a gap.
+	d := newPolledObject("d", "t1")
+	client.set(b2, c, d)
+	w <- Event{Type: Modified, Object: d}
+	cache.expect(t, "REPLACE b,c,d")
+	w = <-source.watches
+
+	// So is the termination of the watch.
+	client.set(c, d)
+	close(w)
+	cache.expect(t, "REPLACE c,d")
+}
+
+func TestSyncFilter(t *testing.T) {
+	client := &listClient{}
+	client.set(newTestObject("a", "blue"), newTestObject("b", "red"))
+	source := &syncSource{watches: make(chan chanWatch, 10)}
+	cache := &syncRecorder{events: make(chan string, 100)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blue := func(obj contrail.IObject) bool {
+		return obj.(*testObject).Value == "blue"
+	}
+	if err := Sync(ctx, client, source, cache,
+		SyncType{Type: "test-network", Filter: blue}); err != nil {
+		t.Fatal(err)
+	}
+	cache.expect(t, "REPLACE a")
+	w := <-source.watches
+
+	w <- Event{Type: Modified, Object: newTestObject("b", "blue")}
+	w <- Event{Type: Modified, Object: newTestObject("a", "red")}
+	w <- Event{Type: Modified, Object: newTestObject("c", "red")}
+	cache.expect(t, "ADDED b", "DELETED a")
+
+	err := Sync(ctx, client, source, cache, SyncType{
+		Type:        "test-network",
+		ListOptions: []contrail.ListOption{contrail.ListParent("p-uuid")},
+	})
+	if err == nil {
+		t.Error("list options accepted by a client without ListDetailWithOptions")
+	}
+}
@@ -0,0 +1,72 @@
+//
+// Copyright (c) 2014 Juniper Networks, Inc. All rights reserved.
+//
+
+// Package watch provides change notifications for API server objects and
+// an informer layer (shared cache, event handlers, listers) built on top
+// of them.
+package watch
+
+import (
+	"github.com/Juniper/contrail-go-api"
+)
+
+// EventType describes a change to an object.
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+)
+
+// Event is a change notification. For Deleted events, Object holds the
+// last known state of the object.
+type Event struct {
+	Type   EventType
+	Object contrail.IObject
+	// Seq is the sequence number assigned by a Replay, 0 otherwise.
+	Seq uint64
+}
+
+// Interface is implemented by watches: a stream of events for a given type.
+type Interface interface {
+	// ResultChan returns the channel events are delivered on. It is
+	// closed when the watch terminates (after Stop or on error).
+	ResultChan() <-chan Event
+	// Stop terminates the watch.
+	Stop()
+}
+
+// Source starts watches. Backends include the API server message bus and
+// list polling.
+type Source interface {
+	// Watch streams the changes to objects of the given type that occur
+	// after the call.
+	Watch(typename string) (Interface, error)
+}
+
+// Key returns the key of an object in the informer caches. Keys are
+// strings so that they can be used as work queue items.
+func Key(obj contrail.IObject) string {
+	return obj.GetType() + "/" + obj.GetUuid()
+}
+
+// SplitKey returns the type and uuid encoded in a key.
+func SplitKey(key string) (typename, uuid string) {
+	for i := 0; i < len(key); i++ {
+		if key[i] == '/' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return "", key
+}
+
+// clientDone returns the channel that is closed when client is closed
+// (see contrail.Client.Done), or nil if the client doesn't report it.
+func clientDone(client contrail.ApiClient) <-chan struct{} {
+	if c, ok := client.(interface{ Done() <-chan struct{} }); ok {
+		return c.Done()
+	}
+	return nil
+}